@@ -16,6 +16,7 @@ package authnode
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
@@ -40,7 +41,8 @@ func (m *Server) getTicket(w http.ResponseWriter, r *http.Request) {
 		err       error
 		jobj      proto.AuthGetTicketReq
 		ts        int64
-		key       []byte
+		keyInfo   *keystore.KeyInfo
+		clientKey []byte
 		message   string
 	)
 
@@ -59,12 +61,12 @@ func (m *Server) getTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if key, err = m.getSecretKey(jobj.ClientID); err != nil {
+	if keyInfo, err = m.getSecretKeyInfo(jobj.ClientID); err != nil {
 		sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
 
-	if ts, err = proto.ParseVerifier(jobj.Verifier, key); err != nil {
+	if ts, clientKey, err = verifyClientKey(keyInfo, jobj.Verifier); err != nil {
 		sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
@@ -74,7 +76,7 @@ func (m *Server) getTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if message, err = m.genGetTicketAuthResp(&jobj, ts, r); err != nil {
+	if message, err = m.genGetTicketAuthResp(&jobj, ts, clientKey, r); err != nil {
 		sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
@@ -111,7 +113,7 @@ func (m *Server) raftNodeOp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ticket, ts, err = proto.ExtractAPIAccessTicket(&apiReq, m.cluster.AuthSecretKey); err != nil {
+	if ticket, ts, err = proto.ExtractAPIAccessTicket(&apiReq, m.cluster.AuthSecretKeys...); err != nil {
 		if err == proto.ErrExpiredTicket {
 			sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeExpiredTicket, Msg: "ExtractAPIAccessTicket failed: " + err.Error()})
 		} else {
@@ -238,6 +240,12 @@ func (m *Server) apiAccessEntry(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	case proto.MsgAuthGetCapsReq:
+	case proto.MsgAuthRotateKeyReq:
+		if keyInfo.ID == proto.AuthServiceID {
+			sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeParamError, Msg: "AuthServiceID is reserved"})
+			return
+		}
+	case proto.MsgAuthRotateRootKeyReq:
 	default:
 		sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeParamError, Msg: fmt.Errorf("invalid request messge type %x", int32(apiReq.Type)).Error()})
 		return
@@ -248,7 +256,7 @@ func (m *Server) apiAccessEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ticket, ts, err = proto.ExtractAPIAccessTicket(&apiReq, m.cluster.AuthSecretKey); err != nil {
+	if ticket, ts, err = proto.ExtractAPIAccessTicket(&apiReq, m.cluster.AuthSecretKeys...); err != nil {
 		if err == proto.ErrExpiredTicket {
 			sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeExpiredTicket, Msg: "ExtractAPIAccessTicket failed: " + err.Error()})
 		} else {
@@ -275,6 +283,10 @@ func (m *Server) apiAccessEntry(w http.ResponseWriter, r *http.Request) {
 		newKeyInfo, err = m.handleDeleteCaps(&keyInfo)
 	case proto.MsgAuthGetCapsReq:
 		newKeyInfo, err = m.handleGetCaps(&keyInfo)
+	case proto.MsgAuthRotateKeyReq:
+		newKeyInfo, err = m.handleRotateKey(&keyInfo, jobj.KeyGrace)
+	case proto.MsgAuthRotateRootKeyReq:
+		newKeyInfo, err = m.handleRotateRootKey(&keyInfo)
 	}
 
 	if err != nil {
@@ -320,6 +332,23 @@ func (m *Server) handleDeleteCaps(keyInfo *keystore.KeyInfo) (res *keystore.KeyI
 	return m.cluster.DeleteCaps(keyInfo.ID, keyInfo)
 }
 
+func (m *Server) handleRotateKey(keyInfo *keystore.KeyInfo, grace int64) (res *keystore.KeyInfo, err error) {
+	return m.cluster.RotateKey(keyInfo.ID, grace)
+}
+
+func (m *Server) handleRotateRootKey(keyInfo *keystore.KeyInfo) (res *keystore.KeyInfo, err error) {
+	var rootKeyInfo *RootKeyInfo
+	if rootKeyInfo, err = m.cluster.RotateRootKey(); err != nil {
+		return
+	}
+	res = &keystore.KeyInfo{
+		ID:         keyInfo.ID,
+		Ts:         rootKeyInfo.Ts,
+		KeyVersion: rootKeyInfo.Version,
+	}
+	return
+}
+
 func (m *Server) handleGetCaps(keyInfo *keystore.KeyInfo) (res *keystore.KeyInfo, err error) {
 	var info *keystore.KeyInfo
 	if info, err = m.getSecretKeyInfo(keyInfo.ID); err != nil {
@@ -402,7 +431,7 @@ func (m *Server) osCapsOp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ticket, ts, err = proto.ExtractAPIAccessTicket(&apiReq, m.cluster.AuthSecretKey); err != nil {
+	if ticket, ts, err = proto.ExtractAPIAccessTicket(&apiReq, m.cluster.AuthSecretKeys...); err != nil {
 		if err == proto.ErrExpiredTicket {
 			sendErrReply(w, r, &proto.HTTPAuthReply{Code: proto.ErrCodeExpiredTicket, Msg: "ExtractAPIAccessTicket failed: " + err.Error()})
 		} else {
@@ -451,22 +480,27 @@ func (m *Server) genTicket(key []byte, serviceID string, IP string, caps []byte)
 	return
 }
 
-func (m *Server) getSecretKey(id string) (key []byte, err error) {
-	var (
-		keyInfo *keystore.KeyInfo
-	)
-	if keyInfo, err = m.getSecretKeyInfo(id); err != nil {
-		return
+// verifyClientKey returns the key of the client which the verifier is sealed with,
+// the previous key of the client is still accepted within the grace period of a key rotation.
+func verifyClientKey(keyInfo *keystore.KeyInfo, verifier string) (ts int64, clientKey []byte, err error) {
+	for _, key := range keyInfo.AcceptedKeys() {
+		if ts, err = proto.ParseVerifier(verifier, key); err == nil {
+			return ts, key, nil
+		}
 	}
-	return keyInfo.AuthKey, err
+	return
 }
 
 func (m *Server) getSecretKeyInfo(id string) (keyInfo *keystore.KeyInfo, err error) {
 	if id == proto.AuthServiceID {
 		keyInfo = &keystore.KeyInfo{
-			AuthKey: m.cluster.AuthSecretKey,
+			AuthKey: m.cluster.AuthSecretKeys[0],
 			Caps:    []byte(`{"API": ["*:*:*"]}`),
 		}
+		// the previous auth service keys are accepted until they are removed from the config
+		for i, key := range m.cluster.AuthSecretKeys[1:] {
+			keyInfo.OldKeys = append(keyInfo.OldKeys, &keystore.VersionedKey{Version: uint32(i), AuthKey: key, ExpireTs: math.MaxInt64})
+		}
 	} else {
 		if keyInfo, err = m.cluster.GetKey(id); err != nil {
 			return
@@ -475,13 +509,12 @@ func (m *Server) getSecretKeyInfo(id string) (keyInfo *keystore.KeyInfo, err err
 	return
 }
 
-func (m *Server) genGetTicketAuthResp(req *proto.AuthGetTicketReq, ts int64, r *http.Request) (message string, err error) {
+func (m *Server) genGetTicketAuthResp(req *proto.AuthGetTicketReq, ts int64, clientKey []byte, r *http.Request) (message string, err error) {
 	var (
 		jticket    []byte
 		jresp      []byte
 		resp       proto.AuthGetTicketResp
 		serviceKey []byte
-		clientKey  []byte
 		caps       []byte
 		keyInfo    *keystore.KeyInfo
 	)
@@ -498,9 +531,10 @@ func (m *Server) genGetTicketAuthResp(req *proto.AuthGetTicketReq, ts int64, r *
 	caps = keyInfo.Caps
 
 	// Use service key to encrypt ticket
-	if serviceKey, err = m.getSecretKey(req.ServiceID); err != nil {
+	if keyInfo, err = m.getSecretKeyInfo(req.ServiceID); err != nil {
 		return
 	}
+	serviceKey = keyInfo.ActiveKey()
 
 	ticket := m.genTicket(serviceKey, resp.ServiceID, iputil.RealIP(r), caps)
 	resp.SessionKey = ticket.SessionKey
@@ -518,10 +552,6 @@ func (m *Server) genGetTicketAuthResp(req *proto.AuthGetTicketReq, ts int64, r *
 	}
 
 	// Use client secret key to encrypt response message
-	if keyInfo, err = m.getSecretKeyInfo(resp.ClientID); err != nil {
-		return
-	}
-	clientKey = keyInfo.AuthKey
 	if message, err = cryptoutil.EncodeMessage(jresp, clientKey); err != nil {
 		return
 	}
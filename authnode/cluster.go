@@ -45,8 +45,7 @@ type Cluster struct {
 	DisableAutoAllocate bool
 	fsm                 *KeystoreFsm
 	partition           raftstore.Partition
-	AuthSecretKey       []byte
-	AuthRootKey         []byte
+	AuthSecretKeys      [][]byte // the current key first, followed by the previous ones being rotated
 	PKIKey              PKIKey
 }
 
@@ -88,6 +87,7 @@ func (c *Cluster) checkLeaderAddr() {
 
 // CreateNewKey create a new key to the keystore
 func (c *Cluster) CreateNewKey(id string, keyInfo *keystore.KeyInfo) (res *keystore.KeyInfo, err error) {
+	var rootKey []byte
 	c.fsm.opKeyMutex.Lock()
 	defer c.fsm.opKeyMutex.Unlock()
 	accessKeyInfo := &keystore.AccessKeyInfo{
@@ -98,7 +98,10 @@ func (c *Cluster) CreateNewKey(id string, keyInfo *keystore.KeyInfo) (res *keyst
 		goto errHandler
 	}
 	keyInfo.Ts = time.Now().Unix()
-	keyInfo.AuthKey = cryptoutil.GenSecretKey([]byte(c.AuthRootKey), keyInfo.Ts, id)
+	accessKeyInfo.Status = keystore.AccessKeyStatusActive
+	accessKeyInfo.CreateTime = time.Unix(keyInfo.Ts, 0).Format(proto.TimeFormat)
+	_, rootKey = c.fsm.currentRootKey()
+	keyInfo.AuthKey = cryptoutil.GenSecretKey(rootKey, keyInfo.Ts, id)
 	//TODO check duplicate
 	keyInfo.AccessKey = util.RandomString(16, util.Numeric|util.LowerLetter|util.UpperLetter)
 	keyInfo.SecretKey = util.RandomString(32, util.Numeric|util.LowerLetter|util.UpperLetter)
@@ -145,6 +148,43 @@ errHandler:
 	return
 }
 
+// RotateKey replaces the key of id with a new version. The previous key is still accepted
+// within the grace period in seconds, so that the holders of the key can be updated in time.
+func (c *Cluster) RotateKey(id string, grace int64) (res *keystore.KeyInfo, err error) {
+	var (
+		cur     *keystore.KeyInfo
+		rootKey []byte
+		newKey  []byte
+		ts      int64
+	)
+	c.fsm.opKeyMutex.Lock()
+	defer c.fsm.opKeyMutex.Unlock()
+	if cur, err = c.fsm.GetKey(id); err != nil {
+		err = proto.ErrKeyNotExists
+		goto errHandler
+	}
+	if grace <= 0 {
+		grace = keystore.DefaultKeyGrace
+	}
+	res = new(keystore.KeyInfo)
+	*res = *cur
+	ts = time.Now().Unix()
+	_, rootKey = c.fsm.currentRootKey()
+	newKey = cryptoutil.GenSecretKey(rootKey, ts, fmt.Sprintf("%s#%d", id, res.KeyVersion+1))
+	res.Rotate(newKey, ts, grace)
+	if err = c.syncRotateKey(res); err != nil {
+		res = nil
+		goto errHandler
+	}
+	c.fsm.PutKey(res)
+	log.LogWarnf("action[RotateKey], clusterID[%v] ID[%v] key rotated to version[%v]", c.Name, id, res.KeyVersion)
+	return
+errHandler:
+	err = fmt.Errorf("action[RotateKey], clusterID[%v] ID:%v, err:%v ", c.Name, id, err.Error())
+	log.LogError(errors.Stack(err))
+	return
+}
+
 // GetKey get a key from the keystore
 func (c *Cluster) GetKey(id string) (res *keystore.KeyInfo, err error) {
 	if res, err = c.fsm.GetKey(id); err != nil {
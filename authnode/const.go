@@ -26,6 +26,8 @@ const (
 	opSyncAddCaps    uint32 = 0x04
 	opSyncDeleteCaps uint32 = 0x05
 	opSyncGetCaps    uint32 = 0x06
+	opSyncRotateKey  uint32 = 0x07
+	opSyncPutRootKey uint32 = 0x08
 )
 
 const (
@@ -36,4 +38,7 @@ const (
 
 	akAcronym = "ak"
 	akPrefix  = keySeparator + akAcronym + keySeparator
+
+	rootKeyAcronym = "rootkey"
+	rootKeyPrefix  = keySeparator + rootKeyAcronym + keySeparator
 )
@@ -87,6 +87,10 @@ func (m *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case proto.AdminDeleteCaps:
 		fallthrough
 	case proto.AdminGetCaps:
+		fallthrough
+	case proto.AdminRotateKey:
+		fallthrough
+	case proto.AdminRotateRootKey:
 		m.apiAccessEntry(w, r)
 	case proto.AdminAddRaftNode:
 		fallthrough
@@ -111,6 +115,8 @@ func (m *Server) handleFunctions() {
 	http.Handle(proto.AdminAddCaps, m.handlerWithInterceptor())
 	http.Handle(proto.AdminDeleteCaps, m.handlerWithInterceptor())
 	http.Handle(proto.AdminGetCaps, m.handlerWithInterceptor())
+	http.Handle(proto.AdminRotateKey, m.handlerWithInterceptor())
+	http.Handle(proto.AdminRotateRootKey, m.handlerWithInterceptor())
 	http.Handle(proto.AdminAddRaftNode, m.handlerWithInterceptor())
 	http.Handle(proto.AdminRemoveRaftNode, m.handlerWithInterceptor())
 	http.Handle(proto.OSAddCaps, m.handlerWithInterceptor())
@@ -9,9 +9,18 @@ import (
 func (mf *KeystoreFsm) PutKey(k *keystore.KeyInfo) {
 	mf.ksMutex.Lock()
 	defer mf.ksMutex.Unlock()
-	if _, ok := (mf.keystore)[k.ID]; !ok {
-		(mf.keystore)[k.ID] = k
+	(mf.keystore)[k.ID] = k
+}
+
+// ListKeys returns all the keyInfo in keystore cache
+func (mf *KeystoreFsm) ListKeys() (keys []*keystore.KeyInfo) {
+	mf.ksMutex.RLock()
+	defer mf.ksMutex.RUnlock()
+	keys = make([]*keystore.KeyInfo, 0, len(mf.keystore))
+	for _, k := range mf.keystore {
+		keys = append(keys, k)
 	}
+	return
 }
 
 // GetKey Get keyInfo from keystore cache
@@ -57,6 +57,11 @@ type KeystoreFsm struct {
 	aksMutex       sync.RWMutex //accesskeystore mutex
 	opKeyMutex     sync.RWMutex // operations on key mutex
 	id             uint64       // current id of server
+
+	keyWrapper     keystore.KeyWrapper
+	rootKeys       map[uint32][]byte // version -> root key
+	rootKeyVersion uint32            // version of the root key to seal the keys
+	rkMutex        sync.RWMutex      // root keys mutex
 }
 
 func newKeystoreFsm(store *raftstore.RocksDBStore, retainsLog uint64, rs *raft.RaftServer) (fsm *KeystoreFsm) {
@@ -84,6 +89,7 @@ func (mf *KeystoreFsm) registerApplySnapshotHandler(handler raftApplySnapshotHan
 
 func (mf *KeystoreFsm) restore() {
 	mf.restoreApplied()
+	mf.restoreRootKeys()
 }
 
 func (mf *KeystoreFsm) restoreApplied() {
@@ -117,10 +123,6 @@ func (mf *KeystoreFsm) Apply(command []byte, index uint64) (resp interface{}, er
 	}
 	log.LogInfof("action[fsmApply],cmd.op[%v],cmd.K[%v],cmd.V[%v]", cmd.Op, cmd.K, string(cmd.V))
 
-	if err = json.Unmarshal(cmd.V, &keyInfo); err != nil {
-		panic(err)
-	}
-
 	s := strings.Split(cmd.K, idSeparator)
 	if len(s) != 2 {
 		panic(fmt.Errorf("cmd.K format error %s", cmd.K))
@@ -135,7 +137,18 @@ func (mf *KeystoreFsm) Apply(command []byte, index uint64) (resp interface{}, er
 	cmdMap[applied] = []byte(strconv.FormatUint(uint64(index), 10))
 
 	switch cmd.Op {
+	case opSyncPutRootKey:
+		if err = mf.batchPut(cmdMap); err != nil {
+			panic(err)
+		}
+		// the root key is needed by all the nodes to unseal the keys, and putting it is idempotent
+		if err = mf.putRootKey(cmd.V); err != nil {
+			panic(err)
+		}
 	case opSyncDeleteKey:
+		if err = json.Unmarshal(cmd.V, &keyInfo); err != nil {
+			panic(err)
+		}
 		if err = mf.delKeyAndPutIndex(cmd.K, cmdMap); err != nil {
 			panic(err)
 		}
@@ -153,13 +166,21 @@ func (mf *KeystoreFsm) Apply(command []byte, index uint64) (resp interface{}, er
 			log.LogInfof("action[Apply], Already delete key in node[%d]", mf.id)
 		}
 	default:
+		if err = json.Unmarshal(cmd.V, &keyInfo); err != nil {
+			panic(err)
+		}
 		if err = mf.batchPut(cmdMap); err != nil {
 			panic(err)
 		}
 		//if mf.leader != mf.id {
 		// Same reasons as the description above
 		if mf.id != leader {
-			mf.PutKey(&keyInfo)
+			if strings.HasPrefix(s[0], ksPrefix) {
+				if err = mf.unsealKeyInfo(&keyInfo); err != nil {
+					panic(err)
+				}
+				mf.PutKey(&keyInfo)
+			}
 			accessKeyInfo := &keystore.AccessKeyInfo{
 				AccessKey: keyInfo.AccessKey,
 				ID:        keyInfo.ID,
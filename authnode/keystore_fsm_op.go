@@ -54,6 +54,8 @@ func (m *RaftCmd) setOpType() {
 		m.Op = opSyncAddKey
 	case akAcronym:
 		m.Op = opSyncAddKey
+	case rootKeyAcronym:
+		m.Op = opSyncPutRootKey
 	default:
 		log.LogWarnf("action[setOpType] unknown opCode[%v]", keyArr[1])
 	}
@@ -95,17 +97,34 @@ func (c *Cluster) syncDeleteCaps(keyInfo *keystore.KeyInfo) (err error) {
 	return c.syncPutKeyInfo(opSyncDeleteCaps, keyInfo)
 }
 
+func (c *Cluster) syncRotateKey(keyInfo *keystore.KeyInfo) (err error) {
+	return c.syncPutKeyInfo(opSyncRotateKey, keyInfo)
+}
+
 func (c *Cluster) syncPutKeyInfo(opType uint32, keyInfo *keystore.KeyInfo) (err error) {
 	keydata := new(RaftCmd)
 	keydata.Op = opType
 	keydata.K = ksPrefix + keyInfo.ID + idSeparator + strconv.FormatUint(c.fsm.id, 10)
-	vv := *keyInfo
+	vv, err := c.fsm.sealKeyInfo(keyInfo)
+	if err != nil {
+		return
+	}
 	if keydata.V, err = json.Marshal(vv); err != nil {
 		return errors.New(err.Error())
 	}
 	return c.submit(keydata)
 }
 
+func (c *Cluster) syncPutRootKey(rootKeyInfo *RootKeyInfo) (err error) {
+	keydata := new(RaftCmd)
+	keydata.Op = opSyncPutRootKey
+	keydata.K = rootKeyPrefix + strconv.FormatUint(uint64(rootKeyInfo.Version), 10) + idSeparator + strconv.FormatUint(c.fsm.id, 10)
+	if keydata.V, err = json.Marshal(rootKeyInfo); err != nil {
+		return errors.New(err.Error())
+	}
+	return c.submit(keydata)
+}
+
 func (c *Cluster) syncPutAccessKeyInfo(opType uint32, accessKeyInfo *keystore.AccessKeyInfo) (err error) {
 	keydata := new(RaftCmd)
 	keydata.Op = opType
@@ -131,6 +150,10 @@ func (c *Cluster) loadKeystore() (err error) {
 			err = fmt.Errorf("action[loadKeystore],value:%v,unmarshal err:%v", string(value), err)
 			return err
 		}
+		if err = c.fsm.unsealKeyInfo(k); err != nil {
+			err = fmt.Errorf("action[loadKeystore],id:%v,unseal err:%v", k.ID, err)
+			return err
+		}
 		if _, ok := ks[k.ID]; !ok {
 			ks[k.ID] = k
 		}
@@ -0,0 +1,200 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package authnode
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cubefs/cubefs/util/cryptoutil"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/keystore"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the root key configured by authRootKey
+	configRootKeyVersion uint32 = 1
+	rootKeySize                 = 32
+)
+
+// RootKeyInfo defines a version of the root key, wrapped by the key wrapper of the authnode.
+// The keys in the keystore are sealed with the latest root key.
+type RootKeyInfo struct {
+	Version    uint32 `json:"version"`
+	WrappedKey []byte `json:"wrapped_key"`
+	Ts         int64  `json:"create_ts"`
+}
+
+func (mf *KeystoreFsm) initRootKeys(wrapper keystore.KeyWrapper, rootKey []byte) {
+	mf.rkMutex.Lock()
+	defer mf.rkMutex.Unlock()
+	mf.keyWrapper = wrapper
+	mf.rootKeys = map[uint32][]byte{configRootKeyVersion: rootKey}
+	mf.rootKeyVersion = configRootKeyVersion
+}
+
+func (mf *KeystoreFsm) restoreRootKeys() {
+	result, err := mf.store.SeekForPrefix([]byte(rootKeyPrefix))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to restore root keys err:%v", err.Error()))
+	}
+	for _, value := range result {
+		if err = mf.putRootKey(value); err != nil {
+			panic(fmt.Sprintf("Failed to restore root keys err:%v", err.Error()))
+		}
+	}
+}
+
+func (mf *KeystoreFsm) putRootKey(data []byte) (err error) {
+	var (
+		rootKeyInfo RootKeyInfo
+		rootKey     []byte
+	)
+	if err = json.Unmarshal(data, &rootKeyInfo); err != nil {
+		return
+	}
+	if rootKey, err = mf.keyWrapper.Unwrap(rootKeyInfo.WrappedKey); err != nil {
+		return
+	}
+	mf.rkMutex.Lock()
+	defer mf.rkMutex.Unlock()
+	mf.rootKeys[rootKeyInfo.Version] = rootKey
+	if rootKeyInfo.Version > mf.rootKeyVersion {
+		mf.rootKeyVersion = rootKeyInfo.Version
+	}
+	log.LogInfof("action[putRootKey] root key version[%v] current version[%v]", rootKeyInfo.Version, mf.rootKeyVersion)
+	return
+}
+
+func (mf *KeystoreFsm) currentRootKey() (version uint32, rootKey []byte) {
+	mf.rkMutex.RLock()
+	defer mf.rkMutex.RUnlock()
+	return mf.rootKeyVersion, mf.rootKeys[mf.rootKeyVersion]
+}
+
+func (mf *KeystoreFsm) getRootKey(version uint32) (rootKey []byte, err error) {
+	mf.rkMutex.RLock()
+	defer mf.rkMutex.RUnlock()
+	var ok bool
+	if rootKey, ok = mf.rootKeys[version]; !ok {
+		err = fmt.Errorf("root key version [%v] not found", version)
+	}
+	return
+}
+
+// sealKeyInfo returns a copy of the keyInfo with the keys encrypted by the current root key,
+// which is persisted in the keystore.
+func (mf *KeystoreFsm) sealKeyInfo(keyInfo *keystore.KeyInfo) (sealed *keystore.KeyInfo, err error) {
+	version, rootKey := mf.currentRootKey()
+	sealed = new(keystore.KeyInfo)
+	*sealed = *keyInfo
+	sealed.RootKeyVersion = version
+	if sealed.AuthKey, err = sealKey(keyInfo.AuthKey, rootKey); err != nil {
+		return
+	}
+	if keyInfo.SecretKey != "" {
+		if sealed.SecretKey, err = cryptoutil.EncodeMessage([]byte(keyInfo.SecretKey), rootKey); err != nil {
+			return
+		}
+	}
+	sealed.OldKeys = make([]*keystore.VersionedKey, 0, len(keyInfo.OldKeys))
+	for _, k := range keyInfo.OldKeys {
+		old := *k
+		if old.AuthKey, err = sealKey(k.AuthKey, rootKey); err != nil {
+			return
+		}
+		sealed.OldKeys = append(sealed.OldKeys, &old)
+	}
+	return
+}
+
+// unsealKeyInfo decrypts the keys of the keyInfo loaded from the keystore in place.
+// The keyInfo persisted before the keys were sealed is left as it is.
+func (mf *KeystoreFsm) unsealKeyInfo(keyInfo *keystore.KeyInfo) (err error) {
+	if keyInfo.RootKeyVersion == 0 {
+		return
+	}
+	var rootKey []byte
+	if rootKey, err = mf.getRootKey(keyInfo.RootKeyVersion); err != nil {
+		return
+	}
+	if keyInfo.AuthKey, err = unsealKey(keyInfo.AuthKey, rootKey); err != nil {
+		return
+	}
+	if keyInfo.SecretKey != "" {
+		var secretKey []byte
+		if secretKey, err = cryptoutil.DecodeMessage(keyInfo.SecretKey, rootKey); err != nil {
+			return
+		}
+		keyInfo.SecretKey = string(secretKey)
+	}
+	for _, k := range keyInfo.OldKeys {
+		if k.AuthKey, err = unsealKey(k.AuthKey, rootKey); err != nil {
+			return
+		}
+	}
+	keyInfo.RootKeyVersion = 0
+	return
+}
+
+func sealKey(key, rootKey []byte) (sealed []byte, err error) {
+	var message string
+	if message, err = cryptoutil.EncodeMessage(key, rootKey); err != nil {
+		return
+	}
+	return []byte(message), nil
+}
+
+func unsealKey(sealed, rootKey []byte) (key []byte, err error) {
+	return cryptoutil.DecodeMessage(string(sealed), rootKey)
+}
+
+// RotateRootKey generates a new version of the root key and seals all the keys in the keystore with it.
+// The previous versions are kept so that the keys sealed with them can still be loaded.
+func (c *Cluster) RotateRootKey() (res *RootKeyInfo, err error) {
+	var (
+		rootKey     []byte
+		rootKeyInfo *RootKeyInfo
+	)
+	c.fsm.opKeyMutex.Lock()
+	defer c.fsm.opKeyMutex.Unlock()
+	version, _ := c.fsm.currentRootKey()
+	rootKey = make([]byte, rootKeySize)
+	if _, err = rand.Read(rootKey); err != nil {
+		goto errHandler
+	}
+	rootKeyInfo = &RootKeyInfo{Version: version + 1, Ts: time.Now().Unix()}
+	if rootKeyInfo.WrappedKey, err = c.fsm.keyWrapper.Wrap(rootKey); err != nil {
+		goto errHandler
+	}
+	if err = c.syncPutRootKey(rootKeyInfo); err != nil {
+		goto errHandler
+	}
+	for _, keyInfo := range c.fsm.ListKeys() {
+		if err = c.syncAddKey(keyInfo); err != nil {
+			goto errHandler
+		}
+	}
+	res = rootKeyInfo
+	log.LogWarnf("action[RotateRootKey], clusterID[%v] root key rotated to version[%v]", c.Name, rootKeyInfo.Version)
+	return
+errHandler:
+	err = fmt.Errorf("action[RotateRootKey], clusterID[%v] err:%v ", c.Name, err.Error())
+	log.LogError(errors.Stack(err))
+	return
+}
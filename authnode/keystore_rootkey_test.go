@@ -0,0 +1,284 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package authnode
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/raftstore"
+	"github.com/cubefs/cubefs/util/cryptoutil"
+	"github.com/cubefs/cubefs/util/keystore"
+)
+
+const (
+	testLeaderID   uint64 = 1
+	testFollowerID uint64 = 2
+)
+
+var (
+	testKeyEncryptionKey = cryptoutil.GenSecretKey([]byte("authnode-test"), 0, "kek")
+	testConfigRootKey    = cryptoutil.GenSecretKey([]byte("authnode-test"), 0, "root")
+)
+
+// testPartition applies the submitted commands to the fsm of every node in order, like a raft group without failures
+type testPartition struct {
+	raftstore.Partition
+	index uint64
+	fsms  []*KeystoreFsm
+}
+
+func (p *testPartition) Submit(cmd []byte) (resp interface{}, err error) {
+	p.index++
+	for _, fsm := range p.fsms {
+		if resp, err = fsm.Apply(cmd, p.index); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// startTestNode starts an authnode on the store with the configured root key,
+// the root keys and the keys persisted in the store are loaded as a restarted node does.
+func startTestNode(t *testing.T, store *raftstore.RocksDBStore, id uint64, partition *testPartition) *Cluster {
+	fsm := newKeystoreFsm(store, DefaultRetainLogs, nil)
+	fsm.id = id
+	fsm.initRootKeys(keystore.NewLocalKeyWrapper(testKeyEncryptionKey), testConfigRootKey)
+	fsm.restore()
+	c := newCluster("test", &LeaderInfo{}, fsm, partition, newClusterConfig())
+	if err := c.loadKeystore(); err != nil {
+		t.Fatalf("load keystore of node(%v) fail: %v", id, err)
+	}
+	partition.fsms = append(partition.fsms, fsm)
+	return c
+}
+
+func newTestStore(t *testing.T) *raftstore.RocksDBStore {
+	store, err := raftstore.NewRocksDBStore(t.TempDir(), 1<<20, 1<<20)
+	if err != nil {
+		t.Fatalf("new rocksdb store fail: %v", err)
+	}
+	return store
+}
+
+func createTestKey(t *testing.T, c *Cluster, id, role string) *keystore.KeyInfo {
+	keyInfo, err := c.CreateNewKey(id, &keystore.KeyInfo{ID: id, Role: role, Caps: []byte(`{"API": ["*:*:*"]}`)})
+	if err != nil {
+		t.Fatalf("create key(%v) fail: %v", id, err)
+	}
+	return keyInfo
+}
+
+func storedKeyInfo(t *testing.T, c *Cluster, id string) *keystore.KeyInfo {
+	value, err := c.fsm.Get(ksPrefix + id)
+	if err != nil {
+		t.Fatalf("get key(%v) from store fail: %v", id, err)
+	}
+	keyInfo := new(keystore.KeyInfo)
+	if err = json.Unmarshal(value.([]byte), keyInfo); err != nil {
+		t.Fatalf("unmarshal key(%v) fail: %v", id, err)
+	}
+	return keyInfo
+}
+
+func checkSameKey(t *testing.T, expect, actual *keystore.KeyInfo) {
+	t.Helper()
+	if !bytes.Equal(expect.AuthKey, actual.AuthKey) || expect.SecretKey != actual.SecretKey ||
+		expect.AccessKey != actual.AccessKey || expect.KeyVersion != actual.KeyVersion {
+		t.Fatalf("key(%v) mismatch: expect version(%v) real version(%v)", expect.ID, expect.KeyVersion, actual.KeyVersion)
+	}
+	if actual.RootKeyVersion != 0 {
+		t.Fatalf("key(%v) still sealed by root key version(%v)", actual.ID, actual.RootKeyVersion)
+	}
+	if len(expect.OldKeys) != len(actual.OldKeys) {
+		t.Fatalf("key(%v) expect %v old keys, real %v", expect.ID, len(expect.OldKeys), len(actual.OldKeys))
+	}
+	for i, k := range expect.OldKeys {
+		if !bytes.Equal(k.AuthKey, actual.OldKeys[i].AuthKey) || k.ExpireTs != actual.OldKeys[i].ExpireTs {
+			t.Fatalf("old key(%v) of key(%v) mismatch", k.Version, expect.ID)
+		}
+	}
+}
+
+func TestRotateRootKeyReseal(t *testing.T) {
+	partition := new(testPartition)
+	leader := startTestNode(t, newTestStore(t), testLeaderID, partition)
+	follower := startTestNode(t, newTestStore(t), testFollowerID, partition)
+
+	keys := []*keystore.KeyInfo{
+		createTestKey(t, leader, "client1", "client"),
+		createTestKey(t, leader, "service1", "service"),
+	}
+	rotated, err := leader.RotateKey("client1", keystore.DefaultKeyGrace)
+	if err != nil {
+		t.Fatalf("rotate key fail: %v", err)
+	}
+	keys[0] = rotated
+
+	rootKeyInfo, err := leader.RotateRootKey()
+	if err != nil {
+		t.Fatalf("rotate root key fail: %v", err)
+	}
+	if rootKeyInfo.Version != configRootKeyVersion+1 {
+		t.Fatalf("expect root key version(%v), real(%v)", configRootKeyVersion+1, rootKeyInfo.Version)
+	}
+
+	for _, c := range []*Cluster{leader, follower} {
+		if version, _ := c.fsm.currentRootKey(); version != rootKeyInfo.Version {
+			t.Fatalf("node(%v): expect root key version(%v), real(%v)", c.fsm.id, rootKeyInfo.Version, version)
+		}
+		for _, expect := range keys {
+			// all the keys are sealed with the new root key in the store
+			sealed := storedKeyInfo(t, c, expect.ID)
+			if sealed.RootKeyVersion != rootKeyInfo.Version {
+				t.Fatalf("node(%v): key(%v) sealed by root key version(%v)", c.fsm.id, expect.ID, sealed.RootKeyVersion)
+			}
+			if bytes.Equal(sealed.AuthKey, expect.AuthKey) || sealed.SecretKey == expect.SecretKey {
+				t.Fatalf("node(%v): key(%v) is stored in plaintext", c.fsm.id, expect.ID)
+			}
+			for i, k := range sealed.OldKeys {
+				if bytes.Equal(k.AuthKey, expect.OldKeys[i].AuthKey) {
+					t.Fatalf("node(%v): old key of key(%v) is stored in plaintext", c.fsm.id, expect.ID)
+				}
+			}
+			// and the keys in the cache are not changed
+			actual, err := c.GetKey(expect.ID)
+			if err != nil {
+				t.Fatalf("node(%v): get key(%v) fail: %v", c.fsm.id, expect.ID, err)
+			}
+			checkSameKey(t, expect, actual)
+		}
+	}
+}
+
+func TestFollowerApplyUnseal(t *testing.T) {
+	partition := new(testPartition)
+	leader := startTestNode(t, newTestStore(t), testLeaderID, partition)
+	follower := startTestNode(t, newTestStore(t), testFollowerID, partition)
+
+	expect := createTestKey(t, leader, "client1", "client")
+	actual, err := follower.GetKey(expect.ID)
+	if err != nil {
+		t.Fatalf("get created key from follower fail: %v", err)
+	}
+	checkSameKey(t, expect, actual)
+	if akInfo, err := follower.fsm.GetAKInfo(expect.AccessKey); err != nil || akInfo.ID != expect.ID {
+		t.Fatalf("get access key from follower fail: %v", err)
+	}
+
+	if _, err = leader.RotateRootKey(); err != nil {
+		t.Fatalf("rotate root key fail: %v", err)
+	}
+	// the keys sealed with the new root key are unsealed by the follower
+	if expect, err = leader.RotateKey(expect.ID, keystore.DefaultKeyGrace); err != nil {
+		t.Fatalf("rotate key fail: %v", err)
+	}
+	if actual, err = follower.GetKey(expect.ID); err != nil {
+		t.Fatalf("get rotated key from follower fail: %v", err)
+	}
+	checkSameKey(t, expect, actual)
+}
+
+func TestRestartWithOlderRootKey(t *testing.T) {
+	partition := new(testPartition)
+	store := newTestStore(t)
+	leader := startTestNode(t, store, testLeaderID, partition)
+	older := createTestKey(t, leader, "client1", "client")
+
+	// a rotation interrupted before the keys are resealed leaves the keys sealed by the older root key
+	rootKey := cryptoutil.GenSecretKey([]byte("authnode-test"), 0, "root2")
+	rootKeyInfo := &RootKeyInfo{Version: configRootKeyVersion + 1, Ts: time.Now().Unix()}
+	wrappedKey, err := leader.fsm.keyWrapper.Wrap(rootKey)
+	if err != nil {
+		t.Fatalf("wrap root key fail: %v", err)
+	}
+	rootKeyInfo.WrappedKey = wrappedKey
+	if err = leader.syncPutRootKey(rootKeyInfo); err != nil {
+		t.Fatalf("put root key fail: %v", err)
+	}
+	newer := createTestKey(t, leader, "client2", "client")
+	if sealed := storedKeyInfo(t, leader, older.ID); sealed.RootKeyVersion != configRootKeyVersion {
+		t.Fatalf("expect key(%v) sealed by root key version(%v), real(%v)", older.ID, configRootKeyVersion, sealed.RootKeyVersion)
+	}
+	if sealed := storedKeyInfo(t, leader, newer.ID); sealed.RootKeyVersion != rootKeyInfo.Version {
+		t.Fatalf("expect key(%v) sealed by root key version(%v), real(%v)", newer.ID, rootKeyInfo.Version, sealed.RootKeyVersion)
+	}
+
+	// only the first version of the root key is configured, the later ones are restored from the store
+	restarted := startTestNode(t, store, testLeaderID, new(testPartition))
+	if version, _ := restarted.fsm.currentRootKey(); version != rootKeyInfo.Version {
+		t.Fatalf("expect root key version(%v) after restart, real(%v)", rootKeyInfo.Version, version)
+	}
+	for _, expect := range []*keystore.KeyInfo{older, newer} {
+		actual, err := restarted.GetKey(expect.ID)
+		if err != nil {
+			t.Fatalf("get key(%v) after restart fail: %v", expect.ID, err)
+		}
+		checkSameKey(t, expect, actual)
+	}
+}
+
+func TestRotateKeyGraceWindow(t *testing.T) {
+	partition := new(testPartition)
+	leader := startTestNode(t, newTestStore(t), testLeaderID, partition)
+	follower := startTestNode(t, newTestStore(t), testFollowerID, partition)
+
+	oldKey := createTestKey(t, leader, "client1", "client").AuthKey
+	rotated, err := leader.RotateKey("client1", 1)
+	if err != nil {
+		t.Fatalf("rotate key fail: %v", err)
+	}
+	newKey := rotated.AuthKey
+	if bytes.Equal(oldKey, newKey) {
+		t.Fatalf("the key is not changed by the rotation")
+	}
+
+	verify := func(c *Cluster, key []byte) error {
+		keyInfo, err := c.GetKey("client1")
+		if err != nil {
+			t.Fatalf("node(%v): get key fail: %v", c.fsm.id, err)
+		}
+		verifier, _, err := cryptoutil.GenVerifier(key)
+		if err != nil {
+			t.Fatalf("gen verifier fail: %v", err)
+		}
+		_, clientKey, err := verifyClientKey(keyInfo, verifier)
+		if err == nil && !bytes.Equal(clientKey, key) {
+			t.Fatalf("node(%v): the verifier is accepted by another key", c.fsm.id)
+		}
+		return err
+	}
+
+	for _, c := range []*Cluster{leader, follower} {
+		if err = verify(c, newKey); err != nil {
+			t.Fatalf("node(%v): the new key is rejected: %v", c.fsm.id, err)
+		}
+		if err = verify(c, oldKey); err != nil {
+			t.Fatalf("node(%v): the old key is rejected within the grace window: %v", c.fsm.id, err)
+		}
+	}
+
+	time.Sleep(2 * time.Second)
+	for _, c := range []*Cluster{leader, follower} {
+		if err = verify(c, newKey); err != nil {
+			t.Fatalf("node(%v): the new key is rejected: %v", c.fsm.id, err)
+		}
+		if err = verify(c, oldKey); err == nil {
+			t.Fatalf("node(%v): the old key is accepted after the grace window", c.fsm.id)
+		}
+	}
+}
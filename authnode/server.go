@@ -30,6 +30,7 @@ import (
 	"github.com/cubefs/cubefs/util/cryptoutil"
 
 	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/keystore"
 	"github.com/cubefs/cubefs/util/log"
 )
 
@@ -68,6 +69,8 @@ type Server struct {
 	wg           sync.WaitGroup
 	authProxy    *AuthProxy
 	metaReady    bool
+	keyWrapper   keystore.KeyWrapper
+	rootKey      []byte
 }
 
 // configuration keys
@@ -87,6 +90,7 @@ const (
 	cfgElectionTick   = "electionTick"
 	AuthSecretKey     = "authServiceKey"
 	AuthRootKey       = "authRootKey"
+	KeyWrapper        = "keyWrapper"
 	EnableHTTPS       = "enableHTTPS"
 )
 
@@ -142,6 +146,30 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 		m.electionTick = 5
 	}
 
+	return m.parseRootKey(cfg)
+}
+
+// parseRootKey parses the root key of the authnode. If a key wrapper is configured, authRootKey
+// is the root key wrapped by it, otherwise it is the root key in clear, which also wraps the
+// rotated root keys.
+func (m *Server) parseRootKey(cfg *config.Config) (err error) {
+	var wrapped []byte
+	authRootKey := cfg.GetString(AuthRootKey)
+	if wrapped, err = cryptoutil.Base64Decode(authRootKey); err != nil {
+		return fmt.Errorf("action[Start] failed %v,err: auth root Key invalid=%s", proto.ErrInvalidCfg, authRootKey)
+	}
+	wrapperName := cfg.GetString(KeyWrapper)
+	if wrapperName == "" {
+		m.rootKey = wrapped
+		m.keyWrapper = keystore.NewLocalKeyWrapper(m.rootKey)
+		return
+	}
+	if m.keyWrapper, err = keystore.NewKeyWrapper(wrapperName, cfg); err != nil {
+		return fmt.Errorf("action[Start] failed %v,err: %v", proto.ErrInvalidCfg, err)
+	}
+	if m.rootKey, err = m.keyWrapper.Unwrap(wrapped); err != nil {
+		return fmt.Errorf("action[Start] failed %v,err: auth root Key can not be unwrapped by %s: %v", proto.ErrInvalidCfg, wrapperName, err)
+	}
 	return
 }
 
@@ -151,6 +179,7 @@ func (m *Server) initFsm() {
 	m.fsm.registerPeerChangeHandler(m.handlePeerChange)
 
 	m.fsm.id = m.id
+	m.fsm.initRootKeys(m.keyWrapper, m.rootKey)
 
 	// register the handlers for the interfaces defined in the Raft library
 	m.fsm.registerApplySnapshotHandler(m.handleApplySnapshot)
@@ -204,15 +233,10 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	m.cluster.partition = m.partition
 
 	AuthSecretKey := cfg.GetString(AuthSecretKey)
-	if m.cluster.AuthSecretKey, err = cryptoutil.Base64Decode(AuthSecretKey); err != nil {
+	if m.cluster.AuthSecretKeys, err = cryptoutil.Base64DecodeKeys(AuthSecretKey); err != nil {
 		return fmt.Errorf("action[Start] failed %v,err: auth service Key invalid=%s", proto.ErrInvalidCfg, AuthSecretKey)
 	}
 
-	AuthRootKey := cfg.GetString(AuthRootKey)
-	if m.cluster.AuthRootKey, err = cryptoutil.Base64Decode(AuthRootKey); err != nil {
-		return fmt.Errorf("action[Start] failed %v,err: auth root Key invalid=%s", proto.ErrInvalidCfg, AuthRootKey)
-	}
-
 	if cfg.GetBool(EnableHTTPS) == true {
 		m.cluster.PKIKey.EnableHTTPS = true
 		if m.cluster.PKIKey.AuthRootPublicKey, err = ioutil.ReadFile("/app/server.crt"); err != nil {
@@ -31,6 +31,8 @@ const (
 	OSAddCaps      = "osaddcaps"
 	OSDeleteCaps   = "osdeletecaps"
 	OSGetCaps      = "osgetcaps"
+	RotateKey      = "rotatekey"
+	RotateRootKey  = "rotaterootkey"
 	HTTP           = "http://"
 	HTTPS          = "https://"
 )
@@ -42,6 +44,8 @@ const (
 	AccessKey  = "access_key"
 	AuthKey    = "auth_key"
 	SessionKey = "session_key"
+	Grace      = "grace"
+	KeyWrapper = "keyWrapper"
 )
 
 var action2PathMap = map[string]string{
@@ -56,6 +60,8 @@ var action2PathMap = map[string]string{
 	OSAddCaps:      proto.OSAddCaps,
 	OSDeleteCaps:   proto.OSDeleteCaps,
 	OSGetCaps:      proto.OSGetCaps,
+	RotateKey:      proto.AdminRotateKey,
+	RotateRootKey:  proto.AdminRotateRootKey,
 }
 
 var (
@@ -243,6 +249,10 @@ func accessAuthServer() {
 		msg = proto.MsgAuthOSDeleteCapsReq
 	case OSGetCaps:
 		msg = proto.MsgAuthOSGetCapsReq
+	case RotateKey:
+		msg = proto.MsgAuthRotateKeyReq
+	case RotateRootKey:
+		msg = proto.MsgAuthRotateRootKeyReq
 	default:
 		panic(fmt.Errorf("wrong requst [%s]", flaginfo.api.request))
 	}
@@ -267,9 +277,12 @@ func accessAuthServer() {
 	}
 	apiReq.Ticket = ticketCFG.GetString("ticket")
 
-	dataCFG, err := config.LoadConfigFile(flaginfo.api.data)
-	if err != nil {
-		panic(err)
+	// rotating the root key takes no request data
+	dataCFG := config.LoadConfigString("{}")
+	if flaginfo.api.request != RotateRootKey {
+		if dataCFG, err = config.LoadConfigFile(flaginfo.api.data); err != nil {
+			panic(err)
+		}
 	}
 
 	switch flaginfo.api.request {
@@ -301,6 +314,21 @@ func accessAuthServer() {
 				Caps: []byte(dataCFG.GetString(Caps)),
 			},
 		}
+	case RotateKey:
+		message = proto.AuthAPIAccessReq{
+			APIReq: *apiReq,
+			KeyInfo: keystore.KeyInfo{
+				ID: dataCFG.GetString(ID),
+			},
+			KeyGrace: dataCFG.GetInt64(Grace),
+		}
+	case RotateRootKey:
+		message = proto.AuthAPIAccessReq{
+			APIReq: *apiReq,
+			KeyInfo: keystore.KeyInfo{
+				ID: proto.AuthServiceID,
+			},
+		}
 	case AddRaftNode:
 		fallthrough
 	case RemoveRaftNode:
@@ -356,6 +384,10 @@ func accessAuthServer() {
 	case AddCaps:
 		fallthrough
 	case DeleteCaps:
+		fallthrough
+	case RotateKey:
+		fallthrough
+	case RotateRootKey:
 		var resp proto.AuthAPIAccessResp
 		if resp, err = proto.ParseAuthAPIAccessResp(body, sessionKey); err != nil {
 			panic(err)
@@ -365,7 +397,7 @@ func accessAuthServer() {
 			panic(err)
 		}
 
-		if flaginfo.api.request == CreateKey {
+		if flaginfo.api.request == CreateKey || flaginfo.api.request == RotateKey {
 			if err = resp.KeyInfo.DumpJSONFile(flaginfo.api.output); err != nil {
 				panic(err)
 			}
@@ -440,10 +472,40 @@ func generateRandomBytes(n int) ([]byte, error) {
 	return b, nil
 }
 
+// wrapKey prints the auth root key wrapped by the key wrapper, which is configured as authRootKey
+// of the authnode along with the key wrapper settings.
+func wrapKey(keyfile, cfgfile, wrapperName string) {
+	keyCFG, err := config.LoadConfigFile(keyfile)
+	if err != nil {
+		panic(err)
+	}
+	rootKey, err := cryptoutil.Base64Decode(keyCFG.GetString(AuthKey))
+	if err != nil {
+		panic(err)
+	}
+	cfg, err := config.LoadConfigFile(cfgfile)
+	if err != nil {
+		panic(err)
+	}
+	if wrapperName == "" {
+		wrapperName = cfg.GetString(KeyWrapper)
+	}
+	wrapper, err := keystore.NewKeyWrapper(wrapperName, cfg)
+	if err != nil {
+		panic(err)
+	}
+	wrapped, err := wrapper.Wrap(rootKey)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(cryptoutil.Base64Encode(wrapped))
+}
+
 func main() {
 	ticketCmd := flag.NewFlagSet("ticket", flag.ExitOnError)
 	apiCmd := flag.NewFlagSet("api", flag.ExitOnError)
 	authkeyCmd := flag.NewFlagSet("authkey", flag.ExitOnError)
+	wrapkeyCmd := flag.NewFlagSet("wrapkey", flag.ExitOnError)
 
 	switch os.Args[1] {
 	case "ticket":
@@ -516,8 +578,15 @@ func main() {
 			keyInfo.DumpJSONFile(*output[i])
 		}
 
+	case "wrapkey":
+		key := wrapkeyCmd.String("keyfile", "authroot.json", "path to keyring file of auth root")
+		cfgfile := wrapkeyCmd.String("config", "authnode.json", "path to authnode config with the key wrapper settings")
+		wrapper := wrapkeyCmd.String("wrapper", "", "name of the key wrapper, keyWrapper of the config by default")
+		wrapkeyCmd.Parse(os.Args[2:])
+		wrapKey(*key, *cfgfile, *wrapper)
+
 	default:
-		fmt.Println("expected 'ticket', 'api' or 'authkey'subcommands")
+		fmt.Println("expected 'ticket', 'api', 'authkey' or 'wrapkey' subcommands")
 		os.Exit(1)
 	}
 }
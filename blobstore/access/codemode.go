@@ -29,17 +29,28 @@ type CodeModePair struct {
 // CodeModePairs map of CodeModePair
 type CodeModePairs map[codemode.CodeMode]CodeModePair
 
-// SelectCodeMode select codemode by size
-func (c CodeModePairs) SelectCodeMode(size int64) codemode.CodeMode {
+// SelectCodeMode select codemode by size and storage class,
+// storage class is a hint, fallback to default storage class if no policy matched
+func (c CodeModePairs) SelectCodeMode(size int64, storageClass codemode.StorageClass) codemode.CodeMode {
+	if codeMode, ok := c.selectCodeMode(size, storageClass); ok {
+		return codeMode
+	}
+	if codeMode, ok := c.selectCodeMode(size, codemode.StorageClassHDD); ok {
+		return codeMode
+	}
+
+	panic(fmt.Sprintf("no codemode policy to be selected by size %d, %+v", size, c))
+}
+
+func (c CodeModePairs) selectCodeMode(size int64, storageClass codemode.StorageClass) (codemode.CodeMode, bool) {
 	for codeMode, pair := range c {
 		policy := pair.Policy
-		if !policy.Enable {
+		if !policy.Enable || policy.StorageClass != storageClass {
 			continue
 		}
 		if size >= policy.MinSize && size <= policy.MaxSize {
-			return codeMode
+			return codeMode, true
 		}
 	}
-
-	panic(fmt.Sprintf("no codemode policy to be selected by size %d, %+v", size, c))
+	return 0, false
 }
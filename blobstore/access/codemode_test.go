@@ -63,9 +63,50 @@ func TestAccessStreamCodeModePairs(t *testing.T) {
 	}
 	for _, cs := range cases {
 		if cs.isPanic {
-			require.Panics(t, func() { m.SelectCodeMode(cs.size) })
+			require.Panics(t, func() { m.SelectCodeMode(cs.size, codemode.StorageClassHDD) })
 		} else {
-			require.Equal(t, cs.mode, m.SelectCodeMode(cs.size))
+			require.Equal(t, cs.mode, m.SelectCodeMode(cs.size, codemode.StorageClassHDD))
 		}
 	}
 }
+
+func TestAccessStreamCodeModePairsStorageClass(t *testing.T) {
+	m := access.CodeModePairs{
+		codemode.EC6P6: access.CodeModePair{
+			Policy: codemode.Policy{
+				ModeName: codemode.EC6P6.Name(),
+				MinSize:  0,
+				MaxSize:  1 << 30,
+				Enable:   true,
+			},
+			Tactic: codemode.EC6P6.Tactic(),
+		},
+		codemode.EC3P3: access.CodeModePair{
+			Policy: codemode.Policy{
+				ModeName:     codemode.EC3P3.Name(),
+				MinSize:      0,
+				MaxSize:      1 << 20,
+				Enable:       true,
+				StorageClass: codemode.StorageClassSSD,
+			},
+			Tactic: codemode.EC3P3.Tactic(),
+		},
+	}
+
+	cases := []struct {
+		size         int64
+		storageClass codemode.StorageClass
+		mode         codemode.CodeMode
+	}{
+		{1 << 10, codemode.StorageClassHDD, codemode.EC6P6},
+		{1 << 10, codemode.StorageClassSSD, codemode.EC3P3},
+		{1 << 20, codemode.StorageClassSSD, codemode.EC3P3},
+		// fallback to default storage class
+		{1 << 25, codemode.StorageClassSSD, codemode.EC6P6},
+		{1 << 25, codemode.StorageClassMax, codemode.EC6P6},
+	}
+	for _, cs := range cases {
+		require.Equal(t, cs.mode, m.SelectCodeMode(cs.size, cs.storageClass))
+	}
+	require.Panics(t, func() { m.SelectCodeMode(1<<40, codemode.StorageClassSSD) })
+}
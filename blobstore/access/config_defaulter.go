@@ -24,6 +24,10 @@ const (
 	defaultEncoderConcurrency     int = 1000
 	defaultMinReadShardsX         int = 1
 
+	defaultHedgedReadMinDelayMS int     = 20
+	defaultHedgedReadMaxDelayMS int     = 1000
+	defaultSlowDiskLatencyRatio float64 = 5
+
 	// client timeout ms
 	defaultTimeoutClusterMgr int64 = 1000 * 3
 	defaultTimeoutProxy      int64 = 1000 * 5
@@ -0,0 +1,83 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+const (
+	// weight of the newest latency in moving average is 1/(1<<latencyEWMAShift)
+	latencyEWMAShift = 3
+	// slow punish time grows up to punishTimeSec<<maxSlowPunishLevel
+	maxSlowPunishLevel = 4
+)
+
+func (h *hostItem) getLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&h.latency))
+}
+
+func (h *hostItem) addLatency(latency time.Duration) {
+	for {
+		old := atomic.LoadInt64(&h.latency)
+		val := int64(latency)
+		if old > 0 {
+			val = old + (val-old)>>latencyEWMAShift
+		}
+		if atomic.CompareAndSwapInt64(&h.latency, old, val) {
+			return
+		}
+	}
+}
+
+// ReportDiskLatency records the latency of a request on the disk
+func (s *serviceControllerImpl) ReportDiskLatency(ctx context.Context, diskID proto.DiskID, latency time.Duration) {
+	v, ok := s.allServices.Load(_diskHostServicePrefix + diskID.ToString())
+	if !ok {
+		return
+	}
+	v.(*hostItem).addLatency(latency)
+}
+
+// PunishSlowDisk will punish a slow disk host, the punish time doubles if
+// the disk is punished again within the last punish interval after recovered
+func (s *serviceControllerImpl) PunishSlowDisk(ctx context.Context, diskID proto.DiskID, punishTimeSec int) {
+	v, ok := s.allServices.Load(_diskHostServicePrefix + diskID.ToString())
+	if !ok {
+		return
+	}
+	item := v.(*hostItem)
+	if item.isPunish() {
+		return
+	}
+
+	level := atomic.LoadUint32(&item.slowLevel)
+	lastPunishEnd := time.Unix(atomic.LoadInt64(&item.punishTimeUnix), 0)
+	lastPunishTime := time.Duration(punishTimeSec<<level) * time.Second
+	switch {
+	case time.Since(lastPunishEnd) > lastPunishTime:
+		level = 0
+	case level < maxSlowPunishLevel:
+		level++
+	}
+	atomic.StoreUint32(&item.slowLevel, level)
+
+	// reset the moving average, the disk starts again after punishment
+	atomic.StoreInt64(&item.latency, 0)
+	atomic.StoreInt64(&item.punishTimeUnix, time.Now().Add(time.Duration(punishTimeSec<<level)*time.Second).Unix())
+}
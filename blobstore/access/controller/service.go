@@ -47,6 +47,9 @@ type HostIDC struct {
 	Host     string
 	IDC      string
 	Punished bool
+	// Latency is the moving average latency of requests on the disk,
+	// it is zero if nothing has been reported
+	Latency time.Duration
 }
 
 // ServiceController support for both data node discovery and normal service discovery
@@ -67,6 +70,11 @@ type ServiceController interface {
 	// PunishDiskWithThreshold will punish a disk host for
 	// an punishTimeSec interval if disk host failed times satisfied with threshold
 	PunishDiskWithThreshold(ctx context.Context, diskID proto.DiskID, punishTimeSec int)
+	// ReportDiskLatency records the latency of a request on the disk
+	ReportDiskLatency(ctx context.Context, diskID proto.DiskID, latency time.Duration)
+	// PunishSlowDisk will punish a slow disk host, the punish time grows
+	// from punishTimeSec if the disk is still slow soon after the last punishment
+	PunishSlowDisk(ctx context.Context, diskID proto.DiskID, punishTimeSec int)
 }
 
 type (
@@ -85,6 +93,11 @@ type hostItem struct {
 	lastModifyTime int64
 	// failedTimes record the service host failed times during some interval
 	failedTimes uint32
+
+	// latency record the moving average latency in nanoseconds
+	latency int64
+	// slowLevel record the times of continuous slow punishment
+	slowLevel uint32
 }
 
 func (h *hostItem) isPunish() bool {
@@ -263,6 +276,7 @@ func (s *serviceControllerImpl) GetDiskHost(ctx context.Context, diskID proto.Di
 			Host:     item.host,
 			IDC:      item.idc,
 			Punished: item.isPunish(),
+			Latency:  item.getLatency(),
 		}, nil
 	}
 	ret, err, _ := s.group.Do("get-diskinfo-"+diskID.ToString(), func() (interface{}, error) {
@@ -215,3 +215,53 @@ func TestAccessServicePunishDisk(t *testing.T) {
 		require.False(t, host.Punished)
 	}
 }
+
+func TestAccessServiceDiskLatency(t *testing.T) {
+	sc, err := controller.NewServiceController(
+		controller.ServiceConfig{IDC: idc, ReloadSec: 1}, cmcli)
+	require.NoError(t, err)
+
+	sc.ReportDiskLatency(serviceCtx, 10001, time.Millisecond)
+	{
+		host, err := sc.GetDiskHost(serviceCtx, proto.DiskID(10001))
+		require.NoError(t, err)
+		require.Equal(t, time.Duration(0), host.Latency)
+	}
+	sc.ReportDiskLatency(serviceCtx, 10001, 10*time.Millisecond)
+	sc.ReportDiskLatency(serviceCtx, 10001, 18*time.Millisecond)
+	{
+		host, err := sc.GetDiskHost(serviceCtx, proto.DiskID(10001))
+		require.NoError(t, err)
+		require.Equal(t, 11*time.Millisecond, host.Latency)
+	}
+}
+
+func TestAccessServicePunishSlowDisk(t *testing.T) {
+	sc, err := controller.NewServiceController(
+		controller.ServiceConfig{IDC: idc, ReloadSec: 1}, cmcli)
+	require.NoError(t, err)
+
+	isPunished := func() bool {
+		host, err := sc.GetDiskHost(serviceCtx, proto.DiskID(10001))
+		require.NoError(t, err)
+		return host.Punished
+	}
+
+	require.False(t, isPunished())
+	sc.ReportDiskLatency(serviceCtx, 10001, 10*time.Millisecond)
+	sc.PunishSlowDisk(serviceCtx, 10001, 1)
+	require.True(t, isPunished())
+	host, err := sc.GetDiskHost(serviceCtx, proto.DiskID(10001))
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), host.Latency)
+
+	time.Sleep(time.Second)
+	require.False(t, isPunished())
+
+	// still slow after punishment, punish time doubles
+	sc.PunishSlowDisk(serviceCtx, 10001, 1)
+	time.Sleep(time.Second)
+	require.True(t, isPunished())
+	time.Sleep(1200 * time.Millisecond)
+	require.False(t, isPunished())
+}
@@ -7,6 +7,7 @@ package access
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	controller "github.com/cubefs/cubefs/blobstore/access/controller"
 	clustermgr "github.com/cubefs/cubefs/blobstore/api/clustermgr"
@@ -255,6 +256,30 @@ func (mr *MockServiceControllerMockRecorder) PunishServiceWithThreshold(arg0, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PunishServiceWithThreshold", reflect.TypeOf((*MockServiceController)(nil).PunishServiceWithThreshold), arg0, arg1, arg2, arg3)
 }
 
+// PunishSlowDisk mocks base method.
+func (m *MockServiceController) PunishSlowDisk(arg0 context.Context, arg1 proto.DiskID, arg2 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PunishSlowDisk", arg0, arg1, arg2)
+}
+
+// PunishSlowDisk indicates an expected call of PunishSlowDisk.
+func (mr *MockServiceControllerMockRecorder) PunishSlowDisk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PunishSlowDisk", reflect.TypeOf((*MockServiceController)(nil).PunishSlowDisk), arg0, arg1, arg2)
+}
+
+// ReportDiskLatency mocks base method.
+func (m *MockServiceController) ReportDiskLatency(arg0 context.Context, arg1 proto.DiskID, arg2 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReportDiskLatency", arg0, arg1, arg2)
+}
+
+// ReportDiskLatency indicates an expected call of ReportDiskLatency.
+func (mr *MockServiceControllerMockRecorder) ReportDiskLatency(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportDiskLatency", reflect.TypeOf((*MockServiceController)(nil).ReportDiskLatency), arg0, arg1, arg2)
+}
+
 // MockVolumeGetter is a mock of VolumeGetter interface.
 type MockVolumeGetter struct {
 	ctrl     *gomock.Controller
@@ -272,7 +272,7 @@ func (s *Service) Put(c *rpc.Context) {
 	}
 
 	rc := s.limiter.Reader(ctx, c.Request.Body)
-	loc, err := s.streamHandler.Put(ctx, rc, args.Size, hasherMap)
+	loc, err := s.streamHandler.Put(ctx, rc, args.Size, args.StorageClass, hasherMap)
 	if err != nil {
 		span.Error("stream put failed", errors.Detail(err))
 		c.RespondError(httpError(err))
@@ -372,7 +372,8 @@ func (s *Service) Alloc(c *rpc.Context) {
 		return
 	}
 
-	location, err := s.streamHandler.Alloc(ctx, args.Size, args.BlobSize, args.AssignClusterID, args.CodeMode)
+	location, err := s.streamHandler.Alloc(ctx, args.Size, args.BlobSize, args.AssignClusterID,
+		args.CodeMode, args.StorageClass)
 	if err != nil {
 		span.Error("stream alloc failed", errors.Detail(err))
 		c.RespondError(httpError(err))
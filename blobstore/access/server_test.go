@@ -68,9 +68,9 @@ func newService() *Service {
 	ctr := gomock.NewController(&testing.T{})
 	s := NewMockStreamHandler(ctr)
 
-	s.EXPECT().Alloc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
-		func(ctx context.Context, size uint64, blobSize uint32,
-			assignClusterID proto.ClusterID, codeMode codemode.CodeMode) (*access.Location, error) {
+	s.EXPECT().Alloc(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, size uint64, blobSize uint32, assignClusterID proto.ClusterID,
+			codeMode codemode.CodeMode, storageClass codemode.StorageClass) (*access.Location, error) {
 			if size < 1024 {
 				return nil, errors.New("fake alloc location")
 			}
@@ -91,8 +91,9 @@ func newService() *Service {
 			return nil
 		})
 
-	s.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
-		func(ctx context.Context, rc io.Reader, size int64, hasherMap access.HasherMap) (*access.Location, error) {
+	s.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, rc io.Reader, size int64, storageClass codemode.StorageClass,
+			hasherMap access.HasherMap) (*access.Location, error) {
 			if size < 1024 {
 				return nil, errors.New("fake put nil body")
 			}
@@ -64,11 +64,11 @@ func NewHandler(service *Service) *rpc.Router {
 
 	rpc.Use(service.Limit)
 
-	// POST /put?size={size}&hashes={hashes}
+	// POST /put?size={size}&hashes={hashes}&storage_class={storage_class}
 	// request  body:  DataStream
 	// response body:  json
 	rpc.POST("/put", service.Put, rpc.OptArgsQuery())
-	// PUT /put?size={size}&hashes={hashes}
+	// PUT /put?size={size}&hashes={hashes}&storage_class={storage_class}
 	rpc.PUT("/put", service.Put, rpc.OptArgsQuery())
 
 	// POST /putat?clusterid={clusterid}&volumeid={volumeid}&blobid={blobid}&size={size}&hashes={hashes}&token={token}
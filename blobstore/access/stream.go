@@ -54,9 +54,10 @@ type StreamHandler interface {
 	//     optional: blobSize > 0, alloc with blobSize
 	//               assignClusterID > 0, assign to alloc in this cluster certainly
 	//               codeMode > 0, alloc in this codemode
+	//               storageClass, hint to select codemode if codeMode is not set
 	//     return: a location of file
-	Alloc(ctx context.Context, size uint64, blobSize uint32,
-		assignClusterID proto.ClusterID, codeMode codemode.CodeMode) (*access.Location, error)
+	Alloc(ctx context.Context, size uint64, blobSize uint32, assignClusterID proto.ClusterID,
+		codeMode codemode.CodeMode, storageClass codemode.StorageClass) (*access.Location, error)
 
 	// PutAt access interface /putat, put one blob
 	//     required: rc file reader
@@ -68,8 +69,10 @@ type StreamHandler interface {
 
 	// Put put one object
 	//     required: size, file size
-	//     optional: hasher map to calculate hash.Hash
-	Put(ctx context.Context, rc io.Reader, size int64, hasherMap access.HasherMap) (*access.Location, error)
+	//     optional: storageClass, hint to select codemode
+	//               hasher map to calculate hash.Hash
+	Put(ctx context.Context, rc io.Reader, size int64, storageClass codemode.StorageClass,
+		hasherMap access.HasherMap) (*access.Location, error)
 
 	// Get read file
 	//     required: location, readSize
@@ -123,6 +126,15 @@ type StreamConfig struct {
 	MinReadShardsX             int    `json:"min_read_shards_x"`
 	ShardCrcDisabled           bool   `json:"shard_crc_disabled"`
 
+	// send a backup shard read if no shard returned after p95 latency,
+	// the delay is limited in [min, max]
+	HedgedReadDisabled   bool `json:"hedged_read_disabled"`
+	HedgedReadMinDelayMS int  `json:"hedged_read_min_delay_ms"`
+	HedgedReadMaxDelayMS int  `json:"hedged_read_max_delay_ms"`
+	// punish the disk whose average latency is greater than ratio * p95,
+	// punish time doubles if the disk is still slow after punishment
+	SlowDiskLatencyRatio float64 `json:"slow_disk_latency_ratio"`
+
 	MemPoolSizeClasses map[int]int `json:"mem_pool_size_classes"`
 
 	// CodeModesPutQuorums
@@ -170,6 +182,8 @@ type Handler struct {
 	discardVidChan chan discardVid
 	stopCh         <-chan struct{}
 
+	latency *latencyTracker
+
 	StreamConfig
 }
 
@@ -203,6 +217,12 @@ func confCheck(cfg *StreamConfig) {
 	}
 	defaulter.LessOrEqual(&cfg.EncoderConcurrency, defaultEncoderConcurrency)
 	defaulter.LessOrEqual(&cfg.MinReadShardsX, defaultMinReadShardsX)
+	defaulter.LessOrEqual(&cfg.HedgedReadMinDelayMS, defaultHedgedReadMinDelayMS)
+	defaulter.LessOrEqual(&cfg.HedgedReadMaxDelayMS, defaultHedgedReadMaxDelayMS)
+	if cfg.HedgedReadMaxDelayMS < cfg.HedgedReadMinDelayMS {
+		cfg.HedgedReadMaxDelayMS = cfg.HedgedReadMinDelayMS
+	}
+	defaulter.Equal(&cfg.SlowDiskLatencyRatio, defaultSlowDiskLatencyRatio)
 
 	defaulter.LessOrEqual(&cfg.ClusterConfig.CMClientConfig.Config.ClientTimeoutMs, defaultTimeoutClusterMgr)
 	defaulter.LessOrEqual(&cfg.BlobnodeConfig.ClientTimeoutMs, defaultTimeoutBlobnode)
@@ -241,6 +261,7 @@ func NewStreamHandler(cfg *StreamConfig, stopCh <-chan struct{}) StreamHandler {
 		proxyClient:    proxy.New(&cfg.ProxyConfig),
 
 		maxObjectSize: defaultMaxObjectSize,
+		latency:       newLatencyTracker(),
 		StreamConfig:  *cfg,
 	}
 
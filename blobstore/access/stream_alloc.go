@@ -37,12 +37,13 @@ var errAllocatePunishedVolume = errors.New("allocate punished volume")
 //	optional: blobSize > 0, alloc with blobSize
 //	          assignClusterID > 0, assign to alloc in this cluster certainly
 //	          codeMode > 0, alloc in this codemode
+//	          storageClass, hint to select codemode if codeMode is not set
 //	return: a location of file
-func (h *Handler) Alloc(ctx context.Context, size uint64, blobSize uint32,
-	assignClusterID proto.ClusterID, codeMode codemode.CodeMode) (*access.Location, error) {
+func (h *Handler) Alloc(ctx context.Context, size uint64, blobSize uint32, assignClusterID proto.ClusterID,
+	codeMode codemode.CodeMode, storageClass codemode.StorageClass) (*access.Location, error) {
 	span := trace.SpanFromContextSafe(ctx)
-	span.Debugf("alloc request with size:%d blobsize:%d cluster:%d codemode:%d",
-		size, blobSize, assignClusterID, codeMode)
+	span.Debugf("alloc request with size:%d blobsize:%d cluster:%d codemode:%d storageclass:%s",
+		size, blobSize, assignClusterID, codeMode, storageClass)
 
 	if int64(size) > h.maxObjectSize {
 		span.Info("exceed max object size", h.maxObjectSize)
@@ -55,7 +56,7 @@ func (h *Handler) Alloc(ctx context.Context, size uint64, blobSize uint32,
 	}
 
 	if codeMode == 0 {
-		codeMode = h.allCodeModes.SelectCodeMode(int64(size))
+		codeMode = h.allCodeModes.SelectCodeMode(int64(size), storageClass)
 		span.Debugf("select codemode:%d", codeMode)
 	}
 	if !codeMode.IsValid() {
@@ -177,7 +177,7 @@ func (h *Handler) Get(ctx context.Context, w io.Writer, location access.Location
 
 						tactic := blobVolume.CodeMode.Tactic()
 						// do not use local shards
-						sortedVuids = genSortedVuidByIDC(ctx, serviceController, h.IDC, blobVolume.Units[:tactic.N+tactic.M])
+						sortedVuids = genSortedVuidByIDC(ctx, serviceController, h.IDC, tactic.N, blobVolume.Units[:tactic.N+tactic.M])
 						span.Debugf("to read blob(%d %d %d) with read-shard-x:%d active-shard-n:%d of data-n:%d party-n:%d",
 							clusterID, blob.Vid, blob.Bid, h.MinReadShardsX, len(sortedVuids), tactic.N, tactic.M)
 						if len(sortedVuids) < tactic.N {
@@ -332,6 +332,18 @@ func (h *Handler) readOneBlob(ctx context.Context, getTime *timeReadWrite,
 				}
 			}
 
+			// send a backup shard read if no shard returned in hedge delay
+			var (
+				hedgeTimer *time.Timer
+				hedgeC     <-chan time.Time
+			)
+			hedgeDelay := h.hedgeDelay()
+			if hedgeDelay > 0 {
+				hedgeTimer = time.NewTimer(hedgeDelay)
+				defer hedgeTimer.Stop()
+				hedgeC = hedgeTimer.C
+			}
+
 			for _, vuid := range sortedVuids[minShardsRead:] {
 				if _, ok := empties[vuid.index]; ok {
 					continue
@@ -341,6 +353,8 @@ func (h *Handler) readOneBlob(ctx context.Context, getTime *timeReadWrite,
 				case <-stopChan:
 					return
 				case <-nextChan:
+				case <-hedgeC:
+					span.Debugf("bid(%d) hedged read ecidx(%d) after %s", blob.Bid, vuid.index, hedgeDelay)
 				}
 
 				wg.Add(1)
@@ -349,6 +363,16 @@ func (h *Handler) readOneBlob(ctx context.Context, getTime *timeReadWrite,
 						shardSize, blob, vuid, stopChan)
 					wg.Done()
 				}(vuid)
+
+				if hedgeTimer != nil {
+					if !hedgeTimer.Stop() {
+						select {
+						case <-hedgeTimer.C:
+						default:
+						}
+					}
+					hedgeTimer.Reset(hedgeDelay)
+				}
 			}
 		}()
 
@@ -467,6 +491,7 @@ func (h *Handler) readOneShard(ctx context.Context, serviceController controller
 		err  error
 		body io.ReadCloser
 	)
+	startRead := time.Now()
 	if hErr := hystrix.Do(rwCommand, func() error {
 		body, err = h.getOneShardFromHost(ctx, serviceController, vuid.host, vuid.diskID, args,
 			vuid.index, clusterID, vid, 3, stopChan)
@@ -507,6 +532,8 @@ func (h *Handler) readOneShard(ctx context.Context, serviceController controller
 		return shardResult
 	}
 
+	h.reportShardLatency(ctx, serviceController, clusterID, vuid.diskID, vuid.host, time.Since(startRead))
+
 	shardResult.status = true
 	shardResult.buffer = buf
 	return shardResult
@@ -741,12 +768,15 @@ func genLocationBlobs(location *access.Location, readSize uint64, offset uint64)
 	return blobs, nil
 }
 
+// genSortedVuidByIDC sorts vuids by distance, in the same distance
+// data shards are in front, then sorted by latency of disk in milliseconds.
 func genSortedVuidByIDC(ctx context.Context, serviceController controller.ServiceController, idc string,
-	vuidPhys []controller.Unit) []sortedVuid {
+	dataN int, vuidPhys []controller.Unit) []sortedVuid {
 	span := trace.SpanFromContextSafe(ctx)
 
 	vuids := make([]sortedVuid, 0, len(vuidPhys))
 	sortMap := make(map[int][]sortedVuid)
+	latencies := make(map[int]time.Duration, len(vuidPhys))
 
 	for idx, phy := range vuidPhys {
 		var hostIDC *controller.HostIDC
@@ -762,6 +792,7 @@ func genSortedVuidByIDC(ctx context.Context, serviceController controller.Servic
 			continue
 		}
 
+		latencies[idx] = hostIDC.Latency / time.Millisecond
 		dis := distance(idc, hostIDC.IDC, hostIDC.Punished)
 		if _, ok := sortMap[dis]; !ok {
 			sortMap[dis] = make([]sortedVuid, 0, 8)
@@ -785,6 +816,13 @@ func genSortedVuidByIDC(ctx context.Context, serviceController controller.Servic
 		rand.Shuffle(len(ids), func(i, j int) {
 			ids[i], ids[j] = ids[j], ids[i]
 		})
+		sort.SliceStable(ids, func(i, j int) bool {
+			iData, jData := ids[i].index < dataN, ids[j].index < dataN
+			if iData != jData {
+				return iData
+			}
+			return latencies[ids[i].index] < latencies[ids[j].index]
+		})
 		vuids = append(vuids, ids...)
 		if dis > 1 {
 			span.Debugf("distance: %d punished vuids: %+v", dis, ids)
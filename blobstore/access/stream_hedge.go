@@ -0,0 +1,133 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package access
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/access/controller"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+)
+
+const (
+	latencyWindowSize = 1 << 10
+	// recompute p95 after so many new samples
+	latencyRecomputeStep = 1 << 7
+	// p95 is meaningless before there are enough samples
+	latencyMinSamples = 100
+)
+
+// latencyTracker keeps a window of recent shard read latencies,
+// nil tracker is valid which knows nothing about latency.
+type latencyTracker struct {
+	lock    sync.Mutex
+	samples []time.Duration
+	next    int
+	added   int
+	p95     time.Duration
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{samples: make([]time.Duration, 0, latencyWindowSize)}
+}
+
+func (t *latencyTracker) Add(latency time.Duration) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	if len(t.samples) < latencyWindowSize {
+		t.samples = append(t.samples, latency)
+	} else {
+		t.samples[t.next] = latency
+		t.next = (t.next + 1) % latencyWindowSize
+	}
+	t.added++
+	if len(t.samples) >= latencyMinSamples && (t.p95 == 0 || t.added >= latencyRecomputeStep) {
+		t.added = 0
+		t.p95 = percentile(t.samples, 95)
+	}
+	t.lock.Unlock()
+}
+
+// P95 returns zero if there is no enough samples
+func (t *latencyTracker) P95() time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.lock.Lock()
+	p95 := t.p95
+	t.lock.Unlock()
+	return p95
+}
+
+func percentile(samples []time.Duration, p int) time.Duration {
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+// hedgeDelay returns the delay before sending a backup shard read,
+// zero means hedged read is disabled.
+func (h *Handler) hedgeDelay() time.Duration {
+	if h.HedgedReadDisabled {
+		return 0
+	}
+	minDelay := time.Duration(h.HedgedReadMinDelayMS) * time.Millisecond
+	maxDelay := time.Duration(h.HedgedReadMaxDelayMS) * time.Millisecond
+	if maxDelay <= 0 {
+		return 0
+	}
+
+	delay := h.latency.P95()
+	if delay <= 0 {
+		return maxDelay
+	}
+	if delay < minDelay {
+		delay = minDelay
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// reportShardLatency reports latency of a successful shard read,
+// punishes the disk whose average latency is too slow than p95 of all disks.
+func (h *Handler) reportShardLatency(ctx context.Context, serviceController controller.ServiceController,
+	clusterID proto.ClusterID, diskID proto.DiskID, host string, latency time.Duration) {
+	h.latency.Add(latency)
+	serviceController.ReportDiskLatency(ctx, diskID, latency)
+
+	p95 := h.latency.P95()
+	if p95 <= 0 || h.SlowDiskLatencyRatio <= 0 {
+		return
+	}
+	diskHost, err := serviceController.GetDiskHost(ctx, diskID)
+	if err != nil || diskHost.Punished {
+		return
+	}
+	if float64(diskHost.Latency) > h.SlowDiskLatencyRatio*float64(p95) {
+		span := trace.SpanFromContextSafe(ctx)
+		span.Infof("punish slow disk:%d on:%s latency:%s p95:%s", diskID, host, diskHost.Latency, p95)
+		reportUnhealth(clusterID, "punish", "slowdisk", host, "Slow")
+		serviceController.PunishSlowDisk(ctx, diskID, h.DiskTimeoutPunishIntervalS)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package access
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/access/controller"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+func TestAccessStreamHedgeLatencyTracker(t *testing.T) {
+	var nilTracker *latencyTracker
+	nilTracker.Add(time.Second)
+	require.Equal(t, time.Duration(0), nilTracker.P95())
+
+	tracker := newLatencyTracker()
+	for ii := 1; ii < latencyMinSamples; ii++ {
+		tracker.Add(time.Duration(ii) * time.Millisecond)
+	}
+	require.Equal(t, time.Duration(0), tracker.P95())
+	tracker.Add(latencyMinSamples * time.Millisecond)
+	require.Equal(t, 95*time.Millisecond, tracker.P95())
+
+	// full window of new samples
+	for ii := 0; ii < latencyWindowSize; ii++ {
+		tracker.Add(time.Second)
+	}
+	require.Equal(t, time.Second, tracker.P95())
+}
+
+func TestAccessStreamHedgeDelay(t *testing.T) {
+	h := &Handler{StreamConfig: StreamConfig{
+		HedgedReadMinDelayMS: 20,
+		HedgedReadMaxDelayMS: 200,
+	}}
+	require.Equal(t, 200*time.Millisecond, h.hedgeDelay())
+
+	h.latency = newLatencyTracker()
+	for ii := 0; ii < latencyMinSamples; ii++ {
+		h.latency.Add(time.Millisecond)
+	}
+	require.Equal(t, 20*time.Millisecond, h.hedgeDelay())
+	for ii := 0; ii < latencyWindowSize; ii++ {
+		h.latency.Add(50 * time.Millisecond)
+	}
+	require.Equal(t, 50*time.Millisecond, h.hedgeDelay())
+	for ii := 0; ii < latencyWindowSize; ii++ {
+		h.latency.Add(time.Second)
+	}
+	require.Equal(t, 200*time.Millisecond, h.hedgeDelay())
+
+	h.HedgedReadDisabled = true
+	require.Equal(t, time.Duration(0), h.hedgeDelay())
+}
+
+func TestAccessStreamHedgeSortedVuid(t *testing.T) {
+	ctx := ctxWithName("TestAccessStreamHedgeSortedVuid")
+	ctr := gomock.NewController(t)
+	defer ctr.Finish()
+
+	hosts := []controller.HostIDC{
+		{IDC: idc, Latency: time.Millisecond},
+		{IDC: idc, Latency: 5 * time.Millisecond},
+		{IDC: idcOther},
+		{IDC: idc, Latency: 1500 * time.Microsecond},
+		{IDC: idc, Latency: 1100 * time.Microsecond},
+		{IDC: idcOther},
+		{IDC: idc, Punished: true},
+	}
+	units := make([]controller.Unit, 0, len(hosts))
+	sc := NewMockServiceController(ctr)
+	for idx := range hosts {
+		diskID := proto.DiskID(idx + 1)
+		units = append(units, controller.Unit{Vuid: proto.Vuid(idx + 1), DiskID: diskID})
+		sc.EXPECT().GetDiskHost(gomock.Any(), diskID).Return(&hosts[idx], nil)
+	}
+
+	sorted := genSortedVuidByIDC(ctx(), sc, idc, 3, units)
+	indexes := make([]int, 0, len(sorted))
+	for _, vuid := range sorted {
+		indexes = append(indexes, vuid.index)
+	}
+	require.Equal(t, 0, indexes[0])
+	require.Equal(t, 1, indexes[1])
+	// latency in the same millisecond
+	require.ElementsMatch(t, []int{3, 4}, indexes[2:4])
+	require.Equal(t, 2, indexes[4])
+	require.Equal(t, 5, indexes[5])
+	require.Equal(t, 6, indexes[6])
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/cubefs/cubefs/blobstore/api/access"
 	"github.com/cubefs/cubefs/blobstore/api/blobnode"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	"github.com/cubefs/cubefs/blobstore/common/ec"
 	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/rpc"
@@ -42,7 +43,8 @@ import (
 // Put put one object
 //
 //	required: size, file size
-//	optional: hasher map to calculate hash.Hash
+//	optional: storageClass, hint to select codemode
+//	          hasher map to calculate hash.Hash
 //
 /*
 Access Object Put handler:
@@ -52,10 +54,10 @@ Access Object Put handler:
 	-- EC encode data
 	-- writeToBlobnodesWithHystrix
 */
-func (h *Handler) Put(ctx context.Context, rc io.Reader, size int64,
+func (h *Handler) Put(ctx context.Context, rc io.Reader, size int64, storageClass codemode.StorageClass,
 	hasherMap access.HasherMap) (*access.Location, error) {
 	span := trace.SpanFromContextSafe(ctx)
-	span.Debugf("put request size:%d hashes:b(%b)", size, hasherMap.ToHashAlgorithm())
+	span.Debugf("put request size:%d storageclass:%s hashes:b(%b)", size, storageClass, hasherMap.ToHashAlgorithm())
 
 	if size <= 0 {
 		return nil, errcode.ErrIllegalArguments
@@ -71,7 +73,7 @@ func (h *Handler) Put(ctx context.Context, rc io.Reader, size int64,
 	}
 
 	// 2.choose cluster and alloc volume from allocator
-	selectedCodeMode := h.allCodeModes.SelectCodeMode(size)
+	selectedCodeMode := h.allCodeModes.SelectCodeMode(size, storageClass)
 	span.Debugf("select codemode %d", selectedCodeMode)
 
 	blobSize := atomic.LoadUint32(&h.MaxBlobSize)
@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	errcode "github.com/cubefs/cubefs/blobstore/common/errors"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 )
@@ -42,13 +43,13 @@ func TestAccessStreamPutBase(t *testing.T) {
 	// 0
 	{
 		size := 0
-		_, err := streamer.Put(ctx(), newReader(size), int64(size), nil)
+		_, err := streamer.Put(ctx(), newReader(size), int64(size), codemode.StorageClassHDD, nil)
 		require.Error(t, err)
 	}
 	// 1 byte
 	{
 		size := 1
-		loc, err := streamer.Put(ctx(), newReader(size), int64(size), nil)
+		loc, err := streamer.Put(ctx(), newReader(size), int64(size), codemode.StorageClassHDD, nil)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(loc.Blobs))
 		require.Equal(t, uint32(1), loc.Blobs[0].Count)
@@ -58,7 +59,7 @@ func TestAccessStreamPutBase(t *testing.T) {
 	// <4M
 	{
 		size := 1 << 18
-		loc, err := streamer.Put(ctx(), newReader(size), int64(size), nil)
+		loc, err := streamer.Put(ctx(), newReader(size), int64(size), codemode.StorageClassHDD, nil)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(loc.Blobs))
 		require.Equal(t, uint32(1), loc.Blobs[0].Count)
@@ -67,7 +68,7 @@ func TestAccessStreamPutBase(t *testing.T) {
 	// 8M + 1k
 	{
 		size := (1 << 23) + 1024
-		loc, err := streamer.Put(ctx(), newReader(size), int64(size), nil)
+		loc, err := streamer.Put(ctx(), newReader(size), int64(size), codemode.StorageClassHDD, nil)
 		require.NoError(t, err)
 		require.Equal(t, 2, len(loc.Blobs))
 		require.Equal(t, uint32(2), loc.Blobs[1].Count)
@@ -76,7 +77,7 @@ func TestAccessStreamPutBase(t *testing.T) {
 	// max size + 1
 	{
 		size := defaultMaxObjectSize + 1
-		_, err := streamer.Put(ctx(), nil, int64(size), nil)
+		_, err := streamer.Put(ctx(), nil, int64(size), codemode.StorageClassHDD, nil)
 		require.EqualError(t, errcode.ErrAccessExceedSize, err.Error())
 	}
 
@@ -97,7 +98,7 @@ func TestAccessStreamPutSum(t *testing.T) {
 		}
 		hashSumMap := make(access.HashSumMap, len(hasherMap))
 
-		_, err := streamer.Put(ctx(), bytes.NewReader(data), int64(len(data)), hasherMap)
+		_, err := streamer.Put(ctx(), bytes.NewReader(data), int64(len(data)), codemode.StorageClassHDD, hasherMap)
 		require.NoError(t, err)
 		for alg, hasher := range hasherMap {
 			hashSumMap[alg] = hasher.Sum(nil)
@@ -231,7 +232,7 @@ func TestAccessStreamPutShardTimeout(t *testing.T) {
 	buff := make([]byte, size)
 	rand.Read(buff)
 	startTime := time.Now()
-	loc, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+	loc, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), codemode.StorageClassHDD, nil)
 	require.NoError(t, err)
 
 	// response immediately if had quorum shards
@@ -247,7 +248,7 @@ func TestAccessStreamPutShardTimeout(t *testing.T) {
 	vuidController.Block(1002)
 	{
 		startTime := time.Now()
-		_, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+		_, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), codemode.StorageClassHDD, nil)
 		require.Error(t, err)
 
 		duration := time.Since(startTime)
@@ -299,7 +300,7 @@ func TestAccessStreamPutQuorum(t *testing.T) {
 			vuidController.Break(id)
 		}
 
-		_, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), nil)
+		_, err := streamer.Put(ctx(), bytes.NewReader(buff), int64(size), codemode.StorageClassHDD, nil)
 		if cs.hasError {
 			require.NotNil(t, err)
 		} else {
@@ -338,7 +339,7 @@ func BenchmarkAccessStreamPut(b *testing.B) {
 		b.ResetTimer()
 		b.Run(cs.name, func(b *testing.B) {
 			for ii := 0; ii <= b.N; ii++ {
-				streamer.Put(ctx, bytes.NewReader(buff[:cs.size]), int64(cs.size), nil)
+				streamer.Put(ctx, bytes.NewReader(buff[:cs.size]), int64(cs.size), codemode.StorageClassHDD, nil)
 			}
 		})
 	}
@@ -62,7 +62,7 @@ func TestAccessStreamNew(t *testing.T) {
 func TestAccessStreamDelete(t *testing.T) {
 	ctx := ctxWithName("TestAccessStreamDelete")
 	size := 1 << 18
-	loc, err := streamer.Put(ctx(), newReader(size), int64(size), nil)
+	loc, err := streamer.Put(ctx(), newReader(size), int64(size), codemode.StorageClassHDD, nil)
 	require.NoError(t, err)
 
 	err = streamer.Delete(ctx(), loc)
@@ -221,6 +221,10 @@ type API interface {
 	// Delete all blobs in these locations.
 	// return failed locations which have yet been deleted if error is not nil.
 	Delete(ctx context.Context, args *DeleteArgs) (failedLocations []Location, err error)
+	// Alloc a location with upload tokens, blobs of the location are put by PutAt.
+	Alloc(ctx context.Context, args *AllocArgs) (resp AllocResp, err error)
+	// PutAt put one blob of the location allocated by Alloc.
+	PutAt(ctx context.Context, args *PutAtArgs) (hashSumMap HashSumMap, err error)
 }
 
 var _ API = (*client)(nil)
@@ -413,7 +417,7 @@ func (c *client) Put(ctx context.Context, args *PutArgs) (location Location, has
 func (c *client) putObject(ctx context.Context, args *PutArgs) (location Location, hashSumMap HashSumMap, err error) {
 	rpcClient := c.rpcClient.Load().(rpc.Client)
 
-	urlStr := fmt.Sprintf("/put?size=%d&hashes=%d", args.Size, args.Hashes)
+	urlStr := fmt.Sprintf("/put?size=%d&hashes=%d&storage_class=%d", args.Size, args.Hashes, args.StorageClass)
 	req, err := http.NewRequest(http.MethodPut, urlStr, args.Body)
 	if err != nil {
 		return
@@ -555,7 +559,7 @@ func (c *client) putParts(ctx context.Context, args *PutArgs) (Location, HashSum
 
 	// alloc
 	allocResp := &AllocResp{}
-	if err := rpcClient.PostWith(ctx, "/alloc", allocResp, AllocArgs{Size: uint64(args.Size)}); err != nil {
+	if err := rpcClient.PostWith(ctx, "/alloc", allocResp, AllocArgs{Size: uint64(args.Size), StorageClass: args.StorageClass}); err != nil {
 		return allocResp.Location, nil, err
 	}
 	loc = allocResp.Location
@@ -786,6 +790,43 @@ func (c *client) Delete(ctx context.Context, args *DeleteArgs) ([]Location, erro
 	return nil, nil
 }
 
+// send alloc request to access server
+// endpoint handler at:  blobstore/access/server.go: func (s *Service) Alloc(c *rpc.Context)
+func (c *client) Alloc(ctx context.Context, args *AllocArgs) (resp AllocResp, err error) {
+	if !args.IsValid() {
+		return resp, errcode.ErrIllegalArguments
+	}
+	rpcClient := c.rpcClient.Load().(rpc.Client)
+
+	ctx = withReqidContext(ctx)
+	err = rpcClient.PostWith(ctx, "/alloc", &resp, args)
+	return
+}
+
+// send putat request to access server
+// endpoint handler at:  blobstore/access/server.go: func (s *Service) PutAt(c *rpc.Context)
+func (c *client) PutAt(ctx context.Context, args *PutAtArgs) (hashSumMap HashSumMap, err error) {
+	if !args.IsValid() {
+		return nil, errcode.ErrIllegalArguments
+	}
+	rpcClient := c.rpcClient.Load().(rpc.Client)
+
+	ctx = withReqidContext(ctx)
+	urlStr := fmt.Sprintf("/putat?clusterid=%d&volumeid=%d&blobid=%d&size=%d&hashes=%d&token=%s",
+		args.ClusterID, args.Vid, args.Blobid, args.Size, args.Hashes, args.Token)
+	req, err := http.NewRequest(http.MethodPut, urlStr, args.Body)
+	if err != nil {
+		return
+	}
+	req.ContentLength = args.Size
+
+	resp := &PutAtResp{}
+	if err = rpcClient.DoWith(ctx, req, resp, rpc.WithCrcEncode()); err == nil {
+		hashSumMap = resp.HashSumMap
+	}
+	return
+}
+
 func shouldRetry(code int, err error) bool {
 	if err != nil {
 		if httpErr, ok := err.(rpc.HTTPError); ok {
@@ -482,10 +482,13 @@ func DecodeLocationFromBase64(s string) (Location, error) {
 // PutArgs for service /put
 // Hashes means how to calculate check sum,
 // HashAlgCRC32 | HashAlgMD5 equal 2 + 4 = 6
+// StorageClass is a hint of media class, default storage class is used
+// if no code mode policy of this storage class matched the size
 type PutArgs struct {
-	Size   int64         `json:"size"`
-	Hashes HashAlgorithm `json:"hashes,omitempty"`
-	Body   io.Reader     `json:"-"`
+	Size         int64                 `json:"size"`
+	Hashes       HashAlgorithm         `json:"hashes,omitempty"`
+	StorageClass codemode.StorageClass `json:"storage_class,omitempty"`
+	Body         io.Reader             `json:"-"`
 }
 
 // IsValid is valid put args
@@ -531,10 +534,11 @@ type PutAtResp struct {
 
 // AllocArgs for service /alloc
 type AllocArgs struct {
-	Size            uint64            `json:"size"`
-	BlobSize        uint32            `json:"blob_size"`
-	AssignClusterID proto.ClusterID   `json:"assign_cluster_id"`
-	CodeMode        codemode.CodeMode `json:"code_mode"`
+	Size            uint64                `json:"size"`
+	BlobSize        uint32                `json:"blob_size"`
+	AssignClusterID proto.ClusterID       `json:"assign_cluster_id"`
+	CodeMode        codemode.CodeMode     `json:"code_mode"`
+	StorageClass    codemode.StorageClass `json:"storage_class"`
 }
 
 // IsValid is valid alloc args
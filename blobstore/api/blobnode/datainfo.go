@@ -17,29 +17,33 @@ package blobnode
 import (
 	"time"
 
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 )
 
 type DiskHeartBeatInfo struct {
-	DiskID       proto.DiskID `json:"disk_id"`
-	Used         int64        `json:"used"`           // disk used space
-	Free         int64        `json:"free"`           // remaining free space on the disk
-	Size         int64        `json:"size"`           // total physical disk space
-	MaxChunkCnt  int64        `json:"max_chunk_cnt"`  // note: maintained by clustermgr
-	FreeChunkCnt int64        `json:"free_chunk_cnt"` // note: maintained by clustermgr
-	UsedChunkCnt int64        `json:"used_chunk_cnt"` // current number of chunks on the disk
+	DiskID             proto.DiskID `json:"disk_id"`
+	Used               int64        `json:"used"`                 // disk used space
+	Free               int64        `json:"free"`                 // remaining free space on the disk
+	Size               int64        `json:"size"`                 // total physical disk space
+	MaxChunkCnt        int64        `json:"max_chunk_cnt"`        // note: maintained by clustermgr
+	FreeChunkCnt       int64        `json:"free_chunk_cnt"`       // note: maintained by clustermgr
+	UsedChunkCnt       int64        `json:"used_chunk_cnt"`       // current number of chunks on the disk
+	CompactingChunkCnt int64        `json:"compacting_chunk_cnt"` // chunks being compacted
+	ReclaimableSpace   int64        `json:"reclaimable_space"`    // space compaction can give back
 }
 
 type DiskInfo struct {
-	ClusterID    proto.ClusterID  `json:"cluster_id"`
-	Idc          string           `json:"idc"`
-	Rack         string           `json:"rack"`
-	Host         string           `json:"host"`
-	Path         string           `json:"path"`
-	Status       proto.DiskStatus `json:"status"` // normal、broken、repairing、repaired、dropped
-	Readonly     bool             `json:"readonly"`
-	CreateAt     time.Time        `json:"create_time"`
-	LastUpdateAt time.Time        `json:"last_update_time"`
+	ClusterID    proto.ClusterID       `json:"cluster_id"`
+	Idc          string                `json:"idc"`
+	Rack         string                `json:"rack"`
+	Host         string                `json:"host"`
+	Path         string                `json:"path"`
+	Status       proto.DiskStatus      `json:"status"` // normal、broken、repairing、repaired、dropped
+	Readonly     bool                  `json:"readonly"`
+	CreateAt     time.Time             `json:"create_time"`
+	LastUpdateAt time.Time             `json:"last_update_time"`
+	StorageClass codemode.StorageClass `json:"storage_class"` // hdd、ssd
 	DiskHeartBeatInfo
 }
 
@@ -48,7 +48,10 @@ type Client struct {
 	lbClient.Client
 }
 
-var _ ClientAPI = (*Client)(nil)
+var (
+	_ ClientAPI = (*Client)(nil)
+	_ APIKV     = (*Client)(nil)
+)
 
 var defaultShouldRetry = func(code int, err error) bool {
 	for i := range retryCodes {
@@ -82,13 +82,15 @@ type DiskStatInfo struct {
 }
 
 type SpaceStatInfo struct {
-	TotalSpace     int64          `json:"total_space"`
-	FreeSpace      int64          `json:"free_space"`
-	UsedSpace      int64          `json:"used_space"`
-	WritableSpace  int64          `json:"writable_space"`
-	TotalBlobNode  int64          `json:"total_blob_node"`
-	TotalDisk      int64          `json:"total_disk"`
-	DisksStatInfos []DiskStatInfo `json:"disk_stat_infos"`
+	TotalSpace         int64          `json:"total_space"`
+	FreeSpace          int64          `json:"free_space"`
+	UsedSpace          int64          `json:"used_space"`
+	WritableSpace      int64          `json:"writable_space"`
+	ReclaimableSpace   int64          `json:"reclaimable_space"` // space compaction can give back
+	CompactingChunkCnt int64          `json:"compacting_chunk_cnt"`
+	TotalBlobNode      int64          `json:"total_blob_node"`
+	TotalDisk          int64          `json:"total_disk"`
+	DisksStatInfos     []DiskStatInfo `json:"disk_stat_infos"`
 }
 
 type DiskAccessArgs struct {
@@ -73,6 +73,14 @@ type APIProxy interface {
 	RegisterService(ctx context.Context, node ServiceNode, tickInterval, heartbeatTicks, expiresTicks uint32) error
 }
 
+// APIKV sub of cluster manager api for kv
+type APIKV interface {
+	GetKV(ctx context.Context, key string) (GetKvRet, error)
+	SetKV(ctx context.Context, key string, value []byte) error
+	DeleteKV(ctx context.Context, key string) error
+	ListKV(ctx context.Context, args *ListKvOpts) (ListKvRet, error)
+}
+
 // APIService sub of cluster manager api for service
 type APIService interface {
 	GetService(ctx context.Context, args GetServiceArgs) (ServiceInfo, error)
@@ -161,6 +161,12 @@ func (cs *chunk) doCompact(ctx context.Context, ncs *chunk) (err error) {
 		// update startBid
 		startBid = blobID
 
+		// yield to foreground io when the disk is busy
+		if err = cs.Disk().CompactThrottle(ctx); err != nil {
+			span.Warnf("compact chunk(%s) throttle stopped: %v", cs.ID(), err)
+			return
+		}
+
 		cs.bidlimiter.Acquire(blobID)
 		defer cs.bidlimiter.Release(blobID)
 
@@ -307,12 +313,19 @@ func (cs *chunk) StopCompact(ctx context.Context, ncs core.ChunkAPI) (err error)
 	return nil
 }
 
+func (cs *chunk) CompactStat(ctx context.Context) (stat core.CompactStat, err error) {
+	stgStat, err := cs.getStg().Stat(ctx)
+	if err != nil {
+		return
+	}
+	stat.FileSize, stat.PhySize = stgStat.FileSize, stgStat.PhySize
+	return
+}
+
 func (cs *chunk) NeedCompact(ctx context.Context) bool {
 	span := trace.SpanFromContextSafe(ctx)
 
-	stg := cs.getStg()
-
-	stat, err := stg.Stat(context.TODO())
+	stat, err := cs.CompactStat(ctx)
 	if err != nil {
 		span.Errorf("get chunk data space info failed: %v", err)
 		return false
@@ -328,7 +341,7 @@ func (cs *chunk) NeedCompact(ctx context.Context) bool {
 	}
 
 	// void rate exceeds threshold
-	if size > cs.conf.CompactMinSizeThreshold && (1-float64(phySize)/float64(size)) >= cs.conf.CompactEmptyRateThreshold {
+	if size > cs.conf.CompactMinSizeThreshold && stat.GarbageRatio() >= cs.conf.CompactEmptyRateThreshold {
 		span.Debugf("phySize:%v/fsize:%v, minSize:%v threshold:%v",
 			phySize, size, cs.conf.CompactMinSizeThreshold, cs.conf.CompactEmptyRateThreshold)
 		return true
@@ -118,6 +118,10 @@ func (mock *diskMock) ListChunks(ctx context.Context) (chunks []core.VuidMeta, e
 func (mock *diskMock) EnqueueCompact(ctx context.Context, vuid proto.Vuid) {
 }
 
+func (mock *diskMock) CompactThrottle(ctx context.Context) (err error) {
+	return
+}
+
 func (mock *diskMock) GcRubbishChunk(ctx context.Context) (mayBeLost []bnapi.ChunkId, err error) {
 	return
 }
@@ -21,6 +21,7 @@ import (
 	cmapi "github.com/cubefs/cubefs/blobstore/api/clustermgr"
 	"github.com/cubefs/cubefs/blobstore/blobnode/base/qos"
 	"github.com/cubefs/cubefs/blobstore/blobnode/db"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 )
 
@@ -42,14 +43,17 @@ const (
 	DefaultCompactTriggerThreshold      = 1 * (1 << 40)   // 1 TiB
 	DefaultMetricReportIntervalS        = 30              // 30 Sec
 	DefaultCompactEmptyRateThreshold    = float64(0.8)    // 80% rate
+	DefaultCompactConcurrency           = 1               // chunks compacted at the same time on one disk
+	DefaultCompactIOBudgetPercent       = 60              // 60% of disk bandwidth and iops
 )
 
 // Config for disk
 type BaseConfig struct {
-	Path        string `json:"path"`
-	AutoFormat  bool   `json:"auto_format"`
-	MaxChunks   int32  `json:"max_chunks"`
-	DisableSync bool   `json:"disable_sync"`
+	Path         string                `json:"path"`
+	AutoFormat   bool                  `json:"auto_format"`
+	MaxChunks    int32                 `json:"max_chunks"`
+	DisableSync  bool                  `json:"disable_sync"`
+	StorageClass codemode.StorageClass `json:"storage_class"`
 }
 
 type RuntimeConfig struct {
@@ -71,6 +75,8 @@ type RuntimeConfig struct {
 	NeedCompactCheck             bool       `json:"need_compact_check"`
 	AllowForceCompact            bool       `json:"allow_force_compact"`
 	CompactBatchSize             int        `json:"compact_batch_size"`
+	CompactConcurrency           int        `json:"compact_concurrency"`       // per disk
+	CompactIOBudgetPercent       int        `json:"compact_io_budget_percent"` // pause compact above it, negative disables
 	MustMountPoint               bool       `json:"must_mount_point"`
 	IOStatFileDryRun             bool       `json:"iostat_file_dryrun"`
 	MetricReportIntervalS        int64      `json:"metric_report_interval_S"`
@@ -106,6 +112,9 @@ func InitConfig(conf *Config) error {
 	if conf.AllocDiskID == nil {
 		return errors.New("allocDiskID is not specified")
 	}
+	if !conf.StorageClass.IsValid() {
+		return errors.New("storageClass is invalid")
+	}
 	if conf.DiskReservedSpaceB <= 0 {
 		conf.DiskReservedSpaceB = DefaultDiskReservedSpaceB
 	}
@@ -156,6 +165,15 @@ func InitConfig(conf *Config) error {
 	if conf.CompactBatchSize <= 0 {
 		conf.CompactBatchSize = DefaultCompactBatchSize
 	}
+	if conf.CompactConcurrency <= 0 {
+		conf.CompactConcurrency = DefaultCompactConcurrency
+	}
+	if conf.CompactIOBudgetPercent == 0 {
+		conf.CompactIOBudgetPercent = DefaultCompactIOBudgetPercent
+	}
+	if conf.CompactIOBudgetPercent > 100 {
+		conf.CompactIOBudgetPercent = 100
+	}
 
 	if conf.MetricReportIntervalS <= 0 {
 		conf.MetricReportIntervalS = DefaultMetricReportIntervalS
@@ -16,8 +16,12 @@ package disk
 
 import (
 	"context"
+	"sort"
+	"sync/atomic"
 	"time"
 
+	"github.com/dustin/go-humanize"
+
 	bnapi "github.com/cubefs/cubefs/blobstore/api/blobnode"
 	cmapi "github.com/cubefs/cubefs/blobstore/api/clustermgr"
 	"github.com/cubefs/cubefs/blobstore/blobnode/base"
@@ -30,6 +34,7 @@ import (
 const (
 	DefaultSetCompactingCnt = 5
 	setCompactInterval      = 100 * time.Millisecond
+	compactThrottleInterval = 100 * time.Millisecond
 )
 
 func (ds *DiskStorage) loopCompactFile() {
@@ -38,23 +43,30 @@ func (ds *DiskStorage) loopCompactFile() {
 	timer := initTimer(ds.Conf.ChunkCompactIntervalSec)
 	defer timer.Stop()
 
-	span.Infof("start compact executor.")
-
-	// consumer
-	ds.loopAttach(func() {
-		for {
-			select {
-			case <-ds.closeCh:
-				span.Warnf("loopCompact done...")
-				return
-			case vuid := <-ds.compactCh:
-				span.Debugf("recv compact message. vuid:[%d]", vuid)
-				if err := ds.ExecCompactChunk(vuid); err != nil {
-					span.Errorf("compact vuid: %d err:%v", vuid, err)
+	concurrency := ds.Conf.CompactConcurrency
+	if concurrency <= 0 {
+		concurrency = core.DefaultCompactConcurrency
+	}
+
+	span.Infof("start %d compact executors.", concurrency)
+
+	// consumer, the number of executors limits compaction concurrency on this disk
+	for i := 0; i < concurrency; i++ {
+		ds.loopAttach(func() {
+			for {
+				select {
+				case <-ds.closeCh:
+					span.Warnf("loopCompact done...")
+					return
+				case vuid := <-ds.compactCh:
+					span.Debugf("recv compact message. vuid:[%d]", vuid)
+					if err := ds.ExecCompactChunk(vuid); err != nil {
+						span.Errorf("compact vuid: %d err:%v", vuid, err)
+					}
 				}
 			}
-		}
-	})
+		})
+	}
 
 	span.Infof("start compact checker.")
 
@@ -71,6 +83,11 @@ func (ds *DiskStorage) loopCompactFile() {
 	}
 }
 
+type compactCandidate struct {
+	vuid  proto.Vuid
+	ratio float64
+}
+
 func (ds *DiskStorage) runCompactFiles() {
 	span, ctx := trace.StartSpanFromContextWithTraceID(context.Background(), "", base.BackgroudReqID("Compact"+ds.Conf.Path))
 
@@ -84,14 +101,37 @@ func (ds *DiskStorage) runCompactFiles() {
 	}
 	ds.Lock.RUnlock()
 
+	reclaimable := int64(0)
+	candidates := make([]compactCandidate, 0)
 	for _, chunk := range chunks {
-		if !chunk.NeedCompact(ctx) {
+		stat, err := chunk.CompactStat(ctx)
+		if err != nil {
+			span.Errorf("get vuid:<%d> compact stat failed: %v", chunk.Vuid(), err)
 			continue
 		}
-		span.Infof("will compact vuid:<%d>", chunk.Vuid())
-		ds.EnqueueCompact(ctx, chunk.Vuid())
-		// Once in a round
-		return
+		reclaimable += stat.Garbage()
+
+		if ds.isCompacting(chunk.Vuid()) || !chunk.NeedCompact(ctx) {
+			continue
+		}
+		candidates = append(candidates, compactCandidate{vuid: chunk.Vuid(), ratio: stat.GarbageRatio()})
+	}
+	atomic.StoreInt64(&ds.reclaimableSpace, reclaimable)
+
+	// the chunk with the most garbage gives back the most space for the same io
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ratio > candidates[j].ratio
+	})
+
+	// fill the idle executors, the rest wait for the next round
+	idle := ds.Conf.CompactConcurrency - int(atomic.LoadInt64(&ds.compactingCnt))
+	for i := 0; i < idle && i < len(candidates); i++ {
+		span.Infof("will compact vuid:<%d> garbage ratio:%.2f", candidates[i].vuid, candidates[i].ratio)
+		select {
+		case ds.compactCh <- candidates[i].vuid:
+		case <-ds.closeCh:
+			return
+		}
 	}
 }
 
@@ -99,6 +139,61 @@ func (ds *DiskStorage) EnqueueCompact(ctx context.Context, vuid proto.Vuid) {
 	ds.compactCh <- vuid
 }
 
+// CompactThrottle blocks while the disk io exceeds the compact budget,
+// so that compaction only uses the bandwidth left by foreground requests
+func (ds *DiskStorage) CompactThrottle(ctx context.Context) (err error) {
+	for ds.overCompactBudget() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ds.closeCh:
+			return core.ErrDiskClosed
+		case <-time.After(compactThrottleInterval):
+		}
+	}
+	return nil
+}
+
+func (ds *DiskStorage) overCompactBudget() bool {
+	if ds.Conf == nil || ds.Conf.CompactIOBudgetPercent <= 0 || ds.Conf.DiskQos.DiskViewer == nil {
+		return false
+	}
+
+	percent := uint64(ds.Conf.CompactIOBudgetPercent)
+	viewer := ds.Conf.DiskQos.DiskViewer
+	rstat, wstat := viewer.ReadStat(), viewer.WriteStat()
+
+	if bandwidth := ds.Conf.DiskQos.DiskBandwidthMBPS; bandwidth > 0 {
+		if (rstat.Bps+wstat.Bps)*100 > uint64(bandwidth)*humanize.MiByte*percent {
+			return true
+		}
+	}
+	if iops := ds.Conf.DiskQos.DiskIOPS; iops > 0 {
+		if (rstat.Iops+wstat.Iops)*100 > uint64(iops)*percent {
+			return true
+		}
+	}
+	return false
+}
+
+func (ds *DiskStorage) isCompacting(vuid proto.Vuid) bool {
+	_, ok := ds.compactingVuids.Load(vuid)
+	return ok
+}
+
+func (ds *DiskStorage) markCompacting(vuid proto.Vuid) bool {
+	if _, loaded := ds.compactingVuids.LoadOrStore(vuid, struct{}{}); loaded {
+		return false
+	}
+	atomic.AddInt64(&ds.compactingCnt, 1)
+	return true
+}
+
+func (ds *DiskStorage) unmarkCompacting(vuid proto.Vuid) {
+	ds.compactingVuids.Delete(vuid)
+	atomic.AddInt64(&ds.compactingCnt, -1)
+}
+
 func (ds *DiskStorage) CompactChunkInternal(ctx context.Context, vuid proto.Vuid) (err error) {
 	span := trace.SpanFromContextSafe(ctx)
 
@@ -204,6 +299,12 @@ func (ds *DiskStorage) ExecCompactChunk(vuid proto.Vuid) (err error) {
 		return bloberr.ErrNoSuchVuid
 	}
 
+	if !ds.markCompacting(vuid) {
+		span.Warnf("chunk(%v) is compacting already", vuid)
+		return bloberr.ErrOverload
+	}
+	defer ds.unmarkCompacting(vuid)
+
 	// Persistent compacting field
 	err = ds.UpdateChunkCompactState(ctx, vuid, true)
 	if err != nil {
@@ -20,15 +20,19 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/stretchr/testify/require"
 
 	bnapi "github.com/cubefs/cubefs/blobstore/api/blobnode"
+	"github.com/cubefs/cubefs/blobstore/blobnode/base/qos"
 	"github.com/cubefs/cubefs/blobstore/blobnode/core"
 	"github.com/cubefs/cubefs/blobstore/blobnode/core/chunk"
 	db2 "github.com/cubefs/cubefs/blobstore/blobnode/db"
+	"github.com/cubefs/cubefs/blobstore/common/iostat"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/common/trace"
 	"github.com/cubefs/cubefs/blobstore/util/log"
@@ -191,3 +195,68 @@ func TestExecCompactChunk(t *testing.T) {
 	err = ds.UpdateChunkCompactState(ctx, proto.Vuid(2011), false)
 	require.Error(t, err)
 }
+
+type mockViewer struct {
+	stat iostat.StatData
+}
+
+func (v *mockViewer) ReadStat() *iostat.StatData  { return &v.stat }
+func (v *mockViewer) WriteStat() *iostat.StatData { return &iostat.StatData{} }
+func (v *mockViewer) Update()                     {}
+func (v *mockViewer) Close()                      {}
+
+func TestCompactThrottle(t *testing.T) {
+	viewer := &mockViewer{}
+	ds := &DiskStorage{
+		Conf: &core.Config{
+			RuntimeConfig: core.RuntimeConfig{
+				CompactIOBudgetPercent: 50,
+				DiskQos: qos.Config{
+					DiskBandwidthMBPS: 100,
+					DiskIOPS:          1000,
+					DiskViewer:        viewer,
+				},
+			},
+		},
+		closeCh: make(chan struct{}),
+	}
+
+	// idle disk
+	require.False(t, ds.overCompactBudget())
+	require.NoError(t, ds.CompactThrottle(context.Background()))
+
+	// bandwidth over budget
+	viewer.stat.Bps = 60 * humanize.MiByte
+	require.True(t, ds.overCompactBudget())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*compactThrottleInterval)
+	defer cancel()
+	require.ErrorIs(t, ds.CompactThrottle(ctx), context.DeadlineExceeded)
+
+	// iops over budget
+	viewer.stat.Bps = 0
+	viewer.stat.Iops = 600
+	require.True(t, ds.overCompactBudget())
+
+	// disk closed while waiting
+	close(ds.closeCh)
+	require.ErrorIs(t, ds.CompactThrottle(context.Background()), core.ErrDiskClosed)
+
+	// budget disabled
+	ds.Conf.CompactIOBudgetPercent = -1
+	require.False(t, ds.overCompactBudget())
+}
+
+func TestCompactingMark(t *testing.T) {
+	ds := &DiskStorage{}
+
+	require.True(t, ds.markCompacting(1))
+	require.False(t, ds.markCompacting(1))
+	require.True(t, ds.markCompacting(2))
+	require.True(t, ds.isCompacting(1))
+	require.Equal(t, int64(2), atomic.LoadInt64(&ds.compactingCnt))
+
+	ds.unmarkCompacting(1)
+	require.False(t, ds.isCompacting(1))
+	require.True(t, ds.markCompacting(1))
+}
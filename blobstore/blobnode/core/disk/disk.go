@@ -90,6 +90,11 @@ type DiskStorage struct {
 	compactCh chan proto.Vuid
 	closeCh   chan struct{}
 
+	// compact status, reported by heartbeat
+	compactingVuids  sync.Map // vuid -> struct{}
+	compactingCnt    int64    // atomic
+	reclaimableSpace int64    // atomic
+
 	// ctx is used for initiated requests that
 	// may need to be canceled on server shutdown.
 	wg  sync.WaitGroup
@@ -174,6 +179,8 @@ func (ds *DiskStorage) DiskInfo() (info bnapi.DiskInfo) {
 	// stats
 	info.Used = stats.Used
 	info.UsedChunkCnt = int64(len(ds.Chunks))
+	info.CompactingChunkCnt = atomic.LoadInt64(&ds.compactingCnt)
+	info.ReclaimableSpace = atomic.LoadInt64(&ds.reclaimableSpace)
 	// for chunk space
 	info.Free = stats.Free - stats.Reserved
 	if info.Free < 0 {
@@ -193,6 +200,7 @@ func (ds *DiskStorage) DiskInfo() (info bnapi.DiskInfo) {
 	info.Rack = hostInfo.Rack
 	info.Host = hostInfo.Host
 	info.Path = ds.Conf.Path
+	info.StorageClass = ds.Conf.StorageClass
 
 	// status
 	info.Status = ds.status
@@ -16,6 +16,7 @@ package disk
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -65,6 +66,8 @@ func (ds *DiskStorage) metricReport(ctx context.Context) {
 		span.Errorf("diskID:%v, err:%v", err)
 		return
 	}
+	statsMetrics["compacting_chunk_cnt"] = float64(atomic.LoadInt64(&ds.compactingCnt))
+	statsMetrics["reclaimable_space"] = float64(atomic.LoadInt64(&ds.reclaimableSpace))
 
 	for item, value := range statsMetrics {
 		DiskStatMetric.With(prometheus.Labels{
@@ -67,6 +67,28 @@ type StorageStat struct {
 	CreateTime int64         `json:"create_time"`
 }
 
+// CompactStat describes the space a chunk would give back after compaction
+type CompactStat struct {
+	FileSize int64 `json:"file_size"` // logical size of the chunk file
+	PhySize  int64 `json:"phy_size"`  // physical space actually occupied
+}
+
+// Garbage returns bytes held by deleted shards
+func (s CompactStat) Garbage() int64 {
+	if s.FileSize <= s.PhySize {
+		return 0
+	}
+	return s.FileSize - s.PhySize
+}
+
+// GarbageRatio returns the share of the chunk file that is garbage
+func (s CompactStat) GarbageRatio() float64 {
+	if s.FileSize <= 0 {
+		return 0
+	}
+	return float64(s.Garbage()) / float64(s.FileSize)
+}
+
 type MetaHandler interface {
 	ID() bnapi.ChunkId
 	InnerDB() db.MetaHandler
@@ -140,6 +162,7 @@ type ChunkAPI interface {
 	CommitCompact(ctx context.Context, ncs ChunkAPI) (err error)
 	StopCompact(ctx context.Context, ncs ChunkAPI) (err error)
 	NeedCompact(ctx context.Context) bool
+	CompactStat(ctx context.Context) (stat CompactStat, err error)
 	IsDirty() bool
 	IsClosed() bool
 	AllowModify() (err error)
@@ -168,6 +191,7 @@ type DiskAPI interface {
 	UpdateChunkCompactState(ctx context.Context, vuid proto.Vuid, compacting bool) (err error)
 	ListChunks(ctx context.Context) (chunks []VuidMeta, err error)
 	EnqueueCompact(ctx context.Context, vuid proto.Vuid)
+	CompactThrottle(ctx context.Context) (err error)
 	GcRubbishChunk(ctx context.Context) (mayBeLost []bnapi.ChunkId, err error)
 	WalkChunksWithLock(ctx context.Context, fn func(cs ChunkAPI) error) (err error)
 	ResetChunks(ctx context.Context)
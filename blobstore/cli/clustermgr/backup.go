@@ -0,0 +1,251 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clustermgr
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/desertbit/grumble"
+
+	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/cli/common"
+	"github.com/cubefs/cubefs/blobstore/cli/common/fmt"
+	"github.com/cubefs/cubefs/blobstore/cli/config"
+	"github.com/cubefs/cubefs/blobstore/clustermgr/base"
+	"github.com/cubefs/cubefs/blobstore/clustermgr/persistence/kvdb"
+	"github.com/cubefs/cubefs/blobstore/clustermgr/persistence/normaldb"
+	"github.com/cubefs/cubefs/blobstore/clustermgr/persistence/volumedb"
+	"github.com/cubefs/cubefs/blobstore/common/kvstore"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+)
+
+// snapshot db name of clustermgr to db path under db_path
+var backupDBPaths = map[string]string{
+	"normal":   "normaldb",
+	"volume":   "volumedb",
+	"keyValue": "kvdb",
+}
+
+func addCmdBackup(cmd *grumble.Command) {
+	cmd.AddCommand(&grumble.Command{
+		Name:     "backup",
+		Help:     "backup clustermgr metadata",
+		LongHelp: "backup all clustermgr metadata at a raft index into a checksummed archive",
+		Run:      cmdBackup,
+		Args: func(a *grumble.Args) {
+			a.String("archive", "backup archive file")
+		},
+		Flags: func(f *grumble.Flags) {
+			clusterFlags(f)
+		},
+	})
+
+	cmd.AddCommand(&grumble.Command{
+		Name: "restore",
+		Help: "restore clustermgr metadata",
+		LongHelp: "restore clustermgr metadata from backup archive into empty db_path,\n" +
+			"  restore the same archive on all nodes to bootstrap a new raft group,\n" +
+			"  the new raft group starts with members in clustermgr config and empty raft wal",
+		Run: cmdRestore,
+		Args: func(a *grumble.Args) {
+			a.String("archive", "backup archive file")
+			a.String("db_path", "clustermgr db_path", grumble.Default(""))
+		},
+		Flags: func(f *grumble.Flags) {
+			f.BoolL("verify", false, "only verify the archive")
+		},
+	})
+}
+
+func cmdBackup(c *grumble.Context) error {
+	archive := c.Args.String("archive")
+	if archive == "" {
+		return errors.New("invalid command arguments")
+	}
+	clusterID := config.DefaultClusterID()
+	if id := c.Flags.String("cluster_id"); id != "" {
+		cid, err := strconv.Atoi(id)
+		if err != nil {
+			return err
+		}
+		clusterID = cid
+	}
+
+	cli := newCMClient(c.Flags)
+	resp, err := cli.Snapshot(common.CmdContext())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 206 {
+		return fmt.Errorf("dump snapshot failed, status: %d", resp.StatusCode)
+	}
+	index, err := strconv.ParseUint(resp.Header.Get(clustermgr.RaftSnapshotIndexHeaderKey), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot index, err: %s", err.Error())
+	}
+
+	f, err := os.OpenFile(archive, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := base.BackupHeader{
+		ClusterID:    proto.ClusterID(clusterID),
+		Index:        index,
+		SnapshotName: resp.Header.Get(clustermgr.RaftSnapshotNameHeaderKey),
+		Source:       resp.Request.URL.Host,
+		CreateTime:   time.Now().Unix(),
+	}
+	w := bufio.NewWriter(f)
+	bw, err := base.NewBackupWriter(w, header)
+	if err != nil {
+		return err
+	}
+	body := bufio.NewReader(resp.Body)
+	for {
+		data, err := base.DecodeSnapshotData(body)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("read snapshot failed, err: %s", err.Error())
+		}
+		if err = bw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err = bw.Close(); err != nil {
+		return err
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	fmt.Printf("backup cluster(%d) at index(%d) from %s, records: %d\n",
+		header.ClusterID, header.Index, header.Source, bw.Count())
+	return nil
+}
+
+func cmdRestore(c *grumble.Context) error {
+	archive := c.Args.String("archive")
+	dbPath := c.Args.String("db_path")
+	verify := c.Flags.Bool("verify")
+	if archive == "" || (dbPath == "" && !verify) {
+		return errors.New("invalid command arguments")
+	}
+
+	// verify the whole archive before writing anything
+	header, count, err := rangeBackup(archive, func(*base.SnapshotData) error { return nil })
+	if err != nil {
+		return err
+	}
+	fmt.Printf("archive of cluster(%d) at index(%d) from %s created at %s, records: %d\n",
+		header.ClusterID, header.Index, header.Source,
+		time.Unix(header.CreateTime, 0).Format(time.RFC3339), count)
+	if verify {
+		return nil
+	}
+
+	for _, name := range backupDBPaths {
+		path := filepath.Join(dbPath, name)
+		entries, err := os.ReadDir(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if len(entries) > 0 {
+			return fmt.Errorf("db path %s is not empty", path)
+		}
+	}
+	if !common.Confirm(fmt.Sprintf("restore into %s?", dbPath)) {
+		fmt.Println("command canceled")
+		return nil
+	}
+
+	createIfMissing := func(option *kvstore.RocksDBOption) {
+		option.CreateIfMissing = true
+	}
+	normalDB, err := normaldb.OpenNormalDB(filepath.Join(dbPath, backupDBPaths["normal"]), false, createIfMissing)
+	if err != nil {
+		return err
+	}
+	defer normalDB.Close()
+	volumeDB, err := volumedb.Open(filepath.Join(dbPath, backupDBPaths["volume"]), false, createIfMissing)
+	if err != nil {
+		return err
+	}
+	defer volumeDB.Close()
+	kvDB, err := kvdb.Open(filepath.Join(dbPath, backupDBPaths["keyValue"]), false, createIfMissing)
+	if err != nil {
+		return err
+	}
+	defer kvDB.Close()
+
+	dbs := map[string]base.SnapshotDB{"normal": normalDB, "volume": volumeDB, "keyValue": kvDB}
+	_, _, err = rangeBackup(archive, func(data *base.SnapshotData) error {
+		db, ok := dbs[data.Header.DbName]
+		if !ok {
+			return fmt.Errorf("unknown db %s in archive", data.Header.DbName)
+		}
+		kv := kvstore.KV{Key: data.Key, Value: data.Value}
+		if data.Header.CfName != "" {
+			return db.Table(data.Header.CfName).Put(kv)
+		}
+		return db.Put(kv)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("restored %d records into %s\n", count, dbPath)
+	return nil
+}
+
+func rangeBackup(archive string, fn func(*base.SnapshotData) error) (header base.BackupHeader, count uint64, err error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	rd, err := base.NewBackupReader(f)
+	if err != nil {
+		return
+	}
+	header = rd.Header()
+	for {
+		var data *base.SnapshotData
+		data, err = rd.Next()
+		if err != nil {
+			if err == io.EOF {
+				return header, rd.Count(), nil
+			}
+			return
+		}
+		if err = fn(data); err != nil {
+			return
+		}
+	}
+}
@@ -76,6 +76,8 @@ func DiskHeartBeatInfoF(info *blobnode.DiskHeartBeatInfo) []string {
 			info.DiskID, info.MaxChunkCnt, info.UsedChunkCnt, info.FreeChunkCnt),
 		fmt.Sprintf("Size  : %-24s | Used: %-24s | Free: %-24s",
 			humanIBytes(info.Size), humanIBytes(info.Used), humanIBytes(info.Free)),
+		fmt.Sprintf("CompactingN: %-8d | Reclaimable: %-24s",
+			info.CompactingChunkCnt, humanIBytes(info.ReclaimableSpace)),
 	}
 }
 
@@ -119,6 +121,8 @@ func DiskInfoFV(info *blobnode.DiskInfo) []string {
 			info.MaxChunkCnt, info.UsedChunkCnt, info.FreeChunkCnt),
 		fmt.Sprintf("Size     : %-24s | Used: %-24s | Free: %-24s",
 			humanIBytes(info.Size), humanIBytes(info.Used), humanIBytes(info.Free)),
+		fmt.Sprintf("Compact  : CompactingN: %-12d | Reclaimable: %-24s",
+			info.CompactingChunkCnt, humanIBytes(info.ReclaimableSpace)),
 		fmt.Sprint("ClusterID: ", info.ClusterID),
 		fmt.Sprint("IDC      : ", info.Idc),
 		fmt.Sprint("Rack     : ", info.Rack),
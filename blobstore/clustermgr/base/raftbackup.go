@@ -0,0 +1,218 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/util/errors"
+)
+
+// backup archive layout, all integers are big endian:
+//   magic(8) | version(4) | header size(4) | header json
+//   record flag(1) | snapshot data ... | end flag(1)
+//   record count(8) | crc32 of all bytes before record count(4)
+const (
+	BackupVersion = uint32(1)
+
+	backupMagic      = "CMBACKUP"
+	backupFlagRecord = byte(1)
+	backupFlagEnd    = byte(0)
+)
+
+var (
+	ErrBackupMagic    = errors.New("invalid backup magic")
+	ErrBackupVersion  = errors.New("unsupported backup version")
+	ErrBackupChecksum = errors.New("backup checksum mismatch")
+	ErrBackupCorrupt  = errors.New("backup corrupted")
+)
+
+// BackupHeader describes the clustermgr state in backup archive,
+// all data in archive is applied at raft index Index
+type BackupHeader struct {
+	Version      uint32          `json:"version"`
+	ClusterID    proto.ClusterID `json:"cluster_id"`
+	Index        uint64          `json:"index"`
+	SnapshotName string          `json:"snapshot_name"`
+	Source       string          `json:"source"`
+	CreateTime   int64           `json:"create_time"`
+}
+
+// BackupWriter writes snapshot data into a backup archive
+type BackupWriter struct {
+	raw   io.Writer
+	w     io.Writer
+	crc   hash.Hash32
+	count uint64
+}
+
+func NewBackupWriter(w io.Writer, header BackupHeader) (*BackupWriter, error) {
+	crc := crc32.NewIEEE()
+	bw := &BackupWriter{raw: w, w: io.MultiWriter(w, crc), crc: crc}
+
+	header.Version = BackupVersion
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = bw.w.Write([]byte(backupMagic)); err != nil {
+		return nil, err
+	}
+	if err = binary.Write(bw.w, binary.BigEndian, header.Version); err != nil {
+		return nil, err
+	}
+	if err = binary.Write(bw.w, binary.BigEndian, uint32(len(data))); err != nil {
+		return nil, err
+	}
+	if _, err = bw.w.Write(data); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+func (bw *BackupWriter) Write(data *SnapshotData) error {
+	one, err := EncodeSnapshotData(data)
+	if err != nil {
+		return err
+	}
+	if _, err = bw.w.Write([]byte{backupFlagRecord}); err != nil {
+		return err
+	}
+	if _, err = bw.w.Write(one); err != nil {
+		return err
+	}
+	bw.count++
+	return nil
+}
+
+// Count returns the number of written records
+func (bw *BackupWriter) Count() uint64 {
+	return bw.count
+}
+
+// Close writes the end of archive, it does not close the underlying writer
+func (bw *BackupWriter) Close() error {
+	if _, err := bw.w.Write([]byte{backupFlagEnd}); err != nil {
+		return err
+	}
+	// trailer is not in checksum
+	if err := binary.Write(bw.raw, binary.BigEndian, bw.count); err != nil {
+		return err
+	}
+	return binary.Write(bw.raw, binary.BigEndian, bw.crc.Sum32())
+}
+
+// BackupReader reads snapshot data from a backup archive,
+// the checksum is verified after the last record was read
+type BackupReader struct {
+	r      io.Reader
+	br     *bufio.Reader
+	crc    hash.Hash32
+	header BackupHeader
+	count  uint64
+	done   bool
+}
+
+func NewBackupReader(r io.Reader) (*BackupReader, error) {
+	crc := crc32.NewIEEE()
+	br := bufio.NewReader(r)
+	rd := &BackupReader{r: io.TeeReader(br, crc), br: br, crc: crc}
+
+	magic := make([]byte, len(backupMagic))
+	if _, err := io.ReadFull(rd.r, magic); err != nil {
+		return nil, err
+	}
+	if string(magic) != backupMagic {
+		return nil, ErrBackupMagic
+	}
+	var version, size uint32
+	if err := binary.Read(rd.r, binary.BigEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != BackupVersion {
+		return nil, ErrBackupVersion
+	}
+	if err := binary.Read(rd.r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(rd.r, data); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &rd.header); err != nil {
+		return nil, errors.Base(ErrBackupCorrupt, "unmarshal header", err)
+	}
+	return rd, nil
+}
+
+func (rd *BackupReader) Header() BackupHeader {
+	return rd.header
+}
+
+// Count returns the number of read records
+func (rd *BackupReader) Count() uint64 {
+	return rd.count
+}
+
+// Next returns the next snapshot data, io.EOF means all data was read and verified
+func (rd *BackupReader) Next() (*SnapshotData, error) {
+	if rd.done {
+		return nil, io.EOF
+	}
+
+	flag := make([]byte, 1)
+	if _, err := io.ReadFull(rd.r, flag); err != nil {
+		return nil, errors.Base(ErrBackupCorrupt, "read flag", err)
+	}
+	switch flag[0] {
+	case backupFlagRecord:
+		data, err := DecodeSnapshotData(rd.r)
+		if err != nil {
+			return nil, errors.Base(ErrBackupCorrupt, "decode record", err)
+		}
+		rd.count++
+		return data, nil
+	case backupFlagEnd:
+	default:
+		return nil, ErrBackupCorrupt
+	}
+
+	// trailer is not in checksum, read it from buffer directly
+	sum := rd.crc.Sum32()
+	var (
+		count uint64
+		crc   uint32
+	)
+	if err := binary.Read(rd.br, binary.BigEndian, &count); err != nil {
+		return nil, errors.Base(ErrBackupCorrupt, "read count", err)
+	}
+	if err := binary.Read(rd.br, binary.BigEndian, &crc); err != nil {
+		return nil, errors.Base(ErrBackupCorrupt, "read checksum", err)
+	}
+	if crc != sum {
+		return nil, ErrBackupChecksum
+	}
+	if count != rd.count {
+		return nil, ErrBackupCorrupt
+	}
+	rd.done = true
+	return nil, io.EOF
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupReadWrite(t *testing.T) {
+	header := BackupHeader{ClusterID: 1, Index: 100, SnapshotName: "snapshot-1", Source: "127.0.0.1:9998"}
+	datas := []*SnapshotData{
+		{Header: SnapshotItem{DbName: "normal", CfName: "disk"}, Key: []byte("k1"), Value: []byte("v1")},
+		{Header: SnapshotItem{DbName: "keyValue"}, Key: []byte("k2"), Value: []byte{}},
+		{Header: SnapshotItem{DbName: "volume", CfName: "volume"}, Key: []byte("k3"), Value: []byte("v3")},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	bw, err := NewBackupWriter(buf, header)
+	require.NoError(t, err)
+	for _, data := range datas {
+		require.NoError(t, bw.Write(data))
+	}
+	require.NoError(t, bw.Close())
+	require.Equal(t, uint64(len(datas)), bw.Count())
+	archive := buf.Bytes()
+
+	rd, err := NewBackupReader(bytes.NewReader(archive))
+	require.NoError(t, err)
+	header.Version = BackupVersion
+	require.Equal(t, header, rd.Header())
+	for _, data := range datas {
+		one, err := rd.Next()
+		require.NoError(t, err)
+		require.Equal(t, data.Header, one.Header)
+		require.Equal(t, data.Key, one.Key)
+		require.Equal(t, len(data.Value), len(one.Value))
+	}
+	_, err = rd.Next()
+	require.Equal(t, io.EOF, err)
+	_, err = rd.Next()
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, uint64(len(datas)), rd.Count())
+
+	// invalid magic
+	_, err = NewBackupReader(bytes.NewReader([]byte("NOBACKUP0000")))
+	require.ErrorIs(t, err, ErrBackupMagic)
+
+	// corrupted data
+	corrupted := append([]byte{}, archive...)
+	corrupted[len(corrupted)-20] ^= 0xff
+	rd, err = NewBackupReader(bytes.NewReader(corrupted))
+	require.NoError(t, err)
+	for err == nil {
+		_, err = rd.Next()
+	}
+	require.ErrorIs(t, err, ErrBackupChecksum)
+
+	// truncated data
+	rd, err = NewBackupReader(bytes.NewReader(archive[:len(archive)-6]))
+	require.NoError(t, err)
+	for err == nil {
+		_, err = rd.Next()
+	}
+	require.ErrorIs(t, err, ErrBackupCorrupt)
+}
@@ -169,7 +169,7 @@ func DecodeSnapshotData(reader io.Reader) (ret *SnapshotData, err error) {
 		return
 	}
 	dbName := make([]byte, dbNameSize)
-	if _, err = reader.Read(dbName); err != nil {
+	if _, err = io.ReadFull(reader, dbName); err != nil {
 		return
 	}
 	_ret.Header.DbName = string(dbName)
@@ -178,7 +178,7 @@ func DecodeSnapshotData(reader io.Reader) (ret *SnapshotData, err error) {
 		return
 	}
 	cfName := make([]byte, cfNameSize)
-	if _, err = reader.Read(cfName); err != nil {
+	if _, err = io.ReadFull(reader, cfName); err != nil {
 		return
 	}
 	_ret.Header.CfName = string(cfName)
@@ -188,7 +188,7 @@ func DecodeSnapshotData(reader io.Reader) (ret *SnapshotData, err error) {
 		return
 	}
 	key := make([]byte, keySize)
-	if _, err = reader.Read(key); err != nil {
+	if _, err = io.ReadFull(reader, key); err != nil {
 		return
 	}
 	_ret.Key = key
@@ -198,7 +198,7 @@ func DecodeSnapshotData(reader io.Reader) (ret *SnapshotData, err error) {
 		return
 	}
 	value := make([]byte, valueSize)
-	if _, err = reader.Read(value); err != nil {
+	if _, err = io.ReadFull(reader, value); err != nil {
 		return
 	}
 	_ret.Value = value
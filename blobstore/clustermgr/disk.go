@@ -68,6 +68,11 @@ func (s *Service) DiskAdd(c *rpc.Context) {
 		c.RespondError(apierrors.ErrIllegalArguments)
 		return
 	}
+	if !args.StorageClass.IsValid() {
+		span.Warnf("invalid storage class %d", args.StorageClass)
+		c.RespondError(apierrors.ErrIllegalArguments)
+		return
+	}
 	for i := range s.IDC {
 		if args.Idc == s.IDC[i] {
 			break
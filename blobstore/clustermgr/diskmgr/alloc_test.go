@@ -127,7 +127,7 @@ func TestAlloc(t *testing.T) {
 
 		// alloc from not enough space, alloc should return ErrNoEnoughSpace
 		for _, idc := range testIdcs {
-			allocator := testDiskMgr.allocators[idc].Load().(*idcStorage)
+			allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, idc)].Load().(*idcStorage)
 			_, err := allocator.alloc(ctx, 9, nil)
 			require.Equal(t, ErrNoEnoughSpace, err)
 		}
@@ -135,7 +135,7 @@ func TestAlloc(t *testing.T) {
 		// alloc with diff rack
 		testDiskMgr.RackAware = true
 		testDiskMgr.refresh(ctx)
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		_, err := allocator.alloc(ctx, 9, nil)
 		require.Equal(t, ErrNoEnoughSpace, err)
 	}
@@ -147,7 +147,7 @@ func TestAlloc(t *testing.T) {
 		testDiskMgr.HostAware = false
 		testDiskMgr.RackAware = false
 		testDiskMgr.refresh(ctx)
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		ret, err := allocator.alloc(ctx, 9, nil)
 		require.NoError(t, err)
 		require.Equal(t, 9, len(ret))
@@ -163,7 +163,7 @@ func TestAlloc(t *testing.T) {
 		testDiskMgr.RackAware = false
 		testDiskMgr.refresh(ctx)
 		// alloc from enough space
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		ret, err := allocator.alloc(ctx, 9, nil)
 		require.NoError(t, err)
 		require.Equal(t, 9, len(ret))
@@ -172,7 +172,7 @@ func TestAlloc(t *testing.T) {
 		_, ctx = trace.StartSpanFromContext(context.Background(), "alloc-diff-race")
 		testDiskMgr.RackAware = true
 		testDiskMgr.refresh(ctx)
-		allocator = testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator = testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		ret, err = allocator.alloc(ctx, 9, nil)
 		require.NoError(t, err)
 		require.Equal(t, 9, len(ret))
@@ -239,7 +239,7 @@ func TestAllocWithSameHost(t *testing.T) {
 
 		// alloc from not enough space, alloc should return ErrNoEnoughSpace
 		for _, idc := range testIdcs {
-			allocator := testDiskMgr.allocators[idc].Load().(*idcStorage)
+			allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, idc)].Load().(*idcStorage)
 			_, err := allocator.alloc(ctx, 11, nil)
 			require.Equal(t, ErrNoEnoughSpace, err)
 		}
@@ -250,7 +250,7 @@ func TestAllocWithSameHost(t *testing.T) {
 		initTestDiskMgrDisks(t, testDiskMgr, 11, 12, testIdcs...)
 		_, ctx = trace.StartSpanFromContext(context.Background(), "alloc-same-host-not-enough")
 		testDiskMgr.refresh(ctx)
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		ret, err := allocator.alloc(ctx, 12, nil)
 		require.NoError(t, err)
 		require.Equal(t, 12, len(ret))
@@ -269,7 +269,7 @@ func TestAllocWithSameHost(t *testing.T) {
 		testDiskMgr.metaLock.RUnlock()
 		testDiskMgr.refresh(ctx)
 		defaultAllocTolerateBuff = 0
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		for i := 1; i <= 10; i++ {
 			diskIDs, err := allocator.alloc(ctx, 12, nil)
 			require.NoError(t, err)
@@ -295,7 +295,7 @@ func TestAllocWithSameHost(t *testing.T) {
 		testDiskMgr.metaLock.RUnlock()
 		testDiskMgr.refresh(ctx)
 		defaultAllocTolerateBuff = 0
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		for i := 1; i <= 10; i++ {
 			diskIDs, err := allocator.alloc(ctx, 1, map[proto.DiskID]*diskItem{
 				1: testDiskMgr.allDisks[1],
@@ -355,7 +355,7 @@ func TestAllocWithDiffRack(t *testing.T) {
 		testDiskMgr.RackAware = true
 		testDiskMgr.refresh(ctx)
 		// alloc from not enough rack, but enough data node, it should be successful
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		diskIDs, err := allocator.alloc(ctx, 10, nil)
 		require.NoError(t, err)
 		require.Equal(t, 10, len(diskIDs))
@@ -371,7 +371,7 @@ func TestAllocWithDiffRack(t *testing.T) {
 		testDiskMgr.metaLock.RUnlock()
 		testDiskMgr.refresh(ctx)
 		defaultAllocTolerateBuff = 0
-		allocator = testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator = testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		for i := 1; i <= 10; i++ {
 			diskIDs, err := allocator.alloc(ctx, 10, nil)
 			require.NoError(t, err)
@@ -411,7 +411,7 @@ func TestAllocWithDiffHost(t *testing.T) {
 		testDiskMgr.HostAware = true
 		testDiskMgr.RackAware = false
 		testDiskMgr.refresh(ctx)
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		diskIDs, err := allocator.alloc(ctx, 10, nil)
 		require.NoError(t, err)
 		require.Equal(t, 10, len(diskIDs))
@@ -427,7 +427,7 @@ func TestAllocWithDiffHost(t *testing.T) {
 		testDiskMgr.metaLock.RUnlock()
 		testDiskMgr.refresh(ctx)
 		defaultAllocTolerateBuff = 0
-		allocator = testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator = testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		for i := 1; i <= 10; i++ {
 			diskIDs, err := allocator.alloc(ctx, 10, nil)
 			require.NoError(t, err)
@@ -475,7 +475,7 @@ func TestAllocWithDiffRackAndSameHost(t *testing.T) {
 		testDiskMgr.HostAware = false
 		testDiskMgr.RackAware = true
 		testDiskMgr.refresh(ctx)
-		allocator := testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		diskIDs, err := allocator.alloc(ctx, 10, nil)
 		require.NoError(t, err)
 		require.Equal(t, 10, len(diskIDs))
@@ -491,7 +491,7 @@ func TestAllocWithDiffRackAndSameHost(t *testing.T) {
 		testDiskMgr.metaLock.RUnlock()
 		testDiskMgr.refresh(ctx)
 		defaultAllocTolerateBuff = 0
-		allocator = testDiskMgr.allocators[testIdcs[0]].Load().(*idcStorage)
+		allocator = testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
 		for i := 1; i <= 10; i++ {
 			diskIDs, err := allocator.alloc(ctx, 10, nil)
 			require.NoError(t, err)
@@ -517,7 +517,7 @@ func TestAllocCost(t *testing.T) {
 	initTestDiskMgrDisks(t, testDiskMgr, 1, 18000, testIdcs[0])
 	// refresh cluster's disk space allocator
 	testDiskMgr.refresh(ctx)
-	allocator := testDiskMgr.allocators["z0"].Load().(*idcStorage)
+	allocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, "z0")].Load().(*idcStorage)
 
 	wg := sync.WaitGroup{}
 	start := time.Now()
@@ -533,3 +533,44 @@ func TestAllocCost(t *testing.T) {
 	wg.Wait()
 	t.Log("op cost:", time.Since(start)/time.Duration(totalTimes))
 }
+
+func TestAllocWithStorageClass(t *testing.T) {
+	testDiskMgr, closeTestDiskMgr := initTestDiskMgr(t)
+	defer closeTestDiskMgr()
+	// disk never expire
+	testDiskMgr.HeartbeatExpireIntervalS = 6000
+
+	_, ctx := trace.StartSpanFromContext(context.Background(), "")
+	initTestDiskMgrDisks(t, testDiskMgr, 1, 600, testIdcs[0])
+	for i := 301; i <= 600; i++ {
+		disk, ok := testDiskMgr.getDisk(proto.DiskID(i))
+		require.True(t, ok)
+		disk.info.StorageClass = codemode.StorageClassSSD
+	}
+	testDiskMgr.refresh(ctx)
+
+	hddAllocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassHDD, testIdcs[0])].Load().(*idcStorage)
+	ssdAllocator := testDiskMgr.allocators[genAllocatorKey(codemode.StorageClassSSD, testIdcs[0])].Load().(*idcStorage)
+	require.Equal(t, len(hddAllocator.blobNodeStorages), len(ssdAllocator.blobNodeStorages))
+
+	ret, err := ssdAllocator.alloc(ctx, 3, nil)
+	require.NoError(t, err)
+	for _, diskID := range ret {
+		disk, _ := testDiskMgr.getDisk(diskID)
+		require.Equal(t, codemode.StorageClassSSD, disk.info.StorageClass)
+	}
+	ret, err = hddAllocator.alloc(ctx, 3, nil)
+	require.NoError(t, err)
+	for _, diskID := range ret {
+		disk, _ := testDiskMgr.getDisk(diskID)
+		require.Equal(t, codemode.StorageClassHDD, disk.info.StorageClass)
+	}
+
+	// no ssd disk in other idc
+	_, err = testDiskMgr.AllocChunks(ctx, &AllocPolicy{
+		Idc:          testIdcs[1],
+		StorageClass: codemode.StorageClassSSD,
+		Vuids:        []proto.Vuid{1},
+	})
+	require.Equal(t, ErrNoEnoughSpace, err)
+}
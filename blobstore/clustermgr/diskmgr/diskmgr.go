@@ -86,9 +86,10 @@ type DiskMgrAPI interface {
 }
 
 type AllocPolicy struct {
-	Idc      string
-	Vuids    []proto.Vuid
-	Excludes []proto.DiskID
+	Idc          string
+	StorageClass codemode.StorageClass
+	Vuids        []proto.Vuid
+	Excludes     []proto.DiskID
 }
 
 type HeartbeatEvent struct {
@@ -206,8 +207,10 @@ func New(scopeMgr scopemgr.ScopeMgrAPI, db *normaldb.NormalDB, cfg DiskMgrConfig
 	}
 
 	allocators := make(map[string]*atomic.Value)
-	for _, idc := range cfg.IDC {
-		allocators[idc] = &atomic.Value{}
+	for _, storageClass := range codemode.GetAllStorageClasses() {
+		for _, idc := range cfg.IDC {
+			allocators[genAllocatorKey(storageClass, idc)] = &atomic.Value{}
+		}
 	}
 
 	dm := &DiskMgr{
@@ -404,7 +407,11 @@ func (d *DiskMgr) ListDroppingDisk(ctx context.Context) ([]*blobnode.DiskInfo, e
 // AllocChunk return available chunks in data center
 func (d *DiskMgr) AllocChunks(ctx context.Context, policy *AllocPolicy) (ret []proto.DiskID, err error) {
 	span, ctx := trace.StartSpanFromContextWithTraceID(ctx, "AllocChunks", trace.SpanFromContextSafe(ctx).TraceID()+"/"+policy.Idc)
-	v := d.allocators[policy.Idc].Load()
+	allocatorValue, ok := d.allocators[genAllocatorKey(policy.StorageClass, policy.Idc)]
+	if !ok {
+		return nil, ErrNoEnoughSpace
+	}
+	v := allocatorValue.Load()
 	if v == nil {
 		return nil, ErrNoEnoughSpace
 	}
@@ -510,6 +517,8 @@ func (d *DiskMgr) ListDiskInfo(ctx context.Context, opt *clustermgr.ListOptionAr
 		diskInfo.Free = disk.info.Free
 		diskInfo.Used = disk.info.Used
 		diskInfo.Size = disk.info.Size
+		diskInfo.CompactingChunkCnt = disk.info.CompactingChunkCnt
+		diskInfo.ReclaimableSpace = disk.info.ReclaimableSpace
 		disk.lock.RUnlock()
 
 		ret.Disks = append(ret.Disks, diskInfo)
@@ -681,6 +690,8 @@ func (d *DiskMgr) heartBeatDiskInfo(ctx context.Context, infos []*blobnode.DiskH
 		diskInfo.info.Size = info.Size
 		diskInfo.info.Used = info.Used
 		diskInfo.info.UsedChunkCnt = info.UsedChunkCnt
+		diskInfo.info.CompactingChunkCnt = info.CompactingChunkCnt
+		diskInfo.info.ReclaimableSpace = info.ReclaimableSpace
 		// calculate free and max chunk count
 		diskInfo.info.MaxChunkCnt = info.Size / d.ChunkSize
 		// use the minimum value as free chunk count
@@ -701,6 +712,11 @@ func (d *DiskMgr) heartBeatDiskInfo(ctx context.Context, infos []*blobnode.DiskH
 	return nil
 }
 
+// genAllocatorKey return allocator key of idc with specified storage class
+func genAllocatorKey(storageClass codemode.StorageClass, idc string) string {
+	return storageClass.String() + "-" + idc
+}
+
 func (d *DiskMgr) getDisk(diskID proto.DiskID) (disk *diskItem, exist bool) {
 	d.metaLock.RLock()
 	disk, exist = d.allDisks[diskID]
@@ -762,6 +778,7 @@ func diskInfoToDiskInfoRecord(info *blobnode.DiskInfo) *normaldb.DiskInfoRecord
 		Free:         info.Free,
 		MaxChunkCnt:  info.MaxChunkCnt,
 		FreeChunkCnt: info.FreeChunkCnt,
+		StorageClass: info.StorageClass,
 	}
 }
 
@@ -776,6 +793,7 @@ func diskInfoRecordToDiskInfo(infoDB *normaldb.DiskInfoRecord) *blobnode.DiskInf
 		Readonly:     infoDB.Readonly,
 		CreateAt:     infoDB.CreateAt,
 		LastUpdateAt: infoDB.LastUpdateAt,
+		StorageClass: infoDB.StorageClass,
 		DiskHeartBeatInfo: blobnode.DiskHeartBeatInfo{
 			DiskID:       infoDB.DiskID,
 			Used:         infoDB.Used,
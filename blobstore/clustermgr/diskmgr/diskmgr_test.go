@@ -159,6 +159,8 @@ func TestDiskMgr_Heartbeat(t *testing.T) {
 		require.NoError(t, err)
 		diskInfo.DiskHeartBeatInfo.Free = 0
 		diskInfo.DiskHeartBeatInfo.FreeChunkCnt = 0
+		diskInfo.DiskHeartBeatInfo.CompactingChunkCnt = 1
+		diskInfo.DiskHeartBeatInfo.ReclaimableSpace = int64(i) << 30
 		heartbeatInfos = append(heartbeatInfos, &diskInfo.DiskHeartBeatInfo)
 	}
 	err := testDiskMgr.heartBeatDiskInfo(ctx, heartbeatInfos)
@@ -170,8 +172,16 @@ func TestDiskMgr_Heartbeat(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, diskInfo.Free/testDiskMgr.ChunkSize, diskInfo.FreeChunkCnt)
 		require.Equal(t, int64(0), diskInfo.Free)
+		require.Equal(t, int64(1), diskInfo.CompactingChunkCnt)
+		require.Equal(t, int64(i)<<30, diskInfo.ReclaimableSpace)
 	}
 
+	// compaction status sums up in space stat
+	testDiskMgr.refresh(ctx)
+	stat := testDiskMgr.Stat(ctx)
+	require.Equal(t, int64(10), stat.CompactingChunkCnt)
+	require.Equal(t, int64(55)<<30, stat.ReclaimableSpace)
+
 	// get heartbeat change disk
 	disks := testDiskMgr.GetHeartbeatChangeDisks()
 	require.Equal(t, 0, len(disks))
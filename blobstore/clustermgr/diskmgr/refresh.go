@@ -61,10 +61,12 @@ func (d *DiskMgr) refresh(ctx context.Context) {
 	span.Info("all disk length: ", len(allDisks))
 
 	blobNodeStgs := make(map[string]*blobNodeStorage)
+	blobNodes := make(map[string]struct{})
 
-	idcFreeChunks := make(map[string]int64)
-	idcRackStgs := make(map[string]map[string]*rackStorage)
-	idcBlobNodeStgs := make(map[string][]*blobNodeStorage)
+	// idc free chunks, rack storages and blobnode storages are grouped by storage class
+	idcFreeChunks := make(map[codemode.StorageClass]map[string]int64)
+	idcRackStgs := make(map[codemode.StorageClass]map[string]map[string]*rackStorage)
+	idcBlobNodeStgs := make(map[codemode.StorageClass]map[string][]*blobNodeStorage)
 
 	rackBlobNodeStgs := make(map[string][]*blobNodeStorage)
 	rackFreeChunks := make(map[string]int64)
@@ -82,8 +84,15 @@ func (d *DiskMgr) refresh(ctx context.Context) {
 		size := disk.info.Size
 		free := disk.info.Free
 		status := disk.info.Status
+		storageClass := disk.info.StorageClass
+		compactingChunk := disk.info.CompactingChunkCnt
+		reclaimable := disk.info.ReclaimableSpace
 		// rack can be the same in different idc, so we make rack string with idc
 		rack = idc + "-" + rack
+		// disks with different storage class in the same rack or host
+		// should be allocated by different allocator
+		rackKey := storageClass.String() + "-" + rack
+		hostKey := storageClass.String() + "-" + host
 
 		spaceStatInfo.TotalDisk += 1
 		// idc disk status num calculate
@@ -106,6 +115,11 @@ func (d *DiskMgr) refresh(ctx context.Context) {
 		if disk.dropping {
 			diskStatInfosM[idc].Dropping += 1
 		}
+		// readonly disk also gives back space by compaction
+		if status == proto.DiskStatusNormal {
+			spaceStatInfo.ReclaimableSpace += reclaimable
+			spaceStatInfo.CompactingChunkCnt += compactingChunk
+		}
 		// filter unavailable disk
 		if !disk.isAvailable() {
 			disk.lock.RUnlock()
@@ -124,57 +138,70 @@ func (d *DiskMgr) refresh(ctx context.Context) {
 		disk.lock.RUnlock()
 
 		// build for idcRackStorage
-		if _, ok := idcRackStgs[idc]; !ok {
-			idcRackStgs[idc] = make(map[string]*rackStorage)
+		if _, ok := idcRackStgs[storageClass]; !ok {
+			idcRackStgs[storageClass] = make(map[string]map[string]*rackStorage)
+			idcBlobNodeStgs[storageClass] = make(map[string][]*blobNodeStorage)
+			idcFreeChunks[storageClass] = make(map[string]int64)
+		}
+		if _, ok := idcRackStgs[storageClass][idc]; !ok {
+			idcRackStgs[storageClass][idc] = make(map[string]*rackStorage)
 		}
-		if _, ok := idcRackStgs[idc][rack]; !ok {
-			idcRackStgs[idc][rack] = &rackStorage{rack: rack}
+		if _, ok := idcRackStgs[storageClass][idc][rackKey]; !ok {
+			idcRackStgs[storageClass][idc][rackKey] = &rackStorage{rack: rack}
 		}
 		// build for idcStorage
-		if _, ok := idcBlobNodeStgs[idc]; !ok {
-			idcBlobNodeStgs[idc] = make([]*blobNodeStorage, 0)
-			idcFreeChunks[idc] = 0
+		if _, ok := idcBlobNodeStgs[storageClass][idc]; !ok {
+			idcBlobNodeStgs[storageClass][idc] = make([]*blobNodeStorage, 0)
+			idcFreeChunks[storageClass][idc] = 0
 		}
-		idcFreeChunks[idc] += freeChunk
+		idcFreeChunks[storageClass][idc] += freeChunk
 		// build for rackStorage
-		if _, ok := rackBlobNodeStgs[rack]; !ok {
-			rackBlobNodeStgs[rack] = make([]*blobNodeStorage, 0)
-			rackFreeChunks[rack] = 0
+		if _, ok := rackBlobNodeStgs[rackKey]; !ok {
+			rackBlobNodeStgs[rackKey] = make([]*blobNodeStorage, 0)
+			rackFreeChunks[rackKey] = 0
 		}
-		rackFreeChunks[rack] += freeChunk
+		rackFreeChunks[rackKey] += freeChunk
 		// build for blobNodeStorage
-		if _, ok := blobNodeStgs[host]; !ok {
-			blobNodeStgs[host] = &blobNodeStorage{host: host, disks: make([]*diskItem, 0)}
+		if _, ok := blobNodeStgs[hostKey]; !ok {
+			blobNodeStgs[hostKey] = &blobNodeStorage{host: host, disks: make([]*diskItem, 0)}
 			// append idc data node
-			idcBlobNodeStgs[idc] = append(idcBlobNodeStgs[idc], blobNodeStgs[host])
+			idcBlobNodeStgs[storageClass][idc] = append(idcBlobNodeStgs[storageClass][idc], blobNodeStgs[hostKey])
 			// append rack data node
-			rackBlobNodeStgs[rack] = append(rackBlobNodeStgs[rack], blobNodeStgs[host])
+			rackBlobNodeStgs[rackKey] = append(rackBlobNodeStgs[rackKey], blobNodeStgs[hostKey])
 		}
-		blobNodeStgs[host].disks = append(blobNodeStgs[host].disks, disk)
-		blobNodeStgs[host].freeChunk += freeChunk
-		blobNodeStgs[host].free += free
+		blobNodeStgs[hostKey].disks = append(blobNodeStgs[hostKey].disks, disk)
+		blobNodeStgs[hostKey].freeChunk += freeChunk
+		blobNodeStgs[hostKey].free += free
+		blobNodes[host] = struct{}{}
 	}
 	span.Debugf("all blobNodeStgs: %+v", blobNodeStgs)
 
-	for _, rackStgs := range idcRackStgs {
-		for rack := range rackStgs {
-			rackStgs[rack].freeChunk = rackFreeChunks[rack]
-			rackStgs[rack].blobNodeStorages = rackBlobNodeStgs[rack]
+	for storageClass := range idcRackStgs {
+		for _, rackStgs := range idcRackStgs[storageClass] {
+			for rackKey := range rackStgs {
+				rackStgs[rackKey].freeChunk = rackFreeChunks[rackKey]
+				rackStgs[rackKey].blobNodeStorages = rackBlobNodeStgs[rackKey]
+			}
 		}
-	}
-	for idc := range idcBlobNodeStgs {
-		span.Infof("%s idcBlobNodeStgs length: %d", idc, len(idcBlobNodeStgs[idc]))
-	}
-	// writable space statistic
-	d.calculateWritable(spaceStatInfo, idcBlobNodeStgs)
+		for idc := range idcBlobNodeStgs[storageClass] {
+			span.Infof("%s %s idcBlobNodeStgs length: %d", storageClass, idc, len(idcBlobNodeStgs[storageClass][idc]))
+		}
+		// writable space statistic
+		d.calculateWritable(spaceStatInfo, idcBlobNodeStgs[storageClass])
 
-	if len(idcRackStgs) > 0 {
 		// atomic store idc allocator
 		for i := range d.IDC {
-			spaceStatInfo.TotalBlobNode += int64(len(idcBlobNodeStgs[d.IDC[i]]))
-			d.allocators[d.IDC[i]].Store(&idcStorage{idc: d.IDC[i], freeChunk: idcFreeChunks[d.IDC[i]], diffRack: d.RackAware, diffHost: d.HostAware, rackStorages: idcRackStgs[d.IDC[i]], blobNodeStorages: idcBlobNodeStgs[d.IDC[i]]})
+			d.allocators[genAllocatorKey(storageClass, d.IDC[i])].Store(&idcStorage{
+				idc:              d.IDC[i],
+				freeChunk:        idcFreeChunks[storageClass][d.IDC[i]],
+				diffRack:         d.RackAware,
+				diffHost:         d.HostAware,
+				rackStorages:     idcRackStgs[storageClass][d.IDC[i]],
+				blobNodeStorages: idcBlobNodeStgs[storageClass][d.IDC[i]],
+			})
 		}
 	}
+	spaceStatInfo.TotalBlobNode = int64(len(blobNodes))
 	for idc := range diskStatInfosM {
 		spaceStatInfo.DisksStatInfos = append(spaceStatInfo.DisksStatInfos, *diskStatInfosM[idc])
 	}
@@ -226,7 +253,7 @@ func (d *DiskMgr) calculateWritable(spaceStatInfo *clustermgr.SpaceStatInfo, idc
 				minimumStripeCount = n
 			}
 		}
-		spaceStatInfo.WritableSpace = minimumStripeCount * int64(codeMode.Tactic().N) * d.ChunkSize
+		spaceStatInfo.WritableSpace += minimumStripeCount * int64(codeMode.Tactic().N) * d.ChunkSize
 		return
 	}
 	if len(idcBlobNodeStgs) > 0 {
@@ -240,7 +267,7 @@ func (d *DiskMgr) calculateWritable(spaceStatInfo *clustermgr.SpaceStatInfo, idc
 				minimumChunkNum = idcChunkNum
 			}
 		}
-		spaceStatInfo.WritableSpace = minimumChunkNum / int64(idcSuCount) * int64(codeMode.Tactic().N) * d.ChunkSize
+		spaceStatInfo.WritableSpace += minimumChunkNum / int64(idcSuCount) * int64(codeMode.Tactic().N) * d.ChunkSize
 	}
 }
 
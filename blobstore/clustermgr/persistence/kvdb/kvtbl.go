@@ -59,8 +59,13 @@ func (t *KvTable) List(args *clustermgr.ListKvOpts) ([]*clustermgr.KeyValue, err
 		seekKey = args.Marker
 	}
 	iter.Seek([]byte(seekKey))
+	// marker is exclusive, skip it only if it exists
 	if args.Marker != "" && iter.Valid() {
-		iter.Next()
+		key := iter.Key()
+		if string(key.Data()) == args.Marker {
+			iter.Next()
+		}
+		key.Free()
 	}
 
 	ret := make([]*clustermgr.KeyValue, 0, 1000)
@@ -126,7 +126,26 @@ func TestKvTable(t *testing.T) {
 		require.Equal(t, len(ret), listCases[i].Count)
 	}
 
-	err := kvTbl.Delete([]byte(putCases[1].key))
+	// marker not exists
+	ret, err := kvTbl.List(&clustermgr.ListKvOpts{
+		Prefix: "repair-100-10000-",
+		Marker: "repair-100-10000-15",
+		Count:  10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(ret))
+	require.Equal(t, putCases[1].key, ret[0].Key)
+	// marker exists
+	ret, err = kvTbl.List(&clustermgr.ListKvOpts{
+		Prefix: "repair-100-10000-",
+		Marker: putCases[0].key,
+		Count:  10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(ret))
+	require.Equal(t, putCases[2].key, ret[0].Key)
+
+	err = kvTbl.Delete([]byte(putCases[1].key))
 	require.NoError(t, err)
 
 	err = kvTbl.Delete([]byte("not exist key"))
@@ -136,7 +155,7 @@ func TestKvTable(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, []byte(nil), v)
 
-	ret, err := kvTbl.List(&clustermgr.ListKvOpts{
+	ret, err = kvTbl.List(&clustermgr.ListKvOpts{
 		Prefix: "repair-100-",
 		Marker: "",
 		Count:  4,
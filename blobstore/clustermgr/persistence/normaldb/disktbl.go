@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/common/codemode"
 	"github.com/cubefs/cubefs/blobstore/common/kvstore"
 	"github.com/cubefs/cubefs/blobstore/common/proto"
 	"github.com/cubefs/cubefs/blobstore/util/errors"
@@ -42,23 +43,24 @@ var (
 )
 
 type DiskInfoRecord struct {
-	Version      uint8            `json:"-"`
-	DiskID       proto.DiskID     `json:"disk_id"`
-	ClusterID    proto.ClusterID  `json:"cluster_id"`
-	Idc          string           `json:"idc"`
-	Rack         string           `json:"rack"`
-	Host         string           `json:"host"`
-	Path         string           `json:"path"`
-	Status       proto.DiskStatus `json:"status"`
-	Readonly     bool             `json:"readonly"`
-	MaxChunkCnt  int64            `json:"max_chunk_cnt"`
-	FreeChunkCnt int64            `json:"free_chunk_cnt"`
-	UsedChunkCnt int64            `json:"used_chunk_cnt"`
-	CreateAt     time.Time        `json:"create_time"`
-	LastUpdateAt time.Time        `json:"last_update_time"`
-	Size         int64            `json:"size"`
-	Used         int64            `json:"used"`
-	Free         int64            `json:"free"`
+	Version      uint8                 `json:"-"`
+	DiskID       proto.DiskID          `json:"disk_id"`
+	ClusterID    proto.ClusterID       `json:"cluster_id"`
+	Idc          string                `json:"idc"`
+	Rack         string                `json:"rack"`
+	Host         string                `json:"host"`
+	Path         string                `json:"path"`
+	Status       proto.DiskStatus      `json:"status"`
+	Readonly     bool                  `json:"readonly"`
+	MaxChunkCnt  int64                 `json:"max_chunk_cnt"`
+	FreeChunkCnt int64                 `json:"free_chunk_cnt"`
+	UsedChunkCnt int64                 `json:"used_chunk_cnt"`
+	CreateAt     time.Time             `json:"create_time"`
+	LastUpdateAt time.Time             `json:"last_update_time"`
+	Size         int64                 `json:"size"`
+	Used         int64                 `json:"used"`
+	Free         int64                 `json:"free"`
+	StorageClass codemode.StorageClass `json:"storage_class"`
 }
 
 type DiskTable struct {
@@ -402,14 +402,21 @@ func (c *Config) checkAndFix() (err error) {
 		return c.CodeModePolicies[i].MinSize < c.CodeModePolicies[j].MinSize
 	})
 	sortedPolicies := make([]codemode.Policy, 0)
+	// size range of enabled policies is checked within each storage class
+	classPolicies := make(map[codemode.StorageClass][]codemode.Policy)
 	for i := range c.CodeModePolicies {
+		if !c.CodeModePolicies[i].StorageClass.IsValid() {
+			return errors.New("invalid code mode storage class")
+		}
 		if c.CodeModePolicies[i].Enable {
 			sortedPolicies = append(sortedPolicies, c.CodeModePolicies[i])
+			storageClass := c.CodeModePolicies[i].StorageClass
+			classPolicies[storageClass] = append(classPolicies[storageClass], c.CodeModePolicies[i])
 		}
 	}
 	if len(sortedPolicies) > 0 {
-		if sortedPolicies[0].MinSize != 0 {
-			return errors.New("min size range must be started with 0")
+		if _, ok := classPolicies[codemode.StorageClassHDD]; !ok {
+			return errors.New("default storage class policy must be enabled")
 		}
 	} else {
 		for _, modePolicy := range c.CodeModePolicies {
@@ -417,9 +424,14 @@ func (c *Config) checkAndFix() (err error) {
 			c.DiskMgrConfig.CodeModes = append(c.DiskMgrConfig.CodeModes, codeMode)
 		}
 	}
-	for i := 0; i < len(sortedPolicies)-1; i++ {
-		if sortedPolicies[i+1].MinSize != sortedPolicies[i].MaxSize+1 {
-			return errors.New("size range must be serially")
+	for _, policies := range classPolicies {
+		if policies[0].MinSize != 0 {
+			return errors.New("min size range must be started with 0")
+		}
+		for i := 0; i < len(policies)-1; i++ {
+			if policies[i+1].MinSize != policies[i].MaxSize+1 {
+				return errors.New("size range must be serially")
+			}
 		}
 	}
 
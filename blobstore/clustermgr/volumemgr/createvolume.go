@@ -282,7 +282,7 @@ func (v *VolumeMgr) allocChunkForAllUnits(ctx context.Context, vol *CreateVolume
 		span.Debugf("start alloc chunk for volume unit,volume is %#v", vol)
 		go func(ctx context.Context, idc string, idcUnits map[proto.VuidPrefix]*clustermgr.VolumeUnitInfo) {
 			defer wg.Done()
-			err := v.allocChunkForIdcUnits(ctx, idc, idcVuInfos)
+			err := v.allocChunkForIdcUnits(ctx, idc, codeInfo.storageClass, idcVuInfos)
 			span.Debugf("alloc chunk in idc:%v, error is %#v", idc, err)
 			errChan <- err
 		}(ctx, availableIDC[i], idcVuInfos)
@@ -300,7 +300,8 @@ func (v *VolumeMgr) allocChunkForAllUnits(ctx context.Context, vol *CreateVolume
 }
 
 // alloc chunk for each idc unit
-func (v *VolumeMgr) allocChunkForIdcUnits(ctx context.Context, idc string, vuInfos map[proto.VuidPrefix]*clustermgr.VolumeUnitInfo) (err error) {
+func (v *VolumeMgr) allocChunkForIdcUnits(ctx context.Context, idc string, storageClass codemode.StorageClass,
+	vuInfos map[proto.VuidPrefix]*clustermgr.VolumeUnitInfo) (err error) {
 	span := trace.SpanFromContextSafe(ctx)
 	vuids := make([]proto.Vuid, 0, len(vuInfos))
 	excludes := make([]proto.DiskID, 0)
@@ -309,8 +310,9 @@ func (v *VolumeMgr) allocChunkForIdcUnits(ctx context.Context, idc string, vuInf
 		vuids = append(vuids, vuInfo.Vuid)
 	}
 	policy := &diskmgr.AllocPolicy{
-		Idc:   idc,
-		Vuids: vuids,
+		Idc:          idc,
+		StorageClass: storageClass,
+		Vuids:        vuids,
 	}
 
 	// Notice: retryTime should never large than IncreaseEpochInterval
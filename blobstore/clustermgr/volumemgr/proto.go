@@ -190,10 +190,11 @@ func (tok *token) String() string {
 }
 
 type codeModeConf struct {
-	mode      codemode.CodeMode
-	tactic    codemode.Tactic
-	sizeRatio float64
-	enable    bool
+	mode         codemode.CodeMode
+	tactic       codemode.Tactic
+	sizeRatio    float64
+	enable       bool
+	storageClass codemode.StorageClass
 }
 
 func newShardedVolumes(sliceMapNum uint32) *shardedVolumes {
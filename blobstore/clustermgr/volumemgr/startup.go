@@ -132,10 +132,11 @@ func NewVolumeMgr(conf VolumeMgrConfig, diskMgr diskmgr.DiskMgrAPI, scopeMgr sco
 	for _, policy := range conf.CodeModePolicies {
 		codeMode := policy.ModeName.GetCodeMode()
 		modeConf := codeModeConf{
-			mode:      codeMode,
-			sizeRatio: policy.SizeRatio,
-			tactic:    codeMode.Tactic(),
-			enable:    policy.Enable,
+			mode:         codeMode,
+			sizeRatio:    policy.SizeRatio,
+			tactic:       codeMode.Tactic(),
+			enable:       policy.Enable,
+			storageClass: policy.StorageClass,
 		}
 		volumeMgr.codeMode[codeMode] = modeConf
 	}
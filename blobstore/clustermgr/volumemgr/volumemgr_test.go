@@ -376,7 +376,7 @@ func TestVolumeMgr_AllocChunkForIdcUnits(t *testing.T) {
 	})
 	_, ctx := trace.StartSpanFromContext(context.Background(), "allocChunkForIdc")
 	mockVolumeMgr.diskMgr = mockDiskMgr
-	mockVolumeMgr.allocChunkForIdcUnits(ctx, "z1", vuInfos)
+	mockVolumeMgr.allocChunkForIdcUnits(ctx, "z1", codemode.StorageClassHDD, vuInfos)
 	for i := range vuInfos {
 		require.Equal(t, vuInfos[i].DiskID, proto.DiskID(9999))
 	}
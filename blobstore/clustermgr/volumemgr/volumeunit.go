@@ -99,7 +99,13 @@ func (v *VolumeMgr) AllocVolumeUnit(ctx context.Context, vuid proto.Vuid) (*cmap
 		return nil, errors.Info(err, "get disk info failed").Detail(err)
 	}
 
-	policy := &diskmgr.AllocPolicy{Idc: diskInfo.Idc, Vuids: []proto.Vuid{newVuid.(proto.Vuid)}, Excludes: excludes}
+	// new volume unit should be placed on disk with the same storage class
+	policy := &diskmgr.AllocPolicy{
+		Idc:          diskInfo.Idc,
+		StorageClass: diskInfo.StorageClass,
+		Vuids:        []proto.Vuid{newVuid.(proto.Vuid)},
+		Excludes:     excludes,
+	}
 	allocDiskID, err := v.diskMgr.AllocChunks(ctx, policy)
 	if err != nil {
 		return nil, errors.Info(err, "alloc chunk failed").Detail(err)
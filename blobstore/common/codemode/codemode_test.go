@@ -221,6 +221,20 @@ func TestLocalStripeInAZ(t *testing.T) {
 	}
 }
 
+func TestCodeModeStorageClass(t *testing.T) {
+	classes := GetAllStorageClasses()
+	require.Equal(t, int(StorageClassMax), len(classes))
+	for _, sc := range classes {
+		require.True(t, sc.IsValid())
+		require.NotEqual(t, "unknown", sc.String())
+	}
+	require.False(t, StorageClassMax.IsValid())
+	require.Equal(t, "unknown", StorageClassMax.String())
+
+	var policy Policy
+	require.Equal(t, StorageClassHDD, policy.StorageClass)
+}
+
 func BenchmarkGlobalStripe(b *testing.B) {
 	tactic := EC16P20L2.Tactic()
 	for ii := 0; ii < b.N; ii++ {
@@ -14,6 +14,43 @@
 
 package codemode
 
+// StorageClass media class of disk, volumes of one code mode
+// are only placed on disks with the same storage class
+type StorageClass uint8
+
+// storage class
+const (
+	StorageClassHDD = StorageClass(iota) // 0, default storage class
+	StorageClassSSD                      // 1
+	StorageClassMax                      // 2
+)
+
+// IsValid check the storage class is valid
+func (sc StorageClass) IsValid() bool {
+	return sc < StorageClassMax
+}
+
+// String turn the storage class to string
+func (sc StorageClass) String() string {
+	switch sc {
+	case StorageClassHDD:
+		return "hdd"
+	case StorageClassSSD:
+		return "ssd"
+	default:
+		return "unknown"
+	}
+}
+
+// GetAllStorageClasses returns all storage classes
+func GetAllStorageClasses() []StorageClass {
+	classes := make([]StorageClass, 0, StorageClassMax)
+	for sc := StorageClassHDD; sc < StorageClassMax; sc++ {
+		classes = append(classes, sc)
+	}
+	return classes
+}
+
 // Policy will be used to adjust code mode's upload range or code mode's volume ratio and so on
 type Policy struct {
 	ModeName CodeModeName `json:"mode_name"`
@@ -27,4 +64,7 @@ type Policy struct {
 	// access/allocator will ignore this kind of code mode's allocation when enable is false
 	// clustermgr will ignore this kind of code mode's creation when enable is false
 	Enable bool `json:"enable"`
+	// storage class of disks which this kind of code mode's volume created on,
+	// size range of enabled policies must be serially within the same storage class
+	StorageClass StorageClass `json:"storage_class"`
 }
@@ -31,7 +31,7 @@ var proxyStatusMetric = prometheus.NewGaugeVec(
 		Name:      "volume_status",
 		Help:      "proxy status about allocator",
 	},
-	[]string{"service", "cluster", "idc", "codemode", "type"},
+	[]string{"service", "cluster", "idc", "codemode", "storage_class", "type"},
 )
 
 func init() {
@@ -61,21 +61,23 @@ func (v *volumeMgr) metricReport(ctx context.Context) {
 		volNums := len(vols)
 		proxyStatusMetric.With(
 			prometheus.Labels{
-				"service":  "PROXY",
-				"cluster":  strconv.FormatUint(uint64(v.ClusterID), 10),
-				"idc":      v.Idc,
-				"codemode": codeMode.String(),
-				"type":     "volume_nums",
+				"service":       "PROXY",
+				"cluster":       strconv.FormatUint(uint64(v.ClusterID), 10),
+				"idc":           v.Idc,
+				"codemode":      codeMode.String(),
+				"storage_class": modeInfo.storageClass.String(),
+				"type":          "volume_nums",
 			}).Set(float64(volNums))
 		proxyStatusMetric.With(
 			prometheus.Labels{
-				"service":  "PROXY",
-				"cluster":  strconv.FormatUint(uint64(v.ClusterID), 10),
-				"idc":      v.Idc,
-				"codemode": codeMode.String(),
-				"type":     "total_free_size",
+				"service":       "PROXY",
+				"cluster":       strconv.FormatUint(uint64(v.ClusterID), 10),
+				"idc":           v.Idc,
+				"codemode":      codeMode.String(),
+				"storage_class": modeInfo.storageClass.String(),
+				"type":          "total_free_size",
 			}).Set(float64(modeInfo.totalFree))
-		span.Debugf("metric report total_free_size: %v, idc: %v, codemode: %v,", float64(modeInfo.totalFree),
-			v.Idc, codeMode.String())
+		span.Debugf("metric report total_free_size: %v, idc: %v, codemode: %v, storage class: %v", float64(modeInfo.totalFree),
+			v.Idc, codeMode.String(), modeInfo.storageClass.String())
 	}
 }
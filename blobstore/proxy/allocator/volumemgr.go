@@ -57,6 +57,8 @@ type ModeInfo struct {
 	volumes        *volumes
 	totalThreshold uint64
 	totalFree      uint64
+	// volumes of this code mode are all placed on disks of the storage class
+	storageClass codemode.StorageClass
 }
 
 type allocArgs struct {
@@ -170,9 +172,11 @@ func (v *volumeMgr) initModeInfo(ctx context.Context) (err error) {
 		modeInfo := &ModeInfo{
 			volumes:        &volumes{},
 			totalThreshold: uint64(threshold),
+			storageClass:   codeModeConfig.StorageClass,
 		}
 		v.modeInfos[codeMode] = modeInfo
-		span.Infof("code_mode: %v, initVolumeNum: %v, threshold: %v", codeModeConfig.ModeName, v.InitVolumeNum, threshold)
+		span.Infof("code_mode: %v, storage_class: %v, initVolumeNum: %v, threshold: %v",
+			codeModeConfig.ModeName, codeModeConfig.StorageClass, v.InitVolumeNum, threshold)
 	}
 
 	for mode := range v.allocChs {
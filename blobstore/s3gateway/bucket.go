@@ -0,0 +1,199 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3gateway
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+)
+
+const maxXMLBodySize = 2 << 20
+
+// ListBuckets list all buckets
+func (s *Service) ListBuckets(c *rpc.Context) {
+	ctx := c.Request.Context()
+	buckets, err := s.meta.ListBuckets(ctx)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	result := ListBucketsResult{
+		Xmlns:   xmlNamespace,
+		Owner:   Owner{ID: serviceName, DisplayName: serviceName},
+		Buckets: make([]Bucket, 0, len(buckets)),
+	}
+	for _, bucket := range buckets {
+		result.Buckets = append(result.Buckets, Bucket{
+			Name:         bucket.Name,
+			CreationDate: formatTime(bucket.CreateTime),
+		})
+	}
+	respondXML(c, result)
+}
+
+// PutBucket create a bucket
+func (s *Service) PutBucket(c *rpc.Context) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	bucket := bucketParam(c)
+	if !isValidBucketName(bucket) {
+		respondError(c, ErrInvalidBucketName)
+		return
+	}
+	// CreateBucketConfiguration is ignored
+	io.Copy(ioutil.Discard, io.LimitReader(c.Request.Body, maxXMLBodySize))
+	if err := verifyPayload(c.Request); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	_, err := s.meta.GetBucket(ctx, bucket)
+	if err == nil {
+		respondError(c, ErrBucketAlreadyExists)
+		return
+	}
+	if err != errNotFound {
+		respondError(c, err)
+		return
+	}
+
+	if err = s.meta.PutBucket(ctx, &BucketMeta{Name: bucket, CreateTime: time.Now().Unix()}); err != nil {
+		respondError(c, err)
+		return
+	}
+	span.Infof("bucket %s created", bucket)
+	c.Writer.Header().Set("Location", "/"+bucket)
+	c.Respond()
+}
+
+// HeadBucket check the bucket exists
+func (s *Service) HeadBucket(c *rpc.Context) {
+	if err := s.checkBucket(c, bucketParam(c)); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Respond()
+}
+
+// DeleteBucket delete the empty bucket
+func (s *Service) DeleteBucket(c *rpc.Context) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	bucket := bucketParam(c)
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	empty, err := s.meta.IsBucketEmpty(ctx, bucket)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if !empty {
+		respondError(c, ErrBucketNotEmpty)
+		return
+	}
+	if err = s.meta.DeleteBucket(ctx, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+	span.Infof("bucket %s deleted", bucket)
+	c.RespondStatus(http.StatusNoContent)
+}
+
+// GetBucket list objects, list multipart uploads or get location of the bucket
+func (s *Service) GetBucket(c *rpc.Context) {
+	bucket := bucketParam(c)
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	switch {
+	case hasQuery(c, "uploads"):
+		s.listMultipartUploads(c, bucket)
+	case hasQuery(c, "location"):
+		respondXML(c, LocationResult{Xmlns: xmlNamespace, Location: s.config.Region})
+	case c.Request.URL.Query().Get("list-type") == "2":
+		s.listObjectsV2(c, bucket)
+	default:
+		s.listObjects(c, bucket)
+	}
+}
+
+// PostBucket delete multiple objects
+func (s *Service) PostBucket(c *rpc.Context) {
+	bucket := bucketParam(c)
+	if !hasQuery(c, "delete") {
+		respondError(c, ErrNotImplemented)
+		return
+	}
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+	s.deleteObjects(c, bucket)
+}
+
+func (s *Service) checkBucket(c *rpc.Context, bucket string) error {
+	if !isValidBucketName(bucket) {
+		return ErrNoSuchBucket
+	}
+	_, err := s.meta.GetBucket(c.Request.Context(), bucket)
+	if err == errNotFound {
+		return ErrNoSuchBucket
+	}
+	return err
+}
+
+func parseXMLBody(c *rpc.Context, obj interface{}) error {
+	data, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, maxXMLBodySize))
+	if err != nil {
+		return err
+	}
+	if err = verifyPayload(c.Request); err != nil {
+		return err
+	}
+	if err = xml.Unmarshal(data, obj); err != nil {
+		return ErrMalformedXML
+	}
+	return nil
+}
+
+// parseMaxKeys parse max keys argument in range [0, defaultListCount],
+// nothing is listed if it's 0
+func parseMaxKeys(c *rpc.Context, name string) (int, error) {
+	val := c.Request.URL.Query().Get(name)
+	if val == "" {
+		return defaultListCount, nil
+	}
+	maxKeys, err := strconv.Atoi(val)
+	if err != nil || maxKeys < 0 {
+		return 0, ErrInvalidArgument
+	}
+	if maxKeys > defaultListCount {
+		maxKeys = defaultListCount
+	}
+	return maxKeys, nil
+}
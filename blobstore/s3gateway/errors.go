@@ -0,0 +1,69 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// APIError s3 error with http status code
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// defined s3 errors
+var (
+	ErrAccessDenied          = &APIError{http.StatusForbidden, "AccessDenied", "Access Denied."}
+	ErrInvalidAccessKeyID    = &APIError{http.StatusForbidden, "InvalidAccessKeyId", "The access key Id you provided does not exist in our records."}
+	ErrSignatureDoesNotMatch = &APIError{http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided."}
+	ErrExpiredToken          = &APIError{http.StatusBadRequest, "ExpiredToken", "The provided token has expired."}
+	ErrAuthorizationHeader   = &APIError{http.StatusBadRequest, "AuthorizationHeaderMalformed", "The authorization header you provided is invalid."}
+	ErrRequestTimeTooSkewed  = &APIError{http.StatusForbidden, "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large."}
+	ErrContentSHA256Mismatch = &APIError{http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed."}
+	ErrInvalidBucketName     = &APIError{http.StatusBadRequest, "InvalidBucketName", "The specified bucket is not valid."}
+	ErrInvalidObjectName     = &APIError{http.StatusBadRequest, "InvalidObjectName", "The specified object name is not valid."}
+	ErrBucketAlreadyExists   = &APIError{http.StatusConflict, "BucketAlreadyOwnedByYou", "The bucket you tried to create already exists."}
+	ErrBucketNotEmpty        = &APIError{http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty."}
+	ErrNoSuchBucket          = &APIError{http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist."}
+	ErrNoSuchKey             = &APIError{http.StatusNotFound, "NoSuchKey", "The specified key does not exist."}
+	ErrNoSuchUpload          = &APIError{http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist."}
+	ErrInvalidPart           = &APIError{http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found."}
+	ErrInvalidPartOrder      = &APIError{http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order."}
+	ErrEntityTooSmall        = &APIError{http.StatusBadRequest, "EntityTooSmall", "Your proposed upload is smaller than the minimum allowed object size."}
+	ErrEntityTooLarge        = &APIError{http.StatusBadRequest, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed object size."}
+	ErrMissingContentLength  = &APIError{http.StatusLengthRequired, "MissingContentLength", "You must provide the Content-Length HTTP header."}
+	ErrIncompleteBody        = &APIError{http.StatusBadRequest, "IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header."}
+	ErrInvalidArgument       = &APIError{http.StatusBadRequest, "InvalidArgument", "Invalid Argument."}
+	ErrInvalidRange          = &APIError{http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable."}
+	ErrMalformedXML          = &APIError{http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed."}
+	ErrBadDigest             = &APIError{http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received."}
+	ErrNotImplemented        = &APIError{http.StatusNotImplemented, "NotImplemented", "A header you provided implies functionality that is not implemented."}
+	ErrInternalError         = &APIError{http.StatusInternalServerError, "InternalError", "We encountered an internal error, please try again."}
+)
+
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource,omitempty"`
+	RequestID string   `xml:"RequestId,omitempty"`
+}
@@ -0,0 +1,379 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3gateway
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+)
+
+// bucket/key index persisted in kv of clustermgr, key layout:
+//
+//	s3gw-bucket-{bucket}                      --> BucketMeta
+//	s3gw-object-{bucket}/{hex(key)}           --> ObjectMeta
+//	s3gw-upload-{bucket}/{upload_id}          --> UploadMeta
+//	s3gw-part-{upload_id}/{part_number(%05d)} --> PartMeta
+//
+// object key is hex encoded, so the kv key is safe in url path of clustermgr api,
+// and the lexicographical order and prefix of object keys are kept.
+const (
+	kvBucketPrefix = "s3gw-bucket-"
+	kvObjectPrefix = "s3gw-object-"
+	kvUploadPrefix = "s3gw-upload-"
+	kvPartPrefix   = "s3gw-part-"
+
+	defaultListCount = 1000
+)
+
+var errNotFound = errors.New("not found")
+
+// BucketMeta bucket meta
+type BucketMeta struct {
+	Name       string `json:"name"`
+	CreateTime int64  `json:"create_time"`
+}
+
+// ObjectMeta object meta, data of the object is stored in blobstore
+// with locations in order, one location for one part of multipart uploaded object
+type ObjectMeta struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ETag         string            `json:"etag"`
+	ContentType  string            `json:"content_type,omitempty"`
+	LastModified int64             `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Locations    []access.Location `json:"locations"`
+}
+
+// UploadMeta multipart upload meta
+type UploadMeta struct {
+	Key         string            `json:"key"`
+	UploadID    string            `json:"upload_id"`
+	Initiated   int64             `json:"initiated"`
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// PartMeta uploaded part meta of multipart upload
+type PartMeta struct {
+	PartNumber   int             `json:"part_number"`
+	Size         int64           `json:"size"`
+	ETag         string          `json:"etag"`
+	LastModified int64           `json:"last_modified"`
+	Location     access.Location `json:"location"`
+}
+
+func bucketKey(bucket string) string {
+	return kvBucketPrefix + bucket
+}
+
+func objectPrefix(bucket, prefix string) string {
+	return kvObjectPrefix + bucket + "/" + hex.EncodeToString([]byte(prefix))
+}
+
+func objectKey(bucket, key string) string {
+	return objectPrefix(bucket, key)
+}
+
+func uploadPrefix(bucket string) string {
+	return kvUploadPrefix + bucket + "/"
+}
+
+func uploadKey(bucket, uploadID string) string {
+	return uploadPrefix(bucket) + uploadID
+}
+
+func partPrefix(uploadID string) string {
+	return kvPartPrefix + uploadID + "/"
+}
+
+func partKey(uploadID string, partNumber int) string {
+	return fmt.Sprintf("%s%05d", partPrefix(uploadID), partNumber)
+}
+
+type metaStore struct {
+	kv clustermgr.APIKV
+}
+
+func (m *metaStore) get(ctx context.Context, key string, val interface{}) error {
+	ret, err := m.kv.GetKV(ctx, key)
+	if err != nil {
+		if rpc.DetectStatusCode(err) == http.StatusNotFound {
+			return errNotFound
+		}
+		return err
+	}
+	return json.Unmarshal(ret.Value, val)
+}
+
+func (m *metaStore) set(ctx context.Context, key string, val interface{}) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return m.kv.SetKV(ctx, key, data)
+}
+
+// list values with the prefix after marker, returns next marker if has more
+func (m *metaStore) list(ctx context.Context, prefix, marker string, count int,
+	fn func(kv *clustermgr.KeyValue) error) (string, error) {
+	if count <= 0 || count > defaultListCount {
+		count = defaultListCount
+	}
+	ret, err := m.kv.ListKV(ctx, &clustermgr.ListKvOpts{Prefix: prefix, Marker: marker, Count: count})
+	if err != nil {
+		return "", err
+	}
+	for _, kv := range ret.Kvs {
+		if err = fn(kv); err != nil {
+			return "", err
+		}
+	}
+	if len(ret.Kvs) < count {
+		return "", nil
+	}
+	return ret.Marker, nil
+}
+
+func (m *metaStore) GetBucket(ctx context.Context, bucket string) (*BucketMeta, error) {
+	meta := new(BucketMeta)
+	if err := m.get(ctx, bucketKey(bucket), meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (m *metaStore) PutBucket(ctx context.Context, meta *BucketMeta) error {
+	return m.set(ctx, bucketKey(meta.Name), meta)
+}
+
+func (m *metaStore) DeleteBucket(ctx context.Context, bucket string) error {
+	return m.kv.DeleteKV(ctx, bucketKey(bucket))
+}
+
+func (m *metaStore) ListBuckets(ctx context.Context) ([]*BucketMeta, error) {
+	buckets := make([]*BucketMeta, 0)
+	marker := ""
+	for {
+		next, err := m.list(ctx, kvBucketPrefix, marker, defaultListCount, func(kv *clustermgr.KeyValue) error {
+			meta := new(BucketMeta)
+			if err := json.Unmarshal(kv.Value, meta); err != nil {
+				return err
+			}
+			buckets = append(buckets, meta)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if next == "" {
+			return buckets, nil
+		}
+		marker = next
+	}
+}
+
+func (m *metaStore) GetObject(ctx context.Context, bucket, key string) (*ObjectMeta, error) {
+	meta := new(ObjectMeta)
+	if err := m.get(ctx, objectKey(bucket, key), meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (m *metaStore) PutObject(ctx context.Context, bucket string, meta *ObjectMeta) error {
+	return m.set(ctx, objectKey(bucket, meta.Key), meta)
+}
+
+func (m *metaStore) DeleteObject(ctx context.Context, bucket, key string) error {
+	return m.kv.DeleteKV(ctx, objectKey(bucket, key))
+}
+
+// ListObjects list objects with prefix after marker in lexicographical order,
+// the marker is an object key, returns next object key marker if has more
+func (m *metaStore) ListObjects(ctx context.Context, bucket, prefix, marker string, count int) ([]*ObjectMeta, string, error) {
+	kvMarker := ""
+	if marker != "" {
+		kvMarker = objectKey(bucket, marker)
+	}
+	objects := make([]*ObjectMeta, 0, count)
+	next, err := m.list(ctx, objectPrefix(bucket, prefix), kvMarker, count, func(kv *clustermgr.KeyValue) error {
+		meta := new(ObjectMeta)
+		if err := json.Unmarshal(kv.Value, meta); err != nil {
+			return err
+		}
+		objects = append(objects, meta)
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if next == "" || len(objects) == 0 {
+		return objects, "", nil
+	}
+	return objects, objects[len(objects)-1].Key, nil
+}
+
+// IsBucketEmpty returns true if there are neither objects nor multipart uploads in the bucket
+func (m *metaStore) IsBucketEmpty(ctx context.Context, bucket string) (bool, error) {
+	for _, prefix := range []string{kvObjectPrefix + bucket + "/", uploadPrefix(bucket)} {
+		ret, err := m.kv.ListKV(ctx, &clustermgr.ListKvOpts{Prefix: prefix, Count: 1})
+		if err != nil {
+			return false, err
+		}
+		if len(ret.Kvs) > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (m *metaStore) GetUpload(ctx context.Context, bucket, uploadID string) (*UploadMeta, error) {
+	meta := new(UploadMeta)
+	if err := m.get(ctx, uploadKey(bucket, uploadID), meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (m *metaStore) PutUpload(ctx context.Context, bucket string, meta *UploadMeta) error {
+	return m.set(ctx, uploadKey(bucket, meta.UploadID), meta)
+}
+
+func (m *metaStore) DeleteUpload(ctx context.Context, bucket, uploadID string) error {
+	return m.kv.DeleteKV(ctx, uploadKey(bucket, uploadID))
+}
+
+// ListUploads list multipart uploads of the objects with prefix in the bucket after upload id marker,
+// the uploads are filtered by prefix before they are counted.
+func (m *metaStore) ListUploads(ctx context.Context, bucket, prefix, marker string, count int) ([]*UploadMeta, string, error) {
+	kvMarker := ""
+	if marker != "" {
+		kvMarker = uploadKey(bucket, marker)
+	}
+	// one more upload is listed to know whether the listing is truncated
+	uploads := make([]*UploadMeta, 0, count+1)
+	for len(uploads) <= count {
+		next, err := m.list(ctx, uploadPrefix(bucket), kvMarker, count+1, func(kv *clustermgr.KeyValue) error {
+			meta := new(UploadMeta)
+			if err := json.Unmarshal(kv.Value, meta); err != nil {
+				return err
+			}
+			if strings.HasPrefix(meta.Key, prefix) {
+				uploads = append(uploads, meta)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if next == "" {
+			break
+		}
+		kvMarker = next
+	}
+	if len(uploads) <= count {
+		return uploads, "", nil
+	}
+	uploads = uploads[:count]
+	return uploads, uploads[count-1].UploadID, nil
+}
+
+func (m *metaStore) GetPart(ctx context.Context, uploadID string, partNumber int) (*PartMeta, error) {
+	meta := new(PartMeta)
+	if err := m.get(ctx, partKey(uploadID, partNumber), meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (m *metaStore) PutPart(ctx context.Context, uploadID string, meta *PartMeta) error {
+	return m.set(ctx, partKey(uploadID, meta.PartNumber), meta)
+}
+
+func (m *metaStore) DeletePart(ctx context.Context, uploadID string, partNumber int) error {
+	return m.kv.DeleteKV(ctx, partKey(uploadID, partNumber))
+}
+
+// ListParts list uploaded parts after part number marker
+func (m *metaStore) ListParts(ctx context.Context, uploadID string, marker, count int) ([]*PartMeta, int, error) {
+	kvMarker := ""
+	if marker > 0 {
+		kvMarker = partKey(uploadID, marker)
+	}
+	parts := make([]*PartMeta, 0, count)
+	next, err := m.list(ctx, partPrefix(uploadID), kvMarker, count, func(kv *clustermgr.KeyValue) error {
+		meta := new(PartMeta)
+		if err := json.Unmarshal(kv.Value, meta); err != nil {
+			return err
+		}
+		parts = append(parts, meta)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if next == "" || len(parts) == 0 {
+		return parts, 0, nil
+	}
+	return parts, parts[len(parts)-1].PartNumber, nil
+}
+
+// ListAllParts list all uploaded parts of the multipart upload
+func (m *metaStore) ListAllParts(ctx context.Context, uploadID string) ([]*PartMeta, error) {
+	all := make([]*PartMeta, 0)
+	marker := 0
+	for {
+		parts, next, err := m.ListParts(ctx, uploadID, marker, defaultListCount)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, parts...)
+		if next == 0 {
+			return all, nil
+		}
+		marker = next
+	}
+}
+
+func isValidBucketName(bucket string) bool {
+	if len(bucket) < 3 || len(bucket) > 63 {
+		return false
+	}
+	if strings.Contains(bucket, "..") {
+		return false
+	}
+	for i, c := range bucket {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-' || c == '.':
+			if i == 0 || i == len(bucket)-1 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
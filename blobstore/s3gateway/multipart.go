@@ -0,0 +1,480 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+)
+
+// multipart upload, each part is put into blobstore as a single location,
+// the completed object has locations of all parts in order, parts are not copied.
+
+// uploadLocker serializes the meta changes of a multipart upload, which are
+// committing a part, completing and aborting the upload.
+// It works in the gateway process only, requests of one upload are expected
+// to be sent to the same gateway.
+type uploadLocker struct {
+	mu    sync.Mutex
+	locks map[string]*uploadLock
+}
+
+type uploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newUploadLocker() *uploadLocker {
+	return &uploadLocker{locks: make(map[string]*uploadLock)}
+}
+
+// lock the upload, returns the unlock function
+func (l *uploadLocker) lock(uploadID string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[uploadID]
+	if !ok {
+		lock = new(uploadLock)
+		l.locks[uploadID] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		l.mu.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(l.locks, uploadID)
+		}
+		l.mu.Unlock()
+	}
+}
+
+func newUploadID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *Service) initiateMultipartUpload(c *rpc.Context, bucket, key string) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	uploadID, err := newUploadID()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	upload := &UploadMeta{
+		Key:         key,
+		UploadID:    uploadID,
+		Initiated:   time.Now().Unix(),
+		ContentType: c.Request.Header.Get(headerContentType),
+		Metadata:    parseMetadata(c.Request.Header),
+	}
+	if err = s.meta.PutUpload(ctx, bucket, upload); err != nil {
+		respondError(c, err)
+		return
+	}
+	span.Infof("initiate multipart upload %s/%s upload id: %s", bucket, key, uploadID)
+	respondXML(c, InitiateMultipartUploadResult{
+		Xmlns:    xmlNamespace,
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uploadID,
+	})
+}
+
+func (s *Service) uploadPart(c *rpc.Context, bucket, key string) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	partNumber, err := strconv.Atoi(c.Request.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		respondError(c, ErrInvalidArgument)
+		return
+	}
+	upload, err := s.getUpload(c, bucket, key)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if c.Request.ContentLength > s.config.MaxObjectSize {
+		respondError(c, ErrEntityTooLarge)
+		return
+	}
+
+	loc, etag, err := s.putPart(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// the upload may be completed or aborted while the data is putting
+	unlock := s.uploadLocks.lock(upload.UploadID)
+	defer unlock()
+	if _, err = s.getUpload(c, bucket, key); err != nil {
+		s.deleteLocations(ctx, []access.Location{loc})
+		respondError(c, err)
+		return
+	}
+	part := &PartMeta{
+		PartNumber:   partNumber,
+		Size:         int64(loc.Size),
+		ETag:         etag,
+		LastModified: time.Now().Unix(),
+		Location:     loc,
+	}
+
+	old, err := s.meta.GetPart(ctx, upload.UploadID, partNumber)
+	if err != nil && err != errNotFound {
+		s.deleteLocations(ctx, []access.Location{loc})
+		respondError(c, err)
+		return
+	}
+	if err = s.meta.PutPart(ctx, upload.UploadID, part); err != nil {
+		s.deleteLocations(ctx, []access.Location{loc})
+		respondError(c, err)
+		return
+	}
+	if old != nil {
+		s.deleteLocations(ctx, []access.Location{old.Location})
+	}
+	span.Debugf("upload part %s/%s upload id: %s part: %d size: %d", bucket, key, upload.UploadID, partNumber, part.Size)
+	c.Writer.Header().Set(headerETag, quoteETag(etag))
+	c.Respond()
+}
+
+// putPart put data of the part into blobs of an allocated location one by one,
+// returns location and md5 of the data, the location is deleted if failed.
+func (s *Service) putPart(c *rpc.Context) (access.Location, string, error) {
+	ctx := c.Request.Context()
+	size := c.Request.ContentLength
+	if size < 0 {
+		return access.Location{}, "", ErrMissingContentLength
+	}
+	contentMD5, err := parseContentMD5(c)
+	if err != nil {
+		return access.Location{}, "", err
+	}
+
+	hasher := md5.New()
+	loc := access.Location{Blobs: make([]access.SliceInfo, 0)}
+	if size > 0 {
+		resp, err := s.access.Alloc(ctx, &access.AllocArgs{Size: uint64(size)})
+		if err != nil {
+			return access.Location{}, "", err
+		}
+		loc = resp.Location
+		if err = s.putBlobs(ctx, c.Request.Body, hasher, &resp); err != nil {
+			s.deleteLocations(ctx, []access.Location{loc})
+			return access.Location{}, "", err
+		}
+	}
+
+	sum := hasher.Sum(nil)
+	if err = verifyPayload(c.Request); err == nil && contentMD5 != nil && !bytes.Equal(contentMD5, sum) {
+		err = ErrBadDigest
+	}
+	if err != nil {
+		if size > 0 {
+			s.deleteLocations(ctx, []access.Location{loc})
+		}
+		return access.Location{}, "", err
+	}
+	return loc, hex.EncodeToString(sum), nil
+}
+
+// putBlobs read body and put each blob of the allocated location with its token,
+// the full blobs of the i-th slice use the i-th token, and the last blob
+// smaller than blob size uses the last token.
+func (s *Service) putBlobs(ctx context.Context, body io.Reader, hasher hash.Hash, resp *access.AllocResp) error {
+	loc := resp.Location
+	if loc.BlobSize == 0 || len(resp.Tokens) < len(loc.Blobs) {
+		return ErrInternalError
+	}
+
+	bufSize := uint64(loc.BlobSize)
+	if bufSize > loc.Size {
+		bufSize = loc.Size
+	}
+	buf := make([]byte, bufSize)
+	remain := loc.Size
+	for idx, slice := range loc.Blobs {
+		for i := uint32(0); i < slice.Count && remain > 0; i++ {
+			blobSize := uint64(loc.BlobSize)
+			token := resp.Tokens[idx]
+			if remain < blobSize {
+				blobSize = remain
+				token = resp.Tokens[len(resp.Tokens)-1]
+			}
+
+			data := buf[:blobSize]
+			if _, err := io.ReadFull(body, data); err != nil {
+				return ErrIncompleteBody
+			}
+			hasher.Write(data)
+			if _, err := s.access.PutAt(ctx, &access.PutAtArgs{
+				ClusterID: loc.ClusterID,
+				Vid:       slice.Vid,
+				Blobid:    slice.MinBid + proto.BlobID(i),
+				Size:      int64(blobSize),
+				Token:     token,
+				Body:      bytes.NewReader(data),
+			}); err != nil {
+				return err
+			}
+			remain -= blobSize
+		}
+	}
+	if remain > 0 {
+		return ErrInternalError
+	}
+	return nil
+}
+
+func (s *Service) completeMultipartUpload(c *rpc.Context, bucket, key string) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	unlock := s.uploadLocks.lock(c.Request.URL.Query().Get("uploadId"))
+	defer unlock()
+	upload, err := s.getUpload(c, bucket, key)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	args := new(CompleteMultipartUploadRequest)
+	if err = parseXMLBody(c, args); err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(args.Parts) == 0 {
+		respondError(c, ErrMalformedXML)
+		return
+	}
+
+	uploaded, err := s.meta.ListAllParts(ctx, upload.UploadID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	parts := make(map[int]*PartMeta, len(uploaded))
+	for _, part := range uploaded {
+		parts[part.PartNumber] = part
+	}
+
+	meta := &ObjectMeta{
+		Key:          key,
+		ContentType:  upload.ContentType,
+		LastModified: time.Now().Unix(),
+		Metadata:     upload.Metadata,
+		Locations:    make([]access.Location, 0, len(args.Parts)),
+	}
+	hasher := md5.New()
+	for idx, p := range args.Parts {
+		if idx > 0 && p.PartNumber <= args.Parts[idx-1].PartNumber {
+			respondError(c, ErrInvalidPartOrder)
+			return
+		}
+		part, ok := parts[p.PartNumber]
+		if !ok || strings.Trim(p.ETag, "\"") != part.ETag {
+			respondError(c, ErrInvalidPart)
+			return
+		}
+		if idx < len(args.Parts)-1 && part.Size < s.config.MinPartSize {
+			respondError(c, ErrEntityTooSmall)
+			return
+		}
+		sum, _ := hex.DecodeString(part.ETag)
+		hasher.Write(sum)
+		meta.Size += part.Size
+		meta.Locations = append(meta.Locations, part.Location)
+		delete(parts, p.PartNumber)
+	}
+	meta.ETag = fmt.Sprintf("%s-%d", hex.EncodeToString(hasher.Sum(nil)), len(args.Parts))
+
+	if err = s.replaceObject(ctx, bucket, meta); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// remove the upload, data of parts not in the object is deleted
+	unused := make([]access.Location, 0, len(parts))
+	for _, part := range parts {
+		unused = append(unused, part.Location)
+	}
+	s.deleteLocations(ctx, unused)
+	s.removeUpload(c, bucket, upload.UploadID, uploaded)
+
+	span.Infof("complete multipart upload %s/%s upload id: %s parts: %d size: %d",
+		bucket, key, upload.UploadID, len(args.Parts), meta.Size)
+	respondXML(c, CompleteMultipartUploadResult{
+		Xmlns:    xmlNamespace,
+		Location: "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     quoteETag(meta.ETag),
+	})
+}
+
+func (s *Service) abortMultipartUpload(c *rpc.Context, bucket, key string) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	unlock := s.uploadLocks.lock(c.Request.URL.Query().Get("uploadId"))
+	defer unlock()
+	upload, err := s.getUpload(c, bucket, key)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	uploaded, err := s.meta.ListAllParts(ctx, upload.UploadID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	locations := make([]access.Location, 0, len(uploaded))
+	for _, part := range uploaded {
+		locations = append(locations, part.Location)
+	}
+	s.deleteLocations(ctx, locations)
+	s.removeUpload(c, bucket, upload.UploadID, uploaded)
+	span.Infof("abort multipart upload %s/%s upload id: %s", bucket, key, upload.UploadID)
+	c.RespondStatus(http.StatusNoContent)
+}
+
+func (s *Service) listParts(c *rpc.Context, bucket, key string) {
+	upload, err := s.getUpload(c, bucket, key)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	maxParts, err := parseMaxKeys(c, "max-parts")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	marker := 0
+	if val := c.Request.URL.Query().Get("part-number-marker"); val != "" {
+		if marker, err = strconv.Atoi(val); err != nil || marker < 0 {
+			respondError(c, ErrInvalidArgument)
+			return
+		}
+	}
+
+	parts, next := make([]*PartMeta, 0), 0
+	if maxParts > 0 {
+		if parts, next, err = s.meta.ListParts(c.Request.Context(), upload.UploadID, marker, maxParts); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	result := ListPartsResult{
+		Xmlns:                xmlNamespace,
+		Bucket:               bucket,
+		Key:                  key,
+		UploadID:             upload.UploadID,
+		PartNumberMarker:     marker,
+		NextPartNumberMarker: next,
+		MaxParts:             maxParts,
+		IsTruncated:          next > 0,
+		Parts:                make([]Part, 0, len(parts)),
+	}
+	for _, part := range parts {
+		result.Parts = append(result.Parts, Part{
+			PartNumber:   part.PartNumber,
+			LastModified: formatTime(part.LastModified),
+			ETag:         quoteETag(part.ETag),
+			Size:         part.Size,
+		})
+	}
+	respondXML(c, result)
+}
+
+func (s *Service) listMultipartUploads(c *rpc.Context, bucket string) {
+	query := c.Request.URL.Query()
+	maxUploads, err := parseMaxKeys(c, "max-uploads")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	prefix, marker := query.Get("prefix"), query.Get("upload-id-marker")
+	uploads, next := make([]*UploadMeta, 0), ""
+	if maxUploads > 0 {
+		if uploads, next, err = s.meta.ListUploads(c.Request.Context(), bucket, prefix, marker, maxUploads); err != nil {
+			respondError(c, err)
+			return
+		}
+	}
+	result := ListMultipartUploadsResult{
+		Xmlns:              xmlNamespace,
+		Bucket:             bucket,
+		Prefix:             prefix,
+		UploadIDMarker:     marker,
+		NextUploadIDMarker: next,
+		MaxUploads:         maxUploads,
+		IsTruncated:        next != "",
+		Uploads:            make([]Upload, 0, len(uploads)),
+	}
+	for _, upload := range uploads {
+		result.Uploads = append(result.Uploads, Upload{
+			Key:       upload.Key,
+			UploadID:  upload.UploadID,
+			Initiated: formatTime(upload.Initiated),
+		})
+	}
+	respondXML(c, result)
+}
+
+func (s *Service) getUpload(c *rpc.Context, bucket, key string) (*UploadMeta, error) {
+	uploadID := c.Request.URL.Query().Get("uploadId")
+	upload, err := s.meta.GetUpload(c.Request.Context(), bucket, uploadID)
+	if err == errNotFound || (err == nil && upload.Key != key) {
+		return nil, ErrNoSuchUpload
+	}
+	return upload, err
+}
+
+// removeUpload remove index of parts and the upload, just log if failed
+func (s *Service) removeUpload(c *rpc.Context, bucket, uploadID string, parts []*PartMeta) {
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	for _, part := range parts {
+		if err := s.meta.DeletePart(ctx, uploadID, part.PartNumber); err != nil {
+			span.Warnf("delete part %d of upload %s failed, err: %s", part.PartNumber, uploadID, err)
+		}
+	}
+	if err := s.meta.DeleteUpload(ctx, bucket, uploadID); err != nil {
+		span.Warnf("delete upload %s of bucket %s failed, err: %s", uploadID, bucket, err)
+	}
+}
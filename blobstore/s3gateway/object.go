@@ -0,0 +1,583 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+)
+
+const (
+	headerETag         = "ETag"
+	headerContentMD5   = "Content-Md5"
+	headerContentType  = "Content-Type"
+	headerContentRange = "Content-Range"
+	headerRange        = "Range"
+	headerLastModified = "Last-Modified"
+	headerAcceptRanges = "Accept-Ranges"
+	headerCopySource   = "X-Amz-Copy-Source"
+	headerMetaPrefix   = "X-Amz-Meta-"
+
+	defaultContentType  = "application/octet-stream"
+	defaultStorageClass = "STANDARD"
+)
+
+// PutObject put an object, or upload a part of multipart upload
+func (s *Service) PutObject(c *rpc.Context) {
+	bucket, key := bucketParam(c), keyParam(c)
+	if key == "" {
+		s.PutBucket(c)
+		return
+	}
+	if c.Request.Header.Get(headerCopySource) != "" {
+		respondError(c, ErrNotImplemented)
+		return
+	}
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+	if hasQuery(c, "uploadId") {
+		s.uploadPart(c, bucket, key)
+		return
+	}
+
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	if c.Request.ContentLength > s.config.MaxObjectSize {
+		respondError(c, ErrEntityTooLarge)
+		return
+	}
+	loc, etag, err := s.putData(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	meta := &ObjectMeta{
+		Key:          key,
+		Size:         int64(loc.Size),
+		ETag:         etag,
+		ContentType:  c.Request.Header.Get(headerContentType),
+		LastModified: time.Now().Unix(),
+		Metadata:     parseMetadata(c.Request.Header),
+		Locations:    []access.Location{loc},
+	}
+	if err = s.replaceObject(ctx, bucket, meta); err != nil {
+		s.deleteLocations(ctx, meta.Locations)
+		respondError(c, err)
+		return
+	}
+	span.Debugf("put object %s/%s size: %d etag: %s", bucket, key, meta.Size, etag)
+	c.Writer.Header().Set(headerETag, quoteETag(etag))
+	c.Respond()
+}
+
+// GetObject get data of an object, or list parts of multipart upload
+func (s *Service) GetObject(c *rpc.Context) {
+	bucket, key := bucketParam(c), keyParam(c)
+	if key == "" {
+		s.GetBucket(c)
+		return
+	}
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+	if hasQuery(c, "uploadId") {
+		s.listParts(c, bucket, key)
+		return
+	}
+
+	ctx := c.Request.Context()
+	span := trace.SpanFromContextSafe(ctx)
+	meta, err := s.getObjectMeta(ctx, bucket, key)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	offset, length, partial, err := parseRange(c.Request.Header.Get(headerRange), meta.Size)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setObjectHeaders(c.Writer.Header(), meta)
+	c.Writer.Header().Set(rpc.HeaderContentLength, strconv.FormatInt(length, 10))
+	status := http.StatusOK
+	if partial {
+		c.Writer.Header().Set(headerContentRange,
+			fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, meta.Size))
+		status = http.StatusPartialContent
+	}
+	c.RespondStatus(status)
+	if err = s.readData(ctx, c.Writer, meta.Locations, offset, length); err != nil {
+		span.Errorf("read object %s/%s offset: %d length: %d failed, err: %s", bucket, key, offset, length, err)
+	}
+}
+
+// HeadObject get meta of an object
+func (s *Service) HeadObject(c *rpc.Context) {
+	bucket, key := bucketParam(c), keyParam(c)
+	if key == "" {
+		s.HeadBucket(c)
+		return
+	}
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	meta, err := s.getObjectMeta(c.Request.Context(), bucket, key)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	setObjectHeaders(c.Writer.Header(), meta)
+	c.Writer.Header().Set(rpc.HeaderContentLength, strconv.FormatInt(meta.Size, 10))
+	c.RespondStatus(http.StatusOK)
+}
+
+// DeleteObject delete an object, or abort multipart upload
+func (s *Service) DeleteObject(c *rpc.Context) {
+	bucket, key := bucketParam(c), keyParam(c)
+	if key == "" {
+		s.DeleteBucket(c)
+		return
+	}
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+	if hasQuery(c, "uploadId") {
+		s.abortMultipartUpload(c, bucket, key)
+		return
+	}
+
+	if err := s.deleteObject(c.Request.Context(), bucket, key); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.RespondStatus(http.StatusNoContent)
+}
+
+// PostObject initiate or complete multipart upload
+func (s *Service) PostObject(c *rpc.Context) {
+	bucket, key := bucketParam(c), keyParam(c)
+	if key == "" {
+		s.PostBucket(c)
+		return
+	}
+	if err := s.checkBucket(c, bucket); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	switch {
+	case hasQuery(c, "uploads"):
+		s.initiateMultipartUpload(c, bucket, key)
+	case hasQuery(c, "uploadId"):
+		s.completeMultipartUpload(c, bucket, key)
+	default:
+		respondError(c, ErrNotImplemented)
+	}
+}
+
+func (s *Service) listObjects(c *rpc.Context, bucket string) {
+	query := c.Request.URL.Query()
+	maxKeys, err := parseMaxKeys(c, "max-keys")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	prefix, delimiter, marker := query.Get("prefix"), query.Get("delimiter"), query.Get("marker")
+	contents, prefixes, next, err := s.list(c.Request.Context(), bucket, prefix, delimiter, marker, maxKeys)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondXML(c, ListObjectsResult{
+		Xmlns:          xmlNamespace,
+		Name:           bucket,
+		Prefix:         prefix,
+		Marker:         marker,
+		NextMarker:     next,
+		MaxKeys:        maxKeys,
+		Delimiter:      delimiter,
+		IsTruncated:    next != "",
+		Contents:       contents,
+		CommonPrefixes: prefixes,
+	})
+}
+
+func (s *Service) listObjectsV2(c *rpc.Context, bucket string) {
+	query := c.Request.URL.Query()
+	maxKeys, err := parseMaxKeys(c, "max-keys")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	prefix, delimiter := query.Get("prefix"), query.Get("delimiter")
+	token, startAfter := query.Get("continuation-token"), query.Get("start-after")
+	marker := startAfter
+	if token != "" {
+		data, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			respondError(c, ErrInvalidArgument)
+			return
+		}
+		marker = string(data)
+	}
+
+	contents, prefixes, next, err := s.list(c.Request.Context(), bucket, prefix, delimiter, marker, maxKeys)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	result := ListObjectsV2Result{
+		Xmlns:             xmlNamespace,
+		Name:              bucket,
+		Prefix:            prefix,
+		StartAfter:        startAfter,
+		ContinuationToken: token,
+		KeyCount:          len(contents) + len(prefixes),
+		MaxKeys:           maxKeys,
+		Delimiter:         delimiter,
+		IsTruncated:       next != "",
+		Contents:          contents,
+		CommonPrefixes:    prefixes,
+	}
+	if next != "" {
+		result.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(next))
+	}
+	respondXML(c, result)
+}
+
+// list objects and common prefixes after marker, returns next marker if truncated
+func (s *Service) list(ctx context.Context, bucket, prefix, delimiter, marker string, maxKeys int) (
+	contents []Content, prefixes []CommonPrefix, next string, err error) {
+	// all keys of the common prefix have been listed if marker is a common prefix
+	skipPrefix := ""
+	if delimiter != "" && strings.HasPrefix(marker, prefix) && strings.HasSuffix(marker, delimiter) {
+		skipPrefix = marker
+	}
+
+	contents = make([]Content, 0)
+	prefixes = make([]CommonPrefix, 0)
+	last, lastPrefix := "", ""
+	for {
+		objects, nextMarker, err := s.meta.ListObjects(ctx, bucket, prefix, marker, defaultListCount)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		for _, obj := range objects {
+			if skipPrefix != "" && strings.HasPrefix(obj.Key, skipPrefix) {
+				continue
+			}
+			if delimiter != "" {
+				if idx := strings.Index(obj.Key[len(prefix):], delimiter); idx >= 0 {
+					commonPrefix := obj.Key[:len(prefix)+idx+len(delimiter)]
+					if commonPrefix == lastPrefix {
+						continue
+					}
+					if len(contents)+len(prefixes) >= maxKeys {
+						return contents, prefixes, last, nil
+					}
+					prefixes = append(prefixes, CommonPrefix{Prefix: commonPrefix})
+					last, lastPrefix = commonPrefix, commonPrefix
+					continue
+				}
+			}
+
+			if len(contents)+len(prefixes) >= maxKeys {
+				return contents, prefixes, last, nil
+			}
+			contents = append(contents, Content{
+				Key:          obj.Key,
+				LastModified: formatTime(obj.LastModified),
+				ETag:         quoteETag(obj.ETag),
+				Size:         obj.Size,
+				StorageClass: defaultStorageClass,
+			})
+			last = obj.Key
+		}
+
+		if nextMarker == "" {
+			return contents, prefixes, "", nil
+		}
+		marker = nextMarker
+	}
+}
+
+func (s *Service) deleteObjects(c *rpc.Context, bucket string) {
+	ctx := c.Request.Context()
+	args := new(DeleteObjectsRequest)
+	if err := parseXMLBody(c, args); err != nil {
+		respondError(c, err)
+		return
+	}
+	if len(args.Objects) > defaultListCount {
+		respondError(c, ErrMalformedXML)
+		return
+	}
+
+	result := DeleteObjectsResult{Xmlns: xmlNamespace}
+	for _, obj := range args.Objects {
+		if err := s.deleteObject(ctx, bucket, obj.Key); err != nil {
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				apiErr = ErrInternalError
+			}
+			result.Errors = append(result.Errors, DeleteError{Key: obj.Key, Code: apiErr.Code, Message: apiErr.Message})
+			continue
+		}
+		if !args.Quiet {
+			result.Deleted = append(result.Deleted, DeletedObject{Key: obj.Key})
+		}
+	}
+	respondXML(c, result)
+}
+
+func (s *Service) getObjectMeta(ctx context.Context, bucket, key string) (*ObjectMeta, error) {
+	meta, err := s.meta.GetObject(ctx, bucket, key)
+	if err == errNotFound {
+		return nil, ErrNoSuchKey
+	}
+	return meta, err
+}
+
+// deleteObject delete index of the object, then delete its data
+func (s *Service) deleteObject(ctx context.Context, bucket, key string) error {
+	meta, err := s.meta.GetObject(ctx, bucket, key)
+	if err == errNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = s.meta.DeleteObject(ctx, bucket, key); err != nil {
+		return err
+	}
+	s.deleteLocations(ctx, meta.Locations)
+	return nil
+}
+
+// replaceObject update index of the object, then delete data of the replaced one
+func (s *Service) replaceObject(ctx context.Context, bucket string, meta *ObjectMeta) error {
+	old, err := s.meta.GetObject(ctx, bucket, meta.Key)
+	if err != nil && err != errNotFound {
+		return err
+	}
+	if err = s.meta.PutObject(ctx, bucket, meta); err != nil {
+		return err
+	}
+	if old != nil {
+		s.deleteLocations(ctx, old.Locations)
+	}
+	return nil
+}
+
+// putData put request body into blobstore, returns location and md5 of the data.
+// access puts data with alloc and putat if size is larger than its MaxSizePutOnce.
+func (s *Service) putData(c *rpc.Context) (access.Location, string, error) {
+	ctx := c.Request.Context()
+	size := c.Request.ContentLength
+	if size < 0 {
+		return access.Location{}, "", ErrMissingContentLength
+	}
+
+	contentMD5, err := parseContentMD5(c)
+	if err != nil {
+		return access.Location{}, "", err
+	}
+
+	loc, hashSumMap, err := s.access.Put(ctx, &access.PutArgs{
+		Size:   size,
+		Hashes: access.HashAlgMD5,
+		Body:   c.Request.Body,
+	})
+	if err != nil {
+		return access.Location{}, "", err
+	}
+	if err = verifyPayload(c.Request); err != nil {
+		s.deleteLocations(ctx, []access.Location{loc})
+		return access.Location{}, "", err
+	}
+	sum := hashSumMap[access.HashAlgMD5]
+	if contentMD5 != nil && !bytes.Equal(contentMD5, sum) {
+		s.deleteLocations(ctx, []access.Location{loc})
+		return access.Location{}, "", ErrBadDigest
+	}
+	return loc, hex.EncodeToString(sum), nil
+}
+
+// parseContentMD5 returns nil if no Content-MD5 header
+func parseContentMD5(c *rpc.Context) ([]byte, error) {
+	val := c.Request.Header.Get(headerContentMD5)
+	if val == "" {
+		return nil, nil
+	}
+	sum, err := base64.StdEncoding.DecodeString(val)
+	if err != nil || len(sum) != md5.Size {
+		return nil, ErrBadDigest
+	}
+	return sum, nil
+}
+
+// readData read data in range [offset, offset+length) of locations
+func (s *Service) readData(ctx context.Context, w io.Writer, locations []access.Location, offset, length int64) error {
+	for _, loc := range locations {
+		if length <= 0 {
+			break
+		}
+		size := int64(loc.Size)
+		if offset >= size {
+			offset -= size
+			continue
+		}
+
+		readSize := size - offset
+		if readSize > length {
+			readSize = length
+		}
+		body, err := s.access.Get(ctx, &access.GetArgs{
+			Location: loc,
+			Offset:   uint64(offset),
+			ReadSize: uint64(readSize),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = io.CopyN(w, body, readSize)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		offset = 0
+		length -= readSize
+	}
+	return nil
+}
+
+// deleteLocations delete data in blobstore, just log failed locations
+func (s *Service) deleteLocations(ctx context.Context, locations []access.Location) {
+	span := trace.SpanFromContextSafe(ctx)
+	for len(locations) > 0 {
+		n := len(locations)
+		if n > access.MaxDeleteLocations {
+			n = access.MaxDeleteLocations
+		}
+		failed, err := s.access.Delete(ctx, &access.DeleteArgs{Locations: locations[:n]})
+		if err != nil {
+			span.Errorf("delete locations failed, err: %s", err)
+			for idx := range failed {
+				span.Warnf("leaked location: %s", failed[idx].HexString())
+			}
+		}
+		locations = locations[n:]
+	}
+}
+
+func parseMetadata(header http.Header) map[string]string {
+	var metadata map[string]string
+	for name, vals := range header {
+		if !strings.HasPrefix(name, headerMetaPrefix) || len(vals) == 0 {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[strings.ToLower(strings.TrimPrefix(name, headerMetaPrefix))] = vals[0]
+	}
+	return metadata
+}
+
+func setObjectHeaders(header http.Header, meta *ObjectMeta) {
+	contentType := meta.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	header.Set(headerContentType, contentType)
+	header.Set(headerETag, quoteETag(meta.ETag))
+	header.Set(headerLastModified, time.Unix(meta.LastModified, 0).UTC().Format(http.TimeFormat))
+	header.Set(headerAcceptRanges, "bytes")
+	for name, val := range meta.Metadata {
+		header.Set(headerMetaPrefix+name, val)
+	}
+}
+
+// parseRange parse single byte range of http header,
+// returns the whole content if range is not specified or is ignored.
+func parseRange(val string, size int64) (offset, length int64, partial bool, err error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(val, prefix) || strings.Contains(val, ",") {
+		return 0, size, false, nil
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(val, prefix))
+	idx := strings.Index(spec, "-")
+	if idx < 0 {
+		return 0, size, false, nil
+	}
+
+	startStr, endStr := spec[:idx], spec[idx+1:]
+	if startStr == "" {
+		suffix, perr := strconv.ParseInt(endStr, 10, 64)
+		if perr != nil || suffix < 0 {
+			return 0, size, false, nil
+		}
+		if suffix == 0 || size == 0 {
+			return 0, 0, false, ErrInvalidRange
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, suffix, true, nil
+	}
+
+	start, perr := strconv.ParseInt(startStr, 10, 64)
+	if perr != nil || start < 0 {
+		return 0, size, false, nil
+	}
+	if start >= size {
+		return 0, 0, false, ErrInvalidRange
+	}
+	end := size - 1
+	if endStr != "" {
+		e, perr := strconv.ParseInt(endStr, 10, 64)
+		if perr != nil || e < start {
+			return 0, size, false, nil
+		}
+		if e < end {
+			end = e
+		}
+	}
+	return start, end - start + 1, true, nil
+}
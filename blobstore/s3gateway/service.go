@@ -0,0 +1,229 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	"github.com/cubefs/cubefs/blobstore/cmd"
+	"github.com/cubefs/cubefs/blobstore/common/config"
+	"github.com/cubefs/cubefs/blobstore/common/rpc"
+	"github.com/cubefs/cubefs/blobstore/common/trace"
+	"github.com/cubefs/cubefs/blobstore/util/defaulter"
+	"github.com/cubefs/cubefs/blobstore/util/log"
+)
+
+const (
+	serviceName = "S3GATEWAY"
+
+	defaultMaxObjectSize = int64(5 << 30) // 5GB
+	defaultMinPartSize   = int64(5 << 20) // 5MB
+	maxPartNumber        = 10000
+
+	iso8601TimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+var (
+	service *Service
+	conf    Config
+)
+
+// Config s3 gateway config
+type Config struct {
+	cmd.Config
+
+	// Region is the region in credential scope of signature,
+	// requests of any region are accepted if it's empty.
+	Region      string       `json:"region"`
+	DisableAuth bool         `json:"disable_auth"`
+	Credentials []Credential `json:"credentials"`
+
+	// MaxObjectSize max size of object putting once
+	MaxObjectSize int64 `json:"max_object_size"`
+	// MinPartSize min size of each part except the last one of multipart upload
+	MinPartSize int64 `json:"min_part_size"`
+
+	Access     access.Config     `json:"access"`
+	Clustermgr clustermgr.Config `json:"clustermgr"`
+}
+
+// Service s3 gateway service, serves s3 objects on blobstore,
+// the bucket/key --> location index is kept in kv of clustermgr.
+type Service struct {
+	config Config
+	auth   *authenticator
+	access access.API
+	meta   *metaStore
+
+	uploadLocks *uploadLocker
+}
+
+func init() {
+	mod := &cmd.Module{
+		Name:       serviceName,
+		InitConfig: initConfig,
+		SetUp:      setUp,
+		TearDown:   tearDown,
+	}
+	cmd.RegisterGracefulModule(mod)
+}
+
+func initConfig(args []string) (*cmd.Config, error) {
+	config.Init("f", "", "s3gateway.conf")
+	if err := config.Load(&conf); err != nil {
+		return nil, err
+	}
+	return &conf.Config, nil
+}
+
+func setUp() (*rpc.Router, []rpc.ProgressHandler) {
+	accessAPI, err := access.New(conf.Access)
+	if err != nil {
+		log.Fatalf("fail to new access client, error: %s", err.Error())
+	}
+	cmcli := clustermgr.New(&conf.Clustermgr)
+	service = New(conf, accessAPI, cmcli)
+	return NewHandler(service), nil
+}
+
+func tearDown() {}
+
+// New returns a s3 gateway service
+func New(cfg Config, accessAPI access.API, kv clustermgr.APIKV) *Service {
+	defaulter.LessOrEqual(&cfg.MaxObjectSize, defaultMaxObjectSize)
+	defaulter.LessOrEqual(&cfg.MinPartSize, defaultMinPartSize)
+
+	var auth *authenticator
+	if !cfg.DisableAuth {
+		if len(cfg.Credentials) == 0 {
+			log.Fatal("no credentials of s3 gateway, set disable_auth if anonymous access is expected")
+		}
+		auth = newAuthenticator(cfg.Region, cfg.Credentials)
+	}
+
+	return &Service{
+		config: cfg,
+		auth:   auth,
+		access: accessAPI,
+		meta:   &metaStore{kv: kv},
+
+		uploadLocks: newUploadLocker(),
+	}
+}
+
+// NewHandler returns s3 gateway handler, path style request only.
+func NewHandler(service *Service) *rpc.Router {
+	router := rpc.New()
+	router.Use(service.Authenticate)
+
+	// GET /
+	// response body: xml
+	router.Handle(http.MethodGet, "/", service.ListBuckets)
+
+	// PUT /{bucket}
+	router.Handle(http.MethodPut, "/:bucket", service.PutBucket)
+	// HEAD /{bucket}
+	router.Handle(http.MethodHead, "/:bucket", service.HeadBucket)
+	// DELETE /{bucket}
+	router.Handle(http.MethodDelete, "/:bucket", service.DeleteBucket)
+	// GET /{bucket}?list-type=2&prefix={prefix}&delimiter={delimiter}&...
+	// GET /{bucket}?uploads
+	// GET /{bucket}?location
+	// response body: xml
+	router.Handle(http.MethodGet, "/:bucket", service.GetBucket)
+	// POST /{bucket}?delete
+	// request  body: xml
+	// response body: xml
+	router.Handle(http.MethodPost, "/:bucket", service.PostBucket)
+
+	// PUT /{bucket}/{key}
+	// PUT /{bucket}/{key}?partNumber={part_number}&uploadId={upload_id}
+	// request  body: DataStream
+	router.Handle(http.MethodPut, "/:bucket/*key", service.PutObject)
+	// GET /{bucket}/{key}
+	// response body: DataStream
+	// GET /{bucket}/{key}?uploadId={upload_id}
+	// response body: xml
+	router.Handle(http.MethodGet, "/:bucket/*key", service.GetObject)
+	// HEAD /{bucket}/{key}
+	router.Handle(http.MethodHead, "/:bucket/*key", service.HeadObject)
+	// DELETE /{bucket}/{key}
+	// DELETE /{bucket}/{key}?uploadId={upload_id}
+	router.Handle(http.MethodDelete, "/:bucket/*key", service.DeleteObject)
+	// POST /{bucket}/{key}?uploads
+	// POST /{bucket}/{key}?uploadId={upload_id}
+	// request  body: xml
+	// response body: xml
+	router.Handle(http.MethodPost, "/:bucket/*key", service.PostObject)
+
+	return router
+}
+
+func bucketParam(c *rpc.Context) string {
+	return c.Param.ByName("bucket")
+}
+
+func keyParam(c *rpc.Context) string {
+	return strings.TrimPrefix(c.Param.ByName("key"), "/")
+}
+
+func hasQuery(c *rpc.Context, key string) bool {
+	_, ok := c.Request.URL.Query()[key]
+	return ok
+}
+
+func formatTime(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(iso8601TimeFormat)
+}
+
+func quoteETag(etag string) string {
+	return "\"" + etag + "\""
+}
+
+func respondXML(c *rpc.Context, obj interface{}) {
+	body, err := xml.Marshal(obj)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.RespondWith(http.StatusOK, "application/xml", append([]byte(xml.Header), body...))
+}
+
+func respondError(c *rpc.Context, err error) {
+	span := trace.SpanFromContextSafe(c.Request.Context())
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		span.Errorf("unexpected error, method: %s, uri: %s, err: %s", c.Request.Method, c.Request.RequestURI, err)
+		apiErr = ErrInternalError
+	}
+
+	if c.Request.Method == http.MethodHead {
+		c.Writer.Header().Set(rpc.HeaderContentLength, "0")
+		c.RespondStatus(apiErr.StatusCode)
+		return
+	}
+	body, _ := xml.Marshal(errorResponse{
+		Code:      apiErr.Code,
+		Message:   apiErr.Message,
+		Resource:  c.Request.URL.Path,
+		RequestID: span.TraceID(),
+	})
+	c.RespondWith(apiErr.StatusCode, "application/xml", append([]byte(xml.Header), body...))
+}
@@ -0,0 +1,708 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cubefs/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/blobstore/api/clustermgr"
+	apierrors "github.com/cubefs/cubefs/blobstore/common/errors"
+	"github.com/cubefs/cubefs/blobstore/common/proto"
+	"github.com/cubefs/cubefs/blobstore/testing/mocks"
+	_ "github.com/cubefs/cubefs/blobstore/testing/nolog"
+)
+
+// memKV kv of clustermgr in memory
+type memKV struct {
+	mu  sync.Mutex
+	kvs map[string][]byte
+}
+
+func (m *memKV) GetKV(ctx context.Context, key string) (clustermgr.GetKvRet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	val, ok := m.kvs[key]
+	if !ok {
+		return clustermgr.GetKvRet{}, apierrors.ErrNotFound
+	}
+	return clustermgr.GetKvRet{Value: val}, nil
+}
+
+func (m *memKV) SetKV(ctx context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	m.kvs[key] = value
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memKV) DeleteKV(ctx context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.kvs, key)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memKV) ListKV(ctx context.Context, args *clustermgr.ListKvOpts) (clustermgr.ListKvRet, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0)
+	for key := range m.kvs {
+		if strings.HasPrefix(key, args.Prefix) && key > args.Marker {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	ret := clustermgr.ListKvRet{Kvs: make([]*clustermgr.KeyValue, 0)}
+	for _, key := range keys {
+		if len(ret.Kvs) == args.Count {
+			break
+		}
+		ret.Kvs = append(ret.Kvs, &clustermgr.KeyValue{Key: key, Value: m.kvs[key]})
+	}
+	if len(ret.Kvs) == args.Count {
+		ret.Marker = ret.Kvs[len(ret.Kvs)-1].Key
+	}
+	return ret, nil
+}
+
+const (
+	testBlobSize      = 4 // blob size of the allocated locations
+	testSliceMaxBlobs = 2 // max count of blobs in a slice of the allocated locations
+)
+
+// memBlobs data of blobs in memory, location is identified by the min bid of its first slice
+type memBlobs struct {
+	mu        sync.Mutex
+	bid       proto.BlobID
+	locations map[proto.BlobID]bool
+	data      map[proto.BlobID][]byte
+	failPutAt bool
+}
+
+// len returns count of the locations
+func (m *memBlobs) len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.locations)
+}
+
+// testToken is the upload token of count blobs from bid with the size
+func testToken(bid proto.BlobID, count, size uint32) string {
+	return fmt.Sprintf("%d-%d-%d", bid, count, size)
+}
+
+// alloc location with slices of testSliceMaxBlobs blobs at most, tokens are generated
+// as access does, the last token is for the last blob smaller than blob size.
+func (m *memBlobs) alloc(size uint64) access.AllocResp {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	loc := access.Location{ClusterID: 1, Size: size, BlobSize: testBlobSize}
+	resp := access.AllocResp{}
+	blobs := uint32((size + testBlobSize - 1) / testBlobSize)
+	lastSize := uint32(size % testBlobSize)
+	for vid := proto.Vid(1); blobs > 0; vid++ {
+		count := uint32(testSliceMaxBlobs)
+		if count > blobs {
+			count = blobs
+		}
+		slice := access.SliceInfo{MinBid: m.bid + 1, Vid: vid, Count: count}
+		m.bid += proto.BlobID(count)
+		blobs -= count
+		loc.Blobs = append(loc.Blobs, slice)
+
+		fullCount := count
+		if blobs == 0 && lastSize > 0 {
+			fullCount--
+		}
+		if size >= testBlobSize {
+			resp.Tokens = append(resp.Tokens, testToken(slice.MinBid, fullCount, testBlobSize))
+		}
+		if blobs == 0 && lastSize > 0 {
+			resp.Tokens = append(resp.Tokens, testToken(m.bid, 1, lastSize))
+		}
+	}
+	m.locations[loc.Blobs[0].MinBid] = true
+	resp.Location = loc
+	return resp
+}
+
+func (m *memBlobs) putAt(args *access.PutAtArgs) error {
+	var bid proto.BlobID
+	var count, size uint32
+	if _, err := fmt.Sscanf(args.Token, "%d-%d-%d", &bid, &count, &size); err != nil ||
+		args.Blobid < bid || args.Blobid >= bid+proto.BlobID(count) || uint32(args.Size) != size {
+		return apierrors.ErrIllegalArguments
+	}
+	data, err := ioutil.ReadAll(args.Body)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failPutAt {
+		return apierrors.ErrUnexpected
+	}
+	m.data[args.Blobid] = data
+	return nil
+}
+
+func newMockAccess(t *testing.T, blobs *memBlobs) access.API {
+	api := mocks.NewMockAccessAPI(gomock.NewController(t))
+	api.EXPECT().Put(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, args *access.PutArgs) (access.Location, access.HashSumMap, error) {
+			data, err := ioutil.ReadAll(args.Body)
+			if err != nil {
+				return access.Location{}, nil, err
+			}
+			sum := md5.Sum(data)
+			hashSumMap := access.HashSumMap{access.HashAlgMD5: sum[:]}
+			if len(data) == 0 {
+				return access.Location{Blobs: make([]access.SliceInfo, 0)}, hashSumMap, nil
+			}
+
+			blobs.mu.Lock()
+			defer blobs.mu.Unlock()
+			blobs.bid++
+			blobs.locations[blobs.bid] = true
+			blobs.data[blobs.bid] = data
+			loc := access.Location{
+				ClusterID: 1,
+				Size:      uint64(len(data)),
+				BlobSize:  1 << 22,
+				Blobs:     []access.SliceInfo{{MinBid: blobs.bid, Vid: 1, Count: 1}},
+			}
+			return loc, hashSumMap, nil
+		})
+	api.EXPECT().Alloc(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, args *access.AllocArgs) (access.AllocResp, error) {
+			return blobs.alloc(args.Size), nil
+		})
+	api.EXPECT().PutAt(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, args *access.PutAtArgs) (access.HashSumMap, error) {
+			return nil, blobs.putAt(args)
+		})
+	api.EXPECT().Get(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, args *access.GetArgs) (io.ReadCloser, error) {
+			blobs.mu.Lock()
+			defer blobs.mu.Unlock()
+			var data []byte
+			for _, blob := range args.Location.Spread() {
+				blobData, ok := blobs.data[blob.Bid]
+				if !ok {
+					return nil, apierrors.ErrNotFound
+				}
+				data = append(data, blobData...)
+			}
+			data = data[args.Offset : args.Offset+args.ReadSize]
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		})
+	api.EXPECT().Delete(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, args *access.DeleteArgs) ([]access.Location, error) {
+			blobs.mu.Lock()
+			defer blobs.mu.Unlock()
+			for _, loc := range args.Locations {
+				if loc.Size == 0 {
+					continue
+				}
+				delete(blobs.locations, loc.Blobs[0].MinBid)
+				for _, blob := range loc.Spread() {
+					delete(blobs.data, blob.Bid)
+				}
+			}
+			return nil, nil
+		})
+	return api
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, *memBlobs) {
+	blobs := &memBlobs{locations: make(map[proto.BlobID]bool), data: make(map[proto.BlobID][]byte)}
+	kv := &memKV{kvs: make(map[string][]byte)}
+	svc := New(Config{DisableAuth: true, MinPartSize: 4}, newMockAccess(t, blobs), kv)
+	return httptest.NewServer(NewHandler(svc)), blobs
+}
+
+func doRequest(t *testing.T, method, url string, body []byte, headers map[string]string) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, data
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestS3GatewayBucket(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	resp, _ := doRequest(t, http.MethodPut, server.URL+"/a", nil, nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodHead, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	for _, bucket := range []string{"bucket", "bucket-2"} {
+		resp, _ = doRequest(t, http.MethodPut, server.URL+"/"+bucket, nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	resp, body := doRequest(t, http.MethodPut, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	errResp := new(errorResponse)
+	require.NoError(t, xml.Unmarshal(body, errResp))
+	require.Equal(t, ErrBucketAlreadyExists.Code, errResp.Code)
+
+	resp, _ = doRequest(t, http.MethodHead, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	buckets := new(ListBucketsResult)
+	require.NoError(t, xml.Unmarshal(body, buckets))
+	require.Equal(t, 2, len(buckets.Buckets))
+	require.Equal(t, "bucket", buckets.Buckets[0].Name)
+	require.Equal(t, "bucket-2", buckets.Buckets[1].Name)
+
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/key", []byte("data"), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/key", nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/", nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodHead, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestS3GatewayObject(t *testing.T) {
+	server, blobs := newTestServer(t)
+	defer server.Close()
+
+	resp, _ := doRequest(t, http.MethodPut, server.URL+"/bucket/key", []byte("data"), nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data := []byte("0123456789")
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/dir/key", data, map[string]string{
+		"Content-Type":    "text/plain",
+		"X-Amz-Meta-Name": "value",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, quoteETag(md5Hex(data)), resp.Header.Get("ETag"))
+	require.Equal(t, 1, blobs.len())
+
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/bucket/dir/key", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, data, body)
+	require.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	require.Equal(t, "value", resp.Header.Get("X-Amz-Meta-Name"))
+
+	resp, _ = doRequest(t, http.MethodHead, server.URL+"/bucket/dir/key", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int64(len(data)), resp.ContentLength)
+	require.Equal(t, quoteETag(md5Hex(data)), resp.Header.Get("ETag"))
+
+	for _, cs := range []struct {
+		rangeVal string
+		status   int
+		body     string
+	}{
+		{"bytes=2-5", http.StatusPartialContent, "2345"},
+		{"bytes=7-", http.StatusPartialContent, "789"},
+		{"bytes=-3", http.StatusPartialContent, "789"},
+		{"bytes=8-100", http.StatusPartialContent, "89"},
+		{"bytes=10-", http.StatusRequestedRangeNotSatisfiable, ""},
+		{"bytes=5-2", http.StatusOK, string(data)},
+	} {
+		resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/dir/key", nil, map[string]string{"Range": cs.rangeVal})
+		require.Equal(t, cs.status, resp.StatusCode, cs.rangeVal)
+		if cs.status != http.StatusRequestedRangeNotSatisfiable {
+			require.Equal(t, cs.body, string(body), cs.rangeVal)
+		}
+	}
+
+	// bad digest
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/dir/key", []byte("other"),
+		map[string]string{"Content-Md5": "1B2M2Y8AsgTpgAmY7PhCfg=="})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, 1, blobs.len())
+
+	// overwrite and the old data is deleted
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/dir/key", []byte("new data"), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, blobs.len())
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/dir/key", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "new data", string(body))
+
+	// empty object
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/empty", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/empty", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 0, len(body))
+
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/dir/key", nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, 0, blobs.len())
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/bucket/dir/key", nil, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/dir/key", nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// delete objects
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/key", data, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, body = doRequest(t, http.MethodPost, server.URL+"/bucket?delete",
+		[]byte("<Delete><Object><Key>key</Key></Object><Object><Key>empty</Key></Object></Delete>"), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	deleted := new(DeleteObjectsResult)
+	require.NoError(t, xml.Unmarshal(body, deleted))
+	require.Equal(t, 2, len(deleted.Deleted))
+	require.Equal(t, 0, blobs.len())
+}
+
+func TestS3GatewayListObjects(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	resp, _ := doRequest(t, http.MethodPut, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	keys := []string{"a", "b/1", "b/2", "b/3/x", "c", "c/1", "d"}
+	for _, key := range keys {
+		resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/"+key, []byte(key), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	listV1 := func(query string) *ListObjectsResult {
+		resp, body := doRequest(t, http.MethodGet, server.URL+"/bucket?"+query, nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		result := new(ListObjectsResult)
+		require.NoError(t, xml.Unmarshal(body, result))
+		return result
+	}
+	contentKeys := func(contents []Content) []string {
+		ret := make([]string, 0, len(contents))
+		for _, content := range contents {
+			ret = append(ret, content.Key)
+		}
+		return ret
+	}
+	prefixKeys := func(prefixes []CommonPrefix) []string {
+		ret := make([]string, 0, len(prefixes))
+		for _, prefix := range prefixes {
+			ret = append(ret, prefix.Prefix)
+		}
+		return ret
+	}
+
+	result := listV1("")
+	require.False(t, result.IsTruncated)
+	require.Equal(t, keys, contentKeys(result.Contents))
+
+	result = listV1("prefix=b/")
+	require.Equal(t, []string{"b/1", "b/2", "b/3/x"}, contentKeys(result.Contents))
+
+	result = listV1("delimiter=/")
+	require.Equal(t, []string{"a", "c", "d"}, contentKeys(result.Contents))
+	require.Equal(t, []string{"b/", "c/"}, prefixKeys(result.CommonPrefixes))
+
+	result = listV1("prefix=b/&delimiter=/")
+	require.Equal(t, []string{"b/1", "b/2"}, contentKeys(result.Contents))
+	require.Equal(t, []string{"b/3/"}, prefixKeys(result.CommonPrefixes))
+
+	// paging with common prefixes
+	result = listV1("delimiter=/&max-keys=2")
+	require.True(t, result.IsTruncated)
+	require.Equal(t, "b/", result.NextMarker)
+	require.Equal(t, []string{"a"}, contentKeys(result.Contents))
+	require.Equal(t, []string{"b/"}, prefixKeys(result.CommonPrefixes))
+	result = listV1("delimiter=/&max-keys=2&marker=" + result.NextMarker)
+	require.True(t, result.IsTruncated)
+	require.Equal(t, []string{"c"}, contentKeys(result.Contents))
+	require.Equal(t, []string{"c/"}, prefixKeys(result.CommonPrefixes))
+	result = listV1("delimiter=/&max-keys=2&marker=" + result.NextMarker)
+	require.False(t, result.IsTruncated)
+	require.Equal(t, []string{"d"}, contentKeys(result.Contents))
+
+	// marker is not an existing key
+	result = listV1("marker=b/25")
+	require.Equal(t, []string{"b/3/x", "c", "c/1", "d"}, contentKeys(result.Contents))
+
+	// list v2
+	all := make([]string, 0)
+	token := ""
+	for {
+		query := "list-type=2&max-keys=3"
+		if token != "" {
+			query += "&continuation-token=" + token
+		}
+		resp, body := doRequest(t, http.MethodGet, server.URL+"/bucket?"+query, nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		result := new(ListObjectsV2Result)
+		require.NoError(t, xml.Unmarshal(body, result))
+		require.Equal(t, len(result.Contents), result.KeyCount)
+		all = append(all, contentKeys(result.Contents)...)
+		if !result.IsTruncated {
+			break
+		}
+		token = strings.ReplaceAll(result.NextContinuationToken, "=", "%3D")
+	}
+	require.Equal(t, keys, all)
+}
+
+func TestS3GatewayMultipartUpload(t *testing.T) {
+	server, blobs := newTestServer(t)
+	defer server.Close()
+
+	resp, _ := doRequest(t, http.MethodPut, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	initiate := func() string {
+		resp, body := doRequest(t, http.MethodPost, server.URL+"/bucket/multi?uploads", nil,
+			map[string]string{"Content-Type": "text/plain"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		result := new(InitiateMultipartUploadResult)
+		require.NoError(t, xml.Unmarshal(body, result))
+		require.Equal(t, "multi", result.Key)
+		return result.UploadID
+	}
+	uploadPart := func(uploadID, partNumber string, data []byte) string {
+		resp, _ := doRequest(t, http.MethodPut,
+			server.URL+"/bucket/multi?partNumber="+partNumber+"&uploadId="+uploadID, data, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return resp.Header.Get("ETag")
+	}
+
+	// abort
+	uploadID := initiate()
+	uploadPart(uploadID, "1", []byte("abcde"))
+	require.Equal(t, 1, blobs.len())
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/multi?uploadId="+uploadID, nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, 0, blobs.len())
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/multi?uploadId="+uploadID, nil, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// complete
+	uploadID = initiate()
+	resp, _ = doRequest(t, http.MethodPut, server.URL+"/bucket/multi?partNumber=0&uploadId="+uploadID, []byte("x"), nil)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	etag1 := uploadPart(uploadID, "1", []byte("abcde"))
+	etag2 := uploadPart(uploadID, "2", []byte("fghij"))
+	uploadPart(uploadID, "3", []byte("unused"))
+	etag3 := uploadPart(uploadID, "3", []byte("xyz"))
+	uploadPart(uploadID, "4", []byte("unused"))
+	require.Equal(t, 4, blobs.len())
+
+	resp, body := doRequest(t, http.MethodGet, server.URL+"/bucket?uploads", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	uploads := new(ListMultipartUploadsResult)
+	require.NoError(t, xml.Unmarshal(body, uploads))
+	require.Equal(t, 1, len(uploads.Uploads))
+	require.Equal(t, uploadID, uploads.Uploads[0].UploadID)
+
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/multi?max-parts=3&uploadId="+uploadID, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	parts := new(ListPartsResult)
+	require.NoError(t, xml.Unmarshal(body, parts))
+	require.Equal(t, 3, len(parts.Parts))
+	require.True(t, parts.IsTruncated)
+	require.Equal(t, 3, parts.NextPartNumberMarker)
+
+	complete := func(parts ...string) (*http.Response, []byte) {
+		buf := bytes.NewBufferString("<CompleteMultipartUpload>")
+		for idx := 0; idx < len(parts); idx += 2 {
+			buf.WriteString("<Part><PartNumber>" + parts[idx] + "</PartNumber><ETag>" + parts[idx+1] + "</ETag></Part>")
+		}
+		buf.WriteString("</CompleteMultipartUpload>")
+		return doRequest(t, http.MethodPost, server.URL+"/bucket/multi?uploadId="+uploadID, buf.Bytes(), nil)
+	}
+	resp, _ = complete("2", etag2, "1", etag1)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = complete("1", etag2)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = complete("3", etag3, "4", etag3)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = complete("3", etag3, "5", etag1)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, body = complete("1", etag1, "2", etag2, "3", etag3)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	completed := new(CompleteMultipartUploadResult)
+	require.NoError(t, xml.Unmarshal(body, completed))
+	require.True(t, strings.HasSuffix(completed.ETag, "-3\""))
+	// data of the unused part is deleted
+	require.Equal(t, 3, blobs.len())
+
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/multi", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "abcdefghijxyz", string(body))
+	require.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	require.Equal(t, completed.ETag, resp.Header.Get("ETag"))
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/multi", nil, map[string]string{"Range": "bytes=3-11"})
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "defghijxy", string(body))
+
+	resp, _ = doRequest(t, http.MethodGet, server.URL+"/bucket/multi?uploadId="+uploadID, nil, nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket?uploads", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	uploads = new(ListMultipartUploadsResult)
+	require.NoError(t, xml.Unmarshal(body, uploads))
+	require.Equal(t, 0, len(uploads.Uploads))
+
+	resp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/multi", nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, 0, blobs.len())
+}
+
+func TestS3GatewayUploadPartBlobs(t *testing.T) {
+	server, blobs := newTestServer(t)
+	defer server.Close()
+
+	resp, _ := doRequest(t, http.MethodPut, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, body := doRequest(t, http.MethodPost, server.URL+"/bucket/multi?uploads", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	result := new(InitiateMultipartUploadResult)
+	require.NoError(t, xml.Unmarshal(body, result))
+	partURL := server.URL + "/bucket/multi?uploadId=" + result.UploadID + "&partNumber="
+
+	// full blobs, blobs in two slices with a short last one, and a single short blob
+	datas := []string{"abcdefgh", "ijklmnopqrs", "tuv"}
+	completeBody := bytes.NewBufferString("<CompleteMultipartUpload>")
+	for idx, data := range datas {
+		partNumber := fmt.Sprint(idx + 1)
+		resp, _ = doRequest(t, http.MethodPut, partURL+partNumber, []byte(data), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, quoteETag(md5Hex([]byte(data))), resp.Header.Get("ETag"))
+		completeBody.WriteString("<Part><PartNumber>" + partNumber + "</PartNumber><ETag>" +
+			resp.Header.Get("ETag") + "</ETag></Part>")
+	}
+	completeBody.WriteString("</CompleteMultipartUpload>")
+	require.Equal(t, 3, blobs.len())
+
+	// the allocated location is deleted if the part is not put
+	resp, _ = doRequest(t, http.MethodPut, partURL+"4", []byte("wxyz"),
+		map[string]string{"Content-MD5": "1B2M2Y8AsgTpgAmY7PhCfg=="})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	blobs.mu.Lock()
+	blobs.failPutAt = true
+	blobs.mu.Unlock()
+	resp, _ = doRequest(t, http.MethodPut, partURL+"4", []byte("wxyz"), nil)
+	require.NotEqual(t, http.StatusOK, resp.StatusCode)
+	blobs.mu.Lock()
+	blobs.failPutAt = false
+	blobs.mu.Unlock()
+	require.Equal(t, 3, blobs.len())
+
+	// nothing is listed with max-parts and max-uploads of 0
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/multi?max-parts=0&uploadId="+result.UploadID, nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	parts := new(ListPartsResult)
+	require.NoError(t, xml.Unmarshal(body, parts))
+	require.Equal(t, 0, parts.MaxParts)
+	require.Equal(t, 0, len(parts.Parts))
+	require.False(t, parts.IsTruncated)
+	resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket?uploads&max-uploads=0", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	uploads := new(ListMultipartUploadsResult)
+	require.NoError(t, xml.Unmarshal(body, uploads))
+	require.Equal(t, 0, len(uploads.Uploads))
+
+	// the upload is either completed or aborted
+	var wg sync.WaitGroup
+	var completeResp, abortResp *http.Response
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		completeResp, _ = doRequest(t, http.MethodPost, server.URL+"/bucket/multi?uploadId="+result.UploadID,
+			completeBody.Bytes(), nil)
+	}()
+	go func() {
+		defer wg.Done()
+		abortResp, _ = doRequest(t, http.MethodDelete, server.URL+"/bucket/multi?uploadId="+result.UploadID, nil, nil)
+	}()
+	wg.Wait()
+	if completeResp.StatusCode == http.StatusOK {
+		require.Equal(t, http.StatusNotFound, abortResp.StatusCode)
+		require.Equal(t, 3, blobs.len())
+		resp, body = doRequest(t, http.MethodGet, server.URL+"/bucket/multi", nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, strings.Join(datas, ""), string(body))
+	} else {
+		require.Equal(t, http.StatusNotFound, completeResp.StatusCode)
+		require.Equal(t, http.StatusNoContent, abortResp.StatusCode)
+		require.Equal(t, 0, blobs.len())
+	}
+}
+
+func TestS3GatewayListMultipartUploadsPrefix(t *testing.T) {
+	server, _ := newTestServer(t)
+	defer server.Close()
+
+	resp, _ := doRequest(t, http.MethodPut, server.URL+"/bucket", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	for _, key := range []string{"a/1", "b/1", "a/2", "b/2", "a/3", "b/3"} {
+		resp, _ = doRequest(t, http.MethodPost, server.URL+"/bucket/"+key+"?uploads", nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// the pages are full of the uploads with the prefix, and the last one is not truncated
+	keys := make(map[string]bool)
+	marker := ""
+	for page := 0; page < 2; page++ {
+		resp, body := doRequest(t, http.MethodGet, server.URL+"/bucket?uploads&prefix=a/&max-uploads=2&upload-id-marker="+marker, nil, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		uploads := new(ListMultipartUploadsResult)
+		require.NoError(t, xml.Unmarshal(body, uploads))
+		require.Equal(t, 2-page, len(uploads.Uploads))
+		require.Equal(t, page == 0, uploads.IsTruncated)
+		for _, upload := range uploads.Uploads {
+			require.True(t, strings.HasPrefix(upload.Key, "a/"))
+			keys[upload.Key] = true
+		}
+		marker = uploads.NextUploadIDMarker
+		require.Equal(t, page == 0, marker != "")
+	}
+	require.Equal(t, 3, len(keys))
+}
@@ -40,6 +40,7 @@ var Buckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
 // ClusterTopologyStatsMgr cluster topology stats manager
 type ClusterTopologyStatsMgr struct {
 	freeChunkCntRangeProHis *prometheus.HistogramVec
+	reclaimableSpaceGauge   *prometheus.GaugeVec
 }
 
 // NewClusterTopologyStatisticsMgr returns cluster topology stats manager
@@ -68,9 +69,26 @@ func NewClusterTopologyStatisticsMgr(clusterID proto.ClusterID, buckets []float6
 			panic(err)
 		}
 	}
+	reclaimableSpaceGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "reclaimable_space",
+			Help:        "space can be reclaimed by chunk compaction",
+			ConstLabels: labels,
+		},
+		[]string{"idc"},
+	)
+	if err := prometheus.Register(reclaimableSpaceGauge); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			reclaimableSpaceGauge = are.ExistingCollector.(*prometheus.GaugeVec)
+		} else {
+			panic(err)
+		}
+	}
 
 	return &ClusterTopologyStatsMgr{
 		freeChunkCntRangeProHis: freeChunkCntRangeProHis,
+		reclaimableSpaceGauge:   reclaimableSpaceGauge,
 	}
 }
 
@@ -79,6 +97,11 @@ func (statsMgr *ClusterTopologyStatsMgr) ReportFreeChunk(disk *api.DiskInfoSimpl
 	statsMgr.freeChunkCntRangeProHis.WithLabelValues(disk.Rack, disk.Idc).Observe(float64(disk.FreeChunkCnt))
 }
 
+// ReportReclaimableSpace report reclaimable space of idc
+func (statsMgr *ClusterTopologyStatsMgr) ReportReclaimableSpace(idc string, size int64) {
+	statsMgr.reclaimableSpaceGauge.WithLabelValues(idc).Set(float64(size))
+}
+
 // TaskCntStats information of task running on worker
 type TaskCntStats interface {
 	StatQueueTaskCnt() (preparing, workerDoing, finishing int)
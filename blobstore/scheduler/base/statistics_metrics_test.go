@@ -60,6 +60,7 @@ func TestNewClusterTopoStatisticsMgr(t *testing.T) {
 		FreeChunkCnt: 100,
 	}
 	mgr.ReportFreeChunk(disk)
+	mgr.ReportReclaimableSpace("z0", 1<<30)
 }
 
 func TestErrorStats(t *testing.T) {
@@ -286,16 +286,18 @@ func (vunit *VunitInfoSimple) set(info *cmapi.VolumeUnitInfo, host string) {
 
 // DiskInfoSimple disk simple info
 type DiskInfoSimple struct {
-	ClusterID    proto.ClusterID  `json:"cluster_id"`
-	DiskID       proto.DiskID     `json:"disk_id"`
-	Idc          string           `json:"idc"`
-	Rack         string           `json:"rack"`
-	Host         string           `json:"host"`
-	Status       proto.DiskStatus `json:"status"`
-	Readonly     bool             `json:"readonly"`
-	UsedChunkCnt int64            `json:"used_chunk_cnt"`
-	MaxChunkCnt  int64            `json:"max_chunk_cnt"`
-	FreeChunkCnt int64            `json:"free_chunk_cnt"`
+	ClusterID          proto.ClusterID  `json:"cluster_id"`
+	DiskID             proto.DiskID     `json:"disk_id"`
+	Idc                string           `json:"idc"`
+	Rack               string           `json:"rack"`
+	Host               string           `json:"host"`
+	Status             proto.DiskStatus `json:"status"`
+	Readonly           bool             `json:"readonly"`
+	UsedChunkCnt       int64            `json:"used_chunk_cnt"`
+	MaxChunkCnt        int64            `json:"max_chunk_cnt"`
+	FreeChunkCnt       int64            `json:"free_chunk_cnt"`
+	CompactingChunkCnt int64            `json:"compacting_chunk_cnt"`
+	ReclaimableSpace   int64            `json:"reclaimable_space"`
 }
 
 // IsHealth return true if disk is health
@@ -341,6 +343,8 @@ func (disk *DiskInfoSimple) set(info *blobnode.DiskInfo) {
 	disk.UsedChunkCnt = info.UsedChunkCnt
 	disk.MaxChunkCnt = info.MaxChunkCnt
 	disk.FreeChunkCnt = info.FreeChunkCnt
+	disk.CompactingChunkCnt = info.CompactingChunkCnt
+	disk.ReclaimableSpace = info.ReclaimableSpace
 }
 
 // RegisterInfo register info use for clustermgr
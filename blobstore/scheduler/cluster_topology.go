@@ -42,19 +42,21 @@ type clusterTopoConf struct {
 
 // ClusterTopology cluster topology
 type ClusterTopology struct {
-	clusterID    proto.ClusterID
-	idcMap       map[string]*IDC
-	diskMap      map[string][]*client.DiskInfoSimple
-	FreeChunkCnt int64
-	MaxChunkCnt  int64
+	clusterID        proto.ClusterID
+	idcMap           map[string]*IDC
+	diskMap          map[string][]*client.DiskInfoSimple
+	FreeChunkCnt     int64
+	MaxChunkCnt      int64
+	ReclaimableSpace int64
 }
 
 // IDC idc info
 type IDC struct {
-	name         string
-	rackMap      map[string]*Rack
-	FreeChunkCnt int64
-	MaxChunkCnt  int64
+	name             string
+	rackMap          map[string]*Rack
+	FreeChunkCnt     int64
+	MaxChunkCnt      int64
+	ReclaimableSpace int64
 }
 
 // Rack rack info
@@ -163,6 +165,9 @@ func (m *ClusterTopologyMgr) buildClusterTopo(disks []*client.DiskInfoSimple, cl
 	for idc := range cluster.diskMap {
 		sortDiskByFreeChunkCnt(cluster.diskMap[idc])
 	}
+	for idc := range cluster.idcMap {
+		m.taskStatsMgr.ReportReclaimableSpace(idc, cluster.idcMap[idc].ReclaimableSpace)
+	}
 	m.clusterTopo = cluster
 }
 
@@ -178,6 +183,7 @@ func (cluster *ClusterTopology) addDiskToCluster(disk *client.DiskInfoSimple) {
 	// statistics cluster chunk info
 	cluster.FreeChunkCnt += disk.FreeChunkCnt
 	cluster.MaxChunkCnt += disk.MaxChunkCnt
+	cluster.ReclaimableSpace += disk.ReclaimableSpace
 }
 
 func (cluster *ClusterTopology) addDiskToDiskMap(disk *client.DiskInfoSimple) {
@@ -199,6 +205,7 @@ func (cluster *ClusterTopology) addDiskToIdc(disk *client.DiskInfoSimple) {
 	// statistics idc chunk info
 	cluster.idcMap[idcName].FreeChunkCnt += disk.FreeChunkCnt
 	cluster.idcMap[idcName].MaxChunkCnt += disk.MaxChunkCnt
+	cluster.idcMap[idcName].ReclaimableSpace += disk.ReclaimableSpace
 }
 
 func (cluster *ClusterTopology) addDiskToRack(disk *client.DiskInfoSimple) {
@@ -34,6 +34,8 @@ var (
 		DiskID:       1,
 		FreeChunkCnt: 10,
 		MaxChunkCnt:  700,
+
+		ReclaimableSpace: 1 << 30,
 	}
 	topoDisk2 = &client.DiskInfoSimple{
 		ClusterID:    1,
@@ -43,6 +45,8 @@ var (
 		DiskID:       2,
 		FreeChunkCnt: 100,
 		MaxChunkCnt:  700,
+
+		ReclaimableSpace: 2 << 30,
 	}
 	topoDisk3 = &client.DiskInfoSimple{
 		ClusterID:    1,
@@ -98,6 +102,9 @@ func TestNewClusterTopologyMgr(t *testing.T) {
 	require.Equal(t, 1, len(disks))
 	disks = clusterTopMgr.GetIDCDisks("z3")
 	require.True(t, disks == nil)
+	require.Equal(t, int64(3<<30), clusterTopMgr.GetIDCs()["z0"].ReclaimableSpace)
+	require.Equal(t, int64(0), clusterTopMgr.GetIDCs()["z1"].ReclaimableSpace)
+	require.Equal(t, int64(3<<30), clusterTopMgr.clusterTopo.ReclaimableSpace)
 
 	ctr := gomock.NewController(t)
 	clusterMgrCli := NewMockClusterMgrAPI(ctr)
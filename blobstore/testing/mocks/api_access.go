@@ -36,6 +36,21 @@ func (m *MockAccessAPI) EXPECT() *MockAccessAPIMockRecorder {
 	return m.recorder
 }
 
+// Alloc mocks base method.
+func (m *MockAccessAPI) Alloc(arg0 context.Context, arg1 *access.AllocArgs) (access.AllocResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Alloc", arg0, arg1)
+	ret0, _ := ret[0].(access.AllocResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Alloc indicates an expected call of Alloc.
+func (mr *MockAccessAPIMockRecorder) Alloc(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Alloc", reflect.TypeOf((*MockAccessAPI)(nil).Alloc), arg0, arg1)
+}
+
 // Delete mocks base method.
 func (m *MockAccessAPI) Delete(arg0 context.Context, arg1 *access.DeleteArgs) ([]access.Location, error) {
 	m.ctrl.T.Helper()
@@ -81,3 +96,18 @@ func (mr *MockAccessAPIMockRecorder) Put(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockAccessAPI)(nil).Put), arg0, arg1)
 }
+
+// PutAt mocks base method.
+func (m *MockAccessAPI) PutAt(arg0 context.Context, arg1 *access.PutAtArgs) (access.HashSumMap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutAt", arg0, arg1)
+	ret0, _ := ret[0].(access.HashSumMap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutAt indicates an expected call of PutAt.
+func (mr *MockAccessAPIMockRecorder) PutAt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutAt", reflect.TypeOf((*MockAccessAPI)(nil).PutAt), arg0, arg1)
+}
@@ -38,6 +38,8 @@ const (
 	CliOpDelReplica        = "del-replica"
 	CliOpExpand            = "expand"
 	CliOpShrink            = "shrink"
+	CliOpSplit             = "split"
+	CliOpRevoke            = "revoke"
 
 	//Shorthand format of operation name
 	CliOpDecommissionShortHand = "dec"
@@ -92,6 +94,7 @@ const (
 	CliFlagDelWorkerSleepMs   = "deleteWorkerSleepMs"
 	CliFlagLoadFactor         = "loadFactor"
 	CliFlagMarkDelRate        = "markDeleteRate"
+	CliFlagMpSplitMem         = "mpSplitMemMB"
 	CliFlagCrossZone          = "crossZone"
 	CliNormalZonesFirst       = "normalZonesFirst"
 	CliFlagCount              = "count"
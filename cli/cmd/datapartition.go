@@ -162,6 +162,25 @@ The "reset" command will be released in next version`,
 					stdout(badPartitionTablePattern, bdpv.Path, pid)
 				}
 			}
+
+			stdout("\n")
+			stdout("%v\n", "[Partition with scrub corruption(not repaired)]:")
+			sort.SliceStable(diagnosis.ScrubCorruptPartitionIDs, func(i, j int) bool {
+				return diagnosis.ScrubCorruptPartitionIDs[i] < diagnosis.ScrubCorruptPartitionIDs[j]
+			})
+			for _, pid := range diagnosis.ScrubCorruptPartitionIDs {
+				var partition *proto.DataPartitionInfo
+				if partition, err = client.AdminAPI().GetDataPartition("", pid); err != nil {
+					err = fmt.Errorf("Partition not found, err:[%v] ", err)
+					return
+				}
+				stdout("%v\n", partitionInfoTableHeader)
+				stdout("%v\n", formatDataPartitionInfoRow(partition))
+				stdout("%v\n", formatDataReplicaScrubTableHeader())
+				for _, replica := range partition.Replicas {
+					stdout("%v\n", formatDataReplicaScrub(replica))
+				}
+			}
 			return
 		},
 	}
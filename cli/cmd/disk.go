@@ -0,0 +1,120 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"sort"
+
+	"github.com/cubefs/cubefs/proto"
+	sdk "github.com/cubefs/cubefs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdDiskUse   = CliResourceDisk + " [COMMAND]"
+	cmdDiskShort = "Manage the evacuation of bad disks"
+)
+
+func newDiskCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdDiskUse,
+		Short: cmdDiskShort,
+	}
+	cmd.AddCommand(
+		newDiskEvacuationListCmd(client),
+		newDiskEvacuationHoldCmd(client, true),
+		newDiskEvacuationHoldCmd(client, false),
+	)
+	return cmd
+}
+
+const (
+	cmdDiskEvacuationListShort    = "List the evacuation jobs of bad disks"
+	cmdDiskEvacuationHoldShort    = "Hold the automatic evacuation of a bad disk, or of all the disks on the node if no disk is given"
+	cmdDiskEvacuationReleaseShort = "Release a held disk evacuation"
+)
+
+func newDiskEvacuationListCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdDiskEvacuationListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var view *proto.DiskEvacuationsView
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = client.NodeAPI().QueryDiskEvacuation(); err != nil {
+				return
+			}
+			stdout("Concurrent migrations per node: %v\n", view.Limit)
+			stdout("Held : %v\n", view.Holds)
+			stdout("\n")
+			stdout("%v\n", formatDiskEvacuationTableHeader())
+			for _, job := range view.Jobs {
+				stdout("%v\n", formatDiskEvacuation(job))
+			}
+			for _, job := range view.Jobs {
+				ids := make([]uint64, 0, len(job.Failed))
+				for id := range job.Failed {
+					ids = append(ids, id)
+				}
+				sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+				for _, id := range ids {
+					stdout("failed: %v %v partition[%v] %v\n", job.Addr, job.DiskPath, id, job.Failed[id])
+				}
+			}
+		},
+	}
+	return cmd
+}
+
+func newDiskEvacuationHoldCmd(client *sdk.MasterClient, hold bool) *cobra.Command {
+	use, short := "hold", cmdDiskEvacuationHoldShort
+	if !hold {
+		use, short = "release", cmdDiskEvacuationReleaseShort
+	}
+	var cmd = &cobra.Command{
+		Use:   use + " [{HOST}:{PORT}] [DISK]",
+		Short: short,
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var diskPath string
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if len(args) > 1 {
+				diskPath = args[1]
+			}
+			if err = client.NodeAPI().HoldDiskEvacuation(args[0], diskPath, hold); err != nil {
+				return
+			}
+			stdout("%v disk evacuation of node[%v] disk[%v] successfully\n", use, args[0], diskPath)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
@@ -106,6 +106,7 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  DpReplicaNum         : %v\n", svv.DpReplicaNum))
 	sb.WriteString(fmt.Sprintf("  Follower read        : %v\n", formatEnabledDisabled(svv.FollowerRead)))
 	sb.WriteString(fmt.Sprintf("  Inode count          : %v\n", svv.InodeCount))
+	sb.WriteString(fmt.Sprintf("  Inline size limit    : %v byte\n", svv.InlineSizeLimit))
 	sb.WriteString(fmt.Sprintf("  Max metaPartition ID : %v\n", svv.MaxMetaPartitionID))
 	sb.WriteString(fmt.Sprintf("  MpCnt                : %v\n", svv.MpCnt))
 	sb.WriteString(fmt.Sprintf("  MpReplicaNum         : %v\n", svv.MpReplicaNum))
@@ -196,6 +197,13 @@ func formatDataPartitionInfo(partition *proto.DataPartitionInfo) string {
 		sb.WriteString(fmt.Sprintf("%v\n", formatDataReplica("", replica, true)))
 	}
 
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("Scrub : \n"))
+	sb.WriteString(fmt.Sprintf("%v\n", formatDataReplicaScrubTableHeader()))
+	for _, replica := range partition.Replicas {
+		sb.WriteString(fmt.Sprintf("%v\n", formatDataReplicaScrub(replica)))
+	}
+
 	sb.WriteString("\n")
 	sb.WriteString(fmt.Sprintf("FileInCoreMap : \n"))
 	sb.WriteString(fmt.Sprintf("%v\n", formatDataFileInCoreTableHeader()))
@@ -297,6 +305,51 @@ var (
 		"ID", "TYPE", "ACCESS KEY", "SECRET KEY", "CREATE TIME")
 )
 
+var (
+	userSessionTablePattern = "%-20v    %-16v    %-20v    %-20v    %-6v"
+	userSessionTableHeader  = fmt.Sprintf(userSessionTablePattern,
+		"USER ID", "ACCESS KEY", "CREATE TIME", "EXPIRATION", "POLICY")
+)
+
+func formatUserSessionTableRow(session *proto.UserSession) string {
+	return fmt.Sprintf(userSessionTablePattern,
+		session.UserID, session.AccessKey, session.CreateTime, formatTime(session.Expiration), formatYesNo(session.Policy != ""))
+}
+
+var (
+	userKeyTablePattern = "%-20v    %-16v    %-8v    %-20v    %-20v"
+	userKeyTableHeader  = fmt.Sprintf(userKeyTablePattern,
+		"NAME", "ACCESS KEY", "STATUS", "CREATE TIME", "LAST USED")
+)
+
+func formatUserKeyTableRow(key *proto.UserAccessKey) string {
+	var lastUsed = "N/A"
+	if key.LastUsedTime > 0 {
+		lastUsed = formatTime(key.LastUsedTime)
+	}
+	return fmt.Sprintf(userKeyTablePattern,
+		key.Name, key.AccessKey, key.Status, key.CreateTime, lastUsed)
+}
+
+var (
+	userGroupTablePattern = "%-20v    %-8v    %-8v    %-20v    %v"
+	userGroupTableHeader  = fmt.Sprintf(userGroupTablePattern,
+		"GROUP ID", "MEMBERS", "ROLES", "CREATE TIME", "DESCRIPTION")
+	userRoleTablePattern = "%-20v    %-8v    %-20v    %v"
+	userRoleTableHeader  = fmt.Sprintf(userRoleTablePattern,
+		"ROLE ID", "VOLUMES", "CREATE TIME", "DESCRIPTION")
+)
+
+func formatUserGroupTableRow(group *proto.UserGroup) string {
+	return fmt.Sprintf(userGroupTablePattern,
+		group.GroupID, len(group.Members), len(group.Roles), group.CreateTime, group.Description)
+}
+
+func formatUserRoleTableRow(role *proto.UserRole) string {
+	return fmt.Sprintf(userRoleTablePattern,
+		role.RoleID, len(role.Policy.AuthorizedVols), role.CreateTime, role.Description)
+}
+
 func formatUserInfoTableRow(userInfo *proto.UserInfo) string {
 	return fmt.Sprintf(userInfoTablePattern,
 		userInfo.UserID, formatUserType(userInfo.UserType), userInfo.AccessKey, userInfo.SecretKey, userInfo.CreateTime)
@@ -452,6 +505,34 @@ func formatDataReplica(indentation string, replica *proto.DataReplica, rowTable
 	return sb.String()
 }
 
+var dataReplicaScrubTableRowPattern = "%-18v    %-8v    %-16v    %-12v    %-12v    %-12v    %-20v"
+
+func formatDataReplicaScrubTableHeader() string {
+	return fmt.Sprintf(dataReplicaScrubTableRowPattern, "ADDR", "ROUND", "PROGRESS", "CHECKED", "CORRUPT", "REPAIRED", "LAST SCRUB TIME")
+}
+
+func formatDataReplicaScrub(replica *proto.DataReplica) string {
+	scrub := replica.Scrub
+	lastScrubTime := "N/A"
+	if scrub.LastScrubTime > 0 {
+		lastScrubTime = formatTime(scrub.LastScrubTime)
+	}
+	return fmt.Sprintf(dataReplicaScrubTableRowPattern, replica.Addr, scrub.Round,
+		fmt.Sprintf("%v/%v", scrub.ScrubbedExtents, scrub.TotalExtents), scrub.CheckedBlocks, scrub.CorruptBlocks,
+		scrub.RepairedBlocks, lastScrubTime)
+}
+
+var diskEvacuationTableRowPattern = "%-18v    %-16v    %-10v    %-10v    %-10v    %-8v    %-8v    %-20v"
+
+func formatDiskEvacuationTableHeader() string {
+	return fmt.Sprintf(diskEvacuationTableRowPattern, "ADDR", "DISK", "STATUS", "REMAINING", "MIGRATED", "RUNNING", "FAILED", "UPDATE TIME")
+}
+
+func formatDiskEvacuation(job *proto.DiskEvacuationView) string {
+	return fmt.Sprintf(diskEvacuationTableRowPattern, job.Addr, job.DiskPath, job.Status, job.Remaining, job.Migrated,
+		len(job.Running), len(job.Failed), formatTime(job.UpdateTime))
+}
+
 var metaReplicaTableRowPattern = "%-18v    %-6v    %-6v    %-10v"
 
 func formatMetaReplicaTableHeader() string {
@@ -0,0 +1,576 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdGroupUse   = "group [COMMAND]"
+	cmdGroupShort = "Manage user groups and roles"
+)
+
+func newGroupCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdGroupUse,
+		Short: cmdGroupShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newGroupCreateCmd(client),
+		newGroupDeleteCmd(client),
+		newGroupInfoCmd(client),
+		newGroupListCmd(client),
+		newGroupAddMemberCmd(client),
+		newGroupRemoveMemberCmd(client),
+		newGroupPermCmd(client),
+		newGroupGrantCmd(client),
+		newGroupRevokeCmd(client),
+		newRoleCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdGroupCreateUse          = "create [GROUP ID]"
+	cmdGroupCreateShort        = "Create a new user group"
+	cmdGroupDeleteUse          = "delete [GROUP ID]"
+	cmdGroupDeleteShort        = "Delete the specified user group"
+	cmdGroupInfoUse            = "info [GROUP ID]"
+	cmdGroupInfoShort          = "Show detail information about specified user group"
+	cmdGroupListShort          = "List user groups"
+	cmdGroupAddMemberUse       = "add-member [GROUP ID] [USER ID]"
+	cmdGroupAddMemberShort     = "Add a user to the group"
+	cmdGroupRemoveMemberUse    = "remove-member [GROUP ID] [USER ID]"
+	cmdGroupRemoveMemberShort  = "Remove a user from the group"
+	cmdGroupPermUse            = "perm [GROUP ID] [VOLUME] [PERM (READONLY,RO,READWRITE,RW,NONE)]"
+	cmdGroupPermShort          = "Setup volume permission for a user group"
+	cmdGroupGrantUse           = "grant [GROUP ID] [ROLE ID]"
+	cmdGroupGrantShort         = "Grant a role to the group"
+	cmdGroupRevokeUse          = "revoke [GROUP ID] [ROLE ID]"
+	cmdGroupRevokeShort        = "Revoke a role from the group"
+	cmdGroupOptDescriptionHelp = "Description"
+)
+
+func newGroupCreateCmd(client *master.MasterClient) *cobra.Command {
+	var optDescription string
+	var cmd = &cobra.Command{
+		Use:   cmdGroupCreateUse,
+		Short: cmdGroupCreateShort,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var group *proto.UserGroup
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			param := &proto.GroupCreateParam{ID: args[0], Description: optDescription}
+			if group, err = client.UserAPI().CreateGroup(param); err != nil {
+				err = fmt.Errorf("Create group failed: %v\n", err)
+				return
+			}
+			stdout("Create group success:\n")
+			printUserGroup(group)
+		},
+	}
+	cmd.Flags().StringVar(&optDescription, "description", "", cmdGroupOptDescriptionHelp)
+	return cmd
+}
+
+func newGroupDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdGroupDeleteUse,
+		Short: cmdGroupDeleteShort,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var groupID = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !optYes {
+				stdout("Delete group [%v] (yes/no)[no]:", groupID)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.UserAPI().DeleteGroup(groupID); err != nil {
+				err = fmt.Errorf("Delete group failed:\n%v\n", err)
+				return
+			}
+			stdout("Delete group success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validGroups(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newGroupInfoCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdGroupInfoUse,
+		Short: cmdGroupInfoShort,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var group *proto.UserGroup
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if group, err = client.UserAPI().GetGroup(args[0]); err != nil {
+				err = fmt.Errorf("Get group info failed: %v\n", err)
+				return
+			}
+			printUserGroup(group)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validGroups(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newGroupListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdGroupListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var groups []*proto.UserGroup
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if groups, err = client.UserAPI().ListGroups(); err != nil {
+				return
+			}
+			stdout("%v\n", userGroupTableHeader)
+			for _, group := range groups {
+				stdout("%v\n", formatUserGroupTableRow(group))
+			}
+		},
+	}
+	return cmd
+}
+
+func newGroupAddMemberCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdGroupAddMemberUse,
+		Short: cmdGroupAddMemberShort,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var group *proto.UserGroup
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if group, err = client.UserAPI().AddGroupMember(args[0], args[1]); err != nil {
+				err = fmt.Errorf("Add group member failed: %v\n", err)
+				return
+			}
+			printUserGroup(group)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return validGroups(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+			case 1:
+				return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newGroupRemoveMemberCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdGroupRemoveMemberUse,
+		Short: cmdGroupRemoveMemberShort,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var group *proto.UserGroup
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if group, err = client.UserAPI().RemoveGroupMember(args[0], args[1]); err != nil {
+				err = fmt.Errorf("Remove group member failed: %v\n", err)
+				return
+			}
+			printUserGroup(group)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validGroups(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newGroupPermCmd(client *master.MasterClient) *cobra.Command {
+	var subdir string
+	var cmd = &cobra.Command{
+		Use:   cmdGroupPermUse,
+		Short: cmdGroupPermShort,
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var group *proto.UserGroup
+			var perm proto.Permission
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if perm, err = parsePermission(args[2], subdir); err != nil {
+				return
+			}
+			if perm.IsNone() {
+				param := &proto.GroupPermRemoveParam{ID: args[0], Volume: args[1]}
+				group, err = client.UserAPI().RemoveGroupPolicy(param)
+			} else {
+				param := &proto.GroupPermUpdateParam{ID: args[0], Volume: args[1], Policy: []string{perm.String()}}
+				group, err = client.UserAPI().UpdateGroupPolicy(param)
+			}
+			if err != nil {
+				return
+			}
+			printUserGroup(group)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validGroups(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&subdir, "subdir", "", "Subdir")
+	return cmd
+}
+
+func newGroupGrantCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdGroupGrantUse,
+		Short: cmdGroupGrantShort,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var group *proto.UserGroup
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if group, err = client.UserAPI().GrantGroupRole(args[0], args[1]); err != nil {
+				err = fmt.Errorf("Grant role failed: %v\n", err)
+				return
+			}
+			printUserGroup(group)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return validGroups(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+			case 1:
+				return validRoles(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newGroupRevokeCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdGroupRevokeUse,
+		Short: cmdGroupRevokeShort,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var group *proto.UserGroup
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if group, err = client.UserAPI().RevokeGroupRole(args[0], args[1]); err != nil {
+				err = fmt.Errorf("Revoke role failed: %v\n", err)
+				return
+			}
+			printUserGroup(group)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validGroups(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+const (
+	cmdRoleUse         = "role [COMMAND]"
+	cmdRoleShort       = "Manage roles which are granted to user groups"
+	cmdRoleCreateUse   = "create [ROLE ID]"
+	cmdRoleCreateShort = "Create a new role"
+	cmdRoleDeleteUse   = "delete [ROLE ID]"
+	cmdRoleDeleteShort = "Delete the specified role"
+	cmdRoleInfoUse     = "info [ROLE ID]"
+	cmdRoleInfoShort   = "Show detail information about specified role"
+	cmdRoleListShort   = "List roles"
+	cmdRolePermUse     = "perm [ROLE ID] [VOLUME] [PERM (READONLY,RO,READWRITE,RW,NONE)]"
+	cmdRolePermShort   = "Setup volume permission for a role"
+)
+
+func newRoleCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdRoleUse,
+		Short: cmdRoleShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newRoleCreateCmd(client),
+		newRoleDeleteCmd(client),
+		newRoleInfoCmd(client),
+		newRoleListCmd(client),
+		newRolePermCmd(client),
+	)
+	return cmd
+}
+
+func newRoleCreateCmd(client *master.MasterClient) *cobra.Command {
+	var optDescription string
+	var cmd = &cobra.Command{
+		Use:   cmdRoleCreateUse,
+		Short: cmdRoleCreateShort,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var role *proto.UserRole
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			param := &proto.GroupCreateParam{ID: args[0], Description: optDescription}
+			if role, err = client.UserAPI().CreateRole(param); err != nil {
+				err = fmt.Errorf("Create role failed: %v\n", err)
+				return
+			}
+			stdout("Create role success:\n")
+			printUserRole(role)
+		},
+	}
+	cmd.Flags().StringVar(&optDescription, "description", "", cmdGroupOptDescriptionHelp)
+	return cmd
+}
+
+func newRoleDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdRoleDeleteUse,
+		Short: cmdRoleDeleteShort,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var roleID = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !optYes {
+				stdout("Delete role [%v] (yes/no)[no]:", roleID)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.UserAPI().DeleteRole(roleID); err != nil {
+				err = fmt.Errorf("Delete role failed:\n%v\n", err)
+				return
+			}
+			stdout("Delete role success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validRoles(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newRoleInfoCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdRoleInfoUse,
+		Short: cmdRoleInfoShort,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var role *proto.UserRole
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if role, err = client.UserAPI().GetRole(args[0]); err != nil {
+				err = fmt.Errorf("Get role info failed: %v\n", err)
+				return
+			}
+			printUserRole(role)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validRoles(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newRoleListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdRoleListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var roles []*proto.UserRole
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if roles, err = client.UserAPI().ListRoles(); err != nil {
+				return
+			}
+			stdout("%v\n", userRoleTableHeader)
+			for _, role := range roles {
+				stdout("%v\n", formatUserRoleTableRow(role))
+			}
+		},
+	}
+	return cmd
+}
+
+func newRolePermCmd(client *master.MasterClient) *cobra.Command {
+	var subdir string
+	var cmd = &cobra.Command{
+		Use:   cmdRolePermUse,
+		Short: cmdRolePermShort,
+		Args:  cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var role *proto.UserRole
+			var perm proto.Permission
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if perm, err = parsePermission(args[2], subdir); err != nil {
+				return
+			}
+			if perm.IsNone() {
+				param := &proto.GroupPermRemoveParam{ID: args[0], Volume: args[1]}
+				role, err = client.UserAPI().RemoveRolePolicy(param)
+			} else {
+				param := &proto.GroupPermUpdateParam{ID: args[0], Volume: args[1], Policy: []string{perm.String()}}
+				role, err = client.UserAPI().UpdateRolePolicy(param)
+			}
+			if err != nil {
+				return
+			}
+			printUserRole(role)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validRoles(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&subdir, "subdir", "", "Subdir")
+	return cmd
+}
+
+func printUserGroup(group *proto.UserGroup) {
+	stdout("[Summary]\n")
+	stdout("  Group ID   : %v\n", group.GroupID)
+	stdout("  Members    : %v\n", strings.Join(group.Members, ","))
+	stdout("  Roles      : %v\n", strings.Join(group.Roles, ","))
+	stdout("  Description: %v\n", group.Description)
+	stdout("  Create Time: %v\n", group.CreateTime)
+	printVolumePermissions(group.Policy)
+}
+
+func printUserRole(role *proto.UserRole) {
+	stdout("[Summary]\n")
+	stdout("  Role ID    : %v\n", role.RoleID)
+	stdout("  Description: %v\n", role.Description)
+	stdout("  Create Time: %v\n", role.CreateTime)
+	printVolumePermissions(role.Policy)
+}
+
+func printVolumePermissions(policy *proto.UserPolicy) {
+	if policy == nil {
+		return
+	}
+	stdout("[Volumes]\n")
+	stdout("%-20v    %-12v\n", "VOLUME", "PERMISSION")
+	for vol, perms := range policy.AuthorizedVols {
+		stdout("%-20v    %-12v\n", vol, strings.Join(perms, ","))
+	}
+}
@@ -39,6 +39,7 @@ func newMetaPartitionCmd(client *master.MasterClient) *cobra.Command {
 		newMetaPartitionDecommissionCmd(client),
 		newMetaPartitionReplicateCmd(client),
 		newMetaPartitionDeleteReplicaCmd(client),
+		newMetaPartitionSplitCmd(client),
 	)
 	return cmd
 }
@@ -49,6 +50,7 @@ const (
 	cmdMetaPartitionDecommissionShort  = "Decommission a replication of the meta partition to a new address"
 	cmdMetaPartitionReplicateShort     = "Add a replication of the meta partition on a new address"
 	cmdMetaPartitionDeleteReplicaShort = "Delete a replication of the meta partition on a fixed address"
+	cmdMetaPartitionSplitShort         = "Split the inode range of a meta partition into a new meta partition"
 )
 
 func newMetaPartitionGetCmd(client *master.MasterClient) *cobra.Command {
@@ -268,3 +270,38 @@ func newMetaPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 	}
 	return cmd
 }
+
+func newMetaPartitionSplitCmd(client *master.MasterClient) *cobra.Command {
+	var optStart uint64
+	var cmd = &cobra.Command{
+		Use:   CliOpSplit + " [VOLUME] [META PARTITION ID]",
+		Short: cmdMetaPartitionSplitShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				return
+			}
+			if err = client.AdminAPI().SplitMetaPartition(args[0], partitionID, optStart); err != nil {
+				return
+			}
+			stdout("Split meta partition successfully\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint64Var(&optStart, CliFlagINodeStartID, 0, "The first inode of the new meta partition, split in the middle of the allocated inodes if not set")
+	return cmd
+}
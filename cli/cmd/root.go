@@ -55,8 +55,10 @@ func NewRootCmd(client *master.MasterClient) *CubeFSCmd {
 		cmd.newClusterCmd(client),
 		newVolCmd(client),
 		newUserCmd(client),
+		newGroupCmd(client),
 		newMetaNodeCmd(client),
 		newDataNodeCmd(client),
+		newDiskCmd(client),
 		newDataPartitionCmd(client),
 		newMetaPartitionCmd(client),
 		newConfigCmd(),
@@ -41,6 +41,8 @@ func newUserCmd(client *master.MasterClient) *cobra.Command {
 		newUserPermCmd(client),
 		newUserUpdateCmd(client),
 		newUserDeleteCmd(client),
+		newUserSessionCmd(client),
+		newUserKeyCmd(client),
 	)
 	return cmd
 }
@@ -328,20 +330,7 @@ func newUserPermCmd(client *master.MasterClient) *cobra.Command {
 				}
 			}()
 
-			perm = proto.BuiltinPermissionPrefix
-			if subdir != "" && subdir != "/" {
-				perm = proto.Permission(string(perm) + subdir + ":")
-			}
-
-			switch strings.ToLower(args[2]) {
-			case "ro", "readonly":
-				perm = perm + "ReadOnly"
-			case "rw", "readwrite":
-				perm = perm + "Writable"
-			case "none":
-				perm = proto.NonePermission
-			default:
-				err = fmt.Errorf("Permission must be on of ro, rw, none ")
+			if perm, err = parsePermission(args[2], subdir); err != nil {
 				return
 			}
 			stdout("Setup volume permission\n")
@@ -417,6 +406,286 @@ func newUserListCmd(client *master.MasterClient) *cobra.Command {
 	return cmd
 }
 
+const (
+	cmdUserSessionUse         = "session [COMMAND]"
+	cmdUserSessionShort       = "Manage temporary sessions of users"
+	cmdUserSessionListShort   = "List sessions of all users or the specified user"
+	cmdUserSessionRevokeUse   = "revoke [USER ID]"
+	cmdUserSessionRevokeShort = "Revoke all sessions of the user or the session of the specified access key"
+)
+
+func newUserSessionCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdUserSessionUse,
+		Short: cmdUserSessionShort,
+	}
+	cmd.AddCommand(
+		newUserSessionListCmd(client),
+		newUserSessionRevokeCmd(client),
+	)
+	return cmd
+}
+
+func newUserSessionListCmd(client *master.MasterClient) *cobra.Command {
+	var optUserID string
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdUserSessionListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var sessions []*proto.UserSession
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if sessions, err = client.UserAPI().ListSessions(optUserID); err != nil {
+				return
+			}
+			stdout("%v\n", userSessionTableHeader)
+			for _, session := range sessions {
+				stdout("%v\n", formatUserSessionTableRow(session))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optUserID, CliFlagOnwer, "", "Specify the user to list sessions of")
+	return cmd
+}
+
+func newUserSessionRevokeCmd(client *master.MasterClient) *cobra.Command {
+	var optAccessKey string
+	var cmd = &cobra.Command{
+		Use:   cmdUserSessionRevokeUse,
+		Short: cmdUserSessionRevokeShort,
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var userID string
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if len(args) > 0 {
+				userID = args[0]
+			}
+			if userID == "" && optAccessKey == "" {
+				err = fmt.Errorf("Specify the user or the access key of the session to revoke.\n")
+				return
+			}
+			if err = client.UserAPI().RevokeSession(optAccessKey, userID); err != nil {
+				err = fmt.Errorf("Revoke session failed:\n%v\n", err)
+				return
+			}
+			stdout("Revoke session success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optAccessKey, "access-key", "", "Specify the access key of the session to revoke")
+	return cmd
+}
+
+const (
+	cmdUserKeyUse          = "key [COMMAND]"
+	cmdUserKeyShort        = "Manage access keys of users"
+	cmdUserKeyAddUse       = "add [USER ID] [NAME]"
+	cmdUserKeyAddShort     = "Add an access key to the user"
+	cmdUserKeyEnableUse    = "enable [USER ID] [ACCESS KEY]"
+	cmdUserKeyEnableShort  = "Enable the access key of the user"
+	cmdUserKeyDisableUse   = "disable [USER ID] [ACCESS KEY]"
+	cmdUserKeyDisableShort = "Disable the access key of the user"
+	cmdUserKeyDeleteUse    = "delete [USER ID] [ACCESS KEY]"
+	cmdUserKeyDeleteShort  = "Delete the access key of the user"
+	cmdUserKeyListUse      = "list [USER ID]"
+	cmdUserKeyListShort    = "List access keys of the user"
+)
+
+func newUserKeyCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdUserKeyUse,
+		Short: cmdUserKeyShort,
+	}
+	cmd.AddCommand(
+		newUserKeyAddCmd(client),
+		newUserKeySetStatusCmd(client, cmdUserKeyEnableUse, cmdUserKeyEnableShort, proto.AccessKeyStatusActive),
+		newUserKeySetStatusCmd(client, cmdUserKeyDisableUse, cmdUserKeyDisableShort, proto.AccessKeyStatusInactive),
+		newUserKeyDeleteCmd(client),
+		newUserKeyListCmd(client),
+	)
+	return cmd
+}
+
+func newUserKeyAddCmd(client *master.MasterClient) *cobra.Command {
+	var optAccessKey string
+	var optSecretKey string
+	var cmd = &cobra.Command{
+		Use:   cmdUserKeyAddUse,
+		Short: cmdUserKeyAddShort,
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var param = proto.UserKeyCreateParam{
+				UserID:    args[0],
+				AccessKey: optAccessKey,
+				SecretKey: optSecretKey,
+			}
+			if len(args) > 1 {
+				param.Name = args[1]
+			}
+			var key *proto.UserAccessKey
+			if key, err = client.UserAPI().AddKey(&param); err != nil {
+				err = fmt.Errorf("Add access key failed:\n%v\n", err)
+				return
+			}
+			stdout("Add access key success:\n")
+			stdout("  Name       : %v\n", key.Name)
+			stdout("  Access Key : %v\n", key.AccessKey)
+			stdout("  Secret Key : %v\n", key.SecretKey)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optAccessKey, "access-key", "", "Specify the access key [16 digits & letters]")
+	cmd.Flags().StringVar(&optSecretKey, "secret-key", "", "Specify the secret key [32 digits & letters]")
+	return cmd
+}
+
+func newUserKeySetStatusCmd(client *master.MasterClient, use, short, status string) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if err = client.UserAPI().SetKeyStatus(args[0], args[1], status); err != nil {
+				err = fmt.Errorf("Set access key status failed:\n%v\n", err)
+				return
+			}
+			stdout("Access key [%v] is %v now.\n", args[1], status)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newUserKeyDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdUserKeyDeleteUse,
+		Short: cmdUserKeyDeleteShort,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var userID = args[0]
+			var accessKey = args[1]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !optYes {
+				stdout("Delete access key [%v] of user [%v] (yes/no)[no]:", accessKey, userID)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.UserAPI().DeleteKey(userID, accessKey); err != nil {
+				err = fmt.Errorf("Delete access key failed:\n%v\n", err)
+				return
+			}
+			stdout("Delete access key success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newUserKeyListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     cmdUserKeyListUse,
+		Short:   cmdUserKeyListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var keys []*proto.UserAccessKey
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if keys, err = client.UserAPI().ListKeys(args[0]); err != nil {
+				return
+			}
+			stdout("%v\n", userKeyTableHeader)
+			for _, key := range keys {
+				stdout("%v\n", formatUserKeyTableRow(key))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+// parsePermission parses the permission (READONLY,RO,READWRITE,RW,NONE) on the subdir of a volume.
+func parsePermission(value, subdir string) (perm proto.Permission, err error) {
+	perm = proto.BuiltinPermissionPrefix
+	if subdir != "" && subdir != "/" {
+		perm = proto.Permission(string(perm) + subdir + ":")
+	}
+
+	switch strings.ToLower(value) {
+	case "ro", "readonly":
+		perm = perm + "ReadOnly"
+	case "rw", "readwrite":
+		perm = perm + "Writable"
+	case "none":
+		perm = proto.NonePermission
+	default:
+		err = fmt.Errorf("Permission must be on of ro, rw, none ")
+	}
+	return
+}
+
 func printUserInfo(userInfo *proto.UserInfo) {
 	stdout("[Summary]\n")
 	stdout("  User ID    : %v\n", userInfo.UserID)
@@ -424,6 +693,9 @@ func printUserInfo(userInfo *proto.UserInfo) {
 	stdout("  Secret Key : %v\n", userInfo.SecretKey)
 	stdout("  Type       : %v\n", userInfo.UserType)
 	stdout("  Create Time: %v\n", userInfo.CreateTime)
+	if len(userInfo.Groups) > 0 {
+		stdout("  Groups     : %v\n", strings.Join(userInfo.Groups, ","))
+	}
 	if userInfo.Policy == nil {
 		return
 	}
@@ -435,4 +707,10 @@ func printUserInfo(userInfo *proto.UserInfo) {
 	for vol, perms := range userInfo.Policy.AuthorizedVols {
 		stdout("%-20v    %-12v\n", vol, strings.Join(perms, ","))
 	}
+	if userInfo.InheritedPolicy == nil {
+		return
+	}
+	for vol, perms := range userInfo.InheritedPolicy.AuthorizedVols {
+		stdout("%-20v    %-12v\n", vol, strings.Join(perms, ",")+" (inherited)")
+	}
 }
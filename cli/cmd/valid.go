@@ -15,6 +15,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/cubefs/cubefs/proto"
 	sdk "github.com/cubefs/cubefs/sdk/master"
 )
@@ -82,6 +84,40 @@ func validUsers(client *sdk.MasterClient, toComplete string) []string {
 	return validUsers
 }
 
+func validGroups(client *sdk.MasterClient, toComplete string) []string {
+	var (
+		validGroups []string
+		groups      []*proto.UserGroup
+		err         error
+	)
+	if groups, err = client.UserAPI().ListGroups(); err != nil {
+		errout("Error: %v", err)
+	}
+	for _, group := range groups {
+		if strings.HasPrefix(group.GroupID, toComplete) {
+			validGroups = append(validGroups, group.GroupID)
+		}
+	}
+	return validGroups
+}
+
+func validRoles(client *sdk.MasterClient, toComplete string) []string {
+	var (
+		validRoles []string
+		roles      []*proto.UserRole
+		err        error
+	)
+	if roles, err = client.UserAPI().ListRoles(); err != nil {
+		errout("Error: %v", err)
+	}
+	for _, role := range roles {
+		if strings.HasPrefix(role.RoleID, toComplete) {
+			validRoles = append(validRoles, role.RoleID)
+		}
+	}
+	return validRoles
+}
+
 func validZones(client *sdk.MasterClient, toComplete string) []string {
 	var (
 		validZones []string
@@ -33,6 +33,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 // used to locate the position in parent
@@ -180,8 +181,13 @@ func (d *Dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.Cr
 		stat.EndStat("Create", err, bgTime, 1)
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: d.super.volname})
 	}()
+	span, ctx := trace.StartSpanFromContext(ctx, "fuse.Create")
+	span.SetTag("parent", d.info.Inode)
+	defer func() {
+		span.Finish(err)
+	}()
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(req.Mode.Perm()), req.Uid, req.Gid, nil)
+	info, err := d.super.mw.CreateContext(ctx, d.info.Inode, req.Name, proto.Mode(req.Mode.Perm()), req.Uid, req.Gid, nil)
 	if err != nil {
 		log.LogErrorf("Create: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, nil, ParseError(err)
@@ -234,8 +240,13 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 		stat.EndStat("Mkdir", err, bgTime, 1)
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: d.super.volname})
 	}()
+	span, ctx := trace.StartSpanFromContext(ctx, "fuse.Mkdir")
+	span.SetTag("parent", d.info.Inode)
+	defer func() {
+		span.Finish(err)
+	}()
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(os.ModeDir|req.Mode.Perm()), req.Uid, req.Gid, nil)
+	info, err := d.super.mw.CreateContext(ctx, d.info.Inode, req.Name, proto.Mode(os.ModeDir|req.Mode.Perm()), req.Uid, req.Gid, nil)
 	if err != nil {
 		log.LogErrorf("Mkdir: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, ParseError(err)
@@ -301,12 +312,17 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	defer func() {
 		stat.EndStat("Lookup", err, bgTime, 1)
 	}()
+	span, ctx := trace.StartSpanFromContext(ctx, "fuse.Lookup")
+	span.SetTag("parent", d.info.Inode)
+	defer func() {
+		span.Finish(err)
+	}()
 
 	log.LogDebugf("TRACE Lookup: parent(%v) req(%v)", d.info.Inode, req)
 
 	ino, ok := d.dcache.Get(req.Name)
 	if !ok {
-		ino, _, err = d.super.mw.Lookup_ll(d.info.Inode, req.Name)
+		ino, _, err = d.super.mw.LookupContext(ctx, d.info.Inode, req.Name)
 		if err != nil {
 			if err != syscall.ENOENT {
 				log.LogErrorf("Lookup: parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
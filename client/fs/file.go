@@ -33,6 +33,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 
 	"github.com/cubefs/cubefs/sdk/data/blobstore"
 )
@@ -94,6 +95,7 @@ func NewFile(s *Super, i *proto.InodeInfo, flag uint32, pino uint64) fs.Node {
 			FileCache:       false,
 			FileSize:        i.Size,
 			CacheThreshold:  s.CacheThreshold,
+			Packer:          s.packer,
 		}
 		log.LogDebugf("Trace NewFile:flag(%v). clientConf(%v)", flag, clientConf)
 
@@ -160,6 +162,11 @@ func (f *File) Attr(ctx context.Context, a *fuse.Attr) error {
 	log.LogDebugf("Attr: ino(%v) fileSize(%v) gen(%v) inode.gen(%v)", ino, fileSize, gen, info.Generation)
 	if gen >= info.Generation {
 		a.Size = uint64(fileSize)
+	} else if f.super.autoInvalData && proto.IsHot(f.super.volType) {
+		// changed by other clients, drop the stale data cached locally
+		if err := f.super.ec.InvalidateCache(ino); err != nil {
+			log.LogWarnf("Attr: invalidate cache failed, ino(%v) err(%v)", ino, err)
+		}
 	}
 	if proto.IsSymlink(info.Mode) {
 		a.Size = uint64(len(info.Target))
@@ -259,6 +266,7 @@ func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenR
 			FileCache:       false,
 			FileSize:        uint64(fileSize),
 			CacheThreshold:  f.super.CacheThreshold,
+			Packer:          f.super.packer,
 		}
 
 		switch req.Flags & 0x0f {
@@ -329,9 +337,14 @@ func (f *File) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadR
 	defer func() {
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: f.super.volname})
 	}()
+	span, ctx := trace.StartSpanFromContext(ctx, "fuse.Read")
+	span.SetTag("ino", f.info.Inode)
+	defer func() {
+		span.Finish(err)
+	}()
 	var size int
 	if proto.IsHot(f.super.volType) {
-		size, err = f.super.ec.Read(f.info.Inode, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
+		size, err = f.super.ec.ReadContext(ctx, f.info.Inode, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
 	} else {
 		size, err = f.fReader.Read(ctx, resp.Data[fuse.OutHeaderSize:], int(req.Offset), req.Size)
 	}
@@ -424,10 +437,15 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	defer func() {
 		metric.SetWithLabels(err, map[string]string{exporter.Vol: f.super.volname})
 	}()
+	span, ctx := trace.StartSpanFromContext(ctx, "fuse.Write")
+	span.SetTag("ino", ino)
+	defer func() {
+		span.Finish(err)
+	}()
 	var size int
 	if proto.IsHot(f.super.volType) {
 		f.super.ec.GetStreamer(ino).SetParentInode(f.parentIno)
-		size, err = f.super.ec.Write(ino, int(req.Offset), req.Data, flags)
+		size, err = f.super.ec.WriteContext(ctx, ino, int(req.Offset), req.Data, flags)
 	} else { // if proto.IsCold(f.super.volType)
 		atomic.StoreInt32(&f.idle, 0)
 		size, err = f.fWriter.Write(ctx, int(req.Offset), req.Data, flags)
@@ -37,6 +37,7 @@ import (
 	"github.com/cubefs/cubefs/sdk/meta"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 	"github.com/cubefs/cubefs/util/ump"
 
 	"github.com/cubefs/cubefs/blockcache/bcache"
@@ -60,6 +61,7 @@ type Super struct {
 
 	disableDcache bool
 	fsyncOnClose  bool
+	autoInvalData bool
 	enableXattr   bool
 	rootIno       uint64
 
@@ -79,6 +81,7 @@ type Super struct {
 	writeThreads   int
 	bc             *bcache.BcacheClient
 	ebsc           *blobstore.BlobStoreClient
+	packer         *blobstore.Packer
 	sc             *SummaryCache
 }
 
@@ -131,6 +134,7 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	s.nodeCache = make(map[uint64]fs.Node)
 	s.disableDcache = opt.DisableDcache
 	s.fsyncOnClose = opt.FsyncOnClose
+	s.autoInvalData = opt.AutoInvalData > 0
 	s.enableXattr = opt.EnableXattr
 
 	if s.mw.EnableSummary {
@@ -175,26 +179,37 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	}
 
 	var extentConfig = &stream.ExtentConfig{
-		Volume:            opt.Volname,
-		Masters:           masters,
-		FollowerRead:      opt.FollowerRead,
-		NearRead:          opt.NearRead,
-		ReadRate:          opt.ReadRate,
-		WriteRate:         opt.WriteRate,
-		VolumeType:        opt.VolType,
-		BcacheEnable:      opt.EnableBcache,
-		BcacheDir:         opt.BcacheDir,
-		MaxStreamerLimit:  opt.MaxStreamerLimit,
-		OnAppendExtentKey: s.mw.AppendExtentKey,
-		OnGetExtents:      s.mw.GetExtents,
-		OnTruncate:        s.mw.Truncate,
-		OnEvictIcache:     s.ic.Delete,
-		OnLoadBcache:      s.bc.Get,
-		OnCacheBcache:     s.bc.Put,
-		OnEvictBcache:     s.bc.Evict,
+		Volume:             opt.Volname,
+		Masters:            masters,
+		FollowerRead:       opt.FollowerRead,
+		NearRead:           opt.NearRead,
+		ReadRate:           opt.ReadRate,
+		WriteRate:          opt.WriteRate,
+		VolumeType:         opt.VolType,
+		BcacheEnable:       opt.EnableBcache,
+		BcacheDir:          opt.BcacheDir,
+		MaxStreamerLimit:   opt.MaxStreamerLimit,
+		ReadAheadMemMB:     opt.ReadAheadMemMB,
+		ReadAheadWindowMB:  opt.ReadAheadWindowMB,
+		WriteBackCache:     opt.WriteBackCache,
+		BuffersTotalLimit:  opt.BuffersTotalLimit,
+		ReadConsistency:    opt.ReadConsistency,
+		ReadMaxStalenessMS: opt.ReadMaxStalenessMS,
+		OnAppendExtentKey:  s.mw.AppendExtentKey,
+		OnGetExtents:       s.mw.GetExtents,
+		OnTruncate:         s.mw.Truncate,
+		OnEvictIcache:      s.ic.Delete,
+		OnLoadBcache:       s.bc.Get,
+		OnCacheBcache:      s.bc.Put,
+		OnEvictBcache:      s.bc.Evict,
+		OnInlineWrite:      s.mw.InlineWrite,
+		OnInlineRead:       s.mw.InlineRead,
 
 		DisableMetaCache: DisableMetaCache,
 	}
+	if opt.Authenticate {
+		extentConfig.Credential = packetauth.NewCredential(opt.Owner, opt.TicketMess)
+	}
 
 	s.ec, err = stream.NewExtentClient(extentConfig)
 	if err != nil {
@@ -214,6 +229,17 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		if err != nil {
 			return nil, errors.Trace(err, "NewEbsClient failed!")
 		}
+		if opt.EbsPackSize > 0 {
+			s.packer = blobstore.NewPacker(blobstore.PackerConfig{
+				VolName:     opt.Volname,
+				Mw:          s.mw,
+				Ebsc:        s.ebsc,
+				BlockSize:   opt.EbsBlockSize,
+				MaxFileSize: int(opt.EbsPackSize),
+				Compact:     opt.EbsPackCompact,
+			})
+			log.LogInfof("NewSuper: small file packing enabled, %v", s.packer)
+		}
 	}
 
 	if !opt.EnablePosixACL {
@@ -56,6 +56,7 @@ import (
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
 	"github.com/cubefs/cubefs/util/stat"
+	"github.com/cubefs/cubefs/util/trace"
 	"github.com/cubefs/cubefs/util/ump"
 	"github.com/jacobsa/daemonize"
 )
@@ -295,6 +296,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	// the mode of the cluster is followed if not configured
+	tlsTransport, err := util.NewTLSTransport(util.LoadTLSConfig(cfg).WithClusterMode(opt.TLSMode))
+	if err != nil {
+		err = errors.NewErrorf("parse tls config failed: %v\n", err)
+		fmt.Println(err)
+		daemonize.SignalOutcome(err)
+		os.Exit(1)
+	}
+	util.SetTLSTransport(tlsTransport)
+
 	if opt.MaxCPUs > 0 {
 		runtime.GOMAXPROCS(int(opt.MaxCPUs))
 	} else {
@@ -409,6 +420,13 @@ func main() {
 	exporter.Init(ModuleName, cfg)
 	exporter.RegistConsul(super.ClusterName(), ModuleName, cfg)
 
+	if err = trace.Init(ModuleName, cfg); err != nil {
+		log.LogFlush()
+		syslog.Printf("init trace err(%v)", err)
+		os.Exit(1)
+	}
+	defer trace.Stop()
+
 	err = log.OutputPid(opt.Logpath, ModuleName)
 	if err != nil {
 		log.LogFlush()
@@ -700,11 +718,22 @@ func parseMountOption(cfg *config.Config) (*proto.MountOptions, error) {
 	opt.BuffersTotalLimit = GlobalMountOptions[proto.BuffersTotalLimit].GetInt64()
 	opt.MetaSendTimeout = GlobalMountOptions[proto.MetaSendTimeout].GetInt64()
 	opt.MaxStreamerLimit = GlobalMountOptions[proto.MaxStreamerLimit].GetInt64()
+	opt.ReadAheadMemMB = GlobalMountOptions[proto.ReadAheadMemMB].GetInt64()
+	opt.ReadAheadWindowMB = GlobalMountOptions[proto.ReadAheadWindowMB].GetInt64()
+	opt.WriteBackCache = GlobalMountOptions[proto.WriteBackCache].GetBool()
+	opt.ReadConsistency = GlobalMountOptions[proto.ReadConsistency].GetString()
+	opt.ReadMaxStalenessMS = GlobalMountOptions[proto.ReadMaxStalenessMS].GetInt64()
+	opt.EbsPackSize = GlobalMountOptions[proto.EbsPackSize].GetInt64()
+	opt.EbsPackCompact = GlobalMountOptions[proto.EbsPackCompact].GetBool()
 
 	if opt.MountPoint == "" || opt.Volname == "" || opt.Owner == "" || opt.Master == "" {
 		return nil, errors.New(fmt.Sprintf("invalid config file: lack of mandatory fields, mountPoint(%v), volName(%v), owner(%v), masterAddr(%v)", opt.MountPoint, opt.Volname, opt.Owner, opt.Master))
 	}
 
+	if !proto.IsValidReadConsistency(opt.ReadConsistency) {
+		return nil, errors.New(fmt.Sprintf("invalid fields, ReadConsistency(%v) must be one of leader, bounded or any", opt.ReadConsistency))
+	}
+
 	if opt.BuffersTotalLimit < 0 {
 		return nil, errors.New(fmt.Sprintf("invalid fields, BuffersTotalLimit(%v) must larger or equal than 0", opt.BuffersTotalLimit))
 	}
@@ -728,12 +757,12 @@ func checkPermission(opt *proto.MountOptions) (err error) {
 		if policy.IsOwn(opt.Volname) {
 			return
 		}
-		if policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXWriteAction) &&
-			policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXReadAction) {
+		if userInfo.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXWriteAction) &&
+			userInfo.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXReadAction) {
 			return
 		}
-		if policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXReadAction) &&
-			!policy.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXWriteAction) {
+		if userInfo.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXReadAction) &&
+			!userInfo.IsAuthorized(opt.Volname, opt.SubDir, proto.POSIXWriteAction) {
 			opt.Rdonly = true
 			return
 		}
@@ -793,5 +822,6 @@ func loadConfFromMaster(opt *proto.MountOptions) (err error) {
 	}
 	opt.EbsEndpoint = clusterInfo.EbsAddr
 	opt.EbsServicePath = clusterInfo.ServicePath
+	opt.TLSMode = clusterInfo.TLSMode
 	return
 }
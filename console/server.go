@@ -12,8 +12,11 @@ import (
 	"github.com/cubefs/cubefs/console/service"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/graphql/client"
+	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/federation"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 	"github.com/gorilla/mux"
 	"github.com/samsarahq/thunder/graphql"
 	"github.com/samsarahq/thunder/graphql/introspection"
@@ -38,14 +41,33 @@ func (c *ConsoleNode) Start(cfg *config.Config) error {
 
 	c.server.HandleFunc(proto.ConsoleIQL, cutil.IQLFun)
 
-	loginService := service.NewLoginService(cli)
+	fed, err := federation.NewFederation(cfg, master.NewMasterClient(c.masters, false))
+	if err != nil {
+		return err
+	}
+	loginService := service.NewLoginService(cli, fed)
 	c.addHandle(proto.ConsoleLoginAPI, loginService.Schema(), loginService)
+	c.server.HandleFunc(proto.ConsoleLoginSSO, func(writer http.ResponseWriter, request *http.Request) {
+		if err := loginService.SSORedirect(writer, request); err != nil {
+			c.writeError(err, writer)
+		}
+	}).Methods("GET")
+	c.server.HandleFunc(proto.ConsoleLoginSSOCallback, func(writer http.ResponseWriter, request *http.Request) {
+		if err := loginService.SSOCallback(writer, request); err != nil {
+			c.writeError(err, writer)
+		}
+	}).Methods("GET")
 
 	monitorService := service.NewMonitorService(cfg.GetString("monitor_addr"), cfg.GetString("monitor_app"), cfg.GetString("monitor_cluster"), cfg.GetString("dashboard_addr"))
 	c.addHandle(proto.ConsoleMonitorAPI, monitorService.Schema(), monitorService)
 	c.addHandle("/jiankong", monitorService.Schema(), monitorService)
 
-	fileService := service.NewFileService(c.objectNodeDomain, c.masters, cli)
+	// the volumes with authentication enabled are accessed with the tickets of the console
+	credential, err := packetauth.LoadCredential(cfg)
+	if err != nil {
+		return err
+	}
+	fileService := service.NewFileService(c.objectNodeDomain, c.masters, cli, credential)
 	c.server.HandleFunc(proto.ConsoleFileDown, func(writer http.ResponseWriter, request *http.Request) {
 		if err := fileService.DownFile(writer, request); err != nil {
 			c.writeError(err, writer)
@@ -19,6 +19,7 @@ import (
 	"github.com/cubefs/cubefs/sdk/graphql/client"
 	"github.com/cubefs/cubefs/sdk/graphql/client/user"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 	"github.com/samsarahq/thunder/graphql"
 	"github.com/samsarahq/thunder/graphql/schemabuilder"
 )
@@ -29,9 +30,9 @@ type FileService struct {
 	objectNode string
 }
 
-func NewFileService(objectNode string, masters []string, mc *client.MasterGClient) *FileService {
+func NewFileService(objectNode string, masters []string, mc *client.MasterGClient, credential *packetauth.Credential) *FileService {
 	return &FileService{
-		manager:    NewVolumeManager(masters, true),
+		manager:    NewVolumeManager(masters, true, credential),
 		userClient: &user.UserClient{mc},
 		objectNode: objectNode,
 	}
@@ -2,24 +2,37 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/http"
+
 	"github.com/cubefs/cubefs/console/cutil"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/graphql/client"
 	"github.com/cubefs/cubefs/sdk/graphql/client/user"
+	"github.com/cubefs/cubefs/util/federation"
+	"github.com/cubefs/cubefs/util/log"
 	"github.com/samsarahq/thunder/graphql"
 	"github.com/samsarahq/thunder/graphql/schemabuilder"
 )
 
+const ssoStateCookie = "cubefs_sso_state"
+
 type LoginService struct {
 	client     *client.MasterGClient
 	userClient *user.UserClient
+	federation *federation.Federation
 }
 
-func NewLoginService(client *client.MasterGClient) *LoginService {
+// NewLoginService creates the login service, the federation is nil if no identity provider is configured.
+func NewLoginService(client *client.MasterGClient, federation *federation.Federation) *LoginService {
 	return &LoginService{
 		client:     client,
 		userClient: user.NewUserClient(client),
+		federation: federation,
 	}
 }
 
@@ -35,14 +48,41 @@ func (ls *LoginService) login(ctx context.Context, args struct {
 	Password string
 	empty    bool
 }) (*UserToken, error) {
+	userID := args.UserID
 	_, err := ls.client.ValidatePassword(ctx, args.UserID, args.Password)
+	if err != nil {
+		// the users of the LDAP server login with their LDAP passwords
+		if ls.federation == nil || ls.federation.LDAP() == nil {
+			return nil, err
+		}
+		_, userInfo, ferr := ls.federation.LoginWithPassword(args.UserID, args.Password)
+		if ferr != nil {
+			log.LogWarnf("ldap login fail: user(%v) err(%v)", args.UserID, ferr)
+			return nil, err
+		}
+		userID = userInfo.UserID
+	}
+	return ls.issueToken(ctx, userID)
+}
+
+// loginWithToken logins with the ID token issued by the OpenID Connect provider.
+func (ls *LoginService) loginWithToken(ctx context.Context, args struct {
+	Token string
+}) (*UserToken, error) {
+	if ls.federation == nil {
+		return nil, federation.ErrProviderDisabled
+	}
+	_, userInfo, err := ls.federation.LoginWithToken(args.Token)
 	if err != nil {
 		return nil, err
 	}
+	return ls.issueToken(ctx, userInfo.UserID)
+}
 
-	ctx = context.WithValue(ctx, proto.UserKey, args.UserID)
+func (ls *LoginService) issueToken(ctx context.Context, userID string) (*UserToken, error) {
+	ctx = context.WithValue(ctx, proto.UserKey, userID)
 
-	userInfo, err := ls.userClient.GetUserInfoForLogin(ctx, args.UserID)
+	userInfo, err := ls.userClient.GetUserInfoForLogin(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -54,10 +94,74 @@ func (ls *LoginService) login(ctx context.Context, args struct {
 	}, nil
 }
 
+// SSORedirect redirects the browser to the authorization endpoint of the OpenID Connect provider,
+// the state is kept in a cookie to be checked in the callback.
+func (ls *LoginService) SSORedirect(writer http.ResponseWriter, request *http.Request) error {
+	if ls.federation == nil || ls.federation.OIDC() == nil {
+		return federation.ErrProviderDisabled
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	state := hex.EncodeToString(b)
+	authURL, err := ls.federation.OIDC().AuthCodeURL(state)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(writer, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     proto.ConsoleLoginSSO,
+		MaxAge:   600,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(writer, request, authURL, http.StatusFound)
+	return nil
+}
+
+// SSOCallback exchanges the authorization code for the ID token, and writes the login token.
+func (ls *LoginService) SSOCallback(writer http.ResponseWriter, request *http.Request) error {
+	if ls.federation == nil || ls.federation.OIDC() == nil {
+		return federation.ErrProviderDisabled
+	}
+	query := request.URL.Query()
+	if errMsg := query.Get("error"); errMsg != "" {
+		return fmt.Errorf("sso login fail: %s %s", errMsg, query.Get("error_description"))
+	}
+	cookie, err := request.Cookie(ssoStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(query.Get("state"))) != 1 {
+		return fmt.Errorf("sso login fail: state mismatch")
+	}
+	http.SetCookie(writer, &http.Cookie{Name: ssoStateCookie, Path: proto.ConsoleLoginSSO, MaxAge: -1})
+
+	identity, err := ls.federation.OIDC().Exchange(query.Get("code"))
+	if err != nil {
+		return err
+	}
+	userInfo, err := ls.federation.Provision(identity)
+	if err != nil {
+		return err
+	}
+	token, err := ls.issueToken(request.Context(), userInfo.UserID)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	_, err = writer.Write(value)
+	return err
+}
+
 func (ls *LoginService) Schema() *graphql.Schema {
 	schema := schemabuilder.NewSchema()
 	query := schema.Query()
 	query.FieldFunc("login", ls.login)
+	query.FieldFunc("loginWithToken", ls.loginWithToken)
 	return schema.MustBuild()
 }
 
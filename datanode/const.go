@@ -20,6 +20,12 @@ const (
 	NumOfFilesToRecoverInParallel = 10  // number of files to be recovered simultaneously
 )
 
+// Follower read with bounded staleness
+const (
+	FollowerReadMaxWaitAppliedMs = 100 // max time to wait for the applied ID required by a follower read
+	followerReadWaitIntervalMs   = 5
+)
+
 // Network protocol
 const (
 	NetworkProtocol = "tcp"
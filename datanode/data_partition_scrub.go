@@ -0,0 +1,225 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/repl"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultScrubRateMB       = 4  // MB per second of every disk, 0 disables scrubbing
+	DefaultScrubIntervalHour = 24 // minimum interval between two scrub rounds of a disk
+)
+
+// doScrubTask periodically re-reads every block of the normal extents on the disk,
+// verifies it against the block crc and repairs the corrupt ones from a healthy replica.
+// It returns once stopC is closed.
+func (d *Disk) doScrubTask(stopC <-chan bool) {
+	limiter := rate.NewLimiter(rate.Limit(d.dataNode.scrubRate), util.BlockSize)
+	buf := make([]byte, util.BlockSize)
+	for {
+		start := time.Now()
+		partitions := make([]*DataPartition, 0)
+		d.RLock()
+		for _, dp := range d.partitionMap {
+			partitions = append(partitions, dp)
+		}
+		d.RUnlock()
+		for _, dp := range partitions {
+			select {
+			case <-stopC:
+				return
+			default:
+			}
+			dp.scrub(limiter, buf)
+		}
+		wait := d.dataNode.scrubInterval - time.Since(start)
+		if wait < time.Minute {
+			wait = time.Minute
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-stopC:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (dp *DataPartition) isStopped() bool {
+	select {
+	case <-dp.stopC:
+		return true
+	default:
+		return false
+	}
+}
+
+// ScrubInfo returns the scrub progress of the data partition.
+func (dp *DataPartition) ScrubInfo() proto.ScrubInfo {
+	dp.scrubMutex.Lock()
+	defer dp.scrubMutex.Unlock()
+	return dp.scrubInfo
+}
+
+func (dp *DataPartition) updateScrubInfo(update func(info *proto.ScrubInfo)) {
+	dp.scrubMutex.Lock()
+	update(&dp.scrubInfo)
+	dp.scrubMutex.Unlock()
+}
+
+func (dp *DataPartition) scrub(limiter *rate.Limiter, buf []byte) {
+	if !dp.isNormalType() || dp.isLoadingDataPartition || dp.isStopped() {
+		return
+	}
+	extents, _, err := dp.extentStore.GetAllWatermarks(storage.NormalExtentFilter())
+	if err != nil {
+		log.LogWarnf("action[scrub] partition(%v) get watermarks err(%v).", dp.partitionID, err)
+		return
+	}
+	dp.updateScrubInfo(func(info *proto.ScrubInfo) {
+		info.TotalExtents = len(extents)
+		info.ScrubbedExtents = 0
+		info.CheckedBlocks = 0
+		info.CorruptBlocks = 0
+		info.RepairedBlocks = 0
+	})
+	for _, ei := range extents {
+		if dp.isStopped() {
+			return
+		}
+		dp.scrubExtent(limiter, ei.FileID, buf)
+		dp.updateScrubInfo(func(info *proto.ScrubInfo) {
+			info.ScrubbedExtents++
+		})
+	}
+	stat := dp.ScrubInfo()
+	dp.updateScrubInfo(func(info *proto.ScrubInfo) {
+		info.Round++
+		info.LastScrubTime = time.Now().Unix()
+	})
+	log.LogInfof("action[scrub] partition(%v) finish round(%v) extents(%v) checked(%v) corrupt(%v) repaired(%v).",
+		dp.partitionID, stat.Round+1, stat.TotalExtents, stat.CheckedBlocks, stat.CorruptBlocks, stat.RepairedBlocks)
+}
+
+func (dp *DataPartition) scrubExtent(limiter *rate.Limiter, extentID uint64, buf []byte) {
+	store := dp.ExtentStore()
+	for blockNo := 0; ; blockNo++ {
+		if dp.isStopped() {
+			return
+		}
+		if err := limiter.WaitN(context.Background(), util.BlockSize); err != nil {
+			return
+		}
+		dp.Disk().allocCheckLimit(proto.FlowReadType, util.BlockSize)
+		size, crc, err := store.ScrubBlock(extentID, blockNo, buf)
+		if err == storage.ParameterMismatchError || err == storage.ExtentHasBeenDeletedError {
+			return
+		}
+		if err != nil && err != storage.BlockCrcMismatchError {
+			log.LogWarnf("action[scrubExtent] extent(%v_%v) block(%v) err(%v).", dp.partitionID, extentID, blockNo, err)
+			return
+		}
+		if crc == 0 {
+			continue
+		}
+		dp.updateScrubInfo(func(info *proto.ScrubInfo) {
+			info.CheckedBlocks++
+		})
+		if err == nil {
+			continue
+		}
+		log.LogErrorf("action[scrubExtent] extent(%v_%v) block(%v) size(%v) corrupt, expect crc(%v).",
+			dp.partitionID, extentID, blockNo, size, crc)
+		dp.updateScrubInfo(func(info *proto.ScrubInfo) {
+			info.CorruptBlocks++
+		})
+		if err = dp.repairBlockFromReplicas(extentID, blockNo, size); err != nil {
+			log.LogErrorf("action[scrubExtent] extent(%v_%v) block(%v) repair err(%v).", dp.partitionID, extentID, blockNo, err)
+			continue
+		}
+		log.LogWarnf("action[scrubExtent] extent(%v_%v) block(%v) repaired.", dp.partitionID, extentID, blockNo)
+		dp.updateScrubInfo(func(info *proto.ScrubInfo) {
+			info.RepairedBlocks++
+		})
+	}
+}
+
+// repairBlockFromReplicas overwrites a corrupt local block with the first replica
+// copy that matches the local block crc.
+func (dp *DataPartition) repairBlockFromReplicas(extentID uint64, blockNo, size int) (err error) {
+	err = fmt.Errorf("no other replica")
+	for _, addr := range dp.getReplicaCopy() {
+		if addr == dp.dataNode.localServerAddr {
+			continue
+		}
+		var data []byte
+		if data, err = dp.readReplicaBlock(addr, extentID, blockNo, size); err != nil {
+			log.LogWarnf("action[repairBlockFromReplicas] extent(%v_%v) block(%v) read from(%v) err(%v).",
+				dp.partitionID, extentID, blockNo, addr, err)
+			continue
+		}
+		if err = dp.ExtentStore().RepairBlock(extentID, blockNo, data); err != nil {
+			log.LogWarnf("action[repairBlockFromReplicas] extent(%v_%v) block(%v) repair from(%v) err(%v).",
+				dp.partitionID, extentID, blockNo, addr, err)
+			continue
+		}
+		return nil
+	}
+	return
+}
+
+func (dp *DataPartition) readReplicaBlock(addr string, extentID uint64, blockNo, size int) (data []byte, err error) {
+	request := repl.NewExtentRepairReadPacket(dp.partitionID, extentID, blockNo*util.BlockSize, size)
+	conn, err := dp.getRepairConn(addr)
+	if err != nil {
+		return
+	}
+	defer func() {
+		dp.putRepairConn(conn, err != nil)
+	}()
+	if err = request.WriteToConn(conn); err != nil {
+		return
+	}
+	reply := repl.NewPacket()
+	if err = reply.ReadFromConn(conn, proto.ReadDeadlineTime); err != nil {
+		return
+	}
+	if reply.ResultCode != proto.OpOk {
+		err = fmt.Errorf("result code(%v) msg(%v)", reply.ResultCode, string(reply.Data[:intMin(len(reply.Data), int(reply.Size))]))
+		return
+	}
+	if reply.ReqID != request.ReqID || reply.ExtentID != extentID ||
+		reply.ExtentOffset != request.ExtentOffset || int(reply.Size) != size {
+		err = fmt.Errorf("unavalid request(%v) reply(%v)", request.GetUniqueLogId(), reply.GetUniqueLogId())
+		return
+	}
+	if reply.CRC != crc32.ChecksumIEEE(reply.Data[:reply.Size]) {
+		err = fmt.Errorf("reply(%v) crc mismatch", reply.GetUniqueLogId())
+		return
+	}
+	return reply.Data[:reply.Size], nil
+}
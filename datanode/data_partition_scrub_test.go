@@ -0,0 +1,226 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"bytes"
+	"hash/crc32"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"golang.org/x/time/rate"
+)
+
+const (
+	testScrubPartitionID = 1
+	testScrubExtentID    = storage.MinExtentID + 1
+	testScrubLocalAddr   = "127.0.0.1:17310"
+)
+
+func init() {
+	proto.InitBufferPool(int64(32768))
+}
+
+// serveTestReplica answers the repair reads with the blocks of the replica extent.
+func serveTestReplica(ln net.Listener, extent []byte) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			for {
+				p := proto.NewPacket()
+				if err := p.ReadFromConn(conn, proto.ReadDeadlineTime); err != nil {
+					return
+				}
+				p.Data = extent[p.ExtentOffset : p.ExtentOffset+int64(p.Size)]
+				p.CRC = crc32.ChecksumIEEE(p.Data)
+				p.ResultCode = proto.OpOk
+				if err := p.WriteToConn(conn); err != nil {
+					return
+				}
+			}
+		}(conn)
+	}
+}
+
+// addTestScrubReplica adds a replica of the partition holding the extent.
+func addTestScrubReplica(t *testing.T, dp *DataPartition, extent []byte) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go serveTestReplica(ln, extent)
+	dp.replicas = append(dp.replicas, ln.Addr().String())
+}
+
+// newTestScrubPartition returns a partition with a normal extent of two full blocks
+// and no other replica.
+func newTestScrubPartition(t *testing.T) (dp *DataPartition, extent []byte) {
+	dir := t.TempDir()
+	store, err := storage.NewExtentStore(dir, testScrubPartitionID, 0, proto.PartitionTypeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.Close)
+	if err = store.Create(testScrubExtentID); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		data := bytes.Repeat([]byte{byte('a' + i)}, util.BlockSize)
+		if err = store.Write(testScrubExtentID, int64(i*util.BlockSize), util.BlockSize, data,
+			crc32.ChecksumIEEE(data), storage.AppendWriteType, true); err != nil {
+			t.Fatal(err)
+		}
+		extent = append(extent, data...)
+	}
+	// the extents modified recently are being written and skipped by the scrub
+	extents, _, err := store.GetAllWatermarks(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ei := range extents {
+		ei.ModifyTime -= 2 * storage.RepairInterval
+	}
+
+	dataNode := &DataNode{localServerAddr: testScrubLocalAddr}
+	dataNode.getRepairConnFunc = func(target string) (net.Conn, error) {
+		return net.DialTimeout("tcp", target, time.Second)
+	}
+	dataNode.putRepairConnFunc = func(conn net.Conn, forceClose bool) {
+		conn.Close()
+	}
+	dp = &DataPartition{
+		partitionID: testScrubPartitionID,
+		path:        dir,
+		replicas:    []string{testScrubLocalAddr},
+		extentStore: store,
+		dataNode:    dataNode,
+		disk:        &Disk{dataNode: dataNode, partitionMap: make(map[uint64]*DataPartition)},
+		stopC:       make(chan bool),
+	}
+	return
+}
+
+func corruptTestScrubBlock(t *testing.T, dp *DataPartition, blockNo int) {
+	name := path.Join(dp.path, strconv.Itoa(testScrubExtentID))
+	file, err := os.OpenFile(name, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.WriteAt([]byte("corrupt"), int64(blockNo*util.BlockSize+100)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestScrubLimiter() *rate.Limiter {
+	return rate.NewLimiter(rate.Inf, util.BlockSize)
+}
+
+func TestScrubRepairCorruptBlock(t *testing.T) {
+	dp, extent := newTestScrubPartition(t)
+	addTestScrubReplica(t, dp, extent)
+	corruptTestScrubBlock(t, dp, 1)
+
+	buf := make([]byte, util.BlockSize)
+	dp.scrub(newTestScrubLimiter(), buf)
+	info := dp.ScrubInfo()
+	if info.Round != 1 || info.TotalExtents != 1 || info.ScrubbedExtents != 1 || info.CheckedBlocks != 2 ||
+		info.CorruptBlocks != 1 || info.RepairedBlocks != 1 {
+		t.Fatalf("scrub info %+v, expect 1 corrupt block repaired", info)
+	}
+	if _, _, err := dp.extentStore.ScrubBlock(testScrubExtentID, 1, buf); err != nil {
+		t.Fatalf("repaired block: err(%v)", err)
+	}
+	if !bytes.Equal(buf, extent[util.BlockSize:]) {
+		t.Fatal("repaired block does not match the replica")
+	}
+}
+
+func TestScrubRepairFromMatchedReplica(t *testing.T) {
+	dp, extent := newTestScrubPartition(t)
+	// the first replica is corrupt as well, the repair must skip it for the crc mismatch
+	bad := append([]byte(nil), extent...)
+	copy(bad[util.BlockSize+100:], "corrupt")
+	addTestScrubReplica(t, dp, bad)
+	addTestScrubReplica(t, dp, extent)
+	corruptTestScrubBlock(t, dp, 1)
+
+	if err := dp.repairBlockFromReplicas(testScrubExtentID, 1, util.BlockSize); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, util.BlockSize)
+	if _, _, err := dp.extentStore.ScrubBlock(testScrubExtentID, 1, buf); err != nil {
+		t.Fatalf("repaired block: err(%v)", err)
+	}
+	if !bytes.Equal(buf, extent[util.BlockSize:]) {
+		t.Fatal("repaired block does not match the healthy replica")
+	}
+}
+
+func TestScrubUnrepairableBlock(t *testing.T) {
+	dp, extent := newTestScrubPartition(t)
+	// the only other replica is corrupt as well
+	bad := append([]byte(nil), extent...)
+	copy(bad[100:], "corrupt")
+	addTestScrubReplica(t, dp, bad)
+	corruptTestScrubBlock(t, dp, 0)
+
+	buf := make([]byte, util.BlockSize)
+	dp.scrub(newTestScrubLimiter(), buf)
+	info := dp.ScrubInfo()
+	if info.Round != 1 || info.CheckedBlocks != 2 || info.CorruptBlocks != 1 || info.RepairedBlocks != 0 {
+		t.Fatalf("scrub info %+v, expect 1 corrupt block not repaired", info)
+	}
+	if _, _, err := dp.extentStore.ScrubBlock(testScrubExtentID, 0, buf); err != storage.BlockCrcMismatchError {
+		t.Fatalf("unrepaired block: err(%v), expect %v", err, storage.BlockCrcMismatchError)
+	}
+}
+
+func TestScrubStopped(t *testing.T) {
+	dp, _ := newTestScrubPartition(t)
+	dp.Disk().partitionMap[dp.partitionID] = dp
+	dp.dataNode.scrubRate = util.MB
+	close(dp.stopC)
+
+	dp.scrub(newTestScrubLimiter(), make([]byte, util.BlockSize))
+	if info := dp.ScrubInfo(); info.Round != 0 || info.CheckedBlocks != 0 {
+		t.Fatalf("scrub info %+v, stopped partition should not be scrubbed", info)
+	}
+
+	stopC := make(chan bool)
+	done := make(chan struct{})
+	go func() {
+		dp.Disk().doScrubTask(stopC)
+		close(done)
+	}()
+	close(stopC)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scrub task does not exit after stop")
+	}
+}
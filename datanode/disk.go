@@ -69,6 +69,7 @@ type Disk struct {
 	dataNode                                  *DataNode
 
 	limitFactor map[uint32]*rate.Limiter
+	journal     *DiskJournal // optional write-ahead journal of the random writes
 }
 
 const (
@@ -0,0 +1,595 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/storage"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	DefaultJournalSizeMB = 1024 // max size of the random writes not applied to the disk yet
+
+	JournalSegmentSize      = 64 * util.MB
+	journalFilePrefix       = "journal_"
+	journalMarkFile         = ".journal" // the journal dir of the disk, kept in the disk path
+	journalRecordMagic      = 0xCF5A0001
+	journalRecordHeaderSize = 49
+	journalMaxRecordSize    = journalRecordHeaderSize + util.BlockSize
+	journalApplyRetry       = 20
+)
+
+// Binary frame structure of a journal record:
+//  +-------+-------+-----+-------------+-------+----------+--------+------+-----+--------+------+
+//  | item  | magic | crc | partitionID | index | extentID | offset | size | crc | opcode | data |
+//  +-------+-------+-----+-------------+-------+----------+--------+------+-----+--------+------+
+//  | bytes |   4   |  4  |      8      |   8   |     8    |    8   |   4  |  4  |    1   | size |
+//  +-------+-------+-----+-------------+-------+----------+--------+------+-----+--------+------+
+// The crc in the header covers the bytes after it, including the data.
+
+type journalSegment struct {
+	seq     uint64
+	file    *os.File
+	size    int64
+	pending int // records not applied yet
+	sealed  bool
+}
+
+type journalRecord struct {
+	segment     *journalSegment
+	pos         int64 // position of the data in the segment
+	partitionID uint64
+	index       uint64 // raft apply index of the random write
+	extentID    uint64
+	offset      int64
+	size        int64
+	crc         uint32
+	opcode      uint8
+}
+
+func (rec *journalRecord) length() int64 {
+	return journalRecordHeaderSize + rec.size
+}
+
+type journalKey struct {
+	partitionID uint64
+	extentID    uint64
+}
+
+// journalApplyFunc writes the data of a record to the extent.
+type journalApplyFunc func(rec *journalRecord, data []byte) error
+
+// DiskJournal is a write-ahead log on a fast device for the random writes of a disk.
+// A random write is acknowledged once it is persisted in the journal, the records are
+// applied to the extents in order in the background and the applied segments are removed.
+// The records left in the journal after a crash are applied again when the disk is loaded,
+// so the raft apply index persisted by the partitions never runs ahead of the durable data.
+// A record is never dropped before it is applied: if it can not be applied, the journal is
+// halted with the record kept and the disk is marked unavailable, the record is applied
+// again after the restart.
+type DiskJournal struct {
+	sync.Mutex
+	cond       *sync.Cond
+	writeMutex sync.Mutex // serializes the appends to the active segment
+	disk       *Disk
+	dir        string
+	maxSize    int64
+	size       int64 // bytes of the records not applied yet
+	nextSeq    uint64
+	active     *journalSegment
+	segments   []*journalSegment
+	records    []*journalRecord
+	pending    map[journalKey]int
+	partitions map[uint64]int // records not applied yet of every partition
+	halted     error
+	applyFunc  journalApplyFunc
+	stopped    bool           // set by Close to stop the apply task
+	applyWg    sync.WaitGroup // the running apply task
+}
+
+// LoadDiskJournal opens the journal of the disk in dir, an empty dir means the disk has no journal.
+// The journal dir is recorded in the disk path, and the journal is refused to be dropped from the
+// config or moved to another dir while it still has records not applied.
+func LoadDiskJournal(disk *Disk, dir string, maxSize int64) (j *DiskJournal, err error) {
+	markPath := path.Join(disk.Path, journalMarkFile)
+	data, err := ioutil.ReadFile(markPath)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if prev := strings.TrimSpace(string(data)); prev != "" && prev != dir {
+		if err = checkJournalDrained(disk, prev); err != nil {
+			return
+		}
+		log.LogWarnf("action[LoadDiskJournal] disk(%v) journal(%v) dropped.", disk.Path, prev)
+	}
+	if dir == "" {
+		if err = os.Remove(markPath); err != nil && !os.IsNotExist(err) {
+			return
+		}
+		return nil, nil
+	}
+	if err = writeJournalMark(markPath, dir); err != nil {
+		return
+	}
+	return OpenDiskJournal(disk, dir, maxSize)
+}
+
+// checkJournalDrained returns an error if the journal in dir still has records not applied.
+func checkJournalDrained(disk *Disk, dir string) (err error) {
+	if _, err = os.Stat(dir); err != nil {
+		return fmt.Errorf("journal(%v) of disk(%v) is not accessible(%v), remove %v to drop it",
+			dir, disk.Path, err, path.Join(disk.Path, journalMarkFile))
+	}
+	j, err := OpenDiskJournal(disk, dir, 0)
+	if err != nil {
+		return
+	}
+	defer j.Close()
+	if len(j.records) > 0 {
+		return fmt.Errorf("journal(%v) of disk(%v) still has %v records not applied, configure it back",
+			dir, disk.Path, len(j.records))
+	}
+	return
+}
+
+func writeJournalMark(markPath, dir string) (err error) {
+	tmpPath := markPath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+	if _, err = file.WriteString(dir); err == nil {
+		err = file.Sync()
+	}
+	file.Close()
+	if err != nil {
+		return
+	}
+	return os.Rename(tmpPath, markPath)
+}
+
+// OpenDiskJournal opens the journal in dir and loads the records not applied before the last shutdown.
+func OpenDiskJournal(disk *Disk, dir string, maxSize int64) (j *DiskJournal, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	j = &DiskJournal{
+		disk:       disk,
+		dir:        dir,
+		maxSize:    maxSize,
+		pending:    make(map[journalKey]int),
+		partitions: make(map[uint64]int),
+	}
+	j.cond = sync.NewCond(&j.Mutex)
+	j.applyFunc = j.applyToExtent
+
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	seqs := make([]uint64, 0)
+	for _, fi := range fileInfos {
+		if fi.IsDir() || !strings.HasPrefix(fi.Name(), journalFilePrefix) {
+			continue
+		}
+		seq, e := strconv.ParseUint(strings.TrimPrefix(fi.Name(), journalFilePrefix), 10, 64)
+		if e != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, k int) bool { return seqs[i] < seqs[k] })
+	for i, seq := range seqs {
+		if err = j.loadSegment(seq, i == len(seqs)-1); err != nil {
+			j.Close()
+			return nil, err
+		}
+		j.nextSeq = seq + 1
+	}
+	log.LogInfof("action[OpenDiskJournal] disk(%v) journal(%v) loaded segments(%v) records(%v) size(%v).",
+		disk.Path, dir, len(seqs), len(j.records), j.size)
+	return
+}
+
+func (j *DiskJournal) segmentPath(seq uint64) string {
+	return path.Join(j.dir, fmt.Sprintf("%v%020d", journalFilePrefix, seq))
+}
+
+// loadSegment scans the records of a segment written before the restart. As the records are
+// appended one by one, only the last record of the last segment may be torn by a crash, which
+// is truncated since it has never been acknowledged. A bad record anywhere else fails the load,
+// as the records after it would be lost.
+func (j *DiskJournal) loadSegment(seq uint64, last bool) (err error) {
+	seg := &journalSegment{seq: seq, sealed: true}
+	if seg.file, err = os.OpenFile(j.segmentPath(seq), os.O_RDWR, 0644); err != nil {
+		return
+	}
+	fi, err := seg.file.Stat()
+	if err != nil {
+		seg.file.Close()
+		return
+	}
+	fileSize := fi.Size()
+	for seg.size < fileSize {
+		rec, e := readJournalRecord(seg.file, seg.size)
+		if e != nil {
+			if !last || !isTornJournalRecord(rec, seg.size, fileSize) {
+				seg.file.Close()
+				return fmt.Errorf("journal(%v) segment(%v) bad record at(%v) size(%v): %v", j.dir, seq, seg.size, fileSize, e)
+			}
+			log.LogWarnf("action[loadSegment] journal(%v) truncate torn record of segment(%v) at(%v) size(%v) err(%v).",
+				j.dir, seq, seg.size, fileSize, e)
+			if err = seg.file.Truncate(seg.size); err == nil {
+				err = seg.file.Sync()
+			}
+			if err != nil {
+				seg.file.Close()
+				return
+			}
+			break
+		}
+		rec.segment = seg
+		seg.pending++
+		seg.size += rec.length()
+		j.enqueue(rec)
+	}
+	if seg.pending == 0 {
+		j.removeSegment(seg)
+		return
+	}
+	j.segments = append(j.segments, seg)
+	return
+}
+
+// isTornJournalRecord returns whether the bad record at pos reaches the end of the file, which
+// is left by a crash in the middle of the append. The record is nil if the header is bad.
+func isTornJournalRecord(rec *journalRecord, pos, fileSize int64) bool {
+	if rec != nil {
+		return pos+rec.length() >= fileSize
+	}
+	return fileSize-pos < journalMaxRecordSize
+}
+
+// readJournalRecord reads the record at pos, the record is returned with the error if only
+// the data is bad.
+func readJournalRecord(file *os.File, pos int64) (rec *journalRecord, err error) {
+	header := make([]byte, journalRecordHeaderSize)
+	if _, err = file.ReadAt(header, pos); err != nil {
+		return
+	}
+	if binary.BigEndian.Uint32(header[0:4]) != journalRecordMagic {
+		return nil, fmt.Errorf("bad magic")
+	}
+	rec = &journalRecord{
+		pos:         pos + journalRecordHeaderSize,
+		partitionID: binary.BigEndian.Uint64(header[8:16]),
+		index:       binary.BigEndian.Uint64(header[16:24]),
+		extentID:    binary.BigEndian.Uint64(header[24:32]),
+		offset:      int64(binary.BigEndian.Uint64(header[32:40])),
+		size:        int64(binary.BigEndian.Uint32(header[40:44])),
+		crc:         binary.BigEndian.Uint32(header[44:48]),
+		opcode:      header[48],
+	}
+	if rec.size > util.BlockSize {
+		return nil, fmt.Errorf("bad size(%v)", rec.size)
+	}
+	data := make([]byte, rec.size)
+	if _, err = file.ReadAt(data, rec.pos); err != nil {
+		return rec, fmt.Errorf("read data: %v", err)
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(header[8:])
+	crc.Write(data)
+	if crc.Sum32() != binary.BigEndian.Uint32(header[4:8]) {
+		return rec, fmt.Errorf("crc mismatch")
+	}
+	return
+}
+
+func (rec *journalRecord) marshal(data []byte) []byte {
+	buf := make([]byte, rec.length())
+	binary.BigEndian.PutUint32(buf[0:4], journalRecordMagic)
+	binary.BigEndian.PutUint64(buf[8:16], rec.partitionID)
+	binary.BigEndian.PutUint64(buf[16:24], rec.index)
+	binary.BigEndian.PutUint64(buf[24:32], rec.extentID)
+	binary.BigEndian.PutUint64(buf[32:40], uint64(rec.offset))
+	binary.BigEndian.PutUint32(buf[40:44], uint32(rec.size))
+	binary.BigEndian.PutUint32(buf[44:48], rec.crc)
+	buf[48] = rec.opcode
+	copy(buf[journalRecordHeaderSize:], data)
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(buf[8:]))
+	return buf
+}
+
+// enqueue must be called with the lock held.
+func (j *DiskJournal) enqueue(rec *journalRecord) {
+	j.records = append(j.records, rec)
+	j.pending[journalKey{rec.partitionID, rec.extentID}]++
+	j.partitions[rec.partitionID]++
+	j.size += rec.length()
+	j.cond.Broadcast()
+}
+
+// dequeue removes the applied record at the head, it must be called with the lock held.
+func (j *DiskJournal) dequeue() {
+	rec := j.records[0]
+	j.records[0] = nil
+	j.records = j.records[1:]
+	key := journalKey{rec.partitionID, rec.extentID}
+	if j.pending[key]--; j.pending[key] <= 0 {
+		delete(j.pending, key)
+	}
+	if j.partitions[rec.partitionID]--; j.partitions[rec.partitionID] <= 0 {
+		delete(j.partitions, rec.partitionID)
+	}
+	j.size -= rec.length()
+	seg := rec.segment
+	if seg.pending--; seg.pending == 0 && seg.sealed {
+		j.removeSegment(seg)
+	}
+	j.cond.Broadcast()
+}
+
+// Append persists a random write in the journal, the write is applied to the extent later.
+func (j *DiskJournal) Append(partitionID, index uint64, opItem *rndWrtOpItem) (err error) {
+	rec := &journalRecord{
+		partitionID: partitionID,
+		index:       index,
+		extentID:    opItem.extentID,
+		offset:      opItem.offset,
+		size:        opItem.size,
+		crc:         opItem.crc,
+		opcode:      opItem.opcode,
+	}
+	buf := rec.marshal(opItem.data[:opItem.size])
+
+	j.writeMutex.Lock()
+	defer j.writeMutex.Unlock()
+	j.Lock()
+	for j.halted == nil && j.size > 0 && j.size+rec.length() > j.maxSize {
+		j.cond.Wait()
+	}
+	if err = j.halted; err != nil {
+		j.Unlock()
+		return
+	}
+	seg := j.active
+	j.Unlock()
+
+	if seg == nil || seg.size+rec.length() > JournalSegmentSize {
+		if seg, err = j.rotate(); err != nil {
+			return
+		}
+	}
+	if _, err = seg.file.WriteAt(buf, seg.size); err != nil {
+		return
+	}
+	if err = seg.file.Sync(); err != nil {
+		return
+	}
+	rec.segment = seg
+	rec.pos = seg.size + journalRecordHeaderSize
+
+	j.Lock()
+	seg.size += rec.length()
+	seg.pending++
+	j.enqueue(rec)
+	j.Unlock()
+	return
+}
+
+// rotate seals the active segment and creates a new one, it must be called with the write mutex held.
+func (j *DiskJournal) rotate() (seg *journalSegment, err error) {
+	seg = &journalSegment{seq: j.nextSeq}
+	if seg.file, err = os.OpenFile(j.segmentPath(seg.seq), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644); err != nil {
+		return
+	}
+	j.nextSeq++
+
+	j.Lock()
+	if old := j.active; old != nil {
+		old.sealed = true
+		if old.pending == 0 {
+			j.removeSegment(old)
+		} else {
+			j.segments = append(j.segments, old)
+		}
+	}
+	j.active = seg
+	j.Unlock()
+	return
+}
+
+func (j *DiskJournal) removeSegment(seg *journalSegment) {
+	seg.file.Close()
+	if err := os.Remove(j.segmentPath(seg.seq)); err != nil {
+		log.LogWarnf("action[removeSegment] journal(%v) remove segment(%v) err(%v).", j.dir, seg.seq, err)
+	}
+	for i, s := range j.segments {
+		if s == seg {
+			j.segments = append(j.segments[:i], j.segments[i+1:]...)
+			break
+		}
+	}
+}
+
+// Close stops the apply task and closes the segment files, the records not applied are kept in the journal.
+func (j *DiskJournal) Close() {
+	j.Lock()
+	j.stopped = true
+	if j.halted == nil {
+		j.halted = fmt.Errorf("journal(%v) closed", j.dir)
+	}
+	j.cond.Broadcast()
+	j.Unlock()
+	j.applyWg.Wait()
+
+	j.Lock()
+	defer j.Unlock()
+	for _, seg := range j.segments {
+		seg.file.Close()
+	}
+	if j.active != nil {
+		j.active.file.Close()
+	}
+}
+
+// WaitApplied blocks until the journaled writes of the extent are applied, so that the reads
+// of the extent see the data acknowledged to the client.
+func (j *DiskJournal) WaitApplied(partitionID, extentID uint64) (err error) {
+	key := journalKey{partitionID, extentID}
+	j.Lock()
+	defer j.Unlock()
+	for j.pending[key] > 0 && j.halted == nil {
+		j.cond.Wait()
+	}
+	if j.pending[key] > 0 {
+		err = j.halted
+	}
+	return
+}
+
+// WaitPartitionApplied blocks until the journaled writes of the partition are applied, it is
+// called before the partition is deleted.
+func (j *DiskJournal) WaitPartitionApplied(partitionID uint64) (err error) {
+	j.Lock()
+	defer j.Unlock()
+	for j.partitions[partitionID] > 0 && j.halted == nil {
+		j.cond.Wait()
+	}
+	if j.partitions[partitionID] > 0 {
+		err = j.halted
+	}
+	return
+}
+
+// startApplyTask starts the task applying the journal records, which is stopped by Close.
+func (j *DiskJournal) startApplyTask() {
+	j.applyWg.Add(1)
+	go func() {
+		defer j.applyWg.Done()
+		j.doApplyTask()
+	}()
+}
+
+// doApplyTask applies the journal records to the extents in order, it returns when a record
+// can not be applied or the journal is closed.
+func (j *DiskJournal) doApplyTask() {
+	for {
+		j.Lock()
+		for len(j.records) == 0 && !j.stopped {
+			j.cond.Wait()
+		}
+		if j.stopped {
+			j.Unlock()
+			return
+		}
+		rec := j.records[0]
+		j.Unlock()
+
+		if err := j.apply(rec); err != nil {
+			if j.isStopped() {
+				// the record is kept and applied after the restart
+				return
+			}
+			j.halt(rec, err)
+			return
+		}
+
+		j.Lock()
+		j.dequeue()
+		j.Unlock()
+	}
+}
+
+// halt stops applying the journal with the record kept, and marks the disk unavailable so
+// that no more writes are acknowledged. The record is applied again after the restart.
+func (j *DiskJournal) halt(rec *journalRecord, err error) {
+	msg := fmt.Sprintf("action[journalApply] journal(%v) of disk(%v) halted at Partition(%v)_Extent(%v)_ExtentOffset(%v)_Size(%v) index(%v) err(%v)",
+		j.dir, j.disk.Path, rec.partitionID, rec.extentID, rec.offset, rec.size, rec.index, err)
+	log.LogCritical(msg)
+	exporter.Warning(msg)
+
+	j.disk.incWriteErrCnt()
+	j.disk.ForceExitRaftStore()
+	j.disk.Status = proto.Unavailable
+
+	j.Lock()
+	j.halted = fmt.Errorf("journal(%v) halted: %v", j.dir, err)
+	j.cond.Broadcast()
+	j.Unlock()
+}
+
+// apply applies the record with retries, an error is returned if it is still not applied.
+func (j *DiskJournal) apply(rec *journalRecord) (err error) {
+	data := make([]byte, rec.size)
+	if _, err = rec.segment.file.ReadAt(data, rec.pos); err != nil {
+		return
+	}
+	for i := 0; i < journalApplyRetry; i++ {
+		if err = j.applyFunc(rec, data); err == nil {
+			return
+		}
+		if IsDiskErr(err.Error()) {
+			return
+		}
+		log.LogErrorf("action[journalApply] Partition(%v)_Extent(%v)_ExtentOffset(%v)_Size(%v) index(%v) err(%v) retry(%v).",
+			rec.partitionID, rec.extentID, rec.offset, rec.size, rec.index, err, i)
+		if j.isStopped() {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	return
+}
+
+func (j *DiskJournal) isStopped() bool {
+	j.Lock()
+	defer j.Unlock()
+	return j.stopped
+}
+
+// applyToExtent writes the record to the extent of the partition, which must not be deleted
+// before its records are applied.
+func (j *DiskJournal) applyToExtent(rec *journalRecord, data []byte) (err error) {
+	dp := j.disk.space.Partition(rec.partitionID)
+	if dp == nil {
+		return fmt.Errorf("partition(%v) not exist", rec.partitionID)
+	}
+	dp.disk.allocCheckLimit(proto.FlowWriteType, uint32(rec.size))
+	dp.disk.allocCheckLimit(proto.IopsWriteType, 1)
+
+	err = dp.ExtentStore().Write(rec.extentID, rec.offset, rec.size, data, rec.crc, storage.RandomWriteType, true)
+	if err != nil && strings.Contains(err.Error(), storage.ExtentNotFoundError.Error()) {
+		// the extent has been deleted after the write
+		err = nil
+	}
+	return
+}
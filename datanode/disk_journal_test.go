@@ -0,0 +1,290 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+)
+
+const (
+	testJournalPartitionID = 1
+	testJournalSize        = DefaultJournalSizeMB * util.MB
+)
+
+func newTestJournalDisk(t *testing.T) *Disk {
+	return &Disk{Path: t.TempDir(), partitionMap: make(map[uint64]*DataPartition)}
+}
+
+func newTestJournalItem(extentID uint64, offset int64, data string) *rndWrtOpItem {
+	return &rndWrtOpItem{
+		extentID: extentID,
+		offset:   offset,
+		size:     int64(len(data)),
+		data:     []byte(data),
+		crc:      crc32.ChecksumIEEE([]byte(data)),
+	}
+}
+
+func appendTestJournalRecords(t *testing.T, j *DiskJournal, items ...*rndWrtOpItem) {
+	for i, item := range items {
+		if err := j.Append(testJournalPartitionID, uint64(i+1), item); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type testJournalApplier struct {
+	sync.Mutex
+	applied []string
+	err     error
+}
+
+func (a *testJournalApplier) apply(rec *journalRecord, data []byte) error {
+	a.Lock()
+	defer a.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.applied = append(a.applied, fmt.Sprintf("%v:%v:%s", rec.extentID, rec.offset, data))
+	return nil
+}
+
+func journalSegmentFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestDiskJournalReplay(t *testing.T) {
+	disk := newTestJournalDisk(t)
+	dir := t.TempDir()
+	j, err := OpenDiskJournal(disk, dir, testJournalSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendTestJournalRecords(t, j,
+		newTestJournalItem(1024, 0, "hello"),
+		newTestJournalItem(1025, 4096, "cubefs"),
+		newTestJournalItem(1024, 2, "LLO"))
+	// crash before any record is applied
+	j.Close()
+
+	if j, err = OpenDiskJournal(disk, dir, testJournalSize); err != nil {
+		t.Fatal(err)
+	}
+	if len(j.records) != 3 || j.records[1].index != 2 || j.records[1].extentID != 1025 || j.records[1].offset != 4096 {
+		t.Fatalf("replayed records %v, expect the 3 appended", j.records)
+	}
+	applier := new(testJournalApplier)
+	j.applyFunc = applier.apply
+	j.startApplyTask()
+	if err = j.WaitPartitionApplied(testJournalPartitionID); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"1024:0:hello", "1025:4096:cubefs", "1024:2:LLO"}
+	if fmt.Sprint(applier.applied) != fmt.Sprint(expect) {
+		t.Fatalf("applied %v, expect %v in order", applier.applied, expect)
+	}
+	if files := journalSegmentFiles(t, dir); len(files) != 0 {
+		t.Fatalf("segments %v should be removed once applied", files)
+	}
+}
+
+func TestDiskJournalTornTail(t *testing.T) {
+	disk := newTestJournalDisk(t)
+	dir := t.TempDir()
+	j, err := OpenDiskJournal(disk, dir, testJournalSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendTestJournalRecords(t, j, newTestJournalItem(1024, 0, "hello"), newTestJournalItem(1024, 5, "world"))
+	seg := j.active
+	j.Close()
+
+	// the crash tears the last record
+	first := int64(journalRecordHeaderSize + len("hello"))
+	if err = os.Truncate(j.segmentPath(seg.seq), seg.size-2); err != nil {
+		t.Fatal(err)
+	}
+	if j, err = OpenDiskJournal(disk, dir, testJournalSize); err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if len(j.records) != 1 || j.records[0].offset != 0 {
+		t.Fatalf("replayed records %v, expect the first one", j.records)
+	}
+	fi, err := os.Stat(j.segmentPath(seg.seq))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != first {
+		t.Fatalf("segment size %v, expect truncated to %v", fi.Size(), first)
+	}
+}
+
+func TestDiskJournalCorruptRecord(t *testing.T) {
+	disk := newTestJournalDisk(t)
+	dir := t.TempDir()
+	j, err := OpenDiskJournal(disk, dir, testJournalSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendTestJournalRecords(t, j, newTestJournalItem(1024, 0, "hello"), newTestJournalItem(1024, 5, "world"))
+	seg := j.active
+	j.Close()
+
+	// a bad record in the middle must not drop the records after it
+	file, err := os.OpenFile(j.segmentPath(seg.seq), os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = file.WriteAt([]byte("J"), journalRecordHeaderSize); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if _, err = OpenDiskJournal(disk, dir, testJournalSize); err == nil {
+		t.Fatal("journal with a bad record in the middle should fail to open")
+	}
+	fi, err := os.Stat(j.segmentPath(seg.seq))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != seg.size {
+		t.Fatalf("segment size %v, expect %v untouched", fi.Size(), seg.size)
+	}
+}
+
+func TestDiskJournalApplyFailure(t *testing.T) {
+	disk := newTestJournalDisk(t)
+	dir := t.TempDir()
+	j, err := OpenDiskJournal(disk, dir, testJournalSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applier := &testJournalApplier{err: syscall.EIO}
+	j.applyFunc = applier.apply
+	j.startApplyTask()
+	appendTestJournalRecords(t, j, newTestJournalItem(1024, 0, "hello"))
+
+	if err = j.WaitApplied(testJournalPartitionID, 1024); err == nil {
+		t.Fatal("wait should fail once the journal is halted")
+	}
+	if err = j.WaitPartitionApplied(testJournalPartitionID); err == nil {
+		t.Fatal("wait should fail once the journal is halted")
+	}
+	if err = j.Append(testJournalPartitionID, 2, newTestJournalItem(1024, 5, "world")); err == nil {
+		t.Fatal("append should fail once the journal is halted")
+	}
+	if disk.Status != proto.Unavailable {
+		t.Fatalf("disk status %v, expect unavailable", disk.Status)
+	}
+	j.Close()
+
+	// the record is kept and applied after the restart
+	if j, err = OpenDiskJournal(disk, dir, testJournalSize); err != nil {
+		t.Fatal(err)
+	}
+	applier = new(testJournalApplier)
+	j.applyFunc = applier.apply
+	j.startApplyTask()
+	if err = j.WaitPartitionApplied(testJournalPartitionID); err != nil {
+		t.Fatal(err)
+	}
+	if len(applier.applied) != 1 || applier.applied[0] != "1024:0:hello" {
+		t.Fatalf("applied %v, expect the kept record", applier.applied)
+	}
+}
+
+func TestDiskJournalCloseStopsApply(t *testing.T) {
+	disk := newTestJournalDisk(t)
+	j, err := OpenDiskJournal(disk, t.TempDir(), testJournalSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.applyFunc = new(testJournalApplier).apply
+	j.startApplyTask()
+	appendTestJournalRecords(t, j, newTestJournalItem(1024, 0, "hello"))
+	if err = j.WaitPartitionApplied(testJournalPartitionID); err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		j.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("close should wait for the idle apply task to exit")
+	}
+	if err = j.Append(testJournalPartitionID, 2, newTestJournalItem(1024, 5, "world")); err == nil {
+		t.Fatal("append should fail once the journal is closed")
+	}
+	if disk.Status == proto.Unavailable {
+		t.Fatal("closing the journal should not mark the disk unavailable")
+	}
+}
+
+func TestLoadDiskJournalRefuseDrop(t *testing.T) {
+	disk := newTestJournalDisk(t)
+	dir := t.TempDir()
+	j, err := LoadDiskJournal(disk, dir, testJournalSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendTestJournalRecords(t, j, newTestJournalItem(1024, 0, "hello"))
+	j.Close()
+
+	if _, err = LoadDiskJournal(disk, "", testJournalSize); err == nil {
+		t.Fatal("journal with records not applied should not be dropped")
+	}
+	if _, err = LoadDiskJournal(disk, t.TempDir(), testJournalSize); err == nil {
+		t.Fatal("journal with records not applied should not be moved")
+	}
+
+	if j, err = LoadDiskJournal(disk, dir, testJournalSize); err != nil {
+		t.Fatal(err)
+	}
+	j.applyFunc = new(testJournalApplier).apply
+	j.startApplyTask()
+	if err = j.WaitPartitionApplied(testJournalPartitionID); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	if j, err = LoadDiskJournal(disk, "", testJournalSize); err != nil || j != nil {
+		t.Fatalf("drained journal should be dropped, journal %v err %v", j, err)
+	}
+	if _, err = os.Stat(path.Join(disk.Path, journalMarkFile)); !os.IsNotExist(err) {
+		t.Fatalf("journal mark should be removed, err %v", err)
+	}
+}
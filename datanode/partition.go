@@ -136,6 +136,9 @@ type DataPartition struct {
 	DataPartitionCreateType       int
 	isLoadingDataPartition        bool
 	persistMetaMutex              sync.RWMutex
+
+	scrubMutex sync.Mutex
+	scrubInfo  proto.ScrubInfo
 }
 
 func CreateDataPartition(dpCfg *dataPartitionCfg, disk *Disk, request *proto.CreateDataPartitionRequest) (dp *DataPartition, err error) {
@@ -84,6 +84,38 @@ func MarshalRandWriteRaftLog(opcode uint8, extentID uint64, offset, size int64,
 	return
 }
 
+// ApplyRandomWriteToJournal persists the random write in the disk journal.
+func (dp *DataPartition) ApplyRandomWriteToJournal(command []byte, raftApplyID uint64) (resp interface{}, err error) {
+	opItem := &rndWrtOpItem{}
+	defer func() {
+		if err == nil {
+			resp = proto.OpOk
+			dp.uploadApplyID(raftApplyID)
+		} else {
+			err = fmt.Errorf("[ApplyRandomWriteToJournal] ApplyID(%v) Partition(%v)_Extent(%v)_ExtentOffset(%v)_Size(%v) apply err(%v)", raftApplyID, dp.partitionID, opItem.extentID, opItem.offset, opItem.size, err)
+			exporter.Warning(err.Error())
+			resp = proto.OpDiskErr
+			panic(newRaftApplyError(err))
+		}
+	}()
+	if opItem, err = UnmarshalRandWriteRaftLog(command); err != nil {
+		log.LogErrorf("[ApplyRandomWriteToJournal] ApplyID(%v) Partition(%v) unmarshal failed(%v)", raftApplyID, dp.partitionID, err)
+		return
+	}
+	log.LogDebugf("[ApplyRandomWriteToJournal] ApplyID(%v) Partition(%v)_Extent(%v)_ExtentOffset(%v)_Size(%v)",
+		raftApplyID, dp.partitionID, opItem.extentID, opItem.offset, opItem.size)
+	err = dp.disk.journal.Append(dp.partitionID, raftApplyID, opItem)
+	return
+}
+
+// waitJournalApplied waits for the journaled random writes of the extent before reading it.
+func (dp *DataPartition) waitJournalApplied(extentID uint64) (err error) {
+	if dp.disk.journal != nil {
+		err = dp.disk.journal.WaitApplied(dp.partitionID, extentID)
+	}
+	return
+}
+
 // RandomWriteSubmit submits the proposal to raft.
 func UnmarshalRandWriteRaftLog(raw []byte) (opItem *rndWrtOpItem, err error) {
 	opItem = new(rndWrtOpItem)
@@ -485,6 +485,21 @@ func (dp *DataPartition) GetAppliedID() (id uint64) {
 	return dp.appliedID
 }
 
+// WaitForAppliedID waits until the partition has applied the given raft ID.
+func (dp *DataPartition) WaitForAppliedID(appliedID uint64, timeout time.Duration) (err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		current := atomic.LoadUint64(&dp.appliedID)
+		if current >= appliedID {
+			return
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("partition(%v) applied ID(%v) lags behind required(%v)", dp.partitionID, current, appliedID)
+		}
+		time.Sleep(followerReadWaitIntervalMs * time.Millisecond)
+	}
+}
+
 func (s *DataNode) parseRaftConfig(cfg *config.Config) (err error) {
 	s.raftDir = cfg.GetString(ConfigKeyRaftDir)
 	if s.raftDir == "" {
@@ -628,7 +643,7 @@ func (dp *DataPartition) findMaxAppliedID(allAppliedIDs []uint64) (maxAppliedID
 // Get the partition size from the leader.
 func (dp *DataPartition) getLeaderPartitionSize(maxExtentID uint64) (size uint64, err error) {
 	var (
-		conn *net.TCPConn
+		conn net.Conn
 	)
 
 	p := NewPacketToGetPartitionSize(dp.partitionID)
@@ -666,7 +681,7 @@ func (dp *DataPartition) getLeaderPartitionSize(maxExtentID uint64) (size uint64
 // Get the MaxExtentID partition  from the leader.
 func (dp *DataPartition) getLeaderMaxExtentIDAndPartitionSize() (maxExtentID, PartitionSize uint64, err error) {
 	var (
-		conn *net.TCPConn
+		conn net.Conn
 	)
 
 	p := NewPacketToGetMaxExtentIDAndPartitionSIze(dp.partitionID)
@@ -715,7 +730,7 @@ func (dp *DataPartition) broadcastMinAppliedID(minAppliedID uint64) (err error)
 			continue
 		}
 		target := dp.getReplicaAddr(i)
-		var conn *net.TCPConn
+		var conn net.Conn
 		conn, err = gConnPool.GetConnect(target)
 		if err != nil {
 			return
@@ -770,7 +785,7 @@ func (dp *DataPartition) getAllReplicaAppliedID() (allAppliedID []uint64, replyN
 
 // Get target members' applied id
 func (dp *DataPartition) getRemoteAppliedID(target string, p *repl.Packet) (appliedID uint64, err error) {
-	var conn *net.TCPConn
+	var conn net.Conn
 	start := time.Now().UnixNano()
 	defer func() {
 		if err != nil {
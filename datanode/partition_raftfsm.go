@@ -33,7 +33,13 @@ import (
 /* The functions below implement the interfaces defined in the raft library. */
 
 // Apply puts the data onto the disk.
+// The random writes to a disk with a journal are persisted in the journal and applied to the extents
+// asynchronously, the apply index is uploaded once the write is durable in the journal.
 func (dp *DataPartition) Apply(command []byte, index uint64) (resp interface{}, err error) {
+	if dp.disk.journal != nil {
+		resp, err = dp.ApplyRandomWriteToJournal(command, index)
+		return
+	}
 	resp, err = dp.ApplyRandomWrite(command, index)
 	return
 }
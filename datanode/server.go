@@ -39,6 +39,8 @@ import (
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
+	"github.com/cubefs/cubefs/util/trace"
 
 	"github.com/xtaci/smux"
 )
@@ -98,6 +100,14 @@ const (
 
 	//rate limit control enable
 	ConfigDiskQosEnable = "diskQosEnable" //bool
+
+	// extent scrub config
+	ConfigKeyScrubRateMB       = "scrubRateMB"       // int, 0 disables scrubbing
+	ConfigKeyScrubIntervalHour = "scrubIntervalHour" // int
+
+	// random write journal config
+	ConfigKeyDiskJournals  = "diskJournals"  // array of PATH:JOURNAL_DIR
+	ConfigKeyJournalSizeMB = "journalSizeMB" // int
 )
 
 // DataNode defines the structure of a data node.
@@ -120,6 +130,8 @@ type DataNode struct {
 	tcpListener net.Listener
 	stopC       chan bool
 
+	authenticator *packetauth.Authenticator
+
 	smuxPortShift      int
 	enableSmuxConnPool bool
 	smuxConnPool       *util.SmuxConnectPool
@@ -142,6 +154,12 @@ type DataNode struct {
 	diskIopsWriteLimit      uint64
 	diskFlowReadLimit       uint64
 	diskFlowWriteLimit      uint64
+
+	scrubRate     uint64 // bytes per second of every disk
+	scrubInterval time.Duration
+
+	diskJournals map[string]string // disk path to the journal dir on a fast device
+	journalSize  int64
 }
 
 func NewServer() *DataNode {
@@ -178,6 +196,9 @@ func doStart(server common.Server, cfg *config.Config) (err error) {
 	}
 
 	exporter.Init(ModuleName, cfg)
+	if err = trace.Init(ModuleName, cfg); err != nil {
+		return
+	}
 	s.registerMetrics()
 	s.register(cfg)
 
@@ -237,6 +258,7 @@ func doShutdown(server common.Server) {
 	s.stopRaftServer()
 	s.stopSmuxService()
 	s.closeSmuxConnPool()
+	trace.Stop()
 }
 
 func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
@@ -254,6 +276,13 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 		return fmt.Errorf("Err:port must string")
 	}
 	s.port = port
+
+	var tlsTransport *util.TLSTransport
+	if tlsTransport, err = util.LoadTLSTransport(cfg); err != nil {
+		return fmt.Errorf("Err:%v", err)
+	}
+	util.SetTLSTransport(tlsTransport)
+
 	if len(cfg.GetSlice(proto.MasterAddr)) == 0 {
 		return fmt.Errorf("Err:masterAddr unavalid")
 	}
@@ -264,11 +293,33 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	if s.zoneName == "" {
 		s.zoneName = DefaultZoneName
 	}
+	if s.authenticator, err = packetauth.LoadAuthenticator(cfg, packetauth.ConfigKeyDataServiceKey, proto.DataServiceID, MasterClient); err != nil {
+		return fmt.Errorf("Err:%v", err)
+	}
 	s.metricsDegrade = cfg.GetInt(CfgMetricsDegrade)
+	s.scrubRate = uint64(cfg.GetInt64WithDefault(ConfigKeyScrubRateMB, DefaultScrubRateMB)) * util.MB
+	s.scrubInterval = time.Duration(cfg.GetInt64WithDefault(ConfigKeyScrubIntervalHour, DefaultScrubIntervalHour)) * time.Hour
 
 	log.LogDebugf("action[parseConfig] load masterAddrs(%v).", MasterClient.Nodes())
 	log.LogDebugf("action[parseConfig] load port(%v).", s.port)
 	log.LogDebugf("action[parseConfig] load zoneName(%v).", s.zoneName)
+	log.LogDebugf("action[parseConfig] load scrubRate(%v) scrubInterval(%v).", s.scrubRate, s.scrubInterval)
+	return
+}
+
+// parseDiskJournals parses the journal dirs of the disks in the format "PATH:JOURNAL_DIR",
+// the random writes to a disk with a journal are acknowledged once persisted in the journal.
+func (s *DataNode) parseDiskJournals(cfg *config.Config) (err error) {
+	s.diskJournals = make(map[string]string)
+	for _, d := range cfg.GetSlice(ConfigKeyDiskJournals) {
+		arr := strings.Split(d.(string), ":")
+		if len(arr) != 2 || arr[0] == "" || arr[1] == "" {
+			return errors.New("Invalid disk journal configuration. Example: PATH:JOURNAL_DIR")
+		}
+		s.diskJournals[arr[0]] = arr[1]
+		log.LogInfof("action[parseDiskJournals] disk(%v) journal(%v).", arr[0], arr[1])
+	}
+	s.journalSize = cfg.GetInt64WithDefault(ConfigKeyJournalSizeMB, DefaultJournalSizeMB) * util.MB
 	return
 }
 
@@ -302,6 +353,10 @@ func (s *DataNode) startSpaceManager(cfg *config.Config) (err error) {
 
 	log.LogInfof("startSpaceManager preReserveSpace %d", diskRdonlySpace)
 
+	if err = s.parseDiskJournals(cfg); err != nil {
+		return
+	}
+
 	var wg sync.WaitGroup
 	for _, d := range cfg.GetSlice(ConfigKeyDisks) {
 		log.LogDebugf("action[startSpaceManager] load disk raw config(%v).", d)
@@ -482,6 +537,10 @@ func (s *DataNode) startTCPService() (err error) {
 		log.LogError("failed to listen, err:", err)
 		return
 	}
+	if l, err = util.GetTLSTransport().NewListener(l); err != nil {
+		log.LogError("failed to listen with tls, err:", err)
+		return
+	}
 	s.tcpListener = l
 	go func(ln net.Listener) {
 		for {
@@ -509,10 +568,11 @@ func (s *DataNode) stopTCPService() (err error) {
 func (s *DataNode) serveConn(conn net.Conn) {
 	space := s.space
 	space.Stats().AddConnection()
-	c, _ := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
-	packetProcessor := repl.NewReplProtocol(conn, s.Prepare, s.OperatePacket, s.Post)
+	if c, ok := conn.(*net.TCPConn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
+	packetProcessor := repl.NewReplProtocol(s.authenticator.NewConn(conn), s.Prepare, s.OperatePacket, s.Post)
 	packetProcessor.ServerConn()
 }
 
@@ -529,6 +589,10 @@ func (s *DataNode) startSmuxService(cfg *config.Config) (err error) {
 		log.LogError("failed to listen smux addr, err:", err)
 		return
 	}
+	if l, err = util.GetTLSTransport().NewListener(l); err != nil {
+		log.LogError("failed to listen smux addr with tls, err:", err)
+		return
+	}
 	s.smuxListener = l
 	go func(ln net.Listener) {
 		for {
@@ -555,14 +619,15 @@ func (s *DataNode) stopSmuxService() (err error) {
 func (s *DataNode) serveSmuxConn(conn net.Conn) {
 	space := s.space
 	space.Stats().AddConnection()
-	c, _ := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := conn.(*net.TCPConn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 	var sess *smux.Session
 	var err error
 	sess, err = smux.Server(conn, s.smuxServerConfig)
 	if err != nil {
-		log.LogErrorf("action[serveSmuxConn] failed to serve smux connection, addr(%v), err(%v)", c.RemoteAddr(), err)
+		log.LogErrorf("action[serveSmuxConn] failed to serve smux connection, addr(%v), err(%v)", conn.RemoteAddr(), err)
 		return
 	}
 	defer sess.Close()
@@ -582,7 +647,7 @@ func (s *DataNode) serveSmuxConn(conn net.Conn) {
 }
 
 func (s *DataNode) serveSmuxStream(stream *smux.Stream) {
-	packetProcessor := repl.NewReplProtocol(stream, s.Prepare, s.OperatePacket, s.Post)
+	packetProcessor := repl.NewReplProtocol(s.authenticator.NewConn(stream), s.Prepare, s.OperatePacket, s.Post)
 	packetProcessor.ServerConn()
 }
 
@@ -659,9 +724,13 @@ func (s *DataNode) parseSmuxConfig(cfg *config.Config) error {
 }
 
 func (s *DataNode) initConnPool() {
+	// the connections to the peers are authenticated with the service tickets
+	handshake := s.authenticator.PeerCredential(proto.DataNode).Handshake(proto.DataServiceID)
+	gConnPool = util.NewConnectPoolWithHandshake(handshake)
+	repl.SetConnectHandshake(handshake)
 	if s.enableSmuxConnPool {
 		log.LogInfof("Start: init smux conn pool")
-		s.smuxConnPool = util.NewSmuxConnectPool(s.smuxConnPoolConfig)
+		s.smuxConnPool = util.NewSmuxConnectPoolWithHandshake(s.smuxConnPoolConfig, handshake)
 		s.getRepairConnFunc = func(target string) (net.Conn, error) {
 			addr := util.ShiftAddrPort(target, s.smuxPortShift)
 			log.LogDebugf("[dataNode.getRepairConnFunc] get smux conn, addr(%v)", addr)
@@ -678,7 +747,7 @@ func (s *DataNode) initConnPool() {
 		}
 		s.putRepairConnFunc = func(conn net.Conn, forceClose bool) {
 			log.LogDebugf("[dataNode.putRepairConnFunc] put tcp conn, addr(%v), forceClose(%v)", conn.RemoteAddr().String(), forceClose)
-			gConnPool.PutConnect(conn.(*net.TCPConn), forceClose)
+			gConnPool.PutConnect(conn, forceClose)
 			return
 		}
 	}
@@ -93,6 +93,13 @@ func (manager *SpaceManager) Stop() {
 		}(partitionC)
 	}
 	wg.Wait()
+
+	// the journals are closed after the partitions, which stop writing to them
+	for _, disk := range manager.GetDisks() {
+		if disk.journal != nil {
+			disk.journal.Close()
+		}
+	}
 }
 
 func (manager *SpaceManager) SetNodeID(nodeID uint64) {
@@ -172,10 +179,21 @@ func (manager *SpaceManager) LoadDisk(path string, reservedSpace, diskRdonlySpac
 
 	if _, err = manager.GetDisk(path); err != nil {
 		disk = NewDisk(path, reservedSpace, diskRdonlySpace, maxErrCnt, manager)
+		dir := manager.dataNode.diskJournals[path]
+		if disk.journal, err = LoadDiskJournal(disk, dir, manager.dataNode.journalSize); err != nil {
+			log.LogCriticalf("action[LoadDisk] disk(%v) load journal(%v) err(%v).", path, dir, err)
+			return
+		}
 		disk.RestorePartition(visitor)
 		manager.putDisk(disk)
 		err = nil
+		if disk.journal != nil {
+			disk.journal.startApplyTask()
+		}
 		go disk.doBackendTask()
+		if manager.dataNode.scrubRate > 0 {
+			go disk.doScrubTask(manager.stopC)
+		}
 	}
 	return
 }
@@ -338,6 +356,14 @@ func (manager *SpaceManager) DeletePartition(dpID uint64) {
 
 	dp.Stop()
 	dp.Disk().DetachDataPartition(dp)
+	if journal := dp.Disk().journal; journal != nil {
+		// the journaled writes are applied to the partition before it is removed,
+		// it is kept if the journal is halted, so that they can be applied after the restart
+		if err := journal.WaitPartitionApplied(dpID); err != nil {
+			log.LogCriticalf("action[DeletePartition] partition(%v) kept, err(%v).", dpID, err)
+			return
+		}
+	}
 	os.RemoveAll(dp.Path())
 }
 
@@ -371,6 +397,7 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 			IsLeader:        isLeader,
 			ExtentCount:     partition.GetExtentCount(),
 			NeedCompare:     true,
+			Scrub:           partition.ScrubInfo(),
 		}
 		log.LogDebugf("action[Heartbeats] dpid(%v), status(%v) total(%v) used(%v) leader(%v) isLeader(%v).", vr.PartitionID, vr.PartitionStatus, vr.Total, vr.Used, leaderAddr, vr.IsLeader)
 		response.PartitionReports = append(response.PartitionReports, vr)
@@ -35,6 +35,7 @@ import (
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 func (s *DataNode) getPacketTpLabels(p *repl.Packet) map[string]string {
@@ -82,13 +83,18 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 	var (
 		tpLabels map[string]string
 		tpObject *exporter.TimePointCount
+		opMetric *exporter.OpTimePoint
 	)
 
 	shallDegrade := p.ShallDegrade()
 	sz := p.Size
+	p.Span = trace.StartSpan("datanode."+p.GetOpMsg(), p.TraceContext)
+	p.Span.SetTag("partition_id", p.PartitionID)
+	p.Span.SetTag("req_id", p.ReqID)
 	if !shallDegrade {
 		tpObject = exporter.NewTPCnt(p.GetOpMsg())
 		tpLabels = s.getPacketTpLabels(p)
+		opMetric = exporter.NewOpTP(p.GetOpMsg(), tpLabels[exporter.Vol], p.Span.TraceID())
 	}
 	start := time.Now().UnixNano()
 	defer func() {
@@ -119,7 +125,9 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 		p.Size = resultSize
 		if !shallDegrade {
 			tpObject.SetWithLabels(err, tpLabels)
+			opMetric.Set(err)
 		}
+		p.Span.Finish(err)
 	}()
 	switch p.Opcode {
 	case proto.OpCreateExtent:
@@ -129,7 +137,7 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 	case proto.OpStreamRead:
 		s.handleStreamReadPacket(p, c, StreamRead)
 	case proto.OpStreamFollowerRead:
-		s.extentRepairReadPacket(p, c, StreamRead)
+		s.handleStreamFollowerReadPacket(p, c)
 	case proto.OpExtentRepairRead:
 		s.handleExtentRepairReadPacket(p, c, RepairRead)
 	case proto.OpTinyExtentRepairRead:
@@ -168,6 +176,8 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c net.Conn) (err error) {
 		s.handlePacketToGetMaxExtentIDAndPartitionSize(p)
 	case proto.OpReadTinyDeleteRecord:
 		s.handlePacketToReadTinyDeleteRecordFile(p, c)
+	case proto.OpAuthenticate:
+		s.handlePacketToAuthenticate(p, c)
 	case proto.OpBroadcastMinAppliedID:
 		s.handleBroadcastMinAppliedID(p)
 	default:
@@ -499,6 +509,10 @@ func (s *DataNode) handleWritePacket(p *repl.Packet) {
 		return
 	}
 	store := partition.ExtentStore()
+	ioSpan := trace.StartChildSpan("datanode.disk.write", p.Span.Context())
+	defer func() {
+		ioSpan.Finish(err)
+	}()
 	if p.ExtentType == proto.TinyExtentType {
 		if !shallDegrade {
 			partitionIOMetric = exporter.NewTPCnt(MetricPartitionIOName)
@@ -596,7 +610,9 @@ func (s *DataNode) handleRandomWritePacket(p *repl.Packet) {
 		metricPartitionIOLabels = GetIoMetricLabels(partition, "randwrite")
 		partitionIOMetric = exporter.NewTPCnt(MetricPartitionIOName)
 	}
+	raftSpan := trace.StartChildSpan("datanode.raft.submit", p.Span.Context())
 	err = partition.RandomWriteSubmit(p)
+	raftSpan.Finish(err)
 	if !shallDegrade {
 		s.metrics.MetricIOBytes.AddWithLabels(int64(p.Size), metricPartitionIOLabels)
 		partitionIOMetric.SetWithLabels(err, metricPartitionIOLabels)
@@ -639,6 +655,21 @@ func (s *DataNode) handleStreamReadPacket(p *repl.Packet, connect net.Conn, isRe
 	return
 }
 
+// handleStreamFollowerReadPacket serves the read on any replica, a bounded
+// staleness read carries the applied ID learned from the leader, and the
+// replica rejects it if it can not catch up in time.
+func (s *DataNode) handleStreamFollowerReadPacket(p *repl.Packet, connect net.Conn) {
+	if minAppliedID := p.GetMinAppliedID(); minAppliedID > 0 {
+		partition := p.Object.(*DataPartition)
+		if err := partition.WaitForAppliedID(minAppliedID, FollowerReadMaxWaitAppliedMs*time.Millisecond); err != nil {
+			p.PackErrorBody(ActionStreamRead, err.Error())
+			p.WriteToConn(connect)
+			return
+		}
+	}
+	s.extentRepairReadPacket(p, connect, StreamRead)
+}
+
 func (s *DataNode) handleExtentRepairReadPacket(p *repl.Packet, connect net.Conn, isRepairRead bool) {
 	var (
 		err error
@@ -682,6 +713,9 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 	needReplySize := p.Size
 	offset := p.ExtentOffset
 	store := partition.ExtentStore()
+	if err = partition.waitJournalApplied(p.ExtentID); err != nil {
+		return
+	}
 	shallDegrade := p.ShallDegrade()
 	if !shallDegrade {
 		metricPartitionIOLabels = GetIoMetricLabels(partition, "read")
@@ -710,7 +744,9 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 		partition.Disk().allocCheckLimit(proto.IopsReadType, 1)
 		partition.Disk().allocCheckLimit(proto.FlowReadType, currReadSize)
 
+		ioSpan := trace.StartChildSpan("datanode.disk.read", p.Span.Context())
 		reply.CRC, err = store.Read(reply.ExtentID, offset, int64(currReadSize), reply.Data, isRepairRead)
+		ioSpan.Finish(err)
 		if !shallDegrade {
 			s.metrics.MetricIOBytes.AddWithLabels(int64(p.Size), metricPartitionIOLabels)
 			partitionIOMetric.SetWithLabels(err, metricPartitionIOLabels)
@@ -811,6 +847,9 @@ func (s *DataNode) tinyExtentRepairRead(request *repl.Packet, connect net.Conn)
 
 	partition := request.Object.(*DataPartition)
 	store := partition.ExtentStore()
+	if err = partition.waitJournalApplied(request.ExtentID); err != nil {
+		return
+	}
 	tinyExtentFinfoSize, err = store.TinyExtentGetFinfoSize(request.ExtentID)
 	if err != nil {
 		return
@@ -967,6 +1006,13 @@ func (s *DataNode) handlePacketToGetAppliedID(p *repl.Packet) {
 	return
 }
 
+// Handle OpAuthenticate packet, the ticket is bound to the connection.
+func (s *DataNode) handlePacketToAuthenticate(p *repl.Packet, c net.Conn) {
+	if err := s.authenticator.Authenticate(&p.Packet, c); err != nil {
+		p.AddMesgLog(fmt.Sprintf("_authenticate(%v)", err))
+	}
+}
+
 func (s *DataNode) handlePacketToGetPartitionSize(p *repl.Packet) {
 	partition := p.Object.(*DataPartition)
 	usedSize := partition.extentStore.StoreSizeExtentID(p.ExtentID)
@@ -1224,7 +1270,7 @@ func (s *DataNode) handlePacketToDataPartitionTryToLeaderrr(p *repl.Packet) {
 
 func (s *DataNode) forwardToRaftLeader(dp *DataPartition, p *repl.Packet, force bool) (ok bool, err error) {
 	var (
-		conn       *net.TCPConn
+		conn       net.Conn
 		leaderAddr string
 	)
 
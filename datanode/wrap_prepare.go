@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/crc32"
+	"net"
 	"sync/atomic"
 
 	"github.com/cubefs/cubefs/proto"
@@ -25,14 +26,21 @@ import (
 	"github.com/cubefs/cubefs/storage"
 )
 
-func (s *DataNode) Prepare(p *repl.Packet) (err error) {
+func (s *DataNode) Prepare(p *repl.Packet, c net.Conn) (err error) {
 	defer func() {
 		p.SetPacketHasPrepare()
 		if err != nil {
 			p.PackErrorBody(repl.ActionPreparePkt, err.Error())
 		}
 	}()
+	if p.Opcode == proto.OpAuthenticate {
+		return
+	}
 	if p.IsMasterCommand() {
+		// the admin commands are only accepted from the master
+		if err = s.authenticator.AuthorizeMaster(c); err != nil {
+			err = fmt.Errorf("checkAuthority %v remote %v: %v", p.GetOpMsg(), c.RemoteAddr(), err)
+		}
 		return
 	}
 	atomic.AddUint64(&s.metricsCnt, 1)
@@ -52,6 +60,9 @@ func (s *DataNode) Prepare(p *repl.Packet) (err error) {
 	if err = s.checkPartition(p); err != nil {
 		return
 	}
+	if err = s.checkAuthority(p, c); err != nil {
+		return
+	}
 	// For certain packet, we meed to add some additional extent information.
 	if err = s.addExtentInfo(p); err != nil {
 		return
@@ -95,6 +106,17 @@ func (s *DataNode) checkPartition(p *repl.Packet) (err error) {
 	return
 }
 
+// checkAuthority checks every request to a partition of the volumes with authentication
+// enabled. The deletions from the metanodes and the requests between the replicas are
+// authenticated by the service tickets of the peers.
+func (s *DataNode) checkAuthority(p *repl.Packet, c net.Conn) (err error) {
+	dp := p.Object.(*DataPartition)
+	if err = s.authenticator.Authorize(c, dp.volumeID); err != nil {
+		err = fmt.Errorf("checkAuthority partition %v vol %v remote %v: %v", p.PartitionID, dp.volumeID, c.RemoteAddr(), err)
+	}
+	return
+}
+
 func (s *DataNode) addExtentInfo(p *repl.Packet) error {
 	partition := p.Object.(*DataPartition)
 	store := p.Object.(*DataPartition).ExtentStore()
@@ -28,7 +28,9 @@ import (
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/auth"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 	"github.com/cubefs/cubefs/util/ump"
 )
 
@@ -111,6 +113,12 @@ func Clean(opt string) error {
 		Volume:  VolName,
 		Masters: masters,
 	}
+	if ClientKey != "" {
+		metaConfig.Credential = packetauth.NewCredential(AuthClientID, auth.TicketMess{
+			ClientKey:   ClientKey,
+			TicketHosts: strings.Split(TicketHost, ","),
+		})
+	}
 
 	gMetaWrapper, err = meta.NewMetaWrapper(metaConfig)
 	if err != nil {
@@ -24,6 +24,10 @@ var (
 	InodesFile string
 	DensFile   string
 	MetaPort   string
+
+	AuthClientID string
+	ClientKey    string
+	TicketHost   string
 )
 
 var (
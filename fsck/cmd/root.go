@@ -47,6 +47,9 @@ func NewRootCmd() *cobra.Command {
 	c.PersistentFlags().StringVarP(&InodesFile, "inode-list", "i", "", "inode list file")
 	c.PersistentFlags().StringVarP(&DensFile, "dentry-list", "d", "", "dentry list file")
 	c.PersistentFlags().StringVarP(&MetaPort, "mport", "", "", "prof port of metanode")
+	c.PersistentFlags().StringVarP(&AuthClientID, "auth-client-id", "", "", "client id registered in authnode")
+	c.PersistentFlags().StringVarP(&ClientKey, "client-key", "", "", "client key to get the tickets from authnode")
+	c.PersistentFlags().StringVarP(&TicketHost, "ticket-host", "", "", "authnode addresses")
 	c.Flags().BoolVarP(&optShowVersion, "version", "v", false, "Show version information")
 	return c
 }
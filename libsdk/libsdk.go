@@ -102,8 +102,11 @@ import (
 	"github.com/cubefs/cubefs/sdk/data/stream"
 	masterSDK "github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/auth"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 	"github.com/cubefs/cubefs/util/stat"
 	"github.com/hashicorp/consul/api"
 )
@@ -218,6 +221,8 @@ type client struct {
 	volName          string
 	masterAddr       string
 	followerRead     bool
+	readConsistency  string
+	readStalenessMS  int64
 	logDir           string
 	logLevel         string
 	ebsEndpoint      string
@@ -225,6 +230,7 @@ type client struct {
 	volType          int
 	cacheAction      int
 	ebsBlockSize     int
+	ebsPackSize      int
 	enableBcache     bool
 	readBlockThread  int
 	writeBlockThread int
@@ -236,6 +242,10 @@ type client struct {
 	subDir           string
 	pushAddr         string
 	cluster          string
+	tlsConfig        util.TLSConfig
+	clusterTLSMode   string
+	authClientID     string
+	ticketMess       auth.TicketMess
 	// runtime context
 	cwd    string // current working directory
 	fdmap  map[uint]*file
@@ -249,6 +259,7 @@ type client struct {
 	dc   *fs.DentryCache
 	bc   *bcache.BcacheClient
 	ebsc *blobstore.BlobStoreClient
+	pk   *blobstore.Packer
 	sc   *fs.SummaryCache
 }
 
@@ -280,6 +291,17 @@ func cfs_set_client(id C.int64_t, key, val *C.char) C.int {
 		} else {
 			c.followerRead = false
 		}
+	case "readConsistency":
+		if !proto.IsValidReadConsistency(v) {
+			return statusEINVAL
+		}
+		c.readConsistency = v
+	case "readMaxStalenessMS":
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms < 0 {
+			return statusEINVAL
+		}
+		c.readStalenessMS = ms
 	case "logDir":
 		c.logDir = v
 	case "logLevel":
@@ -300,6 +322,12 @@ func cfs_set_client(id C.int64_t, key, val *C.char) C.int {
 		if err == nil {
 			c.writeBlockThread = wt
 		}
+	case "ebsPackSize":
+		ps, err := strconv.Atoi(v)
+		if err != nil || ps < 0 {
+			return statusEINVAL
+		}
+		c.ebsPackSize = ps
 	case "enableSummary":
 		if v == "true" {
 			c.enableSummary = true
@@ -312,6 +340,26 @@ func cfs_set_client(id C.int64_t, key, val *C.char) C.int {
 		c.secretKey = v
 	case "pushAddr":
 		c.pushAddr = v
+	case "tlsMode":
+		c.tlsConfig.Mode = v
+	case "tlsCertFile":
+		c.tlsConfig.CertFile = v
+	case "tlsKeyFile":
+		c.tlsConfig.KeyFile = v
+	case "tlsCAFile":
+		c.tlsConfig.CAFile = v
+	case "tlsServerName":
+		c.tlsConfig.ServerName = v
+	case packetauth.ConfigKeyClientID:
+		c.authClientID = v
+	case packetauth.ConfigKeyClientKey:
+		c.ticketMess.ClientKey = v
+	case packetauth.ConfigKeyTicketHost:
+		c.ticketMess.TicketHosts = strings.Split(v, ",")
+	case packetauth.ConfigKeyEnableHTTPS:
+		c.ticketMess.EnableHTTPS = v == "true"
+	case packetauth.ConfigKeyCertFile:
+		c.ticketMess.CertFile = v
 	default:
 		return statusEINVAL
 	}
@@ -1096,6 +1144,14 @@ func (c *client) start() (err error) {
 	if err = c.loadConfFromMaster(masters); err != nil {
 		return
 	}
+	// the transport is shared by all the clients of the process, the mode of the cluster is followed if not configured
+	if tlsConfig := c.tlsConfig.WithClusterMode(c.clusterTLSMode); tlsConfig.Mode != "" {
+		var tlsTransport *util.TLSTransport
+		if tlsTransport, err = util.NewTLSTransport(tlsConfig); err != nil {
+			return
+		}
+		util.SetTLSTransport(tlsTransport)
+	}
 	if err = c.checkPermission(); err != nil {
 		err = errors.NewErrorf("check permission failed: %v", err)
 		syslog.Println(err)
@@ -1123,30 +1179,40 @@ func (c *client) start() (err error) {
 			return
 		}
 	}
+	var credential *packetauth.Credential
+	if c.ticketMess.ClientKey != "" {
+		credential = packetauth.NewCredential(c.authClientID, c.ticketMess)
+	}
 	var mw *meta.MetaWrapper
 	if mw, err = meta.NewMetaWrapper(&meta.MetaConfig{
 		Volume:        c.volName,
 		Masters:       masters,
 		ValidateOwner: false,
 		EnableSummary: c.enableSummary,
+		Credential:    credential,
 	}); err != nil {
 		log.LogErrorf("newClient NewMetaWrapper failed(%v)", err)
 		return err
 	}
 	var ec *stream.ExtentClient
 	if ec, err = stream.NewExtentClient(&stream.ExtentConfig{
-		Volume:            c.volName,
-		VolumeType:        c.volType,
-		Masters:           masters,
-		FollowerRead:      c.followerRead,
-		OnAppendExtentKey: mw.AppendExtentKey,
-		OnGetExtents:      mw.GetExtents,
-		OnTruncate:        mw.Truncate,
-		BcacheEnable:      c.enableBcache,
-		OnLoadBcache:      c.bc.Get,
-		OnCacheBcache:     c.bc.Put,
-		OnEvictBcache:     c.bc.Evict,
-		DisableMetaCache:  true,
+		Volume:             c.volName,
+		VolumeType:         c.volType,
+		Masters:            masters,
+		FollowerRead:       c.followerRead,
+		ReadConsistency:    c.readConsistency,
+		ReadMaxStalenessMS: c.readStalenessMS,
+		OnAppendExtentKey:  mw.AppendExtentKey,
+		OnGetExtents:       mw.GetExtents,
+		OnTruncate:         mw.Truncate,
+		BcacheEnable:       c.enableBcache,
+		OnLoadBcache:       c.bc.Get,
+		OnCacheBcache:      c.bc.Put,
+		OnEvictBcache:      c.bc.Evict,
+		OnInlineWrite:      mw.InlineWrite,
+		OnInlineRead:       mw.InlineRead,
+		DisableMetaCache:   true,
+		Credential:         credential,
 	}); err != nil {
 		log.LogErrorf("newClient NewExtentClient failed(%v)", err)
 		return
@@ -1170,6 +1236,15 @@ func (c *client) start() (err error) {
 	c.mw = mw
 	c.ec = ec
 	c.ebsc = ebsc
+	if ebsc != nil && c.ebsPackSize > 0 {
+		c.pk = blobstore.NewPacker(blobstore.PackerConfig{
+			VolName:     c.volName,
+			Mw:          mw,
+			Ebsc:        ebsc,
+			BlockSize:   c.ebsBlockSize,
+			MaxFileSize: c.ebsPackSize,
+		})
+	}
 	return nil
 }
 
@@ -1194,13 +1269,13 @@ func (c *client) checkPermission() (err error) {
 		return
 	}
 	// read write
-	if policy.IsAuthorized(c.volName, c.subDir, proto.POSIXWriteAction) &&
-		policy.IsAuthorized(c.volName, c.subDir, proto.POSIXReadAction) {
+	if userInfo.IsAuthorized(c.volName, c.subDir, proto.POSIXWriteAction) &&
+		userInfo.IsAuthorized(c.volName, c.subDir, proto.POSIXReadAction) {
 		return
 	}
 	// read only
-	if policy.IsAuthorized(c.volName, c.subDir, proto.POSIXReadAction) &&
-		!policy.IsAuthorized(c.volName, c.subDir, proto.POSIXWriteAction) {
+	if userInfo.IsAuthorized(c.volName, c.subDir, proto.POSIXReadAction) &&
+		!userInfo.IsAuthorized(c.volName, c.subDir, proto.POSIXWriteAction) {
 		return
 	}
 	err = proto.ErrNoPermission
@@ -1233,6 +1308,7 @@ func (c *client) allocFD(ino uint64, flags, mode uint32, fileCache bool, fileSiz
 			FileCache:       fileCache,
 			FileSize:        fileSize,
 			CacheThreshold:  c.cacheThreshold,
+			Packer:          c.pk,
 		}
 
 		switch flags & 0xff {
@@ -1396,6 +1472,7 @@ func (c *client) loadConfFromMaster(masters []string) (err error) {
 	c.ebsEndpoint = clusterInfo.EbsAddr
 	c.servicePath = clusterInfo.ServicePath
 	c.cluster = clusterInfo.Cluster
+	c.clusterTLSMode = clusterInfo.TLSMode
 	buf.InitCachePool(c.ebsBlockSize)
 	return
 }
@@ -44,11 +44,12 @@ type AdminTaskManager struct {
 	targetAddr string
 	TaskMap    map[string]*proto.AdminTask
 	sync.RWMutex
-	exitCh   chan struct{}
-	connPool *util.ConnectPool
+	exitCh    chan struct{}
+	connPool  *util.ConnectPool
+	handshake util.Handshake // presents the master ticket to the node, nil if not configured
 }
 
-func newAdminTaskManager(targetAddr, clusterID string) (sender *AdminTaskManager) {
+func newAdminTaskManager(targetAddr, clusterID string, handshake util.Handshake) (sender *AdminTaskManager) {
 
 	proto.InitBufferPool(int64(32768))
 
@@ -57,7 +58,8 @@ func newAdminTaskManager(targetAddr, clusterID string) (sender *AdminTaskManager
 		clusterID:  clusterID,
 		TaskMap:    make(map[string]*proto.AdminTask),
 		exitCh:     make(chan struct{}, 1),
-		connPool:   util.NewConnectPoolWithTimeout(idleConnTimeout, connectTimeout),
+		connPool:   util.NewConnectPoolWithTimeoutAndHandshake(idleConnTimeout, connectTimeout, handshake),
+		handshake:  handshake,
 	}
 	go sender.process()
 
@@ -118,21 +120,21 @@ func (sender *AdminTaskManager) doSendTasks() {
 	sender.sendTasks(tasks)
 }
 
-func (sender *AdminTaskManager) getConn() (conn *net.TCPConn, err error) {
+func (sender *AdminTaskManager) getConn() (conn net.Conn, err error) {
 	if useConnPool {
 		return sender.connPool.GetConnect(sender.targetAddr)
 	}
-	var connect net.Conn
-	connect, err = net.Dial("tcp", sender.targetAddr)
-	if err == nil {
-		conn = connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
+	if conn, err = util.DailTimeOut(sender.targetAddr, 0); err != nil || sender.handshake == nil {
+		return
+	}
+	if err = sender.handshake(conn); err != nil {
+		conn.Close()
+		conn = nil
 	}
 	return
 }
 
-func (sender *AdminTaskManager) putConn(conn *net.TCPConn, forceClose bool) {
+func (sender *AdminTaskManager) putConn(conn net.Conn, forceClose bool) {
 	if useConnPool {
 		sender.connPool.PutConnect(conn, forceClose)
 	}
@@ -231,6 +231,7 @@ type updateVolReq struct {
 	description    string
 	dpSelectorName string
 	dpSelectorParm string
+	inlineSize     uint64
 	coldArgs       *coldVolArgs
 }
 
@@ -324,6 +325,10 @@ func parseVolUpdateReq(r *http.Request, vol *Vol, req *updateVolReq) (err error)
 		return
 	}
 
+	if req.inlineSize, err = extractInlineSizeLimit(r, vol.inlineSizeLimit); err != nil {
+		return
+	}
+
 	req.dpSelectorName = r.FormValue(dpSelectorNameKey)
 	req.dpSelectorParm = r.FormValue(dpSelectorParmKey)
 
@@ -449,6 +454,7 @@ type createVolReq struct {
 	description                          string
 	volType                              int
 	enablePosixAcl                       bool
+	inlineSize                           uint64
 	qosLimitArgs                         *qosArgs
 	clientReqPeriod, clientHitTriggerCnt uint32
 	// cold vol args
@@ -576,11 +582,27 @@ func parseRequestToCreateVol(r *http.Request, req *createVolReq) (err error) {
 		return
 	}
 
+	if req.inlineSize, err = extractInlineSizeLimit(r, 0); err != nil {
+		return
+	}
+
 	req.enablePosixAcl, err = extractPosixAcl(r)
 
 	return
 }
 
+func extractInlineSizeLimit(r *http.Request, def uint64) (size uint64, err error) {
+	if size, err = extractUint64WithDefault(r, inlineSizeLimitKey, def); err != nil {
+		return
+	}
+
+	if size > proto.MaxInlineDataSize {
+		return 0, fmt.Errorf("%s(%d) can't be larger than %d", inlineSizeLimitKey, size, proto.MaxInlineDataSize)
+	}
+
+	return
+}
+
 func parseRequestToCreateDataPartition(r *http.Request) (count int, name string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -713,6 +735,25 @@ func parseRequestToDecommissionMetaPartition(r *http.Request) (partitionID uint6
 	return extractMetaPartitionIDAndAddr(r)
 }
 
+func parseRequestToSplitMetaPartition(r *http.Request) (volName string, partitionID, start uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if volName, err = extractName(r); err != nil {
+		return
+	}
+	if partitionID, err = extractMetaPartitionID(r); err != nil {
+		return
+	}
+	if value := r.FormValue(startKey); value != "" {
+		if start, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(startKey)
+			return
+		}
+	}
+	return
+}
+
 func parseAndExtractStatus(r *http.Request) (status bool, err error) {
 
 	if err = r.ParseForm(); err != nil {
@@ -896,6 +937,17 @@ func parseAndExtractSetNodeInfoParams(r *http.Request) (params map[string]interf
 		params[maxDpCntLimitKey] = val
 	}
 
+	if value = r.FormValue(mpSplitMemKey); value != "" {
+		noParams = false
+		var val = uint64(0)
+		val, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			err = unmatchedKey(mpSplitMemKey)
+			return
+		}
+		params[mpSplitMemKey] = val
+	}
+
 	if noParams {
 		err = keyNotFound(nodeDeleteBatchCountKey)
 		return
@@ -1062,7 +1114,7 @@ func extractOwner(r *http.Request) (owner string, err error) {
 	return
 }
 
-func parseAndCheckTicket(r *http.Request, key []byte, volName string) (jobj proto.APIAccessReq, ticket cryptoutil.Ticket, ts int64, err error) {
+func parseAndCheckTicket(r *http.Request, keys [][]byte, volName string) (jobj proto.APIAccessReq, ticket cryptoutil.Ticket, ts int64, err error) {
 	var (
 		plaintext []byte
 	)
@@ -1083,7 +1135,7 @@ func parseAndCheckTicket(r *http.Request, key []byte, volName string) (jobj prot
 		return
 	}
 
-	ticket, ts, err = extractTicketMess(&jobj, key, volName)
+	ticket, ts, err = extractTicketMess(&jobj, keys, volName)
 
 	return
 }
@@ -1108,8 +1160,8 @@ func extractClientReqInfo(r *http.Request) (plaintext []byte, err error) {
 	return
 }
 
-func extractTicketMess(req *proto.APIAccessReq, key []byte, volName string) (ticket cryptoutil.Ticket, ts int64, err error) {
-	if ticket, err = proto.ExtractTicket(req.Ticket, key); err != nil {
+func extractTicketMess(req *proto.APIAccessReq, keys [][]byte, volName string) (ticket cryptoutil.Ticket, ts int64, err error) {
+	if ticket, err = proto.ExtractTicket(req.Ticket, keys...); err != nil {
 		err = fmt.Errorf("extractTicket failed: %s", err.Error())
 		return
 	}
@@ -253,6 +253,7 @@ func (m *Server) getIPAddr(w http.ResponseWriter, r *http.Request) {
 		Ip:                          strings.Split(r.RemoteAddr, ":")[0],
 		EbsAddr:                     m.bStoreAddr,
 		ServicePath:                 m.servicePath,
+		TLSMode:                     m.tlsMode,
 	}
 
 	sendOkReply(w, r, newSuccessHTTPReply(cInfo))
@@ -997,10 +998,12 @@ func (m *Server) diagnoseDataPartition(w http.ResponseWriter, r *http.Request) {
 		lackReplicaDps    []*DataPartition
 		corruptDpIDs      []uint64
 		lackReplicaDpIDs  []uint64
+		scrubCorruptDpIDs []uint64
 		badDataPartitions []badPartitionView
 	)
 	corruptDpIDs = make([]uint64, 0)
 	lackReplicaDpIDs = make([]uint64, 0)
+	scrubCorruptDpIDs = make([]uint64, 0)
 	if inactiveNodes, corruptDps, err = m.cluster.checkCorruptDataPartitions(); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -1016,14 +1019,19 @@ func (m *Server) diagnoseDataPartition(w http.ResponseWriter, r *http.Request) {
 	for _, dp := range lackReplicaDps {
 		lackReplicaDpIDs = append(lackReplicaDpIDs, dp.PartitionID)
 	}
+	for _, dp := range m.cluster.checkScrubCorruptDataPartitions() {
+		scrubCorruptDpIDs = append(scrubCorruptDpIDs, dp.PartitionID)
+	}
 	badDataPartitions = m.cluster.getBadDataPartitionsView()
 	rstMsg = &proto.DataPartitionDiagnosis{
 		InactiveDataNodes:           inactiveNodes,
 		CorruptDataPartitionIDs:     corruptDpIDs,
 		LackReplicaDataPartitionIDs: lackReplicaDpIDs,
 		BadDataPartitionIDs:         badDataPartitions,
+		ScrubCorruptPartitionIDs:    scrubCorruptDpIDs,
 	}
-	log.LogInfof("diagnose dataPartition[%v] inactiveNodes:[%v], corruptDpIDs:[%v], lackReplicaDpIDs:[%v]", m.cluster.Name, inactiveNodes, corruptDpIDs, lackReplicaDpIDs)
+	log.LogInfof("diagnose dataPartition[%v] inactiveNodes:[%v], corruptDpIDs:[%v], lackReplicaDpIDs:[%v], scrubCorruptDpIDs:[%v]",
+		m.cluster.Name, inactiveNodes, corruptDpIDs, lackReplicaDpIDs, scrubCorruptDpIDs)
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
@@ -1079,6 +1087,13 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.inlineSize > 0 && vol.inlineSizeLimit == 0 {
+		if err = m.cluster.checkInlineDataSupported(); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+	}
+
 	if vol.dpReplicaNum == 1 && !req.followRead {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: "single replica must enable follower read"})
 	}
@@ -1101,6 +1116,7 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.dpSelectorName = req.dpSelectorName
 	newArgs.dpSelectorParm = req.dpSelectorParm
 	newArgs.enablePosixAcl = req.enablePosixAcl
+	newArgs.inlineSize = req.inlineSize
 	if req.coldArgs != nil {
 		newArgs.coldArgs = req.coldArgs
 	}
@@ -1297,6 +1313,13 @@ func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+
+	if req.inlineSize > 0 {
+		if err = m.cluster.checkInlineDataSupported(); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+	}
 	if vol, err = m.cluster.createVol(req); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -1382,12 +1405,16 @@ func newSimpleView(vol *Vol) *proto.SimpleVolView {
 	var (
 		volInodeCount  uint64
 		volDentryCount uint64
+		maxPartitionID uint64
 	)
 	for _, mp := range vol.MetaPartitions {
 		volDentryCount = volDentryCount + mp.DentryCount
 		volInodeCount = volInodeCount + mp.InodeCount
+		// the largest id, which is not the tail of the inode range once a partition is split
+		if mp.PartitionID > maxPartitionID {
+			maxPartitionID = mp.PartitionID
+		}
 	}
-	maxPartitionID := vol.maxPartitionID()
 	return &proto.SimpleVolView{
 		ID:                 vol.ID,
 		Name:               vol.Name,
@@ -1414,6 +1441,7 @@ func newSimpleView(vol *Vol) *proto.SimpleVolView {
 		Description:        vol.description,
 		DpSelectorName:     vol.dpSelectorName,
 		DpSelectorParm:     vol.dpSelectorParm,
+		InlineSizeLimit:    vol.inlineSizeLimit,
 		VolType:            vol.VolType,
 		ObjBlockSize:       vol.EbsBlkSize,
 		CacheCapacity:      vol.CacheCapacity,
@@ -1701,6 +1729,15 @@ func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+
+	if val, ok := params[mpSplitMemKey]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setMetaPartitionSplitMemMB(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set nodeinfo params %v successfully", params)))
 
 }
@@ -2309,6 +2346,7 @@ func (m *Server) getNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
 	resp[nodeAutoRepairRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeAutoRepairLimitRate)
 	resp[clusterLoadFactorKey] = fmt.Sprintf("%v", m.cluster.cfg.ClusterLoadFactor)
 	resp[maxDpCntLimitKey] = fmt.Sprintf("%v", m.cluster.cfg.MaxDpCntLimit)
+	resp[mpSplitMemKey] = fmt.Sprintf("%v", atomic.LoadUint64(&m.cluster.cfg.MetaPartitionSplitMemMB))
 
 	sendOkReply(w, r, newSuccessHTTPReply(resp))
 }
@@ -2403,6 +2441,32 @@ func (m *Server) decommissionDisk(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
+func (m *Server) queryDiskEvacuation(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.diskEvacuationMgr.getView(m.cluster.cfg.DiskEvacuateLimit)))
+}
+
+// Hold or release the automatic evacuation of a bad disk, or of all the disks on a node if no disk is given.
+func (m *Server) holdDiskEvacuation(w http.ResponseWriter, r *http.Request) {
+	var (
+		addr, diskPath string
+		hold           bool
+		err            error
+	)
+	if addr, diskPath, hold, err = parseReqToHoldDiskEvacuation(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.dataNode(addr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+		return
+	}
+	if err = m.cluster.holdDiskEvacuation(addr, diskPath, hold); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set evacuation hold of node[%v] disk[%v] to [%v] successfully", addr, diskPath, hold)))
+}
+
 // handle tasks such as heartbeat，loadDataPartition，deleteDataPartition, etc.
 func (m *Server) handleDataNodeTaskResponse(w http.ResponseWriter, r *http.Request) {
 	tr, err := parseRequestToGetTaskResponse(r)
@@ -2546,6 +2610,29 @@ func (m *Server) decommissionMetaPartition(w http.ResponseWriter, r *http.Reques
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
+// splitMetaPartition splits a meta partition at the given start of the new partition,
+// or in the middle of the allocated inodes if no start is given.
+func (m *Server) splitMetaPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		volName     string
+		partitionID uint64
+		start       uint64
+		nextMp      *MetaPartition
+		err         error
+	)
+	if volName, partitionID, start, err = parseRequestToSplitMetaPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if nextMp, err = m.cluster.splitMetaPartitionByRange(volName, partitionID, start); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf(proto.AdminSplitMetaPartition+" partitionID :%v split into partitionID :%v start :%v end :%v successfully",
+		partitionID, nextMp.PartitionID, nextMp.Start, nextMp.End)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
 func parseMigrateNodeParam(r *http.Request) (srcAddr, targetAddr string, limit int, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -2739,6 +2826,18 @@ func (m *Server) getRaftStatus(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(data))
 }
 
+func parseReqToHoldDiskEvacuation(r *http.Request) (nodeAddr, diskPath string, hold bool, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if nodeAddr, err = extractNodeAddr(r); err != nil {
+		return
+	}
+	diskPath = r.FormValue(diskPathKey)
+	hold, err = pareseBoolWithDefault(r, holdKey, true)
+	return
+}
+
 func parseReqToDecoDisk(r *http.Request) (nodeAddr, diskPath string, limit int, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -2883,7 +2982,7 @@ func (m *Server) getVol(w http.ResponseWriter, r *http.Request) {
 		viewCache = vol.getViewCache()
 	}
 	if !param.skipOwnerValidation && vol.authenticate {
-		if jobj, ticket, ts, err = parseAndCheckTicket(r, m.cluster.MasterSecretKey, param.name); err != nil {
+		if jobj, ticket, ts, err = parseAndCheckTicket(r, m.cluster.MasterSecretKeys, param.name); err != nil {
 			if err == proto.ErrExpiredTicket {
 				sendErrReply(w, r, newErrHTTPReply(err))
 				return
@@ -0,0 +1,366 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func (m *Server) createGroup(w http.ResponseWriter, r *http.Request) {
+	var (
+		param *proto.GroupCreateParam
+		group *proto.UserGroup
+		err   error
+	)
+	if param, err = parseGroupCreateParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.createGroup(param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	var (
+		groupID string
+		err     error
+	)
+	if groupID, err = parseGroup(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.user.deleteGroup(groupID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("delete group[%v] successfully", groupID)))
+}
+
+func (m *Server) getGroupInfo(w http.ResponseWriter, r *http.Request) {
+	var (
+		groupID string
+		group   *proto.UserGroup
+		err     error
+	)
+	if groupID, err = parseGroup(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.getGroup(groupID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) listGroups(w http.ResponseWriter, r *http.Request) {
+	_ = sendOkReply(w, r, newSuccessHTTPReply(m.user.getAllGroups()))
+}
+
+func (m *Server) addGroupMember(w http.ResponseWriter, r *http.Request) {
+	var (
+		groupID string
+		userID  string
+		group   *proto.UserGroup
+		err     error
+	)
+	if groupID, userID, err = parseGroupAndUser(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.addGroupMember(groupID, userID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) removeGroupMember(w http.ResponseWriter, r *http.Request) {
+	var (
+		groupID string
+		userID  string
+		group   *proto.UserGroup
+		err     error
+	)
+	if groupID, userID, err = parseGroupAndUser(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.removeGroupMember(groupID, userID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) updateGroupPolicy(w http.ResponseWriter, r *http.Request) {
+	var (
+		param *proto.GroupPermUpdateParam
+		group *proto.UserGroup
+		err   error
+	)
+	if param, err = parseGroupPermUpdateParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(param.Volume); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.updateGroupPolicy(param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) removeGroupPolicy(w http.ResponseWriter, r *http.Request) {
+	var (
+		param *proto.GroupPermRemoveParam
+		group *proto.UserGroup
+		err   error
+	)
+	if param, err = parseGroupPermRemoveParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.removeGroupPolicy(param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) grantGroupRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		groupID string
+		roleID  string
+		group   *proto.UserGroup
+		err     error
+	)
+	if groupID, roleID, err = parseGroupAndRole(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.grantGroupRole(groupID, roleID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) revokeGroupRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		groupID string
+		roleID  string
+		group   *proto.UserGroup
+		err     error
+	)
+	if groupID, roleID, err = parseGroupAndRole(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if group, err = m.user.revokeGroupRole(groupID, roleID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(group))
+}
+
+func (m *Server) createRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		param *proto.GroupCreateParam
+		role  *proto.UserRole
+		err   error
+	)
+	if param, err = parseGroupCreateParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if role, err = m.user.createRole(param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(role))
+}
+
+func (m *Server) deleteRole(w http.ResponseWriter, r *http.Request) {
+	var (
+		roleID string
+		err    error
+	)
+	if roleID, err = parseRole(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.user.deleteRole(roleID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("delete role[%v] successfully", roleID)))
+}
+
+func (m *Server) getRoleInfo(w http.ResponseWriter, r *http.Request) {
+	var (
+		roleID string
+		role   *proto.UserRole
+		err    error
+	)
+	if roleID, err = parseRole(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if role, err = m.user.getRole(roleID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(role))
+}
+
+func (m *Server) listRoles(w http.ResponseWriter, r *http.Request) {
+	_ = sendOkReply(w, r, newSuccessHTTPReply(m.user.getAllRoles()))
+}
+
+func (m *Server) updateRolePolicy(w http.ResponseWriter, r *http.Request) {
+	var (
+		param *proto.GroupPermUpdateParam
+		role  *proto.UserRole
+		err   error
+	)
+	if param, err = parseGroupPermUpdateParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.getVol(param.Volume); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if role, err = m.user.updateRoleVolPolicy(param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(role))
+}
+
+func (m *Server) removeRolePolicy(w http.ResponseWriter, r *http.Request) {
+	var (
+		param *proto.GroupPermRemoveParam
+		role  *proto.UserRole
+		err   error
+	)
+	if param, err = parseGroupPermRemoveParam(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if role, err = m.user.removeRoleVolPolicy(param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(role))
+}
+
+func parseGroupCreateParam(r *http.Request) (param *proto.GroupCreateParam, err error) {
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil {
+		return
+	}
+	param = &proto.GroupCreateParam{}
+	if err = json.Unmarshal(bytes, param); err != nil {
+		return
+	}
+	if !ownerRegexp.MatchString(param.ID) {
+		err = fmt.Errorf("invalid id: %v", param.ID)
+		return
+	}
+	return
+}
+
+func parseGroupPermUpdateParam(r *http.Request) (param *proto.GroupPermUpdateParam, err error) {
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil {
+		return
+	}
+	param = &proto.GroupPermUpdateParam{}
+	if err = json.Unmarshal(bytes, param); err != nil {
+		return
+	}
+	return
+}
+
+func parseGroupPermRemoveParam(r *http.Request) (param *proto.GroupPermRemoveParam, err error) {
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil {
+		return
+	}
+	param = &proto.GroupPermRemoveParam{}
+	if err = json.Unmarshal(bytes, param); err != nil {
+		return
+	}
+	return
+}
+
+func parseGroupAndUser(r *http.Request) (groupID, userID string, err error) {
+	if groupID, err = parseGroup(r); err != nil {
+		return
+	}
+	if userID, err = extractUser(r); err != nil {
+		return
+	}
+	return
+}
+
+func parseGroupAndRole(r *http.Request) (groupID, roleID string, err error) {
+	if groupID, err = parseGroup(r); err != nil {
+		return
+	}
+	if roleID, err = extractRole(r); err != nil {
+		return
+	}
+	return
+}
+
+func parseGroup(r *http.Request) (groupID string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if groupID = r.FormValue(groupKey); groupID == "" {
+		err = keyNotFound(groupKey)
+		return
+	}
+	return
+}
+
+func parseRole(r *http.Request) (roleID string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	return extractRole(r)
+}
+
+func extractRole(r *http.Request) (roleID string, err error) {
+	if roleID = r.FormValue(roleKey); roleID == "" {
+		err = keyNotFound(roleKey)
+		return
+	}
+	return
+}
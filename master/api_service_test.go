@@ -332,6 +332,31 @@ func TestDisk(t *testing.T) {
 	decommissionDisk(addr, disk, t)
 }
 
+func TestDiskEvacuationHold(t *testing.T) {
+	addr := mds5Addr
+	disk := "/cfs"
+	process(fmt.Sprintf("%v%v?addr=%v&disk=%v", hostAddr, proto.HoldDiskEvacuation, addr, disk), t)
+	if !server.cluster.diskEvacuationMgr.isHeld(addr, disk) {
+		t.Errorf("disk[%v] of node[%v] should be held", disk, addr)
+		return
+	}
+	reply := process(fmt.Sprintf("%v%v", hostAddr, proto.QueryDiskEvacuation), t)
+	data, _ := json.Marshal(reply.Data)
+	view := &proto.DiskEvacuationsView{}
+	if err := json.Unmarshal(data, view); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(view.Holds) != 1 || view.Holds[0] != diskEvacuationKey(addr, disk) {
+		t.Errorf("unexpected holds %v", view.Holds)
+		return
+	}
+	process(fmt.Sprintf("%v%v?addr=%v&disk=%v&hold=false", hostAddr, proto.HoldDiskEvacuation, addr, disk), t)
+	if server.cluster.diskEvacuationMgr.isHeld(addr, disk) {
+		t.Errorf("disk[%v] of node[%v] should be released", disk, addr)
+	}
+}
+
 func decommissionDisk(addr, path string, t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?addr=%v&disk=%v",
 		hostAddr, proto.DecommissionDisk, addr, path)
@@ -572,6 +597,42 @@ func TestCreateMetaPartition(t *testing.T) {
 	createMetaPartition(commonVol, t)
 }
 
+func TestSplitMetaPartition(t *testing.T) {
+	var partition *MetaPartition
+	for _, mp := range commonVol.cloneMetaPartitionMap() {
+		if partition == nil || mp.Start < partition.Start {
+			partition = mp
+		}
+	}
+	if partition == nil {
+		t.Error("no meta partition")
+		return
+	}
+	tailID := commonVol.tailPartitionID()
+	start, end := partition.Start, partition.End
+	splitStart := start + (end-start)/2
+	reqURL := fmt.Sprintf("%v%v?name=%v&id=%v&start=%v",
+		hostAddr, proto.AdminSplitMetaPartition, commonVolName, partition.PartitionID, splitStart)
+	process(reqURL, t)
+	if partition.End != splitStart-1 {
+		t.Errorf("partition[%v] end[%v] should be [%v]", partition.PartitionID, partition.End, splitStart-1)
+		return
+	}
+	var nextMp *MetaPartition
+	for _, mp := range commonVol.cloneMetaPartitionMap() {
+		if mp.Start == splitStart {
+			nextMp = mp
+		}
+	}
+	if nextMp == nil || nextMp.End != end {
+		t.Errorf("no partition for range[%v,%v] split from partition[%v]", splitStart, end, partition.PartitionID)
+		return
+	}
+	if partition.PartitionID != tailID && commonVol.tailPartitionID() != tailID {
+		t.Errorf("tail partition[%v] should be [%v]", commonVol.tailPartitionID(), tailID)
+	}
+}
+
 func TestCreateDataPartition(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?count=2&name=%v&type=extent",
 		hostAddr, proto.AdminCreateDataPartition, commonVol.Name)
@@ -689,8 +750,8 @@ func TestRemoveDataReplica(t *testing.T) {
 }
 
 func TestAddMetaReplica(t *testing.T) {
-	maxPartitionID := commonVol.maxPartitionID()
-	partition := commonVol.MetaPartitions[maxPartitionID]
+	tailPartitionID := commonVol.tailPartitionID()
+	partition := commonVol.MetaPartitions[tailPartitionID]
 	if partition == nil {
 		t.Error("no meta partition")
 		return
@@ -711,8 +772,8 @@ func TestAddMetaReplica(t *testing.T) {
 }
 
 func TestRemoveMetaReplica(t *testing.T) {
-	maxPartitionID := commonVol.maxPartitionID()
-	partition := commonVol.MetaPartitions[maxPartitionID]
+	tailPartitionID := commonVol.tailPartitionID()
+	partition := commonVol.MetaPartitions[tailPartitionID]
 	if partition == nil {
 		t.Error("no meta partition")
 		return
@@ -945,6 +1006,125 @@ func TestDeleteVolPolicy(t *testing.T) {
 	}
 }
 
+func TestUserSession(t *testing.T) {
+	session, err := server.user.createSession(&proto.UserSessionCreateParam{UserID: testUserID, Policy: "{}"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	userInfo, err := server.user.getKeyInfo(session.AccessKey)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if userInfo.UserID != testUserID || userInfo.SecretKey != session.SecretKey || userInfo.Session == nil {
+		t.Errorf("unexpected user info of session: %v", userInfo)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?user=%v", hostAddr, proto.UserListSessions, testUserID)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?ak=%v", hostAddr, proto.UserRevokeSession, session.AccessKey)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	if _, err = server.user.getKeyInfo(session.AccessKey); err != proto.ErrAccessKeyNotExists {
+		t.Errorf("expect revoked session, err[%v]", err)
+	}
+}
+
+func TestUserAccessKey(t *testing.T) {
+	key, err := server.user.addAccessKey(&proto.UserKeyCreateParam{UserID: testUserID, Name: "rotation"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	userInfo, err := server.user.getKeyInfo(key.AccessKey)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if userInfo.UserID != testUserID || userInfo.SecretKey != key.SecretKey {
+		t.Errorf("unexpected user info of access key: %v", userInfo)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?user=%v", hostAddr, proto.UserListKeys, testUserID)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?user=%v&ak=%v&status=%v", hostAddr, proto.UserSetKeyStatus, testUserID, key.AccessKey,
+		proto.AccessKeyStatusInactive)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	if _, err = server.user.getKeyInfo(key.AccessKey); err != proto.ErrAccessKeyNotExists {
+		t.Errorf("expect inactive access key, err[%v]", err)
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?user=%v&ak=%v", hostAddr, proto.UserDeleteKey, testUserID, key.AccessKey)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	if _, err = server.user.getAKUser(key.AccessKey); err != proto.ErrAccessKeyNotExists {
+		t.Errorf("expect deleted access key, err[%v]", err)
+	}
+}
+
+func TestUserGroup(t *testing.T) {
+	if _, err := server.user.createGroup(&proto.GroupCreateParam{ID: "testgroup"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.user.createRole(&proto.GroupCreateParam{ID: "testrole"}); err != nil {
+		t.Error(err)
+		return
+	}
+	_, err := server.user.updateRoleVolPolicy(&proto.GroupPermUpdateParam{ID: "testrole", Volume: commonVolName,
+		Policy: []string{proto.BuiltinPermissionReadOnly.String()}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?group=%v&user=%v", hostAddr, proto.GroupAddMember, "testgroup", testUserID)
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?group=%v&role=%v", hostAddr, proto.GroupGrantRole, "testgroup", "testrole")
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	owner, err := server.user.getUserInfo(testUserID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	userInfo, err := server.user.getKeyInfo(owner.AccessKey)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(userInfo.Groups) != 1 || userInfo.InheritedPolicy == nil ||
+		!userInfo.InheritedPolicy.IsAuthorized(commonVolName, "", proto.OSSGetObjectAction) {
+		t.Errorf("expect permission inherited from group, user info: %v", userInfo)
+		return
+	}
+	if err = server.user.deleteRole("testrole"); err == nil {
+		t.Errorf("expect failure to delete the granted role")
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?group=%v&role=%v", hostAddr, proto.GroupRevokeRole, "testgroup", "testrole")
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	if userInfo, err = server.user.getKeyInfo(owner.AccessKey); err != nil {
+		t.Error(err)
+		return
+	}
+	if userInfo.InheritedPolicy.IsAuthorized(commonVolName, "", proto.OSSGetObjectAction) {
+		t.Errorf("expect permission revoked with the role")
+		return
+	}
+	reqURL = fmt.Sprintf("%v%v?role=%v", hostAddr, proto.RoleDelete, "testrole")
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v?group=%v", hostAddr, proto.GroupDelete, "testgroup")
+	fmt.Println(reqURL)
+	process(reqURL, t)
+}
+
 func TestListUser(t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?keywords=%v", hostAddr, proto.UserList, "test")
 	fmt.Println(reqURL)
@@ -116,7 +116,7 @@ func (m *Server) getUserInfo(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
-	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
+	sendOkReply(w, r, newSuccessHTTPReply(m.user.withInheritedPolicy(userInfo)))
 }
 
 func (m *Server) updateUserPolicy(w http.ResponseWriter, r *http.Request) {
@@ -259,6 +259,188 @@ func (m *Server) getUsersOfVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(users))
 }
 
+func (m *Server) createUserSession(w http.ResponseWriter, r *http.Request) {
+	var (
+		session *proto.UserSession
+		err     error
+	)
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	var param = proto.UserSessionCreateParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if param.Duration != 0 && (param.Duration < proto.MinSessionDuration || param.Duration > proto.MaxSessionDuration) {
+		err = fmt.Errorf("session duration %v out of range [%v,%v]", param.Duration, proto.MinSessionDuration, proto.MaxSessionDuration)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if session, err = m.user.createSession(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(session))
+}
+
+// revokeUserSession revokes the session of the access key, or all the sessions of the user.
+func (m *Server) revokeUserSession(w http.ResponseWriter, r *http.Request) {
+	var (
+		count int
+		err   error
+	)
+	if err = r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	ak, userID := r.FormValue(akKey), r.FormValue(userKey)
+	switch {
+	case ak != "":
+		if err = m.user.revokeSession(ak); err == nil {
+			count = 1
+		}
+	case userID != "":
+		count, err = m.user.revokeUserSessions(userID, false)
+	default:
+		err = keyNotFound(akKey)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("revoke %v sessions of ak[%v] user[%v] successfully", count, ak, userID)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) listUserSessions(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.user.listSessions(r.FormValue(userKey))))
+}
+
+func (m *Server) addUserKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		key *proto.UserAccessKey
+		err error
+	)
+	var bytes []byte
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	var param = proto.UserKeyCreateParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if key, err = m.user.addAccessKey(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	_ = sendOkReply(w, r, newSuccessHTTPReply(key))
+}
+
+func (m *Server) deleteUserKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID string
+		ak     string
+		err    error
+	)
+	if userID, ak, err = parseUserAndAccessKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.user.deleteAccessKey(userID, ak); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("delete access key[%v] of user[%v] successfully", ak, userID)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) setUserKeyStatus(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID string
+		ak     string
+		err    error
+	)
+	if userID, ak, err = parseUserAndAccessKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	status := r.FormValue(keyStatusKey)
+	if status != proto.AccessKeyStatusActive && status != proto.AccessKeyStatusInactive {
+		err = fmt.Errorf("invalid access key status %v", status)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.user.setAccessKeyStatus(userID, ak, status); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("set access key[%v] of user[%v] to %v successfully", ak, userID, status)
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+func (m *Server) listUserKeys(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID string
+		keys   []*proto.UserAccessKey
+		err    error
+	)
+	if userID, err = parseUser(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if keys, err = m.user.listAccessKeys(userID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(keys))
+}
+
+// reportUserKeysUsed receives the last used time of the access keys from the object nodes.
+func (m *Server) reportUserKeysUsed(w http.ResponseWriter, r *http.Request) {
+	var (
+		bytes []byte
+		err   error
+	)
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	var used = make(map[string]int64)
+	if err = json.Unmarshal(bytes, &used); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.user.reportAccessKeysUsed(used); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(""))
+}
+
+func parseUserAndAccessKey(r *http.Request) (userID, ak string, err error) {
+	if userID, err = parseUser(r); err != nil {
+		return
+	}
+	if ak, err = extractAccessKey(r); err != nil {
+		return
+	}
+	return
+}
+
 func parseUser(r *http.Request) (userID string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
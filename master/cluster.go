@@ -59,11 +59,12 @@ type Cluster struct {
 	needFaultDomain     bool // FaultDomain is true and normal zone aleady used up
 	fsm                 *MetadataFsm
 	partition           raftstore.Partition
-	MasterSecretKey     []byte
+	MasterSecretKeys    [][]byte // the current key first, followed by the previous ones being rotated
 	lastZoneIdxForNode  int
 	zoneIdxMux          sync.Mutex //
 	zoneList            []string
 	followerReadManager *followerReadManager
+	diskEvacuationMgr   *diskEvacuationManager
 	diskQosEnable       bool
 	QosAcceptLimit      *rate.Limiter
 }
@@ -141,6 +142,7 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.FaultDomain = cfg.faultDomain
 	c.zoneStatInfos = make(map[string]*proto.ZoneStat)
 	c.followerReadManager = newFollowerReadManager()
+	c.diskEvacuationMgr = newDiskEvacuationManager()
 	c.fsm = fsm
 	c.partition = partition
 	c.idAlloc = newIDAllocator(c.fsm.store, c.partition)
@@ -160,6 +162,8 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckVolStatus()
 	c.scheduleToCheckVolQos()
 	c.scheduleToCheckDiskRecoveryProgress()
+	c.scheduleToEvacuateBadDisks()
+	c.scheduleToSplitMetaPartitions()
 	c.scheduleToCheckMetaPartitionRecoveryProgress()
 	c.scheduleToLoadMetaPartitions()
 	c.scheduleToReduceReplicaNum()
@@ -620,7 +624,7 @@ func (c *Cluster) addMetaNode(nodeAddr, zoneName string, nodesetId uint64) (id u
 		return metaNode.ID, nil
 	}
 
-	metaNode = newMetaNode(nodeAddr, zoneName, c.Name)
+	metaNode = newMetaNode(nodeAddr, zoneName, c.Name, c.cfg.metaNodeHandshake())
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
 		zone = c.t.putZoneIfAbsent(newZone(zoneName))
@@ -680,7 +684,7 @@ func (c *Cluster) addDataNode(nodeAddr, zoneName string, nodesetId uint64) (id u
 		return dataNode.ID, nil
 	}
 
-	dataNode = newDataNode(nodeAddr, zoneName, c.Name)
+	dataNode = newDataNode(nodeAddr, zoneName, c.Name, c.cfg.dataNodeHandshake())
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
 		zone = c.t.putZoneIfAbsent(newZone(zoneName))
@@ -782,6 +786,20 @@ func (c *Cluster) checkLackReplicaDataPartitions() (lackReplicaDataPartitions []
 	return
 }
 
+func (c *Cluster) checkScrubCorruptDataPartitions() (corruptPartitions []*DataPartition) {
+	corruptPartitions = make([]*DataPartition, 0)
+	vols := c.copyVols()
+	for _, vol := range vols {
+		for _, dp := range vol.dataPartitions.clonePartitions() {
+			if dp.hasUnrepairedScrubCorruption() {
+				corruptPartitions = append(corruptPartitions, dp)
+			}
+		}
+	}
+	log.LogInfof("clusterID[%v] scrubCorruptDataPartitions count:[%v]", c.Name, len(corruptPartitions))
+	return
+}
+
 func (c *Cluster) getDataPartitionByID(partitionID uint64) (dp *DataPartition, err error) {
 	vols := c.copyVols()
 
@@ -2529,6 +2547,7 @@ func (c *Cluster) doCreateVol(req *createVolReq) (vol *Vol, err error) {
 		CreateTime:        createTime,
 		Description:       req.description,
 		EnablePosixAcl:    req.enablePosixAcl,
+		InlineSizeLimit:   req.inlineSize,
 
 		VolType:          req.volType,
 		EbsBlkSize:       req.coldArgs.objBlockSize,
@@ -2585,9 +2604,9 @@ errHandler:
 func (c *Cluster) updateInodeIDRange(volName string, start uint64) (err error) {
 
 	var (
-		maxPartitionID uint64
-		vol            *Vol
-		partition      *MetaPartition
+		tailPartitionID uint64
+		vol             *Vol
+		partition       *MetaPartition
 	)
 
 	if vol, err = c.getVol(volName); err != nil {
@@ -2595,9 +2614,9 @@ func (c *Cluster) updateInodeIDRange(volName string, start uint64) (err error) {
 		return proto.ErrVolNotExists
 	}
 
-	maxPartitionID = vol.maxPartitionID()
-	if partition, err = vol.metaPartition(maxPartitionID); err != nil {
-		log.LogErrorf("action[updateInodeIDRange]  mp[%v] not found", maxPartitionID)
+	tailPartitionID = vol.tailPartitionID()
+	if partition, err = vol.metaPartition(tailPartitionID); err != nil {
+		log.LogErrorf("action[updateInodeIDRange]  mp[%v] not found", tailPartitionID)
 		return proto.ErrMetaPartitionNotExists
 	}
 
@@ -2611,7 +2630,7 @@ func (c *Cluster) updateInodeIDRange(volName string, start uint64) (err error) {
 	}
 
 	adjustStart = adjustStart + defaultMetaPartitionInodeIDStep
-	log.LogWarnf("vol[%v],maxMp[%v],start[%v],adjustStart[%v]", volName, maxPartitionID, start, adjustStart)
+	log.LogWarnf("vol[%v],tailMp[%v],start[%v],adjustStart[%v]", volName, tailPartitionID, start, adjustStart)
 	if err = vol.splitMetaPartition(c, partition, adjustStart); err != nil {
 		log.LogErrorf("action[updateInodeIDRange]  mp[%v] err[%v]", partition.PartitionID, err)
 	}
@@ -2654,6 +2673,23 @@ func (c *Cluster) allMetaNodes() (metaNodes []proto.NodeView) {
 	return
 }
 
+// checkInlineDataSupported checks that all the meta nodes decode the inodes carrying inline data,
+// which are not known by the meta nodes before the upgrade.
+func (c *Cluster) checkInlineDataSupported() (err error) {
+	c.metaNodes.Range(func(addr, node interface{}) bool {
+		metaNode := node.(*MetaNode)
+		metaNode.RLock()
+		supported := metaNode.SupportInlineData
+		metaNode.RUnlock()
+		if !supported {
+			err = fmt.Errorf("metanode(%v) does not support inline data, upgrade all the metanodes first", metaNode.Addr)
+			return false
+		}
+		return true
+	})
+	return
+}
+
 func (c *Cluster) allVolNames() (vols []string) {
 	vols = make([]string, 0)
 	c.volMutex.RLock()
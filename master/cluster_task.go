@@ -1042,8 +1042,8 @@ func (c *Cluster) updateInodeIDUpperBound(mp *MetaPartition, mr *proto.MetaParti
 		log.LogWarnf("action[updateInodeIDRange] vol[%v] not found", mp.volName)
 		return
 	}
-	maxPartitionID := vol.maxPartitionID()
-	if mr.PartitionID < maxPartitionID {
+	tailPartitionID := vol.tailPartitionID()
+	if mr.PartitionID != tailPartitionID {
 		return
 	}
 	var end uint64
@@ -277,9 +277,9 @@ func TestUpdateInodeIDUpperBound(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	maxPartitionID := vol.maxPartitionID()
+	tailPartitionID := vol.tailPartitionID()
 	vol.volLock.RLock()
-	mp := vol.MetaPartitions[maxPartitionID]
+	mp := vol.MetaPartitions[tailPartitionID]
 	mpLen := len(vol.MetaPartitions)
 	vol.volLock.RUnlock()
 	mr := &proto.MetaPartitionReport{
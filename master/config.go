@@ -21,7 +21,10 @@ import (
 	"strings"
 
 	"github.com/cubefs/cubefs/depends/tiglabs/raft/proto"
+	cfsProto "github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/raftstore"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/packetauth"
 )
 
 //config key
@@ -41,6 +44,7 @@ const (
 	faultDomain                         = "faultDomain"
 	cfgDomainBatchGrpCnt                = "faultDomainGrpBatchCnt"
 	cfgDomainBuildAsPossible            = "faultDomainBuildAsPossible"
+	cfgDiskEvacuateLimit                = "diskEvacuateLimit"
 )
 
 //default value
@@ -74,6 +78,12 @@ const (
 	defaultDiffSpaceUsage                              = 1024 * 1024 * 1024
 	defaultNodeSetGrpStep                              = 1
 	defaultMasterMinQosAccept                          = 20000
+	defaultDiskEvacuateLimit                           = 5 // partitions migrating at the same time on a node
+	defaultIntervalToEvacuateDisk                      = 60
+	defaultIntervalToSplitMetaPartition                = 60
+	defaultSplitMetaPartitionRetry                     = 5
+	defaultIntervalToRetrySplitMetaPartition           = 5
+	defaultSplitMetaPartitionSeedRetry                 = 120 // checks of the seed of the new partition
 )
 
 // AddrDatabase is a map that stores the address of a given host (e.g., the leader)
@@ -109,6 +119,19 @@ type clusterConfig struct {
 	DomainBuildAsPossible               bool
 	DataPartitionUsageThreshold         float64
 	QosMasterAcceptLimit                uint64
+	DiskEvacuateLimit                   int    // max partitions migrating off the bad disks on a node at the same time
+	MetaPartitionSplitMemMB             uint64 // split a meta partition using more memory on a replica, 0 means no limit
+
+	dataCredential *packetauth.Credential // presents the master tickets to the datanodes, nil if not configured
+	metaCredential *packetauth.Credential // presents the master tickets to the metanodes, nil if not configured
+}
+
+func (cfg *clusterConfig) dataNodeHandshake() util.Handshake {
+	return cfg.dataCredential.Handshake(cfsProto.DataServiceID)
+}
+
+func (cfg *clusterConfig) metaNodeHandshake() util.Handshake {
+	return cfg.metaCredential.Handshake(cfsProto.MetaServiceID)
 }
 
 func newClusterConfig() (cfg *clusterConfig) {
@@ -128,6 +151,7 @@ func newClusterConfig() (cfg *clusterConfig) {
 	cfg.metaNodeReservedMem = defaultMetaNodeReservedMem
 	cfg.diffSpaceUsage = defaultDiffSpaceUsage
 	cfg.QosMasterAcceptLimit = defaultMasterMinQosAccept
+	cfg.DiskEvacuateLimit = defaultDiskEvacuateLimit
 	return
 }
 
@@ -60,6 +60,9 @@ const (
 	crossZoneKey            = "crossZone"
 	normalZonesFirstKey     = "normalZonesFirst"
 	userKey                 = "user"
+	keyStatusKey            = "status"
+	groupKey                = "group"
+	roleKey                 = "role"
 	nodeHostsKey            = "hosts"
 	nodeDeleteBatchCountKey = "batchCount"
 	nodeMarkDeleteRateKey   = "markDeleteRate"
@@ -67,8 +70,10 @@ const (
 	nodeAutoRepairRateKey   = "autoRepairRate"
 	clusterLoadFactorKey    = "loadFactor"
 	maxDpCntLimitKey        = "maxDpCntLimit"
+	mpSplitMemKey           = "mpSplitMemMB"
 	descriptionKey          = "description"
 	dpSelectorNameKey       = "dpSelectorName"
+	inlineSizeLimitKey      = "inlineSizeLimit"
 	dpSelectorParmKey       = "dpSelectorParm"
 	nodeTypeKey             = "nodeType"
 	ratio                   = "ratio"
@@ -77,6 +82,7 @@ const (
 	targetAddrKey           = "targetAddr"
 	forceKey                = "force"
 	raftForceDelKey         = "raftForceDel"
+	holdKey                 = "hold"
 	enablePosixAclKey       = "enablePosixAcl"
 	QosEnableKey            = "qosEnable"
 	DiskEnableKey           = "diskenable"
@@ -206,6 +212,15 @@ const (
 	opSyncExclueDomain         uint32 = 0x23
 	opSyncUpdateZone           uint32 = 0x24
 	opSyncAllocClientID        uint32 = 0x25
+	opSyncAddUserSession       uint32 = 0x26
+	opSyncDeleteUserSession    uint32 = 0x27
+	opSyncAddUserGroup         uint32 = 0x28
+	opSyncDeleteUserGroup      uint32 = 0x29
+	opSyncUpdateUserGroup      uint32 = 0x2A
+	opSyncAddUserRole          uint32 = 0x2B
+	opSyncDeleteUserRole       uint32 = 0x2C
+	opSyncUpdateUserRole       uint32 = 0x2D
+	opSyncUpdateAKUser         uint32 = 0x2E
 )
 
 const (
@@ -239,9 +254,15 @@ const (
 	userAcronym           = "user"
 	volUserAcronym        = "voluser"
 	volNameAcronym        = "volname"
+	sessionAcronym        = "session"
+	groupAcronym          = "group"
+	roleAcronym           = "role"
 	akPrefix              = keySeparator + akAcronym + keySeparator
 	userPrefix            = keySeparator + userAcronym + keySeparator
 	volUserPrefix         = keySeparator + volUserAcronym + keySeparator
+	sessionPrefix         = keySeparator + sessionAcronym + keySeparator
+	groupPrefix           = keySeparator + groupAcronym + keySeparator
+	rolePrefix            = keySeparator + roleAcronym + keySeparator
 	volWarnUsedRatio      = 0.9
 	volCachePrefix        = keySeparator + volNameAcronym + keySeparator
 )
@@ -57,14 +57,14 @@ type DataNode struct {
 	QosFlowWLimit             uint64
 }
 
-func newDataNode(addr, zoneName, clusterID string) (dataNode *DataNode) {
+func newDataNode(addr, zoneName, clusterID string, handshake util.Handshake) (dataNode *DataNode) {
 	dataNode = new(DataNode)
 	dataNode.Carry = rand.Float64()
 	dataNode.Total = 1
 	dataNode.Addr = addr
 	dataNode.ZoneName = zoneName
 	dataNode.LastUpdateTime = time.Now().Add(-time.Minute)
-	dataNode.TaskManager = newAdminTaskManager(dataNode.Addr, clusterID)
+	dataNode.TaskManager = newAdminTaskManager(dataNode.Addr, clusterID, handshake)
 	return
 }
 
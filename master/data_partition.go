@@ -373,6 +373,8 @@ func (partition *DataPartition) convertToDataPartitionResponse() (dpr *proto.Dat
 	copy(dpr.Hosts, partition.Hosts)
 	dpr.LeaderAddr = partition.getLeaderAddr()
 	dpr.IsRecover = partition.isRecover
+	dpr.Total = partition.total
+	dpr.Used = partition.used
 
 	return
 }
@@ -532,6 +534,20 @@ func (partition *DataPartition) liveReplicas(timeOutSec int64) (replicas []*Data
 	return
 }
 
+// lostQuorumWithout returns true if the live replicas except the one on addr are not the majority,
+// the replica on addr can only be removed from the raft group by force then.
+func (partition *DataPartition) lostQuorumWithout(addr string) bool {
+	partition.RLock()
+	defer partition.RUnlock()
+	live := 0
+	for _, replica := range partition.liveReplicas(defaultDataPartitionTimeOutSec) {
+		if replica.Addr != addr {
+			live++
+		}
+	}
+	return live < int(partition.ReplicaNum)/2+1
+}
+
 // get all the live replicas from the persistent hosts
 func (partition *DataPartition) getLiveReplicasFromHosts(timeOutSec int64) (replicas []*DataReplica) {
 	replicas = make([]*DataReplica, 0)
@@ -644,6 +660,7 @@ func (partition *DataPartition) updateMetric(vr *proto.PartitionReport, dataNode
 	replica.setAlive()
 	replica.IsLeader = vr.IsLeader
 	replica.NeedsToCompare = vr.NeedCompare
+	replica.Scrub = vr.Scrub
 	if replica.DiskPath != vr.DiskPath && vr.DiskPath != "" {
 		oldDiskPath := replica.DiskPath
 		replica.DiskPath = vr.DiskPath
@@ -848,3 +865,15 @@ func (partition *DataPartition) buildDpInfo(c *Cluster) *proto.DataPartitionInfo
 		SingleDecommissionAddr:   partition.SingleDecommissionAddr,
 	}
 }
+
+// hasUnrepairedScrubCorruption returns true if any replica has corrupt blocks found by scrubbing that could not be repaired.
+func (partition *DataPartition) hasUnrepairedScrubCorruption() bool {
+	partition.RLock()
+	defer partition.RUnlock()
+	for _, replica := range partition.Replicas {
+		if replica.Scrub.Unrepaired() > 0 {
+			return true
+		}
+	}
+	return false
+}
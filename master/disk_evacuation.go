@@ -0,0 +1,297 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// diskEvacuation migrates all the data partitions off a disk reported as bad by the data node.
+type diskEvacuation struct {
+	addr       string
+	diskPath   string
+	status     string
+	remaining  int
+	migrated   int
+	running    map[uint64]bool
+	failed     map[uint64]string
+	createTime int64
+	updateTime int64
+}
+
+type diskEvacuationManager struct {
+	sync.RWMutex
+	jobs  map[string]*diskEvacuation
+	holds map[string]bool
+}
+
+func newDiskEvacuationManager() *diskEvacuationManager {
+	return &diskEvacuationManager{
+		jobs:  make(map[string]*diskEvacuation),
+		holds: make(map[string]bool),
+	}
+}
+
+func diskEvacuationKey(addr, diskPath string) string {
+	if diskPath == "" {
+		return addr
+	}
+	return addr + colonSplit + diskPath
+}
+
+func (mgr *diskEvacuationManager) isHeld(addr, diskPath string) bool {
+	mgr.RLock()
+	defer mgr.RUnlock()
+	return mgr.holds[addr] || mgr.holds[diskEvacuationKey(addr, diskPath)]
+}
+
+// setHold holds or releases the evacuation of a disk, or of all the disks on the node if diskPath is empty.
+func (mgr *diskEvacuationManager) setHold(addr, diskPath string, hold bool) (old bool) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	old = mgr.holds[diskEvacuationKey(addr, diskPath)]
+	if hold {
+		mgr.holds[diskEvacuationKey(addr, diskPath)] = true
+	} else {
+		delete(mgr.holds, diskEvacuationKey(addr, diskPath))
+	}
+	return
+}
+
+func (mgr *diskEvacuationManager) holdList() (holds []string) {
+	mgr.RLock()
+	defer mgr.RUnlock()
+	holds = make([]string, 0, len(mgr.holds))
+	for key := range mgr.holds {
+		holds = append(holds, key)
+	}
+	sort.Strings(holds)
+	return
+}
+
+func (mgr *diskEvacuationManager) loadHolds(holds []string) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	mgr.holds = make(map[string]bool, len(holds))
+	for _, key := range holds {
+		mgr.holds[key] = true
+	}
+}
+
+func (mgr *diskEvacuationManager) getOrCreateJob(addr, diskPath string) (job *diskEvacuation) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	key := diskEvacuationKey(addr, diskPath)
+	if job = mgr.jobs[key]; job == nil {
+		job = &diskEvacuation{
+			addr:       addr,
+			diskPath:   diskPath,
+			status:     proto.DiskEvacuationRunning,
+			running:    make(map[uint64]bool),
+			failed:     make(map[uint64]string),
+			createTime: time.Now().Unix(),
+		}
+		mgr.jobs[key] = job
+	}
+	return
+}
+
+func (mgr *diskEvacuationManager) jobList() (jobs []*diskEvacuation) {
+	mgr.RLock()
+	defer mgr.RUnlock()
+	jobs = make([]*diskEvacuation, 0, len(mgr.jobs))
+	for _, job := range mgr.jobs {
+		jobs = append(jobs, job)
+	}
+	return
+}
+
+func (mgr *diskEvacuationManager) runningOnNode(addr string) (count int) {
+	mgr.RLock()
+	defer mgr.RUnlock()
+	for _, job := range mgr.jobs {
+		if job.addr == addr {
+			count += len(job.running)
+		}
+	}
+	return
+}
+
+func (mgr *diskEvacuationManager) updateJob(job *diskEvacuation, update func(job *diskEvacuation)) {
+	mgr.Lock()
+	defer mgr.Unlock()
+	update(job)
+	job.updateTime = time.Now().Unix()
+}
+
+func (mgr *diskEvacuationManager) getView(limit int) (view *proto.DiskEvacuationsView) {
+	view = &proto.DiskEvacuationsView{
+		Limit: limit,
+		Holds: mgr.holdList(),
+		Jobs:  make([]*proto.DiskEvacuationView, 0),
+	}
+	mgr.RLock()
+	defer mgr.RUnlock()
+	for _, job := range mgr.jobs {
+		jv := &proto.DiskEvacuationView{
+			Addr:       job.addr,
+			DiskPath:   job.diskPath,
+			Status:     job.status,
+			Remaining:  job.remaining,
+			Migrated:   job.migrated,
+			Running:    make([]uint64, 0, len(job.running)),
+			Failed:     make(map[uint64]string, len(job.failed)),
+			CreateTime: job.createTime,
+			UpdateTime: job.updateTime,
+		}
+		for id := range job.running {
+			jv.Running = append(jv.Running, id)
+		}
+		for id, msg := range job.failed {
+			jv.Failed[id] = msg
+		}
+		view.Jobs = append(view.Jobs, jv)
+	}
+	sort.Slice(view.Jobs, func(i, j int) bool {
+		return view.Jobs[i].Addr+view.Jobs[i].DiskPath < view.Jobs[j].Addr+view.Jobs[j].DiskPath
+	})
+	return
+}
+
+func (c *Cluster) scheduleToEvacuateBadDisks() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				if c.vols != nil {
+					c.evacuateBadDisks()
+				}
+			}
+			time.Sleep(time.Second * defaultIntervalToEvacuateDisk)
+		}
+	}()
+}
+
+// evacuateBadDisks creates an evacuation job for every bad disk reported in the data node
+// heartbeats and migrates the data partitions on the disks which are not held by the operator.
+func (c *Cluster) evacuateBadDisks() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("evacuateBadDisks occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"evacuateBadDisks occurred panic")
+		}
+	}()
+
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		badDisks := make([]string, len(dataNode.BadDisks))
+		copy(badDisks, dataNode.BadDisks)
+		dataNode.RUnlock()
+		for _, diskPath := range badDisks {
+			c.diskEvacuationMgr.getOrCreateJob(dataNode.Addr, diskPath)
+		}
+		return true
+	})
+
+	for _, job := range c.diskEvacuationMgr.jobList() {
+		c.evacuateDisk(job)
+	}
+}
+
+func (c *Cluster) evacuateDisk(job *diskEvacuation) {
+	mgr := c.diskEvacuationMgr
+	dataNode, err := c.dataNode(job.addr)
+	if err != nil {
+		log.LogWarnf("action[evacuateDisk] node[%v] disk[%v] err[%v]", job.addr, job.diskPath, err)
+		return
+	}
+
+	partitions := dataNode.badPartitions(job.diskPath, c)
+	held := mgr.isHeld(job.addr, job.diskPath)
+	finished := false
+	mgr.updateJob(job, func(job *diskEvacuation) {
+		job.remaining = len(partitions)
+		switch {
+		case held:
+			job.status = proto.DiskEvacuationHeld
+		case len(partitions) == 0 && len(job.running) == 0:
+			finished = job.status != proto.DiskEvacuationFinished
+			job.status = proto.DiskEvacuationFinished
+		default:
+			job.status = proto.DiskEvacuationRunning
+		}
+	})
+	if finished {
+		Warn(c.Name, fmt.Sprintf("clusterID[%v] node[%v] disk[%v] evacuation finished", c.Name, job.addr, job.diskPath))
+	}
+	if held || len(partitions) == 0 {
+		return
+	}
+
+	quota := c.cfg.DiskEvacuateLimit - mgr.runningOnNode(job.addr)
+	for _, dp := range partitions {
+		if quota <= 0 {
+			return
+		}
+		started := false
+		mgr.updateJob(job, func(job *diskEvacuation) {
+			if !job.running[dp.PartitionID] {
+				job.running[dp.PartitionID] = true
+				started = true
+			}
+		})
+		if !started {
+			continue
+		}
+		quota--
+		go c.evacuateDataPartition(job, dp)
+	}
+}
+
+func (c *Cluster) evacuateDataPartition(job *diskEvacuation, dp *DataPartition) {
+	raftForce := dp.lostQuorumWithout(job.addr)
+	log.LogWarnf("action[evacuateDataPartition] node[%v] disk[%v] dp[%v] raftForce[%v]",
+		job.addr, job.diskPath, dp.PartitionID, raftForce)
+	err := c.decommissionDataPartition(job.addr, dp, raftForce, diskOfflineErr)
+	if err == nil && dp.hasHost(job.addr) {
+		err = fmt.Errorf("replica is still on the node")
+	}
+	c.diskEvacuationMgr.updateJob(job, func(job *diskEvacuation) {
+		delete(job.running, dp.PartitionID)
+		if err != nil {
+			job.failed[dp.PartitionID] = err.Error()
+			return
+		}
+		delete(job.failed, dp.PartitionID)
+		job.migrated++
+	})
+}
+
+func (c *Cluster) holdDiskEvacuation(addr, diskPath string, hold bool) (err error) {
+	oldHold := c.diskEvacuationMgr.setHold(addr, diskPath, hold)
+	if err = c.syncPutCluster(); err != nil {
+		c.diskEvacuationMgr.setHold(addr, diskPath, oldHold)
+		return
+	}
+	log.LogWarnf("action[holdDiskEvacuation] node[%v] disk[%v] hold[%v]", addr, diskPath, hold)
+	return
+}
@@ -180,6 +180,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDecommissionMetaPartition).
 		HandlerFunc(m.decommissionMetaPartition)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSplitMetaPartition).
+		HandlerFunc(m.splitMetaPartition)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientMetaPartitions).
 		HandlerFunc(m.getMetaPartitions)
@@ -302,6 +305,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.DecommissionDisk).
 		HandlerFunc(m.decommissionDisk)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.QueryDiskEvacuation).
+		HandlerFunc(m.queryDiskEvacuation)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.HoldDiskEvacuation).
+		HandlerFunc(m.holdDiskEvacuation)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetNodeInfo).
 		HandlerFunc(m.setNodeInfoHandler)
@@ -370,6 +379,80 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.UsersOfVol).
 		HandlerFunc(m.getUsersOfVol)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserCreateSession).
+		HandlerFunc(m.createUserSession)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserRevokeSession).
+		HandlerFunc(m.revokeUserSession)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserListSessions).
+		HandlerFunc(m.listUserSessions)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserAddKey).
+		HandlerFunc(m.addUserKey)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserDeleteKey).
+		HandlerFunc(m.deleteUserKey)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserSetKeyStatus).
+		HandlerFunc(m.setUserKeyStatus)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserListKeys).
+		HandlerFunc(m.listUserKeys)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.UserReportKeysUsed).
+		HandlerFunc(m.reportUserKeysUsed)
+
+	// group and role management APIs
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.GroupCreate).
+		HandlerFunc(m.createGroup)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.GroupDelete).
+		HandlerFunc(m.deleteGroup)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.GroupInfo).
+		HandlerFunc(m.getGroupInfo)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.GroupList).
+		HandlerFunc(m.listGroups)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.GroupAddMember).
+		HandlerFunc(m.addGroupMember)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.GroupRemoveMember).
+		HandlerFunc(m.removeGroupMember)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.GroupUpdatePolicy).
+		HandlerFunc(m.updateGroupPolicy)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.GroupRemovePolicy).
+		HandlerFunc(m.removeGroupPolicy)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.GroupGrantRole).
+		HandlerFunc(m.grantGroupRole)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.GroupRevokeRole).
+		HandlerFunc(m.revokeGroupRole)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.RoleCreate).
+		HandlerFunc(m.createRole)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.RoleDelete).
+		HandlerFunc(m.deleteRole)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.RoleInfo).
+		HandlerFunc(m.getRoleInfo)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.RoleList).
+		HandlerFunc(m.listRoles)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.RoleUpdatePolicy).
+		HandlerFunc(m.updateRolePolicy)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.RoleRemovePolicy).
+		HandlerFunc(m.removeRolePolicy)
 
 	// zone management APIs
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
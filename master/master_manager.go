@@ -177,6 +177,12 @@ func (m *Server) loadMetadata() {
 	if err = m.user.loadVolUsers(); err != nil {
 		panic(err)
 	}
+	if err = m.user.loadSessions(); err != nil {
+		panic(err)
+	}
+	if err = m.user.loadGroups(); err != nil {
+		panic(err)
+	}
 	log.LogInfo("action[loadUserInfo] end")
 
 	log.LogInfo("action[refreshUser] begin")
@@ -195,6 +201,8 @@ func (m *Server) clearMetadata() {
 	m.user.clearUserStore()
 	m.user.clearAKStore()
 	m.user.clearVolUsers()
+	m.user.clearSessions()
+	m.user.clearGroups()
 	m.cluster.t = newTopology()
 }
 
@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
 )
 
 // MetaNode defines the structure of a meta node
@@ -45,13 +46,14 @@ type MetaNode struct {
 	PersistenceMetaPartitions []uint64
 	RdOnly                    bool
 	MigrateLock               sync.RWMutex
+	SupportInlineData         bool
 }
 
-func newMetaNode(addr, zoneName, clusterID string) (node *MetaNode) {
+func newMetaNode(addr, zoneName, clusterID string, handshake util.Handshake) (node *MetaNode) {
 	return &MetaNode{
 		Addr:     addr,
 		ZoneName: zoneName,
-		Sender:   newAdminTaskManager(addr, clusterID),
+		Sender:   newAdminTaskManager(addr, clusterID, handshake),
 		Carry:    rand.Float64(),
 	}
 }
@@ -128,6 +130,7 @@ func (metaNode *MetaNode) updateMetric(resp *proto.MetaNodeHeartbeatResponse, th
 	metaNode.MaxMemAvailWeight = resp.Total - resp.Used
 	metaNode.ZoneName = resp.ZoneName
 	metaNode.Threshold = threshold
+	metaNode.SupportInlineData = resp.SupportInlineData
 }
 
 func (metaNode *MetaNode) reachesThreshold() bool {
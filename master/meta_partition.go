@@ -192,9 +192,10 @@ func (mp *MetaPartition) dataSize() uint64 {
 	return maxSize
 }
 
-func (mp *MetaPartition) checkEnd(c *Cluster, maxPartitionID uint64) {
+func (mp *MetaPartition) checkEnd(c *Cluster, tailPartitionID uint64) {
 
-	if mp.PartitionID < maxPartitionID {
+	// only the tail of the inode range, which may have a smaller id than the partitions split from the middle
+	if mp.PartitionID != tailPartitionID {
 		return
 	}
 	vol, err := c.getVol(mp.volName)
@@ -204,9 +205,9 @@ func (mp *MetaPartition) checkEnd(c *Cluster, maxPartitionID uint64) {
 	}
 	mp.Lock()
 	defer mp.Unlock()
-	curMaxPartitionID := vol.maxPartitionID()
-	if mp.PartitionID != curMaxPartitionID {
-		log.LogWarnf("action[checkEnd] partition[%v] not max partition[%v]", mp.PartitionID, curMaxPartitionID)
+	curTailPartitionID := vol.tailPartitionID()
+	if mp.PartitionID != curTailPartitionID {
+		log.LogWarnf("action[checkEnd] partition[%v] not tail partition[%v]", mp.PartitionID, curTailPartitionID)
 		return
 	}
 	if _, err = mp.getMetaReplicaLeader(); err != nil {
@@ -254,7 +255,7 @@ func (mp *MetaPartition) checkLeader() {
 	return
 }
 
-func (mp *MetaPartition) checkStatus(clusterID string, writeLog bool, replicaNum int, maxPartitionID uint64) (doSplit bool) {
+func (mp *MetaPartition) checkStatus(clusterID string, writeLog bool, replicaNum int, tailPartitionID uint64) (doSplit bool) {
 	mp.Lock()
 	defer mp.Unlock()
 
@@ -283,7 +284,7 @@ func (mp *MetaPartition) checkStatus(clusterID string, writeLog bool, replicaNum
 				continue
 			}
 
-			if mp.PartitionID == maxPartitionID {
+			if mp.PartitionID == tailPartitionID {
 				log.LogInfof("split[checkStatus] need split,id:%v,status:%v,replicaNum:%v,InodeCount:%v", mp.PartitionID, mp.Status, mp.ReplicaNum, mp.InodeCount)
 				doSplit = true
 			} else {
@@ -296,7 +297,7 @@ func (mp *MetaPartition) checkStatus(clusterID string, writeLog bool, replicaNum
 		}
 	}
 
-	if mp.PartitionID >= maxPartitionID && mp.Status == proto.ReadOnly {
+	if mp.PartitionID == tailPartitionID && mp.Status == proto.ReadOnly {
 		mp.Status = proto.ReadWrite
 	}
 
@@ -0,0 +1,375 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// splitMetaPartitionAt splits a meta partition anywhere in the inode range, unlike splitMetaPartition
+// which only cuts the end of the last partition. The new partition for (end, mp.End] is created on the
+// hosts of mp, the end of mp is cut through its raft log, and the leader of the new partition seeds it
+// with the inodes and the dentries whose parent is beyond end through the raft log of the new partition.
+// Once every replica of the new partition has persisted the seed, the new layout is committed and published
+// to the clients, and the items moved out are dropped from mp.
+func (vol *Vol) splitMetaPartitionAt(c *Cluster, mp *MetaPartition, end uint64) (nextMp *MetaPartition, err error) {
+	vol.createMpMutex.Lock()
+	defer vol.createMpMutex.Unlock()
+
+	mp.RLock()
+	start, oldEnd, maxInodeID := mp.Start, mp.End, mp.MaxInodeID
+	hosts := make([]string, len(mp.Hosts))
+	copy(hosts, mp.Hosts)
+	peers := make([]proto.Peer, len(mp.Peers))
+	copy(peers, mp.Peers)
+	_, err = mp.getMetaReplicaLeader()
+	mp.RUnlock()
+	if err != nil {
+		return
+	}
+	if end < start || end >= oldEnd {
+		err = fmt.Errorf("split point[%v] out of the range[%v,%v) of meta partition[%v]", end, start, oldEnd, mp.PartitionID)
+		return
+	}
+
+	var partitionID uint64
+	if partitionID, err = c.idAlloc.allocateMetaPartitionID(); err != nil {
+		return nil, errors.NewError(err)
+	}
+	nextMp = newMetaPartition(partitionID, end+1, oldEnd, vol.mpReplicaNum, vol.Name, vol.ID)
+	nextMp.setHosts(hosts)
+	nextMp.setPeers(peers)
+	if maxInodeID > end {
+		nextMp.MaxInodeID = maxInodeID
+	}
+	log.LogWarnf("action[splitMetaPartitionAt] vol[%v] partition[%v] range[%v,%v] split at[%v] into partition[%v]",
+		vol.Name, mp.PartitionID, start, oldEnd, end, partitionID)
+	if err = c.createSplitMetaPartition(nextMp, mp.PartitionID); err != nil {
+		return nil, err
+	}
+
+	if err = c.syncSplitMetaPartition(mp, nextMp, end, proto.SplitMetaPartitionCut); err == nil {
+		err = c.seedSplitMetaPartition(mp, nextMp, end)
+	}
+	if err != nil {
+		// the split may have been applied by the meta nodes, so the new partition is kept for the retry
+		Warn(c.Name, fmt.Sprintf("action[splitMetaPartitionAt] clusterID[%v] vol[%v] split of partition[%v] at[%v] "+
+			"into partition[%v] is undecided, err[%v]", c.Name, vol.Name, mp.PartitionID, end, partitionID, err))
+		return nil, err
+	}
+
+	mp.Lock()
+	mp.End = end
+	cmdMap := make(map[string]*RaftCmd, 0)
+	updateMpRaftCmd, err := c.buildMetaPartitionRaftCmd(opSyncUpdateMetaPartition, mp)
+	if err == nil {
+		cmdMap[updateMpRaftCmd.K] = updateMpRaftCmd
+		var addMpRaftCmd *RaftCmd
+		if addMpRaftCmd, err = c.buildMetaPartitionRaftCmd(opSyncAddMetaPartition, nextMp); err == nil {
+			cmdMap[addMpRaftCmd.K] = addMpRaftCmd
+			err = c.syncBatchCommitCmd(cmdMap)
+		}
+	}
+	if err != nil {
+		mp.End = oldEnd
+		mp.Unlock()
+		Warn(c.Name, fmt.Sprintf("action[splitMetaPartitionAt] clusterID[%v] vol[%v] partition[%v] has been split at[%v] "+
+			"into partition[%v] on the meta nodes but failed to persist, err[%v]", c.Name, vol.Name, mp.PartitionID, end, partitionID, err))
+		return nil, errors.NewError(err)
+	}
+	mp.updateInodeIDRangeForAllReplicas()
+	mp.Unlock()
+
+	vol.addMetaPartition(nextMp)
+	vol.updateViewCache(c)
+	log.LogWarnf("action[splitMetaPartitionAt] vol[%v] partition[%v] range[%v,%v], next partition[%v] range[%v,%v]",
+		vol.Name, mp.PartitionID, mp.Start, mp.End, nextMp.PartitionID, nextMp.Start, nextMp.End)
+	if dropErr := c.syncSplitMetaPartition(mp, nextMp, end, proto.SplitMetaPartitionDrop); dropErr != nil {
+		// the items beyond end are out of the range of mp, they only waste the memory of the meta nodes
+		Warn(c.Name, fmt.Sprintf("action[splitMetaPartitionAt] clusterID[%v] vol[%v] partition[%v] split at[%v] "+
+			"failed to drop the items moved into partition[%v], err[%v]", c.Name, vol.Name, mp.PartitionID, end, partitionID, dropErr))
+	}
+	return
+}
+
+// createSplitMetaPartition creates the replicas of the new partition which wait for the items of the split partition.
+func (c *Cluster) createSplitMetaPartition(nextMp *MetaPartition, splitFrom uint64) (err error) {
+	var wg sync.WaitGroup
+	errChannel := make(chan error, len(nextMp.Hosts))
+	for _, host := range nextMp.Hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			metaNode, err := c.metaNode(host)
+			if err != nil {
+				errChannel <- err
+				return
+			}
+			if _, err = metaNode.Sender.syncSendAdminTask(nextMp.createTaskToCreateSplitReplica(host, splitFrom)); err != nil {
+				errChannel <- err
+				return
+			}
+			nextMp.Lock()
+			defer nextMp.Unlock()
+			if err = nextMp.afterCreation(host, c); err != nil {
+				errChannel <- err
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	select {
+	case err = <-errChannel:
+		tasks := make([]*proto.AdminTask, 0)
+		for _, mr := range nextMp.Replicas {
+			tasks = append(tasks, mr.createTaskToDeleteReplica(nextMp.PartitionID))
+		}
+		c.addMetaNodeTasks(tasks)
+		return errors.NewError(err)
+	default:
+		nextMp.Status = proto.ReadWrite
+	}
+	return
+}
+
+func (mp *MetaPartition) createTaskToCreateSplitReplica(host string, splitFrom uint64) (t *proto.AdminTask) {
+	req := &proto.CreateMetaPartitionRequest{
+		Start:       mp.Start,
+		End:         mp.End,
+		PartitionID: mp.PartitionID,
+		Members:     mp.Peers,
+		VolName:     mp.volName,
+		SplitFrom:   splitFrom,
+	}
+	t = proto.NewAdminTask(proto.OpCreateMetaPartition, host, req)
+	resetMetaPartitionTaskID(t, mp.PartitionID)
+	return
+}
+
+// syncSplitMetaPartition asks the leader of mp to run a step of the split. The steps are idempotent on the
+// meta node, so a step is retried as long as the leader does not confirm it.
+func (c *Cluster) syncSplitMetaPartition(mp, nextMp *MetaPartition, end uint64, step uint8) (err error) {
+	req := &proto.SplitMetaPartitionRequest{
+		PartitionID:    mp.PartitionID,
+		VolName:        mp.volName,
+		End:            end,
+		NewPartitionID: nextMp.PartitionID,
+		Step:           step,
+	}
+	for i := 0; i < defaultSplitMetaPartitionRetry; i++ {
+		if i > 0 {
+			time.Sleep(time.Second * defaultIntervalToRetrySplitMetaPartition)
+		}
+		var mr *MetaReplica
+		mp.RLock()
+		mr, err = mp.getMetaReplicaLeader()
+		mp.RUnlock()
+		if err != nil {
+			continue
+		}
+		if err = c.syncSendSplitTask(mr.Addr, mp.PartitionID, req); err == nil {
+			return
+		}
+		log.LogWarnf("action[syncSplitMetaPartition] partition[%v] leader[%v] split at[%v] step[%v] err[%v]",
+			mp.PartitionID, mr.Addr, end, step, err)
+	}
+	return
+}
+
+// seedSplitMetaPartition asks the leader of nextMp to seed it, and waits until every replica of nextMp
+// has persisted the seed. A host of nextMp forwards the request to the leader, which seeds in the background,
+// so the request is sent again on every check in case the leader has changed.
+func (c *Cluster) seedSplitMetaPartition(mp, nextMp *MetaPartition, end uint64) (err error) {
+	req := &proto.SplitMetaPartitionRequest{
+		PartitionID:    mp.PartitionID,
+		VolName:        mp.volName,
+		End:            end,
+		NewPartitionID: nextMp.PartitionID,
+		Step:           proto.SplitMetaPartitionSeed,
+	}
+	hosts := nextMp.Hosts
+	for i := 0; i < defaultSplitMetaPartitionSeedRetry; i++ {
+		if i > 0 {
+			time.Sleep(time.Second * defaultIntervalToRetrySplitMetaPartition)
+		}
+		host := hosts[i%len(hosts)]
+		if err = c.syncSendSplitTask(host, nextMp.PartitionID, req); err != nil {
+			log.LogWarnf("action[seedSplitMetaPartition] partition[%v] host[%v] seed from partition[%v] err[%v]",
+				nextMp.PartitionID, host, mp.PartitionID, err)
+			continue
+		}
+		check := *req
+		check.Step = proto.SplitMetaPartitionCheck
+		for _, host = range hosts {
+			if err = c.syncSendSplitTask(host, nextMp.PartitionID, &check); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			return
+		}
+		log.LogWarnf("action[seedSplitMetaPartition] partition[%v] host[%v] check seed from partition[%v] err[%v]",
+			nextMp.PartitionID, host, mp.PartitionID, err)
+	}
+	return
+}
+
+func (c *Cluster) syncSendSplitTask(host string, partitionID uint64, req *proto.SplitMetaPartitionRequest) (err error) {
+	metaNode, err := c.metaNode(host)
+	if err != nil {
+		return
+	}
+	task := proto.NewAdminTask(proto.OpSplitMetaPartition, host, req)
+	resetMetaPartitionTaskID(task, partitionID)
+	_, err = metaNode.Sender.syncSendAdminTask(task)
+	return
+}
+
+// defaultSplitPoint returns the split point in the middle of the inodes allocated by the partition.
+func (mp *MetaPartition) defaultSplitPoint() (end uint64, err error) {
+	mp.RLock()
+	defer mp.RUnlock()
+	last := mp.MaxInodeID
+	if last > mp.End {
+		last = mp.End
+	}
+	if last <= mp.Start+1 {
+		err = fmt.Errorf("meta partition[%v] range[%v,%v] max inode[%v] has nothing to split",
+			mp.PartitionID, mp.Start, mp.End, mp.MaxInodeID)
+		return
+	}
+	end = mp.Start + (last-mp.Start)/2
+	return
+}
+
+func (c *Cluster) splitMetaPartitionByRange(volName string, partitionID, start uint64) (nextMp *MetaPartition, err error) {
+	var (
+		vol *Vol
+		mp  *MetaPartition
+		end uint64
+	)
+	if vol, err = c.getVol(volName); err != nil {
+		return nil, proto.ErrVolNotExists
+	}
+	if mp, err = vol.metaPartition(partitionID); err != nil {
+		return nil, proto.ErrMetaPartitionNotExists
+	}
+	if start == 0 {
+		if end, err = mp.defaultSplitPoint(); err != nil {
+			return
+		}
+	} else {
+		end = start - 1
+	}
+	return vol.splitMetaPartitionAt(c, mp, end)
+}
+
+// partitionMemUsed estimates the memory used by each meta partition on the node,
+// by sharing the memory used by the node in proportion to the inodes and dentries.
+func (metaNode *MetaNode) partitionMemUsed() (used map[uint64]uint64) {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	used = make(map[uint64]uint64, len(metaNode.metaPartitionInfos))
+	var items uint64
+	for _, mpr := range metaNode.metaPartitionInfos {
+		items += mpr.InodeCnt + mpr.DentryCnt
+	}
+	if items == 0 {
+		return
+	}
+	for _, mpr := range metaNode.metaPartitionInfos {
+		used[mpr.PartitionID] = uint64(float64(metaNode.Used) * float64(mpr.InodeCnt+mpr.DentryCnt) / float64(items))
+	}
+	return
+}
+
+func (c *Cluster) scheduleToSplitMetaPartitions() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				if c.vols != nil {
+					c.splitOversizedMetaPartitions()
+				}
+			}
+			time.Sleep(time.Second * defaultIntervalToSplitMetaPartition)
+		}
+	}()
+}
+
+// splitOversizedMetaPartitions splits the meta partitions which use more memory than the limit on any replica.
+func (c *Cluster) splitOversizedMetaPartitions() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("splitOversizedMetaPartitions occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"splitOversizedMetaPartitions occurred panic")
+		}
+	}()
+
+	limit := atomic.LoadUint64(&c.cfg.MetaPartitionSplitMemMB) * util.MB
+	if limit == 0 {
+		return
+	}
+	oversized := make(map[uint64]uint64)
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		for id, used := range value.(*MetaNode).partitionMemUsed() {
+			if used > limit && used > oversized[id] {
+				oversized[id] = used
+			}
+		}
+		return true
+	})
+	if len(oversized) == 0 {
+		return
+	}
+
+	for _, vol := range c.allVols() {
+		for id, mp := range vol.cloneMetaPartitionMap() {
+			used, ok := oversized[id]
+			if !ok {
+				continue
+			}
+			end, err := mp.defaultSplitPoint()
+			if err != nil {
+				log.LogWarnf("action[splitOversizedMetaPartitions] vol[%v] mem used[%v] err[%v]", vol.Name, used, err)
+				continue
+			}
+			if _, err = vol.splitMetaPartitionAt(c, mp, end); err != nil {
+				Warn(c.Name, fmt.Sprintf("action[splitOversizedMetaPartitions] clusterID[%v] vol[%v] partition[%v] "+
+					"mem used[%v] split at[%v] failed, err[%v]", c.Name, vol.Name, id, used, end, err))
+			}
+		}
+	}
+}
+
+func (c *Cluster) setMetaPartitionSplitMemMB(val uint64) (err error) {
+	oldVal := atomic.LoadUint64(&c.cfg.MetaPartitionSplitMemMB)
+	atomic.StoreUint64(&c.cfg.MetaPartitionSplitMemMB, val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setMetaPartitionSplitMemMB] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitMemMB, oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
@@ -18,17 +18,17 @@ func TestMetaPartition(t *testing.T) {
 		return
 	}
 	createMetaPartition(commonVol, t)
-	maxPartitionID := commonVol.maxPartitionID()
-	getMetaPartition(commonVol.Name, maxPartitionID, t)
-	loadMetaPartitionTest(commonVol, maxPartitionID, t)
+	tailPartitionID := commonVol.tailPartitionID()
+	getMetaPartition(commonVol.Name, tailPartitionID, t)
+	loadMetaPartitionTest(commonVol, tailPartitionID, t)
 	server.cluster.checkMetaNodeHeartbeat()
 	time.Sleep(5 * time.Second)
-	decommissionMetaPartition(commonVol, maxPartitionID, t)
+	decommissionMetaPartition(commonVol, tailPartitionID, t)
 }
 
 func createMetaPartition(vol *Vol, t *testing.T) {
-	maxPartitionID := commonVol.maxPartitionID()
-	mp, err := commonVol.metaPartition(maxPartitionID)
+	tailPartitionID := commonVol.tailPartitionID()
+	mp, err := commonVol.metaPartition(tailPartitionID)
 	if err != nil {
 		t.Error(err)
 		return
@@ -52,8 +52,8 @@ func createMetaPartition(vol *Vol, t *testing.T) {
 		return
 	}
 
-	maxPartitionID = vol.maxPartitionID()
-	mp, err = vol.metaPartition(maxPartitionID)
+	tailPartitionID = vol.tailPartitionID()
+	mp, err = vol.metaPartition(tailPartitionID)
 	if err != nil {
 		t.Errorf("createMetaPartition,err [%v]", err)
 		return
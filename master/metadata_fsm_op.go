@@ -45,6 +45,8 @@ type clusterValue struct {
 	FaultDomain                 bool
 	DiskQosEnable               bool
 	QosLimitUpload              uint64
+	DiskEvacuateHolds           []string
+	MetaPartitionSplitMemMB     uint64
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
@@ -61,6 +63,8 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		FaultDomain:                 c.FaultDomain,
 		DiskQosEnable:               c.diskQosEnable,
 		QosLimitUpload:              uint64(c.QosAcceptLimit.Limit()),
+		DiskEvacuateHolds:           c.diskEvacuationMgr.holdList(),
+		MetaPartitionSplitMemMB:     atomic.LoadUint64(&c.cfg.MetaPartitionSplitMemMB),
 	}
 	return cv
 }
@@ -176,6 +180,7 @@ type volValue struct {
 	CacheRule        string
 
 	EnablePosixAcl                                         bool
+	InlineSizeLimit                                        uint64
 	VolQosEnable                                           bool
 	DiskQosEnable                                          bool
 	IopsRLimit, IopsWLimit, FlowRlimit, FlowWlimit         uint64
@@ -211,6 +216,7 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		DpSelectorParm:    vol.dpSelectorParm,
 		DefaultPriority:   vol.defaultPriority,
 		EnablePosixAcl:    vol.enablePosixAcl,
+		InlineSizeLimit:   vol.inlineSizeLimit,
 
 		VolType:             vol.VolType,
 		EbsBlkSize:          vol.EbsBlkSize,
@@ -378,6 +384,12 @@ func (m *RaftCmd) setOpType() {
 		m.Op = opSyncAddAKUser
 	case volUserAcronym:
 		m.Op = opSyncAddVolUser
+	case sessionAcronym:
+		m.Op = opSyncAddUserSession
+	case groupAcronym:
+		m.Op = opSyncAddUserGroup
+	case roleAcronym:
+		m.Op = opSyncAddUserRole
 	default:
 		log.LogWarnf("action[setOpType] unknown opCode[%v]", keyArr[1])
 	}
@@ -691,6 +703,7 @@ func (c *Cluster) loadClusterValue() (err error) {
 		c.cfg.ClusterLoadFactor = cv.LoadFactor
 		c.DisableAutoAllocate = cv.DisableAutoAllocate
 		c.diskQosEnable = cv.DiskQosEnable
+		c.diskEvacuationMgr.loadHolds(cv.DiskEvacuateHolds)
 		c.cfg.QosMasterAcceptLimit = cv.QosLimitUpload
 
 		if c.cfg.QosMasterAcceptLimit < QosMasterAcceptCnt {
@@ -704,6 +717,7 @@ func (c *Cluster) loadClusterValue() (err error) {
 		c.updateDataNodeDeleteLimitRate(cv.DataNodeDeleteLimitRate)
 		c.updateDataNodeAutoRepairLimit(cv.DataNodeAutoRepairLimitRate)
 		c.updateMaxDpCntLimit(cv.MaxDpCntLimit)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitMemMB, cv.MetaPartitionSplitMemMB)
 
 		log.LogInfof("action[loadClusterValue], metaNodeThreshold[%v]", cv.Threshold)
 	}
@@ -906,7 +920,7 @@ func (c *Cluster) loadDataNodes() (err error) {
 		if dnv.ZoneName == "" {
 			dnv.ZoneName = DefaultZoneName
 		}
-		dataNode := newDataNode(dnv.Addr, dnv.ZoneName, c.Name)
+		dataNode := newDataNode(dnv.Addr, dnv.ZoneName, c.Name, c.cfg.dataNodeHandshake())
 		dataNode.ID = dnv.ID
 		dataNode.NodeSetID = dnv.NodeSetID
 		dataNode.RdOnly = dnv.RdOnly
@@ -937,7 +951,7 @@ func (c *Cluster) loadMetaNodes() (err error) {
 		if mnv.ZoneName == "" {
 			mnv.ZoneName = DefaultZoneName
 		}
-		metaNode := newMetaNode(mnv.Addr, mnv.ZoneName, c.Name)
+		metaNode := newMetaNode(mnv.Addr, mnv.ZoneName, c.Name, c.cfg.metaNodeHandshake())
 		metaNode.ID = mnv.ID
 		metaNode.NodeSetID = mnv.NodeSetID
 		metaNode.RdOnly = mnv.RdOnly
@@ -135,6 +135,9 @@ func (mms *MockMetaServer) serveConn(rc net.Conn) {
 	case proto.OpMetaPartitionTryToLeader:
 		err = mms.handleTryToLeader(conn, req, adminTask)
 		fmt.Printf("meta node [%v] try to leader,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpSplitMetaPartition:
+		err = mms.handleSplitMetaPartition(conn, req, adminTask)
+		fmt.Printf("meta node [%v] split meta partition,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
 	default:
 		fmt.Printf("unknown code [%v]\n", req.Opcode)
 	}
@@ -188,6 +191,34 @@ func (mms *MockMetaServer) handleCreateMetaPartition(conn net.Conn, p *proto.Pac
 	return
 }
 
+func (mms *MockMetaServer) handleSplitMetaPartition(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	defer func() {
+		if err != nil {
+			responseAckErrToMaster(conn, p, err)
+		} else {
+			responseAckOKToMaster(conn, p, nil)
+		}
+	}()
+	requestJson, err := json.Marshal(adminTask.Request)
+	if err != nil {
+		return
+	}
+	req := &proto.SplitMetaPartitionRequest{}
+	if err = json.Unmarshal(requestJson, req); err != nil {
+		return
+	}
+	mms.Lock()
+	defer mms.Unlock()
+	partition, ok := mms.partitions[req.PartitionID]
+	if !ok {
+		return fmt.Errorf("meta partition %v not exists", req.PartitionID)
+	}
+	if req.Step == proto.SplitMetaPartitionCut {
+		partition.End = req.End
+	}
+	return
+}
+
 // Handle OpHeartbeat packet.
 func (mms *MockMetaServer) handleHeartbeats(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	// For ack to master
@@ -227,6 +258,7 @@ func (mms *MockMetaServer) handleHeartbeats(conn net.Conn, p *proto.Packet, admi
 	}
 	mms.RUnlock()
 	resp.ZoneName = mms.ZoneName
+	resp.SupportInlineData = true
 	resp.Status = proto.TaskSucceeds
 end:
 	return mms.postResponseToMaster(adminTask, resp)
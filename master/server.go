@@ -26,11 +26,13 @@ import (
 
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/raftstore"
+	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/cryptoutil"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 )
 
 // configuration keys
@@ -108,6 +110,7 @@ type Server struct {
 	storeDir        string
 	bStoreAddr      string
 	servicePath     string
+	tlsMode         string
 	retainLogs      uint64
 	tickInterval    int
 	raftRecvBufSize int
@@ -155,7 +158,7 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	m.cluster.partition = m.partition
 	m.cluster.idAlloc.partition = m.partition
 	MasterSecretKey := cfg.GetString(SecretKey)
-	if m.cluster.MasterSecretKey, err = cryptoutil.Base64Decode(MasterSecretKey); err != nil {
+	if m.cluster.MasterSecretKeys, err = cryptoutil.Base64DecodeKeys(MasterSecretKey); err != nil {
 		return fmt.Errorf("action[Start] failed %v, err: master service Key invalid = %s", proto.ErrInvalidCfg, MasterSecretKey)
 	}
 	m.cluster.scheduleTask()
@@ -208,6 +211,22 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 		return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
 	}
 
+	// the admin tasks are sent to the data and meta ports with the packet protocol
+	var tlsTransport *util.TLSTransport
+	if tlsTransport, err = util.LoadTLSTransport(cfg); err != nil {
+		return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+	}
+	util.SetTLSTransport(tlsTransport)
+	m.tlsMode = tlsTransport.Mode()
+
+	// the admin commands present the master tickets to the nodes enforcing authentication
+	if m.config.dataCredential, err = packetauth.LoadMasterCredential(cfg, packetauth.ConfigKeyDataServiceKey, proto.DataServiceID); err != nil {
+		return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+	}
+	if m.config.metaCredential, err = packetauth.LoadMasterCredential(cfg, packetauth.ConfigKeyMetaServiceKey, proto.MetaServiceID); err != nil {
+		return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
+	}
+
 	m.config.faultDomain = cfg.GetBoolWithDefault(faultDomain, false)
 	m.config.heartbeatPort = cfg.GetInt64(heartbeatPortKey)
 	m.config.replicaPort = cfg.GetInt64(replicaPortKey)
@@ -289,6 +308,9 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 			return fmt.Errorf("%v,err:%v", proto.ErrInvalidCfg, err.Error())
 		}
 	}
+	if limit := cfg.GetInt64(cfgDiskEvacuateLimit); limit > 0 {
+		m.config.DiskEvacuateLimit = int(limit)
+	}
 	m.tickInterval = int(cfg.GetFloat(cfgTickInterval))
 	m.raftRecvBufSize = int(cfg.GetInt(cfgRaftRecvBufSize))
 	m.electionTick = int(cfg.GetFloat(cfgElectionTick))
@@ -346,4 +368,5 @@ func (m *Server) initCluster() {
 
 func (m *Server) initUser() {
 	m.user = newUser(m.fsm, m.partition)
+	m.user.scheduleToCleanExpiredSessions()
 }
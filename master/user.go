@@ -23,14 +23,18 @@ const (
 )
 
 type User struct {
-	fsm            *MetadataFsm
-	partition      raftstore.Partition
-	userStore      sync.Map //K: userID, V: UserInfo
-	AKStore        sync.Map //K: ak, V: userID
-	volUser        sync.Map //K: vol, V: userIDs
-	userStoreMutex sync.RWMutex
-	AKStoreMutex   sync.RWMutex
-	volUserMutex   sync.RWMutex
+	fsm             *MetadataFsm
+	partition       raftstore.Partition
+	userStore       sync.Map //K: userID, V: UserInfo
+	AKStore         sync.Map //K: ak, V: userID
+	volUser         sync.Map //K: vol, V: userIDs
+	sessionStore    sync.Map //K: ak, V: UserSession
+	groupStore      sync.Map //K: groupID, V: UserGroup
+	roleStore       sync.Map //K: roleID, V: UserRole
+	userStoreMutex  sync.RWMutex
+	AKStoreMutex    sync.RWMutex
+	volUserMutex    sync.RWMutex
+	groupStoreMutex sync.RWMutex
 }
 
 func newUser(fsm *MetadataFsm, partition raftstore.Partition) (u *User) {
@@ -89,10 +93,10 @@ func (u *User) createKey(param *proto.UserCreateParam) (userInfo *proto.UserInfo
 		err = proto.ErrDuplicateUserID
 		return
 	}
-	_, exist = u.AKStore.Load(accessKey)
+	exist = u.accessKeyExists(accessKey)
 	for exist {
 		accessKey = util.RandomString(accessKeyLength, util.Numeric|util.LowerLetter|util.UpperLetter)
-		_, exist = u.AKStore.Load(accessKey)
+		exist = u.accessKeyExists(accessKey)
 	}
 	userPolicy = proto.NewUserPolicy()
 	userInfo = &proto.UserInfo{UserID: userID, AccessKey: accessKey, SecretKey: secretKey, Policy: userPolicy,
@@ -140,6 +144,15 @@ func (u *User) deleteKey(userID string) (err error) {
 	if akUser, err = u.getAKUser(userInfo.AccessKey); err != nil {
 		return
 	}
+	if err = u.deleteUserSessions(userID); err != nil {
+		return
+	}
+	if err = u.deleteUserAccessKeys(userID); err != nil {
+		return
+	}
+	if err = u.removeUserFromAllGroups(userID); err != nil {
+		return
+	}
 	if err = u.syncDeleteUserInfo(userInfo); err != nil {
 		return
 	}
@@ -184,7 +197,7 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 			err = proto.ErrInvalidAccessKey
 			return
 		}
-		if _, exist := u.AKStore.Load(param.AccessKey); exist {
+		if u.accessKeyExists(param.AccessKey) {
 			err = proto.ErrDuplicateAccessKey
 			return
 		}
@@ -237,6 +250,7 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 	}
 
 	akUserAft = &proto.AKUser{AccessKey: userInfo.AccessKey, UserID: param.UserID, Password: akUserBef.Password}
+	akUserAft.Status = akUserBef.Status
 
 	if err = u.syncUpdateUserInfo(userInfo); err != nil {
 		return
@@ -257,11 +271,22 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 func (u *User) getKeyInfo(ak string) (userInfo *proto.UserInfo, err error) {
 	var akUser *proto.AKUser
 	if akUser, err = u.getAKUser(ak); err != nil {
+		if userInfo, err = u.getSessionKeyInfo(ak); err != nil {
+			return
+		}
+		return u.withInheritedPolicy(userInfo), nil
+	}
+	if !akUser.IsActive() {
+		err = proto.ErrAccessKeyNotExists
 		return
 	}
 	if userInfo, err = u.getUserInfo(akUser.UserID); err != nil {
 		return
 	}
+	if !akUser.IsPrimary() {
+		userInfo = newKeyUserInfo(userInfo, akUser.AccessKey, akUser.SecretKey)
+	}
+	userInfo = u.withInheritedPolicy(userInfo)
 	log.LogInfof("action[getKeyInfo], accesskey[%v]", ak)
 	return
 }
@@ -366,6 +391,9 @@ func (u *User) deleteVolPolicy(volName string) (err error) {
 	//delete policy
 	var deletedUsers = make([]string, 0)
 	var userIDs []string
+	if err = u.deleteGroupVolPolicy(volName); err != nil {
+		return
+	}
 	if userIDs, err = u.getUsersOfVol(volName); err != nil {
 		return
 	}
@@ -0,0 +1,483 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+func (u *User) createGroup(param *proto.GroupCreateParam) (group *proto.UserGroup, err error) {
+	u.groupStoreMutex.Lock()
+	defer u.groupStoreMutex.Unlock()
+	if _, exist := u.groupStore.Load(param.ID); exist {
+		err = proto.ErrDuplicateGroupID
+		return
+	}
+	group = &proto.UserGroup{
+		GroupID:     param.ID,
+		Members:     make([]string, 0),
+		Roles:       make([]string, 0),
+		Policy:      proto.NewUserPolicy(),
+		Description: param.Description,
+		CreateTime:  time.Unix(time.Now().Unix(), 0).Format(proto.TimeFormat),
+	}
+	if err = u.syncAddUserGroup(group); err != nil {
+		return
+	}
+	u.groupStore.Store(group.GroupID, group)
+	log.LogInfof("action[createGroup], groupID: %v", group.GroupID)
+	return
+}
+
+func (u *User) deleteGroup(groupID string) (err error) {
+	u.groupStoreMutex.Lock()
+	defer u.groupStoreMutex.Unlock()
+	var group *proto.UserGroup
+	if group, err = u.getGroup(groupID); err != nil {
+		return
+	}
+	if err = u.syncDeleteUserGroup(group); err != nil {
+		return
+	}
+	u.groupStore.Delete(groupID)
+	log.LogInfof("action[deleteGroup], groupID: %v", groupID)
+	return
+}
+
+func (u *User) getGroup(groupID string) (group *proto.UserGroup, err error) {
+	value, exist := u.groupStore.Load(groupID)
+	if !exist {
+		err = proto.ErrGroupNotExists
+		return
+	}
+	group = value.(*proto.UserGroup)
+	return
+}
+
+func (u *User) getAllGroups() (groups []*proto.UserGroup) {
+	groups = make([]*proto.UserGroup, 0)
+	u.groupStore.Range(func(key, value interface{}) bool {
+		groups = append(groups, value.(*proto.UserGroup))
+		return true
+	})
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].GroupID < groups[j].GroupID
+	})
+	return
+}
+
+// updateGroup applies the modification to the group and persists it, the modification is
+// reverted if it fails to be persisted.
+func (u *User) updateGroup(groupID string, modify func(group *proto.UserGroup) error) (group *proto.UserGroup, err error) {
+	u.groupStoreMutex.Lock()
+	defer u.groupStoreMutex.Unlock()
+	if group, err = u.getGroup(groupID); err != nil {
+		return
+	}
+	group.Mu.Lock()
+	defer group.Mu.Unlock()
+	var backup []byte
+	if backup, err = json.Marshal(group); err != nil {
+		return
+	}
+	if err = modify(group); err != nil {
+		return
+	}
+	if err = u.syncUpdateUserGroup(group); err != nil {
+		_ = json.Unmarshal(backup, group)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (u *User) addGroupMember(groupID, userID string) (group *proto.UserGroup, err error) {
+	if _, err = u.getUserInfo(userID); err != nil {
+		return
+	}
+	if group, err = u.updateGroup(groupID, func(group *proto.UserGroup) error {
+		if !contains(group.Members, userID) {
+			group.Members = append(group.Members, userID)
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[addGroupMember], groupID: %v, userID: %v", groupID, userID)
+	return
+}
+
+func (u *User) removeGroupMember(groupID, userID string) (group *proto.UserGroup, err error) {
+	if group, err = u.updateGroup(groupID, func(group *proto.UserGroup) error {
+		var exist bool
+		if group.Members, exist = removeString(group.Members, userID); !exist {
+			return proto.ErrUserNotExists
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[removeGroupMember], groupID: %v, userID: %v", groupID, userID)
+	return
+}
+
+// removeUserFromAllGroups removes the deleted user from the groups it belongs to.
+func (u *User) removeUserFromAllGroups(userID string) (err error) {
+	for _, group := range u.getAllGroups() {
+		group.Mu.RLock()
+		isMember := contains(group.Members, userID)
+		group.Mu.RUnlock()
+		if !isMember {
+			continue
+		}
+		if _, err = u.removeGroupMember(group.GroupID, userID); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (u *User) updateGroupPolicy(param *proto.GroupPermUpdateParam) (group *proto.UserGroup, err error) {
+	if group, err = u.updateGroup(param.ID, func(group *proto.UserGroup) error {
+		group.Policy.AddAuthorizedVol(param.Volume, param.Policy)
+		return nil
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[updateGroupPolicy], groupID: %v, volume: %v", param.ID, param.Volume)
+	return
+}
+
+func (u *User) removeGroupPolicy(param *proto.GroupPermRemoveParam) (group *proto.UserGroup, err error) {
+	if group, err = u.updateGroup(param.ID, func(group *proto.UserGroup) error {
+		group.Policy.RemoveAuthorizedVol(param.Volume)
+		return nil
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[removeGroupPolicy], groupID: %v, volume: %v", param.ID, param.Volume)
+	return
+}
+
+func (u *User) grantGroupRole(groupID, roleID string) (group *proto.UserGroup, err error) {
+	if _, err = u.getRole(roleID); err != nil {
+		return
+	}
+	if group, err = u.updateGroup(groupID, func(group *proto.UserGroup) error {
+		if !contains(group.Roles, roleID) {
+			group.Roles = append(group.Roles, roleID)
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[grantGroupRole], groupID: %v, roleID: %v", groupID, roleID)
+	return
+}
+
+func (u *User) revokeGroupRole(groupID, roleID string) (group *proto.UserGroup, err error) {
+	if group, err = u.updateGroup(groupID, func(group *proto.UserGroup) error {
+		var exist bool
+		if group.Roles, exist = removeString(group.Roles, roleID); !exist {
+			return proto.ErrRoleNotExists
+		}
+		return nil
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[revokeGroupRole], groupID: %v, roleID: %v", groupID, roleID)
+	return
+}
+
+func (u *User) createRole(param *proto.GroupCreateParam) (role *proto.UserRole, err error) {
+	u.groupStoreMutex.Lock()
+	defer u.groupStoreMutex.Unlock()
+	if _, exist := u.roleStore.Load(param.ID); exist {
+		err = proto.ErrDuplicateRoleID
+		return
+	}
+	role = &proto.UserRole{
+		RoleID:      param.ID,
+		Policy:      proto.NewUserPolicy(),
+		Description: param.Description,
+		CreateTime:  time.Unix(time.Now().Unix(), 0).Format(proto.TimeFormat),
+	}
+	if err = u.syncAddUserRole(role); err != nil {
+		return
+	}
+	u.roleStore.Store(role.RoleID, role)
+	log.LogInfof("action[createRole], roleID: %v", role.RoleID)
+	return
+}
+
+// deleteRole deletes the role, which has to be revoked from all the groups first.
+func (u *User) deleteRole(roleID string) (err error) {
+	u.groupStoreMutex.Lock()
+	defer u.groupStoreMutex.Unlock()
+	var role *proto.UserRole
+	if role, err = u.getRole(roleID); err != nil {
+		return
+	}
+	for _, group := range u.getAllGroups() {
+		group.Mu.RLock()
+		granted := contains(group.Roles, roleID)
+		group.Mu.RUnlock()
+		if granted {
+			err = fmt.Errorf("role %v is granted to group %v", roleID, group.GroupID)
+			return
+		}
+	}
+	if err = u.syncDeleteUserRole(role); err != nil {
+		return
+	}
+	u.roleStore.Delete(roleID)
+	log.LogInfof("action[deleteRole], roleID: %v", roleID)
+	return
+}
+
+func (u *User) getRole(roleID string) (role *proto.UserRole, err error) {
+	value, exist := u.roleStore.Load(roleID)
+	if !exist {
+		err = proto.ErrRoleNotExists
+		return
+	}
+	role = value.(*proto.UserRole)
+	return
+}
+
+func (u *User) getAllRoles() (roles []*proto.UserRole) {
+	roles = make([]*proto.UserRole, 0)
+	u.roleStore.Range(func(key, value interface{}) bool {
+		roles = append(roles, value.(*proto.UserRole))
+		return true
+	})
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].RoleID < roles[j].RoleID
+	})
+	return
+}
+
+func (u *User) updateRolePolicy(roleID string, modify func(policy *proto.UserPolicy)) (role *proto.UserRole, err error) {
+	u.groupStoreMutex.Lock()
+	defer u.groupStoreMutex.Unlock()
+	if role, err = u.getRole(roleID); err != nil {
+		return
+	}
+	role.Mu.Lock()
+	defer role.Mu.Unlock()
+	var backup []byte
+	if backup, err = json.Marshal(role); err != nil {
+		return
+	}
+	modify(role.Policy)
+	if err = u.syncUpdateUserRole(role); err != nil {
+		_ = json.Unmarshal(backup, role)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (u *User) updateRoleVolPolicy(param *proto.GroupPermUpdateParam) (role *proto.UserRole, err error) {
+	if role, err = u.updateRolePolicy(param.ID, func(policy *proto.UserPolicy) {
+		policy.AddAuthorizedVol(param.Volume, param.Policy)
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[updateRolePolicy], roleID: %v, volume: %v", param.ID, param.Volume)
+	return
+}
+
+func (u *User) removeRoleVolPolicy(param *proto.GroupPermRemoveParam) (role *proto.UserRole, err error) {
+	if role, err = u.updateRolePolicy(param.ID, func(policy *proto.UserPolicy) {
+		policy.RemoveAuthorizedVol(param.Volume)
+	}); err != nil {
+		return
+	}
+	log.LogInfof("action[removeRolePolicy], roleID: %v, volume: %v", param.ID, param.Volume)
+	return
+}
+
+// deleteGroupVolPolicy removes the permissions on the deleted volume from the groups and the roles.
+func (u *User) deleteGroupVolPolicy(volName string) (err error) {
+	var hasVol = func(policy *proto.UserPolicy) bool {
+		_, exist := policy.AuthorizedVols[volName]
+		return exist
+	}
+	for _, group := range u.getAllGroups() {
+		group.Mu.RLock()
+		authorized := hasVol(group.Policy)
+		group.Mu.RUnlock()
+		if !authorized {
+			continue
+		}
+		if _, err = u.removeGroupPolicy(&proto.GroupPermRemoveParam{ID: group.GroupID, Volume: volName}); err != nil {
+			return
+		}
+	}
+	for _, role := range u.getAllRoles() {
+		role.Mu.RLock()
+		authorized := hasVol(role.Policy)
+		role.Mu.RUnlock()
+		if !authorized {
+			continue
+		}
+		if _, err = u.removeRoleVolPolicy(&proto.GroupPermRemoveParam{ID: role.RoleID, Volume: volName}); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// withInheritedPolicy returns the user info carrying the groups of the user and the permissions
+// inherited from them, or the user info itself if the user belongs to no group.
+func (u *User) withInheritedPolicy(userInfo *proto.UserInfo) *proto.UserInfo {
+	var groups []string
+	var inherited = proto.NewUserPolicy()
+	u.groupStore.Range(func(key, value interface{}) bool {
+		group := value.(*proto.UserGroup)
+		group.Mu.RLock()
+		defer group.Mu.RUnlock()
+		if !contains(group.Members, userInfo.UserID) {
+			return true
+		}
+		groups = append(groups, group.GroupID)
+		inherited.MergeAuthorizedVols(group.Policy)
+		for _, roleID := range group.Roles {
+			if role, err := u.getRole(roleID); err == nil {
+				role.Mu.RLock()
+				inherited.MergeAuthorizedVols(role.Policy)
+				role.Mu.RUnlock()
+			}
+		}
+		return true
+	})
+	if len(groups) == 0 {
+		return userInfo
+	}
+	sort.Strings(groups)
+	userInfo.Mu.RLock()
+	defer userInfo.Mu.RUnlock()
+	return &proto.UserInfo{
+		UserID:          userInfo.UserID,
+		AccessKey:       userInfo.AccessKey,
+		SecretKey:       userInfo.SecretKey,
+		Policy:          userInfo.Policy,
+		UserType:        userInfo.UserType,
+		CreateTime:      userInfo.CreateTime,
+		Description:     userInfo.Description,
+		Session:         userInfo.Session,
+		Groups:          groups,
+		InheritedPolicy: inherited,
+	}
+}
+
+// key = #group#groupID, value = userGroup
+func (u *User) syncAddUserGroup(group *proto.UserGroup) (err error) {
+	return u.syncPutUserGroup(opSyncAddUserGroup, group)
+}
+
+func (u *User) syncDeleteUserGroup(group *proto.UserGroup) (err error) {
+	return u.syncPutUserGroup(opSyncDeleteUserGroup, group)
+}
+
+func (u *User) syncUpdateUserGroup(group *proto.UserGroup) (err error) {
+	return u.syncPutUserGroup(opSyncUpdateUserGroup, group)
+}
+
+func (u *User) syncPutUserGroup(opType uint32, group *proto.UserGroup) (err error) {
+	raftCmd := new(RaftCmd)
+	raftCmd.Op = opType
+	raftCmd.K = groupPrefix + group.GroupID
+	raftCmd.V, err = json.Marshal(group)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return u.submit(raftCmd)
+}
+
+// key = #role#roleID, value = userRole
+func (u *User) syncAddUserRole(role *proto.UserRole) (err error) {
+	return u.syncPutUserRole(opSyncAddUserRole, role)
+}
+
+func (u *User) syncDeleteUserRole(role *proto.UserRole) (err error) {
+	return u.syncPutUserRole(opSyncDeleteUserRole, role)
+}
+
+func (u *User) syncUpdateUserRole(role *proto.UserRole) (err error) {
+	return u.syncPutUserRole(opSyncUpdateUserRole, role)
+}
+
+func (u *User) syncPutUserRole(opType uint32, role *proto.UserRole) (err error) {
+	raftCmd := new(RaftCmd)
+	raftCmd.Op = opType
+	raftCmd.K = rolePrefix + role.RoleID
+	raftCmd.V, err = json.Marshal(role)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return u.submit(raftCmd)
+}
+
+func (u *User) loadGroups() (err error) {
+	result, err := u.fsm.store.SeekForPrefix([]byte(groupPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadGroups], err: %v", err.Error())
+		return err
+	}
+	for _, value := range result {
+		group := &proto.UserGroup{}
+		if err = json.Unmarshal(value, group); err != nil {
+			err = fmt.Errorf("action[loadGroups], unmarshal err: %v", err.Error())
+			return err
+		}
+		u.groupStore.Store(group.GroupID, group)
+		log.LogInfof("action[loadGroups], groupID[%v]", group.GroupID)
+	}
+	result, err = u.fsm.store.SeekForPrefix([]byte(rolePrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadRoles], err: %v", err.Error())
+		return err
+	}
+	for _, value := range result {
+		role := &proto.UserRole{}
+		if err = json.Unmarshal(value, role); err != nil {
+			err = fmt.Errorf("action[loadRoles], unmarshal err: %v", err.Error())
+			return err
+		}
+		u.roleStore.Store(role.RoleID, role)
+		log.LogInfof("action[loadRoles], roleID[%v]", role.RoleID)
+	}
+	return
+}
+
+func (u *User) clearGroups() {
+	u.groupStore.Range(func(key, value interface{}) bool {
+		u.groupStore.Delete(key)
+		return true
+	})
+	u.roleStore.Range(func(key, value interface{}) bool {
+		u.roleStore.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,277 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/keystore"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	keyLetters           = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	maxAccessKeysPerUser = 8
+	// the last used time of an access key is persisted at most once in the interval, in seconds
+	defaultIntervalToPersistKeyUsed = 600
+)
+
+// randomKey generates the keys handed out to the clients from a cryptographically secure source.
+func randomKey(length int) (key string, err error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(keyLetters)))
+	for i := range b {
+		var n *big.Int
+		if n, err = rand.Int(rand.Reader, max); err != nil {
+			return
+		}
+		b[i] = keyLetters[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// newKeyUserInfo returns the user info presented by an access key other than the primary one of the user,
+// it carries the keys and the permissions of the user the access key belongs to.
+func newKeyUserInfo(owner *proto.UserInfo, accessKey, secretKey string) (userInfo *proto.UserInfo) {
+	owner.Mu.RLock()
+	defer owner.Mu.RUnlock()
+	return &proto.UserInfo{
+		UserID:      owner.UserID,
+		AccessKey:   accessKey,
+		SecretKey:   secretKey,
+		Policy:      owner.Policy,
+		UserType:    owner.UserType,
+		CreateTime:  owner.CreateTime,
+		Description: owner.Description,
+	}
+}
+
+// userAccessKeys returns the records of all the access keys of the user, including the primary one.
+func (u *User) userAccessKeys(userID string) (akUsers []*proto.AKUser) {
+	u.AKStore.Range(func(key, value interface{}) bool {
+		if akUser := value.(*proto.AKUser); akUser.UserID == userID {
+			akUsers = append(akUsers, akUser)
+		}
+		return true
+	})
+	return
+}
+
+func (u *User) addAccessKey(param *proto.UserKeyCreateParam) (key *proto.UserAccessKey, err error) {
+	if param.UserID == "" {
+		err = proto.ErrInvalidUserID
+		return
+	}
+	var accessKey = param.AccessKey
+	if accessKey == "" {
+		if accessKey, err = randomKey(accessKeyLength); err != nil {
+			return
+		}
+	} else if !proto.IsValidAK(accessKey) {
+		err = proto.ErrInvalidAccessKey
+		return
+	}
+	var secretKey = param.SecretKey
+	if secretKey == "" {
+		if secretKey, err = randomKey(secretKeyLength); err != nil {
+			return
+		}
+	} else if !proto.IsValidSK(secretKey) {
+		err = proto.ErrInvalidSecretKey
+		return
+	}
+	var name = param.Name
+	if name == "" {
+		name = accessKey
+	}
+	if name == proto.PrimaryAccessKeyName {
+		err = fmt.Errorf("access key name %v is reserved for the primary key", name)
+		return
+	}
+
+	u.userStoreMutex.Lock()
+	defer u.userStoreMutex.Unlock()
+	u.AKStoreMutex.Lock()
+	defer u.AKStoreMutex.Unlock()
+	if _, err = u.getUserInfo(param.UserID); err != nil {
+		return
+	}
+	if param.AccessKey != "" && u.accessKeyExists(accessKey) {
+		err = proto.ErrDuplicateAccessKey
+		return
+	}
+	for u.accessKeyExists(accessKey) {
+		if accessKey, err = randomKey(accessKeyLength); err != nil {
+			return
+		}
+	}
+	var count int
+	for _, akUser := range u.userAccessKeys(param.UserID) {
+		if akUser.IsPrimary() {
+			continue
+		}
+		if akUser.Name == name {
+			err = fmt.Errorf("access key named %v exists", name)
+			return
+		}
+		count++
+	}
+	if count >= maxAccessKeysPerUser {
+		err = fmt.Errorf("user %v has %v access keys, no more than %v is allowed", param.UserID, count+1, maxAccessKeysPerUser+1)
+		return
+	}
+	akUser := &proto.AKUser{
+		AccessKey: accessKey,
+		UserID:    param.UserID,
+		SecretKey: secretKey,
+		AccessKeyMeta: keystore.AccessKeyMeta{
+			Name:       name,
+			Status:     keystore.AccessKeyStatusActive,
+			CreateTime: time.Unix(time.Now().Unix(), 0).Format(proto.TimeFormat),
+		},
+	}
+	if err = u.syncAddAKUser(akUser); err != nil {
+		return
+	}
+	u.AKStore.Store(accessKey, akUser)
+	key = &proto.UserAccessKey{Name: name, AccessKey: accessKey, SecretKey: secretKey,
+		Status: akUser.Status, CreateTime: akUser.CreateTime}
+	log.LogInfof("action[addAccessKey], userID: %v, name[%v], accesskey[%v]", param.UserID, name, accessKey)
+	return
+}
+
+// getUserAccessKey returns the record of the access key, which has to belong to the user.
+func (u *User) getUserAccessKey(userID, ak string) (akUser *proto.AKUser, err error) {
+	if akUser, err = u.getAKUser(ak); err != nil {
+		return
+	}
+	if akUser.UserID != userID {
+		err = proto.ErrAccessKeyNotExists
+	}
+	return
+}
+
+func (u *User) deleteAccessKey(userID, ak string) (err error) {
+	u.AKStoreMutex.Lock()
+	defer u.AKStoreMutex.Unlock()
+	var akUser *proto.AKUser
+	if akUser, err = u.getUserAccessKey(userID, ak); err != nil {
+		return
+	}
+	// the primary access key is replaced by updating the user
+	if akUser.IsPrimary() {
+		err = proto.ErrNoPermission
+		return
+	}
+	if err = u.syncDeleteAKUser(akUser); err != nil {
+		return
+	}
+	u.AKStore.Delete(ak)
+	log.LogInfof("action[deleteAccessKey], userID: %v, name[%v], accesskey[%v]", userID, akUser.Name, ak)
+	return
+}
+
+// deleteUserAccessKeys deletes all the access keys of the user except the primary one, the caller should hold AKStoreMutex.
+func (u *User) deleteUserAccessKeys(userID string) (err error) {
+	for _, akUser := range u.userAccessKeys(userID) {
+		if akUser.IsPrimary() {
+			continue
+		}
+		if err = u.syncDeleteAKUser(akUser); err != nil {
+			return
+		}
+		u.AKStore.Delete(akUser.AccessKey)
+	}
+	return
+}
+
+func (u *User) setAccessKeyStatus(userID, ak, status string) (err error) {
+	if !keystore.IsValidAccessKeyStatus(status) {
+		err = fmt.Errorf("invalid access key status %v", status)
+		return
+	}
+	u.AKStoreMutex.Lock()
+	defer u.AKStoreMutex.Unlock()
+	var akUser *proto.AKUser
+	if akUser, err = u.getUserAccessKey(userID, ak); err != nil {
+		return
+	}
+	// the record is replaced rather than modified, since it is read without lock
+	newAKUser := *akUser
+	newAKUser.Status = status
+	if err = u.syncUpdateAKUser(&newAKUser); err != nil {
+		return
+	}
+	u.AKStore.Store(ak, &newAKUser)
+	log.LogInfof("action[setAccessKeyStatus], userID: %v, accesskey[%v], status[%v]", userID, ak, status)
+	return
+}
+
+func (u *User) listAccessKeys(userID string) (keys []*proto.UserAccessKey, err error) {
+	var userInfo *proto.UserInfo
+	if userInfo, err = u.getUserInfo(userID); err != nil {
+		return
+	}
+	keys = make([]*proto.UserAccessKey, 0)
+	for _, akUser := range u.userAccessKeys(userID) {
+		key := &proto.UserAccessKey{
+			Name:         akUser.Name,
+			AccessKey:    akUser.AccessKey,
+			Status:       keystore.AccessKeyStatusActive,
+			CreateTime:   akUser.CreateTime,
+			LastUsedTime: akUser.LastUsedTime,
+		}
+		if !akUser.IsActive() {
+			key.Status = keystore.AccessKeyStatusInactive
+		}
+		if akUser.IsPrimary() {
+			key.Name = proto.PrimaryAccessKeyName
+			key.CreateTime = userInfo.CreateTime
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreateTime < keys[j].CreateTime
+	})
+	return
+}
+
+// reportAccessKeysUsed updates the last used time of the access keys reported by the object nodes.
+func (u *User) reportAccessKeysUsed(used map[string]int64) (err error) {
+	u.AKStoreMutex.Lock()
+	defer u.AKStoreMutex.Unlock()
+	for ak, usedTime := range used {
+		value, exist := u.AKStore.Load(ak)
+		if !exist {
+			continue
+		}
+		akUser := value.(*proto.AKUser)
+		if usedTime < akUser.LastUsedTime+defaultIntervalToPersistKeyUsed {
+			continue
+		}
+		newAKUser := *akUser
+		newAKUser.LastUsedTime = usedTime
+		if err = u.syncUpdateAKUser(&newAKUser); err != nil {
+			return
+		}
+		u.AKStore.Store(ak, &newAKUser)
+	}
+	return
+}
@@ -0,0 +1,248 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	sessionTokenLength             = 64
+	defaultIntervalToCleanSessions = 60
+)
+
+// accessKeyExists returns true if the access key is used by a user or a session, the caller should hold AKStoreMutex.
+func (u *User) accessKeyExists(ak string) bool {
+	if _, exist := u.AKStore.Load(ak); exist {
+		return true
+	}
+	_, exist := u.sessionStore.Load(ak)
+	return exist
+}
+
+func (u *User) createSession(param *proto.UserSessionCreateParam) (session *proto.UserSession, err error) {
+	if param.UserID == "" {
+		err = proto.ErrInvalidUserID
+		return
+	}
+	duration := param.Duration
+	if duration == 0 {
+		duration = proto.DefaultSessionDuration
+	}
+	if _, err = u.getUserInfo(param.UserID); err != nil {
+		return
+	}
+
+	u.AKStoreMutex.Lock()
+	defer u.AKStoreMutex.Unlock()
+	session = &proto.UserSession{UserID: param.UserID, Policy: param.Policy}
+	for session.AccessKey == "" || u.accessKeyExists(session.AccessKey) {
+		if session.AccessKey, err = randomKey(accessKeyLength); err != nil {
+			return
+		}
+	}
+	if session.SecretKey, err = randomKey(secretKeyLength); err != nil {
+		return
+	}
+	if session.SessionToken, err = randomKey(sessionTokenLength); err != nil {
+		return
+	}
+	now := time.Now().Unix()
+	session.Expiration = now + duration
+	session.CreateTime = time.Unix(now, 0).Format(proto.TimeFormat)
+	if err = u.syncAddUserSession(session); err != nil {
+		return
+	}
+	u.sessionStore.Store(session.AccessKey, session)
+	log.LogInfof("action[createSession], userID: %v, accesskey[%v], expiration[%v]",
+		session.UserID, session.AccessKey, session.Expiration)
+	return
+}
+
+func (u *User) getSession(ak string) (session *proto.UserSession, err error) {
+	value, exist := u.sessionStore.Load(ak)
+	if !exist {
+		err = proto.ErrAccessKeyNotExists
+		return
+	}
+	session = value.(*proto.UserSession)
+	if session.IsExpired() {
+		err = proto.ErrAccessKeyNotExists
+	}
+	return
+}
+
+// getSessionKeyInfo returns the user info presented by the access key of a session, it
+// carries the session keys and the permissions of the user the session belongs to.
+func (u *User) getSessionKeyInfo(ak string) (userInfo *proto.UserInfo, err error) {
+	var (
+		session *proto.UserSession
+		owner   *proto.UserInfo
+	)
+	if session, err = u.getSession(ak); err != nil {
+		return
+	}
+	if owner, err = u.getUserInfo(session.UserID); err != nil {
+		return
+	}
+	userInfo = newKeyUserInfo(owner, session.AccessKey, session.SecretKey)
+	userInfo.Session = session
+	log.LogInfof("action[getSessionKeyInfo], accesskey[%v], userID[%v]", ak, session.UserID)
+	return
+}
+
+// listSessions returns the sessions of the user, or of all the users if userID is empty,
+// without the secret keys and the session tokens.
+func (u *User) listSessions(userID string) (sessions []*proto.UserSession) {
+	sessions = make([]*proto.UserSession, 0)
+	u.sessionStore.Range(func(key, value interface{}) bool {
+		session := value.(*proto.UserSession)
+		if userID != "" && session.UserID != userID {
+			return true
+		}
+		sessions = append(sessions, &proto.UserSession{
+			AccessKey:  session.AccessKey,
+			UserID:     session.UserID,
+			Policy:     session.Policy,
+			Expiration: session.Expiration,
+			CreateTime: session.CreateTime,
+		})
+		return true
+	})
+	return
+}
+
+func (u *User) revokeSession(ak string) (err error) {
+	u.AKStoreMutex.Lock()
+	defer u.AKStoreMutex.Unlock()
+	value, exist := u.sessionStore.Load(ak)
+	if !exist {
+		err = proto.ErrAccessKeyNotExists
+		return
+	}
+	return u.deleteSession(value.(*proto.UserSession))
+}
+
+// deleteUserSessions deletes all the sessions of the user, the caller should hold AKStoreMutex.
+func (u *User) deleteUserSessions(userID string) (err error) {
+	var sessions []*proto.UserSession
+	u.sessionStore.Range(func(key, value interface{}) bool {
+		if session := value.(*proto.UserSession); session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+		return true
+	})
+	for _, session := range sessions {
+		if err = u.deleteSession(session); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (u *User) deleteSession(session *proto.UserSession) (err error) {
+	if err = u.syncDeleteUserSession(session); err != nil {
+		return
+	}
+	u.sessionStore.Delete(session.AccessKey)
+	log.LogInfof("action[deleteSession], userID: %v, accesskey[%v]", session.UserID, session.AccessKey)
+	return
+}
+
+// revokeUserSessions revokes all the sessions of the user, or the expired ones only if expiredOnly is set.
+func (u *User) revokeUserSessions(userID string, expiredOnly bool) (count int, err error) {
+	var sessions []*proto.UserSession
+	u.sessionStore.Range(func(key, value interface{}) bool {
+		session := value.(*proto.UserSession)
+		if (userID == "" || session.UserID == userID) && (!expiredOnly || session.IsExpired()) {
+			sessions = append(sessions, session)
+		}
+		return true
+	})
+	for _, session := range sessions {
+		if err = u.revokeSession(session.AccessKey); err != nil && err != proto.ErrAccessKeyNotExists {
+			return
+		}
+		err = nil
+		count++
+	}
+	return
+}
+
+func (u *User) scheduleToCleanExpiredSessions() {
+	go func() {
+		for {
+			if u.partition != nil && u.partition.IsRaftLeader() {
+				if count, err := u.revokeUserSessions("", true); err != nil {
+					log.LogWarnf("action[cleanExpiredSessions] cleaned[%v] err[%v]", count, err)
+				} else if count > 0 {
+					log.LogInfof("action[cleanExpiredSessions] cleaned[%v]", count)
+				}
+			}
+			time.Sleep(time.Second * defaultIntervalToCleanSessions)
+		}
+	}()
+}
+
+// key = #session#accesskey, value = userSession
+func (u *User) syncAddUserSession(session *proto.UserSession) (err error) {
+	return u.syncPutUserSession(opSyncAddUserSession, session)
+}
+
+func (u *User) syncDeleteUserSession(session *proto.UserSession) (err error) {
+	return u.syncPutUserSession(opSyncDeleteUserSession, session)
+}
+
+func (u *User) syncPutUserSession(opType uint32, session *proto.UserSession) (err error) {
+	raftCmd := new(RaftCmd)
+	raftCmd.Op = opType
+	raftCmd.K = sessionPrefix + session.AccessKey
+	raftCmd.V, err = json.Marshal(session)
+	if err != nil {
+		return errors.New(err.Error())
+	}
+	return u.submit(raftCmd)
+}
+
+func (u *User) loadSessions() (err error) {
+	result, err := u.fsm.store.SeekForPrefix([]byte(sessionPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadSessions], err: %v", err.Error())
+		return err
+	}
+	for _, value := range result {
+		session := &proto.UserSession{}
+		if err = json.Unmarshal(value, session); err != nil {
+			err = fmt.Errorf("action[loadSessions], unmarshal err: %v", err.Error())
+			return err
+		}
+		u.sessionStore.Store(session.AccessKey, session)
+		log.LogInfof("action[loadSessions], ak[%v], userID[%v]", session.AccessKey, session.UserID)
+	}
+	return
+}
+
+func (u *User) clearSessions() {
+	u.sessionStore.Range(func(key, value interface{}) bool {
+		u.sessionStore.Delete(key)
+		return true
+	})
+}
@@ -68,6 +68,11 @@ func (u *User) syncDeleteAKUser(akUser *proto.AKUser) (err error) {
 	return u.syncPutAKUser(opSyncDeleteAKUser, akUser)
 }
 
+// syncUpdateAKUser persists the changed status or last used time of an access key
+func (u *User) syncUpdateAKUser(akUser *proto.AKUser) (err error) {
+	return u.syncPutAKUser(opSyncUpdateAKUser, akUser)
+}
+
 func (u *User) syncPutAKUser(opType uint32, akUser *proto.AKUser) (err error) {
 	userInfo := new(RaftCmd)
 	userInfo.Op = opType
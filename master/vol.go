@@ -38,6 +38,7 @@ type VolVarargs struct {
 	domainId       uint64
 	dpReplicaNum   uint8
 	enablePosixAcl bool
+	inlineSize     uint64
 }
 
 // Vol represents a set of meta partitionMap and data partitionMap
@@ -85,6 +86,7 @@ type Vol struct {
 	description        string
 	dpSelectorName     string
 	dpSelectorParm     string
+	inlineSizeLimit    uint64 // files up to this size are stored inline in the inode
 	domainId           uint64
 	qosManager         *QosCtrlManager
 
@@ -117,6 +119,7 @@ func newVol(vv volValue) (vol *Vol) {
 	vol.defaultPriority = vv.DefaultPriority
 	vol.domainId = vv.DomainId
 	vol.enablePosixAcl = vv.EnablePosixAcl
+	vol.inlineSizeLimit = vv.InlineSizeLimit
 
 	vol.VolType = vv.VolType
 	vol.EbsBlkSize = vv.EbsBlkSize
@@ -242,12 +245,15 @@ func (vol *Vol) metaPartition(partitionID uint64) (mp *MetaPartition, err error)
 	return
 }
 
-func (vol *Vol) maxPartitionID() (maxPartitionID uint64) {
+// tailPartitionID returns the partition at the tail of the inode range. It is the one with the
+// largest id unless a partition has been split in the middle of the range.
+func (vol *Vol) tailPartitionID() (tailPartitionID uint64) {
 	vol.mpsLock.RLock()
 	defer vol.mpsLock.RUnlock()
-	for id := range vol.MetaPartitions {
-		if id > maxPartitionID {
-			maxPartitionID = id
+	var maxStart uint64
+	for id, mp := range vol.MetaPartitions {
+		if tailPartitionID == 0 || mp.Start > maxStart {
+			tailPartitionID, maxStart = id, mp.Start
 		}
 	}
 	return
@@ -395,14 +401,14 @@ func (vol *Vol) checkReplicaNum(c *Cluster) {
 func (vol *Vol) checkMetaPartitions(c *Cluster) {
 	var tasks []*proto.AdminTask
 	vol.checkSplitMetaPartition(c)
-	maxPartitionID := vol.maxPartitionID()
+	tailPartitionID := vol.tailPartitionID()
 	mps := vol.cloneMetaPartitionMap()
 	var (
 		doSplit bool
 		err     error
 	)
 	for _, mp := range mps {
-		doSplit = mp.checkStatus(c.Name, true, int(vol.mpReplicaNum), maxPartitionID)
+		doSplit = mp.checkStatus(c.Name, true, int(vol.mpReplicaNum), tailPartitionID)
 		if doSplit {
 			nextStart := mp.MaxInodeID + defaultMetaPartitionInodeIDStep
 			log.LogInfof(c.Name, fmt.Sprintf("cluster[%v],vol[%v],meta partition[%v] splits start[%v] maxinodeid:[%v] default step:[%v],nextStart[%v]",
@@ -414,7 +420,7 @@ func (vol *Vol) checkMetaPartitions(c *Cluster) {
 
 		mp.checkLeader()
 		mp.checkReplicaNum(c, vol.Name, vol.mpReplicaNum)
-		mp.checkEnd(c, maxPartitionID)
+		mp.checkEnd(c, tailPartitionID)
 		mp.reportMissingReplicas(c.Name, c.leaderInfo.addr, defaultMetaPartitionTimeOutSec, defaultIntervalToAlarmMissingMetaPartition)
 		tasks = append(tasks, mp.replicaCreationTasks(c.Name, vol.Name)...)
 	}
@@ -422,8 +428,8 @@ func (vol *Vol) checkMetaPartitions(c *Cluster) {
 }
 
 func (vol *Vol) checkSplitMetaPartition(c *Cluster) {
-	maxPartitionID := vol.maxPartitionID()
-	partition, ok := vol.MetaPartitions[maxPartitionID]
+	tailPartitionID := vol.tailPartitionID()
+	partition, ok := vol.MetaPartitions[tailPartitionID]
 	if !ok {
 		return
 	}
@@ -971,9 +977,9 @@ func (vol *Vol) splitMetaPartition(c *Cluster, mp *MetaPartition, end uint64) (e
 	vol.createMpMutex.Lock()
 	defer vol.createMpMutex.Unlock()
 
-	maxPartitionID := vol.maxPartitionID()
-	if maxPartitionID != mp.PartitionID {
-		err = fmt.Errorf("mp[%v] is not the last meta partition[%v]", mp.PartitionID, maxPartitionID)
+	tailPartitionID := vol.tailPartitionID()
+	if tailPartitionID != mp.PartitionID {
+		err = fmt.Errorf("mp[%v] is not the last meta partition[%v]", mp.PartitionID, tailPartitionID)
 		return
 	}
 
@@ -1090,6 +1096,7 @@ func setVolFromArgs(args *VolVarargs, vol *Vol) {
 	vol.FollowerRead = args.followerRead
 	vol.authenticate = args.authenticate
 	vol.enablePosixAcl = args.enablePosixAcl
+	vol.inlineSizeLimit = args.inlineSize
 
 	if proto.IsCold(vol.VolType) {
 		coldArgs := args.coldArgs
@@ -1133,6 +1140,7 @@ func getVolVarargs(vol *Vol) *VolVarargs {
 		dpSelectorName: vol.dpSelectorName,
 		dpSelectorParm: vol.dpSelectorParm,
 		enablePosixAcl: vol.enablePosixAcl,
+		inlineSize:     vol.inlineSizeLimit,
 
 		coldArgs: args,
 	}
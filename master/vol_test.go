@@ -275,10 +275,10 @@ func checkMetaPartitionsWritableTest(vol *Vol, t *testing.T) {
 		}
 	}
 
-	maxPartitionID := vol.maxPartitionID()
-	maxMp := vol.MetaPartitions[maxPartitionID]
+	tailPartitionID := vol.tailPartitionID()
+	maxMp := vol.MetaPartitions[tailPartitionID]
 	//after check meta partitions ,the status must be writable
-	maxMp.checkStatus(server.cluster.Name, false, int(vol.mpReplicaNum), maxPartitionID)
+	maxMp.checkStatus(server.cluster.Name, false, int(vol.mpReplicaNum), tailPartitionID)
 	if maxMp.Status != proto.ReadWrite {
 		t.Errorf("expect partition status[%v],real status[%v]\n", proto.ReadWrite, maxMp.Status)
 		return
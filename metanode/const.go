@@ -133,6 +133,11 @@ const (
 
 	opFSMClearInodeCache
 	opFSMSentToChan
+	opFSMInlineWrite
+	opFSMObjExtentReplace
+	opFSMSplitPartition
+	opFSMSeedSplitPartition
+	opFSMDropSplitPartition
 )
 
 var (
@@ -33,6 +33,8 @@ const (
 var (
 	// InodeV1Flag uint64 = 0x01
 	InodeV2Flag uint64 = 0x02
+	// InodeV3Flag marks a V2 value followed by the inline data of a small file.
+	InodeV3Flag uint64 = 0x03
 )
 
 // Inode wraps necessary properties of `Inode` information in the file system.
@@ -72,6 +74,7 @@ type Inode struct {
 	//Extents    *ExtentsTree
 	Extents    *SortedExtents
 	ObjExtents *SortedObjExtents
+	InlineData []byte // file data stored inline, only when there is no extent
 }
 
 type InodeBatch []*Inode
@@ -98,6 +101,7 @@ func (i *Inode) String() string {
 	buff.WriteString(fmt.Sprintf("Reserved[%d]", i.Reserved))
 	buff.WriteString(fmt.Sprintf("Extents[%s]", i.Extents))
 	buff.WriteString(fmt.Sprintf("ObjExtents[%s]", i.ObjExtents))
+	buff.WriteString(fmt.Sprintf("InlineData[%d]", len(i.InlineData)))
 	buff.WriteString("}")
 	return buff.String()
 }
@@ -150,6 +154,10 @@ func (i *Inode) Copy() BtreeItem {
 	newIno.Reserved = i.Reserved
 	newIno.Extents = i.Extents.Clone()
 	newIno.ObjExtents = i.ObjExtents.Clone()
+	if size := len(i.InlineData); size > 0 {
+		newIno.InlineData = make([]byte, size)
+		copy(newIno.InlineData, i.InlineData)
+	}
 	i.RUnlock()
 	return newIno
 }
@@ -320,14 +328,18 @@ func (i *Inode) MarshalValue() (val []byte) {
 	if err = binary.Write(buff, binary.BigEndian, &i.Flag); err != nil {
 		panic(err)
 	}
-	if i.ObjExtents != nil && len(i.ObjExtents.eks) > 0 {
+	if len(i.InlineData) > 0 {
+		i.Reserved = InodeV3Flag
+	} else if i.ObjExtents != nil && len(i.ObjExtents.eks) > 0 {
+		i.Reserved = InodeV2Flag
+	} else if i.Reserved == InodeV3Flag {
 		i.Reserved = InodeV2Flag
 	}
 	if err = binary.Write(buff, binary.BigEndian, &i.Reserved); err != nil {
 		panic(err)
 	}
 
-	if i.Reserved == InodeV2Flag {
+	if i.Reserved == InodeV2Flag || i.Reserved == InodeV3Flag {
 		// marshal ExtentsKey
 		extData, err := i.Extents.MarshalBinary()
 		if err != nil {
@@ -350,6 +362,15 @@ func (i *Inode) MarshalValue() (val []byte) {
 		if _, err = buff.Write(objExtData); err != nil {
 			panic(err)
 		}
+		if i.Reserved == InodeV3Flag {
+			// marshal InlineData
+			if err = binary.Write(buff, binary.BigEndian, uint32(len(i.InlineData))); err != nil {
+				panic(err)
+			}
+			if _, err = buff.Write(i.InlineData); err != nil {
+				panic(err)
+			}
+		}
 	} else {
 		// marshal ExtentsKey
 		extData, err := i.Extents.MarshalBinary()
@@ -423,7 +444,7 @@ func (i *Inode) UnmarshalValue(val []byte) (err error) {
 		i.Extents = NewSortedExtents()
 	}
 
-	if i.Reserved == InodeV2Flag {
+	if i.Reserved == InodeV2Flag || i.Reserved == InodeV3Flag {
 		extSize := uint32(0)
 		if err = binary.Read(buff, binary.BigEndian, &extSize); err != nil {
 			return
@@ -454,6 +475,19 @@ func (i *Inode) UnmarshalValue(val []byte) (err error) {
 				return
 			}
 		}
+		if i.Reserved == InodeV3Flag {
+			// unmarshal InlineData
+			inlineSize := uint32(0)
+			if err = binary.Read(buff, binary.BigEndian, &inlineSize); err != nil {
+				return
+			}
+			if inlineSize > 0 {
+				i.InlineData = make([]byte, inlineSize)
+				if _, err = io.ReadFull(buff, i.InlineData); err != nil {
+					return
+				}
+			}
+		}
 	} else {
 		if err = i.Extents.UnmarshalBinary(buff.Bytes()); err != nil {
 			return
@@ -470,6 +504,9 @@ func (i *Inode) AppendExtents(eks []proto.ExtentKey, ct int64, volType int) (del
 	}
 	i.Lock()
 	defer i.Unlock()
+	// the client has written the inline data into the extents before
+	// appending them, so the inode is no longer inline.
+	i.InlineData = nil
 	for _, ek := range eks {
 		delItems := i.Extents.Append(ek)
 		size := i.Extents.Size()
@@ -513,6 +550,7 @@ func (i *Inode) AppendExtentWithCheck(ek proto.ExtentKey, ct int64, discardExten
 	}
 
 	if proto.IsHot(volType) {
+		i.InlineData = nil
 		size := i.Extents.Size()
 		if i.Size < size {
 			i.Size = size
@@ -527,6 +565,9 @@ func (i *Inode) AppendExtentWithCheck(ek proto.ExtentKey, ct int64, discardExten
 func (i *Inode) ExtentsTruncate(length uint64, ct int64) (delExtents []proto.ExtentKey) {
 	i.Lock()
 	delExtents = i.Extents.Truncate(length)
+	if uint64(len(i.InlineData)) > length {
+		i.InlineData = i.InlineData[:length]
+	}
 	i.Size = length
 	i.ModifyTime = ct
 	i.Generation++
@@ -534,6 +575,33 @@ func (i *Inode) ExtentsTruncate(length uint64, ct int64) (delExtents []proto.Ext
 	return
 }
 
+// WriteInline writes the data at the offset into the inline data of the inode.
+// It returns OpInlineFullErr if the inode already has extents or the file
+// would grow beyond the limit, in which case the client falls back to extents.
+func (i *Inode) WriteInline(offset uint64, data []byte, limit uint64, ct int64) (status uint8) {
+	i.Lock()
+	defer i.Unlock()
+	end := offset + uint64(len(data))
+	if len(i.Extents.eks) > 0 || (i.ObjExtents != nil && len(i.ObjExtents.eks) > 0) || end > limit || i.Size > limit {
+		return proto.OpInlineFullErr
+	}
+	size := i.Size
+	if size < end {
+		size = end
+	}
+	if uint64(len(i.InlineData)) < size {
+		// the gap between the old inline data and the size is a hole
+		inline := make([]byte, size)
+		copy(inline, i.InlineData)
+		i.InlineData = inline
+	}
+	copy(i.InlineData[offset:], data)
+	i.Size = size
+	i.Generation++
+	i.ModifyTime = ct
+	return proto.OpOk
+}
+
 // IncNLink increases the nLink value by one.
 func (i *Inode) IncNLink() {
 	i.Lock()
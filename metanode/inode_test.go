@@ -0,0 +1,87 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func TestInode_InlineDataMarshal(t *testing.T) {
+	ino := NewInode(1, proto.Mode(0644))
+	if status := ino.WriteInline(0, []byte("hello"), proto.MaxInlineDataSize, 1); status != proto.OpOk {
+		t.Fatalf("write inline fail, status(%v)", status)
+	}
+
+	val, err := ino.Marshal()
+	if err != nil {
+		t.Fatalf("marshal inode fail cause: %v", err)
+	}
+	ino2 := NewInode(0, 0)
+	if err = ino2.Unmarshal(val); err != nil {
+		t.Fatalf("unmarshal inode fail cause: %v", err)
+	}
+	if ino2.Reserved != InodeV3Flag || ino2.Size != 5 || !bytes.Equal(ino2.InlineData, []byte("hello")) {
+		t.Fatalf("result mismatch: %v", ino2)
+	}
+
+	// an inode without inline data goes back to the V2 layout
+	ino2.ExtentsTruncate(0, 2)
+	if val, err = ino2.Marshal(); err != nil {
+		t.Fatalf("marshal inode fail cause: %v", err)
+	}
+	ino3 := NewInode(0, 0)
+	if err = ino3.Unmarshal(val); err != nil {
+		t.Fatalf("unmarshal inode fail cause: %v", err)
+	}
+	if ino3.Reserved != InodeV2Flag || ino3.Size != 0 || len(ino3.InlineData) != 0 {
+		t.Fatalf("result mismatch: %v", ino3)
+	}
+
+	// an inode never written inline keeps the layout known by the metanodes before the upgrade
+	if val, err = NewInode(2, proto.Mode(0644)).Marshal(); err != nil {
+		t.Fatalf("marshal inode fail cause: %v", err)
+	}
+	ino4 := NewInode(0, 0)
+	if err = ino4.Unmarshal(val); err != nil {
+		t.Fatalf("unmarshal inode fail cause: %v", err)
+	}
+	if ino4.Reserved == InodeV3Flag {
+		t.Fatalf("result mismatch: %v", ino4)
+	}
+}
+
+func TestInode_WriteInline(t *testing.T) {
+	ino := NewInode(1, proto.Mode(0644))
+	ino.WriteInline(0, []byte("abc"), 16, 1)
+	ino.WriteInline(6, []byte("xyz"), 16, 1)
+	if ino.Size != 9 || !bytes.Equal(ino.InlineData, []byte("abc\x00\x00\x00xyz")) {
+		t.Fatalf("result mismatch: size(%v) data(%q)", ino.Size, ino.InlineData)
+	}
+	if status := ino.WriteInline(8, make([]byte, 9), 16, 1); status != proto.OpInlineFullErr {
+		t.Fatalf("write beyond limit, status(%v)", status)
+	}
+
+	// appending extents promotes the inode out of inline mode
+	ino.AppendExtents([]proto.ExtentKey{{FileOffset: 0, PartitionId: 1, ExtentId: 1, Size: 9}}, 2, proto.VolumeTypeHot)
+	if len(ino.InlineData) != 0 {
+		t.Fatalf("inline data not cleared after appending extents")
+	}
+	if status := ino.WriteInline(0, []byte("abc"), 16, 1); status != proto.OpInlineFullErr {
+		t.Fatalf("write inline to inode with extents, status(%v)", status)
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 const partitionPrefix = "partition_"
@@ -101,11 +102,27 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 
 	metric := exporter.NewTPCnt(p.GetOpMsg())
 	labels := m.getPacketLabels(p)
+	span := trace.StartSpan("metanode."+p.GetOpMsg(), p.TraceContext)
+	span.SetTag("partition_id", p.PartitionID)
+	span.SetTag("req_id", p.GetReqID())
+	opMetric := exporter.NewOpTP(p.GetOpMsg(), labels[exporter.Vol], span.TraceID())
 	defer func() {
 		metric.SetWithLabels(err, labels)
+		opMetric.Set(err)
+		span.Finish(err)
 	}()
 
+	if err = m.checkAuthority(conn, p); err != nil {
+		p.PacketErrorWithBody(proto.OpNotPerm, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("%s [%s] req: %d - %s", remoteAddr, p.GetOpMsg(),
+			p.GetReqID(), err.Error())
+		return
+	}
+
 	switch p.Opcode {
+	case proto.OpAuthenticate:
+		err = m.opAuthenticate(conn, p, remoteAddr)
 	case proto.OpMetaCreateInode:
 		err = m.opCreateInode(conn, p, remoteAddr)
 	case proto.OpMetaLinkInode:
@@ -148,6 +165,10 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opMetaExtentAddWithCheck(conn, p, remoteAddr)
 	case proto.OpMetaExtentsList:
 		err = m.opMetaExtentsList(conn, p, remoteAddr)
+	case proto.OpMetaInlineWrite:
+		err = m.opMetaInlineWrite(conn, p, remoteAddr)
+	case proto.OpMetaInlineRead:
+		err = m.opMetaInlineRead(conn, p, remoteAddr)
 	case proto.OpMetaObjExtentsList:
 		err = m.opMetaObjExtentsList(conn, p, remoteAddr)
 	case proto.OpMetaExtentsDel:
@@ -160,6 +181,8 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opDeleteMetaPartition(conn, p, remoteAddr)
 	case proto.OpUpdateMetaPartition:
 		err = m.opUpdateMetaPartition(conn, p, remoteAddr)
+	case proto.OpSplitMetaPartition:
+		err = m.opSplitMetaPartition(conn, p, remoteAddr)
 	case proto.OpLoadMetaPartition:
 		err = m.opLoadMetaPartition(conn, p, remoteAddr)
 	case proto.OpDecommissionMetaPartition:
@@ -182,6 +205,8 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 		err = m.opMetaBatchObjExtentsAdd(conn, p, remoteAddr)
 	case proto.OpMetaClearInodeCache:
 		err = m.opMetaClearInodeCache(conn, p, remoteAddr)
+	case proto.OpMetaObjExtentReplace:
+		err = m.opMetaObjExtentReplace(conn, p, remoteAddr)
 	// operations for extend attributes
 	case proto.OpMetaSetXAttr:
 		err = m.opMetaSetXAttr(conn, p, remoteAddr)
@@ -217,6 +242,42 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet, remo
 	return
 }
 
+// checkAuthority checks the requests to the volumes with authentication enabled. The
+// partition is resolved from the request as the handlers do, the commands from the
+// master require the master tickets, and the peers forwarding the requests and the commands to the
+// leaders present the service tickets.
+func (m *metadataManager) checkAuthority(conn net.Conn, p *Packet) (err error) {
+	authenticator := m.metaNode.authenticator
+	if authenticator == nil {
+		return
+	}
+	switch p.Opcode {
+	case proto.OpAuthenticate:
+		return
+	case proto.OpCreateMetaPartition, proto.OpMetaNodeHeartbeat, proto.OpDeleteMetaPartition,
+		proto.OpUpdateMetaPartition, proto.OpSplitMetaPartition, proto.OpLoadMetaPartition, proto.OpDecommissionMetaPartition,
+		proto.OpAddMetaPartitionRaftMember, proto.OpRemoveMetaPartitionRaftMember, proto.OpMetaPartitionTryToLeader:
+		return authenticator.AuthorizeMaster(conn)
+	}
+	partitionID := p.PartitionID
+	if p.Opcode != proto.OpMetaFreeInodesOnRaftFollower {
+		req := &struct {
+			PartitionID uint64 `json:"pid"`
+		}{}
+		if json.Unmarshal(p.Data, req) != nil {
+			// rejected by the handler
+			return
+		}
+		partitionID = req.PartitionID
+	}
+	mp, err := m.getPartition(partitionID)
+	if err != nil {
+		// rejected by the handler
+		return nil
+	}
+	return authenticator.Authorize(conn, mp.GetBaseConfig().VolName)
+}
+
 // Start starts the metadata manager.
 func (m *metadataManager) Start() (err error) {
 	if atomic.CompareAndSwapUint32(&m.state, common.StateStandby, common.StateStart) {
@@ -244,7 +305,8 @@ func (m *metadataManager) Stop() {
 
 // onStart creates the connection pool and loads the partitions.
 func (m *metadataManager) onStart() (err error) {
-	m.connPool = util.NewConnectPool()
+	// the requests forwarded to the peers are authenticated with the service tickets
+	m.connPool = util.NewConnectPoolWithHandshake(m.metaNode.authenticator.PeerCredential(proto.MetaNode).Handshake(proto.MetaServiceID))
 	err = m.loadPartitions()
 	return
 }
@@ -432,6 +494,7 @@ func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequ
 		End:         request.End,
 		Cursor:      request.Start,
 		Peers:       request.Members,
+		SplitFrom:   request.SplitFrom,
 		RaftStore:   m.raftStore,
 		NodeId:      m.nodeId,
 		RootDir:     path.Join(m.rootDir, partitionPrefix+partitionId),
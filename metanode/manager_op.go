@@ -34,6 +34,16 @@ const (
 	MaxUsedMemFactor = 1.1
 )
 
+// Authenticate request
+func (m *metadataManager) opAuthenticate(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	err = m.metaNode.authenticator.Authenticate(&p.Packet, conn)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opAuthenticate] req: %d, resp: %v", remoteAddr,
+		p.GetReqID(), p.GetResultMsg())
+	return
+}
+
 func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	// For ack to master
@@ -93,6 +103,7 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			return true
 		})
 		resp.ZoneName = m.zoneName
+		resp.SupportInlineData = true
 		resp.Status = proto.TaskSucceeds
 	end:
 		adminTask.Request = nil
@@ -560,7 +571,7 @@ func (m *metadataManager) opSetAttr(conn net.Conn, p *Packet,
 	if !m.serveProxy(conn, mp, p) {
 		return
 	}
-	if err = mp.SetAttr(p.Data, p); err != nil {
+	if err = mp.SetAttr(req, p.Data, p); err != nil {
 		err = errors.NewErrorf("[opSetAttr] req: %v, error: %s", req, err.Error())
 	}
 	m.respondToClient(conn, p)
@@ -684,6 +695,63 @@ func (m *metadataManager) opMetaExtentsList(conn net.Conn, p *Packet,
 	return
 }
 
+func (m *metadataManager) opMetaInlineWrite(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.InlineWriteRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.InlineWrite(req, p)
+	m.respondToClient(conn, p)
+	if err != nil {
+		log.LogErrorf("%s [opMetaInlineWrite] InlineWrite: %s, "+
+			"response to client: %s", remoteAddr, err.Error(), p.GetResultMsg())
+	}
+	log.LogDebugf("%s [opMetaInlineWrite] req: %d - ino(%v) off(%v) size(%v), resp: %v",
+		remoteAddr, p.GetReqID(), req.Inode, req.Offset, len(req.Data), p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaInlineRead(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.InlineReadRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+
+	err = mp.InlineRead(req, p)
+	m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaInlineRead] req: %d - %v; resp: %v",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg())
+	return
+}
+
 func (m *metadataManager) opMetaObjExtentsList(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	req := &proto.GetExtentsRequest{}
@@ -865,6 +933,58 @@ func (m *metadataManager) opUpdateMetaPartition(conn net.Conn, p *Packet,
 	return
 }
 
+func (m *metadataManager) opSplitMetaPartition(conn net.Conn, p *Packet,
+	remoteAddr string) (err error) {
+	req := &proto.SplitMetaPartitionRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+
+	// the seed and the check are served by the new partition
+	id := req.PartitionID
+	if req.Step == proto.SplitMetaPartitionSeed || req.Step == proto.SplitMetaPartitionCheck {
+		id = req.NewPartitionID
+	}
+	mp, err := m.getPartition(id)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	// every replica is checked by the master, the other steps go to the leader
+	if req.Step != proto.SplitMetaPartitionCheck && !m.serveProxy(conn, mp, p) {
+		return
+	}
+	switch req.Step {
+	case proto.SplitMetaPartitionSeed:
+		err = mp.SeedSplitPartition(req)
+	case proto.SplitMetaPartitionCheck:
+		err = mp.CheckSplitSeeded(req)
+	case proto.SplitMetaPartitionDrop:
+		err = mp.DropSplitPartition(req)
+	default:
+		err = mp.SplitPartition(req)
+	}
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+	} else {
+		p.PacketOkReply()
+	}
+	m.respondToClient(conn, p)
+	log.LogWarnf("%s [opSplitMetaPartition] req[%v], resp[%v].",
+		remoteAddr, req, p.GetResultMsg())
+	return
+}
+
 func (m *metadataManager) opLoadMetaPartition(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	req := &proto.MetaPartitionLoadRequest{}
@@ -1405,6 +1525,31 @@ func (m *metadataManager) opMetaBatchObjExtentsAdd(conn net.Conn, p *Packet, rem
 	return
 }
 
+func (m *metadataManager) opMetaObjExtentReplace(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.ReplaceObjExtentKeyRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.ObjExtentReplace(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaObjExtentReplace] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
 func (m *metadataManager) opCreateMultipart(conn net.Conn, p *Packet, remote string) (err error) {
 	req := &proto.CreateMultipartRequest{}
 	if err = json.Unmarshal(p.Data, req); err != nil {
@@ -34,6 +34,8 @@ import (
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 var (
@@ -65,6 +67,7 @@ type MetaNode struct {
 	metrics           *MetaNodeMetrics
 	tickInterval      int
 	raftRecvBufSize   int
+	authenticator     *packetauth.Authenticator
 
 	control common.Control
 }
@@ -144,6 +147,9 @@ func doStart(s common.Server, cfg *config.Config) (err error) {
 	go m.startUpdateNodeInfo()
 
 	exporter.Init(cfg.GetString("role"), cfg)
+	if err = trace.Init(cfg.GetString("role"), cfg); err != nil {
+		return
+	}
 	m.startStat()
 
 	// check local partition compare with master ,if lack,then not start
@@ -169,6 +175,7 @@ func doShutdown(s common.Server) {
 	m.stopSmuxServer()
 	m.stopMetaManager()
 	m.stopRaftServer()
+	trace.Stop()
 }
 
 // Sync blocks the invoker's goroutine until the meta node shuts down.
@@ -191,6 +198,13 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	m.tickInterval = int(cfg.GetFloat(cfgTickInterval))
 	m.raftRecvBufSize = int(cfg.GetInt(cfgRaftRecvBufSize))
 	m.zoneName = cfg.GetString(cfgZoneName)
+
+	var tlsTransport *util.TLSTransport
+	if tlsTransport, err = util.LoadTLSTransport(cfg); err != nil {
+		return
+	}
+	util.SetTLSTransport(tlsTransport)
+
 	configTotalMem, _ = strconv.ParseUint(cfg.GetString(cfgTotalMem), 10, 64)
 
 	if configTotalMem == 0 {
@@ -244,9 +258,6 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 
 	if err = m.parseSmuxConfig(cfg); err != nil {
 		return fmt.Errorf("parseSmuxConfig fail err %v", err)
-	} else {
-		log.LogInfof("Start: init smux conn pool (%v).", smuxPoolCfg)
-		smuxPool = util.NewSmuxConnectPool(smuxPoolCfg)
 	}
 
 	addrs := cfg.GetSlice(proto.MasterAddr)
@@ -255,6 +266,16 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 		masters = append(masters, addr.(string))
 	}
 	masterClient = masterSDK.NewMasterClient(masters, false)
+	if m.authenticator, err = packetauth.LoadAuthenticator(cfg, packetauth.ConfigKeyMetaServiceKey, proto.MetaServiceID, masterClient); err != nil {
+		return
+	}
+	// the extents are deleted with the service tickets of the datanodes
+	var dataCredential *packetauth.Credential
+	if dataCredential, err = packetauth.LoadServiceCredential(cfg, packetauth.ConfigKeyDataServiceKey, proto.DataServiceID, proto.MetaNode); err != nil {
+		return
+	}
+	log.LogInfof("Start: init smux conn pool (%v).", smuxPoolCfg)
+	smuxPool = util.NewSmuxConnectPoolWithHandshake(smuxPoolCfg, dataCredential.Handshake(proto.DataServiceID))
 	err = m.validConfig()
 	return
 }
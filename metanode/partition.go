@@ -73,6 +73,7 @@ type MetaPartitionConfig struct {
 	Start         uint64              `json:"start"` // Minimal Inode ID of this range. (Required during initialization)
 	End           uint64              `json:"end"`   // Maximal Inode ID of this range. (Required during initialization)
 	PartitionType int                 `json:"partition_type"`
+	SplitFrom     uint64              `json:"split_from"`
 	Peers         []proto.Peer        `json:"peers"` // Peers information of the raftStore
 	Cursor        uint64              `json:"-"`     // Cursor ID of the inode that have been assigned
 	NodeId        uint64              `json:"-"`
@@ -122,7 +123,7 @@ type OpInode interface {
 	CreateInodeLink(req *LinkInodeReq, p *Packet) (err error)
 	EvictInode(req *EvictInodeReq, p *Packet) (err error)
 	EvictInodeBatch(req *BatchEvictInodeReq, p *Packet) (err error)
-	SetAttr(reqData []byte, p *Packet) (err error)
+	SetAttr(req *SetattrRequest, reqData []byte, p *Packet) (err error)
 	GetInodeTree() *BTree
 	DeleteInode(req *proto.DeleteInodeRequest, p *Packet) (err error)
 	DeleteInodeBatch(req *proto.DeleteInodeBatchRequest, p *Packet) (err error)
@@ -160,6 +161,9 @@ type OpExtent interface {
 	ObjExtentsList(req *proto.GetExtentsRequest, p *Packet) (err error)
 	ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (err error)
 	BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error)
+	InlineWrite(req *proto.InlineWriteRequest, p *Packet) (err error)
+	InlineRead(req *proto.InlineReadRequest, p *Packet) (err error)
+	ObjExtentReplace(req *proto.ReplaceObjExtentKeyRequest, p *Packet) (err error)
 	// ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error)
 }
 
@@ -196,6 +200,10 @@ type OpPartition interface {
 	TryToLeader(groupID uint64) error
 	CanRemoveRaftMember(peer proto.Peer) error
 	IsEquareCreateMetaPartitionRequst(request *proto.CreateMetaPartitionRequest) (err error)
+	SplitPartition(req *proto.SplitMetaPartitionRequest) (err error)
+	SeedSplitPartition(req *proto.SplitMetaPartitionRequest) (err error)
+	CheckSplitSeeded(req *proto.SplitMetaPartitionRequest) (err error)
+	DropSplitPartition(req *proto.SplitMetaPartitionRequest) (err error)
 }
 
 // MetaPartition defines the interface for the meta partition operations.
@@ -238,6 +246,10 @@ type metaPartition struct {
 	ebsClient              *blobstore.BlobStoreClient
 	volType                int
 	xattrLock              sync.Mutex
+	storeLock              sync.Mutex
+
+	splitSeedIndex uint64 // the index at which the partition is seeded, until the seed is persisted
+	splitSeeding   int32  // set while the leader seeds the partition
 }
 
 func (mp *metaPartition) updateSize() {
@@ -572,6 +584,8 @@ func (mp *metaPartition) load() (err error) {
 }
 
 func (mp *metaPartition) store(sm *storeMsg) (err error) {
+	mp.storeLock.Lock()
+	defer mp.storeLock.Unlock()
 	tmpDir := path.Join(mp.config.RootDir, snapshotDirTmp)
 	if _, err = os.Stat(tmpDir); err == nil {
 		// TODO Unhandled errors
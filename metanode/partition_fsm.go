@@ -129,6 +129,24 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		resp, err = mp.fsmUpdatePartition(req.End)
+	case opFSMSplitPartition:
+		req := &proto.SplitMetaPartitionRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp, err = mp.fsmSplitPartition(req)
+	case opFSMSeedSplitPartition:
+		batch := &splitSeedBatch{}
+		if err = json.Unmarshal(msg.V, batch); err != nil {
+			return
+		}
+		resp, err = mp.fsmSeedSplitPartition(batch, index)
+	case opFSMDropSplitPartition:
+		req := &proto.SplitMetaPartitionRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp, err = mp.fsmDropSplitPartition(req)
 	case opFSMExtentsAdd:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -141,6 +159,18 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		resp = mp.fsmAppendExtentsWithCheck(ino)
+	case opFSMInlineWrite:
+		ino := NewInode(0, 0)
+		if err = ino.Unmarshal(msg.V); err != nil {
+			return
+		}
+		resp = mp.fsmInlineWrite(ino)
+	case opFSMObjExtentReplace:
+		req := &proto.ReplaceObjExtentKeyRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmReplaceObjExtent(req)
 	case opFSMObjExtentsAdd:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -306,6 +306,43 @@ func (mp *metaPartition) fsmAppendObjExtents(ino *Inode) (status uint8) {
 // 	return
 // }
 
+// fsmInlineWrite writes the inline data carried by ino, which ends at ino.Size.
+func (mp *metaPartition) fsmInlineWrite(ino *Inode) (status uint8) {
+	item := mp.inodeTree.CopyGet(ino)
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	i := item.(*Inode)
+	if i.ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	if !proto.IsRegular(i.Type) {
+		return proto.OpArgMismatchErr
+	}
+	offset := ino.Size - uint64(len(ino.InlineData))
+	return i.WriteInline(offset, ino.InlineData, proto.MaxInlineDataSize, ino.ModifyTime)
+}
+
+// fsmReplaceObjExtent swaps req.OldKey of the inode for req.NewKey. The file
+// content is unchanged, so only the generation is bumped.
+func (mp *metaPartition) fsmReplaceObjExtent(req *proto.ReplaceObjExtentKeyRequest) (status uint8) {
+	item := mp.inodeTree.CopyGet(NewInode(req.Inode, 0))
+	if item == nil {
+		return proto.OpNotExistErr
+	}
+	i := item.(*Inode)
+	if i.ShouldDelete() {
+		return proto.OpNotExistErr
+	}
+	i.Lock()
+	defer i.Unlock()
+	if !i.ObjExtents.Replace(req.OldKey, req.NewKey) {
+		return proto.OpConflictExtentsErr
+	}
+	i.Generation++
+	return proto.OpOk
+}
+
 func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 	resp = NewInodeResponse()
 
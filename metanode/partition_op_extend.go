@@ -23,6 +23,9 @@ import (
 )
 
 func (mp *metaPartition) UpdateXAttr(req *proto.UpdateXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	newValueList := strings.Split(req.Value, ",")
 	filesInc, _ := strconv.ParseInt(newValueList[0], 10, 64)
 	dirsInc, _ := strconv.ParseInt(newValueList[1], 10, 64)
@@ -75,6 +78,9 @@ func (mp *metaPartition) UpdateXAttr(req *proto.UpdateXAttrRequest, p *Packet) (
 }
 
 func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	var extend = NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), []byte(req.Value))
 	if _, err = mp.putExtend(opFSMSetXAttr, extend); err != nil {
@@ -86,6 +92,9 @@ func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err er
 }
 
 func (mp *metaPartition) GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	var response = &proto.GetXAttrResponse{
 		VolName:     req.VolName,
 		PartitionId: req.PartitionId,
@@ -110,6 +119,9 @@ func (mp *metaPartition) GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err er
 }
 
 func (mp *metaPartition) BatchGetXAttr(req *proto.BatchGetXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodesRange(req.Inodes, p) {
+		return
+	}
 	var response = &proto.BatchGetXAttrResponse{
 		VolName:     req.VolName,
 		PartitionId: req.PartitionId,
@@ -141,6 +153,9 @@ func (mp *metaPartition) BatchGetXAttr(req *proto.BatchGetXAttrRequest, p *Packe
 }
 
 func (mp *metaPartition) RemoveXAttr(req *proto.RemoveXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	var extend = NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), nil)
 	if _, err = mp.putExtend(opFSMRemoveXAttr, extend); err != nil {
@@ -152,6 +167,9 @@ func (mp *metaPartition) RemoveXAttr(req *proto.RemoveXAttrRequest, p *Packet) (
 }
 
 func (mp *metaPartition) ListXAttr(req *proto.ListXAttrRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	var response = &proto.ListXAttrResponse{
 		VolName:     req.VolName,
 		PartitionId: req.PartitionId,
@@ -24,6 +24,9 @@ import (
 
 // ExtentAppend appends an extent.
 func (mp *metaPartition) ExtentAppend(req *proto.AppendExtentKeyRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	if !proto.IsHot(mp.volType) {
 		err = fmt.Errorf("only support hot vol")
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
@@ -49,6 +52,9 @@ func (mp *metaPartition) ExtentAppend(req *proto.AppendExtentKeyRequest, p *Pack
 // ExtentAppendWithCheck appends an extent with discard extents check.
 // Format: one valid extent key followed by non or several discard keys.
 func (mp *metaPartition) ExtentAppendWithCheck(req *proto.AppendExtentKeyWithCheckRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	// check volume's Type: if volume's type is cold, cbfs' extent can be modify/add only when objextent exist
 	if proto.IsCold(mp.volType) {
@@ -100,6 +106,9 @@ func (mp *metaPartition) ExtentAppendWithCheck(req *proto.AppendExtentKeyWithChe
 
 // ExtentsList returns the list of extents.
 func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	retMsg := mp.getInode(ino)
 	ino = retMsg.Msg
@@ -130,6 +139,9 @@ func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (e
 
 // ObjExtentsList returns the list of obj extents and extents.
 func (mp *metaPartition) ObjExtentsList(req *proto.GetExtentsRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	retMsg := mp.getInode(ino)
 	ino = retMsg.Msg
@@ -162,8 +174,78 @@ func (mp *metaPartition) ObjExtentsList(req *proto.GetExtentsRequest, p *Packet)
 	return
 }
 
+// InlineWrite writes small file data inline into the inode.
+func (mp *metaPartition) InlineWrite(req *proto.InlineWriteRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
+	if !proto.IsHot(mp.volType) {
+		err = fmt.Errorf("only support hot vol")
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	if req.Offset+uint64(len(req.Data)) > proto.MaxInlineDataSize {
+		p.PacketErrorWithBody(proto.OpInlineFullErr, nil)
+		return
+	}
+
+	// The end of the write is carried in Size, the offset is derived from it.
+	ino := NewInode(req.Inode, 0)
+	ino.Size = req.Offset + uint64(len(req.Data))
+	ino.InlineData = req.Data
+	val, err := ino.Marshal()
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMInlineWrite, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
+// InlineRead returns the inline data of the inode.
+func (mp *metaPartition) InlineRead(req *proto.InlineReadRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
+	ino := NewInode(req.Inode, 0)
+	retMsg := mp.getInode(ino)
+	ino = retMsg.Msg
+	var (
+		reply  []byte
+		status = retMsg.Status
+	)
+
+	if status == proto.OpOk {
+		resp := &proto.InlineReadResponse{}
+		ino.DoReadFunc(func() {
+			resp.Generation = ino.Generation
+			resp.Size = ino.Size
+			// copied under the lock, as the inline writes modify it in place
+			if len(ino.InlineData) > 0 {
+				resp.Data = make([]byte, len(ino.InlineData))
+				copy(resp.Data, ino.InlineData)
+			}
+		})
+		reply, err = json.Marshal(resp)
+		if err != nil {
+			status = proto.OpErr
+			reply = []byte(err.Error())
+		}
+	}
+	p.PacketErrorWithBody(status, reply)
+	return
+}
+
 // ExtentsTruncate truncates an extent.
 func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	if !proto.IsHot(mp.volType) {
 		err = fmt.Errorf("only support hot vol")
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
@@ -188,6 +270,9 @@ func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (er
 }
 
 func (mp *metaPartition) BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	if !proto.IsHot(mp.volType) {
 		err = fmt.Errorf("only support hot vol")
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
@@ -214,6 +299,9 @@ func (mp *metaPartition) BatchExtentAppend(req *proto.AppendExtentKeysRequest, p
 }
 
 func (mp *metaPartition) BatchObjExtentAppend(req *proto.AppendObjExtentKeysRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	objExtents := req.Extents
 	for _, objExtent := range objExtents {
@@ -237,6 +325,31 @@ func (mp *metaPartition) BatchObjExtentAppend(req *proto.AppendObjExtentKeysRequ
 	return
 }
 
+// ObjExtentReplace swaps an obj extent key of the inode, used to move a
+// packed file slice into another shared blob.
+func (mp *metaPartition) ObjExtentReplace(req *proto.ReplaceObjExtentKeyRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
+	if req.OldKey.FileOffset != req.NewKey.FileOffset || req.OldKey.Size != req.NewKey.Size {
+		err = fmt.Errorf("replace obj extent key mismatch: old(%v) new(%v)", req.OldKey, req.NewKey)
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	val, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMObjExtentReplace, val)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketErrorWithBody(resp.(uint8), nil)
+	return
+}
+
 // func (mp *metaPartition) ExtentsDelete(req *proto.DelExtentKeyRequest, p *Packet) (err error) {
 // 	ino := NewInode(req.Inode, 0)
 // 	inode := mp.inodeTree.Get(ino).(*Inode)
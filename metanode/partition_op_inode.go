@@ -90,6 +90,9 @@ func (mp *metaPartition) CreateInode(req *CreateInoReq, p *Packet) (err error) {
 
 // DeleteInode deletes an inode.
 func (mp *metaPartition) UnlinkInode(req *UnlinkInoReq, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -124,6 +127,9 @@ func (mp *metaPartition) UnlinkInodeBatch(req *BatchUnlinkInoReq, p *Packet) (er
 	if len(req.Inodes) == 0 {
 		return nil
 	}
+	if !mp.checkInodesRange(req.Inodes, p) {
+		return
+	}
 
 	var inodes InodeBatch
 
@@ -171,6 +177,9 @@ func (mp *metaPartition) UnlinkInodeBatch(req *BatchUnlinkInoReq, p *Packet) (er
 
 // InodeGet executes the inodeGet command from the client.
 func (mp *metaPartition) InodeGet(req *InodeGetReq, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	retMsg := mp.getInode(ino)
 	ino = retMsg.Msg
@@ -197,6 +206,9 @@ func (mp *metaPartition) InodeGet(req *InodeGetReq, p *Packet) (err error) {
 
 // InodeGetBatch executes the inodeBatchGet command from the client.
 func (mp *metaPartition) InodeGetBatch(req *InodeGetReqBatch, p *Packet) (err error) {
+	if !mp.checkInodesRange(req.Inodes, p) {
+		return
+	}
 	resp := &proto.BatchInodeGetResponse{}
 	ino := NewInode(0, 0)
 	for _, inoId := range req.Inodes {
@@ -220,6 +232,9 @@ func (mp *metaPartition) InodeGetBatch(req *InodeGetReqBatch, p *Packet) (err er
 
 // CreateInodeLink creates an inode link (e.g., soft link).
 func (mp *metaPartition) CreateInodeLink(req *LinkInodeReq, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -254,6 +269,9 @@ func (mp *metaPartition) CreateInodeLink(req *LinkInodeReq, p *Packet) (err erro
 
 // EvictInode evicts an inode.
 func (mp *metaPartition) EvictInode(req *EvictInodeReq, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	val, err := ino.Marshal()
 	if err != nil {
@@ -276,6 +294,9 @@ func (mp *metaPartition) EvictInodeBatch(req *BatchEvictInodeReq, p *Packet) (er
 	if len(req.Inodes) == 0 {
 		return nil
 	}
+	if !mp.checkInodesRange(req.Inodes, p) {
+		return
+	}
 
 	var inodes InodeBatch
 
@@ -306,7 +327,10 @@ func (mp *metaPartition) EvictInodeBatch(req *BatchEvictInodeReq, p *Packet) (er
 }
 
 // SetAttr set the inode attributes.
-func (mp *metaPartition) SetAttr(reqData []byte, p *Packet) (err error) {
+func (mp *metaPartition) SetAttr(req *SetattrRequest, reqData []byte, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	_, err = mp.submit(opFSMSetAttr, reqData)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
@@ -322,6 +346,9 @@ func (mp *metaPartition) GetInodeTree() *BTree {
 }
 
 func (mp *metaPartition) DeleteInode(req *proto.DeleteInodeRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	var bytes = make([]byte, 8)
 	binary.BigEndian.PutUint64(bytes, req.Inode)
 	_, err = mp.submit(opFSMInternalDeleteInode, bytes)
@@ -337,6 +364,9 @@ func (mp *metaPartition) DeleteInodeBatch(req *proto.DeleteInodeBatchRequest, p
 	if len(req.Inodes) == 0 {
 		return nil
 	}
+	if !mp.checkInodesRange(req.Inodes, p) {
+		return
+	}
 
 	var inodes InodeBatch
 
@@ -360,6 +390,9 @@ func (mp *metaPartition) DeleteInodeBatch(req *proto.DeleteInodeBatchRequest, p
 
 // ClearInodeCache clear a inode's cbfs extent but keep ebs extent.
 func (mp *metaPartition) ClearInodeCache(req *proto.ClearInodeCacheRequest, p *Packet) (err error) {
+	if !mp.checkInodeRange(req.Inode, p) {
+		return
+	}
 	if len(mp.extDelCh) > defaultDelExtentsCnt-100 {
 		err = fmt.Errorf("extent del chan full")
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
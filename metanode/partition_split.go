@@ -0,0 +1,411 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// A meta partition is split in the following steps:
+//  1. The master creates the new partition for (End, oldEnd] on the hosts of the partition,
+//     with SplitFrom set so that the new partition knows it is waiting for the items to be moved in.
+//  2. The master asks the leader to cut the end of the partition at End through its raft log.
+//     The items beyond End are kept, but the requests on them are rejected from now on.
+//  3. The master asks the leader of the new partition to seed it. The leader reads the inodes beyond End,
+//     the dentries whose parent is beyond End and the extended attributes of the moved inodes from its local
+//     replica of the split partition, and submits them through the raft log of the new partition, so every
+//     replica of the new partition applies the same items by its own apply goroutine.
+//  4. The master checks that every replica of the new partition has persisted the seed,
+//     then commits the new layout, and the clients refresh their view. A request routed with the
+//     old view is rejected with OpInodeOutOfRangeErr, which makes the client refresh the view at once.
+//  5. The master asks the leader to drop the items beyond End through the raft log of the split partition.
+
+// splitSeedBatchSize is the number of items submitted in a seed batch.
+const splitSeedBatchSize = 1024
+
+// splitSeedBatch carries a batch of the items moved into the new partition. The last batch marks the new
+// partition as seeded, and the batches applied after it are ignored, so a retry of the seed never brings
+// back an item deleted from the new partition.
+type splitSeedBatch struct {
+	SplitFrom uint64
+	Inodes    []byte
+	Dentries  []byte
+	Extends   [][]byte
+	Last      bool
+}
+
+// SplitPartition cuts the end of the partition at req.End through the raft log.
+func (mp *metaPartition) SplitPartition(req *proto.SplitMetaPartitionRequest) (err error) {
+	target, err := mp.splitTarget(req)
+	if err != nil {
+		return
+	}
+	if mp.config.End == req.End {
+		// already cut, the master is retrying
+		return
+	}
+	if target.config.SplitFrom != mp.config.PartitionId {
+		err = fmt.Errorf("partition %v is not created for the split of partition %v",
+			req.NewPartitionID, mp.config.PartitionId)
+		return
+	}
+	return mp.submitSplit(opFSMSplitPartition, req)
+}
+
+// DropSplitPartition drops the items moved out of the partition through the raft log.
+func (mp *metaPartition) DropSplitPartition(req *proto.SplitMetaPartitionRequest) (err error) {
+	if mp.config.End != req.End {
+		err = fmt.Errorf("partition %v range[%v,%v] is not split at %v",
+			mp.config.PartitionId, mp.config.Start, mp.config.End, req.End)
+		return
+	}
+	return mp.submitSplit(opFSMDropSplitPartition, req)
+}
+
+func (mp *metaPartition) submitSplit(op uint32, req *proto.SplitMetaPartitionRequest) (err error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(op, data)
+	if err != nil {
+		return
+	}
+	if status := r.(uint8); status != proto.OpOk {
+		p := &Packet{}
+		p.ResultCode = status
+		err = errors.NewErrorf("[submitSplit]: %s", p.GetResultMsg())
+	}
+	return
+}
+
+// splitTarget returns the local replica of the partition that the items beyond req.End are moved into.
+func (mp *metaPartition) splitTarget(req *proto.SplitMetaPartitionRequest) (target *metaPartition, err error) {
+	if req.End < mp.config.Start || req.End > mp.config.End {
+		err = fmt.Errorf("split point %v out of range [%v,%v]", req.End, mp.config.Start, mp.config.End)
+		return
+	}
+	if target, err = mp.manager.localPartition(req.NewPartitionID); err != nil {
+		return
+	}
+	conf := target.config
+	if conf.VolName != mp.config.VolName || conf.Start != req.End+1 ||
+		(req.End < mp.config.End && conf.End != mp.config.End) {
+		err = fmt.Errorf("meta partition %v vol[%v] range[%v,%v] does not match the split of %v at %v",
+			req.NewPartitionID, conf.VolName, conf.Start, conf.End, mp.config.PartitionId, req.End)
+	}
+	return
+}
+
+func (m *metadataManager) localPartition(id uint64) (mp *metaPartition, err error) {
+	partition, err := m.getPartition(id)
+	if err != nil {
+		return
+	}
+	mp, ok := partition.(*metaPartition)
+	if !ok {
+		err = fmt.Errorf("unknown meta partition type of %v", id)
+	}
+	return
+}
+
+// fsmSplitPartition cuts the end of the partition. The end is persisted at once, so a replay of the log
+// after a restart finds the end already cut.
+func (mp *metaPartition) fsmSplitPartition(req *proto.SplitMetaPartitionRequest) (status uint8, err error) {
+	status = proto.OpOk
+	if req.End >= mp.config.End {
+		return
+	}
+	if req.End < mp.config.Start {
+		log.LogErrorf("[fsmSplitPartition] partition(%v) split point %v out of range [%v,%v]",
+			mp.config.PartitionId, req.End, mp.config.Start, mp.config.End)
+		status = proto.OpArgMismatchErr
+		return
+	}
+	oldEnd := mp.config.End
+	mp.config.End = req.End
+	if err = mp.PersistMetadata(); err != nil {
+		mp.config.End = oldEnd
+		status = proto.OpDiskErr
+		return
+	}
+	log.LogWarnf("[fsmSplitPartition] partition(%v) range[%v,%v] split at %v into partition(%v)",
+		mp.config.PartitionId, mp.config.Start, oldEnd, req.End, req.NewPartitionID)
+	return
+}
+
+// fsmDropSplitPartition drops the inodes beyond the end, the dentries whose parent is beyond the end and
+// the extended attributes of the dropped inodes, which have been moved into the new partition.
+func (mp *metaPartition) fsmDropSplitPartition(req *proto.SplitMetaPartitionRequest) (status uint8, err error) {
+	status = proto.OpOk
+	if req.End != mp.config.End {
+		log.LogWarnf("[fsmDropSplitPartition] partition(%v) range[%v,%v] is not split at %v",
+			mp.config.PartitionId, mp.config.Start, mp.config.End, req.End)
+		return
+	}
+	inodes, dentries, extends := splitItems(mp.inodeTree, mp.dentryTree, mp.extendTree, req.End)
+	for _, ino := range inodes {
+		mp.inodeTree.Delete(ino)
+	}
+	for _, dentry := range dentries {
+		mp.dentryTree.Delete(dentry)
+	}
+	for _, extend := range extends {
+		mp.extendTree.Delete(extend)
+	}
+	log.LogWarnf("[fsmDropSplitPartition] partition(%v) split at %v into partition(%v): inodes(%v) dentries(%v) extends(%v)",
+		mp.config.PartitionId, req.End, req.NewPartitionID, len(inodes), len(dentries), len(extends))
+	return
+}
+
+// splitItems returns the items moved out of a partition split at end.
+func splitItems(inodeTree, dentryTree, extendTree *BTree, end uint64) (inodes InodeBatch, dentries DentryBatch, extends []*Extend) {
+	inodeTree.AscendGreaterOrEqual(&Inode{Inode: end + 1}, func(i BtreeItem) bool {
+		inodes = append(inodes, i.(*Inode))
+		return true
+	})
+	dentryTree.AscendGreaterOrEqual(&Dentry{ParentId: end + 1}, func(i BtreeItem) bool {
+		dentries = append(dentries, i.(*Dentry))
+		return true
+	})
+	extendTree.AscendGreaterOrEqual(NewExtend(end+1), func(i BtreeItem) bool {
+		extends = append(extends, i.(*Extend))
+		return true
+	})
+	return
+}
+
+// SeedSplitPartition starts to seed the partition from the local replica of the partition it is split from,
+// through the raft log of the partition. It is called on the leader, and the master checks every replica
+// for the end of the seed, as the seed of a large partition takes longer than an admin task.
+func (mp *metaPartition) SeedSplitPartition(req *proto.SplitMetaPartitionRequest) (err error) {
+	if mp.config.SplitFrom != 0 && mp.config.SplitFrom != req.PartitionID {
+		err = fmt.Errorf("partition %v is not created for the split of partition %v",
+			mp.config.PartitionId, req.PartitionID)
+		return
+	}
+	var source *metaPartition
+	if source, err = mp.manager.localPartition(req.PartitionID); err != nil {
+		return
+	}
+	if source.config.End != req.End {
+		err = fmt.Errorf("partition %v range[%v,%v] has not applied the split at %v yet",
+			req.PartitionID, source.config.Start, source.config.End, req.End)
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&mp.splitSeeding, 0, 1) {
+		// the seed is in progress
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&mp.splitSeeding, 0)
+		if err := mp.seedSplitPartition(source, req); err != nil {
+			log.LogErrorf("[SeedSplitPartition] partition(%v) seed from partition(%v) at %v: %v",
+				mp.config.PartitionId, req.PartitionID, req.End, err)
+		}
+	}()
+	return
+}
+
+func (mp *metaPartition) seedSplitPartition(source *metaPartition, req *proto.SplitMetaPartitionRequest) (err error) {
+	if mp.config.SplitFrom != 0 && atomic.LoadUint64(&mp.splitSeedIndex) == 0 {
+		var batches []*splitSeedBatch
+		if batches, err = splitSeedBatches(source, req); err != nil {
+			return
+		}
+		for _, batch := range batches {
+			if err = mp.submitSeed(batch); err != nil {
+				return
+			}
+		}
+	}
+	// The last batch is submitted even if the leader is seeded, for the replicas restored from a snapshot of the
+	// leader which have not applied the last batch.
+	return mp.submitSeed(&splitSeedBatch{SplitFrom: req.PartitionID, Last: true})
+}
+
+// splitSeedBatches returns the batches of the items moved out of the source partition split at req.End.
+func splitSeedBatches(source *metaPartition, req *proto.SplitMetaPartitionRequest) (batches []*splitSeedBatch, err error) {
+	inodes, dentries, extends := splitItems(source.inodeTree.GetTree(), source.dentryTree.GetTree(),
+		source.extendTree.GetTree(), req.End)
+	for len(inodes) > 0 || len(dentries) > 0 || len(extends) > 0 {
+		n := len(inodes)
+		if n > splitSeedBatchSize {
+			n = splitSeedBatchSize
+		}
+		batch := &splitSeedBatch{SplitFrom: req.PartitionID}
+		if batch.Inodes, err = inodes[:n].Marshal(); err != nil {
+			return
+		}
+		inodes = inodes[n:]
+		if n = len(dentries); n > splitSeedBatchSize {
+			n = splitSeedBatchSize
+		}
+		if batch.Dentries, err = dentries[:n].Marshal(); err != nil {
+			return
+		}
+		dentries = dentries[n:]
+		if n = len(extends); n > splitSeedBatchSize {
+			n = splitSeedBatchSize
+		}
+		for _, extend := range extends[:n] {
+			var raw []byte
+			if raw, err = extend.Bytes(); err != nil {
+				return
+			}
+			batch.Extends = append(batch.Extends, raw)
+		}
+		extends = extends[n:]
+		batches = append(batches, batch)
+	}
+	return
+}
+
+func (mp *metaPartition) submitSeed(batch *splitSeedBatch) (err error) {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return
+	}
+	r, err := mp.submit(opFSMSeedSplitPartition, data)
+	if err != nil {
+		return
+	}
+	if status := r.(uint8); status != proto.OpOk {
+		p := &Packet{}
+		p.ResultCode = status
+		err = errors.NewErrorf("[submitSeed]: %s", p.GetResultMsg())
+	}
+	return
+}
+
+func (mp *metaPartition) fsmSeedSplitPartition(batch *splitSeedBatch, index uint64) (status uint8, err error) {
+	status = proto.OpOk
+	if mp.config.SplitFrom == 0 || mp.config.SplitFrom != batch.SplitFrom || atomic.LoadUint64(&mp.splitSeedIndex) != 0 {
+		return
+	}
+	var (
+		inodes   InodeBatch
+		dentries DentryBatch
+		extends  = make([]*Extend, 0, len(batch.Extends))
+	)
+	if len(batch.Inodes) > 0 {
+		if inodes, err = InodeBatchUnmarshal(batch.Inodes); err != nil {
+			status = proto.OpArgMismatchErr
+			return
+		}
+	}
+	if len(batch.Dentries) > 0 {
+		if dentries, err = DentryBatchUnmarshal(batch.Dentries); err != nil {
+			status = proto.OpArgMismatchErr
+			return
+		}
+	}
+	for _, raw := range batch.Extends {
+		var extend *Extend
+		if extend, err = NewExtendFromBytes(raw); err != nil {
+			status = proto.OpArgMismatchErr
+			return
+		}
+		extends = append(extends, extend)
+	}
+
+	for _, ino := range inodes {
+		if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
+			continue
+		}
+		if mp.config.Cursor < ino.Inode {
+			mp.config.Cursor = ino.Inode
+		}
+		mp.checkAndInsertFreeList(ino)
+	}
+	for _, dentry := range dentries {
+		mp.dentryTree.ReplaceOrInsert(dentry, false)
+	}
+	for _, extend := range extends {
+		mp.extendTree.ReplaceOrInsert(extend, false)
+	}
+	if !batch.Last {
+		return
+	}
+
+	// SplitFrom is cleared on the disk only after a snapshot including the seed is stored,
+	// otherwise the batches replayed from the log after a restart would be ignored.
+	atomic.StoreUint64(&mp.splitSeedIndex, index)
+	mp.storeChan <- &storeMsg{
+		command:       opFSMStoreTick,
+		applyIndex:    index,
+		inodeTree:     mp.inodeTree.GetTree(),
+		dentryTree:    mp.dentryTree.GetTree(),
+		extendTree:    mp.extendTree.GetTree(),
+		multipartTree: mp.multipartTree.GetTree(),
+	}
+	log.LogWarnf("[fsmSeedSplitPartition] partition(%v) seeded from partition(%v) at index(%v) cursor(%v)",
+		mp.config.PartitionId, batch.SplitFrom, index, mp.config.Cursor)
+	return
+}
+
+// persistSplitSeeded clears SplitFrom on the disk once the snapshot stored at applyIndex includes the seed.
+func (mp *metaPartition) persistSplitSeeded(applyIndex uint64) {
+	index := atomic.LoadUint64(&mp.splitSeedIndex)
+	if index == 0 || applyIndex < index {
+		return
+	}
+	splitFrom := mp.config.SplitFrom
+	mp.config.SplitFrom = 0
+	if err := mp.PersistMetadata(); err != nil {
+		mp.config.SplitFrom = splitFrom
+		log.LogErrorf("[persistSplitSeeded] partition(%v) split from partition(%v): %v",
+			mp.config.PartitionId, splitFrom, err)
+		return
+	}
+	atomic.StoreUint64(&mp.splitSeedIndex, 0)
+	log.LogWarnf("[persistSplitSeeded] partition(%v) split from partition(%v) is seeded at index(%v)",
+		mp.config.PartitionId, splitFrom, index)
+}
+
+// CheckSplitSeeded returns an error unless the local replica of the partition has persisted its seed.
+func (mp *metaPartition) CheckSplitSeeded(req *proto.SplitMetaPartitionRequest) (err error) {
+	if splitFrom := mp.config.SplitFrom; splitFrom != 0 {
+		err = fmt.Errorf("partition %v split from partition %v is not seeded yet", mp.config.PartitionId, splitFrom)
+	}
+	return
+}
+
+// checkInodeRange rejects a request on an inode out of the range of the partition, which is routed
+// with a view fetched by the client before the partition was split.
+func (mp *metaPartition) checkInodeRange(ino uint64, p *Packet) (ok bool) {
+	if ino >= mp.config.Start && ino <= mp.config.End {
+		return true
+	}
+	err := fmt.Errorf("inode %v out of range [%v,%v] of partition %v", ino, mp.config.Start, mp.config.End, mp.config.PartitionId)
+	p.PacketErrorWithBody(proto.OpInodeOutOfRangeErr, []byte(err.Error()))
+	return false
+}
+
+// checkInodesRange rejects a batch request if any of its inodes is out of the range of the partition.
+func (mp *metaPartition) checkInodesRange(inodes []uint64, p *Packet) (ok bool) {
+	for _, ino := range inodes {
+		if !mp.checkInodeRange(ino, p) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func newSplitTestPartition(m *metadataManager, rootDir string, id, start, end, splitFrom uint64) *metaPartition {
+	conf := &MetaPartitionConfig{
+		PartitionId: id,
+		VolName:     "testVol",
+		Start:       start,
+		End:         end,
+		Cursor:      start,
+		SplitFrom:   splitFrom,
+		Peers:       []proto.Peer{{ID: 1, Addr: "127.0.0.1:17210"}},
+		RootDir:     path.Join(rootDir, fmt.Sprintf("%s%d", partitionPrefix, id)),
+	}
+	mp := NewMetaPartition(conf, m).(*metaPartition)
+	m.partitions[id] = mp
+	return mp
+}
+
+func TestMetaPartition_Split(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "mp_split")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootDir)
+
+	m := &metadataManager{partitions: make(map[uint64]MetaPartition)}
+	src := newSplitTestPartition(m, rootDir, 1, 1, 1000, 0)
+	dst := newSplitTestPartition(m, rootDir, 2, 501, 1000, 1)
+
+	for _, ino := range []uint64{1, 100, 500, 501, 800} {
+		src.inodeTree.ReplaceOrInsert(NewInode(ino, proto.Mode(os.ModeDir)), true)
+		src.extendTree.ReplaceOrInsert(NewExtend(ino), true)
+	}
+	src.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "a", Inode: 800}, true)
+	src.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 501, Name: "b", Inode: 100}, true)
+	src.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 800, Name: "c", Inode: 501}, true)
+
+	req := &proto.SplitMetaPartitionRequest{PartitionID: 1, VolName: "testVol", End: 500, NewPartitionID: 2}
+	if status, err := src.fsmSplitPartition(req); err != nil || status != proto.OpOk {
+		t.Fatalf("split: status(%v) err(%v)", status, err)
+	}
+	if src.config.End != 500 || src.inodeTree.Len() != 5 {
+		t.Fatalf("unexpected source after the cut: config(%v) inodes(%v)", src.config, src.inodeTree.Len())
+	}
+
+	// the seed is applied by the new partition from its own raft log
+	batches, err := splitSeedBatches(src, req)
+	if err != nil || len(batches) != 1 {
+		t.Fatalf("seed batches(%v) err(%v)", len(batches), err)
+	}
+	batches = append(batches, &splitSeedBatch{SplitFrom: 1, Last: true})
+	for i, batch := range batches {
+		if status, err := dst.fsmSeedSplitPartition(batch, uint64(10+i)); err != nil || status != proto.OpOk {
+			t.Fatalf("seed: status(%v) err(%v)", status, err)
+		}
+	}
+	if dst.inodeTree.Len() != 2 || dst.dentryTree.Len() != 2 || dst.extendTree.Len() != 2 || dst.config.Cursor != 800 {
+		t.Fatalf("unexpected target: inodes(%v) dentries(%v) extends(%v) cursor(%v)",
+			dst.inodeTree.Len(), dst.dentryTree.Len(), dst.extendTree.Len(), dst.config.Cursor)
+	}
+	if sm := <-dst.storeChan; sm.applyIndex != 11 || sm.inodeTree.Len() != 2 {
+		t.Fatalf("unexpected store of the seed: %v", sm)
+	}
+
+	// the seed is persisted only after it is stored, and a batch applied after the last one is ignored
+	dst.persistSplitSeeded(10)
+	if dst.CheckSplitSeeded(req) == nil {
+		t.Fatalf("seed should not be persisted before it is stored")
+	}
+	dst.inodeTree.Delete(&Inode{Inode: 501})
+	if status, err := dst.fsmSeedSplitPartition(batches[0], 12); err != nil || status != proto.OpOk {
+		t.Fatalf("late seed: status(%v) err(%v)", status, err)
+	}
+	dst.persistSplitSeeded(11)
+	if dst.CheckSplitSeeded(req) != nil || dst.inodeTree.Len() != 1 {
+		t.Fatalf("unexpected target: config(%v) inodes(%v)", dst.config, dst.inodeTree.Len())
+	}
+
+	// the moved items are dropped from the source once the new layout is committed
+	for i := 0; i < 2; i++ {
+		if status, err := src.fsmDropSplitPartition(req); err != nil || status != proto.OpOk {
+			t.Fatalf("drop: status(%v) err(%v)", status, err)
+		}
+	}
+	if src.inodeTree.Len() != 3 || src.dentryTree.Len() != 1 || src.extendTree.Len() != 3 {
+		t.Fatalf("unexpected source: inodes(%v) dentries(%v) extends(%v)",
+			src.inodeTree.Len(), src.dentryTree.Len(), src.extendTree.Len())
+	}
+
+	p := &Packet{}
+	if src.checkInodeRange(501, p) || p.ResultCode != proto.OpInodeOutOfRangeErr {
+		t.Fatalf("inode beyond the end should be rejected, result(%v)", p.GetResultMsg())
+	}
+	if !src.checkInodeRange(500, &Packet{}) || !dst.checkInodeRange(501, &Packet{}) {
+		t.Fatalf("inode in range should be accepted")
+	}
+
+	mismatch := &proto.SplitMetaPartitionRequest{PartitionID: 1, VolName: "testVol", End: 0, NewPartitionID: 2}
+	if status, _ := src.fsmSplitPartition(mismatch); status != proto.OpArgMismatchErr {
+		t.Fatalf("split out of the range should fail, status(%v)", status)
+	}
+}
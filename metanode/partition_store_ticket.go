@@ -52,6 +52,7 @@ func (mp *metaPartition) startSchedule(curIndex uint64) {
 					" truncate raft log")
 			}
 			curIndex = msg.applyIndex
+			mp.persistSplitSeeded(msg.applyIndex)
 		} else {
 			// retry again
 			mp.storeChan <- msg
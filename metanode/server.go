@@ -32,6 +32,9 @@ func (m *MetaNode) startServer() (err error) {
 	if err != nil {
 		return
 	}
+	if ln, err = util.GetTLSTransport().NewListener(ln); err != nil {
+		return
+	}
 	go func(stopC chan uint8) {
 		defer ln.Close()
 		for {
@@ -65,9 +68,11 @@ func (m *MetaNode) stopServer() {
 // Read data from the specified tcp connection until the connection is closed by the remote or the tcp service is down.
 func (m *MetaNode) serveConn(conn net.Conn, stopC chan uint8) {
 	defer conn.Close()
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := conn.(*net.TCPConn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
+	conn = m.authenticator.NewConn(conn)
 	remoteAddr := conn.RemoteAddr().String()
 	for {
 		select {
@@ -103,6 +108,9 @@ func (m *MetaNode) startSmuxServer() (err error) {
 	if err != nil {
 		return
 	}
+	if ln, err = util.GetTLSTransport().NewListener(ln); err != nil {
+		return
+	}
 	go func(stopC chan uint8) {
 		defer ln.Close()
 		for {
@@ -140,9 +148,10 @@ func (m *MetaNode) stopSmuxServer() {
 
 func (m *MetaNode) serveSmuxConn(conn net.Conn, stopC chan uint8) {
 	defer conn.Close()
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
+	if c, ok := conn.(*net.TCPConn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
 	remoteAddr := conn.RemoteAddr().String()
 
 	var sess *smux.Session
@@ -175,6 +184,7 @@ func (m *MetaNode) serveSmuxConn(conn net.Conn, stopC chan uint8) {
 }
 
 func (m *MetaNode) serveSmuxStream(stream *smux.Stream, remoteAddr string, stopC chan uint8) {
+	conn := m.authenticator.NewConn(stream)
 	for {
 		select {
 		case <-stopC:
@@ -183,13 +193,13 @@ func (m *MetaNode) serveSmuxStream(stream *smux.Stream, remoteAddr string, stopC
 		}
 
 		p := &Packet{}
-		if err := p.ReadFromConn(stream, proto.NoReadDeadlineTime); err != nil {
+		if err := p.ReadFromConn(conn, proto.NoReadDeadlineTime); err != nil {
 			if err != io.EOF {
 				log.LogError("serve MetaNode: ", err.Error())
 			}
 			return
 		}
-		if err := m.handlePacket(stream, p, remoteAddr); err != nil {
+		if err := m.handlePacket(conn, p, remoteAddr); err != nil {
 			log.LogErrorf("serve handlePacket fail: %v", err)
 		}
 	}
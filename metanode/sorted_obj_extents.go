@@ -98,6 +98,26 @@ func (se *SortedObjExtents) Append(ek proto.ObjExtentKey) (err error) {
 	return
 }
 
+// Replace swaps the key equal to oldKey for newKey, which must cover the same
+// file range. It returns false if oldKey is not found.
+func (se *SortedObjExtents) Replace(oldKey, newKey proto.ObjExtentKey) bool {
+	if oldKey.FileOffset != newKey.FileOffset || oldKey.Size != newKey.Size {
+		return false
+	}
+	se.Lock()
+	defer se.Unlock()
+	for i := len(se.eks) - 1; i >= 0; i-- {
+		if se.eks[i].FileOffset < oldKey.FileOffset {
+			break
+		}
+		if oldKey.IsEquals(&se.eks[i]) {
+			se.eks[i] = newKey
+			return true
+		}
+	}
+	return false
+}
+
 func (se *SortedObjExtents) Clone() *SortedObjExtents {
 	newSe := NewSortedObjExtents()
 
@@ -0,0 +1,88 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+)
+
+func newPackedObjExtentKey(fileOffset, size, blobOffset, minBid uint64) proto.ObjExtentKey {
+	blobs := []proto.Blob{{MinBid: minBid, Count: 1, Vid: 1}}
+	return proto.ObjExtentKey{
+		FileOffset: fileOffset,
+		Size:       size,
+		Blobs:      blobs,
+		BlobsLen:   uint32(len(blobs)),
+		BlobOffset: blobOffset,
+		PackSize:   1 << 20,
+	}
+}
+
+func TestSortedObjExtents_Replace(t *testing.T) {
+	se := NewSortedObjExtents()
+	old := newPackedObjExtentKey(0, 100, 4096, 1)
+	if err := se.Append(old); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	if se.Replace(newPackedObjExtentKey(0, 100, 0, 1), newPackedObjExtentKey(0, 100, 0, 2)) {
+		t.Fatalf("replace of a missing key should fail")
+	}
+	if se.Replace(old, newPackedObjExtentKey(0, 200, 0, 2)) {
+		t.Fatalf("replace with a different size should fail")
+	}
+
+	nek := newPackedObjExtentKey(0, 100, 512, 2)
+	if !se.Replace(old, nek) {
+		t.Fatalf("replace failed, eks: %v", se)
+	}
+	if !se.eks[0].IsEquals(&nek) || se.Size() != 100 {
+		t.Fatalf("unexpected eks after replace: %v", se)
+	}
+}
+
+func TestSortedObjExtents_PackedMarshal(t *testing.T) {
+	se := NewSortedObjExtents()
+	se.Append(newPackedObjExtentKey(0, 100, 4096, 1))
+	se.Append(proto.ObjExtentKey{FileOffset: 100, Size: 50, Blobs: []proto.Blob{{MinBid: 3, Count: 1, Vid: 1}}, BlobsLen: 1})
+
+	data, err := se.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	se2 := NewSortedObjExtents()
+	if err = se2.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(se2.eks) != 2 {
+		t.Fatalf("unexpected eks: %v", se2)
+	}
+	for i := range se.eks {
+		if !se.eks[i].IsEquals(&se2.eks[i]) {
+			t.Fatalf("key %d mismatch: %v vs %v", i, se.eks[i], se2.eks[i])
+		}
+	}
+	if !se2.eks[0].IsPacked() || se2.eks[1].IsPacked() || se2.eks[0].BlobsLen != 1 {
+		t.Fatalf("packed flag not restored: %v", se2)
+	}
+
+	ek := proto.ObjExtentKey{}
+	if err = ek.UnmarshalBinary(bytes.NewBuffer(data)); err != nil || ek.BlobOffset != 4096 {
+		t.Fatalf("unmarshal single key: %v %v", err, ek)
+	}
+}
@@ -15,6 +15,7 @@
 package objectnode
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
@@ -38,6 +39,20 @@ func GetRequestID(r *http.Request) (id string) {
 	return mux.Vars(r)[ContextKeyRequestID]
 }
 
+type contextKey string
+
+const contextKeyPostPolicyForm contextKey = "ctx_post_policy_form"
+
+// SetPostPolicyForm returns a shallow copy of the request carrying the form of a POST upload.
+func SetPostPolicyForm(r *http.Request, form *postPolicyForm) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), contextKeyPostPolicyForm, form))
+}
+
+func GetPostPolicyForm(r *http.Request) *postPolicyForm {
+	form, _ := r.Context().Value(contextKeyPostPolicyForm).(*postPolicyForm)
+	return form
+}
+
 func SetRequestAction(r *http.Request, action proto.Action) {
 	mux.Vars(r)[ContextKeyRequestAction] = action.String()
 }
@@ -22,6 +22,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"regexp"
 	"sort"
 	"strconv"
@@ -722,7 +723,7 @@ func (o *ObjectNode) copyObjectHandler(w http.ResponseWriter, r *http.Request) {
 	if subdir == "" {
 		subdir = r.URL.Query().Get(ParamPrefix)
 	}
-	if !userInfo.Policy.IsAuthorized(sourceBucket, subdir, proto.OSSCopyObjectAction) {
+	if !userInfo.IsAuthorized(sourceBucket, subdir, proto.OSSCopyObjectAction) {
 		log.LogErrorf("copyObjectHandler: no permission to copy from source bucket, requestID(%v), source bucket(%v), source file(%v), target bucket(%v), target file(%v)",
 			GetRequestID(r), sourceBucket, sourceObject, param.bucket, param.object)
 		errorCode = AccessDenied
@@ -1246,6 +1247,154 @@ func (o *ObjectNode) putObjectHandler(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// Post object
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html
+// The form of the request is parsed and validated by the auth middleware.
+func (o *ObjectNode) postObjectHandler(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+	var errorCode *ErrorCode
+	defer func() {
+		if errorCode != nil {
+			_ = errorCode.ServeResponse(w, r)
+		}
+	}()
+
+	var param = ParseRequestParam(r)
+	if param.Bucket() == "" {
+		errorCode = InvalidBucketName
+		return
+	}
+	var form = GetPostPolicyForm(r)
+	if form == nil {
+		errorCode = AccessDenied
+		return
+	}
+	if param.Object() == "" {
+		errorCode = InvalidKey
+		return
+	}
+	var vol *Volume
+	if vol, err = o.getVol(param.Bucket()); err != nil {
+		log.LogErrorf("postObjectHandler: load volume fail: requestID(%v) volume(%v) err(%v)",
+			GetRequestID(r), param.Bucket(), err)
+		errorCode = NoSuchBucket
+		return
+	}
+
+	var tagging *Tagging
+	if taggingXML := form.Get("tagging"); taggingXML != "" {
+		tagging = NewTagging()
+		if err = xml.Unmarshal([]byte(taggingXML), tagging); err != nil {
+			errorCode = InvalidArgument
+			return
+		}
+		var validateRes bool
+		if validateRes, errorCode = tagging.Validate(); !validateRes {
+			log.LogErrorf("postObjectHandler: tagging validate fail: requestID(%v) tagging(%v)", GetRequestID(r), tagging)
+			return
+		}
+	}
+
+	var header = form.Header()
+	cacheControl := header.Get(HeaderNameCacheControl)
+	if len(cacheControl) > 0 && !ValidateCacheControl(cacheControl) {
+		errorCode = InvalidCacheArgument
+		return
+	}
+	expires := header.Get(HeaderNameExpires)
+	if len(expires) > 0 && !ValidateCacheExpires(expires) {
+		errorCode = InvalidCacheArgument
+		return
+	}
+	contentType := header.Get(HeaderNameContentType)
+
+	// Audit file write
+	log.LogInfof("Audit: post object: requestID(%v) remote(%v) volume(%v) path(%v) type(%v)",
+		GetRequestID(r), getRequestIP(r), vol.Name(), param.Object(), contentType)
+
+	var fsFileInfo *FSFileInfo
+	var opt = &PutFileOption{
+		MIMEType:     contentType,
+		Disposition:  header.Get(HeaderNameContentDisposition),
+		Tagging:      tagging,
+		Metadata:     ParseUserDefinedMetadata(header),
+		CacheControl: cacheControl,
+		Expires:      expires,
+	}
+	fsFileInfo, err = vol.PutObject(param.Object(), form.file, opt)
+	switch {
+	case err == syscall.EINVAL:
+		errorCode = ObjectModeConflict
+		return
+	case err == errPostEntityTooLarge:
+		errorCode = EntityTooLarge
+		return
+	case err == errPostEntityTooSmall, err == io.ErrUnexpectedEOF:
+		errorCode = EntityTooSmall
+		return
+	case err != nil:
+		log.LogErrorf("postObjectHandler: put object fail: requestId(%v) volume(%v) path(%v) remote(%v) err(%v)",
+			GetRequestID(r), vol.Name(), param.Object(), getRequestIP(r), err)
+		errorCode = InternalErrorCode(err)
+		return
+	}
+
+	var etag = wrapUnescapedQuot(fsFileInfo.ETag)
+	var scheme = "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	var location = fmt.Sprintf("%s://%s%s/%s", scheme, r.Host, strings.TrimSuffix(r.URL.EscapedPath(), "/"),
+		url.PathEscape(param.Object()))
+	w.Header()[HeaderNameETag] = []string{etag}
+	w.Header()[HeaderNameLocation] = []string{location}
+
+	// redirect to the specified URL with the bucket, key and etag of the object
+	redirect := form.Get(PostFormFieldSuccessActionRedirect)
+	if redirect == "" {
+		redirect = form.Get(PostFormFieldRedirect)
+	}
+	if redirectURL, parseErr := url.Parse(redirect); redirect != "" && parseErr == nil && redirectURL.IsAbs() {
+		query := redirectURL.Query()
+		query.Set("bucket", param.Bucket())
+		query.Set("key", param.Object())
+		query.Set("etag", etag)
+		redirectURL.RawQuery = query.Encode()
+		http.Redirect(w, r, redirectURL.String(), http.StatusSeeOther)
+		return
+	}
+
+	switch form.Get(PostFormFieldSuccessActionStatus) {
+	case "200":
+		w.Header()[HeaderNameContentLength] = []string{"0"}
+		w.WriteHeader(http.StatusOK)
+	case "201":
+		var bytes []byte
+		var marshalError error
+		var postResponse = &PostResponse{
+			Location: location,
+			Bucket:   param.Bucket(),
+			Key:      param.Object(),
+			ETag:     etag,
+		}
+		if bytes, marshalError = MarshalXMLEntity(postResponse); marshalError != nil {
+			log.LogErrorf("postObjectHandler: marshal result fail, requestID(%v) err(%v)", GetRequestID(r), marshalError)
+			errorCode = InternalErrorCode(marshalError)
+			return
+		}
+		w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+		w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(bytes))}
+		w.WriteHeader(http.StatusCreated)
+		if _, err = w.Write(bytes); err != nil {
+			log.LogErrorf("postObjectHandler: write response body fail, requestID(%v) err(%v)", GetRequestID(r), err)
+		}
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return
+}
+
 // Delete object
 // API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_DeleteObject.html .
 func (o *ObjectNode) deleteObjectHandler(w http.ResponseWriter, r *http.Request) {
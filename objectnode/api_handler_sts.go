@@ -0,0 +1,477 @@
+// Copyright 2019 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/federation"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The temporary credentials are issued by the STS compatible actions AssumeRole and GetSessionToken,
+// which are sent to the endpoint of the object node as a form posted to "/" and signed for the "sts" service.
+// The credentials are sessions of the users kept by the master, a request signed with the session keys
+// has to carry the session token, and is allowed only if both the user and the session policy allow it.
+// AssumeRoleWithWebIdentity is not signed, the caller is authenticated by the ID token of the OpenID
+// Connect provider instead, and gets the credentials of the user provisioned for the identity.
+
+const (
+	STSService                         = "sts"
+	STSActionAssumeRole                = "AssumeRole"
+	STSActionAssumeRoleWithWebIdentity = "AssumeRoleWithWebIdentity"
+	STSActionSessionToken              = "GetSessionToken"
+	STSRequestLimitSize                = 64 * 1024
+	SessionPolicyLimitSize             = 2048
+
+	ParamSTSAction           = "Action"
+	ParamSTSDurationSeconds  = "DurationSeconds"
+	ParamSTSRoleArn          = "RoleArn"
+	ParamSTSRoleSessionName  = "RoleSessionName"
+	ParamSTSPolicy           = "Policy"
+	ParamSTSWebIdentityToken = "WebIdentityToken"
+
+	HeaderNameXAmzSecurityToken = "X-Amz-Security-Token"
+
+	sessionPolicyActionPrefix   = "s3:"
+	sessionPolicyResourcePrefix = "arn:aws:s3:::"
+)
+
+func isSTSAction(action proto.Action) bool {
+	return action == proto.OSSAssumeRoleAction || action == proto.OSSGetSessionTokenAction
+}
+
+// isUnsignedSTSAction returns true for the STS actions authenticated by the token in the form instead of the signature.
+func isUnsignedSTSAction(action proto.Action) bool {
+	return action == proto.OSSAssumeRoleWithWebIdentityAction
+}
+
+// peekRequestBody reads the body of a form request and restores it for the later readers.
+func peekRequestBody(r *http.Request) (body []byte, err error) {
+	if r.Body == nil {
+		return
+	}
+	if body, err = ioutil.ReadAll(http.MaxBytesReader(nil, r.Body, STSRequestLimitSize)); err != nil {
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return
+}
+
+// stsActionMatcher matches the request of the STS action, which is specified in the posted form.
+func stsActionMatcher(action string) mux.MatcherFunc {
+	return func(r *http.Request, rm *mux.RouteMatch) bool {
+		if r.URL.Path != "/" || !strings.HasPrefix(r.Header.Get(HeaderNameContentType), "application/x-www-form-urlencoded") {
+			return false
+		}
+		body, err := peekRequestBody(r)
+		if err != nil {
+			return false
+		}
+		values, err := url.ParseQuery(string(body))
+		return err == nil && values.Get(ParamSTSAction) == action
+	}
+}
+
+// getSTSContentHash returns the hash of the payload for the STS clients which sign
+// the payload without the "X-Amz-Content-Sha256" header.
+func getSTSContentHash(r *http.Request) string {
+	body, err := peekRequestBody(r)
+	if err != nil {
+		return ""
+	}
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+// checkSessionToken checks the token of the request signed with the keys of a session.
+func checkSessionToken(r *http.Request, session *proto.UserSession) *ErrorCode {
+	token := r.Header.Get(HeaderNameXAmzSecurityToken)
+	if token == "" {
+		token = r.URL.Query().Get(HeaderNameXAmzSecurityToken)
+	}
+	if form := GetPostPolicyForm(r); token == "" && form != nil {
+		token = form.Get(PostFormFieldAmzSecurityToken)
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(session.SessionToken)) != 1 {
+		return InvalidToken
+	}
+	if session.IsExpired() {
+		return ExpiredToken
+	}
+	return nil
+}
+
+// parseSessionPolicy parses the policy specified for a session, and rewrites the actions and
+// resources in the AWS form such as "s3:GetObject" and "arn:aws:s3:::bucket/*" into the form
+// used by the bucket policies. The returned policy document is saved in the session.
+func parseSessionPolicy(document string) (policy *Policy, normalized string, err error) {
+	if len(document) > SessionPolicyLimitSize {
+		err = errors.New("session policy is too large")
+		return
+	}
+	policy = &Policy{}
+	if err = json.Unmarshal([]byte(document), policy); err != nil {
+		return
+	}
+	if _, err = policy.isValid(); err != nil {
+		return
+	}
+	if policy.IsEmpty() {
+		err = errors.New("session policy has no statement")
+		return
+	}
+	for i := range policy.Statements {
+		s := &policy.Statements[i]
+		s.Actions = normalizeSessionPolicyActions(s.Actions)
+		s.NotActions = normalizeSessionPolicyActions(s.NotActions)
+		s.Resources = normalizeSessionPolicyResources(s.Resources)
+		s.NotResources = normalizeSessionPolicyResources(s.NotResources)
+	}
+	var data []byte
+	if data, err = json.Marshal(policy); err != nil {
+		return
+	}
+	normalized = string(data)
+	return
+}
+
+func normalizeSessionPolicyActions(actions StringSet) StringSet {
+	if actions.Empty() {
+		return actions
+	}
+	normalized := StringSet{values: make(map[string]null, len(actions.values))}
+	for action := range actions.values {
+		switch {
+		case action == "*" || action == sessionPolicyActionPrefix+"*":
+			action = proto.ActionPrefix + "*"
+		case strings.HasPrefix(action, sessionPolicyActionPrefix):
+			action = proto.OSSActionPrefix + strings.TrimPrefix(action, sessionPolicyActionPrefix)
+		}
+		normalized.values[action] = void
+	}
+	return normalized
+}
+
+func normalizeSessionPolicyResources(resources StringSet) StringSet {
+	if resources.Empty() {
+		return resources
+	}
+	normalized := StringSet{values: make(map[string]null, len(resources.values))}
+	for resource := range resources.values {
+		normalized.values[strings.TrimPrefix(resource, sessionPolicyResourcePrefix)] = void
+	}
+	return normalized
+}
+
+// sessionPolicyAllowed checks the request signed with the keys of a session against the session policy.
+func sessionPolicyAllowed(userInfo *proto.UserInfo, param *RequestParam) bool {
+	if userInfo == nil || userInfo.Session == nil || userInfo.Session.Policy == "" {
+		return true
+	}
+	policy := &Policy{}
+	if err := json.Unmarshal([]byte(userInfo.Session.Policy), policy); err != nil {
+		log.LogErrorf("sessionPolicyAllowed: unmarshal session policy fail: accessKey(%v) err(%v)",
+			userInfo.AccessKey, err)
+		return false
+	}
+	return policy.IsAllowed(param, false)
+}
+
+func newSTSCredentials(session *proto.UserSession) STSCredentials {
+	return STSCredentials{
+		AccessKeyId:     session.AccessKey,
+		SecretAccessKey: session.SecretKey,
+		SessionToken:    session.SessionToken,
+		Expiration:      formatTimeISO(time.Unix(session.Expiration, 0)),
+	}
+}
+
+// parseSessionDuration parses the duration of the session in seconds, 0 for the default duration.
+func parseSessionDuration(r *http.Request) (duration int64, ec *ErrorCode) {
+	value := r.PostForm.Get(ParamSTSDurationSeconds)
+	if value == "" {
+		return
+	}
+	var err error
+	if duration, err = strconv.ParseInt(value, 10, 64); err != nil ||
+		duration < proto.MinSessionDuration || duration > proto.MaxSessionDuration {
+		ec = InvalidSessionDuration
+	}
+	return
+}
+
+// getSessionCaller returns the user who requests the temporary credentials, which
+// has to be signed with the permanent keys of the user.
+func (o *ObjectNode) getSessionCaller(r *http.Request) (userInfo *proto.UserInfo, ec *ErrorCode) {
+	auth := parseRequestAuthInfo(r)
+	if auth == nil {
+		return nil, AccessDenied
+	}
+	var err error
+	if userInfo, err = o.getUserInfoByAccessKey(auth.accessKey); err != nil {
+		log.LogErrorf("getSessionCaller: get user info fail: requestID(%v) accessKey(%v) err(%v)",
+			GetRequestID(r), auth.accessKey, err)
+		return nil, AccessDenied
+	}
+	if userInfo.Session != nil {
+		log.LogWarnf("getSessionCaller: request with session keys: requestID(%v) accessKey(%v) userID(%v)",
+			GetRequestID(r), auth.accessKey, userInfo.UserID)
+		return nil, AccessDenied
+	}
+	return
+}
+
+// Assume role
+// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRole.html
+// The role is a user, which is named by the resource id of the role ARN such as "arn:aws:iam:::role/<user>".
+// A user can assume itself with a session policy, only the root and admin users can assume the other users.
+func (o *ObjectNode) assumeRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		ec      *ErrorCode
+		caller  *proto.UserInfo
+		target  *proto.UserInfo
+		session *proto.UserSession
+	)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
+
+	if caller, ec = o.getSessionCaller(r); ec != nil {
+		return
+	}
+	if err = r.ParseForm(); err != nil {
+		ec = InvalidArgument
+		return
+	}
+	roleArn := r.PostForm.Get(ParamSTSRoleArn)
+	sessionName := r.PostForm.Get(ParamSTSRoleSessionName)
+	roleUser := roleArn[strings.LastIndexAny(roleArn, ":/")+1:]
+	if roleUser == "" || sessionName == "" {
+		ec = InvalidArgument
+		return
+	}
+	var param = &proto.UserSessionCreateParam{UserID: roleUser}
+	if param.Duration, ec = parseSessionDuration(r); ec != nil {
+		return
+	}
+	if policy := r.PostForm.Get(ParamSTSPolicy); policy != "" {
+		if _, param.Policy, err = parseSessionPolicy(policy); err != nil {
+			ec = MalformedPolicyDocument
+			return
+		}
+	}
+
+	if roleUser != caller.UserID {
+		if caller.UserType != proto.UserTypeRoot && caller.UserType != proto.UserTypeAdmin {
+			ec = AccessDenied
+			return
+		}
+		if target, err = o.mc.UserAPI().GetUserInfo(roleUser); err != nil {
+			if err == proto.ErrUserNotExists {
+				err, ec = nil, AccessDenied
+			}
+			return
+		}
+		if target.UserType == proto.UserTypeRoot && caller.UserType != proto.UserTypeRoot {
+			ec = AccessDenied
+			return
+		}
+	}
+	if session, err = o.mc.UserAPI().CreateSession(param); err != nil {
+		log.LogErrorf("assumeRoleHandler: create session fail: requestID(%v) caller(%v) user(%v) err(%v)",
+			GetRequestID(r), caller.UserID, roleUser, err)
+		return
+	}
+	log.LogInfof("assumeRoleHandler: session created: requestID(%v) caller(%v) user(%v) accessKey(%v) sessionName(%v)",
+		GetRequestID(r), caller.UserID, roleUser, session.AccessKey, sessionName)
+
+	var output = AssumeRoleResponse{
+		Credentials: newSTSCredentials(session),
+		AssumedRoleUser: AssumedRoleUser{
+			Arn:           "arn:aws:sts:::assumed-role/" + roleUser + "/" + sessionName,
+			AssumedRoleId: session.AccessKey + ":" + sessionName,
+		},
+		ResponseMetadata: STSResponseMetadata{RequestId: GetRequestID(r)},
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(&output); err != nil {
+		log.LogErrorf("assumeRoleHandler: marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	if _, werr := w.Write(data); werr != nil {
+		log.LogErrorf("assumeRoleHandler: write response body fail, requestID(%v) err(%v)", GetRequestID(r), werr)
+	}
+}
+
+// Assume role with web identity
+// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+// The web identity token is the ID token of the OpenID Connect provider, and the role is the user provisioned
+// for the identity, which is named by the resource id of the role ARN in the same way as AssumeRole.
+func (o *ObjectNode) assumeRoleWithWebIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err      error
+		ec       *ErrorCode
+		identity *federation.Identity
+		userInfo *proto.UserInfo
+		session  *proto.UserSession
+	)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
+
+	if o.federation == nil || o.federation.OIDC() == nil {
+		log.LogWarnf("assumeRoleWithWebIdentityHandler: web identity federation not configured: requestID(%v)",
+			GetRequestID(r))
+		ec = AccessDenied
+		return
+	}
+	if err = r.ParseForm(); err != nil {
+		ec = InvalidArgument
+		return
+	}
+	token := r.PostForm.Get(ParamSTSWebIdentityToken)
+	roleArn := r.PostForm.Get(ParamSTSRoleArn)
+	sessionName := r.PostForm.Get(ParamSTSRoleSessionName)
+	roleUser := roleArn[strings.LastIndexAny(roleArn, ":/")+1:]
+	if token == "" || roleUser == "" || sessionName == "" {
+		err, ec = nil, InvalidArgument
+		return
+	}
+	var param = &proto.UserSessionCreateParam{UserID: roleUser}
+	if param.Duration, ec = parseSessionDuration(r); ec != nil {
+		return
+	}
+	if policy := r.PostForm.Get(ParamSTSPolicy); policy != "" {
+		if _, param.Policy, err = parseSessionPolicy(policy); err != nil {
+			err, ec = nil, MalformedPolicyDocument
+			return
+		}
+	}
+
+	if identity, userInfo, err = o.federation.LoginWithToken(token); err != nil {
+		log.LogWarnf("assumeRoleWithWebIdentityHandler: login with token fail: requestID(%v) identity(%v) err(%v)",
+			GetRequestID(r), identity, err)
+		switch {
+		case err == federation.ErrTokenExpired:
+			err, ec = nil, ExpiredToken
+		case errors.Is(err, federation.ErrInvalidToken):
+			err, ec = nil, InvalidIdentityToken
+		case errors.Is(err, federation.ErrInvalidIdentity), err == federation.ErrIdentityConflict:
+			err, ec = nil, IDPRejectedClaim
+		}
+		return
+	}
+	if userInfo.UserID != roleUser {
+		log.LogWarnf("assumeRoleWithWebIdentityHandler: role not allowed: requestID(%v) identity(%v) user(%v) role(%v)",
+			GetRequestID(r), identity, userInfo.UserID, roleUser)
+		ec = AccessDenied
+		return
+	}
+	if session, err = o.mc.UserAPI().CreateSession(param); err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: create session fail: requestID(%v) identity(%v) user(%v) err(%v)",
+			GetRequestID(r), identity, roleUser, err)
+		return
+	}
+	log.LogInfof("assumeRoleWithWebIdentityHandler: session created: requestID(%v) identity(%v) user(%v) accessKey(%v) sessionName(%v)",
+		GetRequestID(r), identity, roleUser, session.AccessKey, sessionName)
+
+	var output = AssumeRoleWithWebIdentityResponse{
+		Credentials: newSTSCredentials(session),
+		AssumedRoleUser: AssumedRoleUser{
+			Arn:           "arn:aws:sts:::assumed-role/" + roleUser + "/" + sessionName,
+			AssumedRoleId: session.AccessKey + ":" + sessionName,
+		},
+		SubjectFromWebIdentityToken: identity.Subject,
+		Provider:                    o.federation.OIDC().Issuer(),
+		Audience:                    o.federation.OIDC().ClientID(),
+		ResponseMetadata:            STSResponseMetadata{RequestId: GetRequestID(r)},
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(&output); err != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	if _, werr := w.Write(data); werr != nil {
+		log.LogErrorf("assumeRoleWithWebIdentityHandler: write response body fail, requestID(%v) err(%v)", GetRequestID(r), werr)
+	}
+}
+
+// Get session token
+// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_GetSessionToken.html
+func (o *ObjectNode) getSessionTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		ec      *ErrorCode
+		caller  *proto.UserInfo
+		session *proto.UserSession
+	)
+	defer func() {
+		o.errorResponse(w, r, err, ec)
+	}()
+
+	if caller, ec = o.getSessionCaller(r); ec != nil {
+		return
+	}
+	if err = r.ParseForm(); err != nil {
+		ec = InvalidArgument
+		return
+	}
+	var param = &proto.UserSessionCreateParam{UserID: caller.UserID}
+	if param.Duration, ec = parseSessionDuration(r); ec != nil {
+		return
+	}
+	if session, err = o.mc.UserAPI().CreateSession(param); err != nil {
+		log.LogErrorf("getSessionTokenHandler: create session fail: requestID(%v) user(%v) err(%v)",
+			GetRequestID(r), caller.UserID, err)
+		return
+	}
+	log.LogInfof("getSessionTokenHandler: session created: requestID(%v) user(%v) accessKey(%v)",
+		GetRequestID(r), caller.UserID, session.AccessKey)
+
+	var output = GetSessionTokenResponse{
+		Credentials:      newSTSCredentials(session),
+		ResponseMetadata: STSResponseMetadata{RequestId: GetRequestID(r)},
+	}
+	var data []byte
+	if data, err = MarshalXMLEntity(&output); err != nil {
+		log.LogErrorf("getSessionTokenHandler: marshal result fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return
+	}
+	w.Header()[HeaderNameContentType] = []string{HeaderValueContentTypeXML}
+	w.Header()[HeaderNameContentLength] = []string{strconv.Itoa(len(data))}
+	if _, werr := w.Write(data); werr != nil {
+		log.LogErrorf("getSessionTokenHandler: write response body fail, requestID(%v) err(%v)", GetRequestID(r), werr)
+	}
+}
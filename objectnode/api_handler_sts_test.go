@@ -0,0 +1,136 @@
+// Copyright 2019 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/federation"
+)
+
+func TestParseSessionPolicy(t *testing.T) {
+	document := `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {"Effect": "Allow", "Action": ["s3:GetObject", "s3:ListObjects"], "Resource": "arn:aws:s3:::bucket/public/*"},
+    {"Effect": "Deny", "Action": "s3:*", "Resource": "arn:aws:s3:::bucket/public/secret"}
+  ]
+}`
+	_, normalized, err := parseSessionPolicy(document)
+	if err != nil {
+		t.Fatalf("parse session policy fail: %v", err)
+	}
+	userInfo := &proto.UserInfo{Session: &proto.UserSession{Policy: normalized}}
+	cases := []struct {
+		action   proto.Action
+		resource string
+		allowed  bool
+	}{
+		{proto.OSSGetObjectAction, "bucket/public/a", true},
+		{proto.OSSPutObjectAction, "bucket/public/a", false},
+		{proto.OSSGetObjectAction, "bucket/private/a", false},
+		{proto.OSSGetObjectAction, "bucket/public/secret", false},
+	}
+	for _, c := range cases {
+		param := &RequestParam{action: c.action, resource: c.resource, bucket: "bucket"}
+		if allowed := sessionPolicyAllowed(userInfo, param); allowed != c.allowed {
+			t.Errorf("action(%v) resource(%v): expect allowed(%v), real(%v)", c.action, c.resource, c.allowed, allowed)
+		}
+	}
+
+	for _, document := range []string{
+		`{"Statement": [{"Effect": "Allow", "Action": "s3:*"}]}`,
+		`{"Version": "2012-10-17"}`,
+		`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "` +
+			strings.Repeat("a", SessionPolicyLimitSize) + `"}]}`,
+	} {
+		if _, _, err = parseSessionPolicy(document); err == nil {
+			t.Errorf("expect invalid session policy: %.64v", document)
+		}
+	}
+}
+
+func TestCheckSessionToken(t *testing.T) {
+	session := &proto.UserSession{SessionToken: "token", Expiration: time.Now().Unix() + 60}
+	r := httptest.NewRequest("GET", "/bucket/object", nil)
+	if ec := checkSessionToken(r, session); ec != InvalidToken {
+		t.Fatalf("expect invalid token for request without token, real(%v)", ec)
+	}
+	r.Header.Set(HeaderNameXAmzSecurityToken, "token")
+	if ec := checkSessionToken(r, session); ec != nil {
+		t.Fatalf("expect valid token, real(%v)", ec)
+	}
+	r = httptest.NewRequest("GET", "/bucket/object?X-Amz-Security-Token=token", nil)
+	if ec := checkSessionToken(r, session); ec != nil {
+		t.Fatalf("expect valid token in query, real(%v)", ec)
+	}
+	session.Expiration = time.Now().Unix() - 1
+	if ec := checkSessionToken(r, session); ec != ExpiredToken {
+		t.Fatalf("expect expired token, real(%v)", ec)
+	}
+}
+
+func TestAssumeRoleWithWebIdentity(t *testing.T) {
+	jwksFile := path.Join(t.TempDir(), "jwks.json")
+	if err := ioutil.WriteFile(jwksFile, []byte(`{"keys": []}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fed, err := federation.NewFederation(config.LoadConfigString(`{
+		"oidcIssuer": "https://idp.example.com",
+		"oidcClientID": "cubefs",
+		"oidcJWKSFile": "`+jwksFile+`"
+	}`), nil)
+	if err != nil || fed == nil {
+		t.Fatalf("create federation fail: %v", err)
+	}
+	if !isUnsignedSTSAction(proto.OSSAssumeRoleWithWebIdentityAction) || isUnsignedSTSAction(proto.OSSAssumeRoleAction) {
+		t.Errorf("only the web identity action is unsigned")
+	}
+
+	cases := []struct {
+		federation *federation.Federation
+		form       url.Values
+		code       *ErrorCode
+	}{
+		{nil, url.Values{ParamSTSWebIdentityToken: {"token"}, ParamSTSRoleArn: {"arn:aws:iam:::role/alice"},
+			ParamSTSRoleSessionName: {"s"}}, AccessDenied},
+		{fed, url.Values{ParamSTSWebIdentityToken: {"token"}, ParamSTSRoleSessionName: {"s"}}, InvalidArgument},
+		{fed, url.Values{ParamSTSWebIdentityToken: {"token"}, ParamSTSRoleArn: {"arn:aws:iam:::role/alice"},
+			ParamSTSRoleSessionName: {"s"}}, InvalidIdentityToken},
+	}
+	for i, c := range cases {
+		c.form.Set(ParamSTSAction, STSActionAssumeRoleWithWebIdentity)
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(c.form.Encode()))
+		r.Header.Set(HeaderNameContentType, "application/x-www-form-urlencoded")
+		r = mux.SetURLVars(r, map[string]string{})
+		w := httptest.NewRecorder()
+		o := &ObjectNode{federation: c.federation}
+		o.assumeRoleWithWebIdentityHandler(w, r)
+		if w.Code != c.code.StatusCode || !strings.Contains(w.Body.String(), c.code.ErrorCode) {
+			t.Errorf("case %v: expect %v, real(%v %v)", i, c.code.ErrorCode, w.Code, w.Body.String())
+		}
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
@@ -60,6 +61,16 @@ func generateWarnDetail(r *http.Request, errorInfo string) string {
 		statusCode, requestID, action.Name(), bucket, object, errorInfo)
 }
 
+// getTraceParent returns the span context in the traceparent header of the request, which is
+// not valid if the header is absent or malformed.
+func getTraceParent(r *http.Request) trace.SpanContext {
+	sc, err := trace.ParseTraceparent(r.Header.Get(HeaderNameTraceParent))
+	if err != nil {
+		return trace.SpanContext{}
+	}
+	return sc
+}
+
 // TraceMiddleware returns a middleware handler to trace request.
 // After receiving the request, the handler will assign a unique RequestID to
 // the request and record the processing time of the request.
@@ -102,6 +113,14 @@ func (o *ObjectNode) traceMiddleware(next http.Handler) http.Handler {
 		defer func() {
 			metric.Set(err)
 		}()
+		span := trace.StartSpan("objectnode."+action.Name(), getTraceParent(r))
+		opMetric := exporter.NewOpTPSince(action.Name(), mux.Vars(r)["bucket"], span.TraceID(), startTime)
+		if span != nil {
+			span.SetTag("request_id", requestID)
+			span.SetTag("bucket", mux.Vars(r)["bucket"])
+			r = r.WithContext(trace.ContextWithSpan(r.Context(), span))
+			w.Header()[HeaderNameTraceParent] = []string{span.Context().Traceparent()}
+		}
 
 		// Check action is whether enabled.
 		if !action.IsNone() && !o.disabledActions.Contains(action) {
@@ -115,10 +134,14 @@ func (o *ObjectNode) traceMiddleware(next http.Handler) http.Handler {
 
 		// failed request monitor
 		var statusCode = GetStatusCodeFromContext(r)
+		var opErr error
 		if IsMonitoredStatusCode(statusCode) {
+			opErr = fmt.Errorf("status code %v", statusCode)
 			exporter.NewTPCnt(fmt.Sprintf("failed_%v", statusCode)).Set(nil)
 			exporter.Warning(generateWarnDetail(r, getResponseErrorMessage(r)))
 		}
+		opMetric.Set(opErr)
+		span.Finish(opErr)
 
 		// ===== post-handle start =====
 		var headerToString = func(header http.Header) string {
@@ -147,7 +170,7 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			var currentAction = ActionFromRouteName(mux.CurrentRoute(r).GetName())
-			if !currentAction.IsNone() && o.signatureIgnoredActions.Contains(currentAction) {
+			if !currentAction.IsNone() && (o.signatureIgnoredActions.Contains(currentAction) || isUnsignedSTSAction(currentAction)) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -157,7 +180,15 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 				err  error
 			)
 			//  check auth type
-			if isHeaderUsingSignatureAlgorithmV4(r) {
+			if isPostPolicyRequest(r) {
+				// browser-based upload with the signed policy in the form
+				var errorCode *ErrorCode
+				if r, errorCode = o.validatePostPolicy(r); errorCode != nil {
+					_ = errorCode.ServeResponse(w, r)
+					return
+				}
+				pass = true
+			} else if isHeaderUsingSignatureAlgorithmV4(r) {
 				// using signature algorithm version 4 in header
 				pass, err = o.validateHeaderBySignatureAlgorithmV4(r)
 			} else if isHeaderUsingSignatureAlgorithmV2(r) {
@@ -185,6 +216,20 @@ func (o *ObjectNode) authMiddleware(next http.Handler) http.Handler {
 				return
 			}
 
+			// A request signed with the keys of a session has to carry the token of the session.
+			if auth := parseRequestAuthInfo(r); auth != nil {
+				if userInfo, err := o.getUserInfoByAccessKey(auth.accessKey); err == nil && userInfo.Session != nil {
+					if ec := checkSessionToken(r, userInfo.Session); ec != nil {
+						log.LogWarnf("authMiddleware: invalid session token: requestID(%v) accessKey(%v) err(%v)",
+							GetRequestID(r), auth.accessKey, ec.ErrorCode)
+						_ = ec.ServeResponse(w, r)
+						return
+					}
+				} else if err == nil && o.keyUsage != nil {
+					o.keyUsage.Record(auth.accessKey)
+				}
+			}
+
 			next.ServeHTTP(w, r)
 		})
 }
@@ -195,7 +240,7 @@ func (o *ObjectNode) policyCheckMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			action := ActionFromRouteName(mux.CurrentRoute(r).GetName())
-			if !action.IsNone() && o.signatureIgnoredActions.Contains(action) {
+			if !action.IsNone() && (o.signatureIgnoredActions.Contains(action) || isUnsignedSTSAction(action)) {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -25,6 +25,7 @@ const (
 	SignatrueV4          = "signature_v4"
 	PresignedV2          = "presigned_v2"
 	PresignedV4          = "presigned_v4"
+	PostPolicy           = "post_policy"
 )
 
 type RequestAuthInfo struct {
@@ -34,7 +35,10 @@ type RequestAuthInfo struct {
 
 func parseRequestAuthInfo(r *http.Request) *RequestAuthInfo {
 	auth := new(RequestAuthInfo)
-	if isHeaderUsingSignatureAlgorithmV2(r) {
+	if form := GetPostPolicyForm(r); form != nil {
+		auth.authType = PostPolicy
+		auth.accessKey = form.AccessKey()
+	} else if isHeaderUsingSignatureAlgorithmV2(r) {
 		auth.authType = SignatrueV2
 		ai, _ := parseRequestAuthInfoV2(r)
 		if ai != nil {
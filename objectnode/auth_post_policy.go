@@ -0,0 +1,405 @@
+// Copyright 2019 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/gorilla/mux"
+)
+
+// Browser-based uploads using HTTP POST with a signed policy document.
+// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html
+// Policy reference: https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+
+const (
+	PostFormFieldFile                  = "file"
+	PostFormFieldKey                   = "key"
+	PostFormFieldPolicy                = "policy"
+	PostFormFieldBucket                = "bucket"
+	PostFormFieldSignature             = "signature"
+	PostFormFieldAWSAccessKeyId        = "awsaccesskeyid"
+	PostFormFieldAmzAlgorithm          = "x-amz-algorithm"
+	PostFormFieldAmzCredential         = "x-amz-credential"
+	PostFormFieldAmzDate               = "x-amz-date"
+	PostFormFieldAmzSignature          = "x-amz-signature"
+	PostFormFieldAmzSecurityToken      = "x-amz-security-token"
+	PostFormFieldContentType           = "content-type"
+	PostFormFieldContentDisposition    = "content-disposition"
+	PostFormFieldCacheControl          = "cache-control"
+	PostFormFieldExpires               = "expires"
+	PostFormFieldSuccessActionRedirect = "success_action_redirect"
+	PostFormFieldRedirect              = "redirect"
+	PostFormFieldSuccessActionStatus   = "success_action_status"
+	PostFormFieldIgnorePrefix          = "x-ignore-"
+	PostFormFileNameVariable           = "${filename}"
+
+	PostPolicyConditionEq                 = "eq"
+	PostPolicyConditionStartsWith         = "starts-with"
+	PostPolicyConditionContentLengthRange = "content-length-range"
+
+	PostFormLimitSize = 20 * 1024 // the form fields other than the file are limited to 20KB
+)
+
+var (
+	PostPolicyExpired  = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "Invalid according to Policy: Policy expired.", StatusCode: http.StatusForbidden}
+	PostPolicyMismatch = &ErrorCode{ErrorCode: "AccessDenied", ErrorMessage: "Invalid according to Policy: Policy Condition failed.", StatusCode: http.StatusForbidden}
+	InvalidPostPolicy  = &ErrorCode{ErrorCode: "InvalidPolicyDocument", ErrorMessage: "Invalid Policy: Invalid JSON.", StatusCode: http.StatusBadRequest}
+	MalformedPOST      = &ErrorCode{ErrorCode: "MalformedPOSTRequest", ErrorMessage: "The body of your POST request is not well-formed multipart/form-data.", StatusCode: http.StatusBadRequest}
+	MaxPostDataExceed  = &ErrorCode{ErrorCode: "MaxPostPreDataLengthExceeded", ErrorMessage: "Your POST request fields preceding the upload file were too large.", StatusCode: http.StatusBadRequest}
+
+	errPostEntityTooSmall = errors.New("post entity too small")
+	errPostEntityTooLarge = errors.New("post entity too large")
+)
+
+// postPolicyForm holds the fields of a POST upload form and the file part, which is the last
+// part of the form and is read by the handler as a stream.
+type postPolicyForm struct {
+	fields   map[string]string // mapping: lower case field name -> value
+	fileName string
+	fileType string
+	file     io.Reader
+}
+
+func (f *postPolicyForm) Get(name string) string {
+	return f.fields[name]
+}
+
+// Key returns the object key with the file name variable replaced.
+func (f *postPolicyForm) Key() string {
+	return strings.Replace(f.fields[PostFormFieldKey], PostFormFileNameVariable, f.fileName, -1)
+}
+
+func (f *postPolicyForm) AccessKey() string {
+	if credential := f.fields[PostFormFieldAmzCredential]; credential != "" {
+		return strings.Split(credential, "/")[0]
+	}
+	return f.fields[PostFormFieldAWSAccessKeyId]
+}
+
+// Header returns the form fields in the form of the request headers of PutObject.
+func (f *postPolicyForm) Header() http.Header {
+	header := make(http.Header)
+	for name, value := range f.fields {
+		header.Set(name, value)
+	}
+	if header.Get(HeaderNameContentType) == "" {
+		header.Set(HeaderNameContentType, f.fileType)
+	}
+	return header
+}
+
+// isPostPolicyRequest checks if request is a browser-based upload with a multipart/form-data body.
+func isPostPolicyRequest(r *http.Request) bool {
+	if r.Method != http.MethodPost || mux.Vars(r)["object"] != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get(HeaderNameContentType))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// parsePostPolicyForm reads the form fields until the file part, the fields following
+// the file part are ignored.
+func parsePostPolicyForm(r *http.Request) (form *postPolicyForm, errorCode *ErrorCode) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, MalformedPOST
+	}
+	form = &postPolicyForm{fields: make(map[string]string)}
+	var size int
+	for {
+		var part *multipart.Part
+		if part, err = reader.NextPart(); err == io.EOF {
+			return nil, IncorrectNumberOfFilesInPostRequest
+		}
+		if err != nil {
+			return nil, MalformedPOST
+		}
+		name := strings.ToLower(part.FormName())
+		if name == PostFormFieldFile {
+			form.fileName = part.FileName()
+			form.fileType = part.Header.Get(HeaderNameContentType)
+			form.file = part
+			return form, nil
+		}
+		var value []byte
+		if value, err = ioutil.ReadAll(io.LimitReader(part, int64(PostFormLimitSize-size+1))); err != nil {
+			return nil, MalformedPOST
+		}
+		if size += len(value); size > PostFormLimitSize {
+			return nil, MaxPostDataExceed
+		}
+		form.fields[name] = string(value)
+	}
+}
+
+type postPolicy struct {
+	Expiration string          `json:"expiration"`
+	Conditions []postCondition `json:"conditions"`
+}
+
+type postCondition struct {
+	op    string
+	field string // lower case field name without "$"
+	value string
+	min   int64
+	max   int64
+}
+
+// UnmarshalJSON parses a condition in one of the forms:
+//   {"field": "value"}
+//   ["eq" | "starts-with", "$field", "value"]
+//   ["content-length-range", min, max]
+func (c *postCondition) UnmarshalJSON(data []byte) (err error) {
+	var exact map[string]string
+	if err = json.Unmarshal(data, &exact); err == nil {
+		if len(exact) != 1 {
+			return errors.New("condition has to specify exactly one field")
+		}
+		for field, value := range exact {
+			c.op, c.field, c.value = PostPolicyConditionEq, strings.ToLower(field), value
+		}
+		return nil
+	}
+	var items []interface{}
+	if err = json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	if len(items) != 3 {
+		return fmt.Errorf("invalid condition %v", string(data))
+	}
+	op, _ := items[0].(string)
+	c.op = strings.ToLower(op)
+	switch c.op {
+	case PostPolicyConditionEq, PostPolicyConditionStartsWith:
+		field, fieldOK := items[1].(string)
+		value, valueOK := items[2].(string)
+		if !fieldOK || !valueOK || !strings.HasPrefix(field, "$") {
+			return fmt.Errorf("invalid condition %v", string(data))
+		}
+		c.field, c.value = strings.ToLower(strings.TrimPrefix(field, "$")), value
+	case PostPolicyConditionContentLengthRange:
+		if c.min, err = parsePostConditionInt(items[1]); err != nil {
+			return
+		}
+		if c.max, err = parsePostConditionInt(items[2]); err != nil {
+			return
+		}
+		if c.min < 0 || c.min > c.max {
+			return fmt.Errorf("invalid content length range %v", string(data))
+		}
+	default:
+		return fmt.Errorf("unknown condition %v", op)
+	}
+	return nil
+}
+
+func parsePostConditionInt(item interface{}) (int64, error) {
+	switch v := item.(type) {
+	case float64:
+		return int64(v), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("invalid integer %v", item)
+}
+
+func parsePostPolicy(encoded string) (policy *postPolicy, err error) {
+	var data []byte
+	if data, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+		return
+	}
+	policy = &postPolicy{}
+	if err = json.Unmarshal(data, policy); err != nil {
+		return
+	}
+	if policy.Expiration == "" {
+		err = errors.New("policy has no expiration")
+		return
+	}
+	return
+}
+
+// check checks the form against the policy, and returns the allowed range of the file size.
+func (p *postPolicy) check(form *postPolicyForm, bucket string, now time.Time) (min, max int64, errorCode *ErrorCode) {
+	expiration, err := time.Parse(time.RFC3339, p.Expiration)
+	if err != nil {
+		return 0, 0, InvalidPostPolicy
+	}
+	if now.After(expiration) {
+		return 0, 0, PostPolicyExpired
+	}
+	var values = func(field string) string {
+		switch field {
+		case PostFormFieldBucket:
+			return bucket
+		case PostFormFieldKey:
+			return form.Key()
+		}
+		return form.Get(field)
+	}
+	min, max = 0, -1
+	var conditioned = map[string]bool{PostFormFieldBucket: true}
+	for _, c := range p.Conditions {
+		switch c.op {
+		case PostPolicyConditionEq:
+			if values(c.field) != c.value {
+				return 0, 0, PostPolicyMismatch
+			}
+		case PostPolicyConditionStartsWith:
+			if !strings.HasPrefix(values(c.field), c.value) {
+				return 0, 0, PostPolicyMismatch
+			}
+		case PostPolicyConditionContentLengthRange:
+			min, max = c.min, c.max
+		}
+		conditioned[c.field] = true
+	}
+	// Each field of the form has to be specified in the conditions except the following.
+	for name := range form.fields {
+		switch name {
+		case PostFormFieldPolicy, PostFormFieldSignature, PostFormFieldAWSAccessKeyId, PostFormFieldAmzSignature:
+			continue
+		}
+		if !conditioned[name] && !strings.HasPrefix(name, PostFormFieldIgnorePrefix) {
+			log.LogDebugf("check: form field not in policy conditions: field(%v)", name)
+			return 0, 0, PostPolicyMismatch
+		}
+	}
+	return min, max, nil
+}
+
+// calculatePostPolicySignature signs the encoded policy with the signature algorithm V4
+// if the credential is specified, otherwise with the signature algorithm V2.
+func calculatePostPolicySignature(form *postPolicyForm, secretKey string) (string, error) {
+	encoded := form.Get(PostFormFieldPolicy)
+	if credentialStr := form.Get(PostFormFieldAmzCredential); credentialStr != "" {
+		if form.Get(PostFormFieldAmzAlgorithm) != SignatureV4Algorithm {
+			return "", errors.New("unsupported signature algorithm")
+		}
+		req := &signatureRequestV4{}
+		if err := req.parseCredential(credentialStr); err != nil {
+			return "", err
+		}
+		signingKey := buildSigningKey(SCHEME, secretKey, req.Credential.Date, req.Credential.Region,
+			req.Credential.Service, req.Credential.Request)
+		return hex.EncodeToString(sign(encoded, signingKey)), nil
+	}
+	hm := hmac.New(sha1.New, []byte(secretKey))
+	hm.Write([]byte(encoded))
+	return base64.StdEncoding.EncodeToString(hm.Sum(nil)), nil
+}
+
+// validatePostPolicy parses the form of a POST upload request, verifies the signature of the policy
+// and checks the form against the policy. The form is saved in the returned request.
+func (o *ObjectNode) validatePostPolicy(r *http.Request) (*http.Request, *ErrorCode) {
+	form, errorCode := parsePostPolicyForm(r)
+	if errorCode != nil {
+		return r, errorCode
+	}
+	var signature = form.Get(PostFormFieldAmzSignature)
+	if signature == "" {
+		signature = form.Get(PostFormFieldSignature)
+	}
+	var accessKey = form.AccessKey()
+	if accessKey == "" || signature == "" || form.Get(PostFormFieldPolicy) == "" {
+		return r, AccessDenied
+	}
+	if _, exist := form.fields[PostFormFieldKey]; !exist {
+		return r, InvalidKey
+	}
+
+	var secretKey string
+	var bucket = mux.Vars(r)["bucket"]
+	if userInfo, err := o.getUserInfoByAccessKey(accessKey); err == nil {
+		secretKey = userInfo.SecretKey
+	} else if err == proto.ErrUserNotExists || err == proto.ErrAccessKeyNotExists {
+		// compatible with the access key and secret key bound in the volume
+		var volume *Volume
+		if volume, err = o.getVol(bucket); err != nil {
+			return r, NoSuchBucket
+		}
+		ak, sk := volume.OSSSecure()
+		if ak != accessKey {
+			return r, AccessDenied
+		}
+		secretKey = sk
+	} else {
+		log.LogErrorf("validatePostPolicy: get secretKey from master fail: accessKey(%v) err(%v)", accessKey, err)
+		return r, InternalErrorCode(err)
+	}
+
+	newSignature, err := calculatePostPolicySignature(form, secretKey)
+	if err != nil {
+		log.LogDebugf("validatePostPolicy: calculate signature fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return r, AccessDenied
+	}
+	if subtle.ConstantTimeCompare([]byte(signature), []byte(newSignature)) != 1 {
+		log.LogDebugf("validatePostPolicy: invalid signature: requestID(%v) client(%v) server(%v)",
+			GetRequestID(r), signature, newSignature)
+		return r, AccessDenied
+	}
+
+	var policy *postPolicy
+	if policy, err = parsePostPolicy(form.Get(PostFormFieldPolicy)); err != nil {
+		log.LogDebugf("validatePostPolicy: parse policy fail: requestID(%v) err(%v)", GetRequestID(r), err)
+		return r, InvalidPostPolicy
+	}
+	var min, max int64
+	if min, max, errorCode = policy.check(form, bucket, time.Now()); errorCode != nil {
+		return r, errorCode
+	}
+	form.file = &postFileReader{reader: form.file, min: min, max: max}
+	// The object key is used by the policy check as for PutObject.
+	mux.Vars(r)["object"] = form.Key()
+	return SetPostPolicyForm(r, form), nil
+}
+
+// postFileReader fails the upload if the size of the file is out of the range allowed by the policy.
+type postFileReader struct {
+	reader io.Reader
+	size   int64
+	min    int64
+	max    int64 // no limit if negative
+}
+
+func (r *postFileReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.size += int64(n)
+	if r.max >= 0 && r.size > r.max {
+		return n, errPostEntityTooLarge
+	}
+	if err == io.EOF && r.size < r.min {
+		return n, errPostEntityTooSmall
+	}
+	return
+}
@@ -0,0 +1,133 @@
+// Copyright 2019 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newPostPolicyRequest(t *testing.T, fields [][2]string, content string) *postPolicyForm {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, field := range fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	part, err := writer.CreateFormFile(PostFormFieldFile, "photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write([]byte(content))
+	_ = writer.Close()
+
+	r := httptest.NewRequest("POST", "/bucket", body)
+	r.Header.Set(HeaderNameContentType, writer.FormDataContentType())
+	form, ec := parsePostPolicyForm(r)
+	if ec != nil {
+		t.Fatalf("parse post form fail: %v", ec)
+	}
+	return form
+}
+
+func TestPostPolicy(t *testing.T) {
+	document := `{
+  "expiration": "2030-01-01T12:00:00.000Z",
+  "conditions": [
+    {"bucket": "bucket"},
+    ["starts-with", "$key", "user/eric/"],
+    {"success_action_status": "201"},
+    ["starts-with", "$Content-Type", "image/"],
+    ["content-length-range", 1, 10]
+  ]
+}`
+	encoded := base64.StdEncoding.EncodeToString([]byte(document))
+	policy, err := parsePostPolicy(encoded)
+	if err != nil {
+		t.Fatalf("parse post policy fail: %v", err)
+	}
+	fields := [][2]string{
+		{"key", "user/eric/${filename}"},
+		{"AWSAccessKeyId", "0123456789123456"},
+		{"Policy", encoded},
+		{"Signature", "signature"},
+		{"success_action_status", "201"},
+		{"Content-Type", "image/jpeg"},
+	}
+	form := newPostPolicyRequest(t, fields, "0123456789")
+	if key := form.Key(); key != "user/eric/photo.jpg" {
+		t.Fatalf("unexpected key: %v", key)
+	}
+	if ak := form.AccessKey(); ak != "0123456789123456" {
+		t.Fatalf("unexpected access key: %v", ak)
+	}
+	min, max, ec := policy.check(form, "bucket", time.Now())
+	if ec != nil {
+		t.Fatalf("check post policy fail: %v", ec)
+	}
+	if min != 1 || max != 10 {
+		t.Fatalf("unexpected content length range: [%v, %v]", min, max)
+	}
+	if _, _, ec = policy.check(form, "other", time.Now()); ec != PostPolicyMismatch {
+		t.Errorf("expect bucket mismatch, real(%v)", ec)
+	}
+	if _, _, ec = policy.check(form, "bucket", time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC)); ec != PostPolicyExpired {
+		t.Errorf("expect expired policy, real(%v)", ec)
+	}
+	form.fields["x-amz-meta-uuid"] = "14365123651274"
+	if _, _, ec = policy.check(form, "bucket", time.Now()); ec != PostPolicyMismatch {
+		t.Errorf("expect field not in conditions, real(%v)", ec)
+	}
+
+	reader := &postFileReader{reader: strings.NewReader("0123456789a"), min: min, max: max}
+	if _, err = ioutil.ReadAll(reader); err != errPostEntityTooLarge {
+		t.Errorf("expect entity too large, real(%v)", err)
+	}
+	reader = &postFileReader{reader: strings.NewReader(""), min: min, max: max}
+	if _, err = ioutil.ReadAll(reader); err != errPostEntityTooSmall {
+		t.Errorf("expect entity too small, real(%v)", err)
+	}
+	reader = &postFileReader{reader: form.file, min: min, max: max}
+	if data, err := ioutil.ReadAll(reader); err != nil || string(data) != "0123456789" {
+		t.Errorf("unexpected file content: %v, err(%v)", string(data), err)
+	}
+}
+
+func TestPostPolicySignature(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"expiration": "2030-01-01T12:00:00.000Z", "conditions": []}`))
+	form := &postPolicyForm{fields: map[string]string{
+		PostFormFieldPolicy:        encoded,
+		PostFormFieldAmzAlgorithm:  SignatureV4Algorithm,
+		PostFormFieldAmzCredential: "0123456789123456/20300101/cfs_default/s3/aws4_request",
+	}}
+	signature, err := calculatePostPolicySignature(form, "secretKey")
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("unexpected signature V4: %v, err(%v)", signature, err)
+	}
+	if other, _ := calculatePostPolicySignature(form, "otherKey"); other == signature {
+		t.Errorf("expect different signature with different secret key")
+	}
+	delete(form.fields, PostFormFieldAmzCredential)
+	if signature, err = calculatePostPolicySignature(form, "secretKey"); err != nil || len(signature) != 28 {
+		t.Errorf("unexpected signature V2: %v, err(%v)", signature, err)
+	}
+}
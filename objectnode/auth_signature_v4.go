@@ -488,13 +488,20 @@ func calculateSignatureV4(r *http.Request, cred credential, secretKey string, si
 	canonicalHeaderString := buildCanonicalHeaderString(r.Host, headers, signedHeaders)
 	headerNames := getCanonicalHeaderNames(signedHeaders)
 	contentHash := getContentHash(headers)
+	service := SERVICE
+	if isSTSAction(GetActionFromContext(r)) {
+		service = STSService
+		if contentHash == "" {
+			contentHash = getSTSContentHash(r)
+		}
+	}
 	encodeQuery := getEncodeQuery(r)
 	canonicalURI := getCanonicalURI(r)
 	canonicalRequest := createCanonicalRequestString(
 		r.Method, canonicalURI, encodeQuery, canonicalHeaderString, headerNames, contentHash)
 
-	signingKey := buildSigningKey(SCHEME, secretKey, cred.Date, cred.Region, SERVICE, TERMINATOR)
-	scope := buildScope(cred.Date, cred.Region, SERVICE, TERMINATOR)
+	signingKey := buildSigningKey(SCHEME, secretKey, cred.Date, cred.Region, service, TERMINATOR)
+	scope := buildScope(cred.Date, cred.Region, service, TERMINATOR)
 
 	var timestamp = getStartTime(headers)
 	stringToSign := buildStringToSign(SignatureV4Algorithm, timestamp, scope, canonicalRequest)
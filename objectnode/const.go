@@ -40,6 +40,7 @@ const (
 	HeaderNameLocation           = "Location"
 	HeaderNameCacheControl       = "Cache-Control"
 	HeaderNameExpires            = "Expires"
+	HeaderNameTraceParent        = "traceparent"
 
 	// Headers for CORS validation
 	Origin                                = "Origin"
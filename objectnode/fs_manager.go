@@ -23,6 +23,7 @@ import (
 
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 )
 
 const (
@@ -41,6 +42,7 @@ type VolumeLoader struct {
 	closeOnce  sync.Once
 	closeCh    chan struct{}
 	metaStrict bool
+	credential *packetauth.Credential
 }
 
 func (loader *VolumeLoader) blacklistCleanup() {
@@ -136,6 +138,7 @@ func (loader *VolumeLoader) loadVolume(volName string) (*Volume, error) {
 			Store:            loader.store,
 			OnAsyncTaskError: onAsyncTaskError,
 			MetaStrict:       loader.metaStrict,
+			Credential:       loader.credential,
 		}
 		if volume, err = NewVolume(config); err != nil {
 			if err != proto.ErrVolNotExists {
@@ -173,13 +176,14 @@ func (loader *VolumeLoader) Close() {
 	})
 }
 
-func NewVolumeLoader(masters []string, store Store, strict bool) *VolumeLoader {
+func NewVolumeLoader(masters []string, store Store, strict bool, credential *packetauth.Credential) *VolumeLoader {
 	loader := &VolumeLoader{
 		masters:    masters,
 		store:      store,
 		volumes:    make(map[string]*Volume),
 		closeCh:    make(chan struct{}),
 		metaStrict: strict,
+		credential: credential,
 	}
 	go loader.blacklistCleanup()
 	return loader
@@ -191,6 +195,7 @@ type VolumeManager struct {
 	loaders    [volumeLoaderNum]*VolumeLoader
 	store      Store
 	metaStrict bool
+	credential *packetauth.Credential
 	closeOnce  sync.Once
 	closeCh    chan struct{}
 }
@@ -222,15 +227,16 @@ func (m *VolumeManager) init() {
 		vm: m,
 	}
 	for i := 0; i < len(m.loaders); i++ {
-		m.loaders[i] = NewVolumeLoader(m.masters, m.store, m.metaStrict)
+		m.loaders[i] = NewVolumeLoader(m.masters, m.store, m.metaStrict, m.credential)
 	}
 }
 
-func NewVolumeManager(masters []string, strict bool) *VolumeManager {
+func NewVolumeManager(masters []string, strict bool, credential *packetauth.Credential) *VolumeManager {
 	manager := &VolumeManager{
 		masters:    masters,
 		closeCh:    make(chan struct{}),
 		metaStrict: strict,
+		credential: credential,
 	}
 	manager.init()
 	return manager
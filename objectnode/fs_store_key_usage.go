@@ -0,0 +1,96 @@
+// Copyright 2019 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package objectnode
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	reportKeyUsageInterval = time.Minute * 1
+)
+
+// KeyUsageRecorder records the last time each access key authenticated a request,
+// and reports them to the master periodically.
+type KeyUsageRecorder struct {
+	mc        *master.MasterClient
+	used      map[string]int64 // mapping: access key -> last used time in unix seconds
+	usedMutex sync.Mutex
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func NewKeyUsageRecorder(mc *master.MasterClient) *KeyUsageRecorder {
+	recorder := &KeyUsageRecorder{
+		mc:      mc,
+		used:    make(map[string]int64),
+		closeCh: make(chan struct{}, 1),
+	}
+	go recorder.scheduleReport()
+	return recorder
+}
+
+func (r *KeyUsageRecorder) Record(accessKey string) {
+	r.usedMutex.Lock()
+	r.used[accessKey] = time.Now().Unix()
+	r.usedMutex.Unlock()
+}
+
+func (r *KeyUsageRecorder) scheduleReport() {
+	t := time.NewTimer(reportKeyUsageInterval)
+	for {
+		select {
+		case <-t.C:
+		case <-r.closeCh:
+			t.Stop()
+			return
+		}
+		r.report()
+		t.Reset(reportKeyUsageInterval)
+	}
+}
+
+func (r *KeyUsageRecorder) report() {
+	r.usedMutex.Lock()
+	if len(r.used) == 0 {
+		r.usedMutex.Unlock()
+		return
+	}
+	used := r.used
+	r.used = make(map[string]int64)
+	r.usedMutex.Unlock()
+
+	if err := r.mc.UserAPI().ReportKeysUsed(used); err != nil {
+		log.LogWarnf("report: report access keys used fail: count(%v) err(%v)", len(used), err)
+		// keep the records for the next round unless newer ones were recorded meanwhile
+		r.usedMutex.Lock()
+		for ak, usedTime := range used {
+			if usedTime > r.used[ak] {
+				r.used[ak] = usedTime
+			}
+		}
+		r.usedMutex.Unlock()
+	}
+}
+
+func (r *KeyUsageRecorder) Close() {
+	r.closeOnce.Do(func() {
+		close(r.closeCh)
+	})
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/cubefs/cubefs/sdk/meta"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 
 	"github.com/cubefs/cubefs/util"
 )
@@ -76,6 +77,9 @@ type VolumeConfig struct {
 
 	// Get OSSMeta from the MetaNode every time if it is set true.
 	MetaStrict bool
+
+	// Authenticates the connections to the metanodes and datanodes if set.
+	Credential *packetauth.Credential
 }
 
 type PutFileOption struct {
@@ -517,7 +521,7 @@ func (v *Volume) PutObject(path string, reader io.Reader, opt *PutFileOption) (f
 		md5Hash  = md5.New()
 		md5Value string
 	)
-	if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash); err != nil {
+	if _, err = v.streamWrite(invisibleTempDataInode.Inode, reader, md5Hash, 0); err != nil {
 		return
 	}
 	// compute file md5
@@ -805,7 +809,8 @@ func (v *Volume) WritePart(path string, multipartId string, partId uint16, reade
 		etag    string
 		md5Hash = md5.New()
 	)
-	if size, err = v.streamWrite(tempInodeInfo.Inode, reader, md5Hash); err != nil {
+	// the extents of parts are merged on completion, so parts can not be inline
+	if size, err = v.streamWrite(tempInodeInfo.Inode, reader, md5Hash, proto.FlagsNoInline); err != nil {
 		return nil, err
 	}
 	// compute file md5
@@ -1038,7 +1043,7 @@ func (v *Volume) CompleteMultipart(path, multipartID string, multipartInfo *prot
 }
 
 // v.ec.Write lan luot tung block 262 144 (= 2 * 65536 * 2) bytes
-func (v *Volume) streamWrite(inode uint64, reader io.Reader, h hash.Hash) (size uint64, err error) {
+func (v *Volume) streamWrite(inode uint64, reader io.Reader, h hash.Hash, flags int) (size uint64, err error) {
 	var (
 		buf                   = make([]byte, 2*util.BlockSize)
 		readN, writeN, offset int
@@ -1050,7 +1055,7 @@ func (v *Volume) streamWrite(inode uint64, reader io.Reader, h hash.Hash) (size
 			return
 		}
 		if readN > 0 {
-			if writeN, err = v.ec.Write(inode, offset, buf[:readN], 0); err != nil {
+			if writeN, err = v.ec.Write(inode, offset, buf[:readN], flags); err != nil {
 				log.LogErrorf("streamWrite: data write tmp file fail, inode(%v) offset(%v) err(%v)", inode, offset, err)
 				exporter.Warning(fmt.Sprintf("write data fail: volume(%v) inode(%v) offset(%v) size(%v) err(%v)",
 					v.name, inode, offset, readN, err))
@@ -2345,6 +2350,7 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		Volume:        config.Volume,
 		Masters:       config.Masters,
 		Authenticate:  false,
+		Credential:    config.Credential,
 		ValidateOwner: false,
 		OnAsyncTaskError: func(err error) {
 			config.OnAsyncTaskError.OnError(err)
@@ -2367,6 +2373,9 @@ func NewVolume(config *VolumeConfig) (*Volume, error) {
 		OnAppendExtentKey: metaWrapper.AppendExtentKey,
 		OnGetExtents:      metaWrapper.GetExtents,
 		OnTruncate:        metaWrapper.Truncate,
+		OnInlineWrite:     metaWrapper.InlineWrite,
+		OnInlineRead:      metaWrapper.InlineRead,
+		Credential:        config.Credential,
 	}
 	var extentClient *stream.ExtentClient
 	if extentClient, err = stream.NewExtentClient(extentConfig); err != nil {
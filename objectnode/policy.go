@@ -220,6 +220,14 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		// A request signed with the keys of a session is limited by the session policy, even for the admin.
+		if userInfo, err := o.getUserInfoByAccessKey(param.AccessKey()); err == nil && !sessionPolicyAllowed(userInfo, param) {
+			log.LogDebugf("policyCheck: session policy not allowed: requestID(%v) userID(%v) accessKey(%v) volume(%v) action(%v)",
+				GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket(), param.Action())
+			allowed = false
+			return
+		}
+
 		// A create bucket action do not need to check any user policy and volume policy.
 		if param.action == proto.OSSCreateBucketAction {
 			allowed = true
@@ -255,7 +263,7 @@ func (o *ObjectNode) policyCheck(f http.HandlerFunc) http.HandlerFunc {
 			if subdir == "" {
 				subdir = r.URL.Query().Get(ParamPrefix)
 			}
-			if !isOwner && !userPolicy.IsAuthorized(param.Bucket(), subdir, param.Action()) {
+			if !isOwner && !userInfo.IsAuthorized(param.Bucket(), subdir, param.Action()) {
 				log.LogDebugf("policyCheck: user no permission: url(%v) subdir(%v) requestID(%v) userID(%v) accessKey(%v) volume(%v) object(%v) action(%v)",
 					r.URL, subdir, GetRequestID(r), userInfo.UserID, param.AccessKey(), param.Bucket(), param.Object(), param.Action())
 				allowed = false
@@ -80,6 +80,14 @@ type CompleteMultipartResult struct {
 	ETag     string   `xml:"ETag"`
 }
 
+type PostResponse struct {
+	XMLName  xml.Name `xml:"PostResponse"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
 type BucketOwner struct {
 	XMLName     xml.Name `xml:"Owner"`
 	ID          string   `xml:"ID"`
@@ -318,3 +326,42 @@ type CompleteMultipartUploadRequest struct {
 	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
 	Parts   []*PartRequest `xml:"Part"`
 }
+
+type STSCredentials struct {
+	AccessKeyId     string `xml:"AccessKeyId"`
+	SecretAccessKey string `xml:"SecretAccessKey"`
+	SessionToken    string `xml:"SessionToken"`
+	Expiration      string `xml:"Expiration"`
+}
+
+type STSResponseMetadata struct {
+	RequestId string `xml:"RequestId"`
+}
+
+type AssumedRoleUser struct {
+	Arn           string `xml:"Arn"`
+	AssumedRoleId string `xml:"AssumedRoleId"`
+}
+
+type AssumeRoleResponse struct {
+	XMLName          xml.Name            `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleResponse"`
+	Credentials      STSCredentials      `xml:"AssumeRoleResult>Credentials"`
+	AssumedRoleUser  AssumedRoleUser     `xml:"AssumeRoleResult>AssumedRoleUser"`
+	ResponseMetadata STSResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type AssumeRoleWithWebIdentityResponse struct {
+	XMLName                     xml.Name            `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithWebIdentityResponse"`
+	Credentials                 STSCredentials      `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	AssumedRoleUser             AssumedRoleUser     `xml:"AssumeRoleWithWebIdentityResult>AssumedRoleUser"`
+	SubjectFromWebIdentityToken string              `xml:"AssumeRoleWithWebIdentityResult>SubjectFromWebIdentityToken"`
+	Provider                    string              `xml:"AssumeRoleWithWebIdentityResult>Provider"`
+	Audience                    string              `xml:"AssumeRoleWithWebIdentityResult>Audience"`
+	ResponseMetadata            STSResponseMetadata `xml:"ResponseMetadata"`
+}
+
+type GetSessionTokenResponse struct {
+	XMLName          xml.Name            `xml:"https://sts.amazonaws.com/doc/2011-06-15/ GetSessionTokenResponse"`
+	Credentials      STSCredentials      `xml:"GetSessionTokenResult>Credentials"`
+	ResponseMetadata STSResponseMetadata `xml:"ResponseMetadata"`
+}
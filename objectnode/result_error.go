@@ -104,6 +104,12 @@ var (
 	TagsGreaterThen10                   = &ErrorCode{ErrorCode: "BadRequest", ErrorMessage: "Object tags cannot be greater than 10", StatusCode: http.StatusBadRequest}
 	InvalidTagKey                       = &ErrorCode{ErrorCode: "InvalidTag", ErrorMessage: "The TagKey you have provided is invalid", StatusCode: http.StatusBadRequest}
 	InvalidTagValue                     = &ErrorCode{ErrorCode: "InvalidTag", ErrorMessage: "The TagValue you have provided is invalid", StatusCode: http.StatusBadRequest}
+	InvalidToken                        = &ErrorCode{ErrorCode: "InvalidToken", ErrorMessage: "The provided token is malformed or otherwise invalid.", StatusCode: http.StatusBadRequest}
+	ExpiredToken                        = &ErrorCode{ErrorCode: "ExpiredToken", ErrorMessage: "The provided token has expired.", StatusCode: http.StatusBadRequest}
+	MalformedPolicyDocument             = &ErrorCode{ErrorCode: "MalformedPolicyDocument", ErrorMessage: "The request was rejected because the policy document was malformed.", StatusCode: http.StatusBadRequest}
+	InvalidSessionDuration              = &ErrorCode{ErrorCode: "ValidationError", ErrorMessage: "The requested DurationSeconds is out of the range allowed for the session.", StatusCode: http.StatusBadRequest}
+	InvalidIdentityToken                = &ErrorCode{ErrorCode: "InvalidIdentityToken", ErrorMessage: "The web identity token that was passed could not be validated.", StatusCode: http.StatusBadRequest}
+	IDPRejectedClaim                    = &ErrorCode{ErrorCode: "IDPRejectedClaim", ErrorMessage: "The identity provider rejected the claim of the web identity token.", StatusCode: http.StatusForbidden}
 )
 
 func HttpStatusErrorCode(code int) *ErrorCode {
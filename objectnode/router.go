@@ -284,6 +284,14 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 			Methods(http.MethodPost).
 			Queries("delete", "").
 			HandlerFunc(o.deleteObjectsHandler)
+
+		// Post object
+		// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectPOST.html
+		// Notes: browser-based upload with a multipart/form-data body, authorized as PutObject
+		r.NewRoute().Name(ActionToUniqueRouteName(proto.OSSPutObjectAction)).
+			Methods(http.MethodPost).
+			HeadersRegexp(HeaderNameContentType, "^multipart/form-data").
+			HandlerFunc(o.postObjectHandler)
 	}
 
 	var registerBucketHttpPutRouters = func(r *mux.Router) {
@@ -575,6 +583,27 @@ func (o *ObjectNode) registerApiRouters(router *mux.Router) {
 		registerBucketHttpOptionsRouters(r)
 	}
 
+	// Assume role
+	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRole.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSAssumeRoleAction)).
+		Methods(http.MethodPost).
+		MatcherFunc(stsActionMatcher(STSActionAssumeRole)).
+		HandlerFunc(o.assumeRoleHandler)
+
+	// Assume role with web identity
+	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_AssumeRoleWithWebIdentity.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSAssumeRoleWithWebIdentityAction)).
+		Methods(http.MethodPost).
+		MatcherFunc(stsActionMatcher(STSActionAssumeRoleWithWebIdentity)).
+		HandlerFunc(o.assumeRoleWithWebIdentityHandler)
+
+	// Get session token
+	// API reference: https://docs.aws.amazon.com/STS/latest/APIReference/API_GetSessionToken.html
+	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSGetSessionTokenAction)).
+		Methods(http.MethodPost).
+		MatcherFunc(stsActionMatcher(STSActionSessionToken)).
+		HandlerFunc(o.getSessionTokenHandler)
+
 	// List buckets
 	// API reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_ListBuckets.html
 	router.NewRoute().Name(ActionToUniqueRouteName(proto.OSSListBucketsAction)).
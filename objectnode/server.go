@@ -23,13 +23,17 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/config"
 	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/federation"
 
 	"github.com/cubefs/cubefs/cmd/common"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
+	"github.com/cubefs/cubefs/util/trace"
 	"github.com/gorilla/mux"
 )
 
@@ -103,11 +107,14 @@ type ObjectNode struct {
 	state      uint32
 	wg         sync.WaitGroup
 	userStore  UserInfoStore
+	keyUsage   *KeyUsageRecorder
+	federation *federation.Federation
 
 	signatureIgnoredActions proto.Actions // signature ignored actions
 	disabledActions         proto.Actions // disabled actions
 
 	encodedRegion []byte
+	tlsConfig     util.TLSConfig
 
 	control common.Control
 }
@@ -176,9 +183,28 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
 
+	// the volumes are accessed with the packet protocol of the data and meta nodes,
+	// the transport is set up with the mode of the cluster after the master is asked
+	o.tlsConfig = util.LoadTLSConfig(cfg)
+
+	// the volumes with authentication enabled are accessed with the tickets of the objectnode
+	var credential *packetauth.Credential
+	if credential, err = packetauth.LoadCredential(cfg); err != nil {
+		return
+	}
+
 	o.mc = master.NewMasterClient(masters, false)
-	o.vm = NewVolumeManager(masters, strict)
+	o.vm = NewVolumeManager(masters, strict, credential)
 	o.userStore = NewUserInfoStore(masters, strict)
+	o.keyUsage = NewKeyUsageRecorder(o.mc)
+
+	// parse identity federation config
+	if o.federation, err = federation.NewFederation(cfg, o.mc); err != nil {
+		return
+	}
+	if o.federation != nil && o.federation.OIDC() != nil {
+		log.LogInfof("loadConfig: web identity federation enabled")
+	}
 
 	return
 }
@@ -207,6 +233,13 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 	o.updateRegion(ci.Cluster)
 	log.LogInfof("handleStart: get cluster information: region(%v)", o.region)
 
+	// the mode of the cluster is followed if not configured
+	var tlsTransport *util.TLSTransport
+	if tlsTransport, err = util.NewTLSTransport(o.tlsConfig.WithClusterMode(ci.TLSMode)); err != nil {
+		return
+	}
+	util.SetTLSTransport(tlsTransport)
+
 	// start rest api
 	if err = o.startMuxRestAPI(); err != nil {
 		log.LogInfof("handleStart: start rest api fail: err(%v)", err)
@@ -216,6 +249,11 @@ func handleStart(s common.Server, cfg *config.Config) (err error) {
 	exporter.Init(cfg.GetString("role"), cfg)
 	exporter.RegistConsul(ci.Cluster, cfg.GetString("role"), cfg)
 
+	if err = trace.Init(cfg.GetString("role"), cfg); err != nil {
+		log.LogErrorf("handleStart: init trace fail: err(%v)", err)
+		return
+	}
+
 	log.LogInfo("object subsystem start success")
 	return
 }
@@ -226,6 +264,10 @@ func handleShutdown(s common.Server) {
 		return
 	}
 	o.shutdownRestAPI()
+	if o.keyUsage != nil {
+		o.keyUsage.Close()
+	}
+	trace.Stop()
 }
 
 func (o *ObjectNode) startMuxRestAPI() (err error) {
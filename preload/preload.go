@@ -10,6 +10,7 @@ import (
 	"github.com/cubefs/cubefs/preload/sdk"
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/packetauth"
 )
 
 var (
@@ -60,6 +61,11 @@ func main() {
 		buffersTotalLimit = int64(32768)
 	}
 	proto.InitBufferPool(buffersTotalLimit)
+	credential, err := packetauth.LoadCredential(cfg)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 	config := sdk.PreloadConfig{
 		Volume:   cfg.GetString("volumeName"),
 		Masters:  masters,
@@ -71,6 +77,7 @@ func main() {
 			ReadBlockConcurrency:   readBlockConcurrency,
 			PreloadFileSizeLimit:   preloadFileSizeLimit,
 			ClearFileConcurrency:   clearFileConcurrency},
+		Credential: credential,
 	}
 
 	cli := sdk.NewClient(config)
@@ -35,6 +35,7 @@ import (
 	masterSDK "github.com/cubefs/cubefs/sdk/master"
 	"github.com/cubefs/cubefs/sdk/meta"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 	"github.com/cubefs/cubefs/util/stat"
 	"github.com/hashicorp/consul/api"
 )
@@ -76,6 +77,7 @@ type PreloadConfig struct {
 	LogLevel   string
 	ProfPort   string
 	LimitParam LimitParameters
+	Credential *packetauth.Credential
 }
 
 func FlushLog() {
@@ -118,6 +120,7 @@ func NewClient(config PreloadConfig) *PreLoadClient {
 		Volume:        config.Volume,
 		Masters:       config.Masters,
 		ValidateOwner: false,
+		Credential:    config.Credential,
 	}); err != nil {
 		log.LogErrorf("newClient NewMetaWrapper failed(%v)", err)
 		return nil
@@ -131,6 +134,7 @@ func NewClient(config PreloadConfig) *PreLoadClient {
 		OnGetExtents:      mw.GetExtents,
 		OnTruncate:        mw.Truncate,
 		VolumeType:        proto.VolumeTypeCold,
+		Credential:        config.Credential,
 	}); err != nil {
 		log.LogErrorf("newClient NewExtentClient failed(%v)", err)
 		return nil
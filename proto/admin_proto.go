@@ -57,12 +57,14 @@ const (
 	AdminVolumeAPI  = "/api/volume"
 
 	//graphql coonsole api
-	ConsoleIQL        = "/iql"
-	ConsoleLoginAPI   = "/login"
-	ConsoleMonitorAPI = "/cfs_monitor"
-	ConsoleFile       = "/file"
-	ConsoleFileDown   = "/file/down"
-	ConsoleFileUpload = "/file/upload"
+	ConsoleIQL              = "/iql"
+	ConsoleLoginAPI         = "/login"
+	ConsoleLoginSSO         = "/login/sso"
+	ConsoleLoginSSOCallback = "/login/sso/callback"
+	ConsoleMonitorAPI       = "/cfs_monitor"
+	ConsoleFile             = "/file"
+	ConsoleFileDown         = "/file/down"
+	ConsoleFileUpload       = "/file/upload"
 
 	// Client APIs
 	ClientDataPartitions = "/client/partitions"
@@ -93,6 +95,8 @@ const (
 	MigrateDataNode                = "/dataNode/migrate"
 	CancelDecommissionDataNode     = "/dataNode/cancelDecommission"
 	DecommissionDisk               = "/disk/decommission"
+	QueryDiskEvacuation            = "/disk/evacuate/query"
+	HoldDiskEvacuation             = "/disk/evacuate/hold"
 	GetDataNode                    = "/dataNode/get"
 	AddMetaNode                    = "/metaNode/add"
 	DecommissionMetaNode           = "/metaNode/decommission"
@@ -104,6 +108,7 @@ const (
 	AdminLoadMetaPartition         = "/metaPartition/load"
 	AdminDiagnoseMetaPartition     = "/metaPartition/diagnose"
 	AdminDecommissionMetaPartition = "/metaPartition/decommission"
+	AdminSplitMetaPartition        = "/metaPartition/split"
 	AdminAddMetaReplica            = "/metaReplica/add"
 	AdminDeleteMetaReplica         = "/metaReplica/delete"
 
@@ -131,6 +136,33 @@ const (
 	UserTransferVol     = "/user/transferVol"
 	UserList            = "/user/list"
 	UsersOfVol          = "/vol/users"
+	UserCreateSession   = "/user/session/create"
+	UserRevokeSession   = "/user/session/revoke"
+	UserListSessions    = "/user/session/list"
+	UserAddKey          = "/user/key/add"
+	UserDeleteKey       = "/user/key/delete"
+	UserSetKeyStatus    = "/user/key/status"
+	UserListKeys        = "/user/key/list"
+	UserReportKeysUsed  = "/user/key/used"
+
+	// APIs for group and role management
+	GroupCreate       = "/group/create"
+	GroupDelete       = "/group/delete"
+	GroupInfo         = "/group/info"
+	GroupList         = "/group/list"
+	GroupAddMember    = "/group/member/add"
+	GroupRemoveMember = "/group/member/remove"
+	GroupUpdatePolicy = "/group/updatePolicy"
+	GroupRemovePolicy = "/group/removePolicy"
+	GroupGrantRole    = "/group/role/grant"
+	GroupRevokeRole   = "/group/role/revoke"
+	RoleCreate        = "/role/create"
+	RoleDelete        = "/role/delete"
+	RoleInfo          = "/role/info"
+	RoleList          = "/role/list"
+	RoleUpdatePolicy  = "/role/updatePolicy"
+	RoleRemovePolicy  = "/role/removePolicy"
+
 	//graphql api for header
 	HeadAuthorized  = "Authorization"
 	ParamAuthorized = "_authorization"
@@ -162,6 +194,7 @@ type ClusterInfo struct {
 	DataNodeAutoRepairLimitRate uint64
 	EbsAddr                     string
 	ServicePath                 string
+	TLSMode                     string // followed by the clients which do not configure it, empty for the old masters
 }
 
 // CreateDataPartitionRequest defines the request to create a data partition.
@@ -297,6 +330,7 @@ type PartitionReport struct {
 	IsLeader        bool
 	ExtentCount     int
 	NeedCompare     bool
+	Scrub           ScrubInfo
 }
 
 type DataNodeQosResponse struct {
@@ -345,6 +379,7 @@ type MetaNodeHeartbeatResponse struct {
 	Total                uint64
 	Used                 uint64
 	MetaPartitionReports []*MetaPartitionReport
+	SupportInlineData    bool // the node decodes the inodes carrying inline data
 	Status               uint8
 	Result               string
 }
@@ -392,6 +427,24 @@ type UpdateMetaPartitionResponse struct {
 	Result      string
 }
 
+// The steps of a meta partition split, see SplitMetaPartitionRequest.
+const (
+	SplitMetaPartitionCut   uint8 = iota // cut the end of PartitionID at End
+	SplitMetaPartitionSeed               // seed NewPartitionID from the local replicas of PartitionID
+	SplitMetaPartitionCheck              // check that the local replica of NewPartitionID is seeded
+	SplitMetaPartitionDrop               // drop the items beyond End from PartitionID
+)
+
+// SplitMetaPartitionRequest defines the request to split a meta partition at End.
+// The inodes beyond End and the dentries whose parent is beyond End are moved into the partition NewPartitionID.
+type SplitMetaPartitionRequest struct {
+	PartitionID    uint64
+	VolName        string
+	End            uint64
+	NewPartitionID uint64
+	Step           uint8
+}
+
 // MetaPartitionDecommissionRequest defines the request of decommissioning a meta partition.
 type MetaPartitionDecommissionRequest struct {
 	PartitionID uint64
@@ -435,6 +488,8 @@ type DataPartitionResponse struct {
 	Epoch         uint64
 	IsRecover     bool
 	PartitionTTL  int64
+	Total         uint64
+	Used          uint64
 }
 
 // DataPartitionsView defines the view of a data partition
@@ -617,6 +672,7 @@ type SimpleVolView struct {
 	DpSelectorName     string
 	DpSelectorParm     string
 	DefaultZonePrior   bool
+	InlineSizeLimit    uint64
 
 	VolType          int
 	ObjBlockSize     int
@@ -44,6 +44,7 @@ const (
 	APIAccess       = "access"
 	capSeparator    = ":"
 	reqLiveLength   = 10
+	ReqLiveLength   = reqLiveLength // seconds a verifier is accepted
 	ClientMessage   = "Token"
 	OwnerVOLRsc     = "OwnerVOL"
 	NoneOwnerVOLRsc = "NoneOwnerVOL"
@@ -62,6 +63,10 @@ const (
 	AdminAddCaps    = "/admin/addcaps"
 	AdminDeleteCaps = "/admin/deletecaps"
 	AdminGetCaps    = "/admin/getcaps"
+	AdminRotateKey  = "/admin/rotatekey"
+
+	// root key APIs
+	AdminRotateRootKey = "/admin/rotaterootkey"
 
 	//raft node APIs
 	AdminAddRaftNode    = "/admin/addraftnode"
@@ -80,16 +85,35 @@ const (
 	// MasterServiceID defines ticket for master access
 	MasterServiceID = "MasterService"
 
-	// MetaServiceID defines ticket for metanode access (not supported)
+	// MetaServiceID defines ticket for metanode access
 	MetaServiceID = "MetanodeService"
 
-	// DataServiceID defines ticket for datanode access (not supported)
+	// DataServiceID defines ticket for datanode access
 	DataServiceID = "DatanodeService"
 
 	//ObjectServiceID defines ticket for objectnode access
 	ObjectServiceID = "ObjectService"
+
+	// peerServiceSuffix marks the service tickets that the nodes holding the service key
+	// issue to themselves, never issued by the authnode
+	peerServiceSuffix = "@Peer"
+	// masterServiceSuffix marks the service tickets that the master holding the service key
+	// issues to itself for the admin commands, never issued by the authnode
+	masterServiceSuffix = "@Master"
 )
 
+// PeerServiceID returns the service ID of the tickets presented by the peers of the service,
+// e.g. a datanode forwarding a packet to the followers or a metanode deleting extents.
+func PeerServiceID(serviceID string) string {
+	return serviceID + peerServiceSuffix
+}
+
+// MasterTicketServiceID returns the service ID of the tickets presented by the master to the
+// nodes of the service, which are required by the admin commands.
+func MasterTicketServiceID(serviceID string) string {
+	return serviceID + masterServiceSuffix
+}
+
 const (
 	MasterNode = "master"
 	MetaNode   = "metanode"
@@ -172,6 +196,18 @@ const (
 	// MsgAuthRemoveRaftNodeResp response type for authnode remove node
 	MsgAuthRemoveRaftNodeResp MsgType = MsgAuthBase + 0x58001
 
+	// MsgAuthRotateKeyReq request type for authnode rotate key
+	MsgAuthRotateKeyReq MsgType = MsgAuthBase + 0x59000
+
+	// MsgAuthRotateKeyResp response type for authnode rotate key
+	MsgAuthRotateKeyResp MsgType = MsgAuthBase + 0x59001
+
+	// MsgAuthRotateRootKeyReq request type for authnode rotate root key
+	MsgAuthRotateRootKeyReq MsgType = MsgAuthBase + 0x5A000
+
+	// MsgAuthRotateRootKeyResp response type for authnode rotate root key
+	MsgAuthRotateRootKeyResp MsgType = MsgAuthBase + 0x5A001
+
 	// MsgAuthOSAddCapsReq request type from ObjectNode to add caps
 	MsgAuthOSAddCapsReq MsgType = MsgAuthBase + 0x61000
 
@@ -217,6 +253,8 @@ var MsgType2ResourceMap = map[MsgType]string{
 	MsgAuthGetCapsReq:        "auth:getcaps",
 	MsgAuthAddRaftNodeReq:    "auth:addnode",
 	MsgAuthRemoveRaftNodeReq: "auth:removenode",
+	MsgAuthRotateKeyReq:      "auth:rotatekey",
+	MsgAuthRotateRootKeyReq:  "auth:rotaterootkey",
 	MsgAuthOSAddCapsReq:      "auth:osaddcaps",
 	MsgAuthOSDeleteCapsReq:   "auth:osdeletecaps",
 	MsgAuthOSGetCapsReq:      "auth:osgetcaps",
@@ -269,6 +307,8 @@ type APIAccessResp struct {
 type AuthAPIAccessReq struct {
 	APIReq  APIAccessReq     `json:"api_req"`
 	KeyInfo keystore.KeyInfo `json:"key_info"`
+	// seconds the previous key is still accepted after a key rotation
+	KeyGrace int64 `json:"key_grace,omitempty"`
 }
 
 // AuthAPIAccessResp defines the response for creating an key in authnode
@@ -438,12 +478,23 @@ func ParseAuthOSAKResp(body []byte, key []byte) (resp AuthOSAccessKeyResp, err e
 	return
 }
 
-func ExtractTicket(str string, key []byte) (ticket cryptoutil.Ticket, err error) {
+// ExtractTicket decrypts the ticket with the keys of the service in turn, so that the tickets
+// sealed by the previous key are accepted while the key is being rotated.
+func ExtractTicket(str string, keys ...[]byte) (ticket cryptoutil.Ticket, err error) {
 	var (
 		plaintext []byte
 	)
 
-	if plaintext, err = cryptoutil.DecodeMessage(str, key); err != nil {
+	if len(keys) == 0 {
+		err = fmt.Errorf("no service key")
+		return
+	}
+	for _, key := range keys {
+		if plaintext, err = cryptoutil.DecodeMessage(str, key); err == nil {
+			break
+		}
+	}
+	if err != nil {
 		return
 	}
 
@@ -506,8 +557,8 @@ func VerifyAPIAccessReqIDs(req *APIAccessReq) (err error) {
 }
 
 // ExtractAPIAccessTicket verify ticket validity
-func ExtractAPIAccessTicket(req *APIAccessReq, key []byte) (ticket cryptoutil.Ticket, ts int64, err error) {
-	if ticket, err = ExtractTicket(req.Ticket, key); err != nil {
+func ExtractAPIAccessTicket(req *APIAccessReq, keys ...[]byte) (ticket cryptoutil.Ticket, ts int64, err error) {
+	if ticket, err = ExtractTicket(req.Ticket, keys...); err != nil {
 		err = fmt.Errorf("extractTicket failed: %s", err.Error())
 		return
 	}
@@ -525,6 +576,26 @@ func ExtractAPIAccessTicket(req *APIAccessReq, key []byte) (ticket cryptoutil.Ti
 	return
 }
 
+// ExtractPacketAuthTicket verifies the ticket presented on a metanode or datanode connection,
+// either issued by the authnode to a client or a service ticket of a peer.
+func ExtractPacketAuthTicket(req *APIAccessReq, serviceID string, keys ...[]byte) (ticket cryptoutil.Ticket, ts int64, err error) {
+	if req.ServiceID != serviceID {
+		err = fmt.Errorf("invalid service ID [%s]", req.ServiceID)
+		return
+	}
+
+	if ticket, ts, err = ExtractAPIAccessTicket(req, keys...); err != nil {
+		return
+	}
+
+	if ticket.ServiceID != serviceID && ticket.ServiceID != PeerServiceID(serviceID) && ticket.ServiceID != MasterTicketServiceID(serviceID) {
+		err = fmt.Errorf("ticket of service [%s] is not accepted by [%s]", ticket.ServiceID, serviceID)
+		return
+	}
+
+	return
+}
+
 // CheckAPIAccessCaps checks capability
 func CheckAPIAccessCaps(ticket *cryptoutil.Ticket, rscType string, mp MsgType, action string) (err error) {
 	if _, ok := MsgType2ResourceMap[mp]; !ok {
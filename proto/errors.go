@@ -82,6 +82,10 @@ var (
 	ErrInvalidSecretKey                = errors.New("invalid secret key")
 	ErrIsOwner                         = errors.New("user owns the volume")
 	ErrZoneNum                         = errors.New("zone num not qualified")
+	ErrGroupNotExists                  = errors.New("group not exists")
+	ErrDuplicateGroupID                = errors.New("duplicate group id")
+	ErrRoleNotExists                   = errors.New("role not exists")
+	ErrDuplicateRoleID                 = errors.New("duplicate role id")
 )
 
 // http response error code and error message definitions
@@ -146,6 +150,10 @@ const (
 	ErrCodeInvalidSecretKey
 	ErrCodeIsOwner
 	ErrCodeZoneNumError
+	ErrCodeGroupNotExists
+	ErrCodeDuplicateGroupID
+	ErrCodeRoleNotExists
+	ErrCodeDuplicateRoleID
 )
 
 // Err2CodeMap error map to code
@@ -208,6 +216,10 @@ var Err2CodeMap = map[error]int32{
 	ErrInvalidSecretKey:                ErrCodeInvalidSecretKey,
 	ErrIsOwner:                         ErrCodeIsOwner,
 	ErrZoneNum:                         ErrCodeZoneNumError,
+	ErrGroupNotExists:                  ErrCodeGroupNotExists,
+	ErrDuplicateGroupID:                ErrCodeDuplicateGroupID,
+	ErrRoleNotExists:                   ErrCodeRoleNotExists,
+	ErrDuplicateRoleID:                 ErrCodeDuplicateRoleID,
 }
 
 func ParseErrorCode(code int32) error {
@@ -277,6 +289,10 @@ var code2ErrMap = map[int32]error{
 	ErrCodeInvalidSecretKey:                ErrInvalidSecretKey,
 	ErrCodeIsOwner:                         ErrIsOwner,
 	ErrCodeZoneNumError:                    ErrZoneNum,
+	ErrCodeGroupNotExists:                  ErrGroupNotExists,
+	ErrCodeDuplicateGroupID:                ErrDuplicateGroupID,
+	ErrCodeRoleNotExists:                   ErrRoleNotExists,
+	ErrCodeDuplicateRoleID:                 ErrDuplicateRoleID,
 }
 
 type GeneralResp struct {
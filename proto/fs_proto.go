@@ -30,8 +30,30 @@ const (
 	FlagsSyncWrite int = 1 << iota
 	FlagsAppend
 	FlagsCache
+	FlagsNoInline // never store the data inline in the inode
 )
 
+// Read consistency levels of the data path.
+const (
+	ReadConsistencyDefault = ""        // leader or any, decided by followerRead of the volume
+	ReadConsistencyLeader  = "leader"  // always read from the leader
+	ReadConsistencyBounded = "bounded" // read from a follower which has applied what the leader applied recently
+	ReadConsistencyAny     = "any"     // read from any replica
+)
+
+// IsValidReadConsistency returns whether the read consistency level is known.
+func IsValidReadConsistency(level string) bool {
+	switch level {
+	case ReadConsistencyDefault, ReadConsistencyLeader, ReadConsistencyBounded, ReadConsistencyAny:
+		return true
+	}
+	return false
+}
+
+// MaxInlineDataSize is the upper bound of the data stored inline in an inode.
+// The per-volume inline size limit can not exceed it.
+const MaxInlineDataSize uint64 = 64 * 1024
+
 // Mode returns the fileMode.
 func Mode(osMode os.FileMode) uint32 {
 	return uint32(osMode)
@@ -364,6 +386,15 @@ type AppendObjExtentKeysRequest struct {
 	Extents     []ObjExtentKey `json:"ek"`
 }
 
+// ReplaceObjExtentKeyRequest defines the request to swap one obj extent key of an inode.
+type ReplaceObjExtentKeyRequest struct {
+	VolName     string       `json:"vol"`
+	PartitionID uint64       `json:"pid"`
+	Inode       uint64       `json:"ino"`
+	OldKey      ObjExtentKey `json:"oek"`
+	NewKey      ObjExtentKey `json:"nek"`
+}
+
 // GetExtentsRequest defines the reques to get extents.
 type GetExtentsRequest struct {
 	VolName     string `json:"vol"`
@@ -386,6 +417,29 @@ type GetExtentsResponse struct {
 	Extents    []ExtentKey `json:"eks"`
 }
 
+// InlineWriteRequest defines the request to write file data inline into the inode.
+type InlineWriteRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+	Offset      uint64 `json:"off"`
+	Data        []byte `json:"data"`
+}
+
+// InlineReadRequest defines the request to read the inline data of an inode.
+type InlineReadRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Inode       uint64 `json:"ino"`
+}
+
+// InlineReadResponse defines the response to the request of reading inline data.
+type InlineReadResponse struct {
+	Generation uint64 `json:"gen"`
+	Size       uint64 `json:"sz"`
+	Data       []byte `json:"data"`
+}
+
 // TruncateRequest defines the request to truncate.
 type TruncateRequest struct {
 	VolName     string `json:"vol"`
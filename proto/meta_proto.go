@@ -39,6 +39,7 @@ type CreateMetaPartitionRequest struct {
 	End         uint64
 	PartitionID uint64
 	Members     []Peer
+	SplitFrom   uint64 // the partition to be split into this one, see SplitMetaPartitionRequest
 }
 
 // CreateMetaPartitionResponse defines the response to the request of creating a meta partition.
@@ -235,6 +235,23 @@ type DataReplica struct {
 	IsLeader        bool
 	NeedsToCompare  bool
 	DiskPath        string
+	Scrub           ScrubInfo
+}
+
+// ScrubInfo is the progress of the background extent scrubbing of a data partition replica.
+type ScrubInfo struct {
+	Round           uint64 // finished rounds
+	TotalExtents    int
+	ScrubbedExtents int // extents scrubbed in the current round
+	CheckedBlocks   uint64
+	CorruptBlocks   uint64
+	RepairedBlocks  uint64
+	LastScrubTime   int64
+}
+
+// Unrepaired returns the number of corrupt blocks that no healthy replica has fixed.
+func (s *ScrubInfo) Unrepaired() uint64 {
+	return s.CorruptBlocks - s.RepairedBlocks
 }
 
 // data partition diagnosis represents the inactive data nodes, corrupt data partitions, and data partitions lack of replicas
@@ -243,6 +260,7 @@ type DataPartitionDiagnosis struct {
 	CorruptDataPartitionIDs     []uint64
 	LackReplicaDataPartitionIDs []uint64
 	BadDataPartitionIDs         []BadPartitionView
+	ScrubCorruptPartitionIDs    []uint64
 }
 
 // meta partition diagnosis represents the inactive meta nodes, corrupt meta partitions, and meta partitions lack of replicas
@@ -252,3 +270,30 @@ type MetaPartitionDiagnosis struct {
 	LackReplicaMetaPartitionIDs []uint64
 	BadMetaPartitionIDs         []BadPartitionView
 }
+
+// status of the automatic evacuation of a faulty disk
+const (
+	DiskEvacuationHeld     = "held"
+	DiskEvacuationRunning  = "running"
+	DiskEvacuationFinished = "finished"
+)
+
+// DiskEvacuationView is the job migrating all the data partitions off a faulty disk.
+type DiskEvacuationView struct {
+	Addr       string
+	DiskPath   string
+	Status     string
+	Remaining  int // partitions still on the disk
+	Migrated   int
+	Running    []uint64
+	Failed     map[uint64]string // partition id to the last error
+	CreateTime int64
+	UpdateTime int64
+}
+
+// DiskEvacuationsView is the response of querying the disk evacuations.
+type DiskEvacuationsView struct {
+	Limit int      // max partitions migrating at the same time on a node
+	Holds []string // held nodes or node disks in the format of addr[:disk]
+	Jobs  []*DiskEvacuationView
+}
@@ -62,6 +62,13 @@ const (
 	MetaSendTimeout
 	BuffersTotalLimit
 	MaxStreamerLimit
+	ReadAheadMemMB
+	ReadAheadWindowMB
+	WriteBackCache
+	ReadConsistency
+	ReadMaxStalenessMS
+	EbsPackSize
+	EbsPackCompact
 
 	MaxMountOption
 )
@@ -143,6 +150,13 @@ func InitMountOptions(opts []MountOption) {
 	opts[MetaSendTimeout] = MountOption{"metaSendTimeout", "Meta send timeout", "", int64(600)}
 	opts[BuffersTotalLimit] = MountOption{"buffersTotalLimit", "Send/Receive packets memory limit", "", int64(32768)} //default 4G
 	opts[MaxStreamerLimit] = MountOption{"maxStreamerLimit", "The maximum number of streamers", "", int64(0)}         // default 0
+	opts[ReadAheadMemMB] = MountOption{"readAheadMemMB", "Memory limit of read-ahead in MB, 0 means disable read-ahead", "", int64(0)}
+	opts[ReadAheadWindowMB] = MountOption{"readAheadWindowMB", "The maximum read-ahead window of one file in MB", "", int64(16)}
+	opts[ReadConsistency] = MountOption{"readConsistency", "Read consistency: leader, bounded or any, empty follows followerRead", "", ""}
+	opts[ReadMaxStalenessMS] = MountOption{"readMaxStalenessMS", "Max staleness in ms of bounded consistency read", "", int64(1000)}
+	opts[WriteBackCache] = MountOption{"writeBackCache", "Merge small writes in client memory before sending to datanodes", "", false}
+	opts[EbsPackSize] = MountOption{"ebsPackSize", "Pack cold volume files up to this size in bytes into shared blobs, 0 means disable", "", int64(0)}
+	opts[EbsPackCompact] = MountOption{"ebsPackCompact", "Run the compactor of packed blobs on this client", "", false}
 
 	for i := 0; i < MaxMountOption; i++ {
 		flag.StringVar(&opts[i].cmdlineValue, opts[i].keyword, "", opts[i].description)
@@ -275,6 +289,7 @@ type MountOptions struct {
 	VolType              int
 	EbsEndpoint          string
 	EbsServicePath       string
+	TLSMode              string // tls mode of the cluster, taken from the master
 	CacheAction          int
 	CacheThreshold       int
 	EbsBlockSize         int
@@ -288,4 +303,11 @@ type MountOptions struct {
 	MetaSendTimeout      int64
 	BuffersTotalLimit    int64
 	MaxStreamerLimit     int64
+	ReadAheadMemMB       int64
+	ReadAheadWindowMB    int64
+	WriteBackCache       bool
+	ReadConsistency      string
+	ReadMaxStalenessMS   int64
+	EbsPackSize          int64
+	EbsPackCompact       bool
 }
@@ -28,6 +28,9 @@ type Blob struct {
 	Vid    uint64
 }
 
+// packedFlag is set in the marshaled blobs length of a key that refers to a slice of a shared blob.
+const packedFlag uint32 = 1 << 31
+
 // ExtentKey defines the extent key struct.
 type ObjExtentKey struct {
 	Cid        uint64 // cluster id
@@ -38,11 +41,18 @@ type ObjExtentKey struct {
 	Blobs      []Blob
 	FileOffset uint64 // obj offset in file
 	Crc        uint32
+	BlobOffset uint64 // slice offset in a packed blob
+	PackSize   uint64 // size of the whole packed blob, zero if not packed
 }
 
 // String returns the string format of the extentKey.
 func (k ObjExtentKey) String() string {
-	return fmt.Sprintf("ObjExtentKey{FileOffset(%v),Cid(%v),CodeMode(%v),BlobSize(%v),BlobsLen(%v),Blobs(%v),Size(%v),Crc(%v)}", k.FileOffset, k.Cid, k.CodeMode, k.BlobSize, k.BlobsLen, k.Blobs, k.Size, k.Crc)
+	return fmt.Sprintf("ObjExtentKey{FileOffset(%v),Cid(%v),CodeMode(%v),BlobSize(%v),BlobsLen(%v),Blobs(%v),Size(%v),Crc(%v),BlobOffset(%v),PackSize(%v)}", k.FileOffset, k.Cid, k.CodeMode, k.BlobSize, k.BlobsLen, k.Blobs, k.Size, k.Crc, k.BlobOffset, k.PackSize)
+}
+
+// IsPacked returns true if the key refers to a slice of a blob shared with other files.
+func (k *ObjExtentKey) IsPacked() bool {
+	return k.PackSize > 0
 }
 
 // Less defines the less comparator.
@@ -78,6 +88,12 @@ func (k *ObjExtentKey) IsEquals(obj *ObjExtentKey) bool {
 	if k.Crc != obj.Crc {
 		return false
 	}
+	if k.BlobOffset != obj.BlobOffset || k.PackSize != obj.PackSize {
+		return false
+	}
+	if len(k.Blobs) != len(obj.Blobs) {
+		return false
+	}
 	if len(k.Blobs) > 0 {
 		for i := len(k.Blobs) - 1; i >= 0; i-- {
 			if k.Blobs[i].Count != obj.Blobs[i].Count || k.Blobs[i].MinBid != obj.Blobs[i].MinBid || k.Blobs[i].Vid != obj.Blobs[i].Vid {
@@ -91,7 +107,11 @@ func (k *ObjExtentKey) IsEquals(obj *ObjExtentKey) bool {
 // MarshalBinary marshals the binary format of the extent key.
 func (k *ObjExtentKey) MarshalBinary() ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0))
-	if err := binary.Write(buf, binary.BigEndian, uint32(len(k.Blobs))); err != nil {
+	blobsLen := uint32(len(k.Blobs))
+	if k.IsPacked() {
+		blobsLen |= packedFlag
+	}
+	if err := binary.Write(buf, binary.BigEndian, blobsLen); err != nil {
 		return nil, err
 	}
 	if err := binary.Write(buf, binary.BigEndian, k.FileOffset); err != nil {
@@ -115,6 +135,14 @@ func (k *ObjExtentKey) MarshalBinary() ([]byte, error) {
 	if err := binary.Write(buf, binary.BigEndian, k.Blobs); err != nil {
 		return nil, err
 	}
+	if k.IsPacked() {
+		if err := binary.Write(buf, binary.BigEndian, k.BlobOffset); err != nil {
+			return nil, err
+		}
+		if err := binary.Write(buf, binary.BigEndian, k.PackSize); err != nil {
+			return nil, err
+		}
+	}
 	return buf.Bytes(), nil
 }
 
@@ -122,6 +150,8 @@ func (k *ObjExtentKey) UnmarshalBinary(buf *bytes.Buffer) (err error) {
 	if err = binary.Read(buf, binary.BigEndian, &k.BlobsLen); err != nil {
 		return
 	}
+	packed := k.BlobsLen&packedFlag != 0
+	k.BlobsLen &^= packedFlag
 	if err = binary.Read(buf, binary.BigEndian, &k.FileOffset); err != nil {
 		return
 	}
@@ -149,6 +179,15 @@ func (k *ObjExtentKey) UnmarshalBinary(buf *bytes.Buffer) (err error) {
 		blobs = append(blobs, tmpBlob)
 	}
 	k.Blobs = blobs
+	k.BlobOffset, k.PackSize = 0, 0
+	if packed {
+		if err = binary.Read(buf, binary.BigEndian, &k.BlobOffset); err != nil {
+			return
+		}
+		if err = binary.Read(buf, binary.BigEndian, &k.PackSize); err != nil {
+			return
+		}
+	}
 
 	return
 }
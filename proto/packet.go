@@ -28,6 +28,7 @@ import (
 
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/buf"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 var (
@@ -100,6 +101,8 @@ const (
 	OpMetaBatchGetXAttr      uint8 = 0x39
 	OpMetaExtentAddWithCheck uint8 = 0x3A // Append extent key with discard extents check
 	OpMetaReadDirLimit       uint8 = 0x3D
+	OpMetaInlineWrite        uint8 = 0x3E // write file data inline into the inode
+	OpMetaInlineRead         uint8 = 0x3F // read the inline data of an inode
 
 	// Operations: Master -> MetaNode
 	OpCreateMetaPartition           uint8 = 0x40
@@ -111,6 +114,7 @@ const (
 	OpAddMetaPartitionRaftMember    uint8 = 0x46
 	OpRemoveMetaPartitionRaftMember uint8 = 0x47
 	OpMetaPartitionTryToLeader      uint8 = 0x48
+	OpSplitMetaPartition            uint8 = 0x49
 
 	// Operations: Master -> DataNode
 	OpCreateDataPartition           uint8 = 0x60
@@ -141,6 +145,8 @@ const (
 	OpMetaBatchEvictInode   uint8 = 0x93
 
 	// Commons
+	OpInodeOutOfRangeErr uint8 = 0xEF
+	OpInlineFullErr      uint8 = 0xF1
 	OpConflictExtentsErr uint8 = 0xF2
 	OpIntraGroupNetErr   uint8 = 0xF3
 	OpArgMismatchErr     uint8 = 0xF4
@@ -166,6 +172,10 @@ const (
 	OpMetaExtentsEmpty       uint8 = 0xDF
 	OpMetaBatchObjExtentsAdd uint8 = 0xD0
 	OpMetaClearInodeCache    uint8 = 0xD1
+	OpMetaObjExtentReplace   uint8 = 0xD2 // swap a packed obj extent key for another one
+
+	// Operations: Client -> MetaNode/DataNode
+	OpAuthenticate uint8 = 0xE0 // present an authnode ticket on the connection
 )
 
 const (
@@ -181,6 +191,11 @@ const (
 const (
 	TinyExtentType   = 0
 	NormalExtentType = 1
+
+	// PacketTraceFlag is set in the extent type of the header if the trace context follows the
+	// header. The nodes before it was introduced can't parse such packets, so a packet carries
+	// the trace context only if its trace is sampled.
+	PacketTraceFlag = 0x80
 )
 
 const (
@@ -208,6 +223,8 @@ type Packet struct {
 	StartT             int64
 	mesg               string
 	HasPrepare         bool
+	TraceContext       trace.SpanContext // propagated to the receiver if valid
+	hasTraceContext    bool
 }
 
 // NewPacket returns a new packet.
@@ -293,6 +310,10 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaReadDir"
 	case OpMetaReadDirLimit:
 		m = "OpMetaReadDirLimit"
+	case OpMetaInlineWrite:
+		m = "OpMetaInlineWrite"
+	case OpMetaInlineRead:
+		m = "OpMetaInlineRead"
 	case OpMetaInodeGet:
 		m = "OpMetaInodeGet"
 	case OpMetaBatchInodeGet:
@@ -375,6 +396,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpRemoveMetaPartitionRaftMember"
 	case OpMetaPartitionTryToLeader:
 		m = "OpMetaPartitionTryToLeader"
+	case OpSplitMetaPartition:
+		m = "OpSplitMetaPartition"
 	case OpDataPartitionTryToLeader:
 		m = "OpDataPartitionTryToLeader"
 	case OpMetaDeleteInode:
@@ -385,6 +408,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaBatchExtentsAdd"
 	case OpMetaBatchObjExtentsAdd:
 		m = "OpMetaBatchObjExtentsAdd"
+	case OpMetaObjExtentReplace:
+		m = "OpMetaObjExtentReplace"
 	case OpMetaSetXAttr:
 		m = "OpMetaSetXAttr"
 	case OpMetaGetXAttr:
@@ -411,6 +436,8 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpBatchDeleteExtent"
 	case OpMetaClearInodeCache:
 		m = "OpMetaClearInodeCache"
+	case OpAuthenticate:
+		m = "OpAuthenticate"
 	}
 	return
 }
@@ -422,6 +449,10 @@ func (p *Packet) GetResultMsg() (m string) {
 	}
 
 	switch p.ResultCode {
+	case OpInodeOutOfRangeErr:
+		m = "InodeOutOfRangeErr"
+	case OpInlineFullErr:
+		m = "InlineFullErr"
 	case OpConflictExtentsErr:
 		m = "ConflictExtentsErr"
 	case OpIntraGroupNetErr:
@@ -464,6 +495,9 @@ func (p *Packet) GetReqID() int64 {
 func (p *Packet) MarshalHeader(out []byte) {
 	out[0] = p.Magic
 	out[1] = p.ExtentType
+	if p.carriesTraceContext() {
+		out[1] |= PacketTraceFlag
+	}
 	out[2] = p.Opcode
 	out[3] = p.ResultCode
 	out[4] = p.RemainingFollowers
@@ -485,7 +519,11 @@ func (p *Packet) UnmarshalHeader(in []byte) error {
 		return errors.New("Bad Magic " + strconv.Itoa(int(p.Magic)))
 	}
 
-	p.ExtentType = in[1]
+	p.ExtentType = in[1] &^ PacketTraceFlag
+	p.hasTraceContext = in[1]&PacketTraceFlag != 0
+	if !p.hasTraceContext {
+		p.TraceContext = trace.SpanContext{}
+	}
 	p.Opcode = in[2]
 	p.ResultCode = in[3]
 	p.RemainingFollowers = in[4]
@@ -501,6 +539,35 @@ func (p *Packet) UnmarshalHeader(in []byte) error {
 	return nil
 }
 
+// ReadTraceContext reads the trace context following the header if the header has the
+// PacketTraceFlag. It should be invoked after UnmarshalHeader.
+func (p *Packet) ReadTraceContext(c io.Reader) (err error) {
+	if !p.hasTraceContext {
+		return
+	}
+	var in [trace.SpanContextSize]byte
+	if _, err = io.ReadFull(c, in[:]); err != nil {
+		return
+	}
+	return p.TraceContext.Unmarshal(in[:])
+}
+
+// carriesTraceContext returns whether the trace context is sent with the packet, the packets
+// of the unsampled traces are encoded as before the trace context was introduced.
+func (p *Packet) carriesTraceContext() bool {
+	return p.TraceContext.IsValid() && p.TraceContext.IsSampled()
+}
+
+func (p *Packet) writeTraceContext(c io.Writer) (err error) {
+	if !p.carriesTraceContext() {
+		return
+	}
+	var out [trace.SpanContextSize]byte
+	p.TraceContext.Marshal(out[:])
+	_, err = c.Write(out[:])
+	return
+}
+
 // MarshalData marshals the packet data.
 func (p *Packet) MarshalData(v interface{}) error {
 	data, err := json.Marshal(v)
@@ -526,9 +593,11 @@ func (p *Packet) WriteToNoDeadLineConn(c net.Conn) (err error) {
 
 	p.MarshalHeader(header)
 	if _, err = c.Write(header); err == nil {
-		if _, err = c.Write(p.Arg[:int(p.ArgLen)]); err == nil {
-			if p.Data != nil {
-				_, err = c.Write(p.Data[:p.Size])
+		if err = p.writeTraceContext(c); err == nil {
+			if _, err = c.Write(p.Arg[:int(p.ArgLen)]); err == nil {
+				if p.Data != nil {
+					_, err = c.Write(p.Data[:p.Size])
+				}
 			}
 		}
 	}
@@ -546,9 +615,11 @@ func (p *Packet) WriteToConn(c net.Conn) (err error) {
 	c.SetWriteDeadline(time.Now().Add(WriteDeadlineTime * time.Second))
 	p.MarshalHeader(header)
 	if _, err = c.Write(header); err == nil {
-		if _, err = c.Write(p.Arg[:int(p.ArgLen)]); err == nil {
-			if p.Data != nil && p.Size != 0 {
-				_, err = c.Write(p.Data[:p.Size])
+		if err = p.writeTraceContext(c); err == nil {
+			if _, err = c.Write(p.Arg[:int(p.ArgLen)]); err == nil {
+				if p.Data != nil && p.Size != 0 {
+					_, err = c.Write(p.Data[:p.Size])
+				}
 			}
 		}
 	}
@@ -585,6 +656,9 @@ func (p *Packet) ReadFromConn(c net.Conn, timeoutSec int) (err error) {
 	if err = p.UnmarshalHeader(header); err != nil {
 		return
 	}
+	if err = p.ReadTraceContext(c); err != nil {
+		return
+	}
 
 	if p.ArgLen > 0 {
 		p.Arg = make([]byte, int(p.ArgLen))
@@ -746,6 +820,26 @@ func (p *Packet) IsBatchDeleteExtents() bool {
 	return p.Opcode == OpBatchDeleteExtent
 }
 
+// SetMinAppliedID carries the raft applied ID a follower must reach before
+// serving an OpStreamFollowerRead. It is encoded as decimal text, so that it
+// can never be taken as follower addresses by the replication protocol.
+func (p *Packet) SetMinAppliedID(appliedID uint64) {
+	p.Arg = []byte(strconv.FormatUint(appliedID, 10))
+	p.ArgLen = uint32(len(p.Arg))
+}
+
+// GetMinAppliedID returns the applied ID required by a follower read, 0 means no requirement.
+func (p *Packet) GetMinAppliedID() uint64 {
+	if p.Opcode != OpStreamFollowerRead || p.ArgLen == 0 || len(p.Arg) < int(p.ArgLen) {
+		return 0
+	}
+	appliedID, err := strconv.ParseUint(string(p.Arg[:p.ArgLen]), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return appliedID
+}
+
 func InitBufferPool(bufLimit int64) {
 	buf.NormalBuffersTotalLimit = bufLimit
 	buf.HeadBuffersTotalLimit = bufLimit
@@ -0,0 +1,162 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/trace"
+)
+
+var testSpanContext = trace.SpanContext{
+	TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+	SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	Flags:   trace.FlagSampled,
+}
+
+func init() {
+	InitBufferPool(int64(32768))
+}
+
+func newTestPacket() *Packet {
+	p := NewPacket()
+	p.ExtentType = NormalExtentType
+	p.Opcode = OpWrite
+	p.RemainingFollowers = 2
+	p.PartitionID = 10
+	p.ExtentID = 1024
+	p.ExtentOffset = 4096
+	p.KernelOffset = 8192
+	p.ReqID = 12345
+	p.Arg = []byte("192.168.0.1:17310/192.168.0.2:17310/")
+	p.ArgLen = uint32(len(p.Arg))
+	p.Data = []byte("hello cubefs")
+	p.Size = uint32(len(p.Data))
+	p.CRC = 0xCF5A
+	return p
+}
+
+// baselineEncoding encodes the packet as before the trace context was introduced.
+func baselineEncoding(p *Packet) []byte {
+	out := make([]byte, util.PacketHeaderSize)
+	out[0] = p.Magic
+	out[1] = p.ExtentType
+	out[2] = p.Opcode
+	out[3] = p.ResultCode
+	out[4] = p.RemainingFollowers
+	binary.BigEndian.PutUint32(out[5:9], p.CRC)
+	binary.BigEndian.PutUint32(out[9:13], p.Size)
+	binary.BigEndian.PutUint32(out[13:17], p.ArgLen)
+	binary.BigEndian.PutUint64(out[17:25], p.PartitionID)
+	binary.BigEndian.PutUint64(out[25:33], p.ExtentID)
+	binary.BigEndian.PutUint64(out[33:41], uint64(p.ExtentOffset))
+	binary.BigEndian.PutUint64(out[41:49], uint64(p.ReqID))
+	binary.BigEndian.PutUint64(out[49:util.PacketHeaderSize], p.KernelOffset)
+	out = append(out, p.Arg[:p.ArgLen]...)
+	return append(out, p.Data[:p.Size]...)
+}
+
+func encodePacket(t *testing.T, p *Packet) []byte {
+	client, server := net.Pipe()
+	go func() {
+		if err := p.WriteToConn(client); err != nil {
+			t.Error(err)
+		}
+		client.Close()
+	}()
+	data, err := ioutil.ReadAll(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPacketTraceContextRoundTrip(t *testing.T) {
+	p := newTestPacket()
+	p.TraceContext = testSpanContext
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		if err := p.WriteToConn(client); err != nil {
+			t.Error(err)
+		}
+		client.Close()
+	}()
+	got := new(Packet)
+	if err := got.ReadFromConn(server, ReadDeadlineTime); err != nil {
+		t.Fatal(err)
+	}
+	if got.TraceContext != testSpanContext {
+		t.Fatalf("trace context %+v, expect %+v", got.TraceContext, testSpanContext)
+	}
+	if got.ExtentType != NormalExtentType || got.Opcode != p.Opcode || got.RemainingFollowers != p.RemainingFollowers ||
+		got.CRC != p.CRC || got.PartitionID != p.PartitionID || got.ExtentID != p.ExtentID ||
+		got.ExtentOffset != p.ExtentOffset || got.KernelOffset != p.KernelOffset || got.ReqID != p.ReqID {
+		t.Fatalf("header %+v, expect %+v", got, p)
+	}
+	if !bytes.Equal(got.Arg, p.Arg) || !bytes.Equal(got.Data, p.Data) {
+		t.Fatalf("arg [%s] data [%s], expect [%s] [%s]", got.Arg, got.Data, p.Arg, p.Data)
+	}
+
+	encoded := encodePacket(t, p)
+	if len(encoded) != len(baselineEncoding(p))+trace.SpanContextSize || encoded[1] != NormalExtentType|PacketTraceFlag {
+		t.Fatalf("traced packet [len=%d] extent type %x", len(encoded), encoded[1])
+	}
+}
+
+func TestPacketUnsampledEncoding(t *testing.T) {
+	unsampled := testSpanContext
+	unsampled.Flags = 0
+	for _, sc := range []trace.SpanContext{{}, unsampled} {
+		p := newTestPacket()
+		p.TraceContext = sc
+		if encoded, expect := encodePacket(t, p), baselineEncoding(p); !bytes.Equal(encoded, expect) {
+			t.Fatalf("packet with trace context %+v encoded as\n%x\nexpect\n%x", sc, encoded, expect)
+		}
+	}
+}
+
+func TestPacketTraceContextReset(t *testing.T) {
+	// a packet read twice must not keep the trace context of the first one
+	traced, plain := newTestPacket(), newTestPacket()
+	traced.TraceContext = testSpanContext
+
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		for _, p := range []*Packet{traced, plain} {
+			if err := p.WriteToConn(client); err != nil {
+				t.Error(err)
+			}
+		}
+		client.Close()
+	}()
+	got := new(Packet)
+	if err := got.ReadFromConn(server, ReadDeadlineTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := got.ReadFromConn(server, ReadDeadlineTime); err != nil {
+		t.Fatal(err)
+	}
+	if got.TraceContext.IsValid() {
+		t.Fatalf("trace context %+v should be reset", got.TraceContext)
+	}
+}
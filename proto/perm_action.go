@@ -159,6 +159,11 @@ const (
 	OSSPutBucketReplicationAction    Action = OSSActionPrefix + "PutBucketReplicationAction"    // unsupported
 	OSSDeleteBucketReplicationAction Action = OSSActionPrefix + "DeleteBucketReplicationAction" // unsupported
 
+	// Temporary credentials actions
+	OSSAssumeRoleAction                Action = OSSActionPrefix + "AssumeRole"
+	OSSAssumeRoleWithWebIdentityAction Action = OSSActionPrefix + "AssumeRoleWithWebIdentity"
+	OSSGetSessionTokenAction           Action = OSSActionPrefix + "GetSessionToken"
+
 	// constants for POSIX file system interface
 	POSIXReadAction  Action = POSIXActionPrefix + "Read"
 	POSIXWriteAction Action = POSIXActionPrefix + "Write"
@@ -236,6 +241,9 @@ var (
 		OSSPutBucketReplicationAction,
 		OSSDeleteBucketReplicationAction,
 		OSSOptionsObjectAction,
+		OSSAssumeRoleAction,
+		OSSAssumeRoleWithWebIdentityAction,
+		OSSGetSessionTokenAction,
 
 		// POSIX file system interface actions
 		POSIXReadAction,
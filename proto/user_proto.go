@@ -18,6 +18,9 @@ import (
 	"fmt"
 	"regexp"
 	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/util/keystore"
 )
 
 var (
@@ -87,10 +90,20 @@ func IsValidSK(sk string) bool {
 	}
 }
 
+// AKUser is the record of an access key of a user. The primary access key of the user is
+// the one in the user info, the secret key is kept only in the record of an additional key.
 type AKUser struct {
 	AccessKey string `json:"access_key" graphql:"access_key"`
 	UserID    string `json:"user_id" graphql:"user_id"`
 	Password  string `json:"password" graphql:"password"`
+	SecretKey string `json:"secret_key,omitempty" graphql:"-"`
+
+	keystore.AccessKeyMeta `graphql:"-"`
+}
+
+// IsPrimary returns true if it is the record of the primary access key of the user.
+func (u *AKUser) IsPrimary() bool {
+	return u.SecretKey == ""
 }
 
 type UserInfo struct {
@@ -101,8 +114,22 @@ type UserInfo struct {
 	UserType    UserType     `json:"user_type" graphql:"user_type"`
 	CreateTime  string       `json:"create_time" graphql:"create_time"`
 	Description string       `json:"description" graphql:"description"`
+	Session     *UserSession `json:"session,omitempty" graphql:"-"` // set if the access key belongs to a session of the user
 	Mu          sync.RWMutex `json:"-" graphql:"-"`
 	EMPTY       bool         //graphql need ???
+
+	// The groups of the user and the permissions inherited from the groups and the roles granted to
+	// the groups, they are evaluated by the master when the user is queried and are not persisted.
+	Groups          []string    `json:"groups,omitempty" graphql:"-"`
+	InheritedPolicy *UserPolicy `json:"inherited_policy,omitempty" graphql:"-"`
+}
+
+// IsAuthorized checks the permission of the user on the volume, including the inherited permissions.
+func (i *UserInfo) IsAuthorized(volume, subdir string, action Action) bool {
+	if i.Policy != nil && i.Policy.IsAuthorized(volume, subdir, action) {
+		return true
+	}
+	return i.InheritedPolicy != nil && i.InheritedPolicy.IsAuthorized(volume, subdir, action)
 }
 
 func (i *UserInfo) String() string {
@@ -219,6 +246,30 @@ func (policy *UserPolicy) RemoveAuthorizedVol(volume string) {
 	delete(policy.AuthorizedVols, volume)
 }
 
+// MergeAuthorizedVols adds the authorized volumes of the other policy to the policy.
+func (policy *UserPolicy) MergeAuthorizedVols(other *UserPolicy) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+	for volume, values := range other.AuthorizedVols {
+		for _, value := range values {
+			if !containsString(policy.AuthorizedVols[volume], value) {
+				policy.AuthorizedVols[volume] = append(policy.AuthorizedVols[volume], value)
+			}
+		}
+	}
+}
+
+func containsString(array []string, element string) bool {
+	for _, value := range array {
+		if value == element {
+			return true
+		}
+	}
+	return false
+}
+
 func (policy *UserPolicy) SetPerm(volume string, perm Permission) {
 	policy.mu.Lock()
 	defer policy.mu.Unlock()
@@ -299,6 +350,59 @@ func CleanPolicy(policy *UserPolicy) (newUserPolicy *UserPolicy) {
 	return
 }
 
+const (
+	AccessKeyStatusActive   = keystore.AccessKeyStatusActive
+	AccessKeyStatusInactive = keystore.AccessKeyStatusInactive
+	PrimaryAccessKeyName    = "default"
+)
+
+// UserAccessKey is the view of an access key of a user, the secret key is returned only when the key is added.
+type UserAccessKey struct {
+	Name         string `json:"name"`
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key,omitempty"`
+	Status       string `json:"status"`
+	CreateTime   string `json:"create_time"`
+	LastUsedTime int64  `json:"last_used_time"` // unix time, 0 if never used
+}
+
+type UserKeyCreateParam struct {
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	AccessKey string `json:"access_key"` // generated if empty
+	SecretKey string `json:"secret_key"` // generated if empty
+}
+
+// Limits of the duration of a session in seconds.
+const (
+	MinSessionDuration     int64 = 15 * 60
+	MaxSessionDuration     int64 = 12 * 60 * 60
+	DefaultSessionDuration int64 = 60 * 60
+)
+
+// UserSession is a set of temporary credentials of a user. A request signed with the session keys
+// has to carry the session token, and is allowed only if both the permissions of the user and the
+// session policy allow it.
+type UserSession struct {
+	AccessKey    string `json:"access_key"`
+	SecretKey    string `json:"secret_key"`
+	SessionToken string `json:"session_token"`
+	UserID       string `json:"user_id"`
+	Policy       string `json:"policy"`     // policy document in the bucket policy language, empty for no more limit
+	Expiration   int64  `json:"expiration"` // unix time
+	CreateTime   string `json:"create_time"`
+}
+
+func (s *UserSession) IsExpired() bool {
+	return time.Now().Unix() >= s.Expiration
+}
+
+type UserSessionCreateParam struct {
+	UserID   string `json:"user_id"`
+	Duration int64  `json:"duration"` // seconds, DefaultSessionDuration if 0
+	Policy   string `json:"policy"`
+}
+
 type UserCreateParam struct {
 	ID          string   `json:"id"`
 	Password    string   `json:"pwd"`
@@ -347,3 +451,41 @@ type UserUpdateParam struct {
 	Password    string   `json:"password"`
 	Description string   `json:"description"`
 }
+
+// UserGroup is a group of users, the members inherit the permissions of the policy
+// attached to the group and of the roles granted to the group.
+type UserGroup struct {
+	GroupID     string       `json:"group_id"`
+	Members     []string     `json:"members"`
+	Roles       []string     `json:"roles"`
+	Policy      *UserPolicy  `json:"policy"`
+	Description string       `json:"description"`
+	CreateTime  string       `json:"create_time"`
+	Mu          sync.RWMutex `json:"-"`
+}
+
+// UserRole is a named set of permissions, which is granted to the groups.
+type UserRole struct {
+	RoleID      string       `json:"role_id"`
+	Policy      *UserPolicy  `json:"policy"`
+	Description string       `json:"description"`
+	CreateTime  string       `json:"create_time"`
+	Mu          sync.RWMutex `json:"-"`
+}
+
+type GroupCreateParam struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+// GroupPermUpdateParam updates the permissions on the volume of a group or a role.
+type GroupPermUpdateParam struct {
+	ID     string   `json:"id"`
+	Volume string   `json:"volume"`
+	Policy []string `json:"policy"`
+}
+
+type GroupPermRemoveParam struct {
+	ID     string `json:"id"`
+	Volume string `json:"volume"`
+}
@@ -28,6 +28,8 @@ import (
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/packetauth"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 var (
@@ -42,6 +44,7 @@ type Packet struct {
 	IsReleased      int32 // TODO what is released?
 	Object          interface{}
 	TpObject        *exporter.TimePointCount
+	Span            *trace.Span // the span of the operation on this node, nil if not traced
 	NeedReply       bool
 	OrgBuffer       []byte
 
@@ -52,6 +55,7 @@ type Packet struct {
 type FollowerPacket struct {
 	proto.Packet
 	respCh chan error
+	span   *trace.Span
 }
 
 func NewFollowerPacket() (fp *FollowerPacket) {
@@ -90,6 +94,10 @@ func (p *FollowerPacket) identificationErrorResultCode(errLog string, errMsg str
 		p.ResultCode = proto.OpAgain
 	} else if strings.Contains(errMsg, raft.ErrNotLeader.Error()) {
 		p.ResultCode = proto.OpTryOtherAddr
+	} else if strings.Contains(errMsg, proto.ErrNoPermission.Error()) ||
+		strings.Contains(errMsg, proto.ErrExpiredTicket.Error()) ||
+		strings.Contains(errMsg, packetauth.ErrNotAuthenticated.Error()) {
+		p.ResultCode = proto.OpNotPerm
 	} else {
 		p.ResultCode = proto.OpIntraGroupNetErr
 	}
@@ -109,6 +117,7 @@ func (p *Packet) clean() {
 	}
 	p.Object = nil
 	p.TpObject = nil
+	p.Span = nil
 	p.Data = nil
 	p.Arg = nil
 	if p.OrgBuffer != nil && len(p.OrgBuffer) == util.BlockSize && p.IsWriteOperation() {
@@ -306,6 +315,10 @@ func (p *Packet) identificationErrorResultCode(errLog string, errMsg string) {
 		p.ResultCode = proto.OpAgain
 	} else if strings.Contains(errMsg, raft.ErrNotLeader.Error()) {
 		p.ResultCode = proto.OpTryOtherAddr
+	} else if strings.Contains(errMsg, proto.ErrNoPermission.Error()) ||
+		strings.Contains(errMsg, proto.ErrExpiredTicket.Error()) ||
+		strings.Contains(errMsg, packetauth.ErrNotAuthenticated.Error()) {
+		p.ResultCode = proto.OpNotPerm
 	} else {
 		p.ResultCode = proto.OpIntraGroupNetErr
 	}
@@ -345,6 +358,9 @@ func (p *Packet) ReadFromConnFromCli(c net.Conn, deadlineTime time.Duration) (er
 	if err = p.UnmarshalHeader(header); err != nil {
 		return
 	}
+	if err = p.ReadTraceContext(c); err != nil {
+		return
+	}
 
 	if p.ArgLen > 0 {
 		if err = proto.ReadFull(c, &p.Arg, int(p.ArgLen)); err != nil {
@@ -0,0 +1,80 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package repl
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/trace"
+)
+
+func init() {
+	proto.InitBufferPool(int64(32768))
+}
+
+func TestPacketTraceContextFromCli(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		Flags:   trace.FlagSampled,
+	}
+	unsampled := sc
+	unsampled.Flags = 0
+
+	for _, ctx := range []trace.SpanContext{sc, unsampled, {}} {
+		p := NewPacket()
+		p.ExtentType = proto.TinyExtentType
+		p.Opcode = proto.OpWrite
+		p.RemainingFollowers = 2
+		p.PartitionID = 10
+		p.ExtentID = 1
+		p.ReqID = proto.GenerateRequestID()
+		p.Arg = []byte("192.168.0.2:17310/192.168.0.3:17310/")
+		p.ArgLen = uint32(len(p.Arg))
+		p.Data = []byte("hello cubefs")
+		p.Size = uint32(len(p.Data))
+		p.TraceContext = ctx
+
+		client, server := net.Pipe()
+		go func() {
+			if err := p.WriteToConn(client); err != nil {
+				t.Error(err)
+			}
+			client.Close()
+		}()
+		got := NewPacket()
+		if err := got.ReadFromConnFromCli(server, proto.NoReadDeadlineTime); err != nil {
+			t.Fatal(err)
+		}
+		server.Close()
+
+		expect := ctx
+		if !ctx.IsSampled() {
+			expect = trace.SpanContext{}
+		}
+		if got.TraceContext != expect {
+			t.Fatalf("trace context %+v, expect %+v", got.TraceContext, expect)
+		}
+		if got.ExtentType != proto.TinyExtentType || !got.IsTinyExtentType() || got.RemainingFollowers != 2 || got.ReqID != p.ReqID {
+			t.Fatalf("header %+v, expect %+v", got.Packet, p.Packet)
+		}
+		if !bytes.Equal(got.Arg, p.Arg) || !bytes.Equal(got.Data, p.Data) {
+			t.Fatalf("arg [%s] data [%s], expect [%s] [%s]", got.Arg, got.Data, p.Arg, p.Data)
+		}
+	}
+}
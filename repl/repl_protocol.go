@@ -26,12 +26,19 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 var (
 	gConnPool = util.NewConnectPool()
 )
 
+// SetConnectHandshake sets the handshake performed on the new connections to the followers,
+// it should be invoked before serving the packets.
+func SetConnectHandshake(handshake util.Handshake) {
+	gConnPool = util.NewConnectPoolWithHandshake(handshake)
+}
+
 // ReplProtocol defines the struct of the replication protocol.
 // 1. ServerConn reads a packet from the client socket, and analyzes the addresses of the followers.
 // 2. After the preparation, the packet is send to toBeProcessedCh. If failure happens, send it to the response channel.
@@ -54,7 +61,7 @@ type ReplProtocol struct {
 	followerConnects map[string]*FollowerTransport
 	lock             sync.RWMutex
 
-	prepareFunc  func(p *Packet) error             // prepare packet
+	prepareFunc  func(p *Packet, c net.Conn) error // prepare packet
 	operatorFunc func(p *Packet, c net.Conn) error // operator
 	postFunc     func(p *Packet) error             // post-processing packet
 
@@ -97,6 +104,7 @@ func (ft *FollowerTransport) serverWriteToFollower() {
 		case p := <-ft.sendCh:
 			if err := p.WriteToConn(ft.conn); err != nil {
 				p.PackErrorBody(ActionSendToFollowers, err.Error())
+				p.span.Finish(err)
 				p.respCh <- fmt.Errorf(string(p.Data[:p.Size]))
 				log.LogErrorf("serverWriteToFollower ft.addr(%v), err (%v)", ft.addr, err.Error())
 				ft.conn.Close()
@@ -137,6 +145,7 @@ func (ft *FollowerTransport) readFollowerResult(request *FollowerPacket) (err er
 	reply := NewPacket()
 	defer func() {
 		reply.clean()
+		request.span.Finish(err)
 		request.respCh <- err
 		if err != nil {
 			ft.conn.Close()
@@ -190,7 +199,7 @@ func (ft *FollowerTransport) Write(p *FollowerPacket) {
 	ft.sendCh <- p
 }
 
-func NewReplProtocol(inConn net.Conn, prepareFunc func(p *Packet) error,
+func NewReplProtocol(inConn net.Conn, prepareFunc func(p *Packet, c net.Conn) error,
 	operatorFunc func(p *Packet, c net.Conn) error, postFunc func(p *Packet) error) *ReplProtocol {
 	rp := new(ReplProtocol)
 	rp.packetList = list.New()
@@ -277,7 +286,7 @@ func (rp *ReplProtocol) readPkgAndPrepare() (err error) {
 		err = rp.putResponse(request)
 		return
 	}
-	if err = rp.prepareFunc(request); err != nil {
+	if err = rp.prepareFunc(request, rp.sourceConn); err != nil {
 		err = rp.putResponse(request)
 		return
 	}
@@ -296,6 +305,9 @@ func (rp *ReplProtocol) sendRequestToAllFollowers(request *Packet) (index int, e
 		followerRequest := NewFollowerPacket()
 		copyPacket(request, followerRequest)
 		followerRequest.RemainingFollowers = 0
+		followerRequest.span = trace.StartChildSpan("datanode.repl.forward", request.TraceContext)
+		followerRequest.span.SetTag("follower", transport.addr)
+		followerRequest.TraceContext = followerRequest.span.Context()
 		request.followerPackets[index] = followerRequest
 		transport.Write(followerRequest)
 	}
@@ -80,3 +80,29 @@ func (api *API) AdminGetCaps(clientID, clientKey, userID string) (res *keystore.
 	}
 	return api.ac.serveAdminRequest(clientID, clientKey, api.ac.ticket, keyInfo, proto.MsgAuthGetCapsReq, proto.AdminGetCaps)
 }
+
+// AdminRotateKey rotates the key of userID, the previous key is still accepted for grace seconds.
+func (api *API) AdminRotateKey(clientID, clientKey, userID string, grace int64) (res *keystore.KeyInfo, err error) {
+	if api.ac.ticket == nil {
+		if api.ac.ticket, err = api.GetTicket(clientID, clientKey, proto.AuthServiceID); err != nil {
+			return
+		}
+	}
+	keyInfo := &keystore.KeyInfo{
+		ID: userID,
+	}
+	return api.ac.serveAdminRequestWithGrace(clientID, clientKey, api.ac.ticket, keyInfo, grace, proto.MsgAuthRotateKeyReq, proto.AdminRotateKey)
+}
+
+// AdminRotateRootKey rotates the root key of the authnode which seals the keys in the keystore.
+func (api *API) AdminRotateRootKey(clientID, clientKey string) (res *keystore.KeyInfo, err error) {
+	if api.ac.ticket == nil {
+		if api.ac.ticket, err = api.GetTicket(clientID, clientKey, proto.AuthServiceID); err != nil {
+			return
+		}
+	}
+	keyInfo := &keystore.KeyInfo{
+		ID: proto.AuthServiceID,
+	}
+	return api.ac.serveAdminRequest(clientID, clientKey, api.ac.ticket, keyInfo, proto.MsgAuthRotateRootKeyReq, proto.AdminRotateRootKey)
+}
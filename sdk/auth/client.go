@@ -147,6 +147,10 @@ func (c *AuthClient) serveOSSRequest(id, key string, ticket *auth.Ticket, akCaps
 }
 
 func (c *AuthClient) serveAdminRequest(id, key string, ticket *auth.Ticket, keyInfo *keystore.KeyInfo, reqType proto.MsgType, reqPath string) (res *keystore.KeyInfo, err error) {
+	return c.serveAdminRequestWithGrace(id, key, ticket, keyInfo, 0, reqType, reqPath)
+}
+
+func (c *AuthClient) serveAdminRequestWithGrace(id, key string, ticket *auth.Ticket, keyInfo *keystore.KeyInfo, grace int64, reqType proto.MsgType, reqPath string) (res *keystore.KeyInfo, err error) {
 	var (
 		sessionKey []byte
 		ts         int64
@@ -166,8 +170,9 @@ func (c *AuthClient) serveAdminRequest(id, key string, ticket *auth.Ticket, keyI
 		return nil, err
 	}
 	message := &proto.AuthAPIAccessReq{
-		APIReq:  *apiReq,
-		KeyInfo: *keyInfo,
+		APIReq:   *apiReq,
+		KeyInfo:  *keyInfo,
+		KeyGrace: grace,
 	}
 	if respData, err = c.request(id, key, sessionKey, message, reqPath, proto.AuthServiceID); err != nil {
 		return
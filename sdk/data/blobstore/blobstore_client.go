@@ -82,6 +82,11 @@ func (ebs *BlobStoreClient) Read(ctx context.Context, volName string, buf []byte
 		BlobSize:  oek.BlobSize,
 		Blobs:     sliceInfos,
 	}
+	// a packed key is a slice of a shared blob, read it relative to the whole blob
+	if oek.IsPacked() {
+		loc.Size = oek.PackSize
+		offset += oek.BlobOffset
+	}
 	//func get has retry
 	log.LogDebugf("TRACE Ebs Read,oek(%v) loc(%v)", oek, loc)
 	var (
@@ -167,6 +172,10 @@ func (ebs *BlobStoreClient) Delete(oeks []proto.ObjExtentKey) (err error) {
 	locs := make([]access.Location, 0)
 
 	for _, oek := range oeks {
+		// packed blobs are shared and owned by their pack inode, never delete them with a file slice
+		if oek.IsPacked() {
+			continue
+		}
 		sliceInfos := make([]access.SliceInfo, 0)
 		for _, b := range oek.Blobs {
 			sliceInfo := access.SliceInfo{
@@ -187,6 +196,9 @@ func (ebs *BlobStoreClient) Delete(oeks []proto.ObjExtentKey) (err error) {
 		}
 		locs = append(locs, loc)
 	}
+	if len(locs) == 0 {
+		return
+	}
 
 	requestId := uuid.New().String()
 	log.LogDebugf("start Ebs delete Enter,requestId(%v)  len(%v)", requestId, len(oeks))
@@ -0,0 +1,539 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/stat"
+)
+
+const (
+	// hidden directory under the root linking the pack inodes, each named by its inode ID
+	packDirName = ".cbfs_pack"
+	// pack inode xattr holding the file slices packed into its blob
+	packRefsKey = "cbfs.packrefs"
+
+	packFileMode = 0644
+	packDirMode  = os.ModeDir | 0755
+	// count of the pack inodes listed at a time by the compactor
+	packDirReadLimit = 1000
+
+	DefaultPackDelay       = 20 * time.Millisecond
+	DefaultCompactInterval = 10 * time.Minute
+	DefaultCompactRatio    = 0.5
+)
+
+type PackerConfig struct {
+	VolName         string
+	Mw              *meta.MetaWrapper
+	Ebsc            *BlobStoreClient
+	BlockSize       int           // max size of a packed blob
+	MaxFileSize     int           // files no larger than this are packed
+	Delay           time.Duration // max time a file waits for others to share its blob
+	Compact         bool          // run the background compactor
+	CompactInterval time.Duration
+	CompactRatio    float64 // repack a blob when its live bytes drop below this ratio
+}
+
+// Packer aggregates small files of a cold volume into shared blobs, so that
+// each of them does not cost a whole EC stripe in the blobstore.
+//
+// A packed blob is owned by an unlinked pack inode holding its full obj extent
+// key, while every file gets a packed key pointing at its slice of the blob.
+// Deleting a file never deletes a packed blob; instead the pack inodes are linked
+// in the pack directory, and the compactor walks it to drop the blobs nobody
+// refers to and repack the ones mostly dead.
+type Packer struct {
+	volName         string
+	mw              *meta.MetaWrapper
+	ebsc            *BlobStoreClient
+	blockSize       int
+	maxFileSize     int
+	delay           time.Duration
+	compactInterval time.Duration
+	compactRatio    float64
+
+	sync.Mutex
+	batch *packBatch
+	stopC chan struct{}
+	wg    sync.WaitGroup
+
+	dirMutex sync.Mutex
+	packDir  uint64 // inode of the pack directory, 0 if not resolved yet
+}
+
+// packRef records one file slice packed into a blob.
+type packRef struct {
+	Inode      uint64 `json:"ino"`
+	FileOffset uint64 `json:"off"`
+	BlobOffset uint64 `json:"boff"`
+	Size       uint64 `json:"size"`
+}
+
+type packBatch struct {
+	data  []byte
+	refs  []packRef
+	keys  []proto.ObjExtentKey
+	err   error
+	done  chan struct{}
+	timer *time.Timer
+}
+
+func NewPacker(config PackerConfig) *Packer {
+	p := &Packer{
+		volName:         config.VolName,
+		mw:              config.Mw,
+		ebsc:            config.Ebsc,
+		blockSize:       config.BlockSize,
+		maxFileSize:     config.MaxFileSize,
+		delay:           config.Delay,
+		compactInterval: config.CompactInterval,
+		compactRatio:    config.CompactRatio,
+		stopC:           make(chan struct{}),
+	}
+	if p.maxFileSize > p.blockSize {
+		p.maxFileSize = p.blockSize
+	}
+	if p.delay <= 0 {
+		p.delay = DefaultPackDelay
+	}
+	if p.compactInterval <= 0 {
+		p.compactInterval = DefaultCompactInterval
+	}
+	if p.compactRatio <= 0 || p.compactRatio > 1 {
+		p.compactRatio = DefaultCompactRatio
+	}
+	if config.Compact {
+		p.wg.Add(1)
+		go p.compactLoop()
+	}
+	return p
+}
+
+func (p *Packer) String() string {
+	return fmt.Sprintf("Packer{volName(%v),blockSize(%v),maxFileSize(%v),delay(%v),compactInterval(%v),compactRatio(%v)}",
+		p.volName, p.blockSize, p.maxFileSize, p.delay, p.compactInterval, p.compactRatio)
+}
+
+// CanPack returns true if a file of the given size should be packed.
+func (p *Packer) CanPack(size int) bool {
+	return p != nil && size > 0 && size <= p.maxFileSize
+}
+
+// Stop stops the compactor and seals the pending batch.
+func (p *Packer) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.stopC)
+	p.wg.Wait()
+	p.Lock()
+	b := p.detach(p.batch)
+	p.Unlock()
+	if b != nil {
+		p.seal(b)
+	}
+}
+
+// Put packs the data of inode at fileOffset into a shared blob and returns
+// the packed obj extent key of it. The caller owns appending the key.
+func (p *Packer) Put(ctx context.Context, inode uint64, fileOffset uint64, data []byte) (oek proto.ObjExtentKey, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("blobstore-pack", err, bgTime, 1)
+	}()
+
+	p.Lock()
+	b := p.batch
+	if b != nil && len(b.data)+len(data) > p.blockSize {
+		full := p.detach(b)
+		go p.seal(full)
+		b = nil
+	}
+	if b == nil {
+		b = &packBatch{
+			data: make([]byte, 0, p.blockSize),
+			done: make(chan struct{}),
+		}
+		p.batch = b
+		b.timer = time.AfterFunc(p.delay, func() {
+			p.Lock()
+			expired := p.detach(b)
+			p.Unlock()
+			if expired != nil {
+				p.seal(expired)
+			}
+		})
+	}
+	index := len(b.refs)
+	b.refs = append(b.refs, packRef{
+		Inode:      inode,
+		FileOffset: fileOffset,
+		BlobOffset: uint64(len(b.data)),
+		Size:       uint64(len(data)),
+	})
+	b.data = append(b.data, data...)
+	if len(b.data) >= p.blockSize {
+		full := p.detach(b)
+		go p.seal(full)
+	}
+	p.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return oek, ctx.Err()
+	}
+	if b.err != nil {
+		return oek, b.err
+	}
+	return b.keys[index], nil
+}
+
+// detach takes b off the packer if it is still the pending batch, the caller
+// of a non-nil result owns sealing it. It must be called with the lock held.
+func (p *Packer) detach(b *packBatch) *packBatch {
+	if b == nil || p.batch != b {
+		return nil
+	}
+	p.batch = nil
+	b.timer.Stop()
+	return b
+}
+
+// seal writes the batch into one blob, hands it to a new pack inode linked
+// in the pack directory, then wakes up the writers waiting on the batch.
+func (p *Packer) seal(b *packBatch) {
+	defer close(b.done)
+	ctx := context.Background()
+
+	location, err := p.ebsc.Write(ctx, p.volName, b.data, uint32(len(b.data)))
+	if err != nil {
+		log.LogErrorf("Packer seal: write blob failed, vol(%v) files(%v) size(%v) err(%v)", p.volName, len(b.refs), len(b.data), err)
+		b.err = err
+		return
+	}
+	full := objExtentKeyFromLocation(location, 0)
+
+	packIno, err := p.createPackInode(full, b.refs)
+	if err != nil {
+		log.LogErrorf("Packer seal: create pack inode failed, vol(%v) key(%v) err(%v)", p.volName, full, err)
+		b.err = err
+		return
+	}
+
+	b.keys = make([]proto.ObjExtentKey, 0, len(b.refs))
+	for _, ref := range b.refs {
+		oek := full
+		oek.FileOffset = ref.FileOffset
+		oek.Size = ref.Size
+		oek.BlobOffset = ref.BlobOffset
+		oek.PackSize = full.Size
+		b.keys = append(b.keys, oek)
+	}
+	log.LogDebugf("Packer seal: vol(%v) packIno(%v) files(%v) size(%v)", p.volName, packIno, len(b.refs), len(b.data))
+}
+
+func (p *Packer) createPackInode(full proto.ObjExtentKey, refs []packRef) (packIno uint64, err error) {
+	packDir, err := p.getPackDir(true)
+	if err != nil {
+		p.ebsc.Delete([]proto.ObjExtentKey{full})
+		return
+	}
+	info, err := p.mw.InodeCreate_ll(packFileMode, 0, 0, nil)
+	if err != nil {
+		p.ebsc.Delete([]proto.ObjExtentKey{full})
+		return
+	}
+	packIno = info.Inode
+	if err = p.mw.AppendObjExtentKeys(packIno, []proto.ObjExtentKey{full}); err != nil {
+		p.ebsc.Delete([]proto.ObjExtentKey{full})
+		p.dropPackInode(packIno)
+		return
+	}
+	defer func() {
+		if err != nil {
+			// the blob goes away with the pack inode
+			p.dropPackInode(packIno)
+		}
+	}()
+	value, err := json.Marshal(refs)
+	if err != nil {
+		return
+	}
+	if err = p.mw.XAttrSet_ll(packIno, []byte(packRefsKey), value); err != nil {
+		return
+	}
+	// linked at last, so that every pack inode found by the compactor has its refs
+	err = p.mw.DentryCreate_ll(packDir, packInodeName(packIno), packIno, packFileMode)
+	return
+}
+
+// getPackDir returns the inode of the pack directory, it is created if not exists and create is true.
+func (p *Packer) getPackDir(create bool) (packDir uint64, err error) {
+	p.dirMutex.Lock()
+	defer p.dirMutex.Unlock()
+	if p.packDir != 0 {
+		return p.packDir, nil
+	}
+	packDir, _, err = p.mw.Lookup_ll(proto.RootIno, packDirName)
+	if err == syscall.ENOENT && create {
+		var info *proto.InodeInfo
+		info, err = p.mw.Create_ll(proto.RootIno, packDirName, proto.Mode(packDirMode), 0, 0, nil)
+		if err == syscall.EEXIST {
+			// created by another client
+			packDir, _, err = p.mw.Lookup_ll(proto.RootIno, packDirName)
+		} else if err == nil {
+			packDir = info.Inode
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	p.packDir = packDir
+	return
+}
+
+func packInodeName(packIno uint64) string {
+	return strconv.FormatUint(packIno, 10)
+}
+
+func (p *Packer) dropPackInode(packIno uint64) {
+	if _, err := p.mw.InodeUnlink_ll(packIno); err != nil && err != syscall.ENOENT {
+		log.LogWarnf("Packer: unlink pack inode(%v) failed: %v", packIno, err)
+		return
+	}
+	if err := p.mw.Evict(packIno); err != nil && err != syscall.ENOENT {
+		log.LogWarnf("Packer: evict pack inode(%v) failed: %v", packIno, err)
+	}
+}
+
+// removePackInode removes the pack inode from the pack directory, its blob is
+// deleted with the inode.
+func (p *Packer) removePackInode(packDir, packIno uint64) error {
+	if _, err := p.mw.Delete_ll(packDir, packInodeName(packIno), false); err != nil {
+		return err
+	}
+	if err := p.mw.Evict(packIno); err != nil && err != syscall.ENOENT {
+		log.LogWarnf("Packer: evict pack inode(%v) failed: %v", packIno, err)
+	}
+	return nil
+}
+
+func (p *Packer) compactLoop() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(p.compactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopC:
+			return
+		case <-ticker.C:
+			p.Compact()
+		}
+	}
+}
+
+// Compact walks the pack directory, drops the blobs no file refers to and
+// repacks the ones whose live bytes are below the compact ratio.
+func (p *Packer) Compact() {
+	packDir, err := p.getPackDir(false)
+	if err == syscall.ENOENT {
+		return
+	}
+	if err != nil {
+		log.LogWarnf("Packer compact: lookup pack directory failed, vol(%v) err(%v)", p.volName, err)
+		return
+	}
+	from := ""
+	for {
+		dentries, err := p.mw.ReadDirLimit_ll(packDir, from, packDirReadLimit)
+		if err != nil {
+			log.LogWarnf("Packer compact: read pack directory failed, vol(%v) from(%v) err(%v)", p.volName, from, err)
+			return
+		}
+		for _, dentry := range dentries {
+			// the first entry is the last one of the previous batch
+			if dentry.Name == from {
+				continue
+			}
+			select {
+			case <-p.stopC:
+				return
+			default:
+			}
+			if err = p.compactPack(packDir, dentry.Inode); err != nil {
+				log.LogWarnf("Packer compact: vol(%v) packIno(%v) err(%v)", p.volName, dentry.Inode, err)
+			}
+		}
+		if len(dentries) < packDirReadLimit {
+			return
+		}
+		from = dentries[len(dentries)-1].Name
+	}
+}
+
+func (p *Packer) compactPack(packDir, packIno uint64) (err error) {
+	_, _, _, oeks, err := p.mw.GetObjExtents(packIno)
+	if err == syscall.ENOENT {
+		// the pack inode is gone, only the dentry is left
+		_, err = p.mw.Delete_ll(packDir, packInodeName(packIno), false)
+		return
+	}
+	if err != nil {
+		return
+	}
+	if len(oeks) != 1 || oeks[0].IsPacked() {
+		return fmt.Errorf("unexpected pack inode keys %v", oeks)
+	}
+	full := oeks[0]
+	refs, err := p.getPackRefs(packIno)
+	if err != nil {
+		return
+	}
+
+	live, liveBytes, err := p.liveKeys(full, refs)
+	if err != nil {
+		return
+	}
+	if liveBytes > 0 && float64(liveBytes) < p.compactRatio*float64(full.Size) {
+		p.repack(live)
+		if live, liveBytes, err = p.liveKeys(full, refs); err != nil {
+			return
+		}
+	}
+	if liveBytes == 0 {
+		log.LogInfof("Packer compact: drop pack inode(%v) size(%v)", packIno, full.Size)
+		return p.removePackInode(packDir, packIno)
+	}
+	log.LogDebugf("Packer compact: pack inode(%v) size(%v) live(%v)", packIno, full.Size, liveBytes)
+	return nil
+}
+
+func (p *Packer) getPackRefs(packIno uint64) (refs []packRef, err error) {
+	xattr, err := p.mw.XAttrGet_ll(packIno, packRefsKey)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(xattr.XAttrs[packRefsKey]), &refs)
+	return
+}
+
+// liveRef is a packed slice still referred to by its file.
+type liveRef struct {
+	ref packRef
+	oek proto.ObjExtentKey
+}
+
+// liveKeys returns the slices of the blob still in use. A slice is only
+// considered dead on a definite answer from the meta nodes.
+func (p *Packer) liveKeys(full proto.ObjExtentKey, refs []packRef) (live []liveRef, liveBytes uint64, err error) {
+	for _, ref := range refs {
+		var info *proto.InodeInfo
+		if info, err = p.mw.InodeGet_ll(ref.Inode); err == syscall.ENOENT {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return
+		}
+		if info.Nlink == 0 {
+			continue
+		}
+		var oeks []proto.ObjExtentKey
+		if _, _, _, oeks, err = p.mw.GetObjExtents(ref.Inode); err == syscall.ENOENT {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return
+		}
+		for _, oek := range oeks {
+			if oek.FileOffset == ref.FileOffset && oek.BlobOffset == ref.BlobOffset && oek.Size == ref.Size && isSliceOf(&oek, &full) {
+				live = append(live, liveRef{ref: ref, oek: oek})
+				liveBytes += ref.Size
+				break
+			}
+		}
+	}
+	return
+}
+
+// repack moves the live slices into new packed blobs. A slice whose file
+// changed meanwhile stays dead in the new blob until it is compacted again.
+func (p *Packer) repack(live []liveRef) {
+	ctx := context.Background()
+	for _, l := range live {
+		data := make([]byte, l.oek.Size)
+		if _, err := p.ebsc.Read(ctx, p.volName, data, 0, l.oek.Size, l.oek); err != nil {
+			log.LogWarnf("Packer repack: read ino(%v) key(%v) err(%v)", l.ref.Inode, l.oek, err)
+			continue
+		}
+		nek, err := p.Put(ctx, l.ref.Inode, l.ref.FileOffset, data)
+		if err != nil {
+			log.LogWarnf("Packer repack: put ino(%v) err(%v)", l.ref.Inode, err)
+			return
+		}
+		if err = p.mw.ReplaceObjExtentKey(l.ref.Inode, l.oek, nek); err != nil {
+			log.LogWarnf("Packer repack: replace ino(%v) old(%v) new(%v) err(%v)", l.ref.Inode, l.oek, nek, err)
+		}
+	}
+}
+
+// isSliceOf returns true if the packed key oek refers to the blob of full.
+func isSliceOf(oek, full *proto.ObjExtentKey) bool {
+	if !oek.IsPacked() || oek.PackSize != full.Size || oek.Cid != full.Cid || len(oek.Blobs) != len(full.Blobs) {
+		return false
+	}
+	for i := range oek.Blobs {
+		if oek.Blobs[i] != full.Blobs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func objExtentKeyFromLocation(location access.Location, fileOffset uint64) proto.ObjExtentKey {
+	blobs := make([]proto.Blob, 0, len(location.Blobs))
+	for _, info := range location.Blobs {
+		blobs = append(blobs, proto.Blob{
+			MinBid: uint64(info.MinBid),
+			Count:  uint64(info.Count),
+			Vid:    uint64(info.Vid),
+		})
+	}
+	return proto.ObjExtentKey{
+		Cid:        uint64(location.ClusterID),
+		CodeMode:   uint8(location.CodeMode),
+		Size:       location.Size,
+		BlobSize:   location.BlobSize,
+		Blobs:      blobs,
+		BlobsLen:   uint32(len(blobs)),
+		FileOffset: fileOffset,
+		Crc:        location.Crc,
+	}
+}
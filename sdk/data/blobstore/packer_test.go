@@ -0,0 +1,320 @@
+// Copyright 2022 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package blobstore
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/brahma-adshonor/gohook"
+	"github.com/cubefs/blobstore/api/access"
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPacker(t *testing.T, blockSize, maxFileSize int) *Packer {
+	mockServer := NewMockEbsService()
+	cfg := access.Config{
+		ConnMode:       access.QuickConnMode,
+		PriorityAddrs:  []string{mockServer.service.URL},
+		MaxSizePutOnce: 1 << 20,
+	}
+	cfg.Consul.Address = mockServer.service.URL[7:]
+	ebsc, err := NewEbsClient(cfg)
+	require.NoError(t, err)
+
+	mw := &meta.MetaWrapper{}
+	require.NoError(t, gohook.HookMethod(mw, "InodeCreate_ll", MockInodeCreateTrue, nil))
+	require.NoError(t, gohook.HookMethod(mw, "AppendObjExtentKeys", MockAppendObjExtentKeysTrue, nil))
+	require.NoError(t, gohook.HookMethod(mw, "XAttrSet_ll", MockXAttrSetTrue, nil))
+	require.NoError(t, gohook.HookMethod(mw, "LookupContext", MockPackLookup, nil))
+	require.NoError(t, gohook.HookMethod(mw, "CreateContext", MockPackCreate, nil))
+	require.NoError(t, gohook.HookMethod(mw, "DentryCreate_ll", MockPackDentryCreate, nil))
+	testPackMeta.reset()
+
+	return NewPacker(PackerConfig{
+		VolName:     "testVolume",
+		Mw:          mw,
+		Ebsc:        ebsc,
+		BlockSize:   blockSize,
+		MaxFileSize: maxFileSize,
+		Delay:       10 * time.Millisecond,
+	})
+}
+
+func TestPacker_CanPack(t *testing.T) {
+	var nilPacker *Packer
+	require.False(t, nilPacker.CanPack(1))
+
+	p := newTestPacker(t, 1<<20, 4096)
+	require.False(t, p.CanPack(0))
+	require.True(t, p.CanPack(4096))
+	require.False(t, p.CanPack(4097))
+}
+
+func TestPacker_Put(t *testing.T) {
+	p := newTestPacker(t, 1<<20, 4096)
+	ctx := context.Background()
+
+	sizes := []int{100, 200, 300}
+	keys := make([]proto.ObjExtentKey, len(sizes))
+	var wg sync.WaitGroup
+	for i, size := range sizes {
+		wg.Add(1)
+		go func(i, size int) {
+			defer wg.Done()
+			oek, err := p.Put(ctx, uint64(1000+i), 0, make([]byte, size))
+			require.NoError(t, err)
+			keys[i] = oek
+		}(i, size)
+	}
+	wg.Wait()
+
+	var total uint64
+	seen := make(map[uint64]bool)
+	for i, oek := range keys {
+		require.True(t, oek.IsPacked())
+		require.Equal(t, uint64(600), oek.PackSize)
+		require.Equal(t, uint64(sizes[i]), oek.Size)
+		require.False(t, seen[oek.BlobOffset])
+		seen[oek.BlobOffset] = true
+		total += oek.Size
+
+		// a packed slice is read relative to the whole blob
+		buf := make([]byte, oek.Size)
+		n, err := p.ebsc.Read(ctx, "testVolume", buf, 0, oek.Size, oek)
+		require.NoError(t, err)
+		require.Equal(t, sizes[i], n)
+	}
+	require.Equal(t, uint64(600), total)
+}
+
+func TestPacker_PutFullBlock(t *testing.T) {
+	p := newTestPacker(t, 1024, 1024)
+	ctx := context.Background()
+
+	first, err := p.Put(ctx, 1000, 0, make([]byte, 1024))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), first.BlobOffset)
+	require.Equal(t, uint64(1024), first.PackSize)
+
+	second, err := p.Put(ctx, 1001, 0, make([]byte, 10))
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), second.PackSize)
+}
+
+func TestPacker_Compact(t *testing.T) {
+	p := newTestPacker(t, 1<<20, 4096)
+	mw := p.mw
+	require.NoError(t, gohook.HookMethod(mw, "XAttrGet_ll", MockPackXAttrGet, nil))
+	require.NoError(t, gohook.HookMethod(mw, "GetObjExtents", MockPackGetObjExtents, nil))
+	require.NoError(t, gohook.HookMethod(mw, "InodeGetContext", MockPackInodeGet, nil))
+	require.NoError(t, gohook.HookMethod(mw, "ReadDirLimit_ll", MockPackReadDirLimit, nil))
+	require.NoError(t, gohook.HookMethod(mw, "Delete_ll", MockPackDelete, nil))
+	require.NoError(t, gohook.HookMethod(mw, "Evict", MockPackEvict, nil))
+	ctx := context.Background()
+
+	// nothing to compact before any blob is packed
+	p.Compact()
+	require.Equal(t, uint64(0), testPackMeta.packDir)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			oek, err := p.Put(ctx, uint64(1000+i), 0, make([]byte, 100))
+			require.NoError(t, err)
+			testPackMeta.setFileKey(uint64(1000+i), oek)
+		}(i)
+	}
+	wg.Wait()
+
+	// the pack inode is linked in the pack directory with the refs of both files
+	testPackMeta.Lock()
+	require.NotEqual(t, uint64(0), testPackMeta.packDir)
+	require.Equal(t, map[string]uint64{"2000": 2000}, testPackMeta.dentries)
+	var refs []packRef
+	require.NoError(t, json.Unmarshal(testPackMeta.xattrs[packRefsKey], &refs))
+	require.Equal(t, 2, len(refs))
+	testPackMeta.Unlock()
+
+	// the blob is kept while any file refers to it
+	testPackMeta.deleteFile(1000)
+	p.Compact()
+	testPackMeta.Lock()
+	require.Equal(t, 1, len(testPackMeta.dentries))
+	testPackMeta.Unlock()
+
+	testPackMeta.deleteFile(1001)
+	p.Compact()
+	testPackMeta.Lock()
+	require.Equal(t, 0, len(testPackMeta.dentries))
+	require.Equal(t, []uint64{2000}, testPackMeta.evicted)
+	testPackMeta.Unlock()
+}
+
+func TestIsSliceOf(t *testing.T) {
+	blobs := []proto.Blob{{MinBid: 1, Count: 1, Vid: 1}}
+	full := proto.ObjExtentKey{Cid: 1, Size: 600, Blobs: blobs, BlobsLen: 1}
+	oek := full
+	oek.Size, oek.BlobOffset, oek.PackSize = 100, 200, 600
+	require.True(t, isSliceOf(&oek, &full))
+
+	other := oek
+	other.Blobs = []proto.Blob{{MinBid: 2, Count: 1, Vid: 1}}
+	require.False(t, isSliceOf(&other, &full))
+	require.False(t, isSliceOf(&full, &full))
+}
+
+func MockInodeCreateTrue(mw *meta.MetaWrapper, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
+	return &proto.InodeInfo{Inode: 2000, Mode: mode, Nlink: 1}, nil
+}
+
+func MockXAttrSetTrue(mw *meta.MetaWrapper, inode uint64, name, value []byte) error {
+	testPackMeta.Lock()
+	testPackMeta.xattrs[string(name)] = value
+	testPackMeta.Unlock()
+	return nil
+}
+
+// testPackMeta is the meta of the volume touched by the packer, the pack inode is always 2000.
+var testPackMeta = &packMeta{}
+
+type packMeta struct {
+	sync.Mutex
+	packDir  uint64
+	dentries map[string]uint64
+	xattrs   map[string][]byte
+	files    map[uint64][]proto.ObjExtentKey
+	packKey  proto.ObjExtentKey // full key of the pack inode
+	evicted  []uint64
+}
+
+func (m *packMeta) reset() {
+	m.Lock()
+	defer m.Unlock()
+	m.packDir = 0
+	m.dentries = make(map[string]uint64)
+	m.xattrs = make(map[string][]byte)
+	m.files = make(map[uint64][]proto.ObjExtentKey)
+	m.evicted = nil
+}
+
+func (m *packMeta) setFileKey(ino uint64, oek proto.ObjExtentKey) {
+	m.Lock()
+	m.files[ino] = []proto.ObjExtentKey{oek}
+	m.packKey = oek
+	m.packKey.FileOffset, m.packKey.Size, m.packKey.BlobOffset, m.packKey.PackSize = 0, oek.PackSize, 0, 0
+	m.Unlock()
+}
+
+func (m *packMeta) deleteFile(ino uint64) {
+	m.Lock()
+	delete(m.files, ino)
+	m.Unlock()
+}
+
+func MockPackLookup(mw *meta.MetaWrapper, ctx context.Context, parentID uint64, name string) (uint64, uint32, error) {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	if parentID != proto.RootIno || name != packDirName || testPackMeta.packDir == 0 {
+		return 0, 0, syscall.ENOENT
+	}
+	return testPackMeta.packDir, proto.Mode(packDirMode), nil
+}
+
+func MockPackCreate(mw *meta.MetaWrapper, ctx context.Context, parentID uint64, name string, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	if testPackMeta.packDir != 0 {
+		return nil, syscall.EEXIST
+	}
+	testPackMeta.packDir = 1500
+	return &proto.InodeInfo{Inode: testPackMeta.packDir, Mode: mode, Nlink: 2}, nil
+}
+
+func MockPackDentryCreate(mw *meta.MetaWrapper, parentID uint64, name string, inode uint64, mode uint32) error {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	if parentID != testPackMeta.packDir {
+		return syscall.ENOENT
+	}
+	testPackMeta.dentries[name] = inode
+	return nil
+}
+
+func MockPackReadDirLimit(mw *meta.MetaWrapper, parentID uint64, from string, limit uint64) ([]proto.Dentry, error) {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	dentries := make([]proto.Dentry, 0)
+	for name, ino := range testPackMeta.dentries {
+		if name >= from {
+			dentries = append(dentries, proto.Dentry{Name: name, Inode: ino})
+		}
+	}
+	return dentries, nil
+}
+
+func MockPackDelete(mw *meta.MetaWrapper, parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	ino, ok := testPackMeta.dentries[name]
+	if !ok {
+		return nil, nil
+	}
+	delete(testPackMeta.dentries, name)
+	return &proto.InodeInfo{Inode: ino}, nil
+}
+
+func MockPackEvict(mw *meta.MetaWrapper, inode uint64) error {
+	testPackMeta.Lock()
+	testPackMeta.evicted = append(testPackMeta.evicted, inode)
+	testPackMeta.Unlock()
+	return nil
+}
+
+func MockPackXAttrGet(mw *meta.MetaWrapper, inode uint64, name string) (*proto.XAttrInfo, error) {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	return &proto.XAttrInfo{Inode: inode, XAttrs: map[string]string{name: string(testPackMeta.xattrs[name])}}, nil
+}
+
+func MockPackGetObjExtents(mw *meta.MetaWrapper, inode uint64) (uint64, uint64, []proto.ExtentKey, []proto.ObjExtentKey, error) {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	if inode == 2000 {
+		return 0, testPackMeta.packKey.Size, nil, []proto.ObjExtentKey{testPackMeta.packKey}, nil
+	}
+	oeks, ok := testPackMeta.files[inode]
+	if !ok {
+		return 0, 0, nil, nil, syscall.ENOENT
+	}
+	return 0, 0, nil, oeks, nil
+}
+
+func MockPackInodeGet(mw *meta.MetaWrapper, ctx context.Context, inode uint64) (*proto.InodeInfo, error) {
+	testPackMeta.Lock()
+	defer testPackMeta.Unlock()
+	if _, ok := testPackMeta.files[inode]; !ok {
+		return nil, syscall.ENOENT
+	}
+	return &proto.InodeInfo{Inode: inode, Nlink: 1}, nil
+}
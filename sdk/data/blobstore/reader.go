@@ -102,6 +102,7 @@ type ClientConfig struct {
 	FileCache       bool
 	FileSize        uint64
 	CacheThreshold  int
+	Packer          *Packer
 }
 
 func NewReader(config ClientConfig) (reader *Reader) {
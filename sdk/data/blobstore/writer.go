@@ -62,6 +62,7 @@ type Writer struct {
 	dirty          bool
 	blockPosition  int
 	limitManager   *manager.LimitManager
+	packer         *Packer
 }
 
 func NewWriter(config ClientConfig) (writer *Writer) {
@@ -88,6 +89,7 @@ func NewWriter(config ClientConfig) (writer *Writer) {
 	writer.dirty = false
 	writer.AllocateCache()
 	writer.limitManager = writer.ec.LimitManager
+	writer.packer = config.Packer
 
 	return
 }
@@ -291,25 +293,7 @@ func (writer *Writer) writeSlice(ctx context.Context, wSlice *rwSlice, wg bool)
 		return err
 	}
 	log.LogDebugf("TRACE blobStore,location(%v)", location)
-	blobs := make([]proto.Blob, 0)
-	for _, info := range location.Blobs {
-		blob := proto.Blob{
-			MinBid: uint64(info.MinBid),
-			Count:  uint64(info.Count),
-			Vid:    uint64(info.Vid),
-		}
-		blobs = append(blobs, blob)
-	}
-	wSlice.objExtentKey = proto.ObjExtentKey{
-		Cid:        uint64(location.ClusterID),
-		CodeMode:   uint8(location.CodeMode),
-		Size:       location.Size,
-		BlobSize:   location.BlobSize,
-		Blobs:      blobs,
-		BlobsLen:   uint32(len(blobs)),
-		FileOffset: wSlice.fileOffset,
-		Crc:        location.Crc,
-	}
+	wSlice.objExtentKey = objExtentKeyFromLocation(location, wSlice.fileOffset)
 	log.LogDebugf("TRACE blobStore,objExtentKey(%v)", wSlice.objExtentKey)
 
 	if wg {
@@ -318,6 +302,17 @@ func (writer *Writer) writeSlice(ctx context.Context, wSlice *rwSlice, wg bool)
 	return
 }
 
+func (writer *Writer) packSlice(ctx context.Context, wSlice *rwSlice) (err error) {
+	writer.limitManager.WriteAlloc(ctx, int(wSlice.size))
+	log.LogDebugf("TRACE blobStore,packSlice. ino(%v) fileOffset(%v) len(%v)", writer.ino, wSlice.fileOffset, wSlice.size)
+	wSlice.objExtentKey, err = writer.packer.Put(ctx, writer.ino, wSlice.fileOffset, wSlice.Data[:wSlice.size])
+	if err != nil {
+		return
+	}
+	log.LogDebugf("TRACE blobStore,objExtentKey(%v)", wSlice.objExtentKey)
+	return
+}
+
 func (writer *Writer) asyncCache(ino uint64, offset int, data []byte) {
 	var err error
 	bgTime := stat.BeginStat()
@@ -360,7 +355,12 @@ func (writer *Writer) flush(inode uint64, ctx context.Context, flushFlag bool) (
 		size:       uint32(bufferSize),
 		Data:       writer.buf,
 	}
-	err = writer.writeSlice(ctx, wSlice, false)
+	// a small file flushed as a whole shares its blob with other small files
+	if flushFlag && wSlice.fileOffset == 0 && writer.packer.CanPack(bufferSize) {
+		err = writer.packSlice(ctx, wSlice)
+	} else {
+		err = writer.writeSlice(ctx, wSlice, false)
+	}
 	if err != nil {
 		if flushFlag {
 			atomic.AddUint64(&writer.fileSize, -uint64(bufferSize))
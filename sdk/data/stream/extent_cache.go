@@ -224,6 +224,13 @@ func (cache *ExtentCache) SetSize(size uint64, sync bool) {
 	}
 }
 
+// Len returns the number of the extents in the cache.
+func (cache *ExtentCache) Len() int {
+	cache.RLock()
+	defer cache.RUnlock()
+	return cache.root.Len()
+}
+
 // List returns a list of the extents in the cache.
 func (cache *ExtentCache) List() []*proto.ExtentKey {
 	cache.RLock()
@@ -32,6 +32,7 @@ import (
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 	"github.com/cubefs/cubefs/util/stat"
 )
 
@@ -42,6 +43,8 @@ type EvictIcacheFunc func(inode uint64)
 type LoadBcacheFunc func(key string, buf []byte, offset uint64, size uint32) (int, error)
 type CacheBcacheFunc func(key string, buf []byte) error
 type EvictBacheFunc func(key string)
+type InlineWriteFunc func(inode, offset uint64, data []byte) error
+type InlineReadFunc func(inode uint64) (uint64, uint64, []byte, error)
 
 const (
 	MaxMountRetryLimit = 5
@@ -93,26 +96,37 @@ func init() {
 }
 
 type ExtentConfig struct {
-	Volume            string
-	VolumeType        int
-	Masters           []string
-	FollowerRead      bool
-	NearRead          bool
-	Preload           bool
-	ReadRate          int64
-	WriteRate         int64
-	BcacheEnable      bool
-	BcacheDir         string
-	MaxStreamerLimit  int64
-	OnAppendExtentKey AppendExtentKeyFunc
-	OnGetExtents      GetExtentsFunc
-	OnTruncate        TruncateFunc
-	OnEvictIcache     EvictIcacheFunc
-	OnLoadBcache      LoadBcacheFunc
-	OnCacheBcache     CacheBcacheFunc
-	OnEvictBcache     EvictBacheFunc
+	Volume             string
+	VolumeType         int
+	Masters            []string
+	FollowerRead       bool
+	NearRead           bool
+	Preload            bool
+	ReadRate           int64
+	WriteRate          int64
+	BcacheEnable       bool
+	BcacheDir          string
+	MaxStreamerLimit   int64
+	ReadAheadMemMB     int64 // memory cap of read-ahead, 0 means disable read-ahead
+	ReadAheadWindowMB  int64 // max read-ahead window of one stream
+	WriteBackCache     bool  // merge small writes in memory before sending to datanodes
+	BuffersTotalLimit  int64 // packet buffers limit, bounds the write-back cache
+	ReadConsistency    string
+	ReadMaxStalenessMS int64 // max staleness of bounded consistency read
+	OnAppendExtentKey  AppendExtentKeyFunc
+	OnGetExtents       GetExtentsFunc
+	OnTruncate         TruncateFunc
+	OnEvictIcache      EvictIcacheFunc
+	OnLoadBcache       LoadBcacheFunc
+	OnCacheBcache      CacheBcacheFunc
+	OnEvictBcache      EvictBacheFunc
+	OnInlineWrite      InlineWriteFunc // may be nil, inline storage is disabled then
+	OnInlineRead       InlineReadFunc
 
 	DisableMetaCache bool
+
+	// authenticates the connections to the datanodes if set
+	Credential *packetauth.Credential
 }
 
 // ExtentClient defines the struct of the extent client.
@@ -142,7 +156,19 @@ type ExtentClient struct {
 	loadBcache      LoadBcacheFunc
 	cacheBcache     CacheBcacheFunc
 	evictBcache     EvictBacheFunc
+	inlineWrite     InlineWriteFunc
+	inlineRead      InlineReadFunc
 	inflightL1cache sync.Map
+
+	readAheadPool   *ReadAheadPool
+	readAheadWindow int
+
+	writeBackPages    int64 // pages cached by all streamers
+	writeBackMaxPages int64 // 0 means write-back is disabled
+
+	readConsistency  string
+	readMaxStaleness time.Duration
+	leaderAppliedIDs sync.Map // partition id -> *leaderAppliedID
 }
 
 func (client *ExtentClient) evictStreamer() bool {
@@ -204,6 +230,10 @@ func (client *ExtentClient) backgroundEvictStream() {
 
 // NewExtentClient returns a new extent client.
 func NewExtentClient(config *ExtentConfig) (client *ExtentClient, err error) {
+	if !proto.IsValidReadConsistency(config.ReadConsistency) {
+		return nil, fmt.Errorf("invalid read consistency[%v]", config.ReadConsistency)
+	}
+
 	client = new(ExtentClient)
 	client.LimitManager = manager.NewLimitManager(client)
 	client.LimitManager.WrapperUpdate = client.UploadFlowInfo
@@ -227,9 +257,14 @@ retry:
 	client.evictIcache = config.OnEvictIcache
 	client.dataWrapper.InitFollowerRead(config.FollowerRead)
 	client.dataWrapper.SetNearRead(config.NearRead)
+	if config.Credential != nil {
+		client.dataWrapper.SetConnectPool(util.NewConnectPoolWithHandshake(config.Credential.Handshake(proto.DataServiceID)))
+	}
 	client.loadBcache = config.OnLoadBcache
 	client.cacheBcache = config.OnCacheBcache
 	client.evictBcache = config.OnEvictBcache
+	client.inlineWrite = config.OnInlineWrite
+	client.inlineRead = config.OnInlineRead
 	client.volumeType = config.VolumeType
 	client.volumeName = config.Volume
 	client.bcacheEnable = config.BcacheEnable
@@ -252,6 +287,29 @@ retry:
 	client.readLimiter = rate.NewLimiter(readLimit, defaultReadLimitBurst)
 	client.writeLimiter = rate.NewLimiter(writeLimit, defaultWriteLimitBurst)
 
+	if config.ReadAheadMemMB > 0 {
+		client.readAheadPool = NewReadAheadPool(config.Volume, config.ReadAheadMemMB*util.MB)
+		client.readAheadWindow = defaultReadAheadWindowMB * util.MB
+		if config.ReadAheadWindowMB > 0 {
+			client.readAheadWindow = int(config.ReadAheadWindowMB) * util.MB
+		}
+		if client.readAheadWindow < minReadAheadWindow {
+			client.readAheadWindow = minReadAheadWindow
+		}
+		log.LogInfof("read ahead mem(%v MB) window(%v)", config.ReadAheadMemMB, client.readAheadWindow)
+	}
+
+	client.readConsistency = config.ReadConsistency
+	client.readMaxStaleness = defaultReadMaxStalenessMS * time.Millisecond
+	if config.ReadMaxStalenessMS > 0 {
+		client.readMaxStaleness = time.Duration(config.ReadMaxStalenessMS) * time.Millisecond
+	}
+
+	if config.WriteBackCache {
+		client.writeBackMaxPages = writeBackMaxPages(config.BuffersTotalLimit)
+		log.LogInfof("write back cache max pages(%v)", client.writeBackMaxPages)
+	}
+
 	if config.MaxStreamerLimit <= 0 {
 		client.disableMetaCache = true
 		return
@@ -379,6 +437,20 @@ func (client *ExtentClient) RefreshExtentsCache(inode uint64) error {
 	return s.GetExtents()
 }
 
+// InvalidateCache is called when the inode is changed by others. Local dirty
+// data is flushed first, then the read-ahead blocks and extents are dropped.
+func (client *ExtentClient) InvalidateCache(inode uint64) error {
+	s := client.GetStreamer(inode)
+	if s == nil {
+		return nil
+	}
+	if err := s.IssueFlushRequest(); err != nil {
+		return err
+	}
+	s.invalidateReadAhead()
+	return s.GetExtentsForce()
+}
+
 // FileSize returns the file size.
 func (client *ExtentClient) FileSize(inode uint64) (size int, gen uint64, valid bool) {
 	s := client.GetStreamer(inode)
@@ -401,6 +473,12 @@ func (client *ExtentClient) SetFileSize(inode uint64, size int) {
 
 // Write writes the data.
 func (client *ExtentClient) Write(inode uint64, offset int, data []byte, flags int) (write int, err error) {
+	return client.WriteContext(context.Background(), inode, offset, data, flags)
+}
+
+// WriteContext is Write with the context, the packets carrying the data are traced as the
+// children of the span in ctx.
+func (client *ExtentClient) WriteContext(ctx context.Context, inode uint64, offset int, data []byte, flags int) (write int, err error) {
 	prefix := fmt.Sprintf("Write{ino(%v)offset(%v)size(%v)}", inode, offset, len(data))
 	s := client.GetStreamer(inode)
 	if s == nil {
@@ -413,7 +491,7 @@ func (client *ExtentClient) Write(inode uint64, offset int, data []byte, flags i
 		s.GetExtents()
 	})
 
-	write, err = s.IssueWriteRequest(offset, data, flags)
+	write, err = s.IssueWriteRequest(ctx, offset, data, flags)
 	if err != nil {
 		err = errors.Trace(err, prefix)
 		log.LogError(errors.Stack(err))
@@ -458,6 +536,12 @@ func (client *ExtentClient) Flush(inode uint64) error {
 }
 
 func (client *ExtentClient) Read(inode uint64, data []byte, offset int, size int) (read int, err error) {
+	return client.ReadContext(context.Background(), inode, data, offset, size)
+}
+
+// ReadContext is Read with the context, the packets reading the data are traced as the
+// children of the span in ctx.
+func (client *ExtentClient) ReadContext(ctx context.Context, inode uint64, data []byte, offset int, size int) (read int, err error) {
 	//log.LogErrorf("======> ExtentClient Read Enter, inode(%v), len(data)=(%v), offset(%v), size(%v).", inode, len(data), offset, size)
 	//t1 := time.Now()
 	if size == 0 {
@@ -479,7 +563,7 @@ func (client *ExtentClient) Read(inode uint64, data []byte, offset int, size int
 		return
 	}
 
-	read, err = s.read(data, offset, size)
+	read, err = s.read(ctx, data, offset, size)
 	// log.LogErrorf("======> ExtentClient Read Exit, inode(%v), time[%v us].", inode, time.Since(t1).Microseconds())
 	return
 }
@@ -523,7 +607,7 @@ func (client *ExtentClient) ReadExtent(inode uint64, ek *proto.ExtentKey, data [
 		//read full extent
 		buf := make([]byte, ek.Size)
 		req = NewExtentRequest(int(ek.FileOffset), int(ek.Size), buf, ek)
-		read, err = reader.Read(req)
+		read, err = reader.Read(context.Background(), req)
 		if err != nil {
 			return
 		}
@@ -549,7 +633,7 @@ func (client *ExtentClient) ReadExtent(inode uint64, ek *proto.ExtentKey, data [
 		s.client.LimitManager.ReadAlloc(ctx, size)
 		isStream = true
 
-		read, err = reader.Read(req)
+		read, err = reader.Read(context.Background(), req)
 		if err != nil {
 			return
 		}
@@ -620,6 +704,9 @@ func (client *ExtentClient) Close() error {
 		_ = client.EvictStream(inode)
 	}
 	client.dataWrapper.Stop()
+	if client.readAheadPool != nil {
+		client.readAheadPool.Stop()
+	}
 	return nil
 }
 
@@ -17,6 +17,7 @@ package stream
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"sync/atomic"
 	"time"
 
@@ -26,7 +27,9 @@ import (
 	"github.com/cubefs/cubefs/sdk/data/wrapper"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 // State machines
@@ -76,7 +79,7 @@ type ExtentHandler struct {
 
 	// Allocated in the sender, and released in the receiver.
 	// Will not be changed.
-	conn *net.TCPConn
+	conn net.Conn
 	dp   *wrapper.DataPartition
 
 	// Issue a signal to this channel when *inflight* hits zero.
@@ -171,6 +174,7 @@ func (eh *ExtentHandler) write(data []byte, offset, size int, direct bool) (ek *
 	for total < size {
 		if eh.packet == nil {
 			eh.packet = NewWritePacket(eh.inode, offset+total, eh.storeMode)
+			eh.packet.traceParent = eh.stream.writeTraceContext
 			if direct {
 				eh.packet.Opcode = proto.OpSyncWrite
 			}
@@ -253,6 +257,10 @@ func (eh *ExtentHandler) sender() {
 				packet.RemainingFollowers = 127
 			}
 			packet.StartT = time.Now().UnixNano()
+			packet.span = trace.StartSpan("client.stream."+packet.GetOpMsg(), packet.traceParent)
+			packet.span.SetTag("partition_id", packet.PartitionID)
+			packet.span.SetTag("req_id", packet.ReqID)
+			packet.TraceContext = packet.span.Context()
 
 			//log.LogDebugf("ExtentHandler sender: extent allocated, eh(%v) dp(%v) extID(%v) packet(%v)", eh, eh.dp, eh.extID, packet.GetUniqueLogId())
 
@@ -307,6 +315,7 @@ func (eh *ExtentHandler) processReply(packet *Packet) {
 		log.LogErrorf("processReply discard packet: handler is in error status, inflight(%v) eh(%v) packet(%v)", atomic.LoadInt32(&eh.inflight), eh, packet)
 		return
 	} else if status >= ExtentStatusRecovery {
+		packet.span.Finish(errors.New("handler is in recovery status"))
 		if err := eh.recoverPacket(packet); err != nil {
 			eh.discardPacket(packet)
 			log.LogErrorf("processReply discard packet: handler is in recovery status, inflight(%v) eh(%v) packet(%v) err(%v)", atomic.LoadInt32(&eh.inflight), eh, packet, err)
@@ -343,6 +352,8 @@ func (eh *ExtentHandler) processReply(packet *Packet) {
 	}
 
 	eh.dp.RecordWrite(packet.StartT)
+	eh.newOpTP(packet).Set(nil)
+	packet.span.Finish(nil)
 
 	var (
 		extID, extOffset uint64
@@ -375,6 +386,9 @@ func (eh *ExtentHandler) processReply(packet *Packet) {
 }
 
 func (eh *ExtentHandler) processReplyError(packet *Packet, errmsg string) {
+	eh.dp.RecordWriteError()
+	eh.newOpTP(packet).Set(errors.New(errmsg))
+	packet.span.Finish(errors.New(errmsg))
 	eh.setClosed()
 	eh.setRecovery()
 	if err := eh.recoverPacket(packet); err != nil {
@@ -385,6 +399,16 @@ func (eh *ExtentHandler) processReplyError(packet *Packet, errmsg string) {
 	}
 }
 
+// newOpTP times the write packet since it was sent, or since now if it has not been sent.
+func (eh *ExtentHandler) newOpTP(packet *Packet) *exporter.OpTimePoint {
+	startTime := time.Now()
+	if packet.StartT != 0 {
+		startTime = time.Unix(0, packet.StartT)
+	}
+	return exporter.NewOpTPSince(packet.GetOpMsg(), eh.stream.client.volumeName,
+		strconv.FormatInt(packet.ReqID, 10), startTime)
+}
+
 func (eh *ExtentHandler) flush() (err error) {
 	eh.flushPacket()
 	eh.waitForFlush()
@@ -412,10 +436,11 @@ func (eh *ExtentHandler) cleanup() (err error) {
 		conn := eh.conn
 		eh.conn = nil
 		// TODO unhandled error
+		connPool := getConnectPool(eh.dp)
 		if status := eh.getStatus(); status >= ExtentStatusRecovery {
-			StreamConnPool.PutConnect(conn, true)
+			connPool.PutConnect(conn, true)
 		} else {
-			StreamConnPool.PutConnect(conn, false)
+			connPool.PutConnect(conn, false)
 		}
 	}
 	return
@@ -507,7 +532,7 @@ func (eh *ExtentHandler) discardPacket(packet *Packet) {
 func (eh *ExtentHandler) allocateExtent() (err error) {
 	var (
 		dp    *wrapper.DataPartition
-		conn  *net.TCPConn
+		conn  net.Conn
 		extID int
 	)
 
@@ -530,6 +555,7 @@ func (eh *ExtentHandler) allocateExtent() (err error) {
 			if err != nil {
 				log.LogWarnf("allocateExtent: exclude dp[%v] for write caused by create extent failed, eh(%v) err(%v) exclude(%v)",
 					dp, eh, err, exclude)
+				dp.RecordWriteError()
 				eh.stream.client.dataWrapper.RemoveDataPartitionForWrite(dp.PartitionID)
 				dp.CheckAllHostsIsAvail(exclude)
 				continue
@@ -542,7 +568,7 @@ func (eh *ExtentHandler) allocateExtent() (err error) {
 			extID = int(eh.key.ExtentId)
 		}
 
-		if conn, err = StreamConnPool.GetConnect(dp.Hosts[0]); err != nil {
+		if conn, err = getConnectPool(dp).GetConnect(dp.Hosts[0]); err != nil {
 			log.LogWarnf("allocateExtent: failed to create connection, eh(%v) err(%v) dp(%v) exclude(%v)",
 				eh, err, dp, exclude)
 			// If storeMode is tinyExtentType and can't create connection, we also check host status.
@@ -571,16 +597,8 @@ func (eh *ExtentHandler) allocateExtent() (err error) {
 	return err
 }
 
-func (eh *ExtentHandler) createConnection(dp *wrapper.DataPartition) (*net.TCPConn, error) {
-	conn, err := net.DialTimeout("tcp", dp.Hosts[0], time.Second)
-	if err != nil {
-		return nil, err
-	}
-	connect := conn.(*net.TCPConn)
-	// TODO unhandled error
-	connect.SetKeepAlive(true)
-	connect.SetNoDelay(true)
-	return connect, nil
+func (eh *ExtentHandler) createConnection(dp *wrapper.DataPartition) (net.Conn, error) {
+	return util.DailTimeOut(dp.Hosts[0], time.Second)
 }
 
 func (eh *ExtentHandler) createExtent(dp *wrapper.DataPartition) (extID int, err error) {
@@ -589,16 +607,17 @@ func (eh *ExtentHandler) createExtent(dp *wrapper.DataPartition) (extID int, err
 		stat.EndStat("createExtent", err, bgTime, 1)
 	}()
 
-	conn, err := StreamConnPool.GetConnect(dp.Hosts[0])
+	connPool := getConnectPool(dp)
+	conn, err := connPool.GetConnect(dp.Hosts[0])
 	if err != nil {
 		return extID, errors.Trace(err, "createExtent: failed to create connection, eh(%v) datapartionHosts(%v)", eh, dp.Hosts[0])
 	}
 
 	defer func() {
 		if err != nil {
-			StreamConnPool.PutConnect(conn, true)
+			connPool.PutConnect(conn, true)
 		} else {
-			StreamConnPool.PutConnect(conn, false)
+			connPool.PutConnect(conn, false)
 		}
 	}()
 
@@ -15,6 +15,7 @@
 package stream
 
 import (
+	"context"
 	"fmt"
 	"hash/crc32"
 	"net"
@@ -24,7 +25,9 @@ import (
 	"github.com/cubefs/cubefs/sdk/data/wrapper"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/errors"
+	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 // ExtentReader defines the struct of the extent reader.
@@ -34,6 +37,7 @@ type ExtentReader struct {
 	dp           *wrapper.DataPartition
 	followerRead bool
 	retryRead    bool
+	minAppliedID uint64 // required applied ID of follower read, 0 means none
 }
 
 // NewExtentReader returns a new extent reader.
@@ -54,16 +58,28 @@ func (reader *ExtentReader) String() (m string) {
 }
 
 // Read reads the extent request.
-func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error) {
+func (reader *ExtentReader) Read(ctx context.Context, req *ExtentRequest) (readBytes int, err error) {
 	offset := req.FileOffset - int(reader.key.FileOffset) + int(reader.key.ExtentOffset)
 	size := req.Size
 
 	reqPacket := NewReadPacket(reader.key, offset, size, reader.inode, req.FileOffset, reader.followerRead)
+	if reader.followerRead && reader.minAppliedID > 0 {
+		reqPacket.SetMinAppliedID(reader.minAppliedID)
+	}
 	sc := NewStreamConn(reader.dp, reader.followerRead)
+	span := trace.StartSpan("client.stream."+reqPacket.GetOpMsg(), trace.SpanContextFromContext(ctx))
+	span.SetTag("partition_id", reqPacket.PartitionID)
+	span.SetTag("req_id", reqPacket.ReqID)
+	opMetric := exporter.NewOpTP(reqPacket.GetOpMsg(), reader.dp.ClientWrapper.VolName(), span.TraceID())
+	reqPacket.TraceContext = span.Context()
+	defer func() {
+		opMetric.Set(err)
+		span.Finish(err)
+	}()
 
 	log.LogDebugf("ExtentReader Read enter: size(%v) req(%v) reqPacket(%v)", size, req, reqPacket)
 
-	err = sc.Send(reader.retryRead, reqPacket, func(conn *net.TCPConn) (error, bool) {
+	err = sc.Send(reader.retryRead, reqPacket, func(conn net.Conn) (error, bool) {
 		readBytes = 0
 		for readBytes < size {
 			replyPacket := NewReply(reqPacket.ReqID, reader.dp.PartitionID, reqPacket.ExtentID)
@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/sdk/data/wrapper"
 	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 // Packet defines a wrapper of the packet in proto.
@@ -32,6 +33,10 @@ type Packet struct {
 	proto.Packet
 	inode    uint64
 	errCount int
+
+	// the span of the current attempt to send the packet, the attempts are the children of traceParent
+	span        *trace.Span
+	traceParent trace.SpanContext
 }
 
 // String returns the string format of the packet.
@@ -96,6 +101,17 @@ func NewReadPacket(key *proto.ExtentKey, extentOffset, size int, inode uint64, f
 	return p
 }
 
+// NewGetAppliedIDPacket returns a new packet to get the raft applied ID of the partition.
+func NewGetAppliedIDPacket(partitionID uint64) *Packet {
+	p := new(Packet)
+	p.PartitionID = partitionID
+	p.Magic = proto.ProtoMagic
+	p.ExtentType = proto.NormalExtentType
+	p.ReqID = proto.GenerateRequestID()
+	p.Opcode = proto.OpGetAppliedId
+	return p
+}
+
 // NewCreateExtentPacket returns a new packet to create extent.
 func NewCreateExtentPacket(dp *wrapper.DataPartition, inode uint64) *Packet {
 	p := new(Packet)
@@ -158,6 +174,9 @@ func (p *Packet) readFromConn(c net.Conn, deadlineTime time.Duration) (err error
 	if err = p.UnmarshalHeader(header); err != nil {
 		return
 	}
+	if err = p.ReadTraceContext(c); err != nil {
+		return
+	}
 
 	if p.ArgLen > 0 {
 		if err = readToBuffer(c, &p.Arg, int(p.ArgLen)); err != nil {
@@ -0,0 +1,77 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/trace"
+)
+
+func init() {
+	proto.InitBufferPool(int64(32768))
+}
+
+func TestPacketTraceContextRoundTrip(t *testing.T) {
+	sc := trace.SpanContext{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		Flags:   trace.FlagSampled,
+	}
+	unsampled := sc
+	unsampled.Flags = 0
+
+	for _, ctx := range []trace.SpanContext{sc, unsampled, {}} {
+		req := NewWritePacket(1, 0, proto.NormalExtentType)
+		req.ExtentType = proto.NormalExtentType
+		req.PartitionID = 10
+		req.ExtentID = 1024
+		copy(req.Data, "hello cubefs")
+		req.Size = uint32(len("hello cubefs"))
+		req.TraceContext = ctx
+
+		client, server := net.Pipe()
+		go func() {
+			if err := req.writeToConn(client); err != nil {
+				t.Error(err)
+			}
+			client.Close()
+		}()
+		reply := NewReply(req.ReqID, req.PartitionID, req.ExtentID)
+		reply.Data = make([]byte, req.Size)
+		if err := reply.readFromConn(server, proto.ReadDeadlineTime); err != nil {
+			t.Fatal(err)
+		}
+		server.Close()
+
+		expect := ctx
+		if !ctx.IsSampled() {
+			expect = trace.SpanContext{}
+		}
+		if reply.TraceContext != expect {
+			t.Fatalf("trace context %+v, expect %+v", reply.TraceContext, expect)
+		}
+		if reply.ExtentType != proto.NormalExtentType || reply.Opcode != proto.OpWrite || reply.CRC != req.CRC ||
+			reply.ReqID != req.ReqID || reply.Size != req.Size {
+			t.Fatalf("header %v, expect %v", reply, req)
+		}
+		if !bytes.Equal(reply.Data, req.Data[:req.Size]) {
+			t.Fatalf("data [%s], expect [%s]", reply.Data, req.Data[:req.Size])
+		}
+	}
+}
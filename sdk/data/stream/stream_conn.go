@@ -35,7 +35,7 @@ const (
 	StreamSendSleepInterval = 100 * time.Millisecond
 )
 
-type GetReplyFunc func(conn *net.TCPConn) (err error, again bool)
+type GetReplyFunc func(conn net.Conn) (err error, again bool)
 
 // StreamConn defines the struct of the stream connection.
 type StreamConn struct {
@@ -47,6 +47,16 @@ var (
 	StreamConnPool = util.NewConnectPool()
 )
 
+// getConnectPool returns the pool of the connections to the datanodes of the partition.
+func getConnectPool(dp *wrapper.DataPartition) *util.ConnectPool {
+	if dp.ClientWrapper != nil {
+		if pool := dp.ClientWrapper.ConnectPool(); pool != nil {
+			return pool
+		}
+	}
+	return StreamConnPool
+}
+
 // NewStreamConn returns a new stream connection.
 func NewStreamConn(dp *wrapper.DataPartition, follower bool) (sc *StreamConn) {
 	if !follower {
@@ -116,15 +126,16 @@ func (sc *StreamConn) Send(retry bool, req *Packet, getReply GetReplyFunc) (err
 }
 
 func (sc *StreamConn) sendToPartition(req *Packet, retry bool, getReply GetReplyFunc) (err error) {
-	conn, err := StreamConnPool.GetConnect(sc.currAddr)
+	connPool := getConnectPool(sc.dp)
+	conn, err := connPool.GetConnect(sc.currAddr)
 	if err == nil {
 		err = sc.sendToConn(conn, req, getReply)
 		if err == nil {
-			StreamConnPool.PutConnect(conn, false)
+			connPool.PutConnect(conn, false)
 			return
 		}
 		log.LogWarnf("sendToPartition: send to curr addr failed, addr(%v) reqPacket(%v) err(%v)", sc.currAddr, req, err)
-		StreamConnPool.PutConnect(conn, true)
+		connPool.PutConnect(conn, true)
 		if err != TryOtherAddrError || !retry {
 			return
 		}
@@ -136,7 +147,7 @@ func (sc *StreamConn) sendToPartition(req *Packet, retry bool, getReply GetReply
 
 	for _, addr := range hosts {
 		log.LogWarnf("sendToPartition: try addr(%v) reqPacket(%v)", addr, req)
-		conn, err = StreamConnPool.GetConnect(addr)
+		conn, err = connPool.GetConnect(addr)
 		if err != nil {
 			log.LogWarnf("sendToPartition: failed to get connection to addr(%v) reqPacket(%v) err(%v)", addr, req, err)
 			continue
@@ -145,10 +156,10 @@ func (sc *StreamConn) sendToPartition(req *Packet, retry bool, getReply GetReply
 		sc.dp.LeaderAddr = addr
 		err = sc.sendToConn(conn, req, getReply)
 		if err == nil {
-			StreamConnPool.PutConnect(conn, false)
+			connPool.PutConnect(conn, false)
 			return
 		}
-		StreamConnPool.PutConnect(conn, true)
+		connPool.PutConnect(conn, true)
 		if err != TryOtherAddrError {
 			return
 		}
@@ -157,7 +168,7 @@ func (sc *StreamConn) sendToPartition(req *Packet, retry bool, getReply GetReply
 	return errors.New(fmt.Sprintf("sendToPatition Failed: sc(%v) reqPacket(%v)", sc, req))
 }
 
-func (sc *StreamConn) sendToConn(conn *net.TCPConn, req *Packet, getReply GetReplyFunc) (err error) {
+func (sc *StreamConn) sendToConn(conn net.Conn, req *Packet, getReply GetReplyFunc) (err error) {
 	for i := 0; i < StreamSendMaxRetry; i++ {
 		log.LogDebugf("sendToConn: send to addr(%v), reqPacket(%v)", sc.currAddr, req)
 		err = req.WriteToConn(conn)
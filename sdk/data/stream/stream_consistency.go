@@ -0,0 +1,108 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/wrapper"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	defaultReadMaxStalenessMS = 1000
+)
+
+type leaderAppliedID struct {
+	appliedID  uint64
+	updateTime time.Time
+}
+
+// readPolicy decides whether the read of the partition can go to followers,
+// minAppliedID is only set for bounded staleness reads.
+func (client *ExtentClient) readPolicy(dp *wrapper.DataPartition) (followerRead bool, minAppliedID uint64) {
+	switch client.readConsistency {
+	case proto.ReadConsistencyLeader:
+		return false, 0
+	case proto.ReadConsistencyAny:
+		return true, 0
+	case proto.ReadConsistencyBounded:
+		appliedID, err := client.getLeaderAppliedID(dp)
+		if err != nil {
+			log.LogWarnf("readPolicy: read from leader as failed to get applied ID, dp(%v) err(%v)", dp.PartitionID, err)
+			return false, 0
+		}
+		return true, appliedID
+	default:
+		return client.dataWrapper.FollowerRead(), 0
+	}
+}
+
+// getLeaderAppliedID returns the applied ID learned from the leader, it is
+// refreshed once it gets older than readMaxStaleness.
+func (client *ExtentClient) getLeaderAppliedID(dp *wrapper.DataPartition) (appliedID uint64, err error) {
+	if value, ok := client.leaderAppliedIDs.Load(dp.PartitionID); ok {
+		entry := value.(*leaderAppliedID)
+		if time.Since(entry.updateTime) < client.readMaxStaleness {
+			return entry.appliedID, nil
+		}
+	}
+	if appliedID, err = fetchLeaderAppliedID(dp); err != nil {
+		return
+	}
+	client.leaderAppliedIDs.Store(dp.PartitionID, &leaderAppliedID{appliedID: appliedID, updateTime: time.Now()})
+	return
+}
+
+// forgetLeaderAppliedID drops the learned applied ID of the partition,
+// so that the following reads see the local overwrites.
+func (client *ExtentClient) forgetLeaderAppliedID(partitionID uint64) {
+	if client.readConsistency == proto.ReadConsistencyBounded {
+		client.leaderAppliedIDs.Delete(partitionID)
+	}
+}
+
+func fetchLeaderAppliedID(dp *wrapper.DataPartition) (appliedID uint64, err error) {
+	leaderAddr := dp.LeaderAddr
+	if leaderAddr == "" {
+		return 0, fmt.Errorf("no leader")
+	}
+	connPool := getConnectPool(dp)
+	conn, err := connPool.GetConnect(leaderAddr)
+	if err != nil {
+		return
+	}
+	defer func() {
+		connPool.PutConnect(conn, err != nil)
+	}()
+
+	reqPacket := NewGetAppliedIDPacket(dp.PartitionID)
+	if err = reqPacket.WriteToConn(conn); err != nil {
+		return
+	}
+	replyPacket := new(Packet)
+	if err = replyPacket.ReadFromConn(conn, proto.ReadDeadlineTime); err != nil {
+		return
+	}
+	if replyPacket.ResultCode != proto.OpOk || !reqPacket.isValidWriteReply(replyPacket) || len(replyPacket.Data) < 8 {
+		return 0, fmt.Errorf("leader(%v) reply NOK: reply(%v)", leaderAddr, replyPacket)
+	}
+	appliedID = binary.BigEndian.Uint64(replyPacket.Data[:8])
+	log.LogDebugf("fetchLeaderAppliedID: dp(%v) leader(%v) appliedID(%v)", dp.PartitionID, leaderAddr, appliedID)
+	return
+}
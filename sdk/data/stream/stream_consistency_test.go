@@ -0,0 +1,115 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/data/wrapper"
+)
+
+func newTestConsistencyPartition() *wrapper.DataPartition {
+	// no leader, so the applied ID can only come from the cache
+	dp := new(wrapper.DataPartition)
+	dp.PartitionID = 10
+	return dp
+}
+
+func TestReadPolicy(t *testing.T) {
+	dp := newTestConsistencyPartition()
+	client := &ExtentClient{readMaxStaleness: time.Second}
+	client.leaderAppliedIDs.Store(dp.PartitionID, &leaderAppliedID{appliedID: 100, updateTime: time.Now()})
+
+	for _, c := range []struct {
+		consistency  string
+		followerRead bool
+		minAppliedID uint64
+	}{
+		{proto.ReadConsistencyLeader, false, 0},
+		{proto.ReadConsistencyAny, true, 0},
+		{proto.ReadConsistencyBounded, true, 100},
+	} {
+		client.readConsistency = c.consistency
+		if followerRead, minAppliedID := client.readPolicy(dp); followerRead != c.followerRead || minAppliedID != c.minAppliedID {
+			t.Fatalf("consistency(%v): follower read(%v) min applied ID(%v), expect %v %v",
+				c.consistency, followerRead, minAppliedID, c.followerRead, c.minAppliedID)
+		}
+	}
+}
+
+func TestLeaderAppliedIDStaleness(t *testing.T) {
+	dp := newTestConsistencyPartition()
+	client := &ExtentClient{readConsistency: proto.ReadConsistencyBounded, readMaxStaleness: 50 * time.Millisecond}
+	client.leaderAppliedIDs.Store(dp.PartitionID, &leaderAppliedID{appliedID: 100, updateTime: time.Now()})
+
+	if appliedID, err := client.getLeaderAppliedID(dp); err != nil || appliedID != 100 {
+		t.Fatalf("fresh applied ID(%v) err(%v), expect the cached one", appliedID, err)
+	}
+	// the stale applied ID is refreshed from the leader, which is not reachable
+	time.Sleep(2 * client.readMaxStaleness)
+	if appliedID, err := client.getLeaderAppliedID(dp); err == nil {
+		t.Fatalf("stale applied ID(%v) should not be used", appliedID)
+	}
+	if followerRead, _ := client.readPolicy(dp); followerRead {
+		t.Fatal("read should go to the leader without a fresh applied ID")
+	}
+
+	// a local overwrite drops the applied ID learned before it
+	client.leaderAppliedIDs.Store(dp.PartitionID, &leaderAppliedID{appliedID: 100, updateTime: time.Now()})
+	client.forgetLeaderAppliedID(dp.PartitionID)
+	if _, ok := client.leaderAppliedIDs.Load(dp.PartitionID); ok {
+		t.Fatal("applied ID should be forgotten")
+	}
+}
+
+func TestMinAppliedIDRoundTrip(t *testing.T) {
+	key := &proto.ExtentKey{PartitionId: 10, ExtentId: 1024}
+	for _, appliedID := range []uint64{1, 12345, 1<<64 - 1} {
+		req := NewReadPacket(key, 0, 4096, 1, 0, true)
+		req.SetMinAppliedID(appliedID)
+
+		client, server := net.Pipe()
+		go func() {
+			if err := req.WriteToConn(client); err != nil {
+				t.Error(err)
+			}
+			client.Close()
+		}()
+		got := proto.NewPacket()
+		if err := got.ReadFromConn(server, proto.ReadDeadlineTime); err != nil {
+			t.Fatal(err)
+		}
+		server.Close()
+		if got.Opcode != proto.OpStreamFollowerRead || got.GetMinAppliedID() != appliedID {
+			t.Fatalf("op(%v) min applied ID(%v), expect %v", got.Opcode, got.GetMinAppliedID(), appliedID)
+		}
+	}
+
+	// only a follower read carries the applied ID, and the follower addresses are not taken as it
+	read := NewReadPacket(key, 0, 4096, 1, 0, false)
+	read.SetMinAppliedID(12345)
+	if appliedID := read.GetMinAppliedID(); appliedID != 0 {
+		t.Fatalf("stream read min applied ID(%v), expect 0", appliedID)
+	}
+	followerRead := NewReadPacket(key, 0, 4096, 1, 0, true)
+	followerRead.Arg = []byte("192.168.0.1:17310/")
+	followerRead.ArgLen = uint32(len(followerRead.Arg))
+	if appliedID := followerRead.GetMinAppliedID(); appliedID != 0 {
+		t.Fatalf("min applied ID(%v) of follower addresses, expect 0", appliedID)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"io"
+	"syscall"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// inlineSupported returns whether the files of the volume may hold inline
+// data. It does not depend on the inline size limit, which may be lowered
+// after some files are stored inline.
+func (client *ExtentClient) inlineSupported() bool {
+	return client.inlineWrite != nil && client.inlineRead != nil && proto.IsHot(client.volumeType)
+}
+
+// inlineSizeLimit returns the size up to which new data is stored inline in
+// the inode, 0 means inline writes are disabled.
+func (client *ExtentClient) inlineSizeLimit() int {
+	if !client.inlineSupported() {
+		return 0
+	}
+	return int(client.dataWrapper.InlineSizeLimit())
+}
+
+// isInline returns whether the file has no extent and no pending extent
+// handler, so its data, if any, may be inline in the inode.
+func (s *Streamer) isInline() bool {
+	return s.extents.Len() == 0 && s.handler == nil && s.dirtylist.Len() == 0
+}
+
+// writeInline writes the data inline into the inode if allowed and the file
+// stays within the inline size limit. Otherwise the inline data of the file, if
+// any, is moved to extents, and false is returned to let the caller write the
+// data to extents. The inline data is moved even if the limit has been lowered
+// to 0, since the metanode drops it once the extents are appended.
+func (s *Streamer) writeInline(data []byte, offset, size int, allowed bool) (written bool, err error) {
+	if !s.client.inlineSupported() || !s.isInline() {
+		return false, nil
+	}
+
+	limit := s.client.inlineSizeLimit()
+	filesize, _ := s.extents.Size()
+	if allowed && limit > 0 && offset+size <= limit {
+		err = s.client.inlineWrite(s.inode, uint64(offset), data[:size])
+		if err == nil {
+			if offset+size > filesize {
+				filesize = offset + size
+			}
+			// the metanode bumps the generation on every inline write
+			s.extents.SetSize(uint64(filesize), true)
+			log.LogDebugf("writeInline: ino(%v) offset(%v) size(%v) filesize(%v)", s.inode, offset, size, filesize)
+			return true, nil
+		}
+		if err != syscall.EFBIG {
+			return false, err
+		}
+	} else if filesize == 0 {
+		// nothing inline yet
+		return false, nil
+	}
+
+	return false, s.promoteInline()
+}
+
+// promoteInline moves the inline data of the file to extents. The metanode
+// drops the inline data once the extent keys are appended to the inode.
+func (s *Streamer) promoteInline() (err error) {
+	_, _, data, err := s.client.inlineRead(s.inode)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	if _, err = s.writeExtents(data, 0, len(data), 0); err != nil {
+		return
+	}
+	if err = s.flush(); err != nil {
+		return
+	}
+	log.LogDebugf("promoteInline: ino(%v) size(%v)", s.inode, len(data))
+	return
+}
+
+// readInline reads a file without extents from its inline data in the inode,
+// the part beyond the inline data is a hole.
+func (s *Streamer) readInline(data []byte, offset, size int) (total int, err error) {
+	_, fsize, inline, err := s.client.inlineRead(s.inode)
+	if err != nil {
+		return
+	}
+
+	filesize := int(fsize)
+	if offset+size > filesize {
+		if offset > filesize {
+			return
+		}
+		size = filesize - offset
+		err = io.EOF
+	}
+
+	n := 0
+	if offset < len(inline) {
+		n = copy(data[:size], inline[offset:])
+	}
+	for i := n; i < size; i++ {
+		data[i] = 0
+	}
+	log.LogDebugf("readInline: ino(%v) offset(%v) size(%v) inline(%v)", s.inode, offset, size, len(inline))
+	return size, err
+}
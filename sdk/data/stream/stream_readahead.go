@@ -0,0 +1,358 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/exporter"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	readAheadBlockSize       = 1 << 20
+	defaultReadAheadWindowMB = 16
+	minReadAheadWindow       = 2 * readAheadBlockSize
+	readAheadConcurrency     = 16
+	readAheadMetricInterval  = 10 * time.Second
+)
+
+type readAheadKey struct {
+	inode uint64
+	index int
+}
+
+type readAheadBlock struct {
+	key   readAheadKey
+	gen   uint64 // generation of streamer when the block is fetched
+	data  []byte
+	size  int // valid bytes in data
+	refs  int
+	err   error
+	ready chan struct{}
+	elem  *list.Element
+}
+
+// ReadAheadPool is the bounded buffer pool of prefetched blocks shared by all streamers.
+type ReadAheadPool struct {
+	sync.Mutex
+	capacity int64
+	used     int64
+	blocks   map[readAheadKey]*readAheadBlock
+	lru      *list.List
+	buffers  [][]byte // free buffers to reuse
+	limit    chan struct{}
+
+	hit  uint64
+	miss uint64
+
+	volume string
+	stopC  chan struct{}
+}
+
+func NewReadAheadPool(volume string, capacity int64) *ReadAheadPool {
+	pool := &ReadAheadPool{
+		capacity: capacity,
+		blocks:   make(map[readAheadKey]*readAheadBlock),
+		lru:      list.New(),
+		limit:    make(chan struct{}, readAheadConcurrency),
+		volume:   volume,
+		stopC:    make(chan struct{}),
+	}
+	go pool.reportMetrics()
+	return pool
+}
+
+// get returns the block with a reference if it is prefetched in generation gen,
+// it waits for the inflight prefetching. The block must be put back after used.
+func (pool *ReadAheadPool) get(key readAheadKey, gen uint64) *readAheadBlock {
+	pool.Lock()
+	blk, ok := pool.blocks[key]
+	if !ok || blk.gen != gen {
+		pool.Unlock()
+		return nil
+	}
+	blk.refs++
+	pool.lru.MoveToFront(blk.elem)
+	pool.Unlock()
+
+	<-blk.ready
+	if blk.err != nil {
+		pool.put(blk)
+		return nil
+	}
+	return blk
+}
+
+func (pool *ReadAheadPool) put(blk *readAheadBlock) {
+	pool.Lock()
+	blk.refs--
+	pool.Unlock()
+}
+
+// reserve allocates a block to prefetch, returns nil if the block exists or no memory.
+func (pool *ReadAheadPool) reserve(key readAheadKey, gen uint64) *readAheadBlock {
+	pool.Lock()
+	defer pool.Unlock()
+
+	if blk, ok := pool.blocks[key]; ok {
+		if blk.gen == gen || blk.refs > 0 {
+			return nil
+		}
+		pool.remove(blk)
+	}
+	for pool.used+readAheadBlockSize > pool.capacity {
+		if !pool.evictOne() {
+			return nil
+		}
+	}
+
+	var data []byte
+	if n := len(pool.buffers); n > 0 {
+		data = pool.buffers[n-1]
+		pool.buffers = pool.buffers[:n-1]
+	} else {
+		data = make([]byte, readAheadBlockSize)
+	}
+	blk := &readAheadBlock{key: key, gen: gen, data: data, refs: 1, ready: make(chan struct{})}
+	blk.elem = pool.lru.PushFront(blk)
+	pool.blocks[key] = blk
+	pool.used += readAheadBlockSize
+	return blk
+}
+
+// complete finishes the prefetching of block and drops the reference of reserve.
+func (pool *ReadAheadPool) complete(blk *readAheadBlock, size int, err error) {
+	blk.size, blk.err = size, err
+	close(blk.ready)
+
+	pool.Lock()
+	blk.refs--
+	if err != nil && pool.blocks[blk.key] == blk {
+		pool.remove(blk)
+	}
+	pool.Unlock()
+}
+
+// evictOne evicts the least recently used block which is not in use.
+func (pool *ReadAheadPool) evictOne() bool {
+	for elem := pool.lru.Back(); elem != nil; elem = elem.Prev() {
+		if blk := elem.Value.(*readAheadBlock); blk.refs == 0 {
+			pool.remove(blk)
+			return true
+		}
+	}
+	return false
+}
+
+func (pool *ReadAheadPool) remove(blk *readAheadBlock) {
+	pool.lru.Remove(blk.elem)
+	delete(pool.blocks, blk.key)
+	pool.used -= readAheadBlockSize
+	if blk.refs == 0 {
+		pool.buffers = append(pool.buffers, blk.data)
+	}
+}
+
+func (pool *ReadAheadPool) HitRate() float64 {
+	hit, miss := atomic.LoadUint64(&pool.hit), atomic.LoadUint64(&pool.miss)
+	if hit+miss == 0 {
+		return 0
+	}
+	return float64(hit) / float64(hit+miss)
+}
+
+func (pool *ReadAheadPool) reportMetrics() {
+	t := time.NewTicker(readAheadMetricInterval)
+	defer t.Stop()
+	labels := map[string]string{"volName": pool.volume}
+	for {
+		select {
+		case <-pool.stopC:
+			return
+		case <-t.C:
+		}
+		pool.Lock()
+		used := pool.used
+		pool.Unlock()
+		exporter.NewGauge("readAheadHitRate").SetWithLabels(pool.HitRate(), labels)
+		exporter.NewGauge("readAheadMemUsed").SetWithLabels(float64(used), labels)
+		exporter.NewCounter("readAheadHit").AddWithLabels(int64(atomic.SwapUint64(&pool.hit, 0)), labels)
+		exporter.NewCounter("readAheadMiss").AddWithLabels(int64(atomic.SwapUint64(&pool.miss, 0)), labels)
+	}
+}
+
+func (pool *ReadAheadPool) Stop() {
+	close(pool.stopC)
+}
+
+// readAheadState detects the access pattern of a streamer.
+type readAheadState struct {
+	sync.Mutex
+	nextOffset int // end of the last read
+	window     int
+	prefetched int // file offset prefetched to
+}
+
+// updateReadAhead returns the read-ahead window after the read,
+// window grows twice on sequential read and resets on random read.
+func (s *Streamer) updateReadAhead(offset, size int) int {
+	st := &s.readAhead
+	st.Lock()
+	defer st.Unlock()
+
+	// a little reorder of concurrent reads is considered as sequential,
+	// the read may be one read ahead of or behind the last one
+	if offset <= st.nextOffset+size && offset+2*size >= st.nextOffset {
+		if st.window == 0 {
+			st.window = minReadAheadWindow
+		} else {
+			st.window *= 2
+		}
+		if st.window > s.client.readAheadWindow {
+			st.window = s.client.readAheadWindow
+		}
+		if offset+size > st.nextOffset {
+			st.nextOffset = offset + size
+		}
+	} else {
+		st.window = 0
+		st.prefetched = 0
+		st.nextOffset = offset + size
+	}
+	return st.window
+}
+
+// invalidateReadAhead drops all prefetched blocks of the streamer.
+func (s *Streamer) invalidateReadAhead() {
+	if s.client.readAheadPool == nil {
+		return
+	}
+	atomic.AddUint64(&s.readAheadGen, 1)
+	s.readAhead.Lock()
+	s.readAhead.prefetched = 0
+	s.readAhead.Unlock()
+}
+
+// readFromReadAhead copies data from prefetched blocks, it returns false if any block missed.
+func (s *Streamer) readFromReadAhead(data []byte, offset, size int) bool {
+	pool := s.client.readAheadPool
+	if filesize, _ := s.extents.Size(); offset+size > filesize {
+		atomic.AddUint64(&pool.miss, 1)
+		return false
+	}
+
+	gen := atomic.LoadUint64(&s.readAheadGen)
+	for off := offset; off < offset+size; {
+		index := off / readAheadBlockSize
+		blk := pool.get(readAheadKey{inode: s.inode, index: index}, gen)
+		if blk == nil {
+			atomic.AddUint64(&pool.miss, 1)
+			return false
+		}
+		blkOffset := off - index*readAheadBlockSize
+		end := (index + 1) * readAheadBlockSize
+		if end > offset+size {
+			end = offset + size
+		}
+		if blkOffset+end-off > blk.size {
+			pool.put(blk)
+			atomic.AddUint64(&pool.miss, 1)
+			return false
+		}
+		copy(data[off-offset:end-offset], blk.data[blkOffset:])
+		pool.put(blk)
+		off = end
+	}
+	atomic.AddUint64(&pool.hit, 1)
+	return true
+}
+
+// prefetch reads blocks of [offset, offset+window) into the pool in background.
+func (s *Streamer) prefetch(offset, window int) {
+	pool := s.client.readAheadPool
+	filesize, _ := s.extents.Size()
+	end := offset + window
+	if end > filesize {
+		end = filesize
+	}
+
+	st := &s.readAhead
+	st.Lock()
+	if offset < st.prefetched {
+		offset = st.prefetched
+	}
+	if offset >= end {
+		st.Unlock()
+		return
+	}
+	st.prefetched = end
+	st.Unlock()
+
+	gen := atomic.LoadUint64(&s.readAheadGen)
+	for index := offset / readAheadBlockSize; index*readAheadBlockSize < end; index++ {
+		blk := pool.reserve(readAheadKey{inode: s.inode, index: index}, gen)
+		if blk == nil {
+			continue
+		}
+		go func(blk *readAheadBlock) {
+			pool.limit <- struct{}{}
+			size, err := s.fetchBlock(blk, filesize)
+			<-pool.limit
+			if err != nil {
+				log.LogDebugf("prefetch: ino(%v) block(%v) err(%v)", s.inode, blk.key.index, err)
+			}
+			pool.complete(blk, size, err)
+		}(blk)
+	}
+}
+
+func (s *Streamer) fetchBlock(blk *readAheadBlock, filesize int) (int, error) {
+	offset := blk.key.index * readAheadBlockSize
+	size := readAheadBlockSize
+	if offset+size > filesize {
+		size = filesize - offset
+	}
+
+	requests := s.extents.PrepareReadRequests(offset, size, blk.data[:size])
+	for _, req := range requests {
+		if req.ExtentKey == nil {
+			for i := range req.Data {
+				req.Data[i] = 0
+			}
+			continue
+		}
+		if req.ExtentKey.PartitionId == 0 || req.ExtentKey.ExtentId == 0 {
+			return 0, fmt.Errorf("extent of offset(%v) is not flushed", req.FileOffset)
+		}
+		reader, err := s.GetExtentReader(req.ExtentKey)
+		if err != nil {
+			return 0, err
+		}
+		readBytes, err := reader.Read(context.Background(), req)
+		if err != nil {
+			return 0, err
+		}
+		if readBytes != req.Size {
+			return 0, fmt.Errorf("read req(%v) readBytes(%v)", req, readBytes)
+		}
+	}
+	return size, nil
+}
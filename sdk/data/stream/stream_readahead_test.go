@@ -0,0 +1,204 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/cubefs/cubefs/util"
+)
+
+const testReadAheadInode = 1024
+
+func newTestReadAheadStreamer(t *testing.T, window int, capacity int64) *Streamer {
+	pool := NewReadAheadPool("test", capacity)
+	t.Cleanup(pool.Stop)
+	client := &ExtentClient{readAheadPool: pool, readAheadWindow: window}
+	return NewStreamer(client, testReadAheadInode)
+}
+
+// fillTestReadAheadBlock prefetches the block with every byte set to the block index.
+func fillTestReadAheadBlock(t *testing.T, s *Streamer, index int) {
+	pool := s.client.readAheadPool
+	blk := pool.reserve(readAheadKey{inode: s.inode, index: index}, s.readAheadGen)
+	if blk == nil {
+		t.Fatalf("reserve block(%v) failed", index)
+	}
+	copy(blk.data, bytes.Repeat([]byte{byte(index)}, readAheadBlockSize))
+	pool.complete(blk, readAheadBlockSize, nil)
+}
+
+func TestReadAheadSequentialGrowth(t *testing.T) {
+	s := newTestReadAheadStreamer(t, 8*util.MB, 0)
+	const size = 128 * util.KB
+	expect := []int{minReadAheadWindow, 2 * minReadAheadWindow, 8 * util.MB, 8 * util.MB}
+	for i, window := range expect {
+		if got := s.updateReadAhead(i*size, size); got != window {
+			t.Fatalf("read(%v) window(%v), expect %v", i, got, window)
+		}
+	}
+	// concurrent sequential reads may arrive a little out of order
+	if got := s.updateReadAhead(5*size, size); got != 8*util.MB {
+		t.Fatalf("reordered read window(%v), expect sequential", got)
+	}
+	if got := s.updateReadAhead(4*size, size); got != 8*util.MB {
+		t.Fatalf("reordered read window(%v), expect sequential", got)
+	}
+	if s.readAhead.nextOffset != 6*size {
+		t.Fatalf("next offset(%v), expect %v", s.readAhead.nextOffset, 6*size)
+	}
+}
+
+func TestReadAheadResetOnRandomRead(t *testing.T) {
+	s := newTestReadAheadStreamer(t, 8*util.MB, 0)
+	const size = 128 * util.KB
+	for i := 0; i < 3; i++ {
+		s.updateReadAhead(i*size, size)
+	}
+	s.readAhead.prefetched = 4 * util.MB
+
+	if got := s.updateReadAhead(100*util.MB, size); got != 0 {
+		t.Fatalf("random read window(%v), expect 0", got)
+	}
+	if s.readAhead.prefetched != 0 {
+		t.Fatalf("prefetched(%v) should be reset on random read", s.readAhead.prefetched)
+	}
+	// sequential read from the new position starts over with the minimum window
+	if got := s.updateReadAhead(100*util.MB+size, size); got != minReadAheadWindow {
+		t.Fatalf("window(%v) after random read, expect %v", got, minReadAheadWindow)
+	}
+}
+
+func TestReadFromReadAhead(t *testing.T) {
+	s := newTestReadAheadStreamer(t, 8*util.MB, 4*readAheadBlockSize)
+	s.extents.SetSize(2*readAheadBlockSize, false)
+	fillTestReadAheadBlock(t, s, 0)
+	fillTestReadAheadBlock(t, s, 1)
+
+	// the read across the block boundary is served by both blocks
+	data := make([]byte, 4096)
+	offset := readAheadBlockSize - 2048
+	if !s.readFromReadAhead(data, offset, len(data)) {
+		t.Fatal("read should hit the prefetched blocks")
+	}
+	expect := append(bytes.Repeat([]byte{0}, 2048), bytes.Repeat([]byte{1}, 2048)...)
+	if !bytes.Equal(data, expect) {
+		t.Fatal("read data does not match the prefetched blocks")
+	}
+	// beyond the file size
+	if s.readFromReadAhead(data, 2*readAheadBlockSize-2048, len(data)) {
+		t.Fatal("read beyond the file size should miss")
+	}
+	// the blocks of the old generation are dropped by a write
+	s.invalidateReadAhead()
+	if s.readFromReadAhead(data, 0, len(data)) {
+		t.Fatal("read should miss after the read-ahead is invalidated")
+	}
+	if rate := s.client.readAheadPool.HitRate(); fmt.Sprintf("%.2f", rate) != "0.33" {
+		t.Fatalf("hit rate(%v), expect 1 hit of 3 reads", rate)
+	}
+}
+
+func TestReadAheadWriteBackInterleave(t *testing.T) {
+	s := newTestReadAheadStreamer(t, 8*util.MB, 4*readAheadBlockSize)
+	s.client.writeBackMaxPages = 16
+	recorder := new(testWriteBackRecorder)
+	s.writeBack.writeFunc = recorder.write
+	s.extents.SetSize(readAheadBlockSize, false)
+
+	// the write is cached in the dirty pages, and a prefetch running meanwhile
+	// reads the old data of the extents in the generation after the write
+	data := bytes.Repeat([]byte{'a'}, 4096)
+	if n, err := s.write(data, 0, len(data), 0); err != nil || n != len(data) {
+		t.Fatalf("write: n(%v) err(%v)", n, err)
+	}
+	fillTestReadAheadBlock(t, s, 0)
+
+	// the read flushes the dirty pages first, and must not be served by the old block
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.writes) != 1 {
+		t.Fatalf("flushed %v, expect the cached write", len(recorder.writes))
+	}
+	if s.readFromReadAhead(make([]byte, len(data)), 0, len(data)) {
+		t.Fatal("read should miss the block prefetched before the dirty pages are flushed")
+	}
+
+	// a flush without dirty data keeps the blocks prefetched after the write
+	fillTestReadAheadBlock(t, s, 0)
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !s.readFromReadAhead(make([]byte, len(data)), 0, len(data)) {
+		t.Fatal("read should hit the block prefetched after the flush")
+	}
+}
+
+func TestReadAheadPoolEviction(t *testing.T) {
+	pool := NewReadAheadPool("test", 2*readAheadBlockSize)
+	defer pool.Stop()
+	keys := []readAheadKey{{inode: 1, index: 0}, {inode: 1, index: 1}, {inode: 1, index: 2}}
+
+	first := pool.reserve(keys[0], 0)
+	second := pool.reserve(keys[1], 0)
+	if first == nil || second == nil {
+		t.Fatal("reserve failed within the capacity")
+	}
+	// no block can be evicted while being prefetched
+	if pool.reserve(keys[2], 0) != nil {
+		t.Fatal("reserve should fail when all blocks are in use")
+	}
+	if pool.reserve(keys[0], 0) != nil {
+		t.Fatal("the block of the same generation should not be reserved twice")
+	}
+	pool.complete(first, readAheadBlockSize, nil)
+	pool.complete(second, readAheadBlockSize, nil)
+
+	// the least recently used block is evicted
+	if blk := pool.get(keys[0], 0); blk == nil {
+		t.Fatal("prefetched block should be found")
+	} else {
+		pool.put(blk)
+	}
+	third := pool.reserve(keys[2], 0)
+	if third == nil {
+		t.Fatal("reserve should evict the least recently used block")
+	}
+	pool.complete(third, readAheadBlockSize, nil)
+	if blk := pool.get(keys[1], 0); blk != nil {
+		t.Fatal("the least recently used block should be evicted")
+	}
+	if blk := pool.get(keys[0], 1); blk != nil {
+		t.Fatal("the block of other generation should not be found")
+	}
+	// the buffer of the evicted block is reused
+	if pool.used != 2*readAheadBlockSize || len(pool.buffers) != 0 {
+		t.Fatalf("used(%v) free buffers(%v), expect 2 blocks used and no free buffer", pool.used, len(pool.buffers))
+	}
+
+	// the failed block is removed once completed
+	pool.remove(pool.blocks[keys[0]])
+	failed := pool.reserve(keys[0], 1)
+	pool.complete(failed, 0, fmt.Errorf("read failed"))
+	if blk := pool.get(keys[0], 1); blk != nil {
+		t.Fatal("the failed block should not be found")
+	}
+	if _, ok := pool.blocks[keys[0]]; ok {
+		t.Fatal("the failed block should be removed")
+	}
+}
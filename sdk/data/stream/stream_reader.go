@@ -25,6 +25,7 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 // One inode corresponds to one streamer. All the requests to the same inode will be queued.
@@ -56,6 +57,13 @@ type Streamer struct {
 	writeLock            sync.Mutex
 	inflightL1cache      sync.Map
 	inflightEvictL1cache sync.Map
+
+	readAhead    readAheadState
+	readAheadGen uint64 // prefetched blocks of old generation are invalid
+
+	writeBack writeBackCache
+
+	writeTraceContext trace.SpanContext // the trace of the write request being handled
 }
 
 // NewStreamer returns a new streamer.
@@ -108,11 +116,13 @@ func (s *Streamer) GetExtentReader(ek *proto.ExtentKey) (*ExtentReader, error) {
 		retryRead = false
 	}
 
-	reader := NewExtentReader(s.inode, ek, partition, s.client.dataWrapper.FollowerRead(), retryRead)
+	followerRead, minAppliedID := s.client.readPolicy(partition)
+	reader := NewExtentReader(s.inode, ek, partition, followerRead, retryRead)
+	reader.minAppliedID = minAppliedID
 	return reader, nil
 }
 
-func (s *Streamer) read(data []byte, offset int, size int) (total int, err error) {
+func (s *Streamer) read(ctx context.Context, data []byte, offset int, size int) (total int, err error) {
 	//log.LogErrorf("==========> Streamer Read Enter, inode(%v).", s.inode)
 	//t1 := time.Now()
 	var (
@@ -122,9 +132,22 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 		revisedRequests []*ExtentRequest
 	)
 
-	ctx := context.Background()
 	s.client.readLimiter.Wait(ctx)
 	s.client.LimitManager.ReadAlloc(ctx, size)
+
+	if filesize, _ := s.extents.Size(); filesize > 0 && s.extents.Len() == 0 && s.client.inlineSupported() {
+		return s.readInline(data, offset, size)
+	}
+
+	if s.client.readAheadPool != nil {
+		if window := s.updateReadAhead(offset, size); window > 0 {
+			defer s.prefetch(offset+size, window)
+		}
+		if s.readFromReadAhead(data, offset, size) {
+			return size, nil
+		}
+	}
+
 	requests = s.extents.PrepareReadRequests(offset, size, data)
 	for _, req := range requests {
 		if req.ExtentKey == nil {
@@ -206,7 +229,7 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 				//read full extent
 				buf = make([]byte, req.ExtentKey.Size)
 				fullReq := NewExtentRequest(int(req.ExtentKey.FileOffset), int(req.ExtentKey.Size), buf, req.ExtentKey)
-				readBytes, err = reader.Read(fullReq)
+				readBytes, err = reader.Read(ctx, fullReq)
 				if err != nil || readBytes != len(buf) {
 					s.inflightL1cache.Delete(cacheKey)
 					log.LogErrorf("ERROR Stream read. read full extent error. fullReq(%v) readBytes(%v) err(%v)", fullReq, readBytes, err)
@@ -228,7 +251,7 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 
 				log.LogDebugf("TRACE Stream read. read full extent Exit. fullReq(%v) readBytes(%v) err(%v)", fullReq, readBytes, err)
 			} else {
-				readBytes, err = reader.Read(req)
+				readBytes, err = reader.Read(ctx, req)
 				if err != nil || readBytes != req.Size {
 					log.LogErrorf("ERROR Stream read. read error. req(%v) readBytes(%v) err(%v)", req, readBytes, err)
 					return
@@ -0,0 +1,221 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	writeBackPageSize        = util.BlockSize
+	writeBackMaxAge          = 5 * time.Second
+	defaultWriteBackMaxPages = 1024 // used when BuffersTotalLimit is unlimited
+	writeBackBufferShare     = 4    // write-back takes at most 1/4 of BuffersTotalLimit
+)
+
+// writeBackPage caches one writeBackPageSize aligned page of a file,
+// [lo, hi) is the dirty range which has not been written to datanodes yet.
+type writeBackPage struct {
+	data []byte
+	lo   int
+	hi   int
+}
+
+// writeBackCache holds the dirty pages of a streamer. It is only accessed
+// by the streamer server goroutine, so no lock is needed.
+type writeBackCache struct {
+	pages   map[int]*writeBackPage // keyed by page index
+	dirtyAt time.Time              // when the oldest dirty page was cached
+	err     error                  // background flush error, reported by the next write and flush
+
+	writeFunc func(data []byte, offset, size int) (int, error) // writes the dirty data out, writeThrough if nil
+}
+
+func writeBackMaxPages(buffersTotalLimit int64) int64 {
+	if buffersTotalLimit <= 0 {
+		return defaultWriteBackMaxPages
+	}
+	pages := buffersTotalLimit / writeBackBufferShare
+	if pages < 1 {
+		pages = 1
+	}
+	return pages
+}
+
+func (client *ExtentClient) writeBackEnabled() bool {
+	return client.writeBackMaxPages > 0
+}
+
+// reserveWriteBackPages reserves n pages from the client wide quota,
+// it fails if the quota is exhausted.
+func (client *ExtentClient) reserveWriteBackPages(n int) bool {
+	if n == 0 {
+		return true
+	}
+	if atomic.AddInt64(&client.writeBackPages, int64(n)) > client.writeBackMaxPages {
+		atomic.AddInt64(&client.writeBackPages, -int64(n))
+		return false
+	}
+	return true
+}
+
+// writeBackUnderPressure reports whether the cached pages exceed 3/4 of the quota.
+func (client *ExtentClient) writeBackUnderPressure() bool {
+	return atomic.LoadInt64(&client.writeBackPages)*4 > client.writeBackMaxPages*3
+}
+
+// writeBackThrough writes the dirty data of a page to datanodes.
+func (s *Streamer) writeBackThrough(data []byte, offset, size int) (int, error) {
+	if s.writeBack.writeFunc != nil {
+		return s.writeBack.writeFunc(data, offset, size)
+	}
+	return s.writeThrough(data, offset, size, 0)
+}
+
+func (client *ExtentClient) releaseWriteBackPage(page *writeBackPage) {
+	proto.Buffers.Put(page.data)
+	page.data = nil
+	atomic.AddInt64(&client.writeBackPages, -1)
+}
+
+// cacheWrite merges the write into the dirty pages of the streamer.
+// It returns false if there is no quota left, then the caller should
+// write the data through.
+func (s *Streamer) cacheWrite(data []byte, offset, size int) (cached bool, err error) {
+	cache := &s.writeBack
+	if cache.pages == nil {
+		cache.pages = make(map[int]*writeBackPage)
+	}
+
+	first, last := offset/writeBackPageSize, (offset+size-1)/writeBackPageSize
+	missing := 0
+	for index := first; index <= last; index++ {
+		page, ok := cache.pages[index]
+		if !ok {
+			missing++
+			continue
+		}
+		pageOffset := index * writeBackPageSize
+		lo := util.Max(offset, pageOffset) - pageOffset
+		hi := util.Min(offset+size, pageOffset+writeBackPageSize) - pageOffset
+		if hi < page.lo || lo > page.hi {
+			// the dirty range can not be merged, write the old one out first
+			if _, err = s.writeBackThrough(page.data[page.lo:page.hi], pageOffset+page.lo, page.hi-page.lo); err != nil {
+				return false, err
+			}
+			page.lo, page.hi = lo, lo
+		}
+	}
+	if !s.client.reserveWriteBackPages(missing) {
+		log.LogDebugf("Streamer cacheWrite: no quota, ino(%v) offset(%v) size(%v)", s.inode, offset, size)
+		return false, nil
+	}
+
+	for index := first; index <= last; index++ {
+		pageOffset := index * writeBackPageSize
+		lo := util.Max(offset, pageOffset) - pageOffset
+		hi := util.Min(offset+size, pageOffset+writeBackPageSize) - pageOffset
+		page, ok := cache.pages[index]
+		if !ok {
+			buf, _ := proto.Buffers.Get(writeBackPageSize)
+			page = &writeBackPage{data: buf, lo: lo, hi: hi}
+			cache.pages[index] = page
+		} else {
+			page.lo, page.hi = util.Min(page.lo, lo), util.Max(page.hi, hi)
+		}
+		copy(page.data[lo:hi], data[pageOffset+lo-offset:pageOffset+hi-offset])
+	}
+
+	if cache.dirtyAt.IsZero() {
+		cache.dirtyAt = time.Now()
+	}
+	if filesize, _ := s.extents.Size(); offset+size > filesize {
+		s.extents.SetSize(uint64(offset+size), false)
+	}
+	log.LogDebugf("Streamer cacheWrite: ino(%v) offset(%v) size(%v) pages(%v)", s.inode, offset, size, len(cache.pages))
+	return true, nil
+}
+
+// flushWriteBack writes all the dirty pages through in file offset order,
+// the pages are released no matter whether the write succeeds. A failure of
+// an earlier background flush is returned here so that fsync sees it.
+func (s *Streamer) flushWriteBack() (err error) {
+	cache := &s.writeBack
+	if len(cache.pages) == 0 {
+		err, cache.err = cache.err, nil
+		return
+	}
+	pages := cache.pages
+	cache.pages = nil
+	cache.dirtyAt = time.Time{}
+	// a block prefetched while the pages are dirty holds the old data
+	defer s.invalidateReadAhead()
+
+	indexes := make([]int, 0, len(pages))
+	for index := range pages {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		page := pages[index]
+		if err == nil && page.hi > page.lo {
+			_, err = s.writeBackThrough(page.data[page.lo:page.hi], index*writeBackPageSize+page.lo, page.hi-page.lo)
+			if err != nil {
+				log.LogErrorf("Streamer flushWriteBack: ino(%v) page(%v) lo(%v) hi(%v) err(%v)", s.inode, index, page.lo, page.hi, err)
+			}
+		}
+		s.client.releaseWriteBackPage(page)
+	}
+	if err == nil {
+		err = cache.err
+	}
+	cache.err = nil
+	log.LogDebugf("Streamer flushWriteBack: ino(%v) pages(%v) err(%v)", s.inode, len(indexes), err)
+	return
+}
+
+// dropWriteBack discards all the dirty pages.
+func (s *Streamer) dropWriteBack() {
+	cache := &s.writeBack
+	for _, page := range cache.pages {
+		s.client.releaseWriteBackPage(page)
+	}
+	cache.pages = nil
+	cache.dirtyAt = time.Time{}
+	cache.err = nil
+}
+
+// traverseWriteBack flushes the dirty pages if they are too old or the
+// client is short of write-back memory.
+func (s *Streamer) traverseWriteBack() {
+	cache := &s.writeBack
+	if len(cache.pages) == 0 {
+		return
+	}
+	if time.Since(cache.dirtyAt) < writeBackMaxAge && !s.client.writeBackUnderPressure() {
+		return
+	}
+	if err := s.flushWriteBack(); err != nil {
+		log.LogWarnf("Streamer traverseWriteBack: ino(%v) err(%v)", s.inode, err)
+		cache.err = err
+	}
+}
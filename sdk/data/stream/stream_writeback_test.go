@@ -0,0 +1,181 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stream
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const testWriteBackInode = 1024
+
+// testWriteBackRecorder records the dirty ranges written out by the write-back cache.
+type testWriteBackRecorder struct {
+	writes []string
+	err    error
+}
+
+func (r *testWriteBackRecorder) write(data []byte, offset, size int) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.writes = append(r.writes, fmt.Sprintf("%v:%s", offset, data[:size]))
+	return size, nil
+}
+
+func newTestWriteBackStreamer(maxPages int64) (*Streamer, *testWriteBackRecorder) {
+	client := &ExtentClient{writeBackMaxPages: maxPages}
+	s := NewStreamer(client, testWriteBackInode)
+	recorder := new(testWriteBackRecorder)
+	s.writeBack.writeFunc = recorder.write
+	return s, recorder
+}
+
+func writeTestWriteBack(t *testing.T, s *Streamer, offset int, data string) {
+	if n, err := s.write([]byte(data), offset, len(data), 0); err != nil || n != len(data) {
+		t.Fatalf("write offset(%v) size(%v): n(%v) err(%v)", offset, len(data), n, err)
+	}
+}
+
+func TestWriteBackFlushOrder(t *testing.T) {
+	s, recorder := newTestWriteBackStreamer(16)
+	writeTestWriteBack(t, s, 2*writeBackPageSize+2, "ccc")
+	writeTestWriteBack(t, s, 10, "aaaa")
+	writeTestWriteBack(t, s, 12, "bb")
+	writeTestWriteBack(t, s, 14, "dd")
+	// across the page boundary, merged with the dirty range of the next page
+	writeTestWriteBack(t, s, 2*writeBackPageSize-2, "eeee")
+	if len(recorder.writes) != 0 {
+		t.Fatalf("writes %v before flush, expect all cached", recorder.writes)
+	}
+	if filesize, _ := s.extents.Size(); filesize != 2*writeBackPageSize+5 {
+		t.Fatalf("file size(%v), expect the end of the cached writes", filesize)
+	}
+
+	// fsync writes the merged dirty ranges out in file offset order
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	expect := fmt.Sprint([]string{
+		"10:aabbdd",
+		fmt.Sprintf("%v:ee", 2*writeBackPageSize-2),
+		fmt.Sprintf("%v:eeccc", 2*writeBackPageSize),
+	})
+	if fmt.Sprint(recorder.writes) != expect {
+		t.Fatalf("flushed %v, expect %v", recorder.writes, expect)
+	}
+	if s.client.writeBackPages != 0 || s.writeBack.pages != nil {
+		t.Fatalf("pages(%v) quota(%v) should be released after flush", len(s.writeBack.pages), s.client.writeBackPages)
+	}
+}
+
+func TestWriteBackUnmergeableWrite(t *testing.T) {
+	s, recorder := newTestWriteBackStreamer(16)
+	writeTestWriteBack(t, s, 10, "aaaa")
+	// the dirty range of the page can not hold a hole, the old one is written out first
+	writeTestWriteBack(t, s, 100, "bbbb")
+	if expect := fmt.Sprint([]string{"10:aaaa"}); fmt.Sprint(recorder.writes) != expect {
+		t.Fatalf("written %v, expect %v", recorder.writes, expect)
+	}
+	if err := s.release(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := fmt.Sprint([]string{"10:aaaa", "100:bbbb"}); fmt.Sprint(recorder.writes) != expect {
+		t.Fatalf("written %v after close, expect %v", recorder.writes, expect)
+	}
+}
+
+func TestWriteBackQuota(t *testing.T) {
+	s, recorder := newTestWriteBackStreamer(2)
+	writeTestWriteBack(t, s, 0, "aa")
+	writeTestWriteBack(t, s, writeBackPageSize, "bb")
+	if cached, err := s.cacheWrite([]byte("cc"), 2*writeBackPageSize, 2); err != nil || cached {
+		t.Fatalf("cached(%v) err(%v), expect no quota left", cached, err)
+	}
+	if !s.client.writeBackUnderPressure() {
+		t.Fatal("write-back should be under pressure with the quota used up")
+	}
+	// the pressure makes the background traverse flush the pages
+	s.traverseWriteBack()
+	if len(recorder.writes) != 2 || s.client.writeBackPages != 0 {
+		t.Fatalf("written %v quota(%v), expect flushed under pressure", recorder.writes, s.client.writeBackPages)
+	}
+}
+
+func TestWriteBackFlushAge(t *testing.T) {
+	s, recorder := newTestWriteBackStreamer(16)
+	writeTestWriteBack(t, s, 0, "aa")
+	s.traverseWriteBack()
+	if len(recorder.writes) != 0 {
+		t.Fatalf("written %v, young pages should stay cached", recorder.writes)
+	}
+	s.writeBack.dirtyAt = time.Now().Add(-writeBackMaxAge)
+	s.traverseWriteBack()
+	if len(recorder.writes) != 1 {
+		t.Fatalf("written %v, old pages should be flushed", recorder.writes)
+	}
+}
+
+func TestWriteBackErrorPropagation(t *testing.T) {
+	s, recorder := newTestWriteBackStreamer(16)
+	writeTestWriteBack(t, s, 0, "aa")
+	writeTestWriteBack(t, s, writeBackPageSize, "bb")
+	s.writeBack.dirtyAt = time.Now().Add(-writeBackMaxAge)
+	recorder.err = syscall.EIO
+
+	// the background flush fails, the pages are dropped and the error is kept
+	s.traverseWriteBack()
+	if s.client.writeBackPages != 0 || len(s.writeBack.pages) != 0 {
+		t.Fatalf("pages(%v) quota(%v) should be released on failure", len(s.writeBack.pages), s.client.writeBackPages)
+	}
+	recorder.err = nil
+	for i := 0; i < 2; i++ {
+		if _, err := s.write([]byte("cc"), 0, 2, 0); err != syscall.EIO {
+			t.Fatalf("write after the failed flush: err(%v), expect %v", err, syscall.EIO)
+		}
+	}
+	// close reports the error once
+	if err := s.release(); err != syscall.EIO {
+		t.Fatalf("close after the failed flush: err(%v), expect %v", err, syscall.EIO)
+	}
+	writeTestWriteBack(t, s, 0, "cc")
+	if err := s.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := fmt.Sprint([]string{"0:cc"}); fmt.Sprint(recorder.writes) != expect {
+		t.Fatalf("written %v, expect %v", recorder.writes, expect)
+	}
+}
+
+func TestWriteBackFsyncError(t *testing.T) {
+	s, recorder := newTestWriteBackStreamer(16)
+	writeTestWriteBack(t, s, 0, "aa")
+	writeTestWriteBack(t, s, 2*writeBackPageSize, "bb")
+	recorder.err = syscall.EIO
+
+	if err := s.flush(); err != syscall.EIO {
+		t.Fatalf("fsync: err(%v), expect %v", err, syscall.EIO)
+	}
+	// the error is reported once, later pages are written normally
+	recorder.err = nil
+	if err := s.flush(); err != nil {
+		t.Fatalf("second fsync: err(%v)", err)
+	}
+	if s.client.writeBackPages != 0 {
+		t.Fatalf("quota(%v) should be released", s.client.writeBackPages)
+	}
+}
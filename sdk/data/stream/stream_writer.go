@@ -30,6 +30,7 @@ import (
 	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 const (
@@ -63,6 +64,8 @@ type WriteRequest struct {
 	writeBytes int
 	err        error
 	done       chan struct{}
+
+	traceContext trace.SpanContext
 }
 
 // FlushRequest defines a flush request.
@@ -101,7 +104,7 @@ func (s *Streamer) IssueOpenRequest() error {
 	return nil
 }
 
-func (s *Streamer) IssueWriteRequest(offset int, data []byte, flags int) (write int, err error) {
+func (s *Streamer) IssueWriteRequest(ctx context.Context, offset int, data []byte, flags int) (write int, err error) {
 	if atomic.LoadInt32(&s.status) >= StreamerError {
 		return 0, errors.New(fmt.Sprintf("IssueWriteRequest: stream writer in error status, ino(%v)", s.inode))
 	}
@@ -112,6 +115,7 @@ func (s *Streamer) IssueWriteRequest(offset int, data []byte, flags int) (write
 	request.fileOffset = offset
 	request.size = len(data)
 	request.flags = flags
+	request.traceContext = trace.SpanContextFromContext(ctx)
 	request.done = make(chan struct{}, 1)
 	s.request <- request
 	s.writeLock.Unlock()
@@ -255,7 +259,9 @@ func (s *Streamer) handleRequest(request interface{}) {
 		s.open()
 		request.done <- struct{}{}
 	case *WriteRequest:
+		s.writeTraceContext = request.traceContext
 		request.writeBytes, request.err = s.write(request.data, request.fileOffset, request.size, request.flags)
+		s.writeTraceContext = trace.SpanContext{}
 		request.done <- struct{}{}
 	case *TruncRequest:
 		request.err = s.truncate(request.size)
@@ -274,17 +280,60 @@ func (s *Streamer) handleRequest(request interface{}) {
 }
 
 func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err error) {
+	if flags&proto.FlagsAppend != 0 {
+		filesize, _ := s.extents.Size()
+		offset = filesize
+	}
+	// a block prefetched while the data is being written may hold the old data,
+	// so the blocks are dropped again once the data is written through
+	s.invalidateReadAhead()
+	defer s.invalidateReadAhead()
+
+	if s.client.writeBackEnabled() {
+		// a failed background flush is reported by every write until it is
+		// reported by a flush or close
+		if err = s.writeBack.err; err != nil {
+			return
+		}
+		// sync, cache and no-inline writes always go to datanodes directly
+		if flags&(proto.FlagsSyncWrite|proto.FlagsCache|proto.FlagsNoInline) == 0 && size > 0 {
+			var cached bool
+			if cached, err = s.cacheWrite(data, offset, size); err != nil || cached {
+				if cached {
+					total = size
+				}
+				return
+			}
+		}
+		if err = s.flushWriteBack(); err != nil {
+			return
+		}
+	}
+	return s.writeThrough(data, offset, size, flags)
+}
+
+func (s *Streamer) writeThrough(data []byte, offset, size, flags int) (total int, err error) {
+	if size > 0 {
+		// called even if the data is not allowed inline, to move the inline data
+		// of the file to extents first
+		var inlined bool
+		if inlined, err = s.writeInline(data, offset, size, flags&(proto.FlagsCache|proto.FlagsNoInline) == 0); err != nil || inlined {
+			if inlined {
+				total = size
+			}
+			return
+		}
+	}
+	return s.writeExtents(data, offset, size, flags)
+}
+
+func (s *Streamer) writeExtents(data []byte, offset, size, flags int) (total int, err error) {
 	var direct bool
 
 	if flags&proto.FlagsSyncWrite != 0 {
 		direct = true
 	}
 
-	if flags&proto.FlagsAppend != 0 {
-		filesize, _ := s.extents.Size()
-		offset = filesize
-	}
-
 	log.LogDebugf("Streamer write enter: ino(%v) offset(%v) size(%v)", s.inode, offset, size)
 
 	ctx := context.Background()
@@ -390,9 +439,13 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 		copy(reqPacket.Data[:packSize], req.Data[total:total+packSize])
 		reqPacket.Size = uint32(packSize)
 		reqPacket.CRC = crc32.ChecksumIEEE(reqPacket.Data[:packSize])
+		span := trace.StartSpan("client.stream."+reqPacket.GetOpMsg(), s.writeTraceContext)
+		span.SetTag("partition_id", reqPacket.PartitionID)
+		span.SetTag("req_id", reqPacket.ReqID)
+		reqPacket.TraceContext = span.Context()
 
 		replyPacket := new(Packet)
-		err = sc.Send(retry, reqPacket, func(conn *net.TCPConn) (error, bool) {
+		err = sc.Send(retry, reqPacket, func(conn net.Conn) (error, bool) {
 			e := replyPacket.ReadFromConn(conn, proto.ReadDeadlineTime)
 			if e != nil {
 				log.LogWarnf("Stream Writer doOverwrite: ino(%v) failed to read from connect, req(%v) err(%v)", s.inode, reqPacket, e)
@@ -416,16 +469,22 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 
 		if err != nil || replyPacket.ResultCode != proto.OpOk {
 			err = errors.New(fmt.Sprintf("doOverwrite: failed or reply NOK: err(%v) ino(%v) req(%v) replyPacket(%v)", err, s.inode, req, replyPacket))
+			span.Finish(err)
 			break
 		}
 
 		if !reqPacket.isValidWriteReply(replyPacket) || reqPacket.CRC != replyPacket.CRC {
 			err = errors.New(fmt.Sprintf("doOverwrite: is not the corresponding reply, ino(%v) req(%v) replyPacket(%v)", s.inode, req, replyPacket))
+			span.Finish(err)
 			break
 		}
+		span.Finish(nil)
 
 		total += packSize
 	}
+	if total > 0 {
+		s.client.forgetLeaderAppliedID(dp.PartitionID)
+	}
 	return
 }
 
@@ -536,6 +595,13 @@ func (s *Streamer) doWrite(data []byte, offset, size int, direct bool) (total in
 // - Goi tat ca dirtyExtentHandler.flush()
 // - Remove dirtyExtentHandler neu flush thanh cong
 func (s *Streamer) flush() (err error) {
+	if err = s.flushWriteBack(); err != nil {
+		return
+	}
+	if s.dirtylist.Len() > 0 {
+		// drop the blocks prefetched before the dirty data reaches the datanodes
+		defer s.invalidateReadAhead()
+	}
 	for {
 		element := s.dirtylist.Get()
 		if element == nil {
@@ -565,6 +631,7 @@ func (s *Streamer) flush() (err error) {
 
 func (s *Streamer) traverse() (err error) {
 	s.traversed++
+	s.traverseWriteBack()
 	length := s.dirtylist.Len()
 	for i := 0; i < length; i++ {
 		element := s.dirtylist.Get()
@@ -642,8 +709,11 @@ func (s *Streamer) open() {
 
 func (s *Streamer) release() error {
 	s.refcnt--
+	err := s.flushWriteBack()
 	s.closeOpenHandler()
-	err := s.flush()
+	if flushErr := s.flush(); err == nil {
+		err = flushErr
+	}
 	if err != nil {
 		s.abort()
 	}
@@ -665,6 +735,7 @@ func (s *Streamer) evict() error {
 }
 
 func (s *Streamer) abort() {
+	s.dropWriteBack()
 	for {
 		element := s.dirtylist.Get()
 		if element == nil {
@@ -678,6 +749,10 @@ func (s *Streamer) abort() {
 }
 
 func (s *Streamer) truncate(size int) error {
+	s.invalidateReadAhead()
+	if err := s.flushWriteBack(); err != nil {
+		return err
+	}
 	s.closeOpenHandler()
 	err := s.flush()
 	if err != nil {
@@ -46,6 +46,9 @@ type DataPartitionMetrics struct {
 	SumWriteLatencyNano int64
 	ReadOpNum           int64
 	WriteOpNum          int64
+	WriteErrNum         int64
+	LastWriteOpNum      int64 // write ops of the last refresh period
+	LastWriteErrNum     int64 // write errors of the last refresh period
 }
 
 func (dp *DataPartition) RecordWrite(startT int64) {
@@ -64,6 +67,13 @@ func (dp *DataPartition) RecordWrite(startT int64) {
 	return
 }
 
+func (dp *DataPartition) RecordWriteError() {
+	dp.Metrics.Lock()
+	defer dp.Metrics.Unlock()
+
+	dp.Metrics.WriteErrNum++
+}
+
 func (dp *DataPartition) MetricsRefresh() {
 	dp.Metrics.Lock()
 	defer dp.Metrics.Unlock()
@@ -80,10 +90,14 @@ func (dp *DataPartition) MetricsRefresh() {
 		dp.Metrics.AvgWriteLatencyNano = 0
 	}
 
+	dp.Metrics.LastWriteOpNum = dp.Metrics.WriteOpNum
+	dp.Metrics.LastWriteErrNum = dp.Metrics.WriteErrNum
+
 	dp.Metrics.SumReadLatencyNano = 0
 	dp.Metrics.SumWriteLatencyNano = 0
 	dp.Metrics.ReadOpNum = 0
 	dp.Metrics.WriteOpNum = 0
+	dp.Metrics.WriteErrNum = 0
 }
 
 func (dp *DataPartition) GetAvgRead() int64 {
@@ -100,6 +114,14 @@ func (dp *DataPartition) GetAvgWrite() int64 {
 	return dp.Metrics.AvgWriteLatencyNano
 }
 
+// GetLastWrite returns the write ops and errors of the last refresh period.
+func (dp *DataPartition) GetLastWrite() (ops, errs int64) {
+	dp.Metrics.RLock()
+	defer dp.Metrics.RUnlock()
+
+	return dp.Metrics.LastWriteOpNum, dp.Metrics.LastWriteErrNum
+}
+
 type DataPartitionSorter []*DataPartition
 
 func (ds DataPartitionSorter) Len() int {
@@ -0,0 +1,300 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wrapper
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	LatencyAwareSelectorName = "latency"
+
+	latencyAwareMinWeight       = 1e-6
+	latencyAwareBadErrRatio     = 0.5  // partitions beyond this error ratio are treated as failing
+	latencyAwareMinSpaceRatio   = 0.05 // partitions with less free space are hardly selected
+	latencyAwareMinFailingShare = 2    // a host is failing if it is shared by this many failing partitions
+)
+
+func init() {
+	_ = RegisterDataPartitionSelector(LatencyAwareSelectorName, newLatencyAwareSelector)
+}
+
+// latencyAwareWeights are the exponents applied to each factor of the score,
+// a zero weight disables the factor.
+type latencyAwareWeights struct {
+	latency float64
+	errors  float64
+	load    float64
+	space   float64
+}
+
+// parseLatencyAwareWeights parses the selector param "latency,error,load,space",
+// e.g. "1,1,1,1". A single value applies to all the factors.
+func parseLatencyAwareWeights(param string) (weights latencyAwareWeights, err error) {
+	fields := strings.Split(param, ",")
+	if len(fields) == 1 {
+		fields = []string{fields[0], fields[0], fields[0], fields[0]}
+	}
+	if len(fields) != 4 {
+		return weights, fmt.Errorf("invalid param[%v], expect latency,error,load,space", param)
+	}
+	values := make([]float64, 4)
+	for i, field := range fields {
+		if values[i], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+			return weights, fmt.Errorf("invalid param[%v]: %v", param, err)
+		}
+		if values[i] < 0 || math.IsNaN(values[i]) || math.IsInf(values[i], 0) {
+			return weights, fmt.Errorf("invalid param[%v], weight must be non-negative", param)
+		}
+	}
+	weights = latencyAwareWeights{latency: values[0], errors: values[1], load: values[2], space: values[3]}
+	return
+}
+
+func newLatencyAwareSelector(selectorParam string) (selector DataPartitionSelector, e error) {
+	weights, err := parseLatencyAwareWeights(selectorParam)
+	if err != nil {
+		return nil, fmt.Errorf("LatencyAwareSelector: %v", err)
+	}
+	selector = &LatencyAwareSelector{
+		weights:    weights,
+		partitions: make([]*DataPartition, 0),
+	}
+	log.LogInfof("LatencyAwareSelector: init selector success, weights(%+v)", weights)
+	return
+}
+
+// LatencyAwareSelector picks data partitions randomly weighted by the recent
+// write latency, write error ratio, load of the leader host and free space.
+// Partitions having a host that looks failing are only used as a last resort.
+type LatencyAwareSelector struct {
+	sync.RWMutex
+	weights    latencyAwareWeights
+	partitions []*DataPartition // healthy partitions
+	cumWeights []float64        // cumulative weights of healthy partitions
+	failing    []*DataPartition // partitions with failing hosts
+	source     []*DataPartition // partitions of the last refresh
+}
+
+func (s *LatencyAwareSelector) Name() string {
+	return LatencyAwareSelectorName
+}
+
+type partitionStat struct {
+	latency int64
+	ops     int64
+	errs    int64
+}
+
+func (stat partitionStat) errRatio() float64 {
+	if stat.ops+stat.errs == 0 {
+		return 0
+	}
+	return float64(stat.errs) / float64(stat.ops+stat.errs)
+}
+
+func (s *LatencyAwareSelector) Refresh(partitions []*DataPartition) (err error) {
+	stats := make([]partitionStat, len(partitions))
+	hostOps := make(map[string]int64)
+	hostPartitions := make(map[string]int)
+	hostFailing := make(map[string]int)
+	latencies := make([]int64, 0, len(partitions))
+	var totalOps int64
+
+	for i, dp := range partitions {
+		ops, errs := dp.GetLastWrite()
+		stats[i] = partitionStat{latency: dp.GetAvgWrite(), ops: ops, errs: errs}
+		if stats[i].latency > 0 {
+			latencies = append(latencies, stats[i].latency)
+		}
+		if len(dp.Hosts) > 0 {
+			hostOps[dp.Hosts[0]] += ops
+			totalOps += ops
+		}
+		bad := stats[i].errRatio() >= latencyAwareBadErrRatio
+		for _, host := range dp.Hosts {
+			hostPartitions[host]++
+			if bad {
+				hostFailing[host]++
+			}
+		}
+	}
+
+	var refLatency float64
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		refLatency = float64(latencies[len(latencies)/2])
+	}
+	var avgHostOps float64
+	if len(hostOps) > 0 {
+		avgHostOps = float64(totalOps) / float64(len(hostOps))
+	}
+
+	var hostsStatus map[string]bool
+	if len(partitions) > 0 && partitions[0].ClientWrapper != nil {
+		hostsStatus = partitions[0].ClientWrapper.HostsStatus
+	}
+	isFailingHost := func(host string) bool {
+		if active, ok := hostsStatus[host]; ok && !active {
+			return true
+		}
+		// errors of a single partition may be caused by any of its replicas,
+		// but a host shared by most of the failing partitions is the culprit
+		failing := hostFailing[host]
+		return failing >= latencyAwareMinFailingShare && failing*2 > hostPartitions[host]
+	}
+
+	healthy := make([]*DataPartition, 0, len(partitions))
+	cumWeights := make([]float64, 0, len(partitions))
+	failing := make([]*DataPartition, 0)
+	var sum float64
+	for i, dp := range partitions {
+		var hasFailingHost bool
+		for _, host := range dp.Hosts {
+			if isFailingHost(host) {
+				hasFailingHost = true
+				break
+			}
+		}
+		if hasFailingHost {
+			failing = append(failing, dp)
+			continue
+		}
+		var leaderOps int64
+		if len(dp.Hosts) > 0 {
+			leaderOps = hostOps[dp.Hosts[0]]
+		}
+		sum += s.score(dp, stats[i], refLatency, leaderOps, avgHostOps)
+		healthy = append(healthy, dp)
+		cumWeights = append(cumWeights, sum)
+	}
+
+	log.LogInfof("LatencyAwareSelector: refresh healthy(%v) failing(%v) refLatency(%v) avgHostOps(%v)",
+		len(healthy), len(failing), refLatency, avgHostOps)
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.partitions = healthy
+	s.cumWeights = cumWeights
+	s.failing = failing
+	s.source = partitions
+	return
+}
+
+// score returns the product of the factors, each one is in (0, 1].
+func (s *LatencyAwareSelector) score(dp *DataPartition, stat partitionStat, refLatency float64, leaderOps int64,
+	avgHostOps float64) float64 {
+	latencyFactor := 1.0
+	if refLatency > 0 && stat.latency > 0 {
+		// the median partition gets 0.5
+		latencyFactor = refLatency / (refLatency + float64(stat.latency))
+	} else if refLatency > 0 {
+		// no write recently, treat it as the median one
+		latencyFactor = 0.5
+	}
+
+	errFactor := 1 - stat.errRatio()
+
+	loadFactor := 1.0
+	if avgHostOps > 0 {
+		loadFactor = avgHostOps / (avgHostOps + float64(leaderOps))
+	}
+
+	spaceFactor := 1.0
+	if dp.Total > 0 {
+		var free float64
+		if dp.Used < dp.Total {
+			free = float64(dp.Total-dp.Used) / float64(dp.Total)
+		}
+		if free < latencyAwareMinSpaceRatio {
+			free = latencyAwareMinWeight
+		}
+		spaceFactor = free
+	}
+
+	score := math.Pow(latencyFactor, s.weights.latency) * math.Pow(errFactor, s.weights.errors) *
+		math.Pow(loadFactor, s.weights.load) * math.Pow(spaceFactor, s.weights.space)
+	if score < latencyAwareMinWeight {
+		score = latencyAwareMinWeight
+	}
+	return score
+}
+
+func (s *LatencyAwareSelector) Select(exclude map[string]struct{}) (dp *DataPartition, err error) {
+	s.RLock()
+	partitions := s.partitions
+	cumWeights := s.cumWeights
+	failing := s.failing
+	s.RUnlock()
+
+	if length := len(partitions); length > 0 {
+		index := sort.SearchFloat64s(cumWeights, rand.Float64()*cumWeights[length-1])
+		if index >= length {
+			index = length - 1
+		}
+		for i := 0; i < length; i++ {
+			dp = partitions[(index+i)%length]
+			if !isExcluded(dp, exclude) {
+				log.LogDebugf("LatencyAwareSelector: select dp[%v], index %v", dp, (index+i)%length)
+				return dp, nil
+			}
+		}
+	}
+
+	if length := len(failing); length > 0 {
+		log.LogWarnf("LatencyAwareSelector: all healthy partitions were excluded, get partition from failing")
+		index := rand.Intn(length)
+		for i := 0; i < length; i++ {
+			dp = failing[(index+i)%length]
+			if !isExcluded(dp, exclude) {
+				return dp, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no writable data partition")
+}
+
+func (s *LatencyAwareSelector) RemoveDP(partitionID uint64) {
+	s.RLock()
+	partitions := s.source
+	s.RUnlock()
+
+	var i int
+	for i = 0; i < len(partitions); i++ {
+		if partitions[i].PartitionID == partitionID {
+			break
+		}
+	}
+	if i >= len(partitions) {
+		return
+	}
+	newRwPartition := make([]*DataPartition, 0)
+	newRwPartition = append(newRwPartition, partitions[:i]...)
+	newRwPartition = append(newRwPartition, partitions[i+1:]...)
+
+	s.Refresh(newRwPartition)
+
+	return
+}
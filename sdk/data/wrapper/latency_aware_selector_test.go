@@ -0,0 +1,107 @@
+// Copyright 2023 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wrapper
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTestPartition(id uint64, latency, ops, errs int64, hosts ...string) *DataPartition {
+	dp := new(DataPartition)
+	dp.PartitionID = id
+	dp.Hosts = hosts
+	dp.Metrics = NewDataPartitionMetrics()
+	dp.Metrics.AvgWriteLatencyNano = latency
+	dp.Metrics.LastWriteOpNum = ops
+	dp.Metrics.LastWriteErrNum = errs
+	return dp
+}
+
+func TestLatencyAwareSelectorParam(t *testing.T) {
+	for _, param := range []string{"1", "1,2,0,1", " 0.5, 1, 1, 1 "} {
+		if _, err := newLatencyAwareSelector(param); err != nil {
+			t.Fatalf("param %q: %v", param, err)
+		}
+	}
+	for _, param := range []string{"", "a", "1,1", "1,1,1,-1", "1,1,1,NaN"} {
+		if _, err := newLatencyAwareSelector(param); err == nil {
+			t.Fatalf("param %q should be invalid", param)
+		}
+	}
+}
+
+func TestLatencyAwareSelectorPreferFaster(t *testing.T) {
+	selector, _ := newLatencyAwareSelector("1")
+	fast := newTestPartition(1, 1000, 100, 0, "a:1", "b:1", "c:1")
+	slow := newTestPartition(2, 100000, 100, 0, "d:1", "e:1", "f:1")
+	full := newTestPartition(3, 1000, 100, 0, "g:1", "h:1", "i:1")
+	full.Total, full.Used = 100, 99
+	if err := selector.Refresh([]*DataPartition{fast, slow, full}); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[uint64]int)
+	for i := 0; i < 10000; i++ {
+		dp, err := selector.Select(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[dp.PartitionID]++
+	}
+	fmt.Printf("select counts: %v\n", counts)
+	if counts[1] <= counts[2]*5 || counts[3] > 10 {
+		t.Fatalf("unexpected select counts %v", counts)
+	}
+
+	selector.RemoveDP(1)
+	for i := 0; i < 100; i++ {
+		if dp, _ := selector.Select(nil); dp.PartitionID == 1 {
+			t.Fatal("removed partition selected")
+		}
+	}
+}
+
+func TestLatencyAwareSelectorAvoidFailingHost(t *testing.T) {
+	selector, _ := newLatencyAwareSelector("1")
+	// partitions 1 and 2 fail and share host x, partition 3 shares x but is fine
+	partitions := []*DataPartition{
+		newTestPartition(1, 1000, 10, 90, "x:1", "a:1", "b:1"),
+		newTestPartition(2, 1000, 10, 90, "c:1", "x:1", "d:1"),
+		newTestPartition(3, 1000, 100, 0, "e:1", "f:1", "x:1"),
+		newTestPartition(4, 1000, 100, 0, "g:1", "h:1", "i:1"),
+	}
+	if err := selector.Refresh(partitions); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 1000; i++ {
+		dp, err := selector.Select(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dp.PartitionID != 4 {
+			t.Fatalf("partition %v with failing host selected", dp.PartitionID)
+		}
+	}
+
+	// fall back to failing partitions when the healthy ones are excluded
+	dp, err := selector.Select(map[string]struct{}{"g:1": {}})
+	if err != nil || dp.PartitionID == 4 {
+		t.Fatalf("unexpected dp %v err %v", dp, err)
+	}
+	if _, err = selector.Select(map[string]struct{}{"g:1": {}, "x:1": {}}); err == nil {
+		t.Fatal("expect no writable data partition")
+	}
+}
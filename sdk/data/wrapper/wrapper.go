@@ -19,10 +19,12 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cubefs/cubefs/proto"
 	masterSDK "github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/iputil"
 	"github.com/cubefs/cubefs/util/log"
@@ -58,6 +60,7 @@ type Wrapper struct {
 	dpSelectorChanged     bool
 	dpSelectorName        string
 	dpSelectorParm        string
+	inlineSizeLimit       uint64
 	mc                    *masterSDK.MasterClient
 	stopOnce              sync.Once
 	stopC                 chan struct{}
@@ -66,6 +69,8 @@ type Wrapper struct {
 
 	HostsStatus map[string]bool
 	preload     bool
+
+	connPool *util.ConnectPool
 }
 
 // NewDataPartitionWrapper returns a new data partition wrapper.
@@ -120,6 +125,19 @@ func (w *Wrapper) FollowerRead() bool {
 	return w.followerRead
 }
 
+// VolName returns the name of the volume, or empty if the wrapper is not set.
+func (w *Wrapper) VolName() string {
+	if w == nil {
+		return ""
+	}
+	return w.volName
+}
+
+// InlineSizeLimit returns the size up to which files of the volume are stored inline in the inode.
+func (w *Wrapper) InlineSizeLimit() uint64 {
+	return atomic.LoadUint64(&w.inlineSizeLimit)
+}
+
 func (w *Wrapper) updateClusterInfo() (err error) {
 	var info *proto.ClusterInfo
 	if info, err = w.mc.AdminAPI().GetClusterInfo(); err != nil {
@@ -144,12 +162,13 @@ func (w *Wrapper) GetSimpleVolView() (err error) {
 	w.dpSelectorParm = view.DpSelectorParm
 	w.volType = view.VolType
 	w.EnablePosixAcl = view.EnablePosixAcl
+	atomic.StoreUint64(&w.inlineSizeLimit, view.InlineSizeLimit)
 
 	log.LogInfof("GetSimpleVolView: get volume simple info: ID(%v) name(%v) owner(%v) status(%v) capacity(%v) "+
 		"metaReplicas(%v) dataReplicas(%v) mpCnt(%v) dpCnt(%v) followerRead(%v) createTime(%v) dpSelectorName(%v) "+
-		"dpSelectorParm(%v)",
+		"dpSelectorParm(%v) inlineSizeLimit(%v)",
 		view.ID, view.Name, view.Owner, view.Status, view.Capacity, view.MpReplicaNum, view.DpReplicaNum, view.MpCnt,
-		view.DpCnt, view.FollowerRead, view.CreateTime, view.DpSelectorName, view.DpSelectorParm)
+		view.DpCnt, view.FollowerRead, view.CreateTime, view.DpSelectorName, view.DpSelectorParm, view.InlineSizeLimit)
 
 	return
 }
@@ -230,6 +249,12 @@ func (w *Wrapper) UpdateSimpleVolView() (err error) {
 		w.Unlock()
 	}
 
+	if old := w.InlineSizeLimit(); old != view.InlineSizeLimit {
+		log.LogDebugf("UpdateSimpleVolView: update inlineSizeLimit from old(%v) to new(%v)",
+			old, view.InlineSizeLimit)
+		atomic.StoreUint64(&w.inlineSizeLimit, view.InlineSizeLimit)
+	}
+
 	return nil
 }
 
@@ -442,6 +467,16 @@ func (w *Wrapper) NearRead() bool {
 	return w.nearRead
 }
 
+// SetConnectPool sets the pool of the connections to the datanodes used by this volume.
+func (w *Wrapper) SetConnectPool(pool *util.ConnectPool) {
+	w.connPool = pool
+}
+
+// ConnectPool returns the pool of the connections to the datanodes, nil if not set.
+func (w *Wrapper) ConnectPool() *util.ConnectPool {
+	return w.connPool
+}
+
 // Sort hosts by distance form local
 func (w *Wrapper) sortHostsByDistance(srcHosts []string) []string {
 	hosts := make([]string, len(srcHosts))
@@ -211,6 +211,20 @@ func (api *AdminAPI) DecommissionMetaPartition(metaPartitionID uint64, nodeAddr
 	return
 }
 
+// SplitMetaPartition splits the meta partition at start, or in the middle of the allocated inodes if start is 0.
+func (api *AdminAPI) SplitMetaPartition(volName string, metaPartitionID, start uint64) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.AdminSplitMetaPartition)
+	request.addParam("name", volName)
+	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
+	if start > 0 {
+		request.addParam("start", strconv.FormatUint(start, 10))
+	}
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
 func (api *AdminAPI) DeleteDataReplica(dataPartitionID uint64, nodeAddr string) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteDataReplica)
 	request.addParam("id", strconv.FormatUint(dataPartitionID, 10))
@@ -464,13 +478,14 @@ func (api *AdminAPI) SetMetaNodeThreshold(threshold float64) (err error) {
 	return
 }
 
-func (api *AdminAPI) SetClusterParas(batchCount, markDeleteRate, deleteWorkerSleepMs, autoRepairRate, loadFactor string) (err error) {
+func (api *AdminAPI) SetClusterParas(batchCount, markDeleteRate, deleteWorkerSleepMs, autoRepairRate, loadFactor, mpSplitMemMB string) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.AdminSetNodeInfo)
 	request.addParam("batchCount", batchCount)
 	request.addParam("markDeleteRate", markDeleteRate)
 	request.addParam("deleteWorkerSleepMs", deleteWorkerSleepMs)
 	request.addParam("autoRepairRate", autoRepairRate)
 	request.addParam("loadFactor", loadFactor)
+	request.addParam("mpSplitMemMB", mpSplitMemMB)
 
 	if _, err = api.mc.serveRequest(request); err != nil {
 		return
@@ -116,6 +116,34 @@ func (api *NodeAPI) DataNodeDecommission(nodeAddr string, count int) (err error)
 	return
 }
 
+func (api *NodeAPI) QueryDiskEvacuation() (view *proto.DiskEvacuationsView, err error) {
+	var buf []byte
+	var request = newAPIRequest(http.MethodGet, proto.QueryDiskEvacuation)
+	if buf, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	view = &proto.DiskEvacuationsView{}
+	if err = json.Unmarshal(buf, view); err != nil {
+		return
+	}
+	return
+}
+
+// HoldDiskEvacuation holds or releases the automatic evacuation of a bad disk,
+// all the disks on the node are affected if diskPath is empty.
+func (api *NodeAPI) HoldDiskEvacuation(nodeAddr, diskPath string, hold bool) (err error) {
+	var request = newAPIRequest(http.MethodGet, proto.HoldDiskEvacuation)
+	request.addParam("addr", nodeAddr)
+	if diskPath != "" {
+		request.addParam("disk", diskPath)
+	}
+	request.addParam("hold", strconv.FormatBool(hold))
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
 func (api *NodeAPI) MetaNodeDecommission(nodeAddr string, count int) (err error) {
 	var request = newAPIRequest(http.MethodGet, proto.DecommissionMetaNode)
 	request.addParam("addr", nodeAddr)
@@ -174,3 +174,356 @@ func (api *UserAPI) ListUsersOfVol(vol string) (users []string, err error) {
 	}
 	return
 }
+
+func (api *UserAPI) CreateSession(param *proto.UserSessionCreateParam) (session *proto.UserSession, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.UserCreateSession)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	session = &proto.UserSession{}
+	if err = json.Unmarshal(data, session); err != nil {
+		return
+	}
+	return
+}
+
+// RevokeSession revokes the session of the access key, or all the sessions of the user if accesskey is empty.
+func (api *UserAPI) RevokeSession(accesskey, userID string) (err error) {
+	var request = newAPIRequest(http.MethodPost, proto.UserRevokeSession)
+	if accesskey != "" {
+		request.addParam("ak", accesskey)
+	} else {
+		request.addParam("user", userID)
+	}
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) ListSessions(userID string) (sessions []*proto.UserSession, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.UserListSessions)
+	request.addParam("user", userID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	sessions = make([]*proto.UserSession, 0)
+	if err = json.Unmarshal(data, &sessions); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) AddKey(param *proto.UserKeyCreateParam) (key *proto.UserAccessKey, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.UserAddKey)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	key = &proto.UserAccessKey{}
+	if err = json.Unmarshal(data, key); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) DeleteKey(userID, accesskey string) (err error) {
+	var request = newAPIRequest(http.MethodPost, proto.UserDeleteKey)
+	request.addParam("user", userID)
+	request.addParam("ak", accesskey)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) SetKeyStatus(userID, accesskey, status string) (err error) {
+	var request = newAPIRequest(http.MethodPost, proto.UserSetKeyStatus)
+	request.addParam("user", userID)
+	request.addParam("ak", accesskey)
+	request.addParam("status", status)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) ListKeys(userID string) (keys []*proto.UserAccessKey, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.UserListKeys)
+	request.addParam("user", userID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	keys = make([]*proto.UserAccessKey, 0)
+	if err = json.Unmarshal(data, &keys); err != nil {
+		return
+	}
+	return
+}
+
+// ReportKeysUsed reports the last used time, in unix seconds, of the access keys.
+func (api *UserAPI) ReportKeysUsed(used map[string]int64) (err error) {
+	var request = newAPIRequest(http.MethodPost, proto.UserReportKeysUsed)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(used); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) CreateGroup(param *proto.GroupCreateParam) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupCreate)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) DeleteGroup(groupID string) (err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupDelete)
+	request.addParam("group", groupID)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) GetGroup(groupID string) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.GroupInfo)
+	request.addParam("group", groupID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) ListGroups() (groups []*proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.GroupList)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	groups = make([]*proto.UserGroup, 0)
+	if err = json.Unmarshal(data, &groups); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) AddGroupMember(groupID, userID string) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupAddMember)
+	request.addParam("group", groupID)
+	request.addParam("user", userID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) RemoveGroupMember(groupID, userID string) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupRemoveMember)
+	request.addParam("group", groupID)
+	request.addParam("user", userID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) UpdateGroupPolicy(param *proto.GroupPermUpdateParam) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupUpdatePolicy)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) RemoveGroupPolicy(param *proto.GroupPermRemoveParam) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupRemovePolicy)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) GrantGroupRole(groupID, roleID string) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupGrantRole)
+	request.addParam("group", groupID)
+	request.addParam("role", roleID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) RevokeGroupRole(groupID, roleID string) (group *proto.UserGroup, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.GroupRevokeRole)
+	request.addParam("group", groupID)
+	request.addParam("role", roleID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	group = &proto.UserGroup{}
+	if err = json.Unmarshal(data, group); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) CreateRole(param *proto.GroupCreateParam) (role *proto.UserRole, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.RoleCreate)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	role = &proto.UserRole{}
+	if err = json.Unmarshal(data, role); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) DeleteRole(roleID string) (err error) {
+	var request = newAPIRequest(http.MethodPost, proto.RoleDelete)
+	request.addParam("role", roleID)
+	if _, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) GetRole(roleID string) (role *proto.UserRole, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.RoleInfo)
+	request.addParam("role", roleID)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	role = &proto.UserRole{}
+	if err = json.Unmarshal(data, role); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) ListRoles() (roles []*proto.UserRole, err error) {
+	var request = newAPIRequest(http.MethodGet, proto.RoleList)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	roles = make([]*proto.UserRole, 0)
+	if err = json.Unmarshal(data, &roles); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) UpdateRolePolicy(param *proto.GroupPermUpdateParam) (role *proto.UserRole, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.RoleUpdatePolicy)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	role = &proto.UserRole{}
+	if err = json.Unmarshal(data, role); err != nil {
+		return
+	}
+	return
+}
+
+func (api *UserAPI) RemoveRolePolicy(param *proto.GroupPermRemoveParam) (role *proto.UserRole, err error) {
+	var request = newAPIRequest(http.MethodPost, proto.RoleRemovePolicy)
+	var reqBody []byte
+	if reqBody, err = json.Marshal(param); err != nil {
+		return
+	}
+	request.addBody(reqBody)
+	var data []byte
+	if data, err = api.mc.serveRequest(request); err != nil {
+		return
+	}
+	role = &proto.UserRole{}
+	if err = json.Unmarshal(data, role); err != nil {
+		return
+	}
+	return
+}
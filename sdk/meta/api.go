@@ -15,6 +15,7 @@
 package meta
 
 import (
+	"context"
 	"fmt"
 	syslog "log"
 	"sort"
@@ -93,6 +94,12 @@ func (mw *MetaWrapper) Statfs() (total, used, inodeCount uint64) {
 }
 
 func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
+	return mw.CreateContext(context.Background(), parentID, name, mode, uid, gid, target)
+}
+
+// CreateContext is Create_ll with the context, the meta requests are traced as the children of
+// the span in ctx.
+func (mw *MetaWrapper) CreateContext(ctx context.Context, parentID uint64, name string, mode, uid, gid uint32, target []byte) (*proto.InodeInfo, error) {
 	var (
 		status       int
 		err          error
@@ -135,7 +142,7 @@ func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid ui
 	for i := 0; i < length; i++ {
 		index := (int(epoch) + i) % length
 		mp = rwPartitions[index]
-		status, info, err = mw.icreate(mp, mode, uid, gid, target)
+		status, info, err = mw.icreate(ctx, mp, mode, uid, gid, target)
 		if err == nil && status == statusOK {
 			goto create_dentry
 		}
@@ -143,7 +150,7 @@ func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid ui
 	return nil, syscall.ENOMEM
 
 create_dentry:
-	status, err = mw.dcreate(parentMP, parentID, name, info.Inode, mode)
+	status, err = mw.dcreate(ctx, parentMP, parentID, name, info.Inode, mode)
 	if err != nil {
 		return nil, statusToErrno(status)
 	} else if status != statusOK {
@@ -166,13 +173,18 @@ create_dentry:
 }
 
 func (mw *MetaWrapper) Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error) {
+	return mw.LookupContext(context.Background(), parentID, name)
+}
+
+// LookupContext is Lookup_ll with the context.
+func (mw *MetaWrapper) LookupContext(ctx context.Context, parentID uint64, name string) (inode uint64, mode uint32, err error) {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
 		log.LogErrorf("Lookup_ll: No parent partition, parentID(%v) name(%v)", parentID, name)
 		return 0, 0, syscall.ENOENT
 	}
 
-	status, inode, mode, err := mw.lookup(parentMP, parentID, name)
+	status, inode, mode, err := mw.lookup(ctx, parentMP, parentID, name)
 	if err != nil || status != statusOK {
 		return 0, 0, statusToErrno(status)
 	}
@@ -180,19 +192,24 @@ func (mw *MetaWrapper) Lookup_ll(parentID uint64, name string) (inode uint64, mo
 }
 
 func (mw *MetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
+	return mw.InodeGetContext(context.Background(), inode)
+}
+
+// InodeGetContext is InodeGet_ll with the context.
+func (mw *MetaWrapper) InodeGetContext(ctx context.Context, inode uint64) (*proto.InodeInfo, error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
 		log.LogErrorf("InodeGet_ll: No such partition, ino(%v)", inode)
 		return nil, syscall.ENOENT
 	}
 
-	status, info, err := mw.iget(mp, inode)
+	status, info, err := mw.iget(ctx, mp, inode)
 	if err != nil || status != statusOK {
 		if status == statusNoent {
 			// For NOENT error, pull the latest mp and give it another try,
 			// in case the mp view is outdated.
 			mw.triggerAndWaitForceUpdate()
-			return mw.doInodeGet(inode)
+			return mw.doInodeGet(ctx, inode)
 		}
 		return nil, statusToErrno(status)
 	}
@@ -201,14 +218,14 @@ func (mw *MetaWrapper) InodeGet_ll(inode uint64) (*proto.InodeInfo, error) {
 }
 
 // Just like InodeGet but without retry
-func (mw *MetaWrapper) doInodeGet(inode uint64) (*proto.InodeInfo, error) {
+func (mw *MetaWrapper) doInodeGet(ctx context.Context, inode uint64) (*proto.InodeInfo, error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
 		log.LogErrorf("InodeGet_ll: No such partition, ino(%v)", inode)
 		return nil, syscall.ENOENT
 	}
 
-	status, info, err := mw.iget(mp, inode)
+	status, info, err := mw.iget(ctx, mp, inode)
 	if err != nil || status != statusOK {
 		return nil, statusToErrno(status)
 	}
@@ -350,7 +367,7 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 	}
 
 	if isDir {
-		status, inode, mode, err = mw.lookup(parentMP, parentID, name)
+		status, inode, mode, err = mw.lookup(context.Background(), parentMP, parentID, name)
 		if err != nil || status != statusOK {
 			return nil, statusToErrno(status)
 		}
@@ -362,7 +379,7 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 			log.LogErrorf("Delete_ll: No inode partition, parentID(%v) name(%v) ino(%v)", parentID, name, inode)
 			return nil, syscall.EAGAIN
 		}
-		status, info, err = mw.iget(mp, inode)
+		status, info, err = mw.iget(context.Background(), mp, inode)
 		if err != nil || status != statusOK {
 			return nil, statusToErrno(status)
 		}
@@ -417,7 +434,7 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	}
 
 	// look up for the src ino
-	status, inode, mode, err := mw.lookup(srcParentMP, srcParentID, srcName)
+	status, inode, mode, err := mw.lookup(context.Background(), srcParentMP, srcParentID, srcName)
 	if err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
@@ -432,7 +449,7 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	}
 
 	// create dentry in dst parent
-	status, err = mw.dcreate(dstParentMP, dstParentID, dstName, inode, mode)
+	status, err = mw.dcreate(context.Background(), dstParentMP, dstParentID, dstName, inode, mode)
 	if err != nil {
 		return syscall.EAGAIN
 	}
@@ -557,7 +574,7 @@ func (mw *MetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint6
 	}
 	var err error
 	var status int
-	if status, err = mw.dcreate(parentMP, parentID, name, inode, mode); err != nil || status != statusOK {
+	if status, err = mw.dcreate(context.Background(), parentMP, parentID, name, inode, mode); err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
 	return nil
@@ -642,6 +659,21 @@ func (mw *MetaWrapper) AppendObjExtentKeys(inode uint64, eks []proto.ObjExtentKe
 	return nil
 }
 
+// ReplaceObjExtentKey swaps oldKey of the inode for newKey covering the same file range.
+func (mw *MetaWrapper) ReplaceObjExtentKey(inode uint64, oldKey, newKey proto.ObjExtentKey) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		return syscall.ENOENT
+	}
+
+	status, err := mw.replaceObjExtentKey(mp, inode, oldKey, newKey)
+	if err != nil || status != statusOK {
+		log.LogErrorf("ReplaceObjExtentKey: inode(%v) old(%v) new(%v) err(%v) status(%v)", inode, oldKey, newKey, err, status)
+		return statusToErrno(status)
+	}
+	return nil
+}
+
 func (mw *MetaWrapper) GetExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, err error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -657,6 +689,39 @@ func (mw *MetaWrapper) GetExtents(inode uint64) (gen uint64, size uint64, extent
 	return gen, size, extents, nil
 }
 
+// InlineWrite writes data inline into the inode of a small file.
+// It returns syscall.EFBIG if the file can not be kept inline anymore.
+func (mw *MetaWrapper) InlineWrite(inode, offset uint64, data []byte) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		return syscall.ENOENT
+	}
+
+	status, err := mw.inlineWrite(mp, inode, offset, data)
+	if err != nil || status != statusOK {
+		log.LogDebugf("InlineWrite: ino(%v) offset(%v) size(%v) err(%v) status(%v)", inode, offset, len(data), err, status)
+		return statusToErrno(status)
+	}
+	log.LogDebugf("InlineWrite: ino(%v) offset(%v) size(%v)", inode, offset, len(data))
+	return nil
+}
+
+// InlineRead returns the inline data of the inode, which is empty if the file is not inline.
+func (mw *MetaWrapper) InlineRead(inode uint64) (gen uint64, size uint64, data []byte, err error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		return 0, 0, nil, syscall.ENOENT
+	}
+
+	status, gen, size, data, err := mw.inlineRead(mp, inode)
+	if err != nil || status != statusOK {
+		log.LogErrorf("InlineRead: ino(%v) err(%v) status(%v)", inode, err, status)
+		return 0, 0, nil, statusToErrno(status)
+	}
+	log.LogDebugf("InlineRead: ino(%v) gen(%v) size(%v) inline(%v)", inode, gen, size, len(data))
+	return gen, size, data, nil
+}
+
 func (mw *MetaWrapper) GetObjExtents(inode uint64) (gen uint64, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -722,7 +787,7 @@ func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64) (*proto.In
 	}
 
 	// create new dentry and refer to the inode
-	status, err = mw.dcreate(parentMP, parentID, name, ino, info.Mode)
+	status, err = mw.dcreate(context.Background(), parentMP, parentID, name, ino, info.Mode)
 	if err != nil {
 		return nil, statusToErrno(status)
 	} else if status != statusOK {
@@ -780,7 +845,7 @@ func (mw *MetaWrapper) InodeCreate_ll(mode, uid, gid uint32, target []byte) (*pr
 	for i := 0; i < length; i++ {
 		index := (int(epoch) + i) % length
 		mp = rwPartitions[index]
-		status, info, err = mw.icreate(mp, mode, uid, gid, target)
+		status, info, err = mw.icreate(context.Background(), mp, mode, uid, gid, target)
 		if err == nil && status == statusOK {
 			return info, nil
 		}
@@ -23,7 +23,9 @@ import (
 	"github.com/cubefs/cubefs/util/errors"
 
 	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 const (
@@ -32,7 +34,7 @@ const (
 )
 
 type MetaConn struct {
-	conn *net.TCPConn
+	conn net.Conn
 	id   uint64 //PartitionID
 	addr string //MetaNode addr
 }
@@ -76,6 +78,11 @@ func (mw *MetaWrapper) sendToMetaPartition(mp *MetaPartition, req *proto.Packet)
 
 	errs := make(map[int]error, len(mp.Members))
 	var j int
+	span := trace.StartSpan("client.meta."+req.GetOpMsg(), req.TraceContext)
+	span.SetTag("partition_id", mp.PartitionID)
+	span.SetTag("req_id", req.ReqID)
+	opMetric := exporter.NewOpTP(req.GetOpMsg(), mw.volname, span.TraceID())
+	req.TraceContext = span.Context()
 
 	addr = mp.LeaderAddr
 	if addr == "" {
@@ -128,7 +135,17 @@ retry:
 
 out:
 	if err != nil || resp == nil {
-		return nil, errors.New(fmt.Sprintf("sendToMetaPartition failed: req(%v) mp(%v) errs(%v) resp(%v)", req, mp, errs, resp))
+		err = errors.New(fmt.Sprintf("sendToMetaPartition failed: req(%v) mp(%v) errs(%v) resp(%v)", req, mp, errs, resp))
+		opMetric.Set(err)
+		span.Finish(err)
+		return nil, err
+	}
+	opMetric.Set(nil)
+	span.Finish(nil)
+	if resp.ResultCode == proto.OpInodeOutOfRangeErr {
+		// The partition has been split since the view was fetched, pull the latest view for the retry.
+		log.LogWarnf("sendToMetaPartition: mp view outdated, req(%v) mp(%v) resp(%v)", req, mp, resp.GetResultMsg())
+		mw.triggerAndWaitForceUpdate()
 	}
 	log.LogDebugf("sendToMetaPartition: succeed! req(%v) mc(%v) resp(%v)", req, mc, resp)
 	return resp, nil
@@ -30,6 +30,7 @@ import (
 	"github.com/cubefs/cubefs/util/btree"
 	"github.com/cubefs/cubefs/util/errors"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/packetauth"
 )
 
 const (
@@ -48,6 +49,7 @@ const (
 	statusInval
 	statusNotPerm
 	statusConflictExtents
+	statusInlineFull
 )
 
 const (
@@ -79,6 +81,10 @@ type MetaConfig struct {
 	OnAsyncTaskError AsyncTaskErrorFunc
 	EnableSummary    bool
 	MetaSendTimeout  int64
+
+	// authenticates the connections to the metanodes if set, created from TicketMess
+	// if not set while Authenticate is enabled
+	Credential *packetauth.Credential
 }
 
 type MetaWrapper struct {
@@ -168,7 +174,11 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.mc = masterSDK.NewMasterClient(config.Masters, false)
 	mw.onAsyncTaskError = config.OnAsyncTaskError
 	mw.metaSendTimeout = config.MetaSendTimeout
-	mw.conns = util.NewConnectPool()
+	credential := config.Credential
+	if credential == nil && config.Authenticate {
+		credential = packetauth.NewCredential(config.Owner, config.TicketMess)
+	}
+	mw.conns = util.NewConnectPoolWithHandshake(credential.Handshake(proto.MetaServiceID))
 	mw.partitions = make(map[uint64]*MetaPartition)
 	mw.ranges = btree.New(32)
 	mw.rwPartitions = make([]*MetaPartition, 0)
@@ -265,7 +275,7 @@ func parseStatus(result uint8) (status int) {
 		status = statusNoent
 	case proto.OpInodeFullErr:
 		status = statusFull
-	case proto.OpAgain:
+	case proto.OpAgain, proto.OpInodeOutOfRangeErr:
 		status = statusAgain
 	case proto.OpArgMismatchErr:
 		status = statusInval
@@ -273,6 +283,8 @@ func parseStatus(result uint8) (status int) {
 		status = statusNotPerm
 	case proto.OpConflictExtentsErr:
 		status = statusConflictExtents
+	case proto.OpInlineFullErr:
+		status = statusInlineFull
 	default:
 		status = statusError
 	}
@@ -300,6 +312,8 @@ func statusToErrno(status int) error {
 		return syscall.EAGAIN
 	case statusConflictExtents:
 		return syscall.ENOTSUP
+	case statusInlineFull:
+		return syscall.EFBIG
 	default:
 	}
 	return syscall.EIO
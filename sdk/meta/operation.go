@@ -15,6 +15,7 @@
 package meta
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"sync"
@@ -25,13 +26,14 @@ import (
 	"github.com/cubefs/cubefs/proto"
 	"github.com/cubefs/cubefs/util/exporter"
 	"github.com/cubefs/cubefs/util/log"
+	"github.com/cubefs/cubefs/util/trace"
 )
 
 // API implementations
 //
 
 // gui request to Meta Partition de tao inode; op = proto.OpMetaCreateInode
-func (mw *MetaWrapper) icreate(mp *MetaPartition, mode, uid, gid uint32, target []byte) (status int, info *proto.InodeInfo, err error) {
+func (mw *MetaWrapper) icreate(ctx context.Context, mp *MetaPartition, mode, uid, gid uint32, target []byte) (status int, info *proto.InodeInfo, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("icreate", err, bgTime, 1)
@@ -47,6 +49,7 @@ func (mw *MetaWrapper) icreate(mp *MetaPartition, mode, uid, gid uint32, target
 	}
 
 	packet := proto.NewPacketReqID()
+	packet.TraceContext = trace.SpanContextFromContext(ctx)
 	packet.Opcode = proto.OpMetaCreateInode
 	packet.PartitionID = mp.PartitionID
 	err = packet.MarshalData(req)
@@ -224,7 +227,7 @@ func (mw *MetaWrapper) ievict(mp *MetaPartition, inode uint64) (status int, err
 	return statusOK, nil
 }
 
-func (mw *MetaWrapper) dcreate(mp *MetaPartition, parentID uint64, name string, inode uint64, mode uint32) (status int, err error) {
+func (mw *MetaWrapper) dcreate(ctx context.Context, mp *MetaPartition, parentID uint64, name string, inode uint64, mode uint32) (status int, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("dcreate", err, bgTime, 1)
@@ -244,6 +247,7 @@ func (mw *MetaWrapper) dcreate(mp *MetaPartition, parentID uint64, name string,
 	}
 
 	packet := proto.NewPacketReqID()
+	packet.TraceContext = trace.SpanContextFromContext(ctx)
 	packet.Opcode = proto.OpMetaCreateDentry
 	packet.PartitionID = mp.PartitionID
 	err = packet.MarshalData(req)
@@ -379,7 +383,7 @@ func (mw *MetaWrapper) ddelete(mp *MetaPartition, parentID uint64, name string)
 	return statusOK, resp.Inode, nil
 }
 
-func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (status int, inode uint64, mode uint32, err error) {
+func (mw *MetaWrapper) lookup(ctx context.Context, mp *MetaPartition, parentID uint64, name string) (status int, inode uint64, mode uint32, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("lookup", err, bgTime, 1)
@@ -392,6 +396,7 @@ func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (
 		Name:        name,
 	}
 	packet := proto.NewPacketReqID()
+	packet.TraceContext = trace.SpanContextFromContext(ctx)
 	packet.Opcode = proto.OpMetaLookup
 	packet.PartitionID = mp.PartitionID
 	err = packet.MarshalData(req)
@@ -440,7 +445,7 @@ func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (
 	return statusOK, resp.Inode, resp.Mode, nil
 }
 
-func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64) (status int, info *proto.InodeInfo, err error) {
+func (mw *MetaWrapper) iget(ctx context.Context, mp *MetaPartition, inode uint64) (status int, info *proto.InodeInfo, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
 		stat.EndStat("iget", err, bgTime, 1)
@@ -453,6 +458,7 @@ func (mw *MetaWrapper) iget(mp *MetaPartition, inode uint64) (status int, info *
 	}
 
 	packet := proto.NewPacketReqID()
+	packet.TraceContext = trace.SpanContextFromContext(ctx)
 	packet.Opcode = proto.OpMetaInodeGet
 	packet.PartitionID = mp.PartitionID
 	err = packet.MarshalData(req)
@@ -736,6 +742,98 @@ func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64) (status int,
 	return statusOK, resp.Generation, resp.Size, resp.Extents, nil
 }
 
+func (mw *MetaWrapper) inlineWrite(mp *MetaPartition, inode, offset uint64, data []byte) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("inlineWrite", err, bgTime, 1)
+	}()
+
+	req := &proto.InlineWriteRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Offset:      offset,
+		Data:        data,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaInlineWrite
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("inlineWrite: ino(%v) offset(%v) size(%v) err(%v)", inode, offset, len(data), err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("inlineWrite: packet(%v) mp(%v) ino(%v) err(%v)", packet, mp, inode, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		if status != statusInlineFull {
+			log.LogErrorf("inlineWrite: packet(%v) mp(%v) ino(%v) result(%v)", packet, mp, inode, packet.GetResultMsg())
+		}
+	}
+	return status, err
+}
+
+func (mw *MetaWrapper) inlineRead(mp *MetaPartition, inode uint64) (status int, gen, size uint64, data []byte, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("inlineRead", err, bgTime, 1)
+	}()
+
+	req := &proto.InlineReadRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaInlineRead
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("inlineRead: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("inlineRead: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("inlineRead: packet(%v) mp(%v) result(%v)", packet, mp, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.InlineReadResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("inlineRead: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	return statusOK, resp.Generation, resp.Size, resp.Data, nil
+}
+
 func (mw *MetaWrapper) getObjExtents(mp *MetaPartition, inode uint64) (status int, gen, size uint64, extents []proto.ExtentKey, objExtents []proto.ObjExtentKey, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
@@ -1302,6 +1400,50 @@ func (mw *MetaWrapper) appendObjExtentKeys(mp *MetaPartition, inode uint64, exte
 	return
 }
 
+func (mw *MetaWrapper) replaceObjExtentKey(mp *MetaPartition, inode uint64, oldKey, newKey proto.ObjExtentKey) (status int, err error) {
+	bgTime := stat.BeginStat()
+	defer func() {
+		stat.EndStat("replaceObjExtentKey", err, bgTime, 1)
+	}()
+
+	req := &proto.ReplaceObjExtentKeyRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		OldKey:      oldKey,
+		NewKey:      newKey,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaObjExtentReplace
+	packet.PartitionID = mp.PartitionID
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("replace obj extent: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer func() {
+		metric.SetWithLabels(err, map[string]string{exporter.Vol: mw.volname})
+	}()
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("replace obj extent: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		err = errors.New(packet.GetResultMsg())
+		log.LogErrorf("replace obj extent: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+	log.LogDebugf("replace obj extent: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
 func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, value []byte) (status int, err error) {
 	bgTime := stat.BeginStat()
 	defer func() {
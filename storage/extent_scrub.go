@@ -0,0 +1,103 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+)
+
+// blockCrc returns the block crc persisted in the extent header, zero means
+// the crc of the block is not computed yet.
+func (e *Extent) blockCrc(blockNo int) uint32 {
+	return binary.BigEndian.Uint32(e.header[blockNo*util.PerBlockCrcSize : (blockNo+1)*util.PerBlockCrcSize])
+}
+
+func (e *Extent) blockSize(blockNo int) (size int) {
+	offset := int64(blockNo) * util.BlockSize
+	if offset >= e.Size() {
+		return 0
+	}
+	return int(util.Min(util.BlockSize, int(e.Size()-offset)))
+}
+
+func (s *ExtentStore) normalExtent(extentID uint64) (e *Extent, err error) {
+	if !proto.IsNormalDp(s.partitionType) || IsTinyExtent(extentID) {
+		return nil, ParameterMismatchError
+	}
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	if ei == nil || ei.IsDeleted {
+		return nil, ExtentHasBeenDeletedError
+	}
+	return s.extentWithHeader(ei)
+}
+
+// ScrubBlock re-reads one block of a normal extent into buf and verifies it
+// against the block crc persisted on write. It returns the block size and the
+// expected crc, a zero crc means the block can not be verified yet.
+// BlockCrcMismatchError is returned if the data on disk is corrupt.
+func (s *ExtentStore) ScrubBlock(extentID uint64, blockNo int, buf []byte) (size int, crc uint32, err error) {
+	e, err := s.normalExtent(extentID)
+	if err != nil {
+		return
+	}
+	if size = e.blockSize(blockNo); size == 0 {
+		return 0, 0, ParameterMismatchError
+	}
+	if crc = e.blockCrc(blockNo); crc == 0 {
+		return
+	}
+	offset := int64(blockNo) * util.BlockSize
+	if _, err = e.file.ReadAt(buf[:size], offset); err != nil {
+		return
+	}
+	if crc32.ChecksumIEEE(buf[:size]) == crc {
+		return
+	}
+	// the block may be overwritten meanwhile, check again with the latest crc
+	e.Lock()
+	crc = e.blockCrc(blockNo)
+	size = e.blockSize(blockNo)
+	_, err = e.file.ReadAt(buf[:size], offset)
+	e.Unlock()
+	if err == nil && crc != 0 && crc32.ChecksumIEEE(buf[:size]) != crc {
+		err = BlockCrcMismatchError
+	}
+	return
+}
+
+// RepairBlock overwrites a corrupt block of a normal extent with data read
+// from a healthy replica, the data must match the persisted block crc.
+func (s *ExtentStore) RepairBlock(extentID uint64, blockNo int, data []byte) (err error) {
+	e, err := s.normalExtent(extentID)
+	if err != nil {
+		return
+	}
+	e.Lock()
+	defer e.Unlock()
+	crc := e.blockCrc(blockNo)
+	if crc == 0 || len(data) != e.blockSize(blockNo) || crc32.ChecksumIEEE(data) != crc {
+		return CrcMismatchError
+	}
+	if _, err = e.file.WriteAt(data, int64(blockNo)*util.BlockSize); err != nil {
+		return
+	}
+	return e.file.Sync()
+}
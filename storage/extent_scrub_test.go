@@ -0,0 +1,129 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"hash/crc32"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util"
+)
+
+const testScrubExtentID = MinExtentID + 1
+
+// newTestScrubStore returns a store with a normal extent of two full blocks,
+// which have the block crc persisted, and a partial block without crc.
+func newTestScrubStore(t *testing.T) (s *ExtentStore, blocks [][]byte) {
+	s, err := NewExtentStore(t.TempDir(), 1, 0, proto.PartitionTypeNormal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	if err = s.Create(testScrubExtentID); err != nil {
+		t.Fatal(err)
+	}
+	sizes := []int{util.BlockSize, util.BlockSize, 4096}
+	for i, size := range sizes {
+		data := bytes.Repeat([]byte{byte('a' + i)}, size)
+		offset := int64(i * util.BlockSize)
+		if err = s.Write(testScrubExtentID, offset, int64(size), data, crc32.ChecksumIEEE(data), AppendWriteType, true); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, data)
+	}
+	return
+}
+
+func corruptTestScrubBlock(t *testing.T, s *ExtentStore, blockNo int) {
+	name := path.Join(s.dataPath, strconv.Itoa(testScrubExtentID))
+	file, err := os.OpenFile(name, os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.WriteAt([]byte("corrupt"), int64(blockNo*util.BlockSize+100)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScrubBlock(t *testing.T) {
+	s, blocks := newTestScrubStore(t)
+	buf := make([]byte, util.BlockSize)
+
+	size, crc, err := s.ScrubBlock(testScrubExtentID, 0, buf)
+	if err != nil || size != util.BlockSize || crc != crc32.ChecksumIEEE(blocks[0]) {
+		t.Fatalf("healthy block: size(%v) crc(%v) err(%v)", size, crc, err)
+	}
+	// the partial block has no crc and can not be verified
+	if size, crc, err = s.ScrubBlock(testScrubExtentID, 2, buf); err != nil || size != len(blocks[2]) || crc != 0 {
+		t.Fatalf("partial block: size(%v) crc(%v) err(%v)", size, crc, err)
+	}
+	if _, _, err = s.ScrubBlock(testScrubExtentID, 3, buf); err != ParameterMismatchError {
+		t.Fatalf("block beyond the extent: err(%v), expect %v", err, ParameterMismatchError)
+	}
+	if _, _, err = s.ScrubBlock(TinyExtentStartID, 0, buf); err != ParameterMismatchError {
+		t.Fatalf("tiny extent: err(%v), expect %v", err, ParameterMismatchError)
+	}
+
+	corruptTestScrubBlock(t, s, 1)
+	if size, crc, err = s.ScrubBlock(testScrubExtentID, 1, buf); err != BlockCrcMismatchError {
+		t.Fatalf("corrupt block: err(%v), expect %v", err, BlockCrcMismatchError)
+	}
+	if size != util.BlockSize || crc != crc32.ChecksumIEEE(blocks[1]) {
+		t.Fatalf("corrupt block: size(%v) crc(%v), expect the persisted ones", size, crc)
+	}
+	if _, _, err = s.ScrubBlock(testScrubExtentID, 0, buf); err != nil {
+		t.Fatalf("block next to the corrupt one: err(%v)", err)
+	}
+}
+
+func TestRepairBlock(t *testing.T) {
+	s, blocks := newTestScrubStore(t)
+	buf := make([]byte, util.BlockSize)
+	corruptTestScrubBlock(t, s, 1)
+
+	// the replica data must match the persisted block crc
+	if err := s.RepairBlock(testScrubExtentID, 1, blocks[0]); err != CrcMismatchError {
+		t.Fatalf("repair with other data: err(%v), expect %v", err, CrcMismatchError)
+	}
+	if err := s.RepairBlock(testScrubExtentID, 1, blocks[1][:4096]); err != CrcMismatchError {
+		t.Fatalf("repair with short data: err(%v), expect %v", err, CrcMismatchError)
+	}
+	if err := s.RepairBlock(testScrubExtentID, 2, blocks[2]); err != CrcMismatchError {
+		t.Fatalf("repair block without crc: err(%v), expect %v", err, CrcMismatchError)
+	}
+	if _, _, err := s.ScrubBlock(testScrubExtentID, 1, buf); err != BlockCrcMismatchError {
+		t.Fatalf("rejected repair should leave the block corrupt, err(%v)", err)
+	}
+
+	if err := s.RepairBlock(testScrubExtentID, 1, blocks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.ScrubBlock(testScrubExtentID, 1, buf); err != nil {
+		t.Fatalf("repaired block: err(%v)", err)
+	}
+	data := make([]byte, util.BlockSize)
+	if _, err := s.Read(testScrubExtentID, util.BlockSize, util.BlockSize, data, false); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, blocks[1]) {
+		t.Fatal("repaired block does not match the replica data")
+	}
+}
@@ -21,7 +21,7 @@ import (
 )
 
 type Object struct {
-	conn *net.TCPConn
+	conn net.Conn
 	idle int64
 }
 
@@ -30,6 +30,10 @@ const (
 	defaultConnectTimeout = 1
 )
 
+// Handshake is performed on every new connection of a pool before it is used,
+// e.g. to present the credentials of the caller.
+type Handshake func(conn net.Conn) error
+
 type ConnectPool struct {
 	sync.RWMutex
 	pools          map[string]*Pool
@@ -37,6 +41,7 @@ type ConnectPool struct {
 	maxcap         int
 	timeout        int64
 	connectTimeout int64
+	handshake      Handshake
 	closeCh        chan struct{}
 	closeOnce      sync.Once
 }
@@ -69,19 +74,27 @@ func NewConnectPoolWithTimeout(idleConnTimeout time.Duration, connectTimeout int
 	return cp
 }
 
-func DailTimeOut(target string, timeout time.Duration) (c *net.TCPConn, err error) {
-	var connect net.Conn
-	connect, err = net.DialTimeout("tcp", target, timeout)
-	if err == nil {
-		conn := connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
-		c = conn
-	}
-	return
+// NewConnectPoolWithHandshake returns a pool whose new connections are set up by the given handshake.
+func NewConnectPoolWithHandshake(handshake Handshake) (cp *ConnectPool) {
+	cp = NewConnectPool()
+	cp.handshake = handshake
+	return cp
+}
+
+// NewConnectPoolWithTimeoutAndHandshake returns a pool with the timeouts whose new connections
+// are set up by the given handshake.
+func NewConnectPoolWithTimeoutAndHandshake(idleConnTimeout time.Duration, connectTimeout int64, handshake Handshake) (cp *ConnectPool) {
+	cp = NewConnectPoolWithTimeout(idleConnTimeout, connectTimeout)
+	cp.handshake = handshake
+	return cp
 }
 
-func (cp *ConnectPool) GetConnect(targetAddr string) (c *net.TCPConn, err error) {
+// DailTimeOut connects to the target with the TLS transport of the process.
+func DailTimeOut(target string, timeout time.Duration) (c net.Conn, err error) {
+	return GetTLSTransport().Dial(target, timeout)
+}
+
+func (cp *ConnectPool) GetConnect(targetAddr string) (c net.Conn, err error) {
 	cp.RLock()
 	pool, ok := cp.pools[targetAddr]
 	cp.RUnlock()
@@ -89,7 +102,7 @@ func (cp *ConnectPool) GetConnect(targetAddr string) (c *net.TCPConn, err error)
 		cp.Lock()
 		pool, ok = cp.pools[targetAddr]
 		if !ok {
-			pool = NewPool(cp.mincap, cp.maxcap, cp.timeout, cp.connectTimeout, targetAddr)
+			pool = newPoolWithHandshake(cp.mincap, cp.maxcap, cp.timeout, cp.connectTimeout, targetAddr, cp.handshake)
 			cp.pools[targetAddr] = pool
 		}
 		cp.Unlock()
@@ -98,7 +111,7 @@ func (cp *ConnectPool) GetConnect(targetAddr string) (c *net.TCPConn, err error)
 	return pool.GetConnectFromPool()
 }
 
-func (cp *ConnectPool) PutConnect(c *net.TCPConn, forceClose bool) {
+func (cp *ConnectPool) PutConnect(c net.Conn, forceClose bool) {
 	if c == nil {
 		return
 	}
@@ -174,9 +187,14 @@ type Pool struct {
 	target         string
 	timeout        int64
 	connectTimeout int64
+	handshake      Handshake
 }
 
 func NewPool(min, max int, timeout, connectTimeout int64, target string) (p *Pool) {
+	return newPoolWithHandshake(min, max, timeout, connectTimeout, target, nil)
+}
+
+func newPoolWithHandshake(min, max int, timeout, connectTimeout int64, target string, handshake Handshake) (p *Pool) {
 	p = new(Pool)
 	p.mincap = min
 	p.maxcap = max
@@ -184,17 +202,15 @@ func NewPool(min, max int, timeout, connectTimeout int64, target string) (p *Poo
 	p.objects = make(chan *Object, max)
 	p.timeout = timeout
 	p.connectTimeout = connectTimeout
+	p.handshake = handshake
 	p.initAllConnect()
 	return p
 }
 
 func (p *Pool) initAllConnect() {
 	for i := 0; i < p.mincap; i++ {
-		c, err := net.Dial("tcp", p.target)
+		conn, err := p.NewConnect(p.target)
 		if err == nil {
-			conn := c.(*net.TCPConn)
-			conn.SetKeepAlive(true)
-			conn.SetNoDelay(true)
 			o := &Object{conn: conn, idle: time.Now().UnixNano()}
 			p.PutConnectObjectToPool(o)
 		}
@@ -241,19 +257,20 @@ func (p *Pool) ReleaseAll() {
 	}
 }
 
-func (p *Pool) NewConnect(target string) (c *net.TCPConn, err error) {
-	var connect net.Conn
-	connect, err = net.DialTimeout("tcp", p.target, time.Duration(p.connectTimeout)*time.Second)
-	if err == nil {
-		conn := connect.(*net.TCPConn)
-		conn.SetKeepAlive(true)
-		conn.SetNoDelay(true)
-		c = conn
+func (p *Pool) NewConnect(target string) (c net.Conn, err error) {
+	if c, err = DailTimeOut(p.target, time.Duration(p.connectTimeout)*time.Second); err != nil {
+		return
+	}
+	if p.handshake != nil {
+		if err = p.handshake(c); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
 	}
 	return
 }
 
-func (p *Pool) GetConnectFromPool() (c *net.TCPConn, err error) {
+func (p *Pool) GetConnectFromPool() (c net.Conn, err error) {
 	var (
 		o *Object
 	)
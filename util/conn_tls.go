@@ -0,0 +1,426 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package util
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+// The connections of the packet protocol, to the data and meta ports and the smux ports, can be
+// encrypted with TLS. The listener tells a TLS connection from a plaintext one by the first byte,
+// which is the handshake record type of TLS, and the magic of the packet or the version of smux
+// otherwise. In the compatible mode both kinds of connections are accepted, and the dialer falls
+// back to plaintext for the servers which do not support TLS yet, so that the nodes and the clients
+// can be upgraded one by one before switching to the strict mode.
+//
+// The mode is switched per cluster: the master publishes its mode in the cluster info, and the
+// clients which do not configure the mode follow it. It is not switched per volume, since the data
+// and meta ports are shared by all the volumes and a listener can not tell the volume of a
+// connection before the first packet.
+
+const (
+	ConfigKeyTLSMode       = "tlsMode"
+	ConfigKeyTLSCertFile   = "tlsCertFile"
+	ConfigKeyTLSKeyFile    = "tlsKeyFile"
+	ConfigKeyTLSCAFile     = "tlsCAFile"
+	ConfigKeyTLSClientAuth = "tlsClientAuth"
+	ConfigKeyTLSServerName = "tlsServerName"
+
+	TLSModeDisable    = "disable"
+	TLSModeCompatible = "compatible"
+	TLSModeStrict     = "strict"
+
+	tlsRecordTypeHandshake  = 0x16
+	tlsReloadInterval       = 10 * time.Second
+	tlsFallbackExpiration   = 5 * time.Minute
+	defaultTLSHandshakeTime = 5 * time.Second
+)
+
+var ErrPlaintextRejected = errors.New("plaintext connection rejected")
+
+// TLSConfig is the config of the TLS transport. The certificate and the key are optional for the
+// clients unless the servers require the client certificates, and the system roots are used if the
+// CA is not specified. The servers are addressed by IP, so only the certificate chain is verified
+// unless ServerName is specified.
+type TLSConfig struct {
+	Mode       string
+	CertFile   string
+	KeyFile    string
+	CAFile     string
+	ServerName string
+	ClientAuth bool
+}
+
+// TLSTransport dials and accepts the connections of the packet protocol with TLS. The certificates
+// are reloaded once the files are modified, so that they can be rotated without restarting.
+// A nil transport dials and accepts plaintext connections only.
+type TLSTransport struct {
+	config    TLSConfig
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	roots     *x509.CertPool
+	modTime   time.Time
+	checked   time.Time
+	fallbacks sync.Map // K: target address, V: expiration of the plaintext fallback
+}
+
+var tlsTransport atomic.Value
+
+// SetTLSTransport sets the transport of the process, which is shared by all the connection pools.
+func SetTLSTransport(t *TLSTransport) {
+	tlsTransport.Store(t)
+}
+
+func GetTLSTransport() *TLSTransport {
+	t, _ := tlsTransport.Load().(*TLSTransport)
+	return t
+}
+
+// LoadTLSConfig returns the TLS config of the transport in the config.
+func LoadTLSConfig(cfg *config.Config) TLSConfig {
+	return TLSConfig{
+		Mode:       cfg.GetString(ConfigKeyTLSMode),
+		CertFile:   cfg.GetString(ConfigKeyTLSCertFile),
+		KeyFile:    cfg.GetString(ConfigKeyTLSKeyFile),
+		CAFile:     cfg.GetString(ConfigKeyTLSCAFile),
+		ServerName: cfg.GetString(ConfigKeyTLSServerName),
+		ClientAuth: cfg.GetBool(ConfigKeyTLSClientAuth),
+	}
+}
+
+// LoadTLSTransport creates the transport with the config, it returns nil if TLS is disabled.
+func LoadTLSTransport(cfg *config.Config) (t *TLSTransport, err error) {
+	return NewTLSTransport(LoadTLSConfig(cfg))
+}
+
+// WithClusterMode returns the config with the mode of the cluster if the mode is not configured.
+func (c TLSConfig) WithClusterMode(mode string) TLSConfig {
+	if c.Mode == "" {
+		c.Mode = mode
+	}
+	return c
+}
+
+func NewTLSTransport(config TLSConfig) (t *TLSTransport, err error) {
+	switch config.Mode {
+	case "", TLSModeDisable:
+		return nil, nil
+	case TLSModeCompatible, TLSModeStrict:
+	default:
+		return nil, fmt.Errorf("invalid tls mode: %v", config.Mode)
+	}
+	if (config.CertFile == "") != (config.KeyFile == "") {
+		return nil, errors.New("both the certificate and the key of tls are required")
+	}
+	if config.ClientAuth && config.CAFile == "" {
+		return nil, errors.New("the ca of tls is required to verify the client certificates")
+	}
+	t = &TLSTransport{config: config}
+	if err = t.reload(); err != nil {
+		return nil, err
+	}
+	t.checked = time.Now()
+	log.LogInfof("tls transport enabled: mode(%v) cert(%v) ca(%v) clientAuth(%v)",
+		config.Mode, config.CertFile, config.CAFile, config.ClientAuth)
+	return
+}
+
+// Mode returns the mode of the transport, TLSModeDisable if t is nil.
+func (t *TLSTransport) Mode() string {
+	if t == nil {
+		return TLSModeDisable
+	}
+	return t.config.Mode
+}
+
+func (t *TLSTransport) files() []string {
+	files := make([]string, 0, 3)
+	for _, file := range []string{t.config.CertFile, t.config.KeyFile, t.config.CAFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (t *TLSTransport) reload() (err error) {
+	var modTime time.Time
+	for _, file := range t.files() {
+		var info os.FileInfo
+		if info, err = os.Stat(file); err != nil {
+			return
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	var cert *tls.Certificate
+	if t.config.CertFile != "" {
+		var c tls.Certificate
+		if c, err = tls.LoadX509KeyPair(t.config.CertFile, t.config.KeyFile); err != nil {
+			return
+		}
+		cert = &c
+	}
+	var roots *x509.CertPool
+	if t.config.CAFile != "" {
+		var data []byte
+		if data, err = ioutil.ReadFile(t.config.CAFile); err != nil {
+			return
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificate found in tls ca file: %v", t.config.CAFile)
+		}
+	}
+	t.mu.Lock()
+	t.cert, t.roots, t.modTime = cert, roots, modTime
+	t.mu.Unlock()
+	return
+}
+
+// material returns the current certificate and CA, the files are checked for modification
+// at most once per reload interval.
+func (t *TLSTransport) material() (cert *tls.Certificate, roots *x509.CertPool) {
+	t.mu.Lock()
+	check := time.Since(t.checked) >= tlsReloadInterval
+	if check {
+		t.checked = time.Now()
+	}
+	modTime := t.modTime
+	t.mu.Unlock()
+	if check {
+		for _, file := range t.files() {
+			if info, err := os.Stat(file); err == nil && info.ModTime().After(modTime) {
+				if err = t.reload(); err != nil {
+					log.LogErrorf("tls transport: reload certificates fail, keep the current ones: err(%v)", err)
+				} else {
+					log.LogInfof("tls transport: certificates reloaded: cert(%v) ca(%v)", t.config.CertFile, t.config.CAFile)
+				}
+				break
+			}
+		}
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.cert, t.roots
+}
+
+func (t *TLSTransport) serverConfig() *tls.Config {
+	cert, roots := t.material()
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cert != nil {
+		c.Certificates = []tls.Certificate{*cert}
+	}
+	if t.config.ClientAuth {
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+		c.ClientCAs = roots
+	}
+	return c
+}
+
+func (t *TLSTransport) clientConfig() *tls.Config {
+	cert, roots := t.material()
+	c := &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots, ServerName: t.config.ServerName}
+	if cert != nil {
+		c.Certificates = []tls.Certificate{*cert}
+	}
+	if t.config.ServerName == "" {
+		// the servers are addressed by IP, verify the chain without the host name
+		c.InsecureSkipVerify = true
+		c.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyCertificateChain(rawCerts, roots)
+		}
+	}
+	return c
+}
+
+func verifyCertificateChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return errors.New("no certificate presented by the server")
+	}
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}
+
+func dialTCP(target string, timeout time.Duration) (c *net.TCPConn, err error) {
+	var connect net.Conn
+	if connect, err = net.DialTimeout("tcp", target, timeout); err != nil {
+		return
+	}
+	c = connect.(*net.TCPConn)
+	c.SetKeepAlive(true)
+	c.SetNoDelay(true)
+	return
+}
+
+// Dial connects to the target, with TLS unless the transport is nil or the target does not support TLS
+// in the compatible mode.
+func (t *TLSTransport) Dial(target string, timeout time.Duration) (conn net.Conn, err error) {
+	var c *net.TCPConn
+	if c, err = dialTCP(target, timeout); err != nil || t == nil {
+		return c, err
+	}
+	if t.config.Mode == TLSModeCompatible {
+		if expiration, ok := t.fallbacks.Load(target); ok && time.Now().Before(expiration.(time.Time)) {
+			return c, nil
+		}
+	}
+	if timeout <= 0 {
+		timeout = defaultTLSHandshakeTime
+	}
+	tc := tls.Client(c, t.clientConfig())
+	_ = c.SetDeadline(time.Now().Add(timeout))
+	if err = tc.Handshake(); err == nil {
+		_ = c.SetDeadline(time.Time{})
+		t.fallbacks.Delete(target)
+		return tc, nil
+	}
+	_ = c.Close()
+	if t.config.Mode != TLSModeCompatible || !isTLSUnsupportedError(err) {
+		return nil, fmt.Errorf("tls handshake with %v fail: %v", target, err)
+	}
+	log.LogWarnf("tls transport: fall back to plaintext: target(%v) err(%v)", target, err)
+	t.fallbacks.Store(target, time.Now().Add(tlsFallbackExpiration))
+	return dialTCP(target, timeout)
+}
+
+// isTLSUnsupportedError returns true if the handshake fails because the server closes the connection
+// or replies with something other than TLS, as a server without TLS does.
+func isTLSUnsupportedError(err error) bool {
+	var recordHeaderError tls.RecordHeaderError
+	return errors.As(err, &recordHeaderError) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET)
+}
+
+// NewListener wraps the listener to accept the TLS connections, and the plaintext connections
+// unless in the strict mode.
+func (t *TLSTransport) NewListener(ln net.Listener) (net.Listener, error) {
+	if t == nil {
+		return ln, nil
+	}
+	if cert, _ := t.material(); cert == nil {
+		return nil, errors.New("the certificate of tls is required to listen")
+	}
+	return &tlsListener{Listener: ln, transport: t}, nil
+}
+
+type tlsListener struct {
+	net.Listener
+	transport *TLSTransport
+}
+
+func (l *tlsListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := conn.(*net.TCPConn); ok {
+		c.SetKeepAlive(true)
+		c.SetNoDelay(true)
+	}
+	return &negotiatedConn{Conn: conn, transport: l.transport}, nil
+}
+
+// negotiatedConn tells TLS from plaintext by the first byte received, which is done on the first
+// read or write to avoid blocking the accept loop.
+type negotiatedConn struct {
+	net.Conn
+	transport *TLSTransport
+	once      sync.Once
+	conn      net.Conn
+	err       error
+}
+
+func (c *negotiatedConn) negotiate() error {
+	c.once.Do(func() {
+		reader := bufio.NewReader(c.Conn)
+		var first []byte
+		if first, c.err = reader.Peek(1); c.err != nil {
+			return
+		}
+		buffered := &bufferedConn{Conn: c.Conn, reader: reader}
+		if first[0] == tlsRecordTypeHandshake {
+			c.conn = tls.Server(buffered, c.transport.serverConfig())
+			return
+		}
+		if c.transport.config.Mode == TLSModeStrict {
+			log.LogWarnf("tls transport: plaintext connection rejected: remote(%v)", c.RemoteAddr())
+			c.err = ErrPlaintextRejected
+			_ = c.Conn.Close()
+			return
+		}
+		c.conn = buffered
+	})
+	return c.err
+}
+
+func (c *negotiatedConn) Read(b []byte) (n int, err error) {
+	if err = c.negotiate(); err != nil {
+		return
+	}
+	return c.conn.Read(b)
+}
+
+func (c *negotiatedConn) Write(b []byte) (n int, err error) {
+	if err = c.negotiate(); err != nil {
+		return
+	}
+	return c.conn.Write(b)
+}
+
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads the bytes peeked first, and then reads the connection directly.
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	if c.reader.Buffered() > 0 {
+		return c.reader.Read(b)
+	}
+	return c.Conn.Read(b)
+}
@@ -0,0 +1,368 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func writePEM(tb testing.TB, file, typ string, data []byte) {
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: data}), 0600); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func newTestCA(tb testing.TB, dir, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	ca := &testCA{dir: dir, cert: cert, key: key, file: path.Join(dir, name+".pem")}
+	writePEM(tb, ca.file, "CERTIFICATE", der)
+	return ca
+}
+
+// issue writes the certificate and the key for both the server and the client auth.
+func (ca *testCA) issue(tb testing.TB, name string, serial int64) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	certFile, keyFile = path.Join(ca.dir, name+".crt"), path.Join(ca.dir, name+".key")
+	writePEM(tb, certFile, "CERTIFICATE", der)
+	writePEM(tb, keyFile, "EC PRIVATE KEY", keyDER)
+	return
+}
+
+func newTestTransport(tb testing.TB, config TLSConfig) *TLSTransport {
+	t, err := NewTLSTransport(config)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return t
+}
+
+// startTLSServer starts a server with the transport, which echoes or discards the data received.
+func startTLSServer(tb testing.TB, t *TLSTransport, discard bool) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if ln, err = t.NewListener(ln); err != nil {
+		tb.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if discard {
+					_, _ = io.Copy(ioutil.Discard, conn)
+				} else {
+					_, _ = io.Copy(conn, conn)
+				}
+			}()
+		}
+	}()
+	tb.Cleanup(func() { ln.Close() })
+	return ln.Addr().String()
+}
+
+func echo(conn net.Conn) error {
+	defer conn.Close()
+	msg := []byte{0xFF, 1, 2, 3}
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+	reply := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if !bytes.Equal(msg, reply) {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+func TestTLSTransportNegotiation(t *testing.T) {
+	ca := newTestCA(t, t.TempDir(), "ca")
+	certFile, keyFile := ca.issue(t, "server", 2)
+	client := newTestTransport(t, TLSConfig{Mode: TLSModeStrict, CAFile: ca.file})
+
+	for _, mode := range []string{TLSModeCompatible, TLSModeStrict} {
+		server := newTestTransport(t, TLSConfig{Mode: mode, CertFile: certFile, KeyFile: keyFile})
+		addr := startTLSServer(t, server, false)
+
+		conn, err := client.Dial(addr, time.Second)
+		if err != nil {
+			t.Fatalf("mode(%v): dial with tls fail: %v", mode, err)
+		}
+		if _, ok := conn.(*tls.Conn); !ok {
+			t.Errorf("mode(%v): expect tls connection, real(%T)", mode, conn)
+		}
+		if err = echo(conn); err != nil {
+			t.Errorf("mode(%v): echo with tls fail: %v", mode, err)
+		}
+
+		var plaintext *TLSTransport
+		if conn, err = plaintext.Dial(addr, time.Second); err != nil {
+			t.Fatalf("mode(%v): dial plaintext fail: %v", mode, err)
+		}
+		if err = echo(conn); (err == nil) != (mode == TLSModeCompatible) {
+			t.Errorf("mode(%v): unexpected result of plaintext echo: %v", mode, err)
+		}
+	}
+}
+
+func TestTLSTransportClusterMode(t *testing.T) {
+	ca := newTestCA(t, t.TempDir(), "ca")
+	for _, c := range []struct {
+		local, cluster, expect string
+	}{
+		{"", "", TLSModeDisable},
+		{"", TLSModeStrict, TLSModeStrict},
+		{TLSModeDisable, TLSModeStrict, TLSModeDisable},
+		{TLSModeCompatible, TLSModeStrict, TLSModeCompatible},
+		{TLSModeStrict, TLSModeDisable, TLSModeStrict},
+	} {
+		transport, err := NewTLSTransport(TLSConfig{Mode: c.local, CAFile: ca.file}.WithClusterMode(c.cluster))
+		if err != nil {
+			t.Fatalf("local(%v) cluster(%v): new transport fail: %v", c.local, c.cluster, err)
+		}
+		if mode := transport.Mode(); mode != c.expect {
+			t.Errorf("local(%v) cluster(%v): expect mode(%v), real(%v)", c.local, c.cluster, c.expect, mode)
+		}
+	}
+}
+
+func TestTLSTransportFallback(t *testing.T) {
+	ca := newTestCA(t, t.TempDir(), "ca")
+	// a server without tls closes the connection with an invalid packet magic
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				magic := make([]byte, 1)
+				if _, err := io.ReadFull(conn, magic); err != nil || magic[0] != 0xFF {
+					return
+				}
+				conn.Write(magic)
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	addr := ln.Addr().String()
+
+	strict := newTestTransport(t, TLSConfig{Mode: TLSModeStrict, CAFile: ca.file})
+	if _, err = strict.Dial(addr, time.Second); err == nil {
+		t.Errorf("expect strict mode not to fall back")
+	}
+	compatible := newTestTransport(t, TLSConfig{Mode: TLSModeCompatible, CAFile: ca.file})
+	for i := 0; i < 2; i++ {
+		conn, err := compatible.Dial(addr, time.Second)
+		if err != nil {
+			t.Fatalf("dial with fallback fail: %v", err)
+		}
+		if _, ok := conn.(*net.TCPConn); !ok {
+			t.Errorf("expect plaintext connection, real(%T)", conn)
+		}
+		if err = echo(conn); err != nil {
+			t.Errorf("echo after fallback fail: %v", err)
+		}
+	}
+}
+
+func TestTLSTransportClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, dir, "ca")
+	certFile, keyFile := ca.issue(t, "server", 2)
+	clientCert, clientKey := ca.issue(t, "client", 3)
+	server := newTestTransport(t, TLSConfig{Mode: TLSModeCompatible, CertFile: certFile, KeyFile: keyFile,
+		CAFile: ca.file, ClientAuth: true})
+	addr := startTLSServer(t, server, false)
+
+	withCert := newTestTransport(t, TLSConfig{Mode: TLSModeCompatible, CertFile: clientCert, KeyFile: clientKey,
+		CAFile: ca.file})
+	conn, err := withCert.Dial(addr, time.Second)
+	if err != nil {
+		t.Fatalf("dial with client certificate fail: %v", err)
+	}
+	if err = echo(conn); err != nil {
+		t.Errorf("echo with client certificate fail: %v", err)
+	}
+
+	// the client certificate is verified after the handshake of the client in TLS 1.3
+	withoutCert := newTestTransport(t, TLSConfig{Mode: TLSModeCompatible, CAFile: ca.file})
+	if conn, err = withoutCert.Dial(addr, time.Second); err == nil {
+		err = echo(conn)
+	}
+	if err == nil {
+		t.Errorf("expect connection without client certificate rejected")
+	}
+
+	// a certificate error never falls back to plaintext
+	other := newTestCA(t, dir, "other")
+	untrusted := newTestTransport(t, TLSConfig{Mode: TLSModeCompatible, CAFile: other.file})
+	if _, err = untrusted.Dial(addr, time.Second); err == nil {
+		t.Errorf("expect untrusted server rejected")
+	}
+}
+
+func TestTLSTransportRotation(t *testing.T) {
+	ca := newTestCA(t, t.TempDir(), "ca")
+	certFile, keyFile := ca.issue(t, "server", 2)
+	server := newTestTransport(t, TLSConfig{Mode: TLSModeStrict, CertFile: certFile, KeyFile: keyFile})
+	addr := startTLSServer(t, server, false)
+	client := newTestTransport(t, TLSConfig{Mode: TLSModeStrict, CAFile: ca.file})
+
+	var serial = func() int64 {
+		conn, err := client.Dial(addr, time.Second)
+		if err != nil {
+			t.Fatalf("dial fail: %v", err)
+		}
+		defer conn.Close()
+		return conn.(*tls.Conn).ConnectionState().PeerCertificates[0].SerialNumber.Int64()
+	}
+	if s := serial(); s != 2 {
+		t.Fatalf("unexpected serial: %v", s)
+	}
+	ca.issue(t, "server", 4)
+	future := time.Now().Add(time.Minute)
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.Chtimes(file, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.mu.Lock()
+	server.checked = time.Time{}
+	server.mu.Unlock()
+	if s := serial(); s != 4 {
+		t.Errorf("expect rotated certificate, real serial %v", s)
+	}
+}
+
+// benchmarkTransport measures the throughput of writing the blocks of the packets to a server.
+func benchmarkTransport(b *testing.B, server, client *TLSTransport) {
+	addr := startTLSServer(b, server, true)
+	conn, err := client.Dial(addr, time.Second)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+	block := make([]byte, BlockSize)
+	block[0] = 0xFF
+	b.SetBytes(int64(len(block)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = conn.Write(block); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTransportPlaintext(b *testing.B) {
+	benchmarkTransport(b, nil, nil)
+}
+
+func BenchmarkTransportCompatiblePlaintext(b *testing.B) {
+	ca := newTestCA(b, b.TempDir(), "ca")
+	certFile, keyFile := ca.issue(b, "server", 2)
+	server := newTestTransport(b, TLSConfig{Mode: TLSModeCompatible, CertFile: certFile, KeyFile: keyFile})
+	benchmarkTransport(b, server, nil)
+}
+
+func BenchmarkTransportTLS(b *testing.B) {
+	ca := newTestCA(b, b.TempDir(), "ca")
+	certFile, keyFile := ca.issue(b, "server", 2)
+	server := newTestTransport(b, TLSConfig{Mode: TLSModeStrict, CertFile: certFile, KeyFile: keyFile})
+	client := newTestTransport(b, TLSConfig{Mode: TLSModeStrict, CAFile: ca.file})
+	benchmarkTransport(b, server, client)
+}
+
+func BenchmarkTransportMutualTLS(b *testing.B) {
+	ca := newTestCA(b, b.TempDir(), "ca")
+	certFile, keyFile := ca.issue(b, "server", 2)
+	clientCert, clientKey := ca.issue(b, "client", 3)
+	server := newTestTransport(b, TLSConfig{Mode: TLSModeStrict, CertFile: certFile, KeyFile: keyFile,
+		CAFile: ca.file, ClientAuth: true})
+	client := newTestTransport(b, TLSConfig{Mode: TLSModeStrict, CertFile: clientCert, KeyFile: clientKey,
+		CAFile: ca.file})
+	benchmarkTransport(b, server, client)
+}
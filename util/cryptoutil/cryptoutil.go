@@ -31,6 +31,7 @@ import (
 	rand2 "math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 	"unsafe"
 )
@@ -41,10 +42,17 @@ func pad(src []byte) []byte {
 	return append(src, padtext...)
 }
 
-func unpad(src []byte) []byte {
+func unpad(src []byte) ([]byte, error) {
 	length := len(src)
+	if length == 0 {
+		return nil, fmt.Errorf("invalid padding of empty text")
+	}
 	unpadding := int(src[length-1])
-	return src[:(length - unpadding)]
+	// a text decrypted with a wrong key usually has an invalid padding
+	if unpadding == 0 || unpadding > aes.BlockSize || unpadding > length {
+		return nil, fmt.Errorf("invalid padding [%d]", unpadding)
+	}
+	return src[:(length - unpadding)], nil
 }
 
 // AesEncryptCBC defines aes encryption with CBC
@@ -98,10 +106,15 @@ func AesDecryptCBC(key, ciphertext []byte) (plaintext []byte, err error) {
 	iv := ciphertext[:aes.BlockSize]
 	ciphertext = ciphertext[aes.BlockSize:]
 
+	if len(ciphertext)%aes.BlockSize != 0 {
+		err = fmt.Errorf("ciphertext [len=%d] is not a multiple of the block size", len(ciphertext))
+		return
+	}
+
 	cbc := cipher.NewCBCDecrypter(block, iv)
 	cbc.CryptBlocks(ciphertext, ciphertext)
 
-	plaintext = unpad(ciphertext)
+	plaintext, err = unpad(ciphertext)
 
 	return
 }
@@ -141,6 +154,19 @@ func Base64Decode(encodedText string) (text []byte, err error) {
 	return
 }
 
+// Base64DecodeKeys decodes the comma separated keys, the first one is the current key and the
+// others are the previous ones still accepted while the key is being rotated.
+func Base64DecodeKeys(encodedText string) (keys [][]byte, err error) {
+	for _, encoded := range strings.Split(encodedText, ",") {
+		var key []byte
+		if key, err = Base64Decode(strings.TrimSpace(encoded)); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return
+}
+
 // EncodeMessage encode a message with aes encrption, md5 signature
 func EncodeMessage(plaintext []byte, key []byte) (message string, err error) {
 	var cipher []byte
@@ -102,8 +102,10 @@ func Init(role string, cfg *config.Config) {
 		enablePush = true
 	}
 
+	initOpMetrics(cfg)
 	http.Handle(PromHandlerPattern, promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
-		Timeout: 60 * time.Second,
+		Timeout:           60 * time.Second,
+		EnableOpenMetrics: enableExemplars,
 	}))
 
 	namespace = AppName + "_" + role
@@ -141,11 +143,13 @@ func InitWithRouter(role string, cfg *config.Config, router *mux.Router, exPort
 	}
 	exporterPort, _ = strconv.ParseInt(exPort, 10, 64)
 	enabledPrometheus = true
+	initOpMetrics(cfg)
 	router.NewRoute().Name("metrics").
 		Methods(http.MethodGet).
 		Path(PromHandlerPattern).
 		Handler(promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{
-			Timeout: 5 * time.Second,
+			Timeout:           5 * time.Second,
+			EnableOpenMetrics: enableExemplars,
 		}))
 	namespace = AppName + "_" + role
 
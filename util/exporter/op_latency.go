@@ -0,0 +1,163 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exporter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	ConfigKeyMaxVolumes      = "exporterMaxVolumes" // max number of volumes labelled in the op metrics
+	ConfigKeyEnableExemplars = "exporterExemplars"  // attach trace IDs to the op latency as exemplars
+
+	DefaultMaxVolumes = 1000
+	maxOps            = 512
+
+	// OverflowLabel replaces the label values beyond the cardinality limit
+	OverflowLabel = "_other_"
+	// TraceID is the exemplar label of the op latency
+	TraceID = "trace_id"
+
+	opLatencyName  = "op_latency_seconds"
+	opErrorsName   = "op_errors_total"
+	overflowedName = "label_overflow_total"
+)
+
+var (
+	// seconds, 100us to 10s
+	latencyBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	opMetricsOnce   sync.Once
+	opLatency       *prometheus.HistogramVec
+	opErrors        *prometheus.CounterVec
+	labelOverflowed *prometheus.CounterVec
+
+	volumeLimiter   = newLabelLimiter(Vol, DefaultMaxVolumes)
+	opLimiter       = newLabelLimiter(Op, maxOps)
+	enableExemplars = false
+)
+
+func initOpMetrics(cfg *config.Config) {
+	if max := cfg.GetInt64(ConfigKeyMaxVolumes); max > 0 {
+		volumeLimiter = newLabelLimiter(Vol, int32(max))
+	}
+	enableExemplars = cfg.GetBoolWithDefault(ConfigKeyEnableExemplars, false)
+	log.LogInfof("op metrics: max volumes(%v) exemplars(%v)", volumeLimiter.max, enableExemplars)
+}
+
+func registerOpMetrics() {
+	opLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    metricsName(opLatencyName),
+		Help:    "Latency of the ops in seconds by op and volume.",
+		Buckets: latencyBuckets,
+	}, []string{Op, Vol})
+	opErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsName(opErrorsName),
+		Help: "Number of the failed ops by op and volume.",
+	}, []string{Op, Vol})
+	labelOverflowed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsName(overflowedName),
+		Help: "Number of the label values replaced by " + OverflowLabel + " beyond the cardinality limit.",
+	}, []string{"label"})
+
+	registerer := prometheus.DefaultRegisterer
+	if enablePush {
+		registerer = registry
+	}
+	for _, c := range []prometheus.Collector{opLatency, opErrors, labelOverflowed} {
+		if err := registerer.Register(c); err != nil {
+			log.LogErrorf("register op metrics, %v", err)
+		}
+	}
+}
+
+// labelLimiter bounds the number of distinct values of a label, the values beyond
+// the limit are reported as OverflowLabel.
+type labelLimiter struct {
+	label  string
+	max    int32
+	count  int32
+	values sync.Map
+}
+
+func newLabelLimiter(label string, max int32) *labelLimiter {
+	return &labelLimiter{label: label, max: max}
+}
+
+func (l *labelLimiter) value(v string) string {
+	if _, ok := l.values.Load(v); ok {
+		return v
+	}
+	if atomic.AddInt32(&l.count, 1) > l.max {
+		atomic.AddInt32(&l.count, -1)
+		labelOverflowed.WithLabelValues(l.label).Inc()
+		return OverflowLabel
+	}
+	if _, loaded := l.values.LoadOrStore(v, struct{}{}); loaded {
+		atomic.AddInt32(&l.count, -1)
+	}
+	return v
+}
+
+// OpTimePoint records the latency of an op on a volume into the op latency histogram,
+// which replaces the TP of ump.
+type OpTimePoint struct {
+	op        string
+	vol       string
+	traceID   string
+	startTime time.Time
+}
+
+// NewOpTP starts timing the op, the traceID is attached to the latency as an exemplar
+// if exemplars are enabled.
+func NewOpTP(op, vol, traceID string) *OpTimePoint {
+	return &OpTimePoint{op: op, vol: vol, traceID: traceID, startTime: time.Now()}
+}
+
+// NewOpTPSince times the op started at startTime.
+func NewOpTPSince(op, vol, traceID string, startTime time.Time) *OpTimePoint {
+	return &OpTimePoint{op: op, vol: vol, traceID: traceID, startTime: startTime}
+}
+
+// SetVol sets the volume once it is known.
+func (tp *OpTimePoint) SetVol(vol string) {
+	tp.vol = vol
+}
+
+// Set records the latency of the op, it should be invoked by defer func{set(err)}.
+func (tp *OpTimePoint) Set(err error) {
+	if !enabledPrometheus {
+		return
+	}
+	opMetricsOnce.Do(registerOpMetrics)
+	op := opLimiter.value(tp.op)
+	vol := volumeLimiter.value(tp.vol)
+	elapsed := time.Since(tp.startTime).Seconds()
+	observer := opLatency.WithLabelValues(op, vol)
+	if eo, ok := observer.(prometheus.ExemplarObserver); ok && enableExemplars && tp.traceID != "" {
+		eo.ObserveWithExemplar(elapsed, prometheus.Labels{TraceID: tp.traceID})
+	} else {
+		observer.Observe(elapsed)
+	}
+	if err != nil {
+		opErrors.WithLabelValues(op, vol).Inc()
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package exporter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestOpTimePoint(t *testing.T) {
+	enabledPrometheus = true
+	enableExemplars = true
+	namespace = "cfs_test"
+	volumeLimiter = newLabelLimiter(Vol, 2)
+	defer func() {
+		enabledPrometheus = false
+		enableExemplars = false
+		volumeLimiter = newLabelLimiter(Vol, DefaultMaxVolumes)
+	}()
+
+	for i := 0; i < 4; i++ {
+		NewOpTP("OpStreamRead", fmt.Sprintf("vol%d", i), fmt.Sprintf("%d", i)).Set(nil)
+	}
+	NewOpTP("OpStreamRead", "vol0", "").Set(fmt.Errorf("read failed"))
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		counts   = make(map[string]uint64)
+		errs     = make(map[string]float64)
+		overflow float64
+	)
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			vol := ""
+			for _, l := range m.GetLabel() {
+				if l.GetName() == Vol {
+					vol = l.GetValue()
+				}
+			}
+			switch f.GetName() {
+			case metricsName(opLatencyName):
+				counts[vol] = m.GetHistogram().GetSampleCount()
+				if vol != "vol1" {
+					continue
+				}
+				traced := false
+				for _, b := range m.GetHistogram().GetBucket() {
+					for _, l := range b.GetExemplar().GetLabel() {
+						traced = traced || (l.GetName() == TraceID && l.GetValue() == "1")
+					}
+				}
+				if !traced {
+					t.Errorf("expect the trace id attached as an exemplar")
+				}
+			case metricsName(opErrorsName):
+				errs[vol] = m.GetCounter().GetValue()
+			case metricsName(overflowedName):
+				overflow = m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	if counts["vol0"] != 2 || counts["vol1"] != 1 || counts[OverflowLabel] != 2 {
+		t.Fatalf("unexpected op latency counts %v", counts)
+	}
+	if errs["vol0"] != 1 || len(errs) != 1 {
+		t.Fatalf("unexpected op errors %v", errs)
+	}
+	if overflow != 2 {
+		t.Fatalf("expect 2 volumes overflowed, got %v", overflow)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package federation
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// A minimal BER codec for the LDAP messages, which accepts the long form lengths
+// in any size as some directory servers send, unlike the DER decoder of encoding/asn1.
+
+const (
+	berClassUniversal   = 0x00
+	berClassApplication = 0x40
+	berClassContext     = 0x80
+
+	berTagBoolean     = 0x01
+	berTagInteger     = 0x02
+	berTagOctetString = 0x04
+	berTagEnumerated  = 0x0a
+	berTagSequence    = 0x10
+	berTagSet         = 0x11
+
+	berMaxPacketSize = 16 * 1024 * 1024
+)
+
+var errBERMalformed = errors.New("malformed BER packet")
+
+type berPacket struct {
+	class    byte
+	compound bool
+	tag      byte
+	value    []byte
+	children []*berPacket
+}
+
+func newBERPrimitive(class, tag byte, value []byte) *berPacket {
+	return &berPacket{class: class, tag: tag, value: value}
+}
+
+func newBERCompound(class, tag byte, children ...*berPacket) *berPacket {
+	return &berPacket{class: class, compound: true, tag: tag, children: children}
+}
+
+func newBERString(value string) *berPacket {
+	return newBERPrimitive(berClassUniversal, berTagOctetString, []byte(value))
+}
+
+func newBERInteger(tag byte, value int64) *berPacket {
+	var b []byte
+	for {
+		b = append([]byte{byte(value)}, b...)
+		value >>= 8
+		if (value == 0 && b[0]&0x80 == 0) || (value == -1 && b[0]&0x80 != 0) {
+			break
+		}
+	}
+	return newBERPrimitive(berClassUniversal, tag, b)
+}
+
+func newBERBoolean(value bool) *berPacket {
+	if value {
+		return newBERPrimitive(berClassUniversal, berTagBoolean, []byte{0xff})
+	}
+	return newBERPrimitive(berClassUniversal, berTagBoolean, []byte{0x00})
+}
+
+func (p *berPacket) is(class, tag byte) bool {
+	return p.class == class && p.tag == tag
+}
+
+func (p *berPacket) int() (value int64) {
+	for i, b := range p.value {
+		if i == 0 && b&0x80 != 0 {
+			value = -1
+		}
+		value = value<<8 | int64(b)
+	}
+	return
+}
+
+func (p *berPacket) bytes() []byte {
+	var content []byte
+	if p.compound {
+		for _, child := range p.children {
+			content = append(content, child.bytes()...)
+		}
+	} else {
+		content = p.value
+	}
+	var identifier = p.class | p.tag
+	if p.compound {
+		identifier |= 0x20
+	}
+	return append(append([]byte{identifier}, encodeBERLength(len(content))...), content...)
+}
+
+func encodeBERLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	var b []byte
+	for ; length > 0; length >>= 8 {
+		b = append([]byte{byte(length)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// readBERPacket reads a packet with the definite length, the tag numbers over 30 are not supported.
+func readBERPacket(r *bufio.Reader) (p *berPacket, err error) {
+	var identifier byte
+	if identifier, err = r.ReadByte(); err != nil {
+		return
+	}
+	var length int
+	if length, err = readBERLength(r); err != nil {
+		return
+	}
+	content := make([]byte, length)
+	if _, err = io.ReadFull(r, content); err != nil {
+		return
+	}
+	return newBERPacket(identifier, content)
+}
+
+func readBERLength(r io.ByteReader) (length int, err error) {
+	var b byte
+	if b, err = r.ReadByte(); err != nil {
+		return
+	}
+	if b < 0x80 {
+		return int(b), nil
+	}
+	n := int(b & 0x7f)
+	if n == 0 || n > 4 {
+		return 0, errBERMalformed
+	}
+	for i := 0; i < n; i++ {
+		if b, err = r.ReadByte(); err != nil {
+			return
+		}
+		length = length<<8 | int(b)
+	}
+	if length > berMaxPacketSize {
+		return 0, errBERMalformed
+	}
+	return
+}
+
+func newBERPacket(identifier byte, content []byte) (p *berPacket, err error) {
+	if identifier&0x1f == 0x1f {
+		return nil, errBERMalformed
+	}
+	p = &berPacket{class: identifier & 0xc0, compound: identifier&0x20 != 0, tag: identifier & 0x1f}
+	if !p.compound {
+		p.value = content
+		return
+	}
+	for len(content) > 0 {
+		if len(content) < 2 {
+			return nil, errBERMalformed
+		}
+		reader := &byteSliceReader{data: content[1:]}
+		var length int
+		if length, err = readBERLength(reader); err != nil {
+			return
+		}
+		start := 1 + reader.offset
+		if start+length > len(content) {
+			return nil, errBERMalformed
+		}
+		var child *berPacket
+		if child, err = newBERPacket(content[0], content[start:start+length]); err != nil {
+			return
+		}
+		p.children = append(p.children, child)
+		content = content[start+length:]
+	}
+	return
+}
+
+type byteSliceReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *byteSliceReader) ReadByte() (b byte, err error) {
+	if r.offset >= len(r.data) {
+		return 0, errBERMalformed
+	}
+	b = r.data[r.offset]
+	r.offset++
+	return
+}
@@ -0,0 +1,265 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package federation maps the identities of the external identity providers, the LDAP
+// servers and the OpenID Connect providers, to the users of the cluster. The users are
+// provisioned in the master on the first login, and the groups of the identities are
+// mapped to the user groups of the cluster.
+package federation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ConfigKeyLDAPURL                = "ldapURL"
+	ConfigKeyLDAPUserDNPattern      = "ldapUserDNPattern"
+	ConfigKeyLDAPBindDN             = "ldapBindDN"
+	ConfigKeyLDAPBindPassword       = "ldapBindPassword"
+	ConfigKeyLDAPBaseDN             = "ldapBaseDN"
+	ConfigKeyLDAPUserAttribute      = "ldapUserAttribute"
+	ConfigKeyLDAPGroupAttribute     = "ldapGroupAttribute"
+	ConfigKeyLDAPInsecureSkipVerify = "ldapInsecureSkipVerify"
+	ConfigKeyOIDCIssuer             = "oidcIssuer"
+	ConfigKeyOIDCClientID           = "oidcClientID"
+	ConfigKeyOIDCClientSecret       = "oidcClientSecret"
+	ConfigKeyOIDCRedirectURL        = "oidcRedirectURL"
+	ConfigKeyOIDCJWKSURL            = "oidcJWKSURL"
+	ConfigKeyOIDCJWKSFile           = "oidcJWKSFile"
+	ConfigKeyOIDCUsernameClaim      = "oidcUsernameClaim"
+	ConfigKeyOIDCGroupsClaim        = "oidcGroupsClaim"
+	ConfigKeyOIDCScopes             = "oidcScopes"
+	ConfigKeyUserPrefix             = "federationUserPrefix"
+	ConfigKeyGroupMapping           = "federationGroupMapping"
+
+	federatedDescriptionPrefix = "federated:"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidToken       = errors.New("invalid identity token")
+	ErrTokenExpired       = errors.New("identity token expired")
+	ErrInvalidIdentity    = errors.New("identity cannot be mapped to a user")
+	ErrIdentityConflict   = errors.New("user is not provisioned for the identity")
+	ErrProviderDisabled   = errors.New("identity provider is not configured")
+
+	// the same as the user ID accepted by the master
+	userIDRegexp       = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_]{0,20}$")
+	invalidUserIDChars = regexp.MustCompile("[^A-Za-z0-9_]")
+)
+
+// Identity is an identity authenticated by an external identity provider.
+type Identity struct {
+	Provider string
+	Subject  string
+	Groups   []string
+}
+
+func (i *Identity) String() string {
+	return i.Provider + ":" + i.Subject
+}
+
+// Federation authenticates the identities with the configured identity providers, and provisions
+// the users of the identities with the master.
+type Federation struct {
+	ldap         *LDAPProvider
+	oidc         *OIDCProvider
+	mc           *master.MasterClient
+	userPrefix   string
+	groupMapping map[string][]string // K: external group, V: user groups
+}
+
+// NewFederation creates the federation with the identity providers in the config,
+// it returns nil if no identity provider is configured.
+func NewFederation(cfg *config.Config, mc *master.MasterClient) (f *Federation, err error) {
+	f = &Federation{mc: mc, userPrefix: cfg.GetString(ConfigKeyUserPrefix), groupMapping: make(map[string][]string)}
+	if url := cfg.GetString(ConfigKeyLDAPURL); url != "" {
+		if f.ldap, err = NewLDAPProvider(LDAPConfig{
+			URL:                url,
+			UserDNPattern:      cfg.GetString(ConfigKeyLDAPUserDNPattern),
+			BindDN:             cfg.GetString(ConfigKeyLDAPBindDN),
+			BindPassword:       cfg.GetString(ConfigKeyLDAPBindPassword),
+			BaseDN:             cfg.GetString(ConfigKeyLDAPBaseDN),
+			UserAttribute:      cfg.GetString(ConfigKeyLDAPUserAttribute),
+			GroupAttribute:     cfg.GetString(ConfigKeyLDAPGroupAttribute),
+			InsecureSkipVerify: cfg.GetBool(ConfigKeyLDAPInsecureSkipVerify),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if issuer := cfg.GetString(ConfigKeyOIDCIssuer); issuer != "" {
+		if f.oidc, err = NewOIDCProvider(OIDCConfig{
+			Issuer:        issuer,
+			ClientID:      cfg.GetString(ConfigKeyOIDCClientID),
+			ClientSecret:  cfg.GetString(ConfigKeyOIDCClientSecret),
+			RedirectURL:   cfg.GetString(ConfigKeyOIDCRedirectURL),
+			JWKSURL:       cfg.GetString(ConfigKeyOIDCJWKSURL),
+			JWKSFile:      cfg.GetString(ConfigKeyOIDCJWKSFile),
+			UsernameClaim: cfg.GetString(ConfigKeyOIDCUsernameClaim),
+			GroupsClaim:   cfg.GetString(ConfigKeyOIDCGroupsClaim),
+			Scopes:        cfg.GetString(ConfigKeyOIDCScopes),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if f.ldap == nil && f.oidc == nil {
+		return nil, nil
+	}
+	// the mapping is in the form of "external group:user group"
+	for _, mapping := range cfg.GetStringSlice(ConfigKeyGroupMapping) {
+		i := strings.LastIndex(mapping, ":")
+		if i <= 0 || i == len(mapping)-1 {
+			return nil, config.NewIllegalConfigError(ConfigKeyGroupMapping)
+		}
+		external, group := mapping[:i], mapping[i+1:]
+		f.groupMapping[external] = append(f.groupMapping[external], group)
+	}
+	return
+}
+
+func (f *Federation) LDAP() *LDAPProvider {
+	return f.ldap
+}
+
+func (f *Federation) OIDC() *OIDCProvider {
+	return f.oidc
+}
+
+// LoginWithPassword authenticates the user with the LDAP server, and returns the provisioned user.
+func (f *Federation) LoginWithPassword(username, password string) (identity *Identity, userInfo *proto.UserInfo, err error) {
+	if f.ldap == nil {
+		return nil, nil, ErrProviderDisabled
+	}
+	if identity, err = f.ldap.Authenticate(username, password); err != nil {
+		return
+	}
+	userInfo, err = f.Provision(identity)
+	return
+}
+
+// LoginWithToken verifies the ID token of the OpenID Connect provider, and returns the provisioned user.
+func (f *Federation) LoginWithToken(token string) (identity *Identity, userInfo *proto.UserInfo, err error) {
+	if f.oidc == nil {
+		return nil, nil, ErrProviderDisabled
+	}
+	if identity, err = f.oidc.VerifyToken(token); err != nil {
+		return
+	}
+	userInfo, err = f.Provision(identity)
+	return
+}
+
+// UserID maps the identity to the user ID, the characters not allowed in the user ID are
+// replaced with "_", such as "alice_example_com" for "alice@example.com".
+func (f *Federation) UserID(identity *Identity) (userID string, err error) {
+	userID = f.userPrefix + invalidUserIDChars.ReplaceAllString(identity.Subject, "_")
+	if !userIDRegexp.MatchString(userID) {
+		return "", fmt.Errorf("%w: %v", ErrInvalidIdentity, identity)
+	}
+	return
+}
+
+// Provision returns the user of the identity, the user is created on the first login with a
+// random password, and joins the user groups mapped from the groups of the identity. An existing
+// user which is not provisioned for the identity is never taken over.
+func (f *Federation) Provision(identity *Identity) (userInfo *proto.UserInfo, err error) {
+	var userID string
+	if userID, err = f.UserID(identity); err != nil {
+		return
+	}
+	description := federatedDescriptionPrefix + identity.String()
+	if userInfo, err = f.mc.UserAPI().GetUserInfo(userID); err == proto.ErrUserNotExists {
+		var password string
+		if password, err = randomPassword(); err != nil {
+			return
+		}
+		param := &proto.UserCreateParam{ID: userID, Password: password, Type: proto.UserTypeNormal, Description: description}
+		if userInfo, err = f.mc.UserAPI().CreateUser(param); err == proto.ErrDuplicateUserID {
+			userInfo, err = f.mc.UserAPI().GetUserInfo(userID)
+		} else if err == nil {
+			log.LogInfof("federation: user provisioned: identity(%v) userID(%v)", identity, userID)
+		}
+	}
+	if err != nil {
+		return
+	}
+	if userInfo.Description != description || userInfo.UserType != proto.UserTypeNormal {
+		log.LogWarnf("federation: identity conflicts with user: identity(%v) userID(%v) description(%v)",
+			identity, userID, userInfo.Description)
+		return nil, ErrIdentityConflict
+	}
+	f.syncGroups(identity, userID)
+	return
+}
+
+// syncGroups adds the user to the mapped user groups of the identity, and removes it from the
+// other mapped user groups. The failures are logged and do not fail the login.
+func (f *Federation) syncGroups(identity *Identity, userID string) {
+	if len(f.groupMapping) == 0 {
+		return
+	}
+	var wanted = make(map[string]bool)
+	for _, external := range identity.Groups {
+		for _, group := range f.groupMapping[external] {
+			wanted[group] = true
+		}
+	}
+	var synced = make(map[string]bool)
+	for _, groups := range f.groupMapping {
+		for _, groupID := range groups {
+			if synced[groupID] {
+				continue
+			}
+			synced[groupID] = true
+			group, err := f.mc.UserAPI().GetGroup(groupID)
+			if err != nil {
+				log.LogWarnf("federation: get group fail: groupID(%v) err(%v)", groupID, err)
+				continue
+			}
+			isMember := false
+			for _, member := range group.Members {
+				if member == userID {
+					isMember = true
+				}
+			}
+			switch {
+			case wanted[groupID] && !isMember:
+				_, err = f.mc.UserAPI().AddGroupMember(groupID, userID)
+			case !wanted[groupID] && isMember:
+				_, err = f.mc.UserAPI().RemoveGroupMember(groupID, userID)
+			}
+			if err != nil {
+				log.LogWarnf("federation: sync group member fail: groupID(%v) userID(%v) err(%v)", groupID, userID, err)
+			}
+		}
+	}
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package federation
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	testUserDN       = "uid=alice,ou=people,dc=example,dc=com"
+	testUserPassword = "secret"
+	testAdminDN      = "cn=admin,dc=example,dc=com"
+	testAdminPasswd  = "admin"
+	testGroupDN      = "cn=dev,ou=groups,dc=example,dc=com"
+)
+
+// startLDAPStub starts an LDAP server with the user alice in the group dev.
+func startLDAPStub(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveLDAPStub(conn)
+		}
+	}()
+	return "ldap://" + listener.Addr().String()
+}
+
+func serveLDAPStub(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	var reply = func(id *berPacket, op *berPacket) {
+		_, _ = conn.Write(newBERCompound(berClassUniversal, berTagSequence, id, op).bytes())
+	}
+	var result = func(op byte, code int64) *berPacket {
+		return newBERCompound(berClassApplication, op,
+			newBERInteger(berTagEnumerated, code), newBERString(""), newBERString(""))
+	}
+	var entry = newBERCompound(berClassApplication, ldapOpSearchResultEntry, newBERString(testUserDN),
+		newBERCompound(berClassUniversal, berTagSequence,
+			newBERCompound(berClassUniversal, berTagSequence, newBERString("memberOf"),
+				newBERCompound(berClassUniversal, berTagSet, newBERString(testGroupDN)))))
+	for {
+		message, err := readBERPacket(reader)
+		if err != nil || len(message.children) < 2 {
+			return
+		}
+		id, op := message.children[0], message.children[1]
+		switch {
+		case op.is(berClassApplication, ldapOpBindRequest):
+			dn, password := string(op.children[1].value), string(op.children[2].value)
+			if (dn == testUserDN && password == testUserPassword) || (dn == testAdminDN && password == testAdminPasswd) {
+				reply(id, result(ldapOpBindResponse, ldapResultSuccess))
+			} else {
+				reply(id, result(ldapOpBindResponse, ldapResultInvalidCredentials))
+			}
+		case op.is(berClassApplication, ldapOpSearchRequest):
+			base, filter := string(op.children[0].value), op.children[6]
+			if base == testUserDN || (filter.is(berClassContext, ldapFilterEqualityMatch) &&
+				string(filter.children[1].value) == "alice") {
+				reply(id, entry)
+			}
+			reply(id, result(ldapOpSearchResultDone, ldapResultSuccess))
+		default:
+			return
+		}
+	}
+}
+
+func TestLDAPAuthenticate(t *testing.T) {
+	url := startLDAPStub(t)
+	providers := make([]*LDAPProvider, 0)
+	for _, config := range []LDAPConfig{
+		{URL: url, UserDNPattern: "uid=%s,ou=people,dc=example,dc=com"},
+		{URL: url, BindDN: testAdminDN, BindPassword: testAdminPasswd, BaseDN: "dc=example,dc=com"},
+	} {
+		p, err := NewLDAPProvider(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		providers = append(providers, p)
+	}
+	for _, p := range providers {
+		identity, err := p.Authenticate("alice", testUserPassword)
+		if err != nil {
+			t.Fatalf("authenticate fail: %v", err)
+		}
+		if identity.Subject != "alice" || !reflect.DeepEqual(identity.Groups, []string{"dev"}) {
+			t.Errorf("unexpected identity: %v groups %v", identity, identity.Groups)
+		}
+		if _, err = p.Authenticate("alice", "wrong"); err != ErrInvalidCredentials {
+			t.Errorf("expect invalid credentials, real(%v)", err)
+		}
+		if _, err = p.Authenticate("alice", ""); err != ErrInvalidCredentials {
+			t.Errorf("expect empty password rejected, real(%v)", err)
+		}
+	}
+	if _, err := providers[1].Authenticate("bob", testUserPassword); err != ErrInvalidCredentials {
+		t.Errorf("expect unknown user rejected, real(%v)", err)
+	}
+}
+
+func TestBER(t *testing.T) {
+	for _, value := range []int64{0, 1, 127, 128, 255, 256, 65535, -1, -129} {
+		p := newBERInteger(berTagInteger, value)
+		decoded, err := readBERPacket(bufio.NewReader(bytes.NewReader(p.bytes())))
+		if err != nil || decoded.int() != value {
+			t.Errorf("integer %v decoded as %v, err(%v)", value, decoded.int(), err)
+		}
+	}
+	long := newBERString(string(make([]byte, 300)))
+	decoded, err := readBERPacket(bufio.NewReader(bytes.NewReader(long.bytes())))
+	if err != nil || len(decoded.value) != 300 {
+		t.Errorf("long string decoded fail: err(%v)", err)
+	}
+	if escaped := escapeDN(" a,b=c\\"); escaped != "\\ a\\,b\\=c\\\\" {
+		t.Errorf("unexpected escaped dn: %v", escaped)
+	}
+}
+
+func signTestToken(t *testing.T, algorithm, keyID string, key crypto.Signer, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": algorithm, "kid": keyID, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCVerifyToken(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encode = func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+	}})
+	jwksFile := path.Join(t.TempDir(), "jwks.json")
+	if err = ioutil.WriteFile(jwksFile, jwks, 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(jwksFile)
+	p, err := NewOIDCProvider(OIDCConfig{Issuer: "https://idp.example.com", ClientID: "cubefs",
+		UsernameClaim: "preferred_username", JWKSFile: jwksFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var claims = func(modify func(claims map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":                "https://idp.example.com",
+			"aud":                []string{"cubefs", "other"},
+			"sub":                "248289761001",
+			"preferred_username": "alice@example.com",
+			"groups":             []string{"dev", "ops"},
+			"exp":                time.Now().Add(time.Hour).Unix(),
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+	for _, token := range []string{
+		signTestToken(t, "RS256", "rsa", rsaKey, claims(nil)),
+		signTestToken(t, "ES256", "ec", ecKey, claims(nil)),
+	} {
+		identity, err := p.VerifyToken(token)
+		if err != nil {
+			t.Fatalf("verify token fail: %v", err)
+		}
+		if identity.Subject != "alice@example.com" || !reflect.DeepEqual(identity.Groups, []string{"dev", "ops"}) {
+			t.Errorf("unexpected identity: %v groups %v", identity, identity.Groups)
+		}
+		f := &Federation{userPrefix: "sso_"}
+		if userID, err := f.UserID(identity); err != nil || userID != "sso_alice_example_com" {
+			t.Errorf("unexpected user id: %v err(%v)", userID, err)
+		}
+	}
+
+	var invalidTokens = map[string]string{
+		"expired": signTestToken(t, "RS256", "rsa", rsaKey, claims(func(c map[string]interface{}) {
+			c["exp"] = time.Now().Add(-time.Hour).Unix()
+		})),
+		"audience": signTestToken(t, "RS256", "rsa", rsaKey, claims(func(c map[string]interface{}) {
+			c["aud"] = "other"
+		})),
+		"issuer": signTestToken(t, "RS256", "rsa", rsaKey, claims(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example.com"
+		})),
+		"key mismatch": signTestToken(t, "RS256", "ec", rsaKey, claims(nil)),
+		"unknown key":  signTestToken(t, "RS256", "unknown", rsaKey, claims(nil)),
+		"none":         signTestToken(t, "none", "rsa", rsaKey, claims(nil)),
+	}
+	for name, token := range invalidTokens {
+		if _, err = p.VerifyToken(token); err == nil {
+			t.Errorf("expect %v token rejected", name)
+		}
+	}
+	if _, err = p.VerifyToken(invalidTokens["expired"]); err != ErrTokenExpired {
+		t.Errorf("expect expired token, real(%v)", err)
+	}
+	if _, err = p.VerifyToken(invalidTokens["audience"]); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expect invalid token, real(%v)", err)
+	}
+}
@@ -0,0 +1,327 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package federation
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderLDAP = "ldap"
+
+	defaultLDAPTimeout        = 10 * time.Second
+	defaultLDAPUserAttribute  = "uid"
+	defaultLDAPGroupAttribute = "memberOf"
+
+	ldapVersion = 3
+
+	ldapOpBindRequest       = 0
+	ldapOpBindResponse      = 1
+	ldapOpUnbindRequest     = 2
+	ldapOpSearchRequest     = 3
+	ldapOpSearchResultEntry = 4
+	ldapOpSearchResultDone  = 5
+
+	ldapFilterEqualityMatch = 3
+	ldapFilterPresent       = 7
+
+	ldapScopeBaseObject   = 0
+	ldapScopeWholeSubtree = 2
+
+	ldapResultSuccess            = 0
+	ldapResultInvalidCredentials = 49
+)
+
+// LDAPConfig is the config of the LDAP identity provider. The user is bound with the DN
+// formatted by UserDNPattern, or with the DN found by searching UserAttribute under BaseDN
+// with the service account BindDN if the pattern is not specified.
+type LDAPConfig struct {
+	URL                string
+	UserDNPattern      string
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	UserAttribute      string
+	GroupAttribute     string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+}
+
+// LDAPProvider authenticates the users by binding to the LDAP server with their passwords.
+type LDAPProvider struct {
+	config LDAPConfig
+}
+
+func NewLDAPProvider(config LDAPConfig) (p *LDAPProvider, err error) {
+	if _, err = url.Parse(config.URL); err != nil || config.URL == "" {
+		return nil, fmt.Errorf("invalid ldap url: %v", config.URL)
+	}
+	if config.UserDNPattern == "" && config.BaseDN == "" {
+		return nil, fmt.Errorf("either the user dn pattern or the base dn of ldap is required")
+	}
+	if config.UserAttribute == "" {
+		config.UserAttribute = defaultLDAPUserAttribute
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = defaultLDAPGroupAttribute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultLDAPTimeout
+	}
+	return &LDAPProvider{config: config}, nil
+}
+
+// Authenticate binds the user with the password, and returns the identity with the
+// groups in the group attribute of the user entry.
+func (p *LDAPProvider) Authenticate(username, password string) (identity *Identity, err error) {
+	// An empty password makes an unauthenticated bind which always succeeds.
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	var conn *ldapConn
+	if conn, err = p.dial(); err != nil {
+		return
+	}
+	defer conn.close()
+
+	var userDN string
+	var entries []*ldapEntry
+	if p.config.UserDNPattern != "" {
+		userDN = fmt.Sprintf(p.config.UserDNPattern, escapeDN(username))
+		if err = conn.bind(userDN, password); err != nil {
+			return
+		}
+		entries, err = conn.search(userDN, ldapScopeBaseObject, presentFilter("objectClass"), p.config.GroupAttribute)
+	} else {
+		if err = conn.bind(p.config.BindDN, p.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("bind service account fail: %v", err)
+		}
+		if entries, err = conn.search(p.config.BaseDN, ldapScopeWholeSubtree,
+			equalityFilter(p.config.UserAttribute, username), p.config.GroupAttribute); err != nil {
+			return
+		}
+		if len(entries) != 1 {
+			return nil, ErrInvalidCredentials
+		}
+		userDN = entries[0].dn
+		err = conn.bind(userDN, password)
+	}
+	if err != nil {
+		return
+	}
+	identity = &Identity{Provider: ProviderLDAP, Subject: username}
+	for _, entry := range entries {
+		for _, value := range entry.attributes[strings.ToLower(p.config.GroupAttribute)] {
+			identity.Groups = append(identity.Groups, groupNameOfDN(value))
+		}
+	}
+	return
+}
+
+func (p *LDAPProvider) dial() (conn *ldapConn, err error) {
+	var u *url.URL
+	if u, err = url.Parse(p.config.URL); err != nil {
+		return
+	}
+	var dialer = &net.Dialer{Timeout: p.config.Timeout}
+	var c net.Conn
+	switch u.Scheme {
+	case "ldaps":
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		c, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: p.config.InsecureSkipVerify,
+		})
+	case "ldap":
+		host := u.Host
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		c, err = dialer.Dial("tcp", host)
+	default:
+		err = fmt.Errorf("unsupported ldap scheme: %v", u.Scheme)
+	}
+	if err != nil {
+		return
+	}
+	_ = c.SetDeadline(time.Now().Add(p.config.Timeout))
+	return &ldapConn{conn: c, reader: bufio.NewReader(c)}, nil
+}
+
+type ldapEntry struct {
+	dn         string
+	attributes map[string][]string // the attribute names are in lower case
+}
+
+type ldapConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	messageID int64
+}
+
+func (c *ldapConn) send(op *berPacket) (err error) {
+	c.messageID++
+	message := newBERCompound(berClassUniversal, berTagSequence, newBERInteger(berTagInteger, c.messageID), op)
+	_, err = c.conn.Write(message.bytes())
+	return
+}
+
+func (c *ldapConn) receive() (op *berPacket, err error) {
+	var message *berPacket
+	if message, err = readBERPacket(c.reader); err != nil {
+		return
+	}
+	if len(message.children) < 2 || message.children[0].int() != c.messageID {
+		return nil, errBERMalformed
+	}
+	return message.children[1], nil
+}
+
+func (c *ldapConn) bind(dn, password string) (err error) {
+	if err = c.send(newBERCompound(berClassApplication, ldapOpBindRequest,
+		newBERInteger(berTagInteger, ldapVersion),
+		newBERString(dn),
+		newBERPrimitive(berClassContext, 0, []byte(password)))); err != nil {
+		return
+	}
+	var op *berPacket
+	if op, err = c.receive(); err != nil {
+		return
+	}
+	if !op.is(berClassApplication, ldapOpBindResponse) {
+		return errBERMalformed
+	}
+	return ldapResultError(op)
+}
+
+func (c *ldapConn) search(baseDN string, scope int64, filter *berPacket, attributes ...string) (entries []*ldapEntry, err error) {
+	var attributeList = newBERCompound(berClassUniversal, berTagSequence)
+	for _, attribute := range attributes {
+		attributeList.children = append(attributeList.children, newBERString(attribute))
+	}
+	if err = c.send(newBERCompound(berClassApplication, ldapOpSearchRequest,
+		newBERString(baseDN),
+		newBERInteger(berTagEnumerated, scope),
+		newBERInteger(berTagEnumerated, 0),
+		newBERInteger(berTagInteger, 0),
+		newBERInteger(berTagInteger, 0),
+		newBERBoolean(false),
+		filter,
+		attributeList)); err != nil {
+		return
+	}
+	for {
+		var op *berPacket
+		if op, err = c.receive(); err != nil {
+			return
+		}
+		switch {
+		case op.is(berClassApplication, ldapOpSearchResultEntry):
+			var entry *ldapEntry
+			if entry, err = parseLDAPEntry(op); err != nil {
+				return
+			}
+			entries = append(entries, entry)
+		case op.is(berClassApplication, ldapOpSearchResultDone):
+			err = ldapResultError(op)
+			return
+		}
+	}
+}
+
+func (c *ldapConn) close() {
+	_ = c.send(newBERPrimitive(berClassApplication, ldapOpUnbindRequest, nil))
+	_ = c.conn.Close()
+}
+
+func parseLDAPEntry(op *berPacket) (entry *ldapEntry, err error) {
+	if len(op.children) < 2 {
+		return nil, errBERMalformed
+	}
+	entry = &ldapEntry{dn: string(op.children[0].value), attributes: make(map[string][]string)}
+	for _, attribute := range op.children[1].children {
+		if len(attribute.children) < 2 {
+			return nil, errBERMalformed
+		}
+		name := strings.ToLower(string(attribute.children[0].value))
+		for _, value := range attribute.children[1].children {
+			entry.attributes[name] = append(entry.attributes[name], string(value.value))
+		}
+	}
+	return
+}
+
+func ldapResultError(op *berPacket) error {
+	if len(op.children) < 3 {
+		return errBERMalformed
+	}
+	switch code := op.children[0].int(); code {
+	case ldapResultSuccess:
+		return nil
+	case ldapResultInvalidCredentials:
+		return ErrInvalidCredentials
+	default:
+		return fmt.Errorf("ldap result code %v: %v", code, string(op.children[2].value))
+	}
+}
+
+func equalityFilter(attribute, value string) *berPacket {
+	return newBERCompound(berClassContext, ldapFilterEqualityMatch, newBERString(attribute), newBERString(value))
+}
+
+func presentFilter(attribute string) *berPacket {
+	return newBERPrimitive(berClassContext, ldapFilterPresent, []byte(attribute))
+}
+
+// escapeDN escapes the special characters of an attribute value in a DN, see RFC 4514.
+func escapeDN(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case strings.IndexByte(",+\"\\<>;=", c) >= 0,
+			(c == ' ' || c == '#') && i == 0,
+			c == ' ' && i == len(value)-1:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == 0:
+			b.WriteString("\\00")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// groupNameOfDN returns the value of the first RDN of the group DN, such as "dev" of "cn=dev,ou=groups,dc=example".
+func groupNameOfDN(dn string) string {
+	rdn := dn
+	if i := strings.IndexByte(dn, ','); i >= 0 {
+		rdn = dn[:i]
+	}
+	if i := strings.IndexByte(rdn, '='); i >= 0 {
+		return strings.TrimSpace(rdn[i+1:])
+	}
+	return strings.TrimSpace(rdn)
+}
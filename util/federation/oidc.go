@@ -0,0 +1,452 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package federation
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ProviderOIDC = "oidc"
+
+	defaultOIDCTimeout       = 10 * time.Second
+	defaultOIDCUsernameClaim = "sub"
+	defaultOIDCGroupsClaim   = "groups"
+	defaultOIDCScopes        = "openid profile"
+
+	oidcDiscoveryPath      = "/.well-known/openid-configuration"
+	oidcClockSkew          = time.Minute
+	oidcKeyRefreshInterval = time.Minute
+	oidcResponseLimitSize  = 1024 * 1024
+)
+
+// OIDCConfig is the config of the OpenID Connect identity provider. The endpoints and the
+// key set are discovered from the issuer unless they are specified, a static key set can be
+// loaded from JWKSFile for the providers which are not reachable.
+type OIDCConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string
+	AuthURL       string
+	TokenURL      string
+	JWKSURL       string
+	JWKSFile      string
+	UsernameClaim string
+	GroupsClaim   string
+	Scopes        string
+	Timeout       time.Duration
+}
+
+// OIDCProvider verifies the ID tokens issued by the OpenID Connect provider, and exchanges
+// the authorization codes of the single sign-on for the ID tokens.
+type OIDCProvider struct {
+	config     OIDCConfig
+	client     *http.Client
+	mu         sync.RWMutex
+	keys       map[string]crypto.PublicKey
+	refreshed  time.Time
+	discovered bool
+}
+
+func NewOIDCProvider(config OIDCConfig) (p *OIDCProvider, err error) {
+	if config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("issuer and client id of oidc are required")
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = defaultOIDCUsernameClaim
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = defaultOIDCGroupsClaim
+	}
+	if config.Scopes == "" {
+		config.Scopes = defaultOIDCScopes
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultOIDCTimeout
+	}
+	p = &OIDCProvider{config: config, client: &http.Client{Timeout: config.Timeout}}
+	if config.JWKSFile != "" {
+		var data []byte
+		if data, err = ioutil.ReadFile(config.JWKSFile); err != nil {
+			return nil, err
+		}
+		if p.keys, err = parseJWKS(data); err != nil {
+			return nil, fmt.Errorf("load oidc key set fail: %v", err)
+		}
+	}
+	return
+}
+
+func (p *OIDCProvider) Issuer() string {
+	return p.config.Issuer
+}
+
+func (p *OIDCProvider) ClientID() string {
+	return p.config.ClientID
+}
+
+// VerifyToken verifies the signature and the claims of the ID token, and returns the identity of it.
+func (p *OIDCProvider) VerifyToken(token string) (identity *Identity, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err = decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	var signature []byte
+	if signature, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return nil, ErrInvalidToken
+	}
+	var key crypto.PublicKey
+	if key, err = p.getKey(header.KeyID); err != nil {
+		return
+	}
+	if err = verifyJWTSignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return
+	}
+	var claims map[string]interface{}
+	if err = decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err = p.checkClaims(claims, time.Now()); err != nil {
+		return
+	}
+	identity = &Identity{Provider: ProviderOIDC}
+	if identity.Subject, _ = claims[p.config.UsernameClaim].(string); identity.Subject == "" {
+		return nil, fmt.Errorf("%w: claim %v not found", ErrInvalidToken, p.config.UsernameClaim)
+	}
+	switch groups := claims[p.config.GroupsClaim].(type) {
+	case []interface{}:
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				identity.Groups = append(identity.Groups, name)
+			}
+		}
+	case string:
+		identity.Groups = append(identity.Groups, groups)
+	}
+	return
+}
+
+func (p *OIDCProvider) checkClaims(claims map[string]interface{}, now time.Time) error {
+	if issuer, _ := claims["iss"].(string); issuer != p.config.Issuer {
+		return fmt.Errorf("%w: unexpected issuer %v", ErrInvalidToken, issuer)
+	}
+	var audienceMatched bool
+	switch audience := claims["aud"].(type) {
+	case string:
+		audienceMatched = audience == p.config.ClientID
+	case []interface{}:
+		for _, value := range audience {
+			if value == p.config.ClientID {
+				audienceMatched = true
+			}
+		}
+	}
+	if !audienceMatched {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	expiration, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(expiration), 0).Add(oidcClockSkew)) {
+		return ErrTokenExpired
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(notBefore), 0)) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+	}
+	return nil
+}
+
+// getKey returns the key to verify the token, the key set is refreshed at most once a minute
+// if the key is not found, which happens after the keys are rotated by the provider.
+func (p *OIDCProvider) getKey(keyID string) (key crypto.PublicKey, err error) {
+	if key = p.lookupKey(keyID); key != nil {
+		return
+	}
+	if p.config.JWKSFile != "" {
+		return nil, fmt.Errorf("%w: key %v not found", ErrInvalidToken, keyID)
+	}
+	p.mu.Lock()
+	if time.Since(p.refreshed) < oidcKeyRefreshInterval {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: key %v not found", ErrInvalidToken, keyID)
+	}
+	p.refreshed = time.Now()
+	p.mu.Unlock()
+
+	if err = p.discover(); err != nil {
+		return
+	}
+	var data []byte
+	if data, err = p.get(p.config.JWKSURL); err != nil {
+		return
+	}
+	var keys map[string]crypto.PublicKey
+	if keys, err = parseJWKS(data); err != nil {
+		return
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	if key = p.lookupKey(keyID); key == nil {
+		return nil, fmt.Errorf("%w: key %v not found", ErrInvalidToken, keyID)
+	}
+	return
+}
+
+func (p *OIDCProvider) lookupKey(keyID string) crypto.PublicKey {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if key, ok := p.keys[keyID]; ok {
+		return key
+	}
+	// the key id is optional if the key set has only one key
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// discover fills the endpoints which are not specified with the provider metadata of the issuer.
+func (p *OIDCProvider) discover() (err error) {
+	p.mu.RLock()
+	discovered := p.discovered || (p.config.JWKSURL != "" && p.config.AuthURL != "" && p.config.TokenURL != "")
+	p.mu.RUnlock()
+	if discovered {
+		return
+	}
+	var data []byte
+	if data, err = p.get(strings.TrimSuffix(p.config.Issuer, "/") + oidcDiscoveryPath); err != nil {
+		return
+	}
+	var metadata struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+	if err = json.Unmarshal(data, &metadata); err != nil {
+		return
+	}
+	if metadata.Issuer != p.config.Issuer {
+		return fmt.Errorf("oidc issuer mismatch: %v", metadata.Issuer)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.config.AuthURL == "" {
+		p.config.AuthURL = metadata.AuthorizationEndpoint
+	}
+	if p.config.TokenURL == "" {
+		p.config.TokenURL = metadata.TokenEndpoint
+	}
+	if p.config.JWKSURL == "" {
+		p.config.JWKSURL = metadata.JWKSURI
+	}
+	p.discovered = true
+	return
+}
+
+// AuthCodeURL returns the URL of the provider to redirect the browser to sign in.
+func (p *OIDCProvider) AuthCodeURL(state string) (authURL string, err error) {
+	if err = p.discover(); err != nil {
+		return
+	}
+	values := url.Values{}
+	values.Set("response_type", "code")
+	values.Set("client_id", p.config.ClientID)
+	values.Set("redirect_uri", p.config.RedirectURL)
+	values.Set("scope", p.config.Scopes)
+	values.Set("state", state)
+	separator := "?"
+	if strings.Contains(p.config.AuthURL, "?") {
+		separator = "&"
+	}
+	return p.config.AuthURL + separator + values.Encode(), nil
+}
+
+// Exchange exchanges the authorization code for the ID token, and returns the identity of it.
+func (p *OIDCProvider) Exchange(code string) (identity *Identity, err error) {
+	if err = p.discover(); err != nil {
+		return
+	}
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("code", code)
+	values.Set("redirect_uri", p.config.RedirectURL)
+	values.Set("client_id", p.config.ClientID)
+	values.Set("client_secret", p.config.ClientSecret)
+	var resp *http.Response
+	if resp, err = p.client.PostForm(p.config.TokenURL, values); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	var data []byte
+	if data, err = ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, oidcResponseLimitSize)); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange authorization code fail: status(%v) body(%v)", resp.StatusCode, string(data))
+	}
+	var result struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = json.Unmarshal(data, &result); err != nil {
+		return
+	}
+	if result.IDToken == "" {
+		return nil, errors.New("no id token in the token response")
+	}
+	return p.VerifyToken(result.IDToken)
+}
+
+func (p *OIDCProvider) get(url string) (data []byte, err error) {
+	var resp *http.Response
+	if resp, err = p.client.Get(url); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %v fail: status(%v)", url, resp.StatusCode)
+	}
+	return ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, oidcResponseLimitSize))
+}
+
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifyJWTSignature verifies the signature with the RSA or ECDSA key, the symmetric
+// algorithms and "none" are rejected since the key set is public.
+func verifyJWTSignature(algorithm string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch algorithm {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %v", ErrInvalidToken, algorithm)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if algorithm[0] != 'R' || rsa.VerifyPKCS1v15(k, hash, digest, signature) != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if algorithm[0] != 'E' || len(signature) != 2*size {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported key", ErrInvalidToken)
+	}
+	return nil
+}
+
+// parseJWKS parses the RSA and EC keys for signature in the JSON web key set.
+func parseJWKS(data []byte) (keys map[string]crypto.PublicKey, err error) {
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+			Curve   string `json:"crv"`
+			X       string `json:"x"`
+			Y       string `json:"y"`
+		} `json:"keys"`
+	}
+	if err = json.Unmarshal(data, &set); err != nil {
+		return
+	}
+	keys = make(map[string]crypto.PublicKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.KeyType {
+		case "RSA":
+			n, e := decodeBigInt(k.N), decodeBigInt(k.E)
+			if n == nil || e == nil || !e.IsInt64() {
+				return nil, fmt.Errorf("invalid rsa key %v", k.KeyID)
+			}
+			keys[k.KeyID] = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Curve {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				return nil, fmt.Errorf("unsupported curve %v of key %v", k.Curve, k.KeyID)
+			}
+			x, y := decodeBigInt(k.X), decodeBigInt(k.Y)
+			if x == nil || y == nil || !curve.IsOnCurve(x, y) {
+				return nil, fmt.Errorf("invalid ec key %v", k.KeyID)
+			}
+			keys[k.KeyID] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		}
+	}
+	return
+}
+
+func decodeBigInt(value string) *big.Int {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return new(big.Int).SetBytes(data)
+}
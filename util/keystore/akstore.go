@@ -8,9 +8,32 @@ import (
 	"github.com/cubefs/cubefs/util/caps"
 )
 
+const (
+	AccessKeyStatusActive   = "active"
+	AccessKeyStatusInactive = "inactive"
+)
+
+// AccessKeyMeta is the metadata of a named access key, an owner may hold several access keys
+// to rotate them without downtime. The records without status are active.
+type AccessKeyMeta struct {
+	Name         string `json:"name,omitempty"`
+	Status       string `json:"status,omitempty"`
+	CreateTime   string `json:"create_time,omitempty"`
+	LastUsedTime int64  `json:"last_used_time,omitempty"`
+}
+
+func (m *AccessKeyMeta) IsActive() bool {
+	return m.Status != AccessKeyStatusInactive
+}
+
+func IsValidAccessKeyStatus(status string) bool {
+	return status == AccessKeyStatusActive || status == AccessKeyStatusInactive
+}
+
 type AccessKeyInfo struct {
 	AccessKey string `json:"access_key"`
 	ID        string `json:"id"`
+	AccessKeyMeta
 }
 
 type AccessKeyCaps struct {
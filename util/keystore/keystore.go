@@ -6,10 +6,16 @@ import (
 	"io"
 	"os"
 	"regexp"
+	"time"
 
 	"github.com/cubefs/cubefs/util/caps"
 )
 
+const (
+	// DefaultKeyGrace is the default seconds the previous key is accepted after a key rotation
+	DefaultKeyGrace = 24 * 60 * 60
+)
+
 var roleSet = map[string]bool{
 	"client":  true,
 	"service": true,
@@ -17,13 +23,61 @@ var roleSet = map[string]bool{
 
 // KeyInfo defines the key info structure in key store
 type KeyInfo struct {
-	ID        string `json:"id"`
-	AuthKey   []byte `json:"auth_key"`
-	AccessKey string `json:"access_key"`
-	SecretKey string `json:"secret_key"`
-	Ts        int64  `json:"create_ts"`
-	Role      string `json:"role"`
-	Caps      []byte `json:"caps"`
+	ID         string          `json:"id"`
+	AuthKey    []byte          `json:"auth_key"`
+	AccessKey  string          `json:"access_key"`
+	SecretKey  string          `json:"secret_key"`
+	Ts         int64           `json:"create_ts"`
+	Role       string          `json:"role"`
+	Caps       []byte          `json:"caps"`
+	KeyVersion uint32          `json:"key_version"`
+	OldKeys    []*VersionedKey `json:"old_keys,omitempty"`
+	// version of the root key sealing the keys in the keystore, 0 if not sealed
+	RootKeyVersion uint32 `json:"root_key_version,omitempty"`
+}
+
+// VersionedKey defines a previous key which is accepted until it expires
+type VersionedKey struct {
+	Version  uint32 `json:"version"`
+	AuthKey  []byte `json:"auth_key"`
+	ExpireTs int64  `json:"expire_ts"`
+}
+
+// Rotate replaces the key with a new one, the current key is still accepted for grace seconds.
+func (u *KeyInfo) Rotate(newKey []byte, ts int64, grace int64) {
+	oldKeys := make([]*VersionedKey, 0, len(u.OldKeys)+1)
+	oldKeys = append(oldKeys, &VersionedKey{Version: u.KeyVersion, AuthKey: u.AuthKey, ExpireTs: ts + grace})
+	for _, k := range u.OldKeys {
+		if k.ExpireTs > ts {
+			oldKeys = append(oldKeys, k)
+		}
+	}
+	u.OldKeys = oldKeys
+	u.AuthKey = newKey
+	u.Ts = ts
+	u.KeyVersion++
+}
+
+// AcceptedKeys returns the current key followed by the previous keys not expired yet.
+func (u *KeyInfo) AcceptedKeys() (keys [][]byte) {
+	now := time.Now().Unix()
+	keys = append(keys, u.AuthKey)
+	for _, k := range u.OldKeys {
+		if k.ExpireTs > now {
+			keys = append(keys, k.AuthKey)
+		}
+	}
+	return
+}
+
+// ActiveKey returns the key to seal the tickets of a service. The previous key of a service
+// stays active during the grace window, so that the service can be configured with the new
+// key in the meantime.
+func (u *KeyInfo) ActiveKey() []byte {
+	if u.Role == "service" && len(u.OldKeys) > 0 && u.OldKeys[0].ExpireTs > time.Now().Unix() {
+		return u.OldKeys[0].AuthKey
+	}
+	return u.AuthKey
 }
 
 // DumpJSONFile dump KeyInfo to file in json format
@@ -51,13 +105,14 @@ func (u *KeyInfo) DumpJSONFile(filename string) (err error) {
 // DumpJSONStr dump KeyInfo to string in json format
 func (u *KeyInfo) DumpJSONStr() (r string, err error) {
 	dumpInfo := struct {
-		ID        string `json:"id"`
-		AuthKey   []byte `json:"auth_key"`
-		AccessKey string `json:"access_key"`
-		SecretKey string `json:"secret_key"`
-		Ts        int64  `json:"create_ts"`
-		Role      string `json:"role"`
-		Caps      string `json:"caps"`
+		ID         string `json:"id"`
+		AuthKey    []byte `json:"auth_key"`
+		AccessKey  string `json:"access_key"`
+		SecretKey  string `json:"secret_key"`
+		Ts         int64  `json:"create_ts"`
+		Role       string `json:"role"`
+		Caps       string `json:"caps"`
+		KeyVersion uint32 `json:"key_version"`
 	}{
 		u.ID,
 		u.AuthKey,
@@ -66,6 +121,7 @@ func (u *KeyInfo) DumpJSONStr() (r string, err error) {
 		u.Ts,
 		u.Role,
 		string(u.Caps),
+		u.KeyVersion,
 	}
 	data, err := json.MarshalIndent(dumpInfo, "", "  ")
 	if err != nil {
@@ -0,0 +1,159 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/cryptoutil"
+)
+
+func testKey(id string) []byte {
+	return cryptoutil.GenSecretKey([]byte("keystore-test"), 0, id)
+}
+
+func TestRotate(t *testing.T) {
+	now := time.Now().Unix()
+	k := &KeyInfo{ID: "client1", AuthKey: testKey("v0"), Role: "client"}
+	k.Rotate(testKey("v1"), now, 100)
+	if k.KeyVersion != 1 || !bytes.Equal(k.AuthKey, testKey("v1")) {
+		t.Fatalf("unexpected key version %v after rotation", k.KeyVersion)
+	}
+	keys := k.AcceptedKeys()
+	if len(keys) != 2 || !bytes.Equal(keys[0], testKey("v1")) || !bytes.Equal(keys[1], testKey("v0")) {
+		t.Fatalf("expect the new and previous keys accepted, got %v keys", len(keys))
+	}
+	if !bytes.Equal(k.ActiveKey(), testKey("v1")) {
+		t.Fatalf("the new key of a client should be active")
+	}
+
+	// the expired keys are pruned by the next rotation
+	k.OldKeys[0].ExpireTs = now - 1
+	if keys = k.AcceptedKeys(); len(keys) != 1 {
+		t.Fatalf("expect the expired key rejected, got %v keys", len(keys))
+	}
+	k.Rotate(testKey("v2"), now, 100)
+	if k.KeyVersion != 2 || len(k.OldKeys) != 1 || k.OldKeys[0].Version != 1 {
+		t.Fatalf("unexpected old keys %v after rotation", len(k.OldKeys))
+	}
+}
+
+func TestActiveKeyOfService(t *testing.T) {
+	now := time.Now().Unix()
+	k := &KeyInfo{ID: "MetaService", AuthKey: testKey("v0"), Role: "service"}
+	k.Rotate(testKey("v1"), now, 100)
+	if !bytes.Equal(k.ActiveKey(), testKey("v0")) {
+		t.Fatalf("the previous key of a service should be active within the grace window")
+	}
+	k.OldKeys[0].ExpireTs = now - 1
+	if !bytes.Equal(k.ActiveKey(), testKey("v1")) {
+		t.Fatalf("the new key of a service should be active after the grace window")
+	}
+}
+
+func TestLocalKeyWrapper(t *testing.T) {
+	w := NewLocalKeyWrapper(testKey("kek"))
+	wrapped, err := w.Wrap(testKey("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(wrapped, testKey("root")) {
+		t.Fatalf("the key is not wrapped")
+	}
+	key, err := w.Unwrap(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, testKey("root")) {
+		t.Fatalf("unwrapped key mismatch")
+	}
+	if _, err = NewLocalKeyWrapper(testKey("other")).Unwrap(wrapped); err == nil {
+		t.Fatalf("unwrap with another key encryption key should fail")
+	}
+}
+
+func TestFileKeyWrapper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kek.json")
+	data := fmt.Sprintf(`{"id": "AuthService", "auth_key": "%s"}`, cryptoutil.Base64Encode(testKey("kek")))
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.LoadConfigString(fmt.Sprintf(`{"%s": "%s"}`, CfgKeyWrapperKeyFile, path))
+	w, err := NewKeyWrapper(FileKeyWrapperName, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := w.Wrap(testKey("root"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the file key wrapper is interchangeable with the local one with the same key
+	key, err := NewLocalKeyWrapper(testKey("kek")).Unwrap(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, testKey("root")) {
+		t.Fatalf("unwrapped key mismatch")
+	}
+
+	if _, err = NewKeyWrapper(FileKeyWrapperName, config.LoadConfigString("{}")); err == nil {
+		t.Fatalf("expect error without %s", CfgKeyWrapperKeyFile)
+	}
+	if _, err = NewKeyWrapper("hsm", cfg); err == nil {
+		t.Fatalf("expect error with an unregistered key wrapper")
+	}
+	RegisterKeyWrapper("hsm", func(cfg *config.Config) (KeyWrapper, error) {
+		return NewLocalKeyWrapper(testKey("hsm")), nil
+	})
+	if _, err = NewKeyWrapper("hsm", cfg); err != nil {
+		t.Fatalf("registered key wrapper: %v", err)
+	}
+}
+
+func TestAccessKeyMeta(t *testing.T) {
+	// the records persisted before the access keys are named are active
+	akInfo := new(AccessKeyInfo)
+	if err := json.Unmarshal([]byte(`{"access_key":"ak","id":"user1"}`), akInfo); err != nil {
+		t.Fatal(err)
+	}
+	if !akInfo.IsActive() || akInfo.Name != "" {
+		t.Fatalf("unexpected meta %+v of the old record", akInfo.AccessKeyMeta)
+	}
+
+	akInfo.AccessKeyMeta = AccessKeyMeta{Name: "rotate", Status: AccessKeyStatusInactive, LastUsedTime: 100}
+	data, err := json.Marshal(akInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(AccessKeyInfo)
+	if err = json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if *got != *akInfo || got.IsActive() {
+		t.Fatalf("access key info %+v, expect %+v", got, akInfo)
+	}
+	for _, status := range []string{AccessKeyStatusActive, AccessKeyStatusInactive, "", "disabled"} {
+		if valid := IsValidAccessKeyStatus(status); valid != (status == AccessKeyStatusActive || status == AccessKeyStatusInactive) {
+			t.Fatalf("status %q valid %v", status, valid)
+		}
+	}
+}
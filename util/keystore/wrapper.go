@@ -0,0 +1,109 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package keystore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/cryptoutil"
+)
+
+const (
+	// FileKeyWrapperName is the name of the key wrapper with the key encryption key in a local file
+	FileKeyWrapperName = "file"
+
+	// CfgKeyWrapperKeyFile is the config of the key file of the file key wrapper, in the format
+	// of the key files generated by cfs-authtool
+	CfgKeyWrapperKeyFile = "keyWrapperKeyFile"
+)
+
+// KeyWrapper wraps the root keys of the authnode with a key encryption key kept out of the
+// keystore, e.g. in an HSM, so that the root keys are never stored in clear.
+type KeyWrapper interface {
+	Wrap(key []byte) (wrapped []byte, err error)
+	Unwrap(wrapped []byte) (key []byte, err error)
+}
+
+// NewKeyWrapperFunc creates a key wrapper with the config of the authnode.
+type NewKeyWrapperFunc func(cfg *config.Config) (KeyWrapper, error)
+
+var (
+	keyWrappersMu sync.RWMutex
+	keyWrappers   = map[string]NewKeyWrapperFunc{
+		FileKeyWrapperName: newFileKeyWrapper,
+	}
+)
+
+// RegisterKeyWrapper registers a key wrapper implementation by name.
+func RegisterKeyWrapper(name string, newFunc NewKeyWrapperFunc) {
+	keyWrappersMu.Lock()
+	defer keyWrappersMu.Unlock()
+	keyWrappers[name] = newFunc
+}
+
+// NewKeyWrapper creates the key wrapper registered by name.
+func NewKeyWrapper(name string, cfg *config.Config) (KeyWrapper, error) {
+	keyWrappersMu.RLock()
+	newFunc, ok := keyWrappers[name]
+	keyWrappersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown key wrapper [%s]", name)
+	}
+	return newFunc(cfg)
+}
+
+type localKeyWrapper struct {
+	kek []byte
+}
+
+// NewLocalKeyWrapper returns the key wrapper with a local key encryption key.
+func NewLocalKeyWrapper(kek []byte) KeyWrapper {
+	return &localKeyWrapper{kek: kek}
+}
+
+func (w *localKeyWrapper) Wrap(key []byte) (wrapped []byte, err error) {
+	var message string
+	if message, err = cryptoutil.EncodeMessage(key, w.kek); err != nil {
+		return
+	}
+	return []byte(message), nil
+}
+
+func (w *localKeyWrapper) Unwrap(wrapped []byte) (key []byte, err error) {
+	if key, err = cryptoutil.DecodeMessage(string(wrapped), w.kek); err != nil {
+		err = fmt.Errorf("unwrap key failed: %v", err)
+	}
+	return
+}
+
+func newFileKeyWrapper(cfg *config.Config) (w KeyWrapper, err error) {
+	var (
+		keyFile *config.Config
+		kek     []byte
+	)
+	path := cfg.GetString(CfgKeyWrapperKeyFile)
+	if path == "" {
+		return nil, fmt.Errorf("%s is not configured", CfgKeyWrapperKeyFile)
+	}
+	if keyFile, err = config.LoadConfigFile(path); err != nil {
+		return
+	}
+	if kek, err = cryptoutil.Base64Decode(keyFile.GetString("auth_key")); err != nil || len(kek) == 0 {
+		return nil, fmt.Errorf("invalid key in %s", path)
+	}
+	return NewLocalKeyWrapper(kek), nil
+}
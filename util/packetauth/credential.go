@@ -0,0 +1,255 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package packetauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	authSDK "github.com/cubefs/cubefs/sdk/auth"
+	"github.com/cubefs/cubefs/util"
+	"github.com/cubefs/cubefs/util/auth"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/cryptoutil"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	// the authnode client of a service accessing the volumes on behalf of the users,
+	// e.g. the objectnode, libsdk, preload and fsck
+	ConfigKeyClientID    = "authClientID"
+	ConfigKeyClientKey   = "clientKey"
+	ConfigKeyTicketHost  = "ticketHost"
+	ConfigKeyEnableHTTPS = "enableHTTPS"
+	ConfigKeyCertFile    = "certFile"
+
+	// renew the tickets an hour before they expire
+	ticketRenewAhead = time.Hour
+)
+
+// Credential fetches the tickets that a client presents on its connections to the
+// metanodes and datanodes, and renews them before they expire. The credential of a node
+// holding the service key issues the service tickets by itself instead.
+type Credential struct {
+	clientID  string
+	clientKey string
+	ac        *authSDK.AuthClient
+
+	serviceID       string
+	serviceKey      []byte
+	ticketServiceID string // service ID of the tickets issued with the service key
+
+	mu      sync.Mutex
+	tickets map[string]*credentialTicket // key: service ID
+}
+
+type credentialTicket struct {
+	ticket     string
+	sessionKey []byte
+	renewTime  time.Time
+}
+
+// NewCredential returns the credential of the client with the key issued by the authnode.
+func NewCredential(clientID string, mess auth.TicketMess) *Credential {
+	return &Credential{
+		clientID:  clientID,
+		clientKey: mess.ClientKey,
+		ac:        authSDK.NewAuthClient(mess.TicketHosts, mess.EnableHTTPS, mess.CertFile),
+		tickets:   make(map[string]*credentialTicket),
+	}
+}
+
+// LoadCredential creates the credential with the authnode client of the config, nil is
+// returned if the client key is not configured.
+func LoadCredential(cfg *config.Config) (*Credential, error) {
+	mess := auth.TicketMess{
+		ClientKey:   cfg.GetString(ConfigKeyClientKey),
+		EnableHTTPS: cfg.GetBool(ConfigKeyEnableHTTPS),
+		CertFile:    cfg.GetString(ConfigKeyCertFile),
+	}
+	if mess.ClientKey == "" {
+		return nil, nil
+	}
+	clientID := cfg.GetString(ConfigKeyClientID)
+	if clientID == "" {
+		return nil, fmt.Errorf("%v is not configured", ConfigKeyClientID)
+	}
+	for _, host := range strings.Split(cfg.GetString(ConfigKeyTicketHost), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			mess.TicketHosts = append(mess.TicketHosts, host)
+		}
+	}
+	if len(mess.TicketHosts) == 0 {
+		return nil, fmt.Errorf("%v is not configured", ConfigKeyTicketHost)
+	}
+	return NewCredential(clientID, mess), nil
+}
+
+// NewServiceCredential returns the credential of a node which issues the service tickets
+// of the service with its key.
+func NewServiceCredential(nodeID, serviceID string, serviceKey []byte) *Credential {
+	return &Credential{
+		clientID:        nodeID,
+		serviceID:       serviceID,
+		serviceKey:      serviceKey,
+		ticketServiceID: proto.PeerServiceID(serviceID),
+		tickets:         make(map[string]*credentialTicket),
+	}
+}
+
+// NewMasterCredential returns the credential of the master which issues the master tickets
+// of the service with its key, presented with the admin commands.
+func NewMasterCredential(serviceID string, serviceKey []byte) *Credential {
+	c := NewServiceCredential(proto.MasterNode, serviceID, serviceKey)
+	c.ticketServiceID = proto.MasterTicketServiceID(serviceID)
+	return c
+}
+
+// Handshake returns the handshake which authenticates the new connections to the nodes of
+// the service, nil for a nil credential.
+func (c *Credential) Handshake(serviceID string) util.Handshake {
+	if c == nil {
+		return nil
+	}
+	return func(conn net.Conn) error {
+		return c.authenticate(conn, serviceID)
+	}
+}
+
+func (c *Credential) getTicket(serviceID string) (t *credentialTicket, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t = c.tickets[serviceID]; t != nil && time.Now().Before(t.renewTime) {
+		return
+	}
+	if c.serviceKey != nil {
+		if t, err = c.issueServiceTicket(serviceID); err != nil {
+			return
+		}
+		c.tickets[serviceID] = t
+		return
+	}
+	ticket, err := c.ac.API().GetTicket(c.clientID, c.clientKey, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("get ticket of %v from authnode failed: %v", serviceID, err)
+	}
+	t = &credentialTicket{
+		ticket:    ticket.Ticket,
+		renewTime: time.Now().Add(cryptoutil.TicketAge*time.Second - ticketRenewAhead),
+	}
+	if t.sessionKey, err = cryptoutil.Base64Decode(ticket.SessionKey); err != nil {
+		return nil, err
+	}
+	c.tickets[serviceID] = t
+	return
+}
+
+// issueServiceTicket seals a ticket of the peers or the master of the service with the service key.
+func (c *Credential) issueServiceTicket(serviceID string) (t *credentialTicket, err error) {
+	if serviceID != c.serviceID {
+		return nil, fmt.Errorf("service key of %v can not issue tickets of %v", c.serviceID, serviceID)
+	}
+	now := time.Now()
+	ticket := cryptoutil.Ticket{
+		Version:   cryptoutil.TicketVersion,
+		ServiceID: c.ticketServiceID,
+		SessionKey: cryptoutil.CryptoKey{
+			Ctime: now.Unix(),
+			Key:   cryptoutil.AuthGenSessionKeyTS(c.serviceKey),
+		},
+		Exp: now.Unix() + cryptoutil.TicketAge,
+	}
+	var data []byte
+	if data, err = json.Marshal(ticket); err != nil {
+		return
+	}
+	t = &credentialTicket{
+		sessionKey: ticket.SessionKey.Key,
+		renewTime:  now.Add(cryptoutil.TicketAge*time.Second - ticketRenewAhead),
+	}
+	if t.ticket, err = cryptoutil.EncodeMessage(data, c.serviceKey); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// invalidate drops the ticket rejected by a node, so that the next connection fetches a new one.
+func (c *Credential) invalidate(serviceID string, t *credentialTicket) {
+	c.mu.Lock()
+	if c.tickets[serviceID] == t {
+		delete(c.tickets, serviceID)
+	}
+	c.mu.Unlock()
+}
+
+func (c *Credential) authenticate(conn net.Conn, serviceID string) (err error) {
+	var (
+		t         *credentialTicket
+		ts        int64
+		plaintext []byte
+		resp      proto.APIAccessResp
+	)
+	if t, err = c.getTicket(serviceID); err != nil {
+		return
+	}
+	req := proto.APIAccessReq{
+		Type:      proto.MsgDataTicketReq,
+		ClientID:  c.clientID,
+		ServiceID: serviceID,
+		Ticket:    t.ticket,
+	}
+	if serviceID == proto.MetaServiceID {
+		req.Type = proto.MsgMetaTicketReq
+	}
+	if req.Verifier, ts, err = cryptoutil.GenVerifier(t.sessionKey); err != nil {
+		return
+	}
+	p := proto.NewPacketReqID()
+	p.Opcode = proto.OpAuthenticate
+	if err = p.MarshalData(req); err != nil {
+		return
+	}
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	if err = p.ReadFromConn(conn, proto.ReadDeadlineTime); err != nil {
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+	switch {
+	case p.ResultCode == proto.OpNotPerm:
+		c.invalidate(serviceID, t)
+		return fmt.Errorf("authenticate to %v rejected: %v", conn.RemoteAddr(), string(p.Data[:p.Size]))
+	case p.ResultCode != proto.OpOk || p.Size == 0:
+		// the node does not enforce authentication
+		log.LogDebugf("authenticate: %v does not enforce authentication, result(%v)", conn.RemoteAddr(), p.GetResultMsg())
+		return nil
+	}
+	if plaintext, err = cryptoutil.DecodeMessage(string(p.Data[:p.Size]), t.sessionKey); err != nil {
+		return fmt.Errorf("authenticate to %v: invalid reply: %v", conn.RemoteAddr(), err)
+	}
+	if err = json.Unmarshal(plaintext, &resp); err != nil {
+		return
+	}
+	if err = proto.VerifyAPIRespComm(&resp, req.Type, c.clientID, serviceID, ts); err != nil {
+		return fmt.Errorf("authenticate to %v: %v", conn.RemoteAddr(), err)
+	}
+	return
+}
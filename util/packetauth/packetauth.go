@@ -0,0 +1,368 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package packetauth authenticates the packet connections from the clients to the
+// metanodes and datanodes with the tickets issued by the authnode. A client presents
+// its ticket once per connection with OpAuthenticate, and the node authorizes every
+// request to a volume with authentication enabled against the capabilities of the ticket.
+// The nodes talking to each other present the service tickets sealed by the service key
+// they hold, which are trusted for all the volumes.
+package packetauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/sdk/master"
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/cryptoutil"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ConfigKeyDataServiceKey = "dataServiceKey"
+	ConfigKeyMetaServiceKey = "metaServiceKey"
+
+	volumePolicyExpiration = 2 * time.Minute
+)
+
+var (
+	ErrNotAuthenticated     = errors.New("connection is not authenticated")
+	ErrNotMaster            = errors.New("connection is not authenticated by the master or the peers")
+	ErrReplayedAuthenticate = errors.New("authenticate request is replayed")
+)
+
+// Authenticator verifies the tickets presented on the packet connections of a
+// metanode or datanode, and authorizes the requests to the volumes with
+// authentication enabled. A nil Authenticator accepts every request.
+type Authenticator struct {
+	serviceID  string
+	accessNode string
+	keys       [][]byte
+
+	// returns whether authentication is enabled on the volume
+	volumeAuthenticate func(volName string) (bool, error)
+
+	mu       sync.RWMutex
+	policies map[string]*volumePolicy
+
+	replays replayCache // verifiers of the accepted authenticate requests
+}
+
+// replayCache remembers the verifiers accepted within their live time, so that a
+// captured authenticate request can not be replayed on another connection.
+type replayCache struct {
+	mu        sync.Mutex
+	verifiers map[string]int64 // verifier -> unix time it expires
+	sweepTime int64
+}
+
+func (rc *replayCache) check(verifier string, ts int64) error {
+	now := time.Now().Unix()
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.verifiers == nil {
+		rc.verifiers = make(map[string]int64)
+	}
+	if now >= rc.sweepTime {
+		for v, expire := range rc.verifiers {
+			if expire <= now {
+				delete(rc.verifiers, v)
+			}
+		}
+		rc.sweepTime = now + proto.ReqLiveLength
+	}
+	if _, ok := rc.verifiers[verifier]; ok {
+		return ErrReplayedAuthenticate
+	}
+	// the verifier is rejected by its timestamp after it expires
+	rc.verifiers[verifier] = ts + proto.ReqLiveLength
+	return nil
+}
+
+type volumePolicy struct {
+	authenticate bool
+	updateTime   time.Time
+	refreshing   int32
+}
+
+// NewAuthenticator returns the authenticator of the service with its secret keys, the
+// current one first, the volume policies are fetched from the master.
+func NewAuthenticator(serviceID string, keys [][]byte, mc *master.MasterClient) (a *Authenticator, err error) {
+	a = &Authenticator{
+		serviceID: serviceID,
+		keys:      keys,
+		policies:  make(map[string]*volumePolicy),
+	}
+	switch serviceID {
+	case proto.DataServiceID:
+		a.accessNode = proto.DataNode
+	case proto.MetaServiceID:
+		a.accessNode = proto.MetaNode
+	default:
+		return nil, fmt.Errorf("invalid service ID [%s]", serviceID)
+	}
+	a.volumeAuthenticate = func(volName string) (bool, error) {
+		view, err := mc.AdminAPI().GetVolumeSimpleInfo(volName)
+		if err != nil {
+			return false, err
+		}
+		return view.Authenticate, nil
+	}
+	return
+}
+
+// LoadAuthenticator creates the authenticator with the service keys of the config,
+// nil is returned if the key is not configured. The previous key may follow the
+// current one, separated by a comma, while the service key is being rotated.
+func LoadAuthenticator(cfg *config.Config, keyName, serviceID string, mc *master.MasterClient) (*Authenticator, error) {
+	encoded := cfg.GetString(keyName)
+	if encoded == "" {
+		return nil, nil
+	}
+	keys, err := cryptoutil.Base64DecodeKeys(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v: %v", keyName, err)
+	}
+	return NewAuthenticator(serviceID, keys, mc)
+}
+
+// LoadServiceCredential creates the credential of the node to present the service tickets
+// to the nodes of another service with the service key of the config, e.g. the metanodes
+// deleting extents on the datanodes. Nil is returned if the key is not configured.
+func LoadServiceCredential(cfg *config.Config, keyName, serviceID, nodeID string) (*Credential, error) {
+	key, err := loadServiceKey(cfg, keyName)
+	if key == nil || err != nil {
+		return nil, err
+	}
+	return NewServiceCredential(nodeID, serviceID, key), nil
+}
+
+// LoadMasterCredential creates the credential of the master to present the master tickets
+// with the admin commands to the nodes of the service with the service key of the config.
+// Nil is returned if the key is not configured.
+func LoadMasterCredential(cfg *config.Config, keyName, serviceID string) (*Credential, error) {
+	key, err := loadServiceKey(cfg, keyName)
+	if key == nil || err != nil {
+		return nil, err
+	}
+	return NewMasterCredential(serviceID, key), nil
+}
+
+// loadServiceKey returns the current service key of the config, nil if not configured.
+func loadServiceKey(cfg *config.Config, keyName string) ([]byte, error) {
+	encoded := cfg.GetString(keyName)
+	if encoded == "" {
+		return nil, nil
+	}
+	keys, err := cryptoutil.Base64DecodeKeys(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v: %v", keyName, err)
+	}
+	return keys[0], nil
+}
+
+// Conn is a packet connection which keeps the session established by OpAuthenticate.
+type Conn struct {
+	net.Conn
+	session atomic.Value // *session
+}
+
+type session struct {
+	clientID string
+	ticket   cryptoutil.Ticket
+	peer     bool     // authenticated by a service ticket of a peer
+	master   bool     // authenticated by a master ticket
+	volumes  sync.Map // volume name -> whether allowed by the caps
+}
+
+// NewConn wraps an accepted connection so that it can be authenticated.
+func (a *Authenticator) NewConn(c net.Conn) net.Conn {
+	if a == nil {
+		return c
+	}
+	return &Conn{Conn: c}
+}
+
+// Authenticate handles OpAuthenticate. The ticket carried by the packet is bound
+// to the connection, and the reply proves to the client that the node holds the
+// service key. The reply is packed into the packet.
+func (a *Authenticator) Authenticate(p *proto.Packet, c net.Conn) (err error) {
+	if a == nil {
+		// authentication is not enforced by this node
+		p.PacketOkReply()
+		return
+	}
+	defer func() {
+		if err != nil {
+			log.LogWarnf("action[Authenticate] remote(%v) err(%v)", c.RemoteAddr(), err)
+			p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
+		}
+	}()
+	conn, ok := c.(*Conn)
+	if !ok {
+		err = fmt.Errorf("connection from %v can not be authenticated", c.RemoteAddr())
+		return
+	}
+	var (
+		req     proto.APIAccessReq
+		ticket  cryptoutil.Ticket
+		ts      int64
+		data    []byte
+		message string
+	)
+	if err = json.Unmarshal(p.Data[:p.Size], &req); err != nil {
+		return
+	}
+	if ticket, ts, err = proto.ExtractPacketAuthTicket(&req, a.serviceID, a.keys...); err != nil {
+		return
+	}
+	if err = a.replays.check(req.Verifier, ts); err != nil {
+		return
+	}
+	resp := proto.APIAccessResp{
+		Type:      req.Type + 1,
+		ClientID:  req.ClientID,
+		ServiceID: req.ServiceID,
+		Verifier:  ts + 1, // increase ts by one for client verify server
+	}
+	if data, err = json.Marshal(resp); err != nil {
+		return
+	}
+	if message, err = cryptoutil.EncodeMessage(data, ticket.SessionKey.Key); err != nil {
+		return
+	}
+	peer := ticket.ServiceID == proto.PeerServiceID(a.serviceID)
+	master := ticket.ServiceID == proto.MasterTicketServiceID(a.serviceID)
+	conn.session.Store(&session{clientID: req.ClientID, ticket: ticket, peer: peer, master: master})
+	log.LogInfof("action[Authenticate] client(%v) remote(%v) peer(%v) master(%v) authenticated", req.ClientID, c.RemoteAddr(), peer, master)
+	p.PacketOkWithBody([]byte(message))
+	return
+}
+
+// Authorize checks whether a request to a partition of the volume is allowed on the
+// connection. The requests of the peers authenticated by the service tickets are trusted.
+func (a *Authenticator) Authorize(c net.Conn, volName string) (err error) {
+	if a == nil {
+		return
+	}
+	var required bool
+	if required, err = a.isRequired(volName); err != nil || !required {
+		return
+	}
+	var s *session
+	if s, err = sessionOf(c); err != nil {
+		return
+	}
+	if s.peer || s.master {
+		return nil
+	}
+	return s.authorize(volName, a.accessNode)
+}
+
+// AuthorizeMaster checks whether an admin command is allowed on the connection, which is
+// required to be authenticated by a master ticket whether the volume enables authentication or not.
+// The peers holding the service key are trusted too, as they forward the commands to the leaders.
+func (a *Authenticator) AuthorizeMaster(c net.Conn) (err error) {
+	if a == nil {
+		return
+	}
+	var s *session
+	if s, err = sessionOf(c); err != nil {
+		return
+	}
+	if !s.master && !s.peer {
+		log.LogWarnf("action[AuthorizeMaster] client(%v) remote(%v) is not the master", s.clientID, c.RemoteAddr())
+		return ErrNotMaster
+	}
+	return
+}
+
+// sessionOf returns the unexpired session authenticated on the connection.
+func sessionOf(c net.Conn) (s *session, err error) {
+	conn, ok := c.(*Conn)
+	if !ok {
+		return nil, ErrNotAuthenticated
+	}
+	if s, _ = conn.session.Load().(*session); s == nil {
+		return nil, ErrNotAuthenticated
+	}
+	if time.Now().Unix() >= s.ticket.Exp {
+		// close the connection so that the client dials again with a renewed ticket
+		_ = c.Close()
+		return nil, proto.ErrExpiredTicket
+	}
+	return
+}
+
+func (s *session) authorize(volName, accessNode string) error {
+	allowed, ok := s.volumes.Load(volName)
+	if !ok {
+		err := proto.CheckVOLAccessCaps(&s.ticket, volName, proto.VOLAccess, accessNode)
+		if err != nil {
+			log.LogWarnf("action[authorize] client(%v) vol(%v) err(%v)", s.clientID, volName, err)
+		}
+		allowed = err == nil
+		s.volumes.Store(volName, allowed)
+	}
+	if !allowed.(bool) {
+		return proto.ErrNoPermission
+	}
+	return nil
+}
+
+// isRequired returns whether authentication is enabled on the volume. An expired
+// policy is refreshed in the background while the cached one is still in use.
+func (a *Authenticator) isRequired(volName string) (bool, error) {
+	a.mu.RLock()
+	policy, ok := a.policies[volName]
+	a.mu.RUnlock()
+	if !ok {
+		return a.refreshPolicy(volName)
+	}
+	if time.Since(policy.updateTime) >= volumePolicyExpiration && atomic.CompareAndSwapInt32(&policy.refreshing, 0, 1) {
+		go func() {
+			if _, err := a.refreshPolicy(volName); err != nil {
+				atomic.StoreInt32(&policy.refreshing, 0)
+			}
+		}()
+	}
+	return policy.authenticate, nil
+}
+
+func (a *Authenticator) refreshPolicy(volName string) (authenticate bool, err error) {
+	if authenticate, err = a.volumeAuthenticate(volName); err != nil {
+		log.LogWarnf("action[refreshPolicy] vol(%v) err(%v)", volName, err)
+		return
+	}
+	a.mu.Lock()
+	a.policies[volName] = &volumePolicy{authenticate: authenticate, updateTime: time.Now()}
+	a.mu.Unlock()
+	return
+}
+
+// PeerCredential returns the credential with which the node presents the service tickets
+// to its peers, nil if authentication is not enforced by this node.
+func (a *Authenticator) PeerCredential(nodeID string) *Credential {
+	if a == nil {
+		return nil
+	}
+	return NewServiceCredential(nodeID, a.serviceID, a.keys[0])
+}
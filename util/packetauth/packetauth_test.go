@@ -0,0 +1,350 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package packetauth
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cubefs/cubefs/proto"
+	"github.com/cubefs/cubefs/util/cryptoutil"
+)
+
+const (
+	testClientID   = "client1"
+	testAuthVolume = "authvol"
+	testOpenVolume = "openvol"
+)
+
+func init() {
+	proto.InitBufferPool(int64(32768))
+}
+
+type testAddrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (c *testAddrConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func newTestConn(t *testing.T, remote string) net.Conn {
+	addr, err := net.ResolveTCPAddr("tcp", remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, c2 := net.Pipe()
+	t.Cleanup(func() {
+		c1.Close()
+		c2.Close()
+	})
+	return &testAddrConn{Conn: c1, remote: addr}
+}
+
+func newTestAuthenticator(t *testing.T, serviceID string) *Authenticator {
+	a, err := NewAuthenticator(serviceID, [][]byte{testKey("service")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.volumeAuthenticate = func(volName string) (bool, error) {
+		return volName == testAuthVolume, nil
+	}
+	return a
+}
+
+func testKey(id string) []byte {
+	return cryptoutil.GenSecretKey([]byte("packetauth-test"), 0, id)
+}
+
+func newTestTicket(t *testing.T, serviceID string, exp int64, caps string) (string, []byte) {
+	ticket := cryptoutil.Ticket{
+		ServiceID:  serviceID,
+		SessionKey: cryptoutil.CryptoKey{Ctime: time.Now().Unix(), Key: testKey("session")},
+		Exp:        exp,
+		Caps:       []byte(caps),
+	}
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := cryptoutil.EncodeMessage(data, testKey("service"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message, ticket.SessionKey.Key
+}
+
+func newTestCredential(t *testing.T, serviceID, caps string) *Credential {
+	ticket, sessionKey := newTestTicket(t, serviceID, time.Now().Unix()+cryptoutil.TicketAge, caps)
+	c := &Credential{clientID: testClientID, tickets: make(map[string]*credentialTicket)}
+	c.tickets[serviceID] = &credentialTicket{
+		ticket:     ticket,
+		sessionKey: sessionKey,
+		renewTime:  time.Now().Add(time.Hour),
+	}
+	return c
+}
+
+// handshake runs the handshake of the credential against a node served by the authenticator.
+func handshake(t *testing.T, a *Authenticator, c *Credential, serviceID string) (net.Conn, error) {
+	clientConn, nodeConn := net.Pipe()
+	t.Cleanup(func() {
+		clientConn.Close()
+		nodeConn.Close()
+	})
+	conn := a.NewConn(nodeConn)
+	go func() {
+		p := proto.NewPacket()
+		if err := p.ReadFromConn(conn, proto.ReadDeadlineTime); err != nil {
+			return
+		}
+		if p.Opcode != proto.OpAuthenticate {
+			p.PacketErrorWithBody(proto.OpErr, []byte("unexpected opcode"))
+		} else {
+			_ = a.Authenticate(p, conn)
+		}
+		_ = p.WriteToConn(conn)
+	}()
+	return conn, c.Handshake(serviceID)(clientConn)
+}
+
+func TestHandshakeAndAuthorize(t *testing.T) {
+	a := newTestAuthenticator(t, proto.DataServiceID)
+	c := newTestCredential(t, proto.DataServiceID, `{"NoneOwnerVOL":["datanode:authvol:*"]}`)
+	conn, err := handshake(t, a, c, proto.DataServiceID)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.Authorize(conn, testAuthVolume); err != nil {
+		t.Fatalf("authorize %v: %v", testAuthVolume, err)
+	}
+	if err = a.Authorize(conn, testOpenVolume); err != nil {
+		t.Fatalf("authorize %v: %v", testOpenVolume, err)
+	}
+}
+
+func TestAuthorizeDenied(t *testing.T) {
+	a := newTestAuthenticator(t, proto.DataServiceID)
+	c := newTestCredential(t, proto.DataServiceID, `{"NoneOwnerVOL":["datanode:othervol:*"]}`)
+	conn, err := handshake(t, a, c, proto.DataServiceID)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.Authorize(conn, testAuthVolume); err != proto.ErrNoPermission {
+		t.Fatalf("expect %v, got %v", proto.ErrNoPermission, err)
+	}
+	// metanode caps do not grant access to the datanodes
+	c = newTestCredential(t, proto.DataServiceID, `{"NoneOwnerVOL":["metanode:authvol:*"]}`)
+	if conn, err = handshake(t, a, c, proto.DataServiceID); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.Authorize(conn, testAuthVolume); err != proto.ErrNoPermission {
+		t.Fatalf("expect %v, got %v", proto.ErrNoPermission, err)
+	}
+}
+
+func TestHandshakeRotatedKey(t *testing.T) {
+	// the node is configured with the new key followed by the previous one
+	a, err := NewAuthenticator(proto.DataServiceID, [][]byte{testKey("rotated"), testKey("service")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.volumeAuthenticate = func(volName string) (bool, error) {
+		return true, nil
+	}
+	c := newTestCredential(t, proto.DataServiceID, `{"NoneOwnerVOL":["datanode:authvol:*"]}`)
+	conn, err := handshake(t, a, c, proto.DataServiceID)
+	if err != nil {
+		t.Fatalf("handshake with a ticket sealed by the previous key: %v", err)
+	}
+	if err = a.Authorize(conn, testAuthVolume); err != nil {
+		t.Fatalf("authorize %v: %v", testAuthVolume, err)
+	}
+
+	a.keys = [][]byte{testKey("rotated")}
+	c = newTestCredential(t, proto.DataServiceID, `{"NoneOwnerVOL":["datanode:authvol:*"]}`)
+	if _, err = handshake(t, a, c, proto.DataServiceID); err == nil {
+		t.Fatalf("handshake with a ticket sealed by a removed key should fail")
+	}
+}
+
+func TestHandshakeWrongService(t *testing.T) {
+	a := newTestAuthenticator(t, proto.DataServiceID)
+	c := newTestCredential(t, proto.MetaServiceID, `{"NoneOwnerVOL":["*:authvol:*"]}`)
+	if _, err := handshake(t, a, c, proto.MetaServiceID); err == nil {
+		t.Fatalf("handshake with a ticket of %v should fail", proto.MetaServiceID)
+	}
+	if _, ok := c.tickets[proto.MetaServiceID]; ok {
+		t.Fatalf("rejected ticket is not invalidated")
+	}
+}
+
+func TestHandshakeNotEnforced(t *testing.T) {
+	var a *Authenticator
+	c := newTestCredential(t, proto.MetaServiceID, `{"NoneOwnerVOL":["*:authvol:*"]}`)
+	conn, err := handshake(t, a, c, proto.MetaServiceID)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.Authorize(conn, testAuthVolume); err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+}
+
+func TestAuthorizeNotAuthenticated(t *testing.T) {
+	a := newTestAuthenticator(t, proto.MetaServiceID)
+	conn := a.NewConn(newTestConn(t, "192.168.0.10:17210"))
+	if err := a.Authorize(conn, testAuthVolume); err != ErrNotAuthenticated {
+		t.Fatalf("expect %v, got %v", ErrNotAuthenticated, err)
+	}
+	if err := a.Authorize(conn, testOpenVolume); err != nil {
+		t.Fatalf("authorize %v: %v", testOpenVolume, err)
+	}
+}
+
+func TestPeerServiceTicket(t *testing.T) {
+	a := newTestAuthenticator(t, proto.DataServiceID)
+	peer := NewServiceCredential("datanode", proto.DataServiceID, testKey("service"))
+	conn, err := handshake(t, a, peer, proto.DataServiceID)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	// the requests of the peers are trusted for all the volumes
+	if err = a.Authorize(conn, testAuthVolume); err != nil {
+		t.Fatalf("authorize peer: %v", err)
+	}
+
+	// a peer of another service can not issue the tickets of the datanodes
+	peer = NewServiceCredential("metanode", proto.MetaServiceID, testKey("meta"))
+	if _, err = handshake(t, a, peer, proto.DataServiceID); err == nil {
+		t.Fatalf("handshake with a credential of %v should fail", proto.MetaServiceID)
+	}
+	// nor can a key other than the service key
+	peer = NewServiceCredential("metanode", proto.DataServiceID, testKey("meta"))
+	if _, err = handshake(t, a, peer, proto.DataServiceID); err == nil {
+		t.Fatalf("handshake with a service ticket sealed by a wrong key should fail")
+	}
+	// the ticket of a client is not taken as a peer one
+	c := newTestCredential(t, proto.DataServiceID, `{"NoneOwnerVOL":["datanode:othervol:*"]}`)
+	if conn, err = handshake(t, a, c, proto.DataServiceID); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.Authorize(conn, testAuthVolume); err != proto.ErrNoPermission {
+		t.Fatalf("expect %v, got %v", proto.ErrNoPermission, err)
+	}
+}
+
+func TestAuthorizeExpired(t *testing.T) {
+	a := newTestAuthenticator(t, proto.MetaServiceID)
+	conn := a.NewConn(newTestConn(t, "192.168.0.10:17210"))
+	conn.(*Conn).session.Store(&session{
+		clientID: testClientID,
+		ticket:   cryptoutil.Ticket{ServiceID: proto.MetaServiceID, Exp: time.Now().Unix() - 1},
+	})
+	if err := a.Authorize(conn, testAuthVolume); err != proto.ErrExpiredTicket {
+		t.Fatalf("expect %v, got %v", proto.ErrExpiredTicket, err)
+	}
+	if _, err := conn.Write([]byte{0}); err == nil {
+		t.Fatalf("connection with an expired session is not closed")
+	}
+}
+
+func TestMasterTicket(t *testing.T) {
+	a := newTestAuthenticator(t, proto.MetaServiceID)
+	master := NewMasterCredential(proto.MetaServiceID, testKey("service"))
+	conn, err := handshake(t, a, master, proto.MetaServiceID)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.AuthorizeMaster(conn); err != nil {
+		t.Fatalf("authorize master: %v", err)
+	}
+	if err = a.Authorize(conn, testAuthVolume); err != nil {
+		t.Fatalf("authorize master for %v: %v", testAuthVolume, err)
+	}
+
+	// the admin commands forwarded by the peers to the leader are accepted
+	peer := NewServiceCredential("metanode", proto.MetaServiceID, testKey("service"))
+	if conn, err = handshake(t, a, peer, proto.MetaServiceID); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.AuthorizeMaster(conn); err != nil {
+		t.Fatalf("authorize a peer: %v", err)
+	}
+	// but not on the connections of the clients
+	c := newTestCredential(t, proto.MetaServiceID, `{"NoneOwnerVOL":["metanode:authvol:*"]}`)
+	if conn, err = handshake(t, a, c, proto.MetaServiceID); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+	if err = a.AuthorizeMaster(conn); err != ErrNotMaster {
+		t.Fatalf("expect %v for a client, got %v", ErrNotMaster, err)
+	}
+	if err = a.AuthorizeMaster(a.NewConn(newTestConn(t, "192.168.0.10:17210"))); err != ErrNotAuthenticated {
+		t.Fatalf("expect %v, got %v", ErrNotAuthenticated, err)
+	}
+
+	// nor can a master ticket be sealed by a key other than the service key
+	master = NewMasterCredential(proto.MetaServiceID, testKey("other"))
+	if _, err = handshake(t, a, master, proto.MetaServiceID); err == nil {
+		t.Fatalf("handshake with a master ticket sealed by a wrong key should fail")
+	}
+
+	// the admin commands are not checked by a node not enforcing authentication
+	var none *Authenticator
+	if err = none.AuthorizeMaster(newTestConn(t, "192.168.0.10:17210")); err != nil {
+		t.Fatalf("authorize master without authenticator: %v", err)
+	}
+}
+
+func TestAuthenticateReplay(t *testing.T) {
+	a := newTestAuthenticator(t, proto.DataServiceID)
+	ticket, sessionKey := newTestTicket(t, proto.DataServiceID, time.Now().Unix()+cryptoutil.TicketAge, `{"NoneOwnerVOL":["datanode:authvol:*"]}`)
+	req := proto.APIAccessReq{
+		Type:      proto.MsgDataTicketReq,
+		ClientID:  testClientID,
+		ServiceID: proto.DataServiceID,
+		Ticket:    ticket,
+	}
+	var err error
+	if req.Verifier, _, err = cryptoutil.GenVerifier(sessionKey); err != nil {
+		t.Fatal(err)
+	}
+	authenticate := func() error {
+		p := proto.NewPacketReqID()
+		p.Opcode = proto.OpAuthenticate
+		if err := p.MarshalData(req); err != nil {
+			t.Fatal(err)
+		}
+		return a.Authenticate(p, a.NewConn(newTestConn(t, "192.168.0.10:17310")))
+	}
+	if err = authenticate(); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	// the captured request is rejected on another connection within the live time of the verifier
+	if err = authenticate(); err != ErrReplayedAuthenticate {
+		t.Fatalf("expect %v, got %v", ErrReplayedAuthenticate, err)
+	}
+
+	// a fresh verifier is accepted
+	if req.Verifier, _, err = cryptoutil.GenVerifier(sessionKey); err != nil {
+		t.Fatal(err)
+	}
+	if err = authenticate(); err != nil {
+		t.Fatalf("authenticate with a fresh verifier: %v", err)
+	}
+}
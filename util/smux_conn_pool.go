@@ -178,6 +178,7 @@ type SmuxConnectPool struct {
 	streamBucket *simpleTokenBucket
 	cfg          *SmuxConnPoolConfig
 	pools        map[string]*SmuxPool
+	handshake    Handshake
 	closeCh      chan struct{}
 	closeOnce    sync.Once
 }
@@ -198,6 +199,13 @@ func NewSmuxConnectPool(cfg *SmuxConnPoolConfig) (cp *SmuxConnectPool) {
 	return cp
 }
 
+// NewSmuxConnectPoolWithHandshake returns a pool whose new streams are set up by the given handshake.
+func NewSmuxConnectPoolWithHandshake(cfg *SmuxConnPoolConfig, handshake Handshake) (cp *SmuxConnectPool) {
+	cp = NewSmuxConnectPool(cfg)
+	cp.handshake = handshake
+	return cp
+}
+
 func (cp *SmuxConnectPool) GetConnect(targetAddr string) (c *smux.Stream, err error) {
 	cp.RLock()
 	pool, ok := cp.pools[targetAddr]
@@ -206,7 +214,7 @@ func (cp *SmuxConnectPool) GetConnect(targetAddr string) (c *smux.Stream, err er
 		cp.Lock()
 		pool, ok = cp.pools[targetAddr]
 		if !ok {
-			pool = NewSmuxPool(cp.cfg, targetAddr, cp.streamBucket)
+			pool = newSmuxPoolWithHandshake(cp.cfg, targetAddr, cp.streamBucket, cp.handshake)
 			cp.pools[targetAddr] = pool
 		}
 		cp.Unlock()
@@ -322,6 +330,7 @@ type SmuxPool struct {
 	inflightStreams int64
 	createSessCall  *createSessCall
 	streamBucket    *simpleTokenBucket
+	handshake       Handshake
 }
 
 type SmuxPoolStat struct {
@@ -333,6 +342,10 @@ type SmuxPoolStat struct {
 }
 
 func NewSmuxPool(cfg *SmuxConnPoolConfig, target string, streamBucket *simpleTokenBucket) (p *SmuxPool) {
+	return newSmuxPoolWithHandshake(cfg, target, streamBucket, nil)
+}
+
+func newSmuxPoolWithHandshake(cfg *SmuxConnPoolConfig, target string, streamBucket *simpleTokenBucket, handshake Handshake) (p *SmuxPool) {
 	if cfg == nil {
 		cfg = gConfig
 	}
@@ -342,6 +355,7 @@ func NewSmuxPool(cfg *SmuxConnPoolConfig, target string, streamBucket *simpleTok
 		cfg:          cfg,
 		streamBucket: streamBucket,
 		objects:      make(chan *streamObject, cfg.PoolCapacity),
+		handshake:    handshake,
 	}
 	p.initSessions()
 	p.initStreams()
@@ -352,7 +366,7 @@ func (p *SmuxPool) initSessions() {
 	p.sessionsLock.Lock()
 	defer p.sessionsLock.Unlock()
 	for i := 0; i < connPreAlloc; i++ {
-		conn, err := net.DialTimeout("tcp", p.target, p.cfg.DialTimeout)
+		conn, err := DailTimeOut(p.target, p.cfg.DialTimeout)
 		if err != nil {
 			continue
 		}
@@ -547,6 +561,17 @@ getFromPool:
 }
 
 func (p *SmuxPool) NewStream() (stream *smux.Stream, err error) {
+	if stream, err = p.newSessionStream(); err != nil || p.handshake == nil {
+		return
+	}
+	if err = p.handshake(stream); err != nil {
+		p.MarkClosed(stream)
+		return nil, err
+	}
+	return
+}
+
+func (p *SmuxPool) newSessionStream() (stream *smux.Stream, err error) {
 	sess := p.getAvailSess()
 	if sess != nil {
 		stream, err = p.openStream(sess)
@@ -592,16 +617,13 @@ func (p *SmuxPool) casCreateCall(prev *createSessCall, new *createSessCall) bool
 func (p *SmuxPool) handleCreateCall(call *createSessCall) {
 	var conn net.Conn
 	defer close(call.notify)
-	conn, call.err = net.DialTimeout("tcp", p.target, p.cfg.DialTimeout)
+	conn, call.err = DailTimeOut(p.target, p.cfg.DialTimeout)
 	if call.err != nil {
 		return
 	}
-	c := conn.(*net.TCPConn)
-	c.SetKeepAlive(true)
-	c.SetNoDelay(true)
 	call.sess, call.err = smux.Client(conn, p.cfg.Config)
 	if call.err != nil {
-		c.Close()
+		conn.Close()
 		return
 	}
 	p.insertSession(call.sess)
@@ -0,0 +1,194 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// the status codes and span kind of OpenTelemetry
+	otlpStatusOk     = 1
+	otlpStatusError  = 2
+	otlpKindInternal = 1
+
+	otlpScopeName   = "github.com/cubefs/cubefs/util/trace"
+	otlpHTTPTimeout = 5 * time.Second
+)
+
+// The spans are encoded as the ExportTraceServiceRequest of OTLP in JSON, which can be
+// received by the OpenTelemetry collector with the otlp receiver over HTTP, or read from
+// the file with the otlpjsonfile receiver.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func newOTLPRequest(service string, spans []*Span) *otlpRequest {
+	hostname, _ := os.Hostname()
+	scopeSpans := otlpScopeSpans{Scope: otlpScope{Name: otlpScopeName}, Spans: make([]otlpSpan, 0, len(spans))}
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.ctx.TraceID.String(),
+			SpanID:            s.ctx.SpanID.String(),
+			Name:              s.name,
+			Kind:              otlpKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.startTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.endTime.UnixNano(), 10),
+			Status:            otlpStatus{Code: otlpStatusOk},
+		}
+		if s.parentID != (SpanID{}) {
+			span.ParentSpanID = s.parentID.String()
+		}
+		if s.err != nil {
+			span.Status = otlpStatus{Code: otlpStatusError, Message: s.err.Error()}
+		}
+		keys := make([]string, 0, len(s.tags))
+		for k := range s.tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			span.Attributes = append(span.Attributes, otlpKeyValue{Key: k, Value: otlpAnyValue{StringValue: s.tags[k]}})
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, span)
+	}
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: otlpAnyValue{StringValue: service}},
+			{Key: "host.name", Value: otlpAnyValue{StringValue: hostname}},
+		}},
+		ScopeSpans: []otlpScopeSpans{scopeSpans},
+	}}}
+}
+
+// fileExporter appends a line of OTLP JSON to the file for each batch of spans.
+type fileExporter struct {
+	service string
+	file    *os.File
+}
+
+func newFileExporter(path, role string) (e *fileExporter, err error) {
+	if path == "" {
+		return nil, fmt.Errorf("%s is not configured", ConfigKeyTraceFile)
+	}
+	e = &fileExporter{service: AppName + "_" + role}
+	if e.file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (e *fileExporter) export(spans []*Span) (err error) {
+	var data []byte
+	if data, err = json.Marshal(newOTLPRequest(e.service, spans)); err != nil {
+		return
+	}
+	_, err = e.file.Write(append(data, '\n'))
+	return
+}
+
+func (e *fileExporter) close() error {
+	return e.file.Close()
+}
+
+// otlpExporter posts the spans to the OTLP/HTTP endpoint of a collector in JSON.
+type otlpExporter struct {
+	service  string
+	endpoint string
+	client   *http.Client
+}
+
+func newOTLPExporter(endpoint, role string) *otlpExporter {
+	return &otlpExporter{
+		service:  AppName + "_" + role,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: otlpHTTPTimeout},
+	}
+}
+
+func (e *otlpExporter) export(spans []*Span) (err error) {
+	var (
+		data []byte
+		resp *http.Response
+	)
+	if data, err = json.Marshal(newOTLPRequest(e.service, spans)); err != nil {
+		return
+	}
+	if resp, err = e.client.Post(e.endpoint, "application/json", bytes.NewReader(data)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("collector %v responds status(%v) body(%s)", e.endpoint, resp.StatusCode, body)
+	}
+	return
+}
+
+func (e *otlpExporter) close() error {
+	return nil
+}
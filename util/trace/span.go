@@ -0,0 +1,140 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type spanContextKey struct{}
+
+// Span records an operation of a trace. A nil span is valid and records nothing, so that
+// the callers need not check whether the trace is sampled.
+// A span is not safe for concurrent use.
+type Span struct {
+	name      string
+	ctx       SpanContext
+	parentID  SpanID
+	startTime time.Time
+	endTime   time.Time
+	tags      map[string]string
+	err       error
+}
+
+// StartSpan starts a span as the child of parent. If parent is not valid, a new trace is
+// started according to the sample rate. It returns nil if the trace is not sampled or the
+// tracer is not initialized.
+func StartSpan(name string, parent SpanContext) *Span {
+	t := globalTracer
+	if t == nil {
+		return nil
+	}
+	s := &Span{name: name, startTime: time.Now()}
+	if parent.IsValid() {
+		if !parent.IsSampled() {
+			return nil
+		}
+		s.ctx.TraceID = parent.TraceID
+		s.parentID = parent.SpanID
+	} else {
+		if !t.sample() {
+			return nil
+		}
+		s.ctx.TraceID = randomTraceID()
+	}
+	s.ctx.SpanID = randomSpanID()
+	s.ctx.Flags = FlagSampled
+	return s
+}
+
+// StartChildSpan starts a span as the child of parent, unlike StartSpan it never starts a
+// new trace and returns nil if parent is not valid.
+func StartChildSpan(name string, parent SpanContext) *Span {
+	if !parent.IsValid() {
+		return nil
+	}
+	return StartSpan(name, parent)
+}
+
+// Context returns the span context to propagate, which is not valid for a nil span.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.ctx
+}
+
+// TraceID returns the trace ID of the span, or empty for a nil span.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.ctx.TraceID.String()
+}
+
+// SetTag sets an attribute of the span.
+func (s *Span) SetTag(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	if s.tags == nil {
+		s.tags = make(map[string]string)
+	}
+	s.tags[key] = fmt.Sprint(value)
+}
+
+// Finish ends the span and exports it, the span is marked as failed if err is not nil.
+// It should be invoked by defer func{span.Finish(err)}.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.endTime = time.Now()
+	s.err = err
+	if t := globalTracer; t != nil {
+		t.put(s)
+	}
+}
+
+// ContextWithSpan returns a new context holding the span.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span held by ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the context of the span held by ctx.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	return SpanFromContext(ctx).Context()
+}
+
+// StartSpanFromContext starts a span as the child of the span held by ctx, and returns
+// the context holding the new span.
+func StartSpanFromContext(ctx context.Context, name string) (*Span, context.Context) {
+	span := StartSpan(name, SpanContextFromContext(ctx))
+	if span == nil {
+		return nil, ctx
+	}
+	return span, ContextWithSpan(ctx, span)
+}
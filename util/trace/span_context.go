@@ -0,0 +1,133 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trace
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FlagSampled marks the trace as sampled, the spans of it are recorded by every node.
+	FlagSampled byte = 0x01
+
+	// SpanContextSize is the size of the marshaled span context carried in the packet header.
+	SpanContextSize = 25
+
+	traceparentVersion = "00"
+)
+
+var (
+	seededIDGen = rand.New(rand.NewSource(time.Now().UnixNano()))
+	// The golang rand generators are *not* intrinsically thread-safe.
+	seededIDLock sync.Mutex
+)
+
+// TraceID identifies a trace, compatible with the W3C trace context and OpenTelemetry.
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID identifies a span within its trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func randomTraceID() (id TraceID) {
+	seededIDLock.Lock()
+	defer seededIDLock.Unlock()
+	binary.BigEndian.PutUint64(id[:8], seededIDGen.Uint64())
+	binary.BigEndian.PutUint64(id[8:], seededIDGen.Uint64())
+	return
+}
+
+func randomSpanID() (id SpanID) {
+	seededIDLock.Lock()
+	defer seededIDLock.Unlock()
+	binary.BigEndian.PutUint64(id[:], seededIDGen.Uint64())
+	return
+}
+
+// SpanContext is the part of a span propagated to the other nodes.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+}
+
+// IsValid returns true if the span context refers to a span.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// IsSampled returns true if the trace is sampled.
+func (sc SpanContext) IsSampled() bool {
+	return sc.Flags&FlagSampled != 0
+}
+
+// Traceparent returns the span context in the format of the W3C traceparent header.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("%s-%s-%s-%02x", traceparentVersion, sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses the W3C traceparent header.
+func ParseTraceparent(s string) (sc SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, fmt.Errorf("invalid traceparent [%s]", s)
+	}
+	var flags []byte
+	if _, err = hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return
+	}
+	if _, err = hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return
+	}
+	if flags, err = hex.DecodeString(parts[3]); err != nil {
+		return
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent [%s]", s)
+	}
+	return
+}
+
+// Marshal writes the span context into out, which is at least SpanContextSize.
+func (sc SpanContext) Marshal(out []byte) {
+	copy(out[0:16], sc.TraceID[:])
+	copy(out[16:24], sc.SpanID[:])
+	out[24] = sc.Flags
+}
+
+// Unmarshal reads the span context from in.
+func (sc *SpanContext) Unmarshal(in []byte) error {
+	if len(in) < SpanContextSize {
+		return fmt.Errorf("span context [len=%d] too short", len(in))
+	}
+	copy(sc.TraceID[:], in[0:16])
+	copy(sc.SpanID[:], in[16:24])
+	sc.Flags = in[24]
+	return nil
+}
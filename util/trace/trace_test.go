@@ -0,0 +1,145 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cubefs/cubefs/util/config"
+)
+
+func TestTraceparent(t *testing.T) {
+	const header = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.IsValid() || !sc.IsSampled() {
+		t.Fatalf("span context %+v should be valid and sampled", sc)
+	}
+	if sc.Traceparent() != header {
+		t.Fatalf("traceparent %v, expect %v", sc.Traceparent(), header)
+	}
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		if _, err = ParseTraceparent(s); err == nil {
+			t.Fatalf("traceparent [%v] should be invalid", s)
+		}
+	}
+}
+
+func TestSpanContextMarshal(t *testing.T) {
+	sc := SpanContext{TraceID: randomTraceID(), SpanID: randomSpanID(), Flags: FlagSampled}
+	buf := make([]byte, SpanContextSize)
+	sc.Marshal(buf)
+
+	var got SpanContext
+	if err := got.Unmarshal(buf); err != nil {
+		t.Fatal(err)
+	}
+	if got != sc {
+		t.Fatalf("unmarshal %+v, expect %+v", got, sc)
+	}
+	if err := got.Unmarshal(buf[:SpanContextSize-1]); err == nil {
+		t.Fatal("unmarshal a short buffer should fail")
+	}
+}
+
+func TestSpanExport(t *testing.T) {
+	if span := StartSpan("disabled", SpanContext{}); span != nil {
+		t.Fatal("no span should be started before the tracer is initialized")
+	}
+
+	path := filepath.Join(t.TempDir(), "trace.json")
+	cfg := config.LoadConfigString(fmt.Sprintf(`{"%s": "%s", "%s": "%s", "%s": 1}`,
+		ConfigKeyTraceExporter, FileExporterName, ConfigKeyTraceFile, path, ConfigKeyTraceSampleRate))
+	if err := Init("test", cfg); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		globalTracer = nil
+	}()
+
+	if span := StartChildSpan("orphan", SpanContext{}); span != nil {
+		t.Fatal("child span should not be started without parent")
+	}
+	unsampled := SpanContext{TraceID: randomTraceID(), SpanID: randomSpanID()}
+	if span := StartSpan("unsampled", unsampled); span != nil {
+		t.Fatal("span should not be started for unsampled parent")
+	}
+
+	root, ctx := StartSpanFromContext(context.Background(), "root")
+	if root == nil {
+		t.Fatal("root span should be started with sample rate 1")
+	}
+	root.SetTag("ino", 1)
+	child, _ := StartSpanFromContext(ctx, "child")
+	if child.TraceID() != root.TraceID() {
+		t.Fatalf("child trace %v, expect %v", child.TraceID(), root.TraceID())
+	}
+	child.Finish(errors.New("child failed"))
+	root.Finish(nil)
+	Stop()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	spans := make(map[string]otlpSpan)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		req := new(otlpRequest)
+		if err = json.Unmarshal(scanner.Bytes(), req); err != nil {
+			t.Fatal(err)
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[s.Name] = s
+				}
+			}
+		}
+	}
+	if len(spans) != 2 {
+		t.Fatalf("exported spans %v, expect root and child", spans)
+	}
+	if spans["root"].ParentSpanID != "" || spans["root"].Status.Code != otlpStatusOk {
+		t.Fatalf("unexpected root span %+v", spans["root"])
+	}
+	if len(spans["root"].Attributes) != 1 || spans["root"].Attributes[0].Value.StringValue != "1" {
+		t.Fatalf("unexpected root attributes %+v", spans["root"].Attributes)
+	}
+	if spans["child"].ParentSpanID != root.Context().SpanID.String() || spans["child"].TraceID != root.TraceID() {
+		t.Fatalf("unexpected child span %+v", spans["child"])
+	}
+	if spans["child"].Status.Code != otlpStatusError || spans["child"].Status.Message != "child failed" {
+		t.Fatalf("unexpected child status %+v", spans["child"].Status)
+	}
+}
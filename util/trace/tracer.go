@@ -0,0 +1,183 @@
+// Copyright 2018 The CubeFS Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package trace
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cubefs/cubefs/util/config"
+	"github.com/cubefs/cubefs/util/log"
+)
+
+const (
+	ConfigKeyTraceExporter   = "traceExporter"   // "file" or "otlp", the tracing is disabled if not set
+	ConfigKeyTraceFile       = "traceFile"       // the file the spans are appended to by the file exporter
+	ConfigKeyTraceEndpoint   = "traceEndpoint"   // the OTLP/HTTP endpoint of the collector
+	ConfigKeyTraceSampleRate = "traceSampleRate" // the ratio of the traces started by this node, 0 to 1
+
+	FileExporterName = "file"
+	OTLPExporterName = "otlp"
+
+	DefaultTraceEndpoint = "http://127.0.0.1:4318/v1/traces"
+
+	AppName = "cfs"
+
+	spanQueueSize     = 8192
+	exportBatchSize   = 512
+	exportInterval    = time.Second
+	dropWarnThreshold = 1000
+)
+
+var globalTracer *tracer
+
+type spanExporter interface {
+	export(spans []*Span) error
+	close() error
+}
+
+type tracer struct {
+	service    string
+	sampleRate float64
+	exporter   spanExporter
+	spanC      chan *Span
+	dropped    uint64
+	stopC      chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+// Init initializes the tracer of the role with the config, the spans are exported to a local
+// collector or file in the OpenTelemetry format. It does nothing if no exporter is configured.
+func Init(role string, cfg *config.Config) (err error) {
+	var exporter spanExporter
+	switch name := cfg.GetString(ConfigKeyTraceExporter); name {
+	case "":
+		log.LogInfof("%v tracing disabled", role)
+		return
+	case FileExporterName:
+		if exporter, err = newFileExporter(cfg.GetString(ConfigKeyTraceFile), role); err != nil {
+			return
+		}
+	case OTLPExporterName:
+		endpoint := cfg.GetString(ConfigKeyTraceEndpoint)
+		if endpoint == "" {
+			endpoint = DefaultTraceEndpoint
+		}
+		exporter = newOTLPExporter(endpoint, role)
+	default:
+		return fmt.Errorf("unknown trace exporter [%s]", name)
+	}
+
+	sampleRate := cfg.GetFloat(ConfigKeyTraceSampleRate)
+	if sampleRate < 0 || sampleRate > 1 {
+		exporter.close()
+		return fmt.Errorf("invalid %s [%v], should be in [0, 1]", ConfigKeyTraceSampleRate, sampleRate)
+	}
+	t := &tracer{
+		service:    AppName + "_" + role,
+		sampleRate: sampleRate,
+		exporter:   exporter,
+		spanC:      make(chan *Span, spanQueueSize),
+		stopC:      make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.exportLoop()
+	globalTracer = t
+	log.LogInfof("%v tracing enabled: exporter(%v) sampleRate(%v)", role, cfg.GetString(ConfigKeyTraceExporter), sampleRate)
+	return
+}
+
+// Stop flushes the spans and stops the tracer, the spans finished later are dropped.
+func Stop() {
+	t := globalTracer
+	if t == nil {
+		return
+	}
+	t.stopOnce.Do(func() {
+		close(t.stopC)
+		t.wg.Wait()
+		t.exporter.close()
+	})
+}
+
+// Enabled returns true if the tracer is initialized.
+func Enabled() bool {
+	return globalTracer != nil
+}
+
+func (t *tracer) sample() bool {
+	if t.sampleRate <= 0 {
+		return false
+	}
+	if t.sampleRate >= 1 {
+		return true
+	}
+	seededIDLock.Lock()
+	defer seededIDLock.Unlock()
+	return seededIDGen.Float64() < t.sampleRate
+}
+
+// put queues the span to export, the span is dropped rather than blocking the caller if the
+// exporter falls behind.
+func (t *tracer) put(s *Span) {
+	select {
+	case t.spanC <- s:
+	default:
+		atomic.AddUint64(&t.dropped, 1)
+	}
+}
+
+func (t *tracer) exportLoop() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	batch := make([]*Span, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.export(batch); err != nil {
+			log.LogWarnf("export %v spans failed: %v", len(batch), err)
+		}
+		batch = batch[:0]
+		if dropped := atomic.SwapUint64(&t.dropped, 0); dropped >= dropWarnThreshold {
+			log.LogWarnf("%v spans dropped as the exporter falls behind", dropped)
+		}
+	}
+	for {
+		select {
+		case s := <-t.spanC:
+			batch = append(batch, s)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-t.stopC:
+			for {
+				select {
+				case s := <-t.spanC:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}